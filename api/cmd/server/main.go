@@ -1,23 +1,29 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/relayooor/api/internal/config"
+	"github.com/redis/go-redis/v9"
 	"github.com/relayooor/api/pkg/chainpulse"
+	"github.com/relayooor/api/pkg/channels"
+	"github.com/relayooor/api/pkg/denoms"
+	"github.com/relayooor/chains/chainmetrics"
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
 	"github.com/rs/cors"
+	"go.uber.org/zap"
 )
 
 type HealthResponse struct {
@@ -89,20 +95,39 @@ type ClearPacketResponse struct {
 	Message   string   `json:"message,omitempty"`
 }
 
-// ChannelInfo stores counterparty chain information for a channel
-type ChannelInfo struct {
-	CounterpartyChainID string
-	CounterpartyChannel string
-	QueryTime           time.Time
-}
-
-// Global channel cache with mutex for thread safety
 var (
-	channelCache      = make(map[string]ChannelInfo) // key: "chainID/channelID"
-	channelCacheMutex sync.RWMutex
-	chainRegistry     = config.DefaultChainRegistry()
+	// logger is handed to the chain packages shared with the middleware
+	logger        = newLogger()
+	chainRegistry = config.DefaultChainRegistry()
+	// channelResolver finds where channels lead from on-chain state
+	channelResolver = channels.NewResolver(chainRegistry)
+	// denomResolver traces IBC denoms and finds their display metadata
+	denomResolver = denoms.NewResolver(chainRegistry, loadAssetLists())
+	// endpointPools ranks each chain's endpoints by probed health
+	endpointPools = endpoints.NewPools(chainRegistry, config.OptionsFromEnv().NodesPath, logger)
 )
 
+func newLogger() *zap.Logger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	return logger
+}
+
+// loadAssetLists reads the asset lists of the chain registry the chains are
+// loaded from
+func loadAssetLists() *denoms.AssetLists {
+	dir := config.OptionsFromEnv().Dir
+	assets, err := denoms.LoadAssetLists(dir)
+	if err != nil {
+		log.Printf("Failed to load asset lists from %s: %v", dir, err)
+		return nil
+	}
+	log.Printf("Loaded asset lists for %d chains from %s", assets.Chains(), dir)
+	return assets
+}
+
 // loggingMiddleware logs all incoming requests
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -112,6 +137,34 @@ func loggingMiddleware(next http.Handler) http.Handler {
 }
 
 func main() {
+	// Reload the chain registry when its files change
+	if status := chainRegistry.Status(); status.Error != "" {
+		log.Printf("Failed to load chain registry: %s", status.Error)
+	} else {
+		log.Printf("Loaded chain registry from %s", strings.Join(status.Sources, ", "))
+		for _, warning := range status.Warnings {
+			log.Printf("Skipped chain registry entry: %s", warning)
+		}
+	}
+	config.NewWatcher(chainRegistry, config.OptionsFromEnv(), logger).Start(context.Background())
+
+	// Send chain queries to the best endpoint of each chain
+	endpointPools.Start(context.Background())
+	channelResolver.UseEndpoints(endpointPools)
+	denomResolver.UseEndpoints(endpointPools)
+
+	// Keep resolved channel counterparties in Redis across restarts and
+	// re-check them for channel state changes
+	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
+		opts, err := redis.ParseURL(redisURL)
+		if err != nil {
+			log.Printf("Invalid REDIS_URL, caching channel counterparties in memory only: %v", err)
+		} else {
+			channelResolver.UseRedis(redis.NewClient(opts))
+		}
+	}
+	channelResolver.Start(context.Background())
+
 	// Initialize Chainpulse client
 	chainpulseURL := os.Getenv("CHAINPULSE_URL")
 	if chainpulseURL == "" {
@@ -198,8 +251,8 @@ func main() {
 							srcChannel := fmt.Sprintf("%v", packet["src_channel"])
 							receiver := fmt.Sprintf("%v", packet["receiver"])
 							
-							// Get destination chain using cached lookup
-							destChain := getDestinationChain(sourceChain, srcChannel, receiver)
+							// Resolve the destination chain from the channel
+							destChain := channelResolver.CounterpartyChain(sourceChain, srcChannel)
 							
 							sp := StuckPacket{
 								ID:               fmt.Sprintf("%v-%v", packet["chain_id"], packet["sequence"]),
@@ -288,7 +341,7 @@ func main() {
 						ChannelID:        fmt.Sprintf("%v", p["channel"]),
 						Sequence:         int(getFloat64(p["sequence"])),
 						SourceChain:      fmt.Sprintf("%v", p["chain"]),
-						DestinationChain: getCounterpartyChain(fmt.Sprintf("%v", p["chain"])),
+						DestinationChain: channelResolver.CounterpartyChain(fmt.Sprintf("%v", p["chain"]), fmt.Sprintf("%v", p["channel"])),
 						Amount:           fmt.Sprintf("%v", p["amount"]),
 						Denom:            fmt.Sprintf("%v", p["denom"]),
 						Sender:           fmt.Sprintf("%v", p["sender"]),
@@ -446,7 +499,7 @@ func main() {
 		
 		// Build chains data
 		chains := []map[string]interface{}{}
-		for chainID, chainConfig := range registry.Chains {
+		for chainID, chainConfig := range registry.AllChains() {
 			chains = append(chains, map[string]interface{}{
 				"chain_id": chainID,
 				"name":     chainConfig.ChainName,
@@ -473,7 +526,7 @@ func main() {
 				
 				channels = append(channels, map[string]interface{}{
 					"src":             srcChain,
-					"dst":             inferDestinationChain(srcChain, ch.SrcChannel),
+					"dst":             channelResolver.CounterpartyChain(srcChain, ch.SrcChannel),
 					"src_channel":     ch.SrcChannel,
 					"dst_channel":     ch.DstChannel,
 					"status":          "active",
@@ -518,7 +571,7 @@ func main() {
 				packet := stuckPacketsResp.Packets[i]
 				recentActivity = append(recentActivity, map[string]interface{}{
 					"from_chain": packet.ChainID,
-					"to_chain":   inferDestinationChain(packet.ChainID, packet.SrcChannel),
+					"to_chain":   channelResolver.CounterpartyChain(packet.ChainID, packet.SrcChannel),
 					"channel":    packet.SrcChannel,
 					"status":     "pending",
 					"timestamp":  time.Now().Add(-time.Duration(packet.AgeSeconds) * time.Second),
@@ -785,7 +838,7 @@ func main() {
 				"channel_id":        p.SrcChannel,
 				"sequence":          p.Sequence,
 				"source_chain":      p.ChainID,
-				"destination_chain": inferDestinationChain(p.ChainID, p.SrcChannel),
+				"destination_chain": channelResolver.CounterpartyChain(p.ChainID, p.SrcChannel),
 				"sender":            p.Sender,
 				"receiver":          p.Receiver,
 				"amount":            p.Amount,
@@ -911,95 +964,9 @@ func main() {
 		})
 	}).Methods("GET")
 
-	// Chain registry endpoint - returns known chain information
+	// Chain registry endpoint - returns the chains loaded from the chain registry
 	api.HandleFunc("/chains/registry", func(w http.ResponseWriter, r *http.Request) {
-		// Enhanced chain registry with more chains and RPC endpoints
-		registry := map[string]interface{}{
-			"chains": []map[string]interface{}{
-				{
-					"chain_id": "cosmoshub-4",
-					"chain_name": "Cosmos Hub",
-					"pretty_name": "Cosmos Hub",
-					"network_type": "mainnet",
-					"prefix": "cosmos",
-					"rest_api": "https://cosmos-rest.publicnode.com",
-					"rpc": "https://rpc.cosmos.network:443",
-					"comet_version": "0.34",
-				},
-				{
-					"chain_id": "osmosis-1",
-					"chain_name": "Osmosis",
-					"pretty_name": "Osmosis",
-					"network_type": "mainnet",
-					"prefix": "osmo",
-					"rest_api": "https://lcd.osmosis.zone",
-					"rpc": "https://rpc.osmosis.zone:443",
-					"comet_version": "0.38",
-				},
-				{
-					"chain_id": "neutron-1",
-					"chain_name": "Neutron",
-					"pretty_name": "Neutron",
-					"network_type": "mainnet",
-					"prefix": "neutron",
-					"rest_api": "https://rest-kralum.neutron-1.neutron.org",
-					"rpc": "https://rpc-kralum.neutron-1.neutron.org:443",
-					"comet_version": "0.37",
-				},
-				{
-					"chain_id": "stride-1",
-					"chain_name": "Stride",
-					"pretty_name": "Stride",
-					"network_type": "mainnet",
-					"prefix": "stride",
-					"rest_api": "https://stride-api.polkachu.com",
-					"rpc": "https://stride-rpc.polkachu.com:443",
-					"comet_version": "0.37",
-				},
-				{
-					"chain_id": "noble-1",
-					"chain_name": "Noble",
-					"pretty_name": "Noble",
-					"network_type": "mainnet",
-					"prefix": "noble",
-					"rest_api": "https://noble-api.polkachu.com",
-					"rpc": "https://noble-rpc.polkachu.com:443",
-					"comet_version": "0.38",
-				},
-				{
-					"chain_id": "juno-1",
-					"chain_name": "Juno",
-					"pretty_name": "Juno",
-					"network_type": "mainnet",
-					"prefix": "juno",
-					"rest_api": "https://juno-api.polkachu.com",
-					"rpc": "https://juno-rpc.polkachu.com:443",
-					"comet_version": "0.34",
-				},
-				{
-					"chain_id": "axelar-dojo-1",
-					"chain_name": "Axelar",
-					"pretty_name": "Axelar",
-					"network_type": "mainnet",
-					"prefix": "axelar",
-					"rest_api": "https://axelar-api.polkachu.com",
-					"rpc": "https://axelar-rpc.polkachu.com:443",
-					"comet_version": "0.34",
-				},
-				{
-					"chain_id": "dydx-mainnet-1",
-					"chain_name": "dYdX",
-					"pretty_name": "dYdX",
-					"network_type": "mainnet",
-					"prefix": "dydx",
-					"rest_api": "https://dydx-rest.publicnode.com",
-					"rpc": "https://dydx-rpc.publicnode.com:443",
-					"comet_version": "0.38",
-				},
-			},
-		}
-		
-		json.NewEncoder(w).Encode(registry)
+		json.NewEncoder(w).Encode(chainRegistryListing())
 	}).Methods("GET")
 
 	// Comprehensive monitoring metrics endpoint
@@ -1027,8 +994,15 @@ func main() {
 		metricsBody := string(body)
 		log.Printf("Successfully fetched metrics from Chainpulse")
 		
-		// Parse metrics using the comprehensive parser
-		parsedData := parsePrometheusMetrics(metricsBody)
+		// Parse metrics using the shared Chainpulse parser
+		metrics, err := chainmetrics.ParseMetricsString(metricsBody)
+		if err != nil {
+			log.Printf("Failed to parse Chainpulse metrics: %v", err)
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid Chainpulse metrics"})
+			return
+		}
+		parsedData := chainpulseMetricsData(metrics)
 		
 		// Extract parsed data
 		chains := parsedData["chains"].([]map[string]interface{})
@@ -1040,83 +1014,16 @@ func main() {
 		totalTxs := 0
 		totalErrors := 0
 		
-		for _, chain := range chains {
-			if packets, ok := chain["packets_24h"].(int); ok {
-				totalPackets += packets
-			}
-			if txs, ok := chain["txs_total"].(int); ok {
-				totalTxs += txs
-			}
-			if errors, ok := chain["errors"].(int); ok {
-				totalErrors += errors
-			}
+		for _, chain := range metrics.Chains {
+			totalPackets += int(chain.Packets)
+			totalTxs += int(chain.Txs)
+			totalErrors += int(chain.Errors)
 		}
 		
 		log.Printf("Real totals - Chains: %d, Packets: %d, Txs: %d, Errors: %d", totalChains, totalPackets, totalTxs, totalErrors)
 		
-		// Extract relayer data from packet metrics
-		relayerMap := make(map[string]map[string]interface{})
-		
-		// Parse packet metrics to get relayer info
-		lines := strings.Split(metricsBody, "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "ibc_effected_packets{") || strings.HasPrefix(line, "ibc_uneffected_packets{") {
-				parts := strings.Split(line, " ")
-				if len(parts) >= 2 {
-					// Extract signer label
-					labelRe := regexp.MustCompile(`signer="([^"]+)"`)
-					matches := labelRe.FindStringSubmatch(parts[0])
-					if len(matches) > 1 {
-						signer := matches[1]
-						value, _ := strconv.ParseFloat(parts[1], 64)
-						
-						if relayerMap[signer] == nil {
-							relayerMap[signer] = map[string]interface{}{
-								"address": signer,
-								"effectedPackets": float64(0),
-								"uneffectedPackets": float64(0),
-								"totalPackets": float64(0),
-							}
-						}
-						
-						if strings.HasPrefix(line, "ibc_effected_packets{") {
-							relayerMap[signer]["effectedPackets"] = relayerMap[signer]["effectedPackets"].(float64) + value
-						} else {
-							relayerMap[signer]["uneffectedPackets"] = relayerMap[signer]["uneffectedPackets"].(float64) + value
-						}
-						relayerMap[signer]["totalPackets"] = relayerMap[signer]["effectedPackets"].(float64) + relayerMap[signer]["uneffectedPackets"].(float64)
-					}
-				}
-			}
-		}
-		
-		// Convert relayer map to array
-		relayers := []map[string]interface{}{}
-		for _, relayer := range relayerMap {
-			effected := relayer["effectedPackets"].(float64)
-			total := relayer["totalPackets"].(float64)
-			successRate := float64(0)
-			if total > 0 {
-				successRate = (effected / total) * 100
-			}
-			
-			relayers = append(relayers, map[string]interface{}{
-				"address": relayer["address"],
-				"totalPackets": int(total),
-				"effectedPackets": int(effected),
-				"uneffectedPackets": int(relayer["uneffectedPackets"].(float64)),
-				"successRate": successRate,
-				"frontrunCount": 0, // TODO: Calculate from frontrun metrics
-				"memo": "",
-				"software": "unknown",
-				"version": "unknown",
-			})
-		}
-		
-		// Sort relayers by total packets
-		sort.Slice(relayers, func(i, j int) bool {
-			return relayers[i]["totalPackets"].(int) > relayers[j]["totalPackets"].(int)
-		})
+		// Relayers come from the signer of each packet series, busiest first
+		relayers := parsedData["relayers"].([]map[string]interface{})
 		
 		// Create comprehensive metrics response matching MetricsSnapshot interface
 		now := time.Now()
@@ -1129,8 +1036,8 @@ func main() {
 				"chainName": chain["name"],
 				"totalTxs": chain["txs_total"],
 				"totalPackets": chain["packets_24h"],
-				"reconnects": 0, // Not available in metrics
-				"timeouts": 0,   // Not available in metrics
+				"reconnects": chain["reconnects"],
+				"timeouts": chain["timeouts"],
 				"errors": chain["errors"],
 				"status": chain["status"],
 				"lastUpdate": now,
@@ -1149,9 +1056,9 @@ func main() {
 			"chains": transformedChains,
 			"relayers": relayers,
 			"channels": transformChannelsForMetrics(channels),
-			"recentPackets": extractRecentPackets(lines, 10), // Extract last 10 packets
+			"recentPackets": extractRecentPackets(metrics, now, 10), // Extract last 10 packets
 			"stuckPackets": []map[string]interface{}{},
-			"frontrunEvents": []map[string]interface{}{}, // TODO: Extract from frontrun metrics
+			"frontrunEvents": extractFrontrunEvents(metrics, now),
 			"timestamp": now,
 		}
 		
@@ -1182,7 +1089,7 @@ func main() {
 		
 		// Build chains data
 		chains := []map[string]interface{}{}
-		for chainID, chainConfig := range registry.Chains {
+		for chainID, chainConfig := range registry.AllChains() {
 			chains = append(chains, map[string]interface{}{
 				"chainId": chainID,
 				"chainName": chainConfig.ChainName,
@@ -1239,7 +1146,7 @@ func main() {
 					for _, packet := range stuckPacketsResp.Packets {
 						if packet.SrcChannel == ch.SrcChannel {
 							srcChain = packet.ChainID
-							dstChain = inferDestinationChain(packet.ChainID, packet.SrcChannel)
+							dstChain = channelResolver.CounterpartyChain(packet.ChainID, packet.SrcChannel)
 							break
 						}
 					}
@@ -1309,27 +1216,6 @@ func main() {
 		
 		json.NewEncoder(w).Encode(metrics)
 	}).Methods("GET")
-
-	// Chain endpoints - provides REST API URLs for chains
-	api.HandleFunc("/chains/endpoints", func(w http.ResponseWriter, r *http.Request) {
-		endpoints := []map[string]string{
-			{"chain_id": "cosmoshub-4", "rest_url": "https://cosmos-rest.publicnode.com"},
-			{"chain_id": "osmosis-1", "rest_url": "https://osmosis-rest.publicnode.com"},
-			{"chain_id": "neutron-1", "rest_url": "https://neutron-rest.publicnode.com"},
-			{"chain_id": "noble-1", "rest_url": "https://noble-rest.publicnode.com"},
-			{"chain_id": "axelar-dojo-1", "rest_url": "https://axelar-rest.publicnode.com"},
-			{"chain_id": "stride-1", "rest_url": "https://stride-rest.publicnode.com"},
-			{"chain_id": "dydx-mainnet-1", "rest_url": "https://dydx-rest.publicnode.com"},
-			{"chain_id": "celestia", "rest_url": "https://celestia-rest.publicnode.com"},
-			{"chain_id": "injective-1", "rest_url": "https://injective-rest.publicnode.com"},
-			{"chain_id": "kava_2222-10", "rest_url": "https://kava-rest.publicnode.com"},
-			{"chain_id": "secret-4", "rest_url": "https://secret-rest.publicnode.com"},
-			{"chain_id": "stargaze-1", "rest_url": "https://stargaze-rest.publicnode.com"},
-		}
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"endpoints": endpoints,
-		})
-	}).Methods("GET")
 	
 	// Platform statistics endpoint
 	api.HandleFunc("/statistics/platform", func(w http.ResponseWriter, r *http.Request) {
@@ -1449,18 +1335,6 @@ func convertAddress(address, targetPrefix string) string {
 	return address
 }
 
-func getCounterpartyChain(chain string) string {
-	// This would normally come from configuration
-	switch chain {
-	case "cosmoshub-4":
-		return "osmosis-1"
-	case "osmosis-1":
-		return "cosmoshub-4"
-	default:
-		return "unknown"
-	}
-}
-
 func getFloat64(v interface{}) float64 {
 	switch val := v.(type) {
 	case float64:
@@ -1503,156 +1377,32 @@ func formatDuration(seconds int) string {
 	}
 }
 
+// parsePrometheusMetrics parses Chainpulse metrics into the chain, channel
+// and relayer maps the monitoring endpoints return. Unparseable metrics
+// give empty lists.
 func parsePrometheusMetrics(metricsText string) map[string]interface{} {
-	// Parse Prometheus metrics to extract chain and channel data
-	result := map[string]interface{}{
-		"chains":   []map[string]interface{}{},
-		"channels": []map[string]interface{}{},
-		"relayers": []map[string]interface{}{},
-	}
-	
-	// Track unique chains and their metrics
-	chainMetrics := make(map[string]map[string]float64)
-	channelMetrics := make(map[string]map[string]interface{})
-	
-	lines := strings.Split(metricsText, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "#") || line == "" {
-			continue
-		}
-		
-		// Parse chainpulse_packets metric
-		if strings.HasPrefix(line, "chainpulse_packets{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 {
-				// Extract chain_id from label
-				re := regexp.MustCompile(`chain_id="([^"]+)"`)
-				matches := re.FindStringSubmatch(parts[0])
-				if len(matches) > 1 {
-					chainID := matches[1]
-					value, _ := strconv.ParseFloat(parts[1], 64)
-					if chainMetrics[chainID] == nil {
-						chainMetrics[chainID] = make(map[string]float64)
-					}
-					chainMetrics[chainID]["packets"] = value
-				}
-			}
-		}
-		
-		// Parse chainpulse_txs metric
-		if strings.HasPrefix(line, "chainpulse_txs{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 {
-				re := regexp.MustCompile(`chain_id="([^"]+)"`)
-				matches := re.FindStringSubmatch(parts[0])
-				if len(matches) > 1 {
-					chainID := matches[1]
-					value, _ := strconv.ParseFloat(parts[1], 64)
-					if chainMetrics[chainID] == nil {
-						chainMetrics[chainID] = make(map[string]float64)
-					}
-					chainMetrics[chainID]["txs"] = value
-				}
-			}
-		}
-		
-		// Parse chainpulse_errors metric
-		if strings.HasPrefix(line, "chainpulse_errors{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 {
-				re := regexp.MustCompile(`chain_id="([^"]+)"`)
-				matches := re.FindStringSubmatch(parts[0])
-				if len(matches) > 1 {
-					chainID := matches[1]
-					value, _ := strconv.ParseFloat(parts[1], 64)
-					if chainMetrics[chainID] == nil {
-						chainMetrics[chainID] = make(map[string]float64)
-					}
-					chainMetrics[chainID]["errors"] = value
-				}
-			}
-		}
-		
-		// Parse ibc_effected_packets metric for channel data
-		if strings.HasPrefix(line, "ibc_effected_packets{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 {
-				// Extract all labels
-				labelRe := regexp.MustCompile(`(\w+)="([^"]+)"`)
-				labelMatches := labelRe.FindAllStringSubmatch(parts[0], -1)
-				
-				labels := make(map[string]string)
-				for _, match := range labelMatches {
-					if len(match) > 2 {
-						labels[match[1]] = match[2]
-					}
-				}
-				
-				if srcChannel, ok := labels["src_channel"]; ok {
-					if dstChannel, ok := labels["dst_channel"]; ok {
-						channelKey := fmt.Sprintf("%s-%s", srcChannel, dstChannel)
-						value, _ := strconv.ParseFloat(parts[1], 64)
-						
-						if channelMetrics[channelKey] == nil {
-							channelMetrics[channelKey] = make(map[string]interface{})
-							channelMetrics[channelKey]["src_channel"] = srcChannel
-							channelMetrics[channelKey]["dst_channel"] = dstChannel
-							channelMetrics[channelKey]["chain_id"] = labels["chain_id"]
-							channelMetrics[channelKey]["src_port"] = labels["src_port"]
-							channelMetrics[channelKey]["dst_port"] = labels["dst_port"]
-							channelMetrics[channelKey]["effected_packets"] = float64(0)
-							channelMetrics[channelKey]["uneffected_packets"] = float64(0)
-						}
-						channelMetrics[channelKey]["effected_packets"] = channelMetrics[channelKey]["effected_packets"].(float64) + value
-					}
-				}
-			}
-		}
-		
-		// Parse ibc_uneffected_packets metric
-		if strings.HasPrefix(line, "ibc_uneffected_packets{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 {
-				labelRe := regexp.MustCompile(`(\w+)="([^"]+)"`)
-				labelMatches := labelRe.FindAllStringSubmatch(parts[0], -1)
-				
-				labels := make(map[string]string)
-				for _, match := range labelMatches {
-					if len(match) > 2 {
-						labels[match[1]] = match[2]
-					}
-				}
-				
-				if srcChannel, ok := labels["src_channel"]; ok {
-					if dstChannel, ok := labels["dst_channel"]; ok {
-						channelKey := fmt.Sprintf("%s-%s", srcChannel, dstChannel)
-						value, _ := strconv.ParseFloat(parts[1], 64)
-						
-						if channelMetrics[channelKey] == nil {
-							channelMetrics[channelKey] = make(map[string]interface{})
-							channelMetrics[channelKey]["src_channel"] = srcChannel
-							channelMetrics[channelKey]["dst_channel"] = dstChannel
-							channelMetrics[channelKey]["chain_id"] = labels["chain_id"]
-							channelMetrics[channelKey]["src_port"] = labels["src_port"]
-							channelMetrics[channelKey]["dst_port"] = labels["dst_port"]
-							channelMetrics[channelKey]["effected_packets"] = float64(0)
-							channelMetrics[channelKey]["uneffected_packets"] = float64(0)
-						}
-						channelMetrics[channelKey]["uneffected_packets"] = channelMetrics[channelKey]["uneffected_packets"].(float64) + value
-					}
-				}
-			}
+	metrics, err := chainmetrics.ParseMetricsString(metricsText)
+	if err != nil {
+		log.Printf("Failed to parse Chainpulse metrics: %v", err)
+		return map[string]interface{}{
+			"chains":   []map[string]interface{}{},
+			"channels": []map[string]interface{}{},
+			"relayers": []map[string]interface{}{},
 		}
 	}
-	
-	// Convert chainMetrics to result format
+	return chainpulseMetricsData(metrics)
+}
+
+// chainpulseMetricsData converts parsed Chainpulse metrics to the maps the
+// monitoring endpoints return
+func chainpulseMetricsData(metrics *chainmetrics.Metrics) map[string]interface{} {
 	chains := []map[string]interface{}{}
 	registry := getChainRegistry()
 	chainsData := registry["chains"].(map[string]interface{})
 	
-	for chainID, metrics := range chainMetrics {
-		name := chainID
-		if chainData, exists := chainsData[chainID]; exists {
+	for _, metric := range metrics.Chains {
+		name := metric.ChainID
+		if chainData, exists := chainsData[metric.ChainID]; exists {
 			if chainMap, ok := chainData.(map[string]interface{}); ok {
 				if chainName, ok := chainMap["chain_name"].(string); ok {
 					name = chainName
@@ -1661,61 +1411,71 @@ func parsePrometheusMetrics(metricsText string) map[string]interface{} {
 		}
 		
 		chain := map[string]interface{}{
-			"chain_id":    chainID,
+			"chain_id":    metric.ChainID,
 			"name":        name,
 			"status":      "connected",
-			"packets_24h": int(metrics["packets"]),
-			"txs_total":   int(metrics["txs"]),
-			"errors":      int(metrics["errors"]),
+			"packets_24h": int(metric.Packets),
+			"txs_total":   int(metric.Txs),
+			"errors":      int(metric.Errors),
+			"reconnects":  int(metric.Reconnects),
+			"timeouts":    int(metric.Timeouts),
 		}
 		
 		// Mark as degraded if there are errors
-		if metrics["errors"] > 0 {
+		if metric.Errors > 0 {
 			chain["status"] = "degraded"
 		}
 		
 		chains = append(chains, chain)
 	}
 	
-	// Convert channelMetrics to result format
 	channels := []map[string]interface{}{}
-	for _, channel := range channelMetrics {
-		effected := channel["effected_packets"].(float64)
-		uneffected := channel["uneffected_packets"].(float64)
-		total := effected + uneffected
-		
-		successRate := float64(0)
-		if total > 0 {
-			successRate = (effected / total) * 100
-		}
-		
+	for _, channel := range metrics.Channels {
+		successRate := channel.SuccessRate()
 		channelData := map[string]interface{}{
-			"src":             channel["chain_id"],
-			"src_channel":     channel["src_channel"],
-			"dst_channel":     channel["dst_channel"],
-			"src_port":        channel["src_port"],
-			"dst_port":        channel["dst_port"],
+			"src":             channel.ChainID,
+			"src_channel":     channel.SrcChannel,
+			"dst_channel":     channel.DstChannel,
+			"src_port":        channel.SrcPort,
+			"dst_port":        channel.DstPort,
 			"status":          "active",
 			"packets_pending": 0, // This would need separate metric
 			"success_rate":    successRate,
-			"total_packets":   int(total),
-			"effected":        int(effected),
-			"uneffected":      int(uneffected),
+			"total_packets":   int(channel.Total()),
+			"effected":        int(channel.Effected),
+			"uneffected":      int(channel.Uneffected),
 		}
 		
 		// Determine status based on success rate
-		if successRate < 50 {
-			channelData["status"] = "degraded"
-		} else if total == 0 {
+		if channel.Total() == 0 {
 			channelData["status"] = "idle"
+		} else if successRate < 50 {
+			channelData["status"] = "degraded"
 		}
 		
 		channels = append(channels, channelData)
 	}
 	
-	result["chains"] = chains
-	result["channels"] = channels
-	return result
+	relayers := []map[string]interface{}{}
+	for _, relayer := range metrics.Relayers {
+		relayers = append(relayers, map[string]interface{}{
+			"address":           relayer.Signer,
+			"totalPackets":      int(relayer.Total()),
+			"effectedPackets":   int(relayer.Effected),
+			"uneffectedPackets": int(relayer.Uneffected),
+			"successRate":       relayer.SuccessRate(),
+			"frontrunCount":     int(relayer.Frontrun),
+			"memo":              relayer.Memo,
+			"software":          relayer.Software,
+			"version":           relayer.Version,
+		})
+	}
+	
+	return map[string]interface{}{
+		"chains":   chains,
+		"channels": channels,
+		"relayers": relayers,
+	}
 }
 
 // transformChannelsForMetrics transforms channel data from monitoring/data format to monitoring/metrics format
@@ -1731,8 +1491,8 @@ func transformChannelsForMetrics(channels []map[string]interface{}) []map[string
 			srcChain = chainId
 		}
 		
-		// Infer destination chain from channel numbers
-		dstChain := inferDestinationChain(srcChain, channel["src_channel"].(string))
+		// Resolve the destination chain from the channel
+		dstChain := channelResolver.CounterpartyChain(srcChain, channel["src_channel"].(string))
 		
 		transformed = append(transformed, map[string]interface{}{
 			"srcChain": srcChain,
@@ -1753,191 +1513,71 @@ func transformChannelsForMetrics(channels []map[string]interface{}) []map[string
 	return transformed
 }
 
-// inferDestinationChain attempts to determine the destination chain based on known channel mappings
-func inferDestinationChain(srcChain, srcChannel string) string {
-	// Look for matching channel in the registry
-	for _, channel := range chainRegistry.Channels {
-		if channel.SourceChain == srcChain && channel.SourceChannel == srcChannel {
-			return channel.DestChain
-		}
-	}
-	
-	// Default fallback
-	return "unknown"
-}
-
-// inferChainFromAddress attempts to determine the chain ID from a bech32 address prefix
-func inferChainFromAddress(address string) string {
-	// Extract prefix from address (everything before "1")
-	idx := strings.Index(address, "1")
-	if idx <= 0 {
-		return ""
-	}
-	prefix := address[:idx]
-	
-	// Look up chain by prefix in the registry
-	if chain, ok := chainRegistry.GetChainByPrefix(prefix); ok {
-		return chain.ChainID
-	}
-	
-	return ""
-}
-
-// queryChannelInfo queries the chain REST API to get counterparty chain information
-func queryChannelInfo(chainID, channelID string) (*ChannelInfo, error) {
-	chain, ok := chainRegistry.GetChainByID(chainID)
-	if !ok {
-		return nil, fmt.Errorf("chain %s not found in registry", chainID)
-	}
-	
-	if chain.RESTEndpoint == "" {
-		return nil, fmt.Errorf("no REST endpoint configured for chain %s", chainID)
-	}
-	
-	// Query the IBC channel client state to get counterparty chain ID
-	url := fmt.Sprintf("%s/ibc/core/channel/v1/channels/%s/ports/transfer/client_state", chain.RESTEndpoint, channelID)
-	
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query REST API: %v", err)
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("REST API returned status %d", resp.StatusCode)
-	}
-	
-	var result struct {
-		IdentifiedClientState struct {
-			ClientState struct {
-				ChainID string `json:"chain_id"`
-			} `json:"client_state"`
-		} `json:"identified_client_state"`
-	}
-	
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-	
-	counterpartyChainID := result.IdentifiedClientState.ClientState.ChainID
-	if counterpartyChainID == "" {
-		return nil, fmt.Errorf("no counterparty chain ID in response")
-	}
-	
-	return &ChannelInfo{
-		CounterpartyChainID: counterpartyChainID,
-		QueryTime:           time.Now(),
-	}, nil
-}
-
-// getDestinationChain gets the destination chain for a channel, using cache when possible
-func getDestinationChain(sourceChain, srcChannel, receiverAddr string) string {
-	cacheKey := fmt.Sprintf("%s/%s", sourceChain, srcChannel)
-	
-	// Check cache first
-	channelCacheMutex.RLock()
-	if info, ok := channelCache[cacheKey]; ok {
-		channelCacheMutex.RUnlock()
-		// Cache entries are valid for 1 hour
-		if time.Since(info.QueryTime) < time.Hour {
-			return info.CounterpartyChainID
-		}
-	} else {
-		channelCacheMutex.RUnlock()
-	}
-	
-	// Try static mappings first (faster)
-	if destChain := inferDestinationChain(sourceChain, srcChannel); destChain != "unknown" {
-		// Cache the result
-		channelCacheMutex.Lock()
-		channelCache[cacheKey] = ChannelInfo{
-			CounterpartyChainID: destChain,
-			QueryTime:           time.Now(),
-		}
-		channelCacheMutex.Unlock()
-		return destChain
-	}
-	
-	// Try to query the REST API
-	if info, err := queryChannelInfo(sourceChain, srcChannel); err == nil {
-		// Cache the result
-		channelCacheMutex.Lock()
-		channelCache[cacheKey] = *info
-		channelCacheMutex.Unlock()
-		log.Printf("Cached channel info: %s/%s -> %s", sourceChain, srcChannel, info.CounterpartyChainID)
-		return info.CounterpartyChainID
-	} else {
-		log.Printf("Failed to query channel info for %s/%s: %v", sourceChain, srcChannel, err)
-	}
-	
-	// Fallback: try to infer from receiver address
-	if receiverAddr != "" && receiverAddr != "<nil>" {
-		if destChain := inferChainFromAddress(receiverAddr); destChain != "" {
-			return destChain
-		}
-	}
-	
-	return "unknown"
-}
-
 // getChainMap returns a map of chain IDs to chain names
 func getChainMap() map[string]string {
 	chainMap := make(map[string]string)
-	for chainID, chain := range chainRegistry.Chains {
+	for chainID, chain := range chainRegistry.AllChains() {
 		chainMap[chainID] = chain.ChainName
 	}
 	return chainMap
 }
 
-// extractRecentPackets extracts recent packet information from metrics
-func extractRecentPackets(metricsLines []string, limit int) []map[string]interface{} {
+// extractRecentPackets lists the channel and relayer series with relayed
+// packets, busiest first. Counters carry no sequences or times, so entries
+// are stamped with the scrape time.
+func extractRecentPackets(metrics *chainmetrics.Metrics, scrapedAt time.Time, limit int) []map[string]interface{} {
 	packets := []map[string]interface{}{}
 	
-	// Extract packet info from effected/uneffected metrics
-	for _, line := range metricsLines {
-		if strings.HasPrefix(line, "ibc_effected_packets{") || strings.HasPrefix(line, "ibc_uneffected_packets{") {
-			parts := strings.Split(line, " ")
-			if len(parts) >= 2 && parts[1] != "0" {
-				// Extract labels
-				labelRe := regexp.MustCompile(`(\w+)="([^"]+)"`)
-				labelMatches := labelRe.FindAllStringSubmatch(parts[0], -1)
-				
-				labels := make(map[string]string)
-				for _, match := range labelMatches {
-					if len(match) > 2 {
-						labels[match[1]] = match[2]
-					}
-				}
-				
-				// Create packet entry
-				packet := map[string]interface{}{
-					"chain_id":    labels["chain_id"],
-					"src_channel": labels["src_channel"],
-					"dst_channel": labels["dst_channel"],
-					"src_port":    labels["src_port"],
-					"dst_port":    labels["dst_port"],
-					"signer":      labels["signer"],
-					"memo":        labels["memo"],
-					"effected":    strings.HasPrefix(line, "ibc_effected_packets{"),
-					"timestamp":   time.Now().Add(-time.Duration(len(packets)) * time.Minute), // Estimate timestamp
-				}
-				
-				// Estimate sequence number (would need real data in production)
-				packet["sequence"] = 100000 + len(packets)
-				
-				packets = append(packets, packet)
-				
-				if len(packets) >= limit {
-					break
-				}
+	var series []chainmetrics.Series
+	for _, name := range []string{chainmetrics.MetricEffected, chainmetrics.MetricUneffected} {
+		for _, s := range metrics.Series(name) {
+			if s.Value == 0 {
+				continue
 			}
+			s.Labels["__effected"] = strconv.FormatBool(name == chainmetrics.MetricEffected)
+			series = append(series, s)
 		}
 	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Value > series[j].Value })
+	
+	for _, s := range series {
+		if len(packets) >= limit {
+			break
+		}
+		packets = append(packets, map[string]interface{}{
+			"chain_id":    s.Labels["chain_id"],
+			"src_channel": s.Labels["src_channel"],
+			"dst_channel": s.Labels["dst_channel"],
+			"src_port":    s.Labels["src_port"],
+			"dst_port":    s.Labels["dst_port"],
+			"signer":      s.Labels["signer"],
+			"memo":        s.Labels["memo"],
+			"effected":    s.Labels["__effected"] == "true",
+			"count":       int(s.Value),
+			"timestamp":   scrapedAt,
+		})
+	}
 	
 	return packets
 }
 
+// extractFrontrunEvents lists how often each relayer was frontrun on each
+// channel, most often first
+func extractFrontrunEvents(metrics *chainmetrics.Metrics, scrapedAt time.Time) []map[string]interface{} {
+	events := []map[string]interface{}{}
+	for _, frontrun := range metrics.Frontruns {
+		events = append(events, map[string]interface{}{
+			"chain_id":       frontrun.ChainID,
+			"channel":        frontrun.SrcChannel,
+			"signer":         frontrun.Signer,
+			"frontrunned_by": frontrun.FrontrunnedBy,
+			"count":          int(frontrun.Count),
+			"timestamp":      scrapedAt,
+		})
+	}
+	return events
+}
+
 func generateMockPrometheusMetrics() string {
 	metrics := ""
 
@@ -2260,11 +1900,53 @@ func getMonitoringMetrics() map[string]interface{} {
 	}
 }
 
+// chainRegistryListing lists the loaded chains as the webapp's chain
+// registry service reads them, with the channels and where they were loaded
+// from
+func chainRegistryListing() map[string]interface{} {
+	all := chainRegistry.AllChains()
+	chains := make([]map[string]interface{}, 0, len(all))
+	for chainID, chain := range all {
+		chains = append(chains, map[string]interface{}{
+			"chain_id":      chainID,
+			"chain_name":    chain.ChainName,
+			"pretty_name":   chain.ChainName,
+			"network_type":  chain.Metadata["network_type"],
+			"prefix":        chain.AddressPrefix,
+			"rest_api":      redactEndpoint(chain.RESTEndpoint),
+			"rpc":           redactEndpoint(chain.RPCEndpoint),
+			"comet_version": chain.Metadata["comet_version"],
+		})
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i]["chain_id"].(string) < chains[j]["chain_id"].(string)
+	})
+
+	return map[string]interface{}{
+		"chains":   chains,
+		"channels": chainRegistry.AllChannels(),
+		"status":   chainRegistry.Status(),
+	}
+}
+
+// redactEndpoint drops credentials an overrides file may put in the userinfo
+// or query string. nodes.toml endpoints aren't part of the served chains at
+// all.
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
 // getChainRegistry returns the chain configuration registry
 func getChainRegistry() map[string]interface{} {
 	// Convert the centralized registry to the API response format
 	chainsMap := make(map[string]interface{})
-	for chainID, chain := range chainRegistry.Chains {
+	for chainID, chain := range chainRegistry.AllChains() {
 		chainsMap[chainID] = map[string]interface{}{
 			"chain_id":       chain.ChainID,
 			"chain_name":     chain.ChainName,
@@ -2279,8 +1961,9 @@ func getChainRegistry() map[string]interface{} {
 	}
 	
 	// Convert channels to interface slice
-	channels := make([]map[string]interface{}, len(chainRegistry.Channels))
-	for i, ch := range chainRegistry.Channels {
+	registryChannels := chainRegistry.AllChannels()
+	channels := make([]map[string]interface{}, len(registryChannels))
+	for i, ch := range registryChannels {
 		channels[i] = map[string]interface{}{
 			"source_chain":   ch.SourceChain,
 			"source_channel": ch.SourceChannel,
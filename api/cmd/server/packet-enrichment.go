@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/relayooor/api/pkg/channels"
+	"github.com/relayooor/api/pkg/denoms"
 )
 
 // EnrichedPacket contains all available data about a packet from multiple sources
@@ -87,18 +92,22 @@ type EnrichedPacket struct {
 
 // PacketEnrichmentService combines data from multiple sources
 type PacketEnrichmentService struct {
-	chainpulseURL string
-	hermesURL     string
-	cache         sync.Map
-	cacheTTL      time.Duration
+	chainpulseURL  string
+	hermesURL      string
+	cache          sync.Map
+	cacheTTL       time.Duration
+	counterparties *channels.Resolver
+	denoms         *denoms.Resolver
 }
 
 // NewPacketEnrichmentService creates a new enrichment service
 func NewPacketEnrichmentService(chainpulseURL, hermesURL string) *PacketEnrichmentService {
 	return &PacketEnrichmentService{
-		chainpulseURL: chainpulseURL,
-		hermesURL:     hermesURL,
-		cacheTTL:      5 * time.Minute,
+		chainpulseURL:  chainpulseURL,
+		hermesURL:      hermesURL,
+		cacheTTL:       5 * time.Minute,
+		counterparties: channelResolver,
+		denoms:         denomResolver,
 	}
 }
 
@@ -151,12 +160,12 @@ func (s *PacketEnrichmentService) EnrichPacket(basicPacket map[string]interface{
 	// Set default port
 	enriched.PortID = "transfer"
 	
+	// Enrich channel information, which can fill in the destination chain
+	s.enrichChannelInfo(enriched)
+	
 	// Enrich chain information
 	s.enrichChainInfo(enriched)
 	
-	// Enrich channel information
-	s.enrichChannelInfo(enriched)
-	
 	// Enrich token information
 	s.enrichTokenInfo(enriched)
 	
@@ -187,59 +196,46 @@ func (s *PacketEnrichmentService) enrichChainInfo(packet *EnrichedPacket) {
 	}
 }
 
-// enrichChannelInfo adds channel metadata
+// enrichChannelInfo adds the channel's state, connection and counterparty,
+// and the destination chain when the packet didn't name it
 func (s *PacketEnrichmentService) enrichChannelInfo(packet *EnrichedPacket) {
 	packet.ChannelInfo.SourceChannel = packet.ChannelID
-	packet.ChannelInfo.State = "OPEN" // Default assumption
 	packet.ChannelInfo.Version = "ics20-1"
 	
-	// Map known channel pairs
-	channelPairs := map[string]map[string]string{
-		"osmosis-1": {
-			"channel-0":   "channel-141", // to cosmoshub-4
-			"channel-141": "channel-326", // to cosmoshub-4
-			"channel-208": "channel-5",   // to axelar
-		},
-		"cosmoshub-4": {
-			"channel-141": "channel-0",   // to osmosis-1
-			"channel-326": "channel-141", // to osmosis-1
-		},
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	counterparty, err := s.counterparties.Resolve(ctx, packet.SourceChain, packet.PortID, packet.ChannelID)
+	if err != nil {
+		log.Printf("Failed to resolve channel %s on %s: %v", packet.ChannelID, packet.SourceChain, err)
+		packet.ChannelInfo.State = "UNKNOWN"
+		return
 	}
 	
-	if channels, ok := channelPairs[packet.SourceChain]; ok {
-		if destChannel, ok := channels[packet.ChannelID]; ok {
-			packet.ChannelInfo.DestinationChannel = destChannel
-		}
+	packet.ChannelInfo.DestinationChannel = counterparty.CounterpartyChannelID
+	packet.ChannelInfo.ConnectionID = counterparty.ConnectionID
+	packet.ChannelInfo.State = strings.TrimPrefix(counterparty.State, "STATE_")
+	if packet.DestinationChain == "" || packet.DestinationChain == "unknown" {
+		packet.DestinationChain = counterparty.CounterpartyChainID
 	}
+	packet.Metrics.DataSources = append(packet.Metrics.DataSources, "chain")
 }
 
-// enrichTokenInfo adds token metadata
+// enrichTokenInfo adds the denom's trace, base denom and display metadata
 func (s *PacketEnrichmentService) enrichTokenInfo(packet *EnrichedPacket) {
-	packet.TokenInfo.IsIBCToken = strings.HasPrefix(packet.Denom, "ibc/") || strings.Contains(packet.Denom, "transfer/")
-	
-	// Extract token symbol
-	if strings.Contains(packet.Denom, "uatom") {
-		packet.TokenInfo.Symbol = "ATOM"
-		packet.TokenInfo.Decimals = 6
-	} else if strings.Contains(packet.Denom, "uosmo") {
-		packet.TokenInfo.Symbol = "OSMO"
-		packet.TokenInfo.Decimals = 6
-	} else if strings.Contains(packet.Denom, "uusdc") {
-		packet.TokenInfo.Symbol = "USDC"
-		packet.TokenInfo.Decimals = 6
-	} else if strings.Contains(packet.Denom, "ustrd") {
-		packet.TokenInfo.Symbol = "STRD"
-		packet.TokenInfo.Decimals = 6
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	denom, err := s.denoms.Resolve(ctx, packet.SourceChain, packet.Denom)
+	if err != nil {
+		log.Printf("Failed to resolve denom %s on %s: %v", packet.Denom, packet.SourceChain, err)
+		packet.TokenInfo.IsIBCToken = strings.HasPrefix(packet.Denom, "ibc/")
+		return
 	}
 	
-	if packet.TokenInfo.IsIBCToken {
-		// Extract base denom from IBC denom
-		parts := strings.Split(packet.Denom, "/")
-		if len(parts) > 2 {
-			packet.TokenInfo.BaseDenom = parts[len(parts)-1]
-			packet.TokenInfo.TracePath = strings.Join(parts[:len(parts)-1], "/")
-		}
-	}
+	packet.TokenInfo.IsIBCToken = denom.IsIBC()
+	packet.TokenInfo.BaseDenom = denom.BaseDenom
+	packet.TokenInfo.TracePath = denom.Path
+	packet.TokenInfo.Symbol = denom.Symbol
+	packet.TokenInfo.Decimals = denom.Decimals
 }
 
 // enrichClearingInfo adds packet clearing requirements
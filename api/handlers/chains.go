@@ -4,7 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/relayooor/api/internal/config"
+	"github.com/relayooor/chains/config"
 )
 
 // ChainEndpoint represents a chain's REST API endpoint
@@ -15,10 +15,10 @@ type ChainEndpoint struct {
 
 // GetChainEndpoints returns known chain REST endpoints
 func (h *Handler) GetChainEndpoints(c *gin.Context) {
-	registry := config.DefaultChainRegistry()
-	
-	endpoints := make([]ChainEndpoint, 0, len(registry.Chains))
-	for chainID, chain := range registry.Chains {
+	chains := config.DefaultChainRegistry().AllChains()
+
+	endpoints := make([]ChainEndpoint, 0, len(chains))
+	for chainID, chain := range chains {
 		if chain.RESTEndpoint != "" {
 			endpoints = append(endpoints, ChainEndpoint{
 				ChainID: chainID,
@@ -35,5 +35,9 @@ func (h *Handler) GetChainEndpoints(c *gin.Context) {
 // GetChainRegistry returns the complete chain registry with all endpoints
 func (h *Handler) GetChainRegistry(c *gin.Context) {
 	registry := config.DefaultChainRegistry()
-	c.JSON(http.StatusOK, registry)
+	c.JSON(http.StatusOK, gin.H{
+		"chains":   registry.AllChains(),
+		"channels": registry.AllChannels(),
+		"status":   registry.Status(),
+	})
 }
\ No newline at end of file
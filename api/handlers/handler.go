@@ -2,16 +2,20 @@ package handlers
 
 import (
 	"github.com/relayooor/api/pkg/chainpulse"
+	"github.com/relayooor/api/pkg/channels"
+	"github.com/relayooor/chains/config"
 )
 
 // Handler holds dependencies for all handlers
 type Handler struct {
 	chainpulseClient *chainpulse.Client
+	counterparties   *channels.Resolver
 }
 
 // NewHandler creates a new handler with dependencies
 func NewHandler(chainpulseURL string) *Handler {
 	return &Handler{
 		chainpulseClient: chainpulse.NewClient(chainpulseURL),
+		counterparties:   channels.NewResolver(config.DefaultChainRegistry()),
 	}
 }
\ No newline at end of file
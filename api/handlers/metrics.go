@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/chainmetrics"
 )
 
 // GetChainpulseMetrics proxies to the actual Chainpulse metrics endpoint
@@ -75,14 +77,17 @@ func GetMonitoringData(c *gin.Context) {
 		return
 	}
 
-	// Note: The actual implementation that parses Chainpulse metrics and returns
-	// structured monitoring data is in api/cmd/server/main.go
-	// This package appears to be unused in the current architecture.
+	metrics, err := chainmetrics.ParseMetrics(bytes.NewReader(metricsBody))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": fmt.Sprintf("Failed to parse metrics: %v", err),
+		})
+		return
+	}
 
-	// For now, return a simple response indicating the service is available
 	data := gin.H{
 		"status":    "healthy",
-		"message":   "Use api/cmd/server for the actual implementation",
+		"metrics":   metrics,
 		"timestamp": time.Now(),
 	}
 
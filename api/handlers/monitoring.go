@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/relayooor/api/internal/config"
+	"github.com/relayooor/chains/config"
 )
 
 // GetMonitoringData returns structured monitoring data
@@ -30,7 +30,7 @@ func (h *Handler) GetMonitoringData(c *gin.Context) {
 	// Build chains data - for now using basic connected status
 	// TODO: Parse actual metrics from Chainpulse prometheus endpoint
 	chains := []gin.H{}
-	for chainID, chainConfig := range registry.Chains {
+	for chainID, chainConfig := range registry.AllChains() {
 		chains = append(chains, gin.H{
 			"chainId": chainID,
 			"chainName": chainConfig.ChainName,
@@ -85,7 +85,7 @@ func (h *Handler) GetMonitoringData(c *gin.Context) {
 			packet := stuckPacketsResp.Packets[i]
 			recentActivity = append(recentActivity, gin.H{
 				"from_chain": packet.ChainID,
-				"to_chain": getDestinationChainFromChannel(packet.ChainID, packet.SrcChannel),
+				"to_chain": h.counterparties.CounterpartyChain(packet.ChainID, packet.SrcChannel),
 				"channel": packet.SrcChannel,
 				"status": "pending",
 				"timestamp": time.Now().Add(-time.Duration(packet.AgeSeconds) * time.Second),
@@ -131,35 +131,6 @@ func (h *Handler) GetMonitoringData(c *gin.Context) {
 	c.JSON(http.StatusOK, data)
 }
 
-// getDestinationChainFromChannel tries to determine destination chain from channel ID
-func getDestinationChainFromChannel(sourceChain, srcChannel string) string {
-	// This is a simplified mapping - in production should use IBC query
-	// TODO: Query actual IBC channel info
-	channelMap := map[string]map[string]string{
-		"osmosis-1": {
-			"channel-0":   "cosmoshub-4",
-			"channel-750": "noble-1",
-			"channel-874": "neutron-1",
-		},
-		"cosmoshub-4": {
-			"channel-141": "osmosis-1",
-			"channel-536": "noble-1",
-		},
-		"noble-1": {
-			"channel-1": "osmosis-1",
-			"channel-4": "cosmoshub-4",
-		},
-	}
-	
-	if chains, ok := channelMap[sourceChain]; ok {
-		if dest, ok := chains[srcChannel]; ok {
-			return dest
-		}
-	}
-	
-	return fmt.Sprintf("%s-dest", srcChannel) // Fallback
-}
-
 // GetMonitoringMetrics returns monitoring metrics in structured format
 func (h *Handler) GetMonitoringMetrics(c *gin.Context) {
 	// Get data from Chainpulse
@@ -177,7 +148,7 @@ func (h *Handler) GetMonitoringMetrics(c *gin.Context) {
 	
 	// Build chains data
 	chains := []gin.H{}
-	for chainID, chainConfig := range registry.Chains {
+	for chainID, chainConfig := range registry.AllChains() {
 		chains = append(chains, gin.H{
 			"chainId": chainID,
 			"chainName": chainConfig.ChainName,
@@ -234,7 +205,7 @@ func (h *Handler) GetMonitoringMetrics(c *gin.Context) {
 				for _, packet := range stuckPacketsResp.Packets {
 					if packet.SrcChannel == ch.SrcChannel {
 						srcChain = packet.ChainID
-						dstChain = getDestinationChainFromChannel(packet.ChainID, packet.SrcChannel)
+						dstChain = h.counterparties.CounterpartyChain(packet.ChainID, packet.SrcChannel)
 						break
 					}
 				}
@@ -332,13 +303,13 @@ func (h *Handler) GetPacketFlowMetrics(c *gin.Context) {
 				for _, packet := range packetsResp.Packets {
 					if packet.SrcChannel == ch.SrcChannel {
 						srcChain = packet.ChainID
-						dstChain = getDestinationChainFromChannel(packet.ChainID, packet.SrcChannel)
+						dstChain = h.counterparties.CounterpartyChain(packet.ChainID, packet.SrcChannel)
 						
 						// Get chain names
-						if srcConfig, ok := registry.Chains[srcChain]; ok {
+						if srcConfig, ok := registry.GetChainByID(srcChain); ok {
 							srcChain = srcConfig.ChainName
 						}
-						if dstConfig, ok := registry.Chains[dstChain]; ok {
+						if dstConfig, ok := registry.GetChainByID(dstChain); ok {
 							dstChain = dstConfig.ChainName
 						}
 						break
@@ -404,7 +375,7 @@ func (h *Handler) GetStuckPackets(c *gin.Context) {
 				for _, packet := range packetsResp.Packets {
 					if packet.SrcChannel == ch.SrcChannel {
 						srcChain = packet.ChainID
-						dstChain = getDestinationChainFromChannel(packet.ChainID, packet.SrcChannel)
+						dstChain = h.counterparties.CounterpartyChain(packet.ChainID, packet.SrcChannel)
 						break
 					}
 				}
@@ -489,21 +460,15 @@ func (h *Handler) GetRelayerPerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
-// GetChainpulseMetrics proxies to chainpulse metrics endpoint
+// GetChainpulseMetrics returns the Chainpulse metrics parsed into chains,
+// channels and relayers
 func (h *Handler) GetChainpulseMetrics(c *gin.Context) {
-	// Proxy to chainpulse metrics
-	resp, err := http.Get("http://localhost:3001/metrics")
+	metrics, err := h.chainpulseClient.GetMetrics()
 	if err != nil {
-		c.String(http.StatusServiceUnavailable, "Chainpulse metrics unavailable")
+		log.Printf("Error fetching Chainpulse metrics: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Chainpulse metrics unavailable"})
 		return
 	}
-	defer resp.Body.Close()
 
-	c.DataFromReader(
-		resp.StatusCode,
-		resp.ContentLength,
-		resp.Header.Get("Content-Type"),
-		resp.Body,
-		nil,
-	)
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, metrics)
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"github.com/relayooor/chains/chainmetrics"
 )
 
 // Client is the Chainpulse API client
@@ -287,6 +289,22 @@ func (c *Client) GetDuplicatePackets() (*DuplicatesResponse, error) {
 	return &result, nil
 }
 
+// GetMetrics scrapes and parses the Chainpulse Prometheus metrics
+func (c *Client) GetMetrics() (*chainmetrics.Metrics, error) {
+	url := fmt.Sprintf("%s/metrics", c.baseURL)
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return chainmetrics.ParseMetrics(resp.Body)
+}
+
 // HealthCheck verifies the Chainpulse API is accessible
 func (c *Client) HealthCheck() error {
 	url := fmt.Sprintf("%s/metrics", c.baseURL)
@@ -0,0 +1,149 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	chainchannels "github.com/relayooor/chains/channels"
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+	"go.uber.org/zap"
+)
+
+// redisKey is the Redis hash counterparties are stored in, keyed by
+// chain/port/channel
+const redisKey = "channels:counterparties"
+
+// Resolver finds the chain at the other end of a channel from each chain's
+// REST API. Results are cached for a TTL, in memory and, with UseRedis, in
+// Redis so they survive restarts. Start re-checks every cached channel
+// periodically so changes are picked up between lookups.
+type Resolver struct {
+	*chainchannels.Resolver
+	querier  *chainchannels.RESTQuerier
+	interval time.Duration
+}
+
+// NewResolver creates a resolver using the REST endpoints of the registry.
+// The TTL comes from CHANNEL_COUNTERPARTY_TTL and defaults to an hour; the
+// refresh interval comes from CHANNEL_COUNTERPARTY_REFRESH_INTERVAL and
+// defaults to 10 minutes.
+func NewResolver(registry *config.ChainRegistry) *Resolver {
+	ttl, interval := time.Hour, 10*time.Minute
+	if value, err := time.ParseDuration(os.Getenv("CHANNEL_COUNTERPARTY_TTL")); err == nil && value > 0 {
+		ttl = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("CHANNEL_COUNTERPARTY_REFRESH_INTERVAL")); err == nil && value > 0 {
+		interval = value
+	}
+	querier := chainchannels.NewRESTQuerier(registry)
+	return &Resolver{
+		Resolver: chainchannels.NewResolver(querier, ttl, zap.NewNop()),
+		querier:  querier,
+		interval: interval,
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (r *Resolver) UseEndpoints(pools *endpoints.Pools) {
+	r.querier.UseEndpoints(pools)
+}
+
+// UseRedis keeps resolved counterparties in Redis as well as in memory
+func (r *Resolver) UseRedis(client *redis.Client) {
+	r.UseStore(&redisStore{client: client})
+}
+
+// Start refreshes the cached counterparties every interval until ctx is
+// done
+func (r *Resolver) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to refresh channel counterparties: %v", err)
+			}
+		}
+	}()
+}
+
+// CounterpartyChain returns the chain a transfer channel leads to, or
+// "unknown" when it can't be resolved
+func (r *Resolver) CounterpartyChain(chainID, channelID string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if chain := r.Resolver.CounterpartyChain(ctx, chainID, channelID); chain != "" {
+		return chain
+	}
+	return "unknown"
+}
+
+// redisStore keeps counterparties in the redisKey hash
+type redisStore struct {
+	client *redis.Client
+}
+
+func cacheKey(chainID, portID, channelID string) string {
+	return chainID + "/" + portID + "/" + channelID
+}
+
+// Get implements chainchannels.Store
+func (s *redisStore) Get(ctx context.Context, chainID, portID, channelID string) (*chainchannels.Counterparty, error) {
+	data, err := s.client.HGet(ctx, redisKey, cacheKey(chainID, portID, channelID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c chainchannels.Counterparty
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// List implements chainchannels.Store
+func (s *redisStore) List(ctx context.Context) ([]chainchannels.Counterparty, error) {
+	stored, err := s.client.HGetAll(ctx, redisKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load channel counterparties: %w", err)
+	}
+	entries := make([]chainchannels.Counterparty, 0, len(stored))
+	for _, data := range stored {
+		var c chainchannels.Counterparty
+		if err := json.Unmarshal([]byte(data), &c); err == nil {
+			entries = append(entries, c)
+		}
+	}
+	return entries, nil
+}
+
+// Save implements chainchannels.Store
+func (s *redisStore) Save(ctx context.Context, counterparty *chainchannels.Counterparty) error {
+	data, err := json.Marshal(counterparty)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, redisKey, cacheKey(counterparty.ChainID, counterparty.PortID, counterparty.ChannelID), data).Err()
+}
+
+// Delete implements chainchannels.Store
+func (s *redisStore) Delete(ctx context.Context, chainID, portID, channelID string) error {
+	return s.client.HDel(ctx, redisKey, cacheKey(chainID, portID, channelID)).Err()
+}
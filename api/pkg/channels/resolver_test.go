@@ -0,0 +1,136 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chainchannels "github.com/relayooor/chains/channels"
+	"github.com/relayooor/chains/config"
+)
+
+func TestResolve(t *testing.T) {
+	state := "STATE_OPEN"
+	queries := map[string]int{}
+	lcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries[r.URL.Path]++
+		switch r.URL.Path {
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer":
+			fmt.Fprintf(w, `{"channel":{"state":%q,"connection_hops":["connection-1"],"counterparty":{"port_id":"transfer","channel_id":"channel-141"}}}`, state)
+		case "/ibc/core/connection/v1/connections/connection-1":
+			fmt.Fprint(w, `{"connection":{"client_id":"07-tendermint-1"}}`)
+		case "/ibc/core/client/v1/client_states/07-tendermint-1":
+			fmt.Fprint(w, `{"client_state":{"@type":"/ibc.lightclients.tendermint.v1.ClientState","chain_id":"cosmoshub-4"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer lcd.Close()
+
+	resolver := NewResolver(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"osmosis-1": {ChainID: "osmosis-1", RESTEndpoint: lcd.URL},
+	}})
+	now := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	resolver.UseClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	counterparty, err := resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", counterparty.CounterpartyChainID)
+	assert.Equal(t, "channel-141", counterparty.CounterpartyChannelID)
+	assert.Equal(t, "07-tendermint-1", counterparty.ClientID)
+
+	// Cached while fresh
+	assert.Equal(t, "cosmoshub-4", resolver.CounterpartyChain("osmosis-1", "channel-0"))
+	assert.Equal(t, 1, queries["/ibc/core/channel/v1/channels/channel-0/ports/transfer"])
+
+	// Once stale only the channel is checked while it's unchanged
+	now = now.Add(2 * time.Hour)
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, 2, queries["/ibc/core/channel/v1/channels/channel-0/ports/transfer"])
+	assert.Equal(t, 1, queries["/ibc/core/connection/v1/connections/connection-1"])
+
+	// and followed through again when its state changes
+	now = now.Add(2 * time.Hour)
+	state = "STATE_CLOSED"
+	counterparty, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "STATE_CLOSED", counterparty.State)
+	assert.Equal(t, 2, queries["/ibc/core/connection/v1/connections/connection-1"])
+
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-9999")
+	assert.ErrorIs(t, err, chainchannels.ErrChannelNotFound)
+	assert.Equal(t, "unknown", resolver.CounterpartyChain("juno-1", "channel-0"))
+}
+
+func TestResolverRedis(t *testing.T) {
+	state, exists := "STATE_OPEN", true
+	queries := map[string]int{}
+	lcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries[r.URL.Path]++
+		switch {
+		case r.URL.Path == "/ibc/core/channel/v1/channels/channel-0/ports/transfer" && exists:
+			fmt.Fprintf(w, `{"channel":{"state":%q,"connection_hops":["connection-1"],"counterparty":{"port_id":"transfer","channel_id":"channel-141"}}}`, state)
+		case r.URL.Path == "/ibc/core/connection/v1/connections/connection-1":
+			fmt.Fprint(w, `{"connection":{"client_id":"07-tendermint-1"}}`)
+		case r.URL.Path == "/ibc/core/client/v1/client_states/07-tendermint-1":
+			fmt.Fprint(w, `{"client_state":{"chain_id":"cosmoshub-4"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer lcd.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
+	registry := &config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"osmosis-1": {ChainID: "osmosis-1", RESTEndpoint: lcd.URL},
+	}}
+	now := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	newResolver := func() *Resolver {
+		resolver := NewResolver(registry)
+		resolver.UseRedis(redisClient)
+		resolver.UseClock(func() time.Time { return now })
+		return resolver
+	}
+	ctx := context.Background()
+
+	_, err := newResolver().Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists(redisKey))
+
+	// A restarted resolver answers from Redis without querying the chain
+	resolver := newResolver()
+	assert.Equal(t, "cosmoshub-4", resolver.CounterpartyChain("osmosis-1", "channel-0"))
+	assert.Equal(t, 1, queries["/ibc/core/channel/v1/channels/channel-0/ports/transfer"])
+
+	// A refresh checks the channel and follows it again once it changed
+	_, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queries["/ibc/core/connection/v1/connections/connection-1"])
+	state = "STATE_CLOSED"
+	_, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, queries["/ibc/core/connection/v1/connections/connection-1"])
+	counterparty, err := newResolver().Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "STATE_CLOSED", counterparty.State)
+
+	// and drops channels that no longer exist
+	exists = false
+	_, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.False(t, mr.Exists(redisKey))
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	assert.ErrorIs(t, err, chainchannels.ErrChannelNotFound)
+}
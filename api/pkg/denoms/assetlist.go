@@ -0,0 +1,127 @@
+package denoms
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Asset is an asset from a chain registry asset list
+type Asset struct {
+	Base       string      `json:"base"`
+	Display    string      `json:"display"`
+	Symbol     string      `json:"symbol"`
+	Name       string      `json:"name"`
+	DenomUnits []DenomUnit `json:"denom_units"`
+	Traces     []struct {
+		Type string `json:"type"`
+	} `json:"traces,omitempty"`
+}
+
+// DenomUnit is one unit of an asset
+type DenomUnit struct {
+	Denom    string `json:"denom"`
+	Exponent int    `json:"exponent"`
+}
+
+// Decimals is the exponent of the asset's display unit
+func (a Asset) Decimals() int {
+	for _, unit := range a.DenomUnits {
+		if unit.Denom == a.Display {
+			return unit.Exponent
+		}
+	}
+	return 0
+}
+
+// native reports whether the asset originates on the chain that lists it
+func (a Asset) native() bool {
+	return len(a.Traces) == 0
+}
+
+// AssetLists are the asset lists of a chain registry checkout, by chain ID
+type AssetLists struct {
+	chains map[string]map[string]Asset
+	// natives are assets listed on the chain they originate on, by base
+	// denom
+	natives map[string][]Asset
+}
+
+// LoadAssetLists reads <dir>/<chain>/assetlist.json for every chain in a
+// checkout of the cosmos chain registry. Chains are keyed by the chain_id
+// of their chain.json; chains without one are skipped.
+func LoadAssetLists(dir string) (*AssetLists, error) {
+	lists := &AssetLists{
+		chains:  make(map[string]map[string]Asset),
+		natives: make(map[string][]Asset),
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "assetlist.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		var chain struct {
+			ChainID string `json:"chain_id"`
+		}
+		if err := readJSON(filepath.Join(filepath.Dir(path), "chain.json"), &chain); err != nil || chain.ChainID == "" {
+			continue
+		}
+
+		var list struct {
+			Assets []Asset `json:"assets"`
+		}
+		if err := readJSON(path, &list); err != nil {
+			return nil, err
+		}
+
+		assets := make(map[string]Asset, len(list.Assets))
+		for _, asset := range list.Assets {
+			assets[asset.Base] = asset
+			if asset.native() {
+				lists.natives[asset.Base] = append(lists.natives[asset.Base], asset)
+			}
+		}
+		lists.chains[chain.ChainID] = assets
+	}
+	return lists, nil
+}
+
+// Lookup returns the asset a chain lists under a denom, such as
+// ibc/<hash> for an asset from another chain
+func (l *AssetLists) Lookup(chainID, denom string) (Asset, bool) {
+	if l == nil {
+		return Asset{}, false
+	}
+	asset, ok := l.chains[chainID][denom]
+	return asset, ok
+}
+
+// Native returns the asset with a base denom on the chain it originates
+// on, if exactly one chain lists it as native
+func (l *AssetLists) Native(base string) (Asset, bool) {
+	if l == nil || len(l.natives[base]) != 1 {
+		return Asset{}, false
+	}
+	return l.natives[base][0], true
+}
+
+// Chains is the number of chains with an asset list
+func (l *AssetLists) Chains() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.chains)
+}
+
+func readJSON(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
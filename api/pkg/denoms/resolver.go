@@ -0,0 +1,207 @@
+package denoms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// ErrTraceNotFound is returned for ibc/ denoms the chain has no trace for
+var ErrTraceNotFound = errors.New("denom trace not found")
+
+// Denom is a denom as sent from a chain, with its trace and metadata
+type Denom struct {
+	Denom string `json:"denom"`
+	// IBCDenom is ibc/<hash> for denoms that came over IBC
+	IBCDenom string `json:"ibc_denom,omitempty"`
+	// Path is the port/channel hops the denom took, e.g.
+	// transfer/channel-0
+	Path      string `json:"path,omitempty"`
+	BaseDenom string `json:"base_denom"`
+	Symbol    string `json:"symbol,omitempty"`
+	Display   string `json:"display,omitempty"`
+	Decimals  int    `json:"decimals"`
+	// Known is true when metadata was found in the asset lists
+	Known bool `json:"known"`
+}
+
+// IsIBC reports whether the denom came from another chain
+func (d Denom) IsIBC() bool {
+	return d.Path != ""
+}
+
+// Resolver turns denoms into base denoms, trace paths and display metadata.
+// ibc/<hash> denoms are traced with the chain's transfer module, and
+// metadata comes from chain registry asset lists. Traces never change, so
+// they're cached for the life of the process.
+type Resolver struct {
+	client *endpoints.Client
+	assets *AssetLists
+
+	mu     sync.RWMutex
+	traces map[string]string // chainID/hash -> path/base
+	hashes map[string]string // chainID/path/base -> ibc/hash
+}
+
+// NewResolver creates a denom resolver using the REST endpoints of the
+// registry. assets may be nil.
+func NewResolver(registry *config.ChainRegistry, assets *AssetLists) *Resolver {
+	return &Resolver{
+		client: endpoints.NewClient(registry, 5*time.Second),
+		assets: assets,
+		traces: make(map[string]string),
+		hashes: make(map[string]string),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (r *Resolver) UseEndpoints(pools *endpoints.Pools) {
+	r.client.UsePools(pools)
+}
+
+// Resolve describes a denom held on chainID, either ibc/<hash> or a full
+// trace such as transfer/channel-0/uatom as it appears in ICS-20 packet
+// data
+func (r *Resolver) Resolve(ctx context.Context, chainID, denom string) (*Denom, error) {
+	result := &Denom{Denom: denom}
+
+	fullPath := denom
+	if strings.HasPrefix(denom, "ibc/") {
+		trace, err := r.trace(ctx, chainID, strings.TrimPrefix(denom, "ibc/"))
+		if err != nil {
+			return nil, err
+		}
+		fullPath = trace
+		result.IBCDenom = denom
+	}
+	result.Path, result.BaseDenom = SplitTrace(fullPath)
+	if result.IsIBC() && result.IBCDenom == "" {
+		result.IBCDenom = r.hash(ctx, chainID, fullPath)
+	}
+
+	// The chain's own listing of the IBC denom, then the asset on the chain
+	// it originates from
+	asset, ok := r.assets.Lookup(chainID, result.IBCDenom)
+	if !ok {
+		asset, ok = r.assets.Lookup(chainID, result.BaseDenom)
+	}
+	if !ok {
+		asset, ok = r.assets.Native(result.BaseDenom)
+	}
+	if ok {
+		result.Symbol = asset.Symbol
+		result.Display = asset.Display
+		result.Decimals = asset.Decimals()
+		result.Known = true
+	}
+	return result, nil
+}
+
+// trace returns the path/base of an IBC denom hash from denom_traces
+func (r *Resolver) trace(ctx context.Context, chainID, hash string) (string, error) {
+	key := chainID + "/" + strings.ToUpper(hash)
+	r.mu.RLock()
+	trace, ok := r.traces[key]
+	r.mu.RUnlock()
+	if ok {
+		return trace, nil
+	}
+
+	var body struct {
+		DenomTrace struct {
+			Path      string `json:"path"`
+			BaseDenom string `json:"base_denom"`
+		} `json:"denom_trace"`
+	}
+	if err := r.get(ctx, chainID, "/ibc/apps/transfer/v1/denom_traces/"+url.PathEscape(hash), &body); err != nil {
+		return "", err
+	}
+	if body.DenomTrace.BaseDenom == "" {
+		return "", fmt.Errorf("ibc/%s on %s: %w", hash, chainID, ErrTraceNotFound)
+	}
+
+	trace = body.DenomTrace.BaseDenom
+	if body.DenomTrace.Path != "" {
+		trace = body.DenomTrace.Path + "/" + trace
+	}
+	r.mu.Lock()
+	r.traces[key] = trace
+	r.mu.Unlock()
+	return trace, nil
+}
+
+// hash returns the ibc/<hash> denom of a trace from denom_hashes, or
+// computes it when the chain can't be asked
+func (r *Resolver) hash(ctx context.Context, chainID, trace string) string {
+	key := chainID + "/" + trace
+	r.mu.RLock()
+	denom, ok := r.hashes[key]
+	r.mu.RUnlock()
+	if ok {
+		return denom
+	}
+
+	var body struct {
+		Hash string `json:"hash"`
+	}
+	if err := r.get(ctx, chainID, "/ibc/apps/transfer/v1/denom_hashes/"+trace, &body); err == nil && body.Hash != "" {
+		denom = "ibc/" + strings.ToUpper(body.Hash)
+	} else {
+		denom = IBCDenom(trace)
+	}
+
+	r.mu.Lock()
+	r.hashes[key] = denom
+	r.mu.Unlock()
+	return denom
+}
+
+func (r *Resolver) get(ctx context.Context, chainID, path string, out interface{}) error {
+	resp, err := r.client.Get(ctx, chainID, endpoints.REST, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("query %s on %s: %w", path, chainID, ErrTraceNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query %s on %s returned %d", path, chainID, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// SplitTrace splits a full denom trace into its port/channel hops and base
+// denom. The base denom may itself contain slashes, as in
+// transfer/channel-0/gamm/pool/1.
+func SplitTrace(trace string) (path, base string) {
+	parts := strings.Split(trace, "/")
+	hops := 0
+	for hops+1 < len(parts) && strings.HasPrefix(parts[hops+1], "channel-") {
+		hops += 2
+	}
+	if hops == 0 || hops >= len(parts) {
+		return "", trace
+	}
+	return strings.Join(parts[:hops], "/"), strings.Join(parts[hops:], "/")
+}
+
+// IBCDenom is the ibc/<hash> denom of a full denom trace
+func IBCDenom(trace string) string {
+	return fmt.Sprintf("ibc/%X", sha256.Sum256([]byte(trace)))
+}
@@ -0,0 +1,112 @@
+package denoms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/relayooor/chains/config"
+)
+
+// atomOnOsmosis is ATOM sent to Osmosis over its channel to the Hub
+const atomOnOsmosis = "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"
+
+func writeChain(t *testing.T, dir, name, chainID, assets string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name, "chain.json"), []byte(fmt.Sprintf(`{"chain_id":%q}`, chainID)), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name, "assetlist.json"), []byte(assets), 0o644))
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeChain(t, dir, "cosmoshub", "cosmoshub-4", `{"assets":[
+		{"base":"uatom","display":"atom","symbol":"ATOM","denom_units":[{"denom":"uatom","exponent":0},{"denom":"atom","exponent":6}]}
+	]}`)
+	writeChain(t, dir, "osmosis", "osmosis-1", `{"assets":[
+		{"base":"uosmo","display":"osmo","symbol":"OSMO","denom_units":[{"denom":"uosmo","exponent":0},{"denom":"osmo","exponent":6}]},
+		{"base":"`+atomOnOsmosis+`","display":"atom","symbol":"ATOM","denom_units":[{"denom":"`+atomOnOsmosis+`","exponent":0},{"denom":"atom","exponent":6}],"traces":[{"type":"ibc"}]}
+	]}`)
+	assets, err := LoadAssetLists(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, assets.Chains())
+
+	queries := 0
+	lcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		switch r.URL.Path {
+		case "/ibc/apps/transfer/v1/denom_traces/" + atomOnOsmosis[4:]:
+			fmt.Fprint(w, `{"denom_trace":{"path":"transfer/channel-0","base_denom":"uatom"}}`)
+		case "/ibc/apps/transfer/v1/denom_traces/0000":
+			fmt.Fprint(w, `{"denom_trace":{"path":"transfer/channel-42","base_denom":"gamm/pool/1"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer lcd.Close()
+
+	resolver := NewResolver(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"osmosis-1":   {ChainID: "osmosis-1", RESTEndpoint: lcd.URL},
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RESTEndpoint: lcd.URL},
+	}}, assets)
+	ctx := context.Background()
+
+	denom, err := resolver.Resolve(ctx, "osmosis-1", atomOnOsmosis)
+	require.NoError(t, err)
+	assert.Equal(t, "transfer/channel-0", denom.Path)
+	assert.Equal(t, "uatom", denom.BaseDenom)
+	assert.Equal(t, "ATOM", denom.Symbol)
+	assert.Equal(t, 6, denom.Decimals)
+	assert.True(t, denom.IsIBC())
+
+	// Traces are cached
+	_, err = resolver.Resolve(ctx, "osmosis-1", atomOnOsmosis)
+	require.NoError(t, err)
+	assert.Equal(t, 1, queries)
+
+	// A full trace as in packet data, hashed locally when the chain can't
+	// be asked; the metadata comes from the asset's home chain
+	denom, err = resolver.Resolve(ctx, "cosmoshub-4", "transfer/channel-141/uatom")
+	require.NoError(t, err)
+	assert.Equal(t, "transfer/channel-141", denom.Path)
+	assert.Equal(t, IBCDenom("transfer/channel-141/uatom"), denom.IBCDenom)
+	assert.Equal(t, "ATOM", denom.Symbol)
+
+	denom, err = resolver.Resolve(ctx, "osmosis-1", "uosmo")
+	require.NoError(t, err)
+	assert.False(t, denom.IsIBC())
+	assert.Equal(t, "OSMO", denom.Symbol)
+
+	// Base denoms with slashes and no asset list entry
+	denom, err = resolver.Resolve(ctx, "osmosis-1", "ibc/0000")
+	require.NoError(t, err)
+	assert.Equal(t, "gamm/pool/1", denom.BaseDenom)
+	assert.False(t, denom.Known)
+
+	_, err = resolver.Resolve(ctx, "osmosis-1", "ibc/FFFF")
+	assert.ErrorIs(t, err, ErrTraceNotFound)
+}
+
+func TestIBCDenom(t *testing.T) {
+	assert.Equal(t, atomOnOsmosis, IBCDenom("transfer/channel-0/uatom"))
+}
+
+func TestSplitTrace(t *testing.T) {
+	for trace, want := range map[string][2]string{
+		"uatom":                    {"", "uatom"},
+		"transfer/channel-0/uatom": {"transfer/channel-0", "uatom"},
+		"transfer/channel-0/transfer/channel-1/uatom": {"transfer/channel-0/transfer/channel-1", "uatom"},
+		"transfer/channel-0/gamm/pool/1":              {"transfer/channel-0", "gamm/pool/1"},
+		"factory/osmo1abc/token":                      {"", "factory/osmo1abc/token"},
+	} {
+		path, base := SplitTrace(trace)
+		assert.Equal(t, want, [2]string{path, base}, trace)
+	}
+}
@@ -0,0 +1,424 @@
+package chainmetrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metric names exported by Chainpulse
+const (
+	MetricChains        = "chainpulse_chains"
+	MetricPackets       = "chainpulse_packets"
+	MetricTxs           = "chainpulse_txs"
+	MetricErrors        = "chainpulse_errors"
+	MetricReconnects    = "chainpulse_reconnects"
+	MetricTimeouts      = "chainpulse_timeouts"
+	MetricEffected      = "ibc_effected_packets"
+	MetricUneffected    = "ibc_uneffected_packets"
+	MetricFrontrun      = "ibc_frontrun_counter"
+	MetricStuckPackets  = "ibc_stuck_packets"
+	MetricStuckDetailed = "ibc_stuck_packets_detailed"
+	MetricPacketAge     = "ibc_packet_age_seconds"
+)
+
+// Metrics is a Chainpulse scrape grouped into chains, channels and relayers
+type Metrics struct {
+	// MonitoredChains is chainpulse_chains
+	MonitoredChains int `json:"monitored_chains"`
+
+	Chains    []ChainMetrics   `json:"chains"`
+	Channels  []ChannelMetrics `json:"channels"`
+	Relayers  []RelayerMetrics `json:"relayers"`
+	Frontruns []FrontrunSeries `json:"frontruns"`
+	Stuck     []StuckChannel   `json:"stuck"`
+
+	families map[string]*dto.MetricFamily
+}
+
+// ChainMetrics are the per-chain counters
+type ChainMetrics struct {
+	ChainID    string  `json:"chain_id"`
+	Packets    float64 `json:"packets"`
+	Txs        float64 `json:"txs"`
+	Errors     float64 `json:"errors"`
+	Reconnects float64 `json:"reconnects"`
+	Timeouts   float64 `json:"timeouts"`
+}
+
+// ChannelMetrics are the relayed packets of a channel, summed over relayers
+type ChannelMetrics struct {
+	ChainID    string  `json:"chain_id"`
+	SrcChannel string  `json:"src_channel"`
+	SrcPort    string  `json:"src_port"`
+	DstChannel string  `json:"dst_channel"`
+	DstPort    string  `json:"dst_port"`
+	Effected   float64 `json:"effected"`
+	Uneffected float64 `json:"uneffected"`
+}
+
+// Total is the number of relay messages seen on the channel
+func (c ChannelMetrics) Total() float64 {
+	return c.Effected + c.Uneffected
+}
+
+// SuccessRate is the percentage of relay messages that were effected
+func (c ChannelMetrics) SuccessRate() float64 {
+	if c.Total() == 0 {
+		return 0
+	}
+	return c.Effected / c.Total() * 100
+}
+
+// RelayerMetrics are the relayed packets of a signer across channels
+type RelayerMetrics struct {
+	Signer     string  `json:"signer"`
+	Memo       string  `json:"memo"`
+	Software   string  `json:"software"`
+	Version    string  `json:"version"`
+	Effected   float64 `json:"effected"`
+	Uneffected float64 `json:"uneffected"`
+	// Frontrun counts the times another signer's message got there first
+	Frontrun float64 `json:"frontrun"`
+}
+
+// Total is the number of relay messages sent by the relayer
+func (r RelayerMetrics) Total() float64 {
+	return r.Effected + r.Uneffected
+}
+
+// SuccessRate is the percentage of the relayer's messages that were effected
+func (r RelayerMetrics) SuccessRate() float64 {
+	if r.Total() == 0 {
+		return 0
+	}
+	return r.Effected / r.Total() * 100
+}
+
+// FrontrunSeries counts how often a signer was frontrun by another on a
+// channel
+type FrontrunSeries struct {
+	ChainID       string  `json:"chain_id"`
+	SrcChannel    string  `json:"src_channel"`
+	DstChannel    string  `json:"dst_channel"`
+	Signer        string  `json:"signer"`
+	Memo          string  `json:"memo"`
+	FrontrunnedBy string  `json:"frontrunned_by"`
+	EffectedMemo  string  `json:"effected_memo"`
+	Count         float64 `json:"count"`
+}
+
+// StuckChannel is the stuck packet backlog Chainpulse reports for a channel.
+// Chainpulse labels the counterparty channel as dst_chain; it's kept as
+// DstLabel rather than guessed at.
+type StuckChannel struct {
+	SrcChain   string  `json:"src_chain"`
+	SrcChannel string  `json:"src_channel"`
+	DstLabel   string  `json:"dst_label"`
+	Stuck      float64 `json:"stuck"`
+}
+
+// Series is one sample of a counter, gauge or untyped metric
+type Series struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// Histogram is one histogram series. Buckets are cumulative, as exposed.
+type Histogram struct {
+	Labels  map[string]string `json:"labels"`
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	Buckets []Bucket          `json:"buckets"`
+}
+
+// Bucket is a histogram bucket
+type Bucket struct {
+	UpperBound float64 `json:"upper_bound"`
+	Count      uint64  `json:"count"`
+}
+
+// softwareVersion finds "hermes 1.13.1" or "rly v2.5.0" in a relayer memo
+var softwareVersion = regexp.MustCompile(`(?i)\b(hermes|rly|relayer|go-relayer|ts-relayer)[ /]v?(\d+\.\d+\.\d+[^\s)]*)`)
+
+// ParseMetrics parses a Prometheus text exposition, or an OpenMetrics one,
+// and groups the Chainpulse metrics it contains
+func ParseMetrics(r io.Reader) (*Metrics, error) {
+	input, err := normalizeOpenMetrics(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(input)
+	if err != nil {
+		return nil, fmt.Errorf("parse metrics: %w", err)
+	}
+
+	m := &Metrics{families: families}
+	m.build()
+	return m, nil
+}
+
+// ParseMetricsString parses metrics text
+func ParseMetricsString(text string) (*Metrics, error) {
+	return ParseMetrics(strings.NewReader(text))
+}
+
+// Series returns the samples of a counter, gauge or untyped metric. The
+// OpenMetrics _total suffix of counters is optional.
+func (m *Metrics) Series(name string) []Series {
+	family := m.family(name)
+	if family == nil {
+		return nil
+	}
+	var series []Series
+	for _, metric := range family.GetMetric() {
+		var value float64
+		switch {
+		case metric.Counter != nil:
+			value = metric.GetCounter().GetValue()
+		case metric.Gauge != nil:
+			value = metric.GetGauge().GetValue()
+		case metric.Untyped != nil:
+			value = metric.GetUntyped().GetValue()
+		default:
+			continue
+		}
+		series = append(series, Series{Labels: labels(metric), Value: value})
+	}
+	return series
+}
+
+// Histograms returns the series of a histogram metric
+func (m *Metrics) Histograms(name string) []Histogram {
+	family := m.family(name)
+	if family == nil {
+		return nil
+	}
+	var histograms []Histogram
+	for _, metric := range family.GetMetric() {
+		h := metric.GetHistogram()
+		if h == nil {
+			continue
+		}
+		histogram := Histogram{
+			Labels: labels(metric),
+			Count:  h.GetSampleCount(),
+			Sum:    h.GetSampleSum(),
+		}
+		for _, b := range h.GetBucket() {
+			histogram.Buckets = append(histogram.Buckets, Bucket{UpperBound: b.GetUpperBound(), Count: b.GetCumulativeCount()})
+		}
+		histograms = append(histograms, histogram)
+	}
+	return histograms
+}
+
+// Sum adds up the samples of a metric
+func (m *Metrics) Sum(name string) float64 {
+	var total float64
+	for _, s := range m.Series(name) {
+		total += s.Value
+	}
+	return total
+}
+
+func (m *Metrics) family(name string) *dto.MetricFamily {
+	if family, ok := m.families[name]; ok {
+		return family
+	}
+	return m.families[name+"_total"]
+}
+
+func (m *Metrics) build() {
+	m.MonitoredChains = int(m.Sum(MetricChains))
+
+	chains := make(map[string]*ChainMetrics)
+	chain := func(id string) *ChainMetrics {
+		if chains[id] == nil {
+			chains[id] = &ChainMetrics{ChainID: id}
+		}
+		return chains[id]
+	}
+	for name, field := range map[string]func(*ChainMetrics) *float64{
+		MetricPackets:    func(c *ChainMetrics) *float64 { return &c.Packets },
+		MetricTxs:        func(c *ChainMetrics) *float64 { return &c.Txs },
+		MetricErrors:     func(c *ChainMetrics) *float64 { return &c.Errors },
+		MetricReconnects: func(c *ChainMetrics) *float64 { return &c.Reconnects },
+		MetricTimeouts:   func(c *ChainMetrics) *float64 { return &c.Timeouts },
+	} {
+		for _, s := range m.Series(name) {
+			if id := s.Labels["chain_id"]; id != "" {
+				*field(chain(id)) += s.Value
+			}
+		}
+	}
+
+	channels := make(map[string]*ChannelMetrics)
+	relayers := make(map[string]*RelayerMetrics)
+	relayer := func(signer, memo string) *RelayerMetrics {
+		r := relayers[signer]
+		if r == nil {
+			r = &RelayerMetrics{Signer: signer}
+			relayers[signer] = r
+		}
+		if r.Memo == "" && memo != "" {
+			r.Memo = memo
+			r.Software, r.Version = parseSoftware(memo)
+		}
+		return r
+	}
+	for _, name := range []string{MetricEffected, MetricUneffected} {
+		effected := name == MetricEffected
+		for _, s := range m.Series(name) {
+			l := s.Labels
+			if l["src_channel"] == "" || l["dst_channel"] == "" {
+				continue
+			}
+			key := l["chain_id"] + "/" + l["src_port"] + "/" + l["src_channel"] + "/" + l["dst_port"] + "/" + l["dst_channel"]
+			channel := channels[key]
+			if channel == nil {
+				channel = &ChannelMetrics{
+					ChainID:    l["chain_id"],
+					SrcChannel: l["src_channel"],
+					SrcPort:    l["src_port"],
+					DstChannel: l["dst_channel"],
+					DstPort:    l["dst_port"],
+				}
+				channels[key] = channel
+			}
+			var r *RelayerMetrics
+			if l["signer"] != "" {
+				r = relayer(l["signer"], l["memo"])
+			}
+			if effected {
+				channel.Effected += s.Value
+				if r != nil {
+					r.Effected += s.Value
+				}
+			} else {
+				channel.Uneffected += s.Value
+				if r != nil {
+					r.Uneffected += s.Value
+				}
+			}
+		}
+	}
+
+	for _, s := range m.Series(MetricFrontrun) {
+		l := s.Labels
+		m.Frontruns = append(m.Frontruns, FrontrunSeries{
+			ChainID:       l["chain_id"],
+			SrcChannel:    l["src_channel"],
+			DstChannel:    l["dst_channel"],
+			Signer:        l["signer"],
+			Memo:          l["memo"],
+			FrontrunnedBy: l["frontrunned_by"],
+			EffectedMemo:  l["effected_memo"],
+			Count:         s.Value,
+		})
+		if l["signer"] != "" {
+			relayer(l["signer"], l["memo"]).Frontrun += s.Value
+		}
+	}
+
+	for _, s := range m.Series(MetricStuckPackets) {
+		m.Stuck = append(m.Stuck, StuckChannel{
+			SrcChain:   s.Labels["src_chain"],
+			SrcChannel: s.Labels["src_channel"],
+			DstLabel:   s.Labels["dst_chain"],
+			Stuck:      s.Value,
+		})
+	}
+
+	for _, c := range chains {
+		m.Chains = append(m.Chains, *c)
+	}
+	sort.Slice(m.Chains, func(i, j int) bool { return m.Chains[i].ChainID < m.Chains[j].ChainID })
+
+	for _, c := range channels {
+		m.Channels = append(m.Channels, *c)
+	}
+	sort.Slice(m.Channels, func(i, j int) bool {
+		a, b := m.Channels[i], m.Channels[j]
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		if a.SrcChannel != b.SrcChannel {
+			return a.SrcChannel < b.SrcChannel
+		}
+		return a.DstChannel < b.DstChannel
+	})
+
+	for _, r := range relayers {
+		m.Relayers = append(m.Relayers, *r)
+	}
+	// Busiest relayers first
+	sort.Slice(m.Relayers, func(i, j int) bool {
+		if m.Relayers[i].Total() != m.Relayers[j].Total() {
+			return m.Relayers[i].Total() > m.Relayers[j].Total()
+		}
+		return m.Relayers[i].Signer < m.Relayers[j].Signer
+	})
+
+	sort.SliceStable(m.Frontruns, func(i, j int) bool { return m.Frontruns[i].Count > m.Frontruns[j].Count })
+	sort.SliceStable(m.Stuck, func(i, j int) bool { return m.Stuck[i].Stuck > m.Stuck[j].Stuck })
+}
+
+func labels(metric *dto.Metric) map[string]string {
+	result := make(map[string]string, len(metric.GetLabel()))
+	for _, pair := range metric.GetLabel() {
+		result[pair.GetName()] = pair.GetValue()
+	}
+	return result
+}
+
+func parseSoftware(memo string) (string, string) {
+	match := softwareVersion.FindStringSubmatch(memo)
+	if match == nil {
+		return "unknown", "unknown"
+	}
+	return strings.ToLower(match[1]), match[2]
+}
+
+// normalizeOpenMetrics rewrites the parts of an OpenMetrics exposition the
+// Prometheus text parser rejects: the # EOF marker, the unknown type and
+// the _created series of counters
+func normalizeOpenMetrics(r io.Reader) (io.Reader, error) {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "# EOF":
+			continue
+		case strings.HasPrefix(line, "# TYPE ") && strings.HasSuffix(line, " unknown"):
+			line = strings.TrimSuffix(line, "unknown") + "untyped"
+		case !strings.HasPrefix(line, "#") && isCreatedSample(line):
+			continue
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read metrics: %w", err)
+	}
+	return &out, nil
+}
+
+func isCreatedSample(line string) bool {
+	end := strings.IndexAny(line, "{ ")
+	if end < 0 {
+		return false
+	}
+	return strings.HasSuffix(line[:end], "_created")
+}
@@ -0,0 +1,146 @@
+package chainmetrics
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSnapshot(t *testing.T) *Metrics {
+	t.Helper()
+	f, err := os.Open("../../chainpulse-metrics-snapshot.txt")
+	require.NoError(t, err)
+	defer f.Close()
+
+	metrics, err := ParseMetrics(f)
+	require.NoError(t, err)
+	return metrics
+}
+
+func TestParseMetricsSnapshot(t *testing.T) {
+	metrics := loadSnapshot(t)
+
+	assert.Equal(t, 3, metrics.MonitoredChains)
+
+	chains := make(map[string]ChainMetrics)
+	for _, chain := range metrics.Chains {
+		chains[chain.ChainID] = chain
+	}
+	require.Len(t, chains, 3)
+	assert.Equal(t, float64(159), chains["osmosis-1"].Packets)
+	assert.Equal(t, float64(2746), chains["osmosis-1"].Txs)
+	assert.Equal(t, float64(10), chains["osmosis-1"].Reconnects)
+	assert.Equal(t, float64(14), chains["cosmoshub-4"].Errors)
+	assert.Equal(t, float64(19), chains["neutron-1"].Reconnects)
+
+	// Every effected and uneffected sample ends up in exactly one channel
+	// and, having a signer, in one relayer
+	var channelTotal, relayerTotal float64
+	for _, channel := range metrics.Channels {
+		channelTotal += channel.Total()
+		assert.NotEmpty(t, channel.SrcChannel)
+		assert.NotEmpty(t, channel.DstChannel)
+	}
+	for _, relayer := range metrics.Relayers {
+		relayerTotal += relayer.Total()
+	}
+	expected := metrics.Sum(MetricEffected) + metrics.Sum(MetricUneffected)
+	assert.Greater(t, expected, float64(0))
+	assert.Equal(t, expected, channelTotal)
+	assert.Equal(t, expected, relayerTotal)
+
+	require.NotEmpty(t, metrics.Relayers)
+	for i := 1; i < len(metrics.Relayers); i++ {
+		assert.GreaterOrEqual(t, metrics.Relayers[i-1].Total(), metrics.Relayers[i].Total(), "busiest first")
+	}
+
+	// Memos with unicode and punctuation come through whole
+	var icy *RelayerMetrics
+	for i, relayer := range metrics.Relayers {
+		if relayer.Signer == "osmo1p7d8mnjttcszv34pk2a5yyug3474mhff4twwa6" {
+			icy = &metrics.Relayers[i]
+		}
+	}
+	require.NotNil(t, icy)
+	assert.Contains(t, icy.Memo, "IcyCRO 🧊")
+	assert.Equal(t, "hermes", icy.Software)
+	assert.Equal(t, "1.9.0+a026d66", icy.Version)
+	assert.Greater(t, icy.Frontrun, float64(0))
+
+	assert.Len(t, metrics.Frontruns, 16)
+	assert.Equal(t, metrics.Sum(MetricFrontrun), sumFrontruns(metrics.Frontruns))
+
+	require.Len(t, metrics.Stuck, 77)
+	for i := 1; i < len(metrics.Stuck); i++ {
+		assert.GreaterOrEqual(t, metrics.Stuck[i-1].Stuck, metrics.Stuck[i].Stuck, "largest backlog first")
+	}
+	assert.Contains(t, metrics.Stuck, StuckChannel{SrcChain: "osmosis-1", SrcChannel: "channel-141", DstLabel: "channel-0", Stuck: 109})
+}
+
+func sumFrontruns(series []FrontrunSeries) float64 {
+	var total float64
+	for _, s := range series {
+		total += s.Count
+	}
+	return total
+}
+
+func TestParseMetricsEscapedLabels(t *testing.T) {
+	metrics, err := ParseMetricsString(`# TYPE ibc_effected_packets counter
+ibc_effected_packets{chain_id="osmosis-1",src_channel="channel-0",src_port="transfer",dst_channel="channel-1",dst_port="transfer",signer="osmo1a",memo="say \"hi\", {braces} \\ back\nslash"} 2
+ibc_effected_packets{chain_id="osmosis-1",src_channel="channel-0",src_port="transfer",dst_channel="channel-1",dst_port="transfer",signer="osmo1b",memo="rly v2.5.0"} 3
+`)
+	require.NoError(t, err)
+
+	require.Len(t, metrics.Channels, 1)
+	assert.Equal(t, float64(5), metrics.Channels[0].Effected)
+	assert.Equal(t, float64(100), metrics.Channels[0].SuccessRate())
+
+	require.Len(t, metrics.Relayers, 2)
+	assert.Equal(t, "osmo1b", metrics.Relayers[0].Signer)
+	assert.Equal(t, "rly", metrics.Relayers[0].Software)
+	assert.Equal(t, "2.5.0", metrics.Relayers[0].Version)
+	assert.Equal(t, "say \"hi\", {braces} \\ back\nslash", metrics.Relayers[1].Memo)
+}
+
+func TestParseMetricsHistogram(t *testing.T) {
+	metrics, err := ParseMetricsString(`# HELP relay_latency_seconds Time to relay
+# TYPE relay_latency_seconds histogram
+relay_latency_seconds_bucket{chain_id="osmosis-1",le="1"} 2
+relay_latency_seconds_bucket{chain_id="osmosis-1",le="10"} 5
+relay_latency_seconds_bucket{chain_id="osmosis-1",le="+Inf"} 6
+relay_latency_seconds_sum{chain_id="osmosis-1"} 31.5
+relay_latency_seconds_count{chain_id="osmosis-1"} 6
+`)
+	require.NoError(t, err)
+
+	histograms := metrics.Histograms("relay_latency_seconds")
+	require.Len(t, histograms, 1)
+	assert.Equal(t, "osmosis-1", histograms[0].Labels["chain_id"])
+	assert.Equal(t, uint64(6), histograms[0].Count)
+	assert.Equal(t, 31.5, histograms[0].Sum)
+	require.Len(t, histograms[0].Buckets, 3)
+	assert.Equal(t, uint64(5), histograms[0].Buckets[1].Count)
+}
+
+func TestParseMetricsOpenMetrics(t *testing.T) {
+	metrics, err := ParseMetricsString(`# TYPE chainpulse_packets counter
+chainpulse_packets_total{chain_id="osmosis-1"} 7
+chainpulse_packets_created{chain_id="osmosis-1"} 1.7e+09
+# TYPE chainpulse_chains unknown
+chainpulse_chains 1
+# EOF
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, metrics.MonitoredChains)
+	require.Len(t, metrics.Chains, 1)
+	assert.Equal(t, float64(7), metrics.Chains[0].Packets)
+}
+
+func TestParseMetricsInvalid(t *testing.T) {
+	_, err := ParseMetricsString("chainpulse_packets{chain_id=\"osmosis-1\" 7\n")
+	assert.Error(t, err)
+}
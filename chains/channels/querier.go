@@ -0,0 +1,114 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// errNotFound is returned by RESTQuerier.get for 404 responses
+var errNotFound = errors.New("not found")
+
+// RESTQuerier reads channels, connections and clients over each chain's
+// REST API
+type RESTQuerier struct {
+	client *endpoints.Client
+}
+
+// NewRESTQuerier creates a querier using the REST endpoints of the registry
+func NewRESTQuerier(registry *config.ChainRegistry) *RESTQuerier {
+	return &RESTQuerier{
+		client: endpoints.NewClient(registry, 10*time.Second),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (q *RESTQuerier) UseEndpoints(pools *endpoints.Pools) {
+	q.client.UsePools(pools)
+}
+
+// Channel implements Querier
+func (q *RESTQuerier) Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error) {
+	var body struct {
+		Channel struct {
+			State          string   `json:"state"`
+			ConnectionHops []string `json:"connection_hops"`
+			Counterparty   struct {
+				PortID    string `json:"port_id"`
+				ChannelID string `json:"channel_id"`
+			} `json:"counterparty"`
+		} `json:"channel"`
+	}
+	path := fmt.Sprintf("/ibc/core/channel/v1/channels/%s/ports/%s",
+		url.PathEscape(channelID), url.PathEscape(portID))
+	if err := q.get(ctx, chainID, path, &body); errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("%s/%s on %s: %w", portID, channelID, chainID, ErrChannelNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	return &ChannelEnd{
+		State:                 body.Channel.State,
+		ConnectionHops:        body.Channel.ConnectionHops,
+		CounterpartyPortID:    body.Channel.Counterparty.PortID,
+		CounterpartyChannelID: body.Channel.Counterparty.ChannelID,
+	}, nil
+}
+
+// ConnectionClient implements Querier
+func (q *RESTQuerier) ConnectionClient(ctx context.Context, chainID, connectionID string) (string, error) {
+	var body struct {
+		Connection struct {
+			ClientID string `json:"client_id"`
+		} `json:"connection"`
+	}
+	if err := q.get(ctx, chainID, "/ibc/core/connection/v1/connections/"+url.PathEscape(connectionID), &body); err != nil {
+		return "", err
+	}
+	if body.Connection.ClientID == "" {
+		return "", fmt.Errorf("connection %s on %s has no client", connectionID, chainID)
+	}
+	return body.Connection.ClientID, nil
+}
+
+// ClientChainID implements Querier
+func (q *RESTQuerier) ClientChainID(ctx context.Context, chainID, clientID string) (string, error) {
+	var body struct {
+		ClientState struct {
+			ChainID string `json:"chain_id"`
+		} `json:"client_state"`
+	}
+	if err := q.get(ctx, chainID, "/ibc/core/client/v1/client_states/"+url.PathEscape(clientID), &body); err != nil {
+		return "", err
+	}
+	if body.ClientState.ChainID == "" {
+		return "", fmt.Errorf("client %s on %s has no chain ID", clientID, chainID)
+	}
+	return body.ClientState.ChainID, nil
+}
+
+func (q *RESTQuerier) get(ctx context.Context, chainID, path string, out interface{}) error {
+	resp, err := q.client.Get(ctx, chainID, endpoints.REST, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("query %s on %s: %w", path, chainID, errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query %s on %s returned %d", path, chainID, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Resolver finds the chain at the other end of a channel from on-chain
+// state. Results are kept in memory and in the store for a TTL. Once the
+// TTL passes only the channel is queried again, and its connection and
+// client are followed again only if the channel changed.
+type Resolver struct {
+	querier Querier
+	store   Store
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu    sync.RWMutex
+	cache map[string]Counterparty
+
+	logger *zap.Logger
+}
+
+// NewResolver creates a counterparty resolver keeping results for ttl. They
+// are only kept in memory until a store is set.
+func NewResolver(querier Querier, ttl time.Duration, logger *zap.Logger) *Resolver {
+	return &Resolver{
+		querier: querier,
+		store:   newMemoryStore(),
+		ttl:     ttl,
+		now:     time.Now,
+		cache:   make(map[string]Counterparty),
+		logger:  logger.With(zap.String("component", "channel_counterparties")),
+	}
+}
+
+// UseStore keeps resolved counterparties in store, e.g. a database table or
+// a Redis hash, so they survive restarts
+func (r *Resolver) UseStore(store Store) {
+	r.store = store
+}
+
+// UseClock replaces the clock TTLs are checked against
+func (r *Resolver) UseClock(now func() time.Time) {
+	r.now = now
+}
+
+// Resolve returns the counterparty of a channel, from the cache while it's
+// fresh. An empty port means the transfer port. If the chain can't be
+// queried a stale entry is returned rather than nothing.
+func (r *Resolver) Resolve(ctx context.Context, chainID, portID, channelID string) (*Counterparty, error) {
+	if portID == "" {
+		portID = DefaultPort
+	}
+
+	cached, ok := r.lookup(ctx, chainID, portID, channelID)
+	if ok && r.now().Before(cached.ExpiresAt) {
+		return &cached, nil
+	}
+
+	var previous *Counterparty
+	if ok {
+		previous = &cached
+	}
+	resolved, err := r.resolve(ctx, chainID, portID, channelID, previous)
+	if err != nil {
+		if ok && !errors.Is(err, ErrChannelNotFound) {
+			r.logger.Warn("Using stale channel counterparty",
+				zap.String("chain_id", chainID),
+				zap.String("channel_id", channelID),
+				zap.Error(err),
+			)
+			return &cached, nil
+		}
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// ResolveNow resolves a channel from on-chain state, bypassing the cache
+func (r *Resolver) ResolveNow(ctx context.Context, chainID, portID, channelID string) (*Counterparty, error) {
+	if portID == "" {
+		portID = DefaultPort
+	}
+	return r.resolve(ctx, chainID, portID, channelID, nil)
+}
+
+// CounterpartyChain returns the chain a transfer channel leads to, or ""
+// when it can't be resolved
+func (r *Resolver) CounterpartyChain(ctx context.Context, chainID, channelID string) string {
+	counterparty, err := r.Resolve(ctx, chainID, DefaultPort, channelID)
+	if err != nil {
+		r.logger.Debug("Failed to resolve channel counterparty",
+			zap.String("chain_id", chainID),
+			zap.String("channel_id", channelID),
+			zap.Error(err),
+		)
+		return ""
+	}
+	return counterparty.CounterpartyChainID
+}
+
+// List returns the stored counterparties, by chain, port and channel
+func (r *Resolver) List(ctx context.Context) ([]Counterparty, error) {
+	counterparties, err := r.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(counterparties, func(i, j int) bool {
+		a, b := counterparties[i], counterparties[j]
+		return key(a.ChainID, a.PortID, a.ChannelID) < key(b.ChainID, b.PortID, b.ChannelID)
+	})
+	return counterparties, nil
+}
+
+// Refresh checks every stored channel. Expired entries are resolved again,
+// and so are channels whose state, connection or counterparty channel has
+// changed since they were resolved. Channels no longer found are dropped.
+func (r *Resolver) Refresh(ctx context.Context) (*RefreshResult, error) {
+	stored, err := r.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load channel counterparties: %w", err)
+	}
+
+	result := &RefreshResult{At: r.now().UTC()}
+	for _, c := range stored {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		result.Checked++
+
+		end, err := r.querier.Channel(ctx, c.ChainID, c.PortID, c.ChannelID)
+		if errors.Is(err, ErrChannelNotFound) {
+			r.forget(ctx, c.ChainID, c.PortID, c.ChannelID)
+			result.Dropped++
+			continue
+		}
+		if err != nil {
+			result.Failed++
+			continue
+		}
+		switch {
+		case changed(c, end):
+			r.logger.Info("Channel changed, resolving counterparty again",
+				zap.String("chain_id", c.ChainID),
+				zap.String("channel_id", c.ChannelID),
+				zap.String("state", end.State),
+			)
+		case r.now().Before(c.ExpiresAt):
+			continue
+		}
+
+		c := c
+		if _, err := r.follow(ctx, c.ChainID, c.PortID, c.ChannelID, end, &c); err != nil {
+			r.logger.Debug("Failed to refresh channel counterparty",
+				zap.String("chain_id", c.ChainID),
+				zap.String("channel_id", c.ChannelID),
+				zap.Error(err),
+			)
+			result.Failed++
+			continue
+		}
+		result.Resolved++
+	}
+	return result, nil
+}
+
+// resolve queries a channel and follows it to its counterparty
+func (r *Resolver) resolve(ctx context.Context, chainID, portID, channelID string, previous *Counterparty) (*Counterparty, error) {
+	end, err := r.querier.Channel(ctx, chainID, portID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	return r.follow(ctx, chainID, portID, channelID, end, previous)
+}
+
+// follow resolves a channel's counterparty chain from its connection and
+// the connection's client, and stores the result. The connection and client
+// of previous are kept if the channel hasn't changed since.
+func (r *Resolver) follow(ctx context.Context, chainID, portID, channelID string, end *ChannelEnd, previous *Counterparty) (*Counterparty, error) {
+	if len(end.ConnectionHops) == 0 {
+		return nil, fmt.Errorf("%s/%s on %s: %w", portID, channelID, chainID, ErrNoConnection)
+	}
+
+	now := r.now().UTC()
+	counterparty := Counterparty{
+		ChainID:               chainID,
+		PortID:                portID,
+		ChannelID:             channelID,
+		State:                 end.State,
+		ConnectionID:          end.ConnectionHops[0],
+		CounterpartyPortID:    end.CounterpartyPortID,
+		CounterpartyChannelID: end.CounterpartyChannelID,
+		ResolvedAt:            now,
+		ExpiresAt:             now.Add(r.ttl),
+	}
+
+	if previous != nil && !changed(*previous, end) && previous.CounterpartyChainID != "" {
+		counterparty.ClientID = previous.ClientID
+		counterparty.CounterpartyChainID = previous.CounterpartyChainID
+	} else {
+		clientID, err := r.querier.ConnectionClient(ctx, chainID, counterparty.ConnectionID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve connection %s: %w", counterparty.ConnectionID, err)
+		}
+		counterpartyChainID, err := r.querier.ClientChainID(ctx, chainID, clientID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve client %s: %w", clientID, err)
+		}
+		counterparty.ClientID = clientID
+		counterparty.CounterpartyChainID = counterpartyChainID
+	}
+
+	if err := r.store.Save(ctx, &counterparty); err != nil {
+		// The result is still good for this process
+		r.logger.Warn("Failed to store channel counterparty", zap.Error(err))
+	}
+
+	r.mu.Lock()
+	r.cache[key(chainID, portID, channelID)] = counterparty
+	r.mu.Unlock()
+	return &counterparty, nil
+}
+
+// lookup returns the cached counterparty, loading it from the store after
+// a restart
+func (r *Resolver) lookup(ctx context.Context, chainID, portID, channelID string) (Counterparty, bool) {
+	k := key(chainID, portID, channelID)
+	r.mu.RLock()
+	cached, ok := r.cache[k]
+	r.mu.RUnlock()
+	if ok {
+		return cached, true
+	}
+
+	stored, err := r.store.Get(ctx, chainID, portID, channelID)
+	if err != nil {
+		r.logger.Warn("Failed to load channel counterparty", zap.String("channel", k), zap.Error(err))
+		return Counterparty{}, false
+	}
+	if stored == nil {
+		return Counterparty{}, false
+	}
+
+	r.mu.Lock()
+	r.cache[k] = *stored
+	r.mu.Unlock()
+	return *stored, true
+}
+
+func (r *Resolver) forget(ctx context.Context, chainID, portID, channelID string) {
+	r.mu.Lock()
+	delete(r.cache, key(chainID, portID, channelID))
+	r.mu.Unlock()
+
+	if err := r.store.Delete(ctx, chainID, portID, channelID); err != nil {
+		r.logger.Warn("Failed to drop channel counterparty", zap.Error(err))
+	}
+}
+
+// changed reports whether a channel no longer matches what it was resolved
+// from
+func changed(c Counterparty, end *ChannelEnd) bool {
+	connectionID := ""
+	if len(end.ConnectionHops) > 0 {
+		connectionID = end.ConnectionHops[0]
+	}
+	return end.State != c.State ||
+		connectionID != c.ConnectionID ||
+		end.CounterpartyChannelID != c.CounterpartyChannelID
+}
+
+func key(chainID, portID, channelID string) string {
+	return chainID + "/" + portID + "/" + channelID
+}
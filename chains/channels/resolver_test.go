@@ -0,0 +1,140 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/relayooor/chains/config"
+)
+
+var testNow = time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+
+// fakeQuerier serves channels of osmosis-1 and counts its queries
+type fakeQuerier struct {
+	channels    map[string]*ChannelEnd
+	err         error
+	queries     int
+	connections int
+}
+
+func (f *fakeQuerier) Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	end, ok := f.channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", channelID, ErrChannelNotFound)
+	}
+	copied := *end
+	return &copied, nil
+}
+
+func (f *fakeQuerier) ConnectionClient(ctx context.Context, chainID, connectionID string) (string, error) {
+	f.connections++
+	return "07-tendermint-" + connectionID, nil
+}
+
+func (f *fakeQuerier) ClientChainID(ctx context.Context, chainID, clientID string) (string, error) {
+	return map[string]string{
+		"07-tendermint-connection-1": "cosmoshub-4",
+		"07-tendermint-connection-2": "noble-1",
+	}[clientID], nil
+}
+
+func TestResolver(t *testing.T) {
+	querier := &fakeQuerier{channels: map[string]*ChannelEnd{
+		"channel-0":   {State: "STATE_OPEN", ConnectionHops: []string{"connection-1"}, CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-141"},
+		"channel-750": {State: "STATE_OPEN", ConnectionHops: []string{"connection-2"}, CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-1"},
+	}}
+	now := testNow
+	resolver := NewResolver(querier, time.Hour, zap.NewNop())
+	resolver.UseClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	counterparty, err := resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", counterparty.CounterpartyChainID)
+	assert.Equal(t, DefaultPort, counterparty.PortID)
+	assert.Equal(t, "cosmoshub-4", resolver.CounterpartyChain(ctx, "osmosis-1", "channel-0"))
+	assert.Equal(t, 1, querier.queries)
+
+	// Once expired only the channel is queried while it's unchanged
+	now = testNow.Add(2 * time.Hour)
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, 2, querier.queries)
+	assert.Equal(t, 1, querier.connections)
+
+	// A stale entry is better than nothing while the chain can't be reached
+	now = testNow.Add(4 * time.Hour)
+	querier.err = errors.New("connection refused")
+	assert.Equal(t, "cosmoshub-4", resolver.CounterpartyChain(ctx, "osmosis-1", "channel-0"))
+	querier.err = nil
+
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-750")
+	require.NoError(t, err)
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-9999")
+	assert.ErrorIs(t, err, ErrChannelNotFound)
+
+	// A refresh follows changed channels again and drops ones that are gone
+	now = testNow.Add(4*time.Hour + time.Minute)
+	querier.channels["channel-0"].State = "STATE_CLOSED"
+	delete(querier.channels, "channel-750")
+	result, err := resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 1, result.Resolved)
+	assert.Equal(t, 1, result.Dropped)
+	assert.Equal(t, 3, querier.connections)
+
+	stored, err := resolver.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "STATE_CLOSED", stored[0].State)
+
+	// Fresh unchanged channels are left alone
+	result, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Resolved)
+}
+
+func TestRESTQuerier(t *testing.T) {
+	lcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer":
+			fmt.Fprint(w, `{"channel":{"state":"STATE_OPEN","connection_hops":["connection-1"],"counterparty":{"port_id":"transfer","channel_id":"channel-141"}}}`)
+		case "/ibc/core/connection/v1/connections/connection-1":
+			fmt.Fprint(w, `{"connection":{"client_id":"07-tendermint-1"}}`)
+		case "/ibc/core/client/v1/client_states/07-tendermint-1":
+			fmt.Fprint(w, `{"client_state":{"@type":"/ibc.lightclients.tendermint.v1.ClientState","chain_id":"cosmoshub-4"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer lcd.Close()
+
+	querier := NewRESTQuerier(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"osmosis-1": {ChainID: "osmosis-1", RESTEndpoint: lcd.URL},
+	}})
+	resolver := NewResolver(querier, time.Hour, zap.NewNop())
+	ctx := context.Background()
+
+	counterparty, err := resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", counterparty.CounterpartyChainID)
+	assert.Equal(t, "07-tendermint-1", counterparty.ClientID)
+	assert.Equal(t, "channel-141", counterparty.CounterpartyChannelID)
+
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-9999")
+	assert.ErrorIs(t, err, ErrChannelNotFound)
+}
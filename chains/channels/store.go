@@ -0,0 +1,54 @@
+package channels
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryStore keeps counterparties for the life of the process
+type memoryStore struct {
+	mu             sync.RWMutex
+	counterparties map[string]Counterparty
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counterparties: make(map[string]Counterparty)}
+}
+
+// Get implements Store
+func (s *memoryStore) Get(ctx context.Context, chainID, portID, channelID string) (*Counterparty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.counterparties[key(chainID, portID, channelID)]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// List implements Store
+func (s *memoryStore) List(ctx context.Context) ([]Counterparty, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counterparties := make([]Counterparty, 0, len(s.counterparties))
+	for _, c := range s.counterparties {
+		counterparties = append(counterparties, c)
+	}
+	return counterparties, nil
+}
+
+// Save implements Store
+func (s *memoryStore) Save(ctx context.Context, counterparty *Counterparty) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counterparties[key(counterparty.ChainID, counterparty.PortID, counterparty.ChannelID)] = *counterparty
+	return nil
+}
+
+// Delete implements Store
+func (s *memoryStore) Delete(ctx context.Context, chainID, portID, channelID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counterparties, key(chainID, portID, channelID))
+	return nil
+}
@@ -0,0 +1,77 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultPort is the port channels are resolved on when none is given
+const DefaultPort = "transfer"
+
+var (
+	ErrChannelNotFound = errors.New("channel not found")
+	ErrNoConnection    = errors.New("channel has no connection")
+)
+
+// Counterparty is where a channel leads, found by following the channel to
+// its connection and the connection to the light client of the other chain.
+// The gorm tags let stores keep it in a database table.
+type Counterparty struct {
+	ID                    uint      `gorm:"primaryKey" json:"-"`
+	ChainID               string    `gorm:"uniqueIndex:idx_channel_counterparties_channel" json:"chain_id"`
+	PortID                string    `gorm:"uniqueIndex:idx_channel_counterparties_channel" json:"port_id"`
+	ChannelID             string    `gorm:"uniqueIndex:idx_channel_counterparties_channel" json:"channel_id"`
+	State                 string    `json:"state"`
+	ConnectionID          string    `json:"connection_id"`
+	ClientID              string    `json:"client_id"`
+	CounterpartyChainID   string    `json:"counterparty_chain_id"`
+	CounterpartyPortID    string    `json:"counterparty_port_id"`
+	CounterpartyChannelID string    `json:"counterparty_channel_id"`
+	ResolvedAt            time.Time `json:"resolved_at"`
+	ExpiresAt             time.Time `gorm:"index" json:"expires_at"`
+}
+
+// TableName sets the table name
+func (Counterparty) TableName() string {
+	return "channel_counterparties"
+}
+
+// ChannelEnd is a channel as its own chain stores it
+type ChannelEnd struct {
+	State                 string
+	ConnectionHops        []string
+	CounterpartyPortID    string
+	CounterpartyChannelID string
+}
+
+// Querier reads the channel, connection and client state a counterparty is
+// resolved from
+type Querier interface {
+	Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error)
+	// ConnectionClient returns the client a connection is built on
+	ConnectionClient(ctx context.Context, chainID, connectionID string) (string, error)
+	// ClientChainID returns the chain a light client tracks
+	ClientChainID(ctx context.Context, chainID, clientID string) (string, error)
+}
+
+// Store keeps resolved counterparties so they survive restarts, e.g. in a
+// database table or a Redis hash
+type Store interface {
+	// Get returns a stored counterparty, or nil if there is none
+	Get(ctx context.Context, chainID, portID, channelID string) (*Counterparty, error)
+	List(ctx context.Context) ([]Counterparty, error)
+	// Save adds or replaces the counterparty of a channel
+	Save(ctx context.Context, counterparty *Counterparty) error
+	Delete(ctx context.Context, chainID, portID, channelID string) error
+}
+
+// RefreshResult summarises one refresh of the stored counterparties
+type RefreshResult struct {
+	Checked  int `json:"checked"`
+	Resolved int `json:"resolved"`
+	// Dropped counts channels no longer found on their chain
+	Dropped int       `json:"dropped"`
+	Failed  int       `json:"failed"`
+	At      time.Time `json:"at"`
+}
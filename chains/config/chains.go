@@ -0,0 +1,181 @@
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// ChainConfig represents the configuration for a blockchain
+type ChainConfig struct {
+	ChainID       string            `json:"chain_id"`
+	ChainName     string            `json:"chain_name"`
+	AddressPrefix string            `json:"address_prefix"`
+	RPCEndpoint   string            `json:"rpc_endpoint,omitempty"`
+	RESTEndpoint  string            `json:"rest_endpoint,omitempty"`
+	WSEndpoint    string            `json:"ws_endpoint,omitempty"`
+	GRPCEndpoint  string            `json:"grpc_endpoint,omitempty"`
+	FeeDenom      string            `json:"fee_denom,omitempty"`
+	GasPrice      float64           `json:"gas_price,omitempty"`
+	Explorer      string            `json:"explorer"`
+	Logo          string            `json:"logo,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+
+	// nodes are the chain's nodes.toml endpoints. They can carry
+	// credentials, so they're kept out of the fields the registry is served
+	// with and only reach callers through Endpoints.
+	nodes Endpoints
+}
+
+// Endpoints are the addresses a chain is reached at
+type Endpoints struct {
+	RPC  string
+	REST string
+	WS   string
+	GRPC string
+}
+
+// Endpoints returns where to query the chain or point a relayer at: the
+// registry's endpoints, with nodes.toml's filling in the ones it doesn't
+// list. The result mustn't be served as is.
+func (c ChainConfig) Endpoints() Endpoints {
+	endpoints := Endpoints{RPC: c.RPCEndpoint, REST: c.RESTEndpoint, WS: c.WSEndpoint, GRPC: c.GRPCEndpoint}
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&endpoints.RPC, c.nodes.RPC)
+	fill(&endpoints.REST, c.nodes.REST)
+	fill(&endpoints.WS, c.nodes.WS)
+	fill(&endpoints.GRPC, c.nodes.GRPC)
+	return endpoints
+}
+
+// ChannelConfig represents an IBC channel configuration
+type ChannelConfig struct {
+	SourceChain   string `json:"source_chain"`
+	SourceChannel string `json:"source_channel"`
+	DestChain     string `json:"dest_chain"`
+	DestChannel   string `json:"dest_channel"`
+	SourcePort    string `json:"source_port"`
+	DestPort      string `json:"dest_port"`
+	Status        string `json:"status"`
+}
+
+// ChainRegistry provides chain and channel configurations. A registry can
+// be replaced in place while it's in use, so a reload reaches everything
+// holding it.
+type ChainRegistry struct {
+	Chains   map[string]ChainConfig `json:"chains"`
+	Channels []ChannelConfig        `json:"channels"`
+
+	mu     sync.RWMutex
+	status LoadStatus
+}
+
+// LoadStatus describes where a registry was loaded from
+type LoadStatus struct {
+	LoadedAt time.Time `json:"loaded_at"`
+	Sources  []string  `json:"sources"`
+	// Warnings are the entries skipped because they failed validation
+	Warnings []string `json:"warnings"`
+	// Error is the last failed reload; the registry keeps its previous
+	// contents
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *ChainRegistry
+)
+
+// DefaultChainRegistry returns the process-wide registry, loaded on first
+// use from the files named by OptionsFromEnv. A Watcher keeps it current.
+func DefaultChainRegistry() *ChainRegistry {
+	defaultOnce.Do(func() {
+		defaultRegistry = &ChainRegistry{Chains: map[string]ChainConfig{}}
+		loaded, err := LoadChainRegistry(OptionsFromEnv())
+		if err != nil {
+			defaultRegistry.status.Error = err.Error()
+			return
+		}
+		defaultRegistry.Replace(loaded)
+	})
+	return defaultRegistry
+}
+
+// Replace swaps in the contents and load status of another registry
+func (r *ChainRegistry) Replace(next *ChainRegistry) {
+	next.mu.RLock()
+	chains, channels, status := next.Chains, next.Channels, next.status
+	next.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Chains, r.Channels, r.status = chains, channels, status
+}
+
+// Status returns where the registry was loaded from
+func (r *ChainRegistry) Status() LoadStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.status
+}
+
+// setError records a failed reload
+func (r *ChainRegistry) setError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Error = err.Error()
+}
+
+// AllChains returns a copy of the chains
+func (r *ChainRegistry) AllChains() map[string]ChainConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chains := make(map[string]ChainConfig, len(r.Chains))
+	for id, chain := range r.Chains {
+		chains[id] = chain
+	}
+	return chains
+}
+
+// AllChannels returns a copy of the channels
+func (r *ChainRegistry) AllChannels() []ChannelConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]ChannelConfig(nil), r.Channels...)
+}
+
+// GetChainByID returns a chain configuration by chain ID
+func (r *ChainRegistry) GetChainByID(chainID string) (ChainConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	chain, exists := r.Chains[chainID]
+	return chain, exists
+}
+
+// GetChainByPrefix returns a chain configuration by address prefix
+func (r *ChainRegistry) GetChainByPrefix(prefix string) (ChainConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, chain := range r.Chains {
+		if chain.AddressPrefix == prefix {
+			return chain, true
+		}
+	}
+	return ChainConfig{}, false
+}
+
+// GetChannelPairs returns all channel pairs for a given chain
+func (r *ChainRegistry) GetChannelPairs(chainID string) []ChannelConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var channels []ChannelConfig
+	for _, ch := range r.Channels {
+		if ch.SourceChain == chainID || ch.DestChain == chainID {
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
@@ -0,0 +1,602 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoChains is returned when a load finds no valid chains, so a broken
+// checkout never replaces a working registry
+var ErrNoChains = errors.New("chain registry has no valid chains")
+
+var (
+	chainIDPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+	channelPattern = regexp.MustCompile(`^channel-(\d+)$`)
+	bech32Pattern  = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+)
+
+// LoadOptions names the files a registry is loaded from. Only Dir is
+// required.
+type LoadOptions struct {
+	// Dir is a chain registry checkout: <chain>/chain.json,
+	// <chain>/assetlist.json and _IBC/<chain>-<chain>.json
+	Dir string
+	// NodesPath is a nodes.toml with private endpoints
+	NodesPath string
+	// OverridesPath is a JSON file in the shape of a ChainRegistry whose
+	// non-empty fields replace the loaded ones
+	OverridesPath string
+}
+
+// OptionsFromEnv reads CHAIN_REGISTRY_DIR, NODES_CONFIG_PATH and
+// CHAIN_REGISTRY_OVERRIDES. The registry defaults to config/chain-registry,
+// looked for in the working directory and its parents so tests and local
+// runs find the repo's copy.
+func OptionsFromEnv() LoadOptions {
+	opts := LoadOptions{
+		Dir:           os.Getenv("CHAIN_REGISTRY_DIR"),
+		NodesPath:     os.Getenv("NODES_CONFIG_PATH"),
+		OverridesPath: os.Getenv("CHAIN_REGISTRY_OVERRIDES"),
+	}
+	if opts.Dir == "" {
+		opts.Dir = findUp(filepath.Join("config", "chain-registry"))
+	}
+	if opts.NodesPath == "" {
+		opts.NodesPath = filepath.Join("config", "nodes.toml")
+	}
+	return opts
+}
+
+// findUp returns the first of path, ../path, ../../path and so on that
+// exists, or path itself
+func findUp(path string) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	for {
+		candidate := filepath.Join(dir, path)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return path
+		}
+		dir = parent
+	}
+}
+
+// registryChain is the part of a chain registry chain.json that's used
+type registryChain struct {
+	ChainName    string `json:"chain_name"`
+	Status       string `json:"status"`
+	NetworkType  string `json:"network_type"`
+	PrettyName   string `json:"pretty_name"`
+	ChainID      string `json:"chain_id"`
+	Bech32Prefix string `json:"bech32_prefix"`
+	Codebase     struct {
+		Consensus struct {
+			Version string `json:"version"`
+		} `json:"consensus"`
+	} `json:"codebase"`
+	Fees struct {
+		FeeTokens []struct {
+			Denom           string  `json:"denom"`
+			AverageGasPrice float64 `json:"average_gas_price"`
+		} `json:"fee_tokens"`
+	} `json:"fees"`
+	APIs struct {
+		RPC  []registryEndpoint `json:"rpc"`
+		REST []registryEndpoint `json:"rest"`
+		GRPC []registryEndpoint `json:"grpc"`
+	} `json:"apis"`
+	Explorers []struct {
+		Kind string `json:"kind"`
+		URL  string `json:"url"`
+	} `json:"explorers"`
+	LogoURIs struct {
+		PNG string `json:"png"`
+		SVG string `json:"svg"`
+	} `json:"logo_URIs"`
+}
+
+type registryEndpoint struct {
+	Address string `json:"address"`
+}
+
+type registryAssets struct {
+	Assets []struct {
+		Base       string `json:"base"`
+		Display    string `json:"display"`
+		Symbol     string `json:"symbol"`
+		DenomUnits []struct {
+			Denom    string `json:"denom"`
+			Exponent int    `json:"exponent"`
+		} `json:"denom_units"`
+	} `json:"assets"`
+}
+
+// registryIBC is an _IBC/<chain>-<chain>.json file
+type registryIBC struct {
+	Chain1   registryIBCChain `json:"chain_1"`
+	Chain2   registryIBCChain `json:"chain_2"`
+	Channels []struct {
+		Chain1 registryIBCEnd `json:"chain_1"`
+		Chain2 registryIBCEnd `json:"chain_2"`
+		Tags   struct {
+			Status string `json:"status"`
+		} `json:"tags"`
+	} `json:"channels"`
+}
+
+type registryIBCChain struct {
+	ChainName string `json:"chain_name"`
+}
+
+type registryIBCEnd struct {
+	ChannelID string `json:"channel_id"`
+	PortID    string `json:"port_id"`
+}
+
+// loader accumulates a registry and the problems found building it
+type loader struct {
+	registry *ChainRegistry
+	// names maps chain registry directory names to chain IDs
+	names    map[string]string
+	warnings []string
+}
+
+func (l *loader) warn(format string, args ...interface{}) {
+	l.warnings = append(l.warnings, fmt.Sprintf(format, args...))
+}
+
+// LoadChainRegistry builds a registry from a chain registry checkout,
+// nodes.toml and an overrides file. Entries that fail validation are skipped
+// and reported in the registry's status warnings; only a missing directory,
+// an unreadable nodes.toml or overrides file, or an empty result are errors.
+func LoadChainRegistry(opts LoadOptions) (*ChainRegistry, error) {
+	l := &loader{
+		registry: &ChainRegistry{Chains: make(map[string]ChainConfig)},
+		names:    make(map[string]string),
+	}
+	sources := []string{opts.Dir}
+
+	if err := l.loadChains(opts.Dir); err != nil {
+		return nil, err
+	}
+	if err := l.loadChannels(opts.Dir); err != nil {
+		return nil, err
+	}
+
+	if opts.NodesPath != "" {
+		nodes, err := LoadNodes(opts.NodesPath)
+		switch {
+		case err == nil:
+			l.mergeNodes(nodes)
+			sources = append(sources, opts.NodesPath)
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	if opts.OverridesPath != "" {
+		if err := l.mergeOverrides(opts.OverridesPath); err != nil {
+			return nil, err
+		}
+		sources = append(sources, opts.OverridesPath)
+	}
+
+	if len(l.registry.Chains) == 0 {
+		return nil, fmt.Errorf("%s: %w", opts.Dir, ErrNoChains)
+	}
+	sortChannels(l.registry.Channels)
+	l.registry.status = LoadStatus{
+		LoadedAt: time.Now().UTC(),
+		Sources:  sources,
+		Warnings: l.warnings,
+	}
+	return l.registry, nil
+}
+
+// loadChains reads every <dir>/<chain>/chain.json and its asset list
+func (l *loader) loadChains(dir string) error {
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "chain.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		name := filepath.Base(filepath.Dir(path))
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		var raw registryChain
+		if err := readJSON(path, &raw); err != nil {
+			l.warn("%v", err)
+			continue
+		}
+		chain := chainFromRegistry(raw)
+		if err := validateChain(chain); err != nil {
+			l.warn("%s: %v", path, err)
+			continue
+		}
+		if !bech32Pattern.MatchString(chain.AddressPrefix) {
+			l.warn("%s: invalid bech32_prefix %q", path, chain.AddressPrefix)
+			continue
+		}
+		if _, exists := l.registry.Chains[chain.ChainID]; exists {
+			l.warn("%s: duplicate chain_id %s", path, chain.ChainID)
+			continue
+		}
+
+		var assets registryAssets
+		assetPath := filepath.Join(filepath.Dir(path), "assetlist.json")
+		if err := readJSON(assetPath, &assets); err == nil {
+			addFeeAsset(&chain, assets)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			l.warn("%v", err)
+		}
+
+		l.registry.Chains[chain.ChainID] = chain
+		l.names[name] = chain.ChainID
+		if raw.ChainName != "" {
+			l.names[raw.ChainName] = chain.ChainID
+		}
+	}
+	return nil
+}
+
+// chainFromRegistry takes the first endpoint of each kind; the registry
+// lists them in order of preference
+func chainFromRegistry(raw registryChain) ChainConfig {
+	chain := ChainConfig{
+		ChainID:       raw.ChainID,
+		ChainName:     raw.PrettyName,
+		AddressPrefix: raw.Bech32Prefix,
+		Logo:          raw.LogoURIs.SVG,
+		Metadata:      map[string]string{},
+	}
+	if chain.ChainName == "" {
+		chain.ChainName = raw.ChainName
+	}
+	if chain.Logo == "" {
+		chain.Logo = raw.LogoURIs.PNG
+	}
+	if len(raw.APIs.RPC) > 0 {
+		chain.RPCEndpoint = raw.APIs.RPC[0].Address
+		chain.WSEndpoint = websocketEndpoint(chain.RPCEndpoint)
+	}
+	if len(raw.APIs.REST) > 0 {
+		chain.RESTEndpoint = raw.APIs.REST[0].Address
+	}
+	if len(raw.APIs.GRPC) > 0 {
+		chain.GRPCEndpoint = raw.APIs.GRPC[0].Address
+	}
+	if len(raw.Fees.FeeTokens) > 0 {
+		chain.FeeDenom = raw.Fees.FeeTokens[0].Denom
+		chain.GasPrice = raw.Fees.FeeTokens[0].AverageGasPrice
+	}
+	if len(raw.Explorers) > 0 && raw.Explorers[0].URL != "" {
+		chain.Explorer = strings.TrimSuffix(raw.Explorers[0].URL, "/") + "/txs"
+	}
+	if raw.Status != "" {
+		chain.Metadata["status"] = raw.Status
+	}
+	if raw.NetworkType != "" {
+		chain.Metadata["network_type"] = raw.NetworkType
+	}
+	if raw.Codebase.Consensus.Version != "" {
+		chain.Metadata["comet_version"] = raw.Codebase.Consensus.Version
+	}
+	return chain
+}
+
+// websocketEndpoint is the CometBFT websocket of an RPC endpoint
+func websocketEndpoint(rpc string) string {
+	u, err := url.Parse(rpc)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+		u.Host = strings.TrimSuffix(u.Host, ":443")
+	case "http":
+		u.Scheme = "ws"
+		u.Host = strings.TrimSuffix(u.Host, ":80")
+	default:
+		return ""
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/websocket"
+	return u.String()
+}
+
+// addFeeAsset records the symbol and decimals of the fee denom
+func addFeeAsset(chain *ChainConfig, assets registryAssets) {
+	for _, asset := range assets.Assets {
+		if asset.Base != chain.FeeDenom {
+			continue
+		}
+		if asset.Symbol != "" {
+			chain.Metadata["fee_symbol"] = asset.Symbol
+		}
+		for _, unit := range asset.DenomUnits {
+			if unit.Denom == asset.Display {
+				chain.Metadata["fee_decimals"] = strconv.Itoa(unit.Exponent)
+			}
+		}
+		return
+	}
+}
+
+// loadChannels reads <dir>/_IBC/*.json. Both ends of a channel become
+// entries, so lookups work from either chain.
+func (l *loader) loadChannels(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "_IBC", "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		var raw registryIBC
+		if err := readJSON(path, &raw); err != nil {
+			l.warn("%v", err)
+			continue
+		}
+		chain1, ok1 := l.names[raw.Chain1.ChainName]
+		chain2, ok2 := l.names[raw.Chain2.ChainName]
+		if !ok1 || !ok2 {
+			l.warn("%s: unknown chain %s or %s", path, raw.Chain1.ChainName, raw.Chain2.ChainName)
+			continue
+		}
+
+		for _, ch := range raw.Channels {
+			// The registry tags usable channels "live"
+			status := ch.Tags.Status
+			if status == "" || status == "live" {
+				status = "active"
+			}
+			forward := ChannelConfig{
+				SourceChain:   chain1,
+				SourceChannel: ch.Chain1.ChannelID,
+				SourcePort:    ch.Chain1.PortID,
+				DestChain:     chain2,
+				DestChannel:   ch.Chain2.ChannelID,
+				DestPort:      ch.Chain2.PortID,
+				Status:        status,
+			}
+			if err := validateChannel(forward, l.registry.Chains); err != nil {
+				l.warn("%s: %v", path, err)
+				continue
+			}
+			l.registry.Channels = append(l.registry.Channels, forward, reverse(forward))
+		}
+	}
+	return nil
+}
+
+func reverse(ch ChannelConfig) ChannelConfig {
+	return ChannelConfig{
+		SourceChain:   ch.DestChain,
+		SourceChannel: ch.DestChannel,
+		SourcePort:    ch.DestPort,
+		DestChain:     ch.SourceChain,
+		DestChannel:   ch.SourceChannel,
+		DestPort:      ch.SourcePort,
+		Status:        ch.Status,
+	}
+}
+
+// mergeNodes adds the chains only nodes.toml knows about and records each
+// chain's nodes.toml endpoints. They're kept apart from the registry's:
+// nodes.toml endpoints can carry credentials, in the path as well as the
+// userinfo or query string, so they're used to query chains and generate
+// relayer config but never served.
+func (l *loader) mergeNodes(nodes map[string]Node) {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		node := nodes[id]
+		if !node.Usable() {
+			continue
+		}
+		chain, exists := l.registry.Chains[id]
+		if !exists {
+			chain = ChainConfig{ChainID: id, ChainName: node.Name, Metadata: map[string]string{}}
+		}
+		chain.nodes = Endpoints{RPC: node.RPC, REST: node.API, WS: node.WS, GRPC: node.GRPC}
+		if node.CometVersion != "" {
+			chain.Metadata["comet_version"] = node.CometVersion
+		}
+		if err := validateChain(chain); err != nil {
+			l.warn("nodes.toml %s: %v", id, err)
+			continue
+		}
+		l.registry.Chains[id] = chain
+	}
+}
+
+// mergeOverrides applies the non-empty fields of an overrides file. A
+// channel replaces the loaded one with the same source chain and channel.
+func (l *loader) mergeOverrides(path string) error {
+	var overrides ChainRegistry
+	if err := readJSON(path, &overrides); err != nil {
+		return err
+	}
+
+	for id, override := range overrides.Chains {
+		if override.ChainID == "" {
+			override.ChainID = id
+		}
+		chain := mergeChain(l.registry.Chains[id], override)
+		if err := validateChain(chain); err != nil {
+			l.warn("%s: %s: %v", path, id, err)
+			continue
+		}
+		l.registry.Chains[id] = chain
+	}
+
+	for _, override := range overrides.Channels {
+		if override.Status == "" {
+			override.Status = "active"
+		}
+		if err := validateChannel(override, l.registry.Chains); err != nil {
+			l.warn("%s: %v", path, err)
+			continue
+		}
+		replaced := false
+		for i, ch := range l.registry.Channels {
+			if ch.SourceChain == override.SourceChain && ch.SourceChannel == override.SourceChannel {
+				l.registry.Channels[i] = override
+				replaced = true
+			}
+		}
+		if !replaced {
+			l.registry.Channels = append(l.registry.Channels, override)
+		}
+	}
+	return nil
+}
+
+func mergeChain(chain, override ChainConfig) ChainConfig {
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	set(&chain.ChainID, override.ChainID)
+	set(&chain.ChainName, override.ChainName)
+	set(&chain.AddressPrefix, override.AddressPrefix)
+	set(&chain.RPCEndpoint, override.RPCEndpoint)
+	set(&chain.RESTEndpoint, override.RESTEndpoint)
+	set(&chain.WSEndpoint, override.WSEndpoint)
+	set(&chain.GRPCEndpoint, override.GRPCEndpoint)
+	set(&chain.FeeDenom, override.FeeDenom)
+	set(&chain.Explorer, override.Explorer)
+	set(&chain.Logo, override.Logo)
+	if override.GasPrice != 0 {
+		chain.GasPrice = override.GasPrice
+	}
+	if len(override.Metadata) > 0 {
+		metadata := make(map[string]string, len(chain.Metadata)+len(override.Metadata))
+		for k, v := range chain.Metadata {
+			metadata[k] = v
+		}
+		for k, v := range override.Metadata {
+			metadata[k] = v
+		}
+		chain.Metadata = metadata
+	}
+	return chain
+}
+
+func validateChain(chain ChainConfig) error {
+	if !chainIDPattern.MatchString(chain.ChainID) {
+		return fmt.Errorf("invalid chain_id %q", chain.ChainID)
+	}
+	public := Endpoints{RPC: chain.RPCEndpoint, REST: chain.RESTEndpoint, WS: chain.WSEndpoint, GRPC: chain.GRPCEndpoint}
+	for _, endpoints := range []Endpoints{public, chain.nodes} {
+		if err := validateEndpoints(endpoints); err != nil {
+			return err
+		}
+	}
+	if chain.GasPrice < 0 {
+		return fmt.Errorf("negative gas price %v", chain.GasPrice)
+	}
+	return nil
+}
+
+func validateEndpoints(endpoints Endpoints) error {
+	for name, endpoint := range map[string]string{
+		"rpc":  endpoints.RPC,
+		"rest": endpoints.REST,
+	} {
+		if endpoint == "" {
+			continue
+		}
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s endpoint %q", name, endpoint)
+		}
+	}
+	if endpoints.WS != "" {
+		if u, err := url.Parse(endpoints.WS); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			return fmt.Errorf("invalid websocket endpoint %q", endpoints.WS)
+		}
+	}
+	if endpoints.GRPC != "" && !validGRPC(endpoints.GRPC) {
+		return fmt.Errorf("invalid grpc endpoint %q", endpoints.GRPC)
+	}
+	return nil
+}
+
+// validGRPC accepts host:port, as the chain registry lists gRPC endpoints,
+// or a URL with a host
+func validGRPC(endpoint string) bool {
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		return err == nil && u.Host != ""
+	}
+	host, port, err := net.SplitHostPort(endpoint)
+	return err == nil && host != "" && port != ""
+}
+
+func validateChannel(ch ChannelConfig, chains map[string]ChainConfig) error {
+	for _, id := range []string{ch.SourceChain, ch.DestChain} {
+		if _, ok := chains[id]; !ok {
+			return fmt.Errorf("channel %s: unknown chain %q", ch.SourceChannel, id)
+		}
+	}
+	for _, id := range []string{ch.SourceChannel, ch.DestChannel} {
+		if !channelPattern.MatchString(id) {
+			return fmt.Errorf("invalid channel id %q", id)
+		}
+	}
+	if ch.SourcePort == "" || ch.DestPort == "" {
+		return fmt.Errorf("channel %s: missing port", ch.SourceChannel)
+	}
+	return nil
+}
+
+// sortChannels orders channels by source chain and channel number
+func sortChannels(channels []ChannelConfig) {
+	number := func(id string) int {
+		n, _ := strconv.Atoi(strings.TrimPrefix(id, "channel-"))
+		return n
+	}
+	sort.SliceStable(channels, func(i, j int) bool {
+		if channels[i].SourceChain != channels[j].SourceChain {
+			return channels[i].SourceChain < channels[j].SourceChain
+		}
+		return number(channels[i].SourceChannel) < number(channels[j].SourceChannel)
+	})
+}
+
+func readJSON(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Node is a private endpoint set for one chain from nodes.toml
+type Node struct {
+	Name         string `toml:"name"`
+	RPC          string `toml:"rpc"`
+	API          string `toml:"api"`
+	GRPC         string `toml:"grpc"`
+	WS           string `toml:"ws"`
+	BackupRPC    string `toml:"backup_rpc"`
+	CometVersion string `toml:"comet_version"`
+	Healthy      *bool  `toml:"healthy"`
+	Active       *bool  `toml:"active"`
+}
+
+// Usable reports whether the node has an RPC endpoint and isn't marked inactive
+func (n Node) Usable() bool {
+	return n.RPC != "" && (n.Active == nil || *n.Active)
+}
+
+// LoadNodes reads the private endpoints in a nodes.toml file, keyed by chain ID
+func LoadNodes(path string) (map[string]Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Chains map[string]Node `toml:"chains"`
+	}
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if file.Chains == nil {
+		file.Chains = map[string]Node{}
+	}
+	return file.Chains, nil
+}
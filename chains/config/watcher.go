@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Watcher reloads a registry when its files change. Files are polled rather
+// than watched with inotify so it works the same on bind mounts and
+// ConfigMaps, which replace files through symlink swaps.
+type Watcher struct {
+	registry *ChainRegistry
+	opts     LoadOptions
+	interval time.Duration
+	logger   *zap.Logger
+
+	fingerprint string
+}
+
+// NewWatcher creates a watcher for the files in opts. The interval is
+// CHAIN_REGISTRY_RELOAD_INTERVAL, 30s by default.
+func NewWatcher(registry *ChainRegistry, opts LoadOptions, logger *zap.Logger) *Watcher {
+	w := &Watcher{
+		registry: registry,
+		opts:     opts,
+		interval: 30 * time.Second,
+		logger:   logger.With(zap.String("component", "chain_registry")),
+	}
+	if value, err := time.ParseDuration(os.Getenv("CHAIN_REGISTRY_RELOAD_INTERVAL")); err == nil && value > 0 {
+		w.interval = value
+	}
+	w.fingerprint, _ = fingerprint(opts)
+	return w
+}
+
+// Start polls the files until ctx is done
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := w.Check(); err != nil {
+					w.logger.Warn("Chain registry reload failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Check reloads the registry if its files changed since the last check and
+// reports whether it did. A failed load leaves the registry as it was.
+func (w *Watcher) Check() (bool, error) {
+	current, err := fingerprint(w.opts)
+	if err != nil {
+		return false, err
+	}
+	// A registry whose last load failed is retried even when unchanged
+	if current == w.fingerprint && w.registry.Status().Error == "" {
+		return false, nil
+	}
+	w.fingerprint = current
+
+	next, err := LoadChainRegistry(w.opts)
+	if err != nil {
+		w.registry.setError(err)
+		return false, err
+	}
+	w.registry.Replace(next)
+
+	status := next.Status()
+	w.logger.Info("Reloaded chain registry",
+		zap.Int("chains", len(next.Chains)),
+		zap.Int("channels", len(next.Channels)),
+		zap.Int("warnings", len(status.Warnings)))
+	for _, warning := range status.Warnings {
+		w.logger.Warn("Skipped chain registry entry", zap.String("reason", warning))
+	}
+	return true, nil
+}
+
+// fingerprint hashes the names, sizes and modification times of the files a
+// registry is loaded from
+func fingerprint(opts LoadOptions) (string, error) {
+	var paths []string
+	for _, pattern := range []string{
+		filepath.Join(opts.Dir, "*", "chain.json"),
+		filepath.Join(opts.Dir, "*", "assetlist.json"),
+		filepath.Join(opts.Dir, "_IBC", "*.json"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", err
+		}
+		paths = append(paths, matches...)
+	}
+	for _, path := range []string{opts.NodesPath, opts.OverridesPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	hash := sha256.New()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
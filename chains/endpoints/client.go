@@ -0,0 +1,59 @@
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/relayooor/chains/config"
+)
+
+// Client sends queries for a chain to its endpoint pool, or to the chain
+// registry's endpoint until pools are set
+type Client struct {
+	registry *config.ChainRegistry
+	pools    *Pools
+	client   *http.Client
+}
+
+// NewClient creates a client querying the endpoints of the registry, each
+// query timing out after timeout
+func NewClient(registry *config.ChainRegistry, timeout time.Duration) *Client {
+	return &Client{
+		registry: registry,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// UsePools sends queries to the best endpoint of each chain's pool, failing
+// over between them, instead of the registry's endpoint
+func (c *Client) UsePools(pools *Pools) {
+	c.pools = pools
+}
+
+// Get sends a GET for path, which may carry a query string, to a chain's
+// endpoint for the API. The caller closes the response body.
+func (c *Client) Get(ctx context.Context, chainID string, api API, path string) (*http.Response, error) {
+	if c.pools != nil {
+		return c.pools.Get(ctx, chainID, api, path)
+	}
+
+	chain, ok := c.registry.GetChainByID(chainID)
+	endpoint := chain.Endpoints().REST
+	if api == RPC {
+		endpoint = chain.Endpoints().RPC
+	}
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("%w for %s on %s", ErrNoEndpoint, api, chainID)
+	}
+	address, err := requestURL(endpoint, path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,611 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/relayooor/chains/config"
+)
+
+// sourceOrder breaks ties between equally good endpoints: the operator's
+// private nodes first, then the public registry endpoint, then backups
+var sourceOrder = map[string]int{SourceNodes: 0, SourceRegistry: 1, SourceBackup: 2}
+
+// latencyBucket is the latency difference in milliseconds below which
+// endpoints rank by source instead
+const latencyBucket = 50
+
+// Pools keeps a ranked pool of endpoints for every chain. Endpoints come
+// from the chain registry and nodes.toml, including its backup_rpc entries.
+// Each is probed through CometBFT /status for latency, height and version,
+// and queries go to the best endpoint, failing over down the ranking.
+type Pools struct {
+	registry  *config.ChainRegistry
+	nodesPath string
+	client    *http.Client
+	interval  time.Duration
+	// maxLag is how many blocks behind an endpoint can be and still rank
+	// with the fully synced ones
+	maxLag int64
+	// maxFailures is how many queries in a row can fail before an endpoint
+	// is marked unhealthy until its next successful probe
+	maxFailures int
+	metrics     Metrics
+	logger      *zap.Logger
+	now         func() time.Time
+
+	mu     sync.RWMutex
+	chains map[string]*pool
+}
+
+// pool is the endpoints of one chain in rank order
+type pool struct {
+	version   string
+	endpoints []*Endpoint
+}
+
+// NewPools creates endpoint pools for the chains of a registry and the
+// nodes.toml at nodesPath. The probe interval is ENDPOINT_PROBE_INTERVAL,
+// 30s by default; ENDPOINT_MAX_LAG (10 blocks) and ENDPOINT_MAX_FAILURES (3)
+// tune the ranking.
+func NewPools(registry *config.ChainRegistry, nodesPath string, logger *zap.Logger) *Pools {
+	p := &Pools{
+		registry:    registry,
+		nodesPath:   nodesPath,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		interval:    30 * time.Second,
+		maxLag:      10,
+		maxFailures: 3,
+		metrics:     noMetrics{},
+		logger:      logger.With(zap.String("component", "endpoint_pools")),
+		now:         time.Now,
+		chains:      make(map[string]*pool),
+	}
+	if value, err := time.ParseDuration(os.Getenv("ENDPOINT_PROBE_INTERVAL")); err == nil && value > 0 {
+		p.interval = value
+	}
+	if value, err := strconv.ParseInt(os.Getenv("ENDPOINT_MAX_LAG"), 10, 64); err == nil && value >= 0 {
+		p.maxLag = value
+	}
+	if value, err := strconv.Atoi(os.Getenv("ENDPOINT_MAX_FAILURES")); err == nil && value > 0 {
+		p.maxFailures = value
+	}
+	p.sync()
+	return p
+}
+
+// UseMetrics records endpoint health and failovers in metrics. Nothing is
+// recorded without it. Call it before Start.
+func (p *Pools) UseMetrics(metrics Metrics) {
+	p.metrics = metrics
+}
+
+// Start probes every endpoint immediately and then every interval until ctx
+// is done
+func (p *Pools) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.Probe(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Chains returns the pool of every chain, or of one when chainID is set
+func (p *Pools) Chains(chainID string) []Chain {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chains := make([]Chain, 0, len(p.chains))
+	for id, pl := range p.chains {
+		if chainID != "" && id != chainID {
+			continue
+		}
+		chain := Chain{ChainID: id, Version: pl.version, Endpoints: make([]Endpoint, len(pl.endpoints))}
+		for i, e := range pl.endpoints {
+			chain.Endpoints[i] = *e
+		}
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ChainID < chains[j].ChainID })
+	return chains
+}
+
+// Get sends a GET for path, which may carry a query string, to the best
+// endpoint of a chain. Connection errors, 5xx and 429 responses fail over to
+// the next endpoint; any other response is returned for the caller to
+// interpret, and the caller closes its body.
+func (p *Pools) Get(ctx context.Context, chainID string, api API, path string) (*http.Response, error) {
+	candidates := p.candidates(chainID, api)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w for %s on %s", ErrNoEndpoint, api, chainID)
+	}
+
+	var lastErr error
+	for i, candidate := range candidates {
+		if i > 0 {
+			p.metrics.Failover(chainID, api)
+		}
+
+		address, err := requestURL(candidate.url, path)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := p.client.Do(req)
+		err = sanitize(err)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			p.succeeded(candidate.endpoint)
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("returned %d", resp.StatusCode)
+		}
+		// A cancelled caller isn't the endpoint's fault
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		p.failed(chainID, candidate.endpoint, err)
+		lastErr = fmt.Errorf("%s: %w", Label(candidate.endpoint), err)
+	}
+	return nil, fmt.Errorf("%w for %s on %s: %v", ErrAllFailed, strings.SplitN(path, "?", 2)[0], chainID, lastErr)
+}
+
+// Status is the pools as served to operators. Endpoints from nodes.toml
+// are named by their label alone, as their URLs can carry credentials.
+func (p *Pools) Status(chainID string) Status {
+	status := Status{Chains: p.Chains(chainID), Endpoints: []Current{}}
+	for i := range status.Chains {
+		chain := &status.Chains[i]
+		found := false
+		for j := range chain.Endpoints {
+			e := &chain.Endpoints[j]
+			if e.Source == SourceRegistry {
+				e.RPC, e.REST = public(e.RPC), public(e.REST)
+			}
+			// Queries go to the first endpoint with a REST API
+			if !found && e.REST != "" {
+				current := Current{ChainID: chain.ChainID, Label: e.Label}
+				if e.Source == SourceRegistry {
+					current.RESTURL = e.REST
+				}
+				status.Endpoints = append(status.Endpoints, current)
+				found = true
+			}
+			if e.Source != SourceRegistry {
+				e.RPC, e.REST = "", ""
+			}
+		}
+	}
+	return status
+}
+
+type candidate struct {
+	endpoint *Endpoint
+	url      string
+}
+
+// candidates lists the endpoints of a chain with the API in rank order.
+// Unhealthy endpoints are kept last as a last resort.
+func (p *Pools) candidates(chainID string, api API) []candidate {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pl, ok := p.chains[chainID]
+	if !ok {
+		return nil
+	}
+	var candidates []candidate
+	for _, e := range pl.endpoints {
+		address := e.RPC
+		if api == REST {
+			address = e.REST
+		}
+		if address != "" {
+			candidates = append(candidates, candidate{endpoint: e, url: address})
+		}
+	}
+	return candidates
+}
+
+func (p *Pools) succeeded(e *Endpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.Failures = 0
+}
+
+func (p *Pools) failed(chainID string, e *Endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e.Failures++
+	e.LastError = err.Error()
+	if e.Healthy && e.Failures >= p.maxFailures {
+		e.Healthy = false
+		p.logger.Warn("Endpoint marked unhealthy after failed queries",
+			zap.String("chain_id", chainID),
+			zap.String("endpoint", Label(e)),
+			zap.Int("failures", e.Failures),
+			zap.Error(err))
+	}
+	if pl, ok := p.chains[chainID]; ok {
+		p.rank(pl)
+	}
+}
+
+// status is the part of a CometBFT /status response that's probed
+type status struct {
+	Result struct {
+		NodeInfo struct {
+			Version string `json:"version"`
+		} `json:"node_info"`
+		SyncInfo struct {
+			LatestBlockHeight string `json:"latest_block_height"`
+			CatchingUp        bool   `json:"catching_up"`
+		} `json:"sync_info"`
+	} `json:"result"`
+}
+
+type probe struct {
+	endpoint *Endpoint
+	rpc      string
+	version  string
+	height   int64
+	catching bool
+	latency  time.Duration
+	err      error
+}
+
+// Probe refreshes the endpoints from the registry and nodes.toml, probes
+// each RPC endpoint and re-ranks every pool
+func (p *Pools) Probe(ctx context.Context) []Chain {
+	p.sync()
+
+	p.mu.RLock()
+	var results []probe
+	for _, pl := range p.chains {
+		for _, e := range pl.endpoints {
+			if e.RPC != "" {
+				results = append(results, probe{endpoint: e, rpc: e.RPC})
+			}
+		}
+	}
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(r *probe) {
+			defer wg.Done()
+			p.probe(ctx, r)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	p.apply(results)
+	return p.Chains("")
+}
+
+// probe fills in a probe from the endpoint's /status
+func (p *Pools) probe(ctx context.Context, result *probe) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	start := p.now()
+	address, err := requestURL(result.rpc, "/status")
+	if err != nil {
+		result.err = err
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		result.err = err
+		return
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		result.err = sanitize(err)
+		return
+	}
+	defer resp.Body.Close()
+	result.latency = p.now().Sub(start)
+
+	if resp.StatusCode != http.StatusOK {
+		result.err = fmt.Errorf("status returned %d", resp.StatusCode)
+		return
+	}
+	var body status
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		result.err = fmt.Errorf("decode status: %w", err)
+		return
+	}
+	result.height, err = strconv.ParseInt(body.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	if err != nil {
+		result.err = fmt.Errorf("invalid height %q", body.Result.SyncInfo.LatestBlockHeight)
+		return
+	}
+	result.version = body.Result.NodeInfo.Version
+	result.catching = body.Result.SyncInfo.CatchingUp
+}
+
+// apply records probe results, works out each endpoint's lag behind the
+// highest endpoint of its chain and re-ranks
+func (p *Pools) apply(results []probe) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now().UTC()
+	probed := make(map[*Endpoint]bool, len(results))
+	for _, r := range results {
+		e := r.endpoint
+		probed[e] = true
+		e.CheckedAt = &now
+		wasHealthy := e.Healthy
+		if r.err != nil {
+			e.Healthy = false
+			e.LastError = r.err.Error()
+			if wasHealthy {
+				p.logger.Warn("Endpoint probe failed",
+					zap.String("chain_id", e.ChainID),
+					zap.String("endpoint", Label(e)),
+					zap.Error(r.err))
+			}
+			continue
+		}
+		e.Healthy = !r.catching
+		e.CatchingUp = r.catching
+		e.Height = r.height
+		e.Version = r.version
+		e.LatencyMS = r.latency.Milliseconds()
+		e.Failures = 0
+		e.LastError = ""
+	}
+
+	p.metrics.Reset()
+	for chainID, pl := range p.chains {
+		var highest int64
+		for _, e := range pl.endpoints {
+			if e.Healthy && e.Height > highest {
+				highest = e.Height
+			}
+		}
+		for _, e := range pl.endpoints {
+			if probed[e] && e.Height > 0 {
+				e.Lag = highest - e.Height
+				if e.Lag < 0 {
+					e.Lag = 0
+				}
+			}
+			e.Compatible = compatible(pl.version, e.Version)
+		}
+		p.rank(pl)
+		for _, e := range pl.endpoints {
+			p.metrics.Observe(chainID, *e)
+		}
+	}
+}
+
+// rank orders a pool: healthy, compatible endpoints within maxLag first,
+// then the other healthy ones, then the rest, each by latency. Latencies
+// within the same latencyBucket count as equal so the preferred source wins.
+func (p *Pools) rank(pl *pool) {
+	tier := func(e *Endpoint) int {
+		switch {
+		case e.Healthy && e.Compatible && e.Lag <= p.maxLag:
+			return 0
+		case e.Healthy:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(pl.endpoints, func(i, j int) bool {
+		a, b := pl.endpoints[i], pl.endpoints[j]
+		if tier(a) != tier(b) {
+			return tier(a) < tier(b)
+		}
+		if a.LatencyMS/latencyBucket != b.LatencyMS/latencyBucket {
+			return a.LatencyMS < b.LatencyMS
+		}
+		return sourceOrder[a.Source] < sourceOrder[b.Source]
+	})
+	for i, e := range pl.endpoints {
+		e.Rank = i
+	}
+}
+
+// sync rebuilds the pools from the registry and nodes.toml, keeping the
+// state of endpoints that are still listed
+func (p *Pools) sync() {
+	var nodes map[string]config.Node
+	if p.nodesPath != "" {
+		loaded, err := config.LoadNodes(p.nodesPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			p.logger.Warn("Failed to load nodes.toml", zap.String("path", p.nodesPath), zap.Error(err))
+		}
+		nodes = loaded
+	}
+	chains := p.registry.AllChains()
+
+	ids := make(map[string]bool, len(chains)+len(nodes))
+	for id := range chains {
+		ids[id] = true
+	}
+	for id := range nodes {
+		ids[id] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next := make(map[string]*pool, len(ids))
+	for id := range ids {
+		chain, node := chains[id], nodes[id]
+		listed := listEndpoints(id, chain, node)
+		if len(listed) == 0 {
+			continue
+		}
+
+		existing := make(map[string]*Endpoint)
+		if old, ok := p.chains[id]; ok {
+			for _, e := range old.endpoints {
+				existing[key(e)] = e
+			}
+		}
+
+		pl := &pool{version: node.CometVersion}
+		if pl.version == "" {
+			pl.version = chain.Metadata["comet_version"]
+		}
+		for _, e := range listed {
+			if kept, ok := existing[key(e)]; ok {
+				e = kept
+			}
+			e.Compatible = compatible(pl.version, e.Version)
+			pl.endpoints = append(pl.endpoints, e)
+		}
+		p.rank(pl)
+		next[id] = pl
+	}
+	p.chains = next
+}
+
+// listEndpoints lists a chain's endpoints, skipping ones already listed from
+// another source: the registry fills endpoints it lacks from nodes.toml
+func listEndpoints(chainID string, chain config.ChainConfig, node config.Node) []*Endpoint {
+	var listed []*Endpoint
+	seen := make(map[string]bool)
+	add := func(source, rpc, rest string, healthy bool) {
+		if rpc == "" && rest == "" {
+			return
+		}
+		if seen[rpc] && (rpc != "" || seen[rest]) {
+			return
+		}
+		seen[rpc], seen[rest] = true, true
+		e := &Endpoint{
+			ChainID:    chainID,
+			Source:     source,
+			RPC:        rpc,
+			REST:       rest,
+			Healthy:    healthy,
+			Compatible: true,
+		}
+		e.Label = Label(e)
+		listed = append(listed, e)
+	}
+
+	if node.Usable() {
+		add(SourceNodes, node.RPC, node.API, node.Healthy == nil || *node.Healthy)
+	}
+	add(SourceRegistry, chain.RPCEndpoint, chain.RESTEndpoint, true)
+	add(SourceBackup, node.BackupRPC, "", true)
+	return listed
+}
+
+func key(e *Endpoint) string {
+	return e.Source + "|" + e.RPC + "|" + e.REST
+}
+
+// Label names an endpoint in logs and metrics without its credentials
+func Label(e *Endpoint) string {
+	if e.RPC != "" {
+		return Redact(e.RPC)
+	}
+	return Redact(e.REST)
+}
+
+// compatible reports whether a node version has the expected CometBFT minor
+// version, e.g. 0.37.2 for 0.37. Unknown versions count as compatible.
+func compatible(expected, version string) bool {
+	if expected == "" || version == "" {
+		return true
+	}
+	return minorVersion(expected) == minorVersion(version)
+}
+
+func minorVersion(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return strings.Join(parts, ".")
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// requestURL appends path, which may carry a query string, to an endpoint's
+// path. The endpoint's own query string, which nodes.toml endpoints can
+// authenticate with, is kept.
+func requestURL(endpoint, path string) (string, error) {
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	u := base.JoinPath(ref.EscapedPath())
+	if ref.RawQuery != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += ref.RawQuery
+	}
+	return u.String(), nil
+}
+
+// Redact reduces an endpoint to its scheme and host, dropping the
+// credentials nodes.toml endpoints carry in the userinfo, path or query
+// string
+func Redact(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "invalid endpoint"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// public drops the userinfo and query string of a registry endpoint, where
+// an overrides file may put credentials
+func public(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
+
+// sanitize drops the request URL a client error carries, as it includes the
+// endpoint's credentials
+func sanitize(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
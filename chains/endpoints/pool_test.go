@@ -0,0 +1,210 @@
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/relayooor/chains/config"
+)
+
+// node serves /status at a height and version, and answers REST queries
+// with its name unless it's down
+type node struct {
+	name    string
+	height  int64
+	version string
+	down    bool
+}
+
+func (n *node) serve(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.URL.Path == "/status" {
+			fmt.Fprintf(w, `{"result":{"node_info":{"version":%q},"sync_info":{"latest_block_height":"%d","catching_up":false}}}`, n.version, n.height)
+			return
+		}
+		fmt.Fprint(w, n.name)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recordedMetrics keeps what the pools record, by endpoint source
+type recordedMetrics struct {
+	failovers int
+	observed  map[string]Endpoint
+}
+
+func (m *recordedMetrics) Failover(chainID string, api API) { m.failovers++ }
+func (m *recordedMetrics) Reset()                           { m.observed = map[string]Endpoint{} }
+func (m *recordedMetrics) Observe(chainID string, e Endpoint) {
+	m.observed[e.Source] = e
+}
+
+func TestPools(t *testing.T) {
+	private := &node{name: "private", height: 100, version: "0.37.4"}
+	public := &node{name: "public", height: 100, version: "0.37.2"}
+	backup := &node{name: "backup", height: 80, version: "0.37.4"}
+	privateURL, publicURL, backupURL := private.serve(t).URL, public.serve(t).URL, backup.serve(t).URL
+
+	nodes := filepath.Join(t.TempDir(), "nodes.toml")
+	require.NoError(t, os.WriteFile(nodes, []byte(fmt.Sprintf(`
+[chains.cosmoshub-4]
+rpc = %q
+api = %q
+backup_rpc = %q
+comet_version = "0.37"
+`, privateURL, privateURL, backupURL)), 0o644))
+
+	registry := &config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RPCEndpoint: publicURL, RESTEndpoint: publicURL},
+	}}
+	pools := NewPools(registry, nodes, zap.NewNop())
+	metrics := &recordedMetrics{}
+	pools.UseMetrics(metrics)
+	ctx := context.Background()
+
+	chains := pools.Probe(ctx)
+	require.Len(t, chains, 1)
+	assert.Equal(t, "0.37", chains[0].Version)
+	endpoints := chains[0].Endpoints
+	require.Len(t, endpoints, 3)
+	for _, e := range endpoints {
+		assert.True(t, e.Healthy, e.Source)
+		assert.True(t, e.Compatible, e.Source)
+	}
+	// The backup is 20 blocks behind and ranks last whatever its latency
+	assert.Equal(t, SourceBackup, endpoints[2].Source)
+	assert.Equal(t, int64(20), endpoints[2].Lag)
+	assert.Len(t, metrics.observed, 3)
+	assert.Equal(t, 2, metrics.observed[SourceBackup].Rank)
+
+	// An endpoint on another CometBFT minor version drops below the rest
+	public.version = "0.38.0"
+	endpoints = pools.Probe(ctx)[0].Endpoints
+	assert.Equal(t, SourceNodes, endpoints[0].Source)
+	assert.Equal(t, SourceRegistry, endpoints[1].Source)
+	assert.False(t, endpoints[1].Compatible)
+
+	get := func() string {
+		t.Helper()
+		resp, err := pools.Get(ctx, "cosmoshub-4", REST, "/cosmos/bank/v1beta1/balances/cosmos1abc")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "private", get())
+
+	// Failed queries fail over, and mark the endpoint unhealthy once
+	// maxFailures have failed in a row
+	private.down = true
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "public", get())
+	}
+	endpoints = pools.Chains("cosmoshub-4")[0].Endpoints
+	assert.Equal(t, SourceRegistry, endpoints[0].Source)
+	assert.False(t, endpoints[2].Healthy)
+	assert.Equal(t, 3, endpoints[2].Failures)
+	assert.Equal(t, 3, metrics.failovers)
+
+	// A successful probe restores it
+	private.down = false
+	endpoints = pools.Probe(ctx)[0].Endpoints
+	assert.Equal(t, SourceNodes, endpoints[0].Source)
+	assert.True(t, endpoints[0].Healthy)
+
+	public.down, private.down = true, true
+	_, err := pools.Get(ctx, "cosmoshub-4", REST, "/cosmos/bank/v1beta1/balances/cosmos1abc")
+	assert.ErrorIs(t, err, ErrAllFailed)
+	_, err = pools.Get(ctx, "juno-1", REST, "/")
+	assert.ErrorIs(t, err, ErrNoEndpoint)
+}
+
+func TestPoolsQueryStringEndpoint(t *testing.T) {
+	// The node only answers under its token path with its API key
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != "SECRET" || !strings.HasPrefix(r.URL.Path, "/TOKEN/") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/TOKEN/status" {
+			fmt.Fprint(w, `{"result":{"node_info":{"version":"0.37.4"},"sync_info":{"latest_block_height":"100","catching_up":false}}}`)
+			return
+		}
+		fmt.Fprint(w, r.URL.Path+" "+r.URL.Query().Get("pagination.limit"))
+	}))
+	t.Cleanup(server.Close)
+
+	nodes := filepath.Join(t.TempDir(), "nodes.toml")
+	endpoint := server.URL + "/TOKEN?apikey=SECRET"
+	require.NoError(t, os.WriteFile(nodes, []byte(fmt.Sprintf("[chains.cosmoshub-4]\nrpc = %q\napi = %q\n", endpoint, endpoint)), 0o644))
+	pools := NewPools(&config.ChainRegistry{}, nodes, zap.NewNop())
+	ctx := context.Background()
+
+	endpoints := pools.Probe(ctx)[0].Endpoints
+	require.Len(t, endpoints, 1)
+	assert.True(t, endpoints[0].Healthy, endpoints[0].LastError)
+
+	resp, err := pools.Get(ctx, "cosmoshub-4", REST, "/ibc/core/channel/v1/channels?pagination.limit=100")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/TOKEN/ibc/core/channel/v1/channels 100", string(body))
+
+	// Neither the errors of a failing endpoint nor what operators are shown
+	// carry its credentials
+	server.Close()
+	pools.Probe(ctx)
+	_, err = pools.Get(ctx, "cosmoshub-4", REST, "/cosmos/bank/v1beta1/balances/cosmos1abc")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "SECRET")
+	assert.NotContains(t, err.Error(), "TOKEN")
+
+	status := pools.Status("")
+	require.Len(t, status.Endpoints, 1)
+	assert.Empty(t, status.Endpoints[0].RESTURL)
+	assert.Equal(t, server.URL, status.Endpoints[0].Label)
+	served, err := json.Marshal(status)
+	require.NoError(t, err)
+	assert.NotEmpty(t, status.Chains[0].Endpoints[0].LastError)
+	assert.NotContains(t, string(served), "SECRET")
+	assert.NotContains(t, string(served), "TOKEN")
+}
+
+func TestRequestURL(t *testing.T) {
+	for endpoint, want := range map[string]string{
+		"https://rpc.example.com":              "https://rpc.example.com/status?height=5",
+		"https://rpc.example.com/":             "https://rpc.example.com/status?height=5",
+		"https://example.com/cosmoshub/":       "https://example.com/cosmoshub/status?height=5",
+		"https://example.com/TOKEN?apikey=KEY": "https://example.com/TOKEN/status?apikey=KEY&height=5",
+	} {
+		got, err := requestURL(endpoint, "/status?height=5")
+		require.NoError(t, err)
+		assert.Equal(t, want, got, endpoint)
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	assert.True(t, compatible("0.37", "0.37.4"))
+	assert.True(t, compatible("0.38", "v0.38.12"))
+	assert.False(t, compatible("0.37", "0.38.0"))
+	assert.True(t, compatible("", "0.38.0"))
+	assert.True(t, compatible("0.37", ""))
+}
@@ -0,0 +1,97 @@
+package endpoints
+
+import (
+	"errors"
+	"time"
+)
+
+// API is the kind of endpoint a query is sent to
+type API string
+
+const (
+	RPC  API = "rpc"
+	REST API = "rest"
+)
+
+// Where an endpoint came from
+const (
+	// SourceNodes is a private endpoint from nodes.toml
+	SourceNodes = "nodes"
+	// SourceRegistry is the public endpoint from the chain registry
+	SourceRegistry = "registry"
+	// SourceBackup is the backup_rpc of a nodes.toml chain
+	SourceBackup = "backup"
+)
+
+var (
+	// ErrNoEndpoint is returned for chains without an endpoint for the API
+	ErrNoEndpoint = errors.New("no endpoint")
+	// ErrAllFailed is returned when every endpoint of a chain failed a query
+	ErrAllFailed = errors.New("all endpoints failed")
+)
+
+// Endpoint is one endpoint of a chain and the result of its last probe
+type Endpoint struct {
+	ChainID string `json:"chain_id"`
+	Source  string `json:"source"`
+	// Label names the endpoint without its credentials
+	Label string `json:"label"`
+	RPC   string `json:"rpc,omitempty"`
+	// REST is the LCD of the same node, if it has one
+	REST string `json:"rest,omitempty"`
+	// Rank is the endpoint's position in the chain's pool, 0 first
+	Rank    int  `json:"rank"`
+	Healthy bool `json:"healthy"`
+	// Compatible is false when the node runs a CometBFT minor version
+	// other than the chain's
+	Compatible bool   `json:"compatible"`
+	Version    string `json:"version,omitempty"`
+	Height     int64  `json:"height,omitempty"`
+	// Lag is how many blocks the endpoint is behind the chain's highest
+	Lag        int64      `json:"lag"`
+	CatchingUp bool       `json:"catching_up"`
+	LatencyMS  int64      `json:"latency_ms"`
+	Failures   int        `json:"consecutive_failures"`
+	LastError  string     `json:"last_error,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"`
+}
+
+// Chain is the endpoint pool of one chain, best first
+type Chain struct {
+	ChainID string `json:"chain_id"`
+	// Version is the CometBFT version endpoints are checked against
+	Version   string     `json:"comet_version,omitempty"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Status is what operators are shown of the pools
+type Status struct {
+	Chains []Chain `json:"chains"`
+	// Endpoints is the endpoint REST queries currently go to on each chain
+	Endpoints []Current `json:"endpoints"`
+}
+
+// Current is the endpoint a chain's REST queries currently go to. RESTURL
+// is only set for registry endpoints.
+type Current struct {
+	ChainID string `json:"chain_id"`
+	Label   string `json:"label"`
+	RESTURL string `json:"rest_url,omitempty"`
+}
+
+// Metrics records the health of the pools, e.g. as Prometheus series
+type Metrics interface {
+	// Failover counts a query retried on the next endpoint after one failed
+	Failover(chainID string, api API)
+	// Reset drops every endpoint before the pools are observed again, so
+	// endpoints no longer listed go away
+	Reset()
+	// Observe records an endpoint after a probe
+	Observe(chainID string, e Endpoint)
+}
+
+type noMetrics struct{}
+
+func (noMetrics) Failover(string, API)     {}
+func (noMetrics) Reset()                   {}
+func (noMetrics) Observe(string, Endpoint) {}
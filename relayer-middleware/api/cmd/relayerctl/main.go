@@ -0,0 +1,136 @@
+// Command relayerctl holds operator tooling that works without the API server
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/relayooor/chains/config"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+const usage = `Usage: relayerctl <command> [flags]
+
+Commands:
+  generate-hermes   Render a Hermes config.toml from the chain registry
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate-hermes":
+		err = generateHermes(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		var validationErr *relayerconfig.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, issue := range validationErr.Issues {
+				fmt.Fprintf(os.Stderr, "%s: %s: %s\n", issue.Severity, issue.Path, issue.Message)
+			}
+		}
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func generateHermes(args []string) error {
+	fs := flag.NewFlagSet("generate-hermes", flag.ExitOnError)
+	chains := fs.String("chains", "", "comma-separated chain IDs to include (required)")
+	channels := fs.String("channels", "", "comma-separated channels to relay as chain:channel or chain:port/channel; defaults to every registry channel between the chains")
+	keyName := fs.String("key-name", "relayer", "Hermes key name used on every chain")
+	keyNames := fs.String("key-names", "", "per-chain key names as chain=key, comma-separated")
+	memoPrefix := fs.String("memo-prefix", "", "memo prefix for relayed transactions")
+	private := fs.Bool("private", false, "use the private endpoints from nodes.toml")
+	nodesPath := fs.String("nodes", relayerconfig.NodesPath(), "path to nodes.toml")
+	output := fs.String("o", "", "write the config to this file instead of stdout")
+	fs.Parse(args)
+
+	opts := relayerconfig.GenerateOptions{
+		Chains:     strings.Split(*chains, ","),
+		KeyName:    *keyName,
+		MemoPrefix: *memoPrefix,
+	}
+
+	for _, value := range splitList(*channels) {
+		ref, err := parseChannelRef(value)
+		if err != nil {
+			return err
+		}
+		opts.Channels = append(opts.Channels, ref)
+	}
+
+	if values := splitList(*keyNames); len(values) > 0 {
+		opts.KeyNames = make(map[string]string, len(values))
+		for _, value := range values {
+			chain, key, ok := strings.Cut(value, "=")
+			if !ok {
+				return fmt.Errorf("invalid key name %q, expected chain=key", value)
+			}
+			opts.KeyNames[chain] = key
+		}
+	}
+
+	if *private {
+		nodes, err := relayerconfig.LoadNodes(*nodesPath)
+		if err != nil {
+			return err
+		}
+		opts.Nodes = nodes
+	}
+
+	generated, err := relayerconfig.GenerateHermes(config.DefaultChainRegistry(), opts)
+	if err != nil {
+		return err
+	}
+
+	for _, chain := range generated.Chains {
+		fmt.Fprintf(os.Stderr, "%s: %s endpoints, key %s, %d channel(s)\n",
+			chain.ID, chain.Endpoints, chain.KeyName, len(chain.Channels))
+	}
+
+	if *output == "" {
+		fmt.Print(generated.Config)
+		return nil
+	}
+	// The config may embed private endpoint credentials
+	return os.WriteFile(*output, []byte(generated.Config), 0600)
+}
+
+// parseChannelRef parses chain:channel or chain:port/channel
+func parseChannelRef(value string) (relayerconfig.ChannelRef, error) {
+	chain, rest, ok := strings.Cut(value, ":")
+	if !ok || chain == "" || rest == "" {
+		return relayerconfig.ChannelRef{}, fmt.Errorf("invalid channel %q, expected chain:channel or chain:port/channel", value)
+	}
+	ref := relayerconfig.ChannelRef{ChainID: chain, ChannelID: rest}
+	if port, channel, ok := strings.Cut(rest, "/"); ok {
+		ref.PortID, ref.ChannelID = port, channel
+	}
+	return ref, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
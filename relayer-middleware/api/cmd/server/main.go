@@ -13,18 +13,38 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/v9"
+	chainchannels "github.com/relayooor/chains/channels"
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	
+	"relayooor/api/pkg/apikeys"
+	"relayooor/api/pkg/audit"
+	"relayooor/api/pkg/auth"
+	"relayooor/api/pkg/balances"
 	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/channels"
 	"relayooor/api/pkg/clearing"
+	"relayooor/api/pkg/competition"
 	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/diagnostics"
+	"relayooor/api/pkg/endpointhealth"
 	"relayooor/api/pkg/handlers"
+	"relayooor/api/pkg/ibcclients"
 	"relayooor/api/pkg/logging"
+	"relayooor/api/pkg/logstream"
 	"relayooor/api/pkg/middleware"
+	"relayooor/api/pkg/packethistory"
+	"relayooor/api/pkg/relayerconfig"
 	"relayooor/api/pkg/server"
+	"relayooor/api/pkg/supervisor"
+	"relayooor/api/pkg/telemetry"
+	"relayooor/api/pkg/timeouts"
 )
 
 func main() {
@@ -65,8 +85,26 @@ func main() {
 	// Initialize health handler
 	healthHandler := handlers.NewHealthHandler(db, redisClient, logger)
 
+	// Initialize API key service for integrators
+	apiKeySecret := os.Getenv("API_KEY_SECRET")
+	if apiKeySecret == "" {
+		apiKeySecret = "default-secret-change-me"
+	}
+	apiKeyService := apikeys.NewService(db, redisClient, apiKeySecret, logger)
+	apiKeyHandlers := apikeys.NewHandlers(apiKeyService, logger)
+
+	// Initialize append-only audit log for privileged actions
+	auditService := audit.NewService(db, logger)
+	auditHandlers := audit.NewHandlers(auditService, logger)
+
 	// Initialize clearing handlers with improved error handling
 	clearingHandlers := clearing.NewHandlersV2(db, redisClient, logger)
+	clearingHandlers.UseAPIKeys(apiKeyService)
+	clearingHandlers.UseAuditLog(auditService)
+
+	// Initialize Redis-backed rate limiter for public and integrator routes
+	rateLimiter := middleware.NewRateLimiter(redisClient, logger)
+	clearingHandlers.UseRateLimiter(rateLimiter)
 
 	// Initialize Chainpulse client first (needed by payment handler)
 	chainpulseURL := os.Getenv("CHAINPULSE_URL")
@@ -83,14 +121,153 @@ func main() {
 	helpHandler := handlers.NewHelpHandler()
 
 	// Initialize packet stream handler
-	packetCache := clearing.NewPacketCache(redisClient, logger)
-	packetStreamHandler := handlers.NewPacketStreamHandler(db, packetCache, logger)
+	packetStreamHandler := handlers.NewPacketStreamHandler(db, logger)
 
 	// Initialize original handlers for backward compatibility
 	originalHandlers := handlers.NewHandler()
+	originalHandlers.UseAuditLog(auditService)
+
+	// Select how relayer processes are managed (supervisord, systemd, docker, child)
+	processManager, err := supervisor.NewFromEnv(logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize process manager", zap.Error(err))
+	}
+	originalHandlers.UseProcessManager(processManager)
+
+	// Stream relayer logs from the process manager or from log files
+	logStreamService := logstream.NewService(logger)
+	tailer, canTail := processManager.(logstream.LogTailer)
+	if os.Getenv("LOG_STREAM_SOURCE") == "process" && canTail {
+		logStreamService.AddProcessSources(tailer, "hermes", handlers.RelayerProcesses("hermes")[0])
+		logStreamService.AddProcessSources(tailer, "rly", handlers.RelayerProcesses("rly")[0])
+	} else {
+		logStreamService.AddFileSources()
+	}
+	logStreamService.Start(context.Background())
+	logStreamHandlers := logstream.NewHandlers(logStreamService, logger)
+	originalHandlers.UseLogStream(logStreamService)
+
+	// Enable refresh token rotation and access token revocation
+	tokenStore := auth.NewTokenStore(redisClient, handlers.RefreshTokenTTL, logger)
+	originalHandlers.UseTokenStore(tokenStore)
+	middleware.SetTokenDenylist(tokenStore)
+
+	// Initialize validated, versioned relayer config editing
+	relayerConfigService := relayerconfig.NewService(db, logger)
+	relayerConfigHandlers := relayerconfig.NewHandlers(
+		relayerConfigService,
+		relayerconfig.RestarterFunc(originalHandlers.RestartRelayer),
+		auditService,
+		logger,
+	)
+
+	// Load the chain registry and reload it when its files change
+	chainRegistry := config.DefaultChainRegistry()
+	if status := chainRegistry.Status(); status.Error != "" {
+		logger.Error("Failed to load chain registry", zap.String("error", status.Error))
+	} else {
+		logger.Info("Loaded chain registry", zap.Strings("sources", status.Sources), zap.Strings("warnings", status.Warnings))
+	}
+	config.NewWatcher(chainRegistry, config.OptionsFromEnv(), logger).Start(context.Background())
+	chainRegistryHandler := handlers.NewChainRegistryHandler(chainRegistry)
+
+	// Rank every chain's endpoints by probing them and fail queries over
+	// between them
+	endpointPools := endpoints.NewPools(chainRegistry, relayerconfig.NodesPath(), logger)
+	endpointMetrics := endpointhealth.NewMetrics()
+	if err := endpointMetrics.Register(prometheus.DefaultRegisterer); err != nil {
+		logger.Fatal("Failed to register endpoint metrics", zap.Error(err))
+	}
+	endpointPools.UseMetrics(endpointMetrics)
+	endpointPools.Start(context.Background())
+	endpointHandlers := endpointhealth.NewHandlers(endpointPools, logger)
+
+	// Monitor relayer account balances and gas runway
+	balanceQuerier := balances.NewRESTQuerier(chainRegistry)
+	balanceQuerier.UseEndpoints(endpointPools)
+	balanceMonitor := balances.NewMonitor(relayerConfigService, balanceQuerier, logger)
+	if err := balanceMonitor.Register(prometheus.DefaultRegisterer); err != nil {
+		logger.Fatal("Failed to register balance metrics", zap.Error(err))
+	}
+	balanceMonitor.UseNotifier(balances.NotifierFunc(func(alert balances.Alert) {
+		originalHandlers.Broadcast(gin.H{"type": "balance_alert", "data": alert})
+	}))
+	balanceMonitor.Start(context.Background())
+	balanceHandlers := balances.NewHandlers(balanceMonitor, logger)
+	originalHandlers.UseBalanceMonitor(balanceMonitor)
+
+	// Watch light client expiry on the relayed channels
+	hermesConfigPath, _ := relayerConfigService.Path(relayerconfig.RelayerHermes)
+	clientQuerier := ibcclients.NewRESTQuerier(chainRegistry)
+	clientQuerier.UseEndpoints(endpointPools)
+	clientWatcher := ibcclients.NewWatcher(relayerConfigService, chainRegistry, clientQuerier, logger)
+	if err := clientWatcher.Register(prometheus.DefaultRegisterer); err != nil {
+		logger.Fatal("Failed to register client metrics", zap.Error(err))
+	}
+	clientWatcher.UseRefresher(ibcclients.NewHermesRefresher(hermesConfigPath))
+	clientWatcher.UseNotifier(ibcclients.NotifierFunc(func(alert ibcclients.Alert) {
+		originalHandlers.Broadcast(gin.H{"type": "client_alert", "data": alert})
+	}))
+	clientWatcher.Start(context.Background())
+	clientHandlers := ibcclients.NewHandlers(clientWatcher, auditService, logger)
+
+	// Explain stuck packets and refuse clearing tokens for packets a clear can't help
+	chainQuerier := diagnostics.NewRESTQuerier(chainRegistry)
+	chainQuerier.UseEndpoints(endpointPools)
+	diagnoser := diagnostics.NewDiagnoser(chainQuerier, clientQuerier, relayerConfigService, logger)
+	diagnoser.UseBalances(balanceMonitor)
+	diagnoser.UseLogs(logStreamService)
+	clearingHandlers.UsePacketChecker(diagnoser)
+
+	// Packet timelines for support, from both chains, Chainpulse and clearing history
+	timelines := diagnostics.NewTimelines(chainQuerier, chainQuerier, clientQuerier, logger)
+	timelines.UseChainpulse(chainpulseClient)
+	timelines.UseClearingHistory(clearing.NewPacketHistory(db))
+	diagnosticsHandlers := diagnostics.NewHandlers(diagnoser, timelines, logger)
+
+	// Scrape Hermes and rly telemetry for metrics and the WebSocket status feed
+	originalHandlers.UseTelemetry(telemetry.NewCollector(logger))
+	originalHandlers.StartMetricsCollector()
+
+	// Resolve where channels lead from on-chain channel, connection and client state
+	channelQuerier := chainchannels.NewRESTQuerier(chainRegistry)
+	channelQuerier.UseEndpoints(endpointPools)
+	counterpartyResolver := channels.NewResolver(db, channelQuerier, logger)
+	counterpartyResolver.Start(context.Background())
+	counterpartyHandlers := channels.NewHandlers(counterpartyResolver, logger)
 
 	// Initialize Chainpulse handler
-	chainpulseHandler := handlers.NewChainpulseHandler(chainpulseURL, logger)
+	chainpulseHandler := handlers.NewChainpulseHandler(chainpulseClient, logger)
+	chainpulseHandler.UseCounterparties(counterpartyResolver)
+
+	// Predict when expiring packets time out on their destination chain,
+	// alert before they do and queue their clearing first
+	timeoutQuerier := timeouts.NewRPCQuerier(chainRegistry)
+	timeoutQuerier.UseEndpoints(endpointPools)
+	timeoutPredictor := timeouts.NewPredictor(chainpulseClient, timeoutQuerier, logger)
+	if err := timeoutPredictor.Register(prometheus.DefaultRegisterer); err != nil {
+		logger.Fatal("Failed to register timeout metrics", zap.Error(err))
+	}
+	timeoutPredictor.UseCounterparties(counterpartyResolver)
+	timeoutPredictor.UseNotifier(timeouts.NotifierFunc(func(alert timeouts.Alert) {
+		originalHandlers.Broadcast(gin.H{"type": "timeout_alert", "data": alert})
+	}))
+	timeoutPredictor.Start(context.Background())
+	timeoutHandlers := timeouts.NewHandlers(timeoutPredictor, logger)
+	chainpulseHandler.UseTimeouts(timeoutPredictor)
+	clearingHandlers.UseTimeouts(timeoutPredictor)
+
+	// Keep a history of what Chainpulse reports as stuck for historical queries
+	packethistory.NewIngester(db, chainpulseClient, logger).Start(context.Background())
+	packetHistoryHandlers := packethistory.NewHandlers(packethistory.NewStore(db), logger)
+
+	// Track relayer competition per channel for the relayer performance analytics
+	competitionTracker := competition.NewTracker(db, chainpulseClient, logger)
+	competitionTracker.UseChains(chainRegistry)
+	competitionTracker.UseCounterparties(counterpartyResolver)
+	competitionTracker.Start(context.Background())
+	originalHandlers.UseCompetition(competitionTracker)
+	originalHandlers.UseChainpulse(chainpulseClient)
 
 	// API routes
 	api := router.Group("/api/v1")
@@ -100,6 +277,13 @@ func main() {
 
 		// Clearing service routes
 		clearingHandlers.RegisterRoutes(api)
+		clearingHandlers.RegisterAdminRoutes(api)
+
+		// API key management (operators only)
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthRequired(), middleware.DenyAPIKeys())
+		apiKeyHandlers.RegisterRoutes(admin)
+		auditHandlers.RegisterRoutes(admin)
 		
 		// Payment and UX routes
 		paymentHandler.RegisterRoutes(api)
@@ -110,31 +294,56 @@ func main() {
 		// Packet stream routes
 		api.GET("/packets/stuck/stream", packetStreamHandler.GetStuckPacketsStream)
 		api.GET("/packets/channel/stream", packetStreamHandler.GetChannelPacketsStream)
+
+		// Stuck packet diagnosis and timelines
+		diagnosticsHandlers.RegisterRoutes(api)
 		
 		// Chainpulse integration routes
 		chainpulseHandler.RegisterRoutes(api)
+
+		// Stuck packet history and mean time to relay
+		packetHistoryHandlers.RegisterRoutes(api)
+
+		// Predicted timeouts of pending packets
+		timeoutHandlers.RegisterRoutes(api)
 		
 		// Channels routes (moved here for better organization)
-		channels := api.Group("/channels")
+		channelRoutes := api.Group("/channels")
 		{
-			channels.GET("/congestion", chainpulseHandler.GetChannelCongestion)
+			channelRoutes.GET("/congestion", chainpulseHandler.GetChannelCongestion)
 		}
 
+		// Channel counterparties resolved on chain
+		counterpartyHandlers.RegisterRoutes(api)
+
+		// Chains and channels loaded from the chain registry
+		chainRegistryHandler.RegisterRoutes(api)
+
+		// Endpoint health and ranking per chain (operators only)
+		endpointRoutes := api.Group("/")
+		endpointRoutes.Use(middleware.AuthRequired(), middleware.DenyAPIKeys())
+		endpointHandlers.RegisterRoutes(endpointRoutes)
+
 		// Original authentication routes
-		auth := api.Group("/auth")
+		authRoutes := api.Group("/auth")
 		{
-			auth.POST("/login", originalHandlers.Login)
-			auth.POST("/refresh", originalHandlers.RefreshToken)
+			authRoutes.POST("/login", originalHandlers.Login)
+			authRoutes.POST("/refresh", originalHandlers.RefreshToken)
+			authRoutes.POST("/logout", middleware.AuthRequired(), originalHandlers.Logout)
 		}
 
-		// Protected routes
+		// Protected routes (JWT or API key)
 		protected := api.Group("/")
 		if os.Getenv("AUTH_ENABLED") == "true" {
-			protected.Use(middleware.AuthRequired())
+			protected.Use(middleware.APIKeyAuth(apiKeyService, middleware.AuthRequired()))
+		} else {
+			protected.Use(middleware.APIKeyAuth(apiKeyService, nil))
 		}
+		protected.Use(rateLimiter.Limit("api"))
 		{
 			// IBC routes
 			ibc := protected.Group("/ibc")
+			ibc.Use(middleware.RequireScope(apikeys.ScopeReadPackets))
 			{
 				// Chains
 				ibc.GET("/chains", originalHandlers.GetChains)
@@ -152,16 +361,16 @@ func main() {
 
 				// Packets
 				ibc.GET("/packets/pending", originalHandlers.GetPendingPackets)
-				ibc.POST("/packets/clear", originalHandlers.ClearPackets)
+				ibc.POST("/packets/clear", middleware.RequireScope(apikeys.ScopeClearingRequest), originalHandlers.ClearPackets)
 				ibc.GET("/packets/stuck", originalHandlers.GetStuckPackets)
 
 				// Clients
-				ibc.GET("/clients", originalHandlers.GetClients)
-				ibc.GET("/chains/:chain_id/clients", originalHandlers.GetChainClients)
+				clientHandlers.RegisterRoutes(ibc)
 			}
 
 			// Relayer management
 			relayer := protected.Group("/relayer")
+			relayer.Use(middleware.DenyAPIKeys())
 			{
 				relayer.GET("/status", originalHandlers.GetRelayerStatus)
 				relayer.POST("/hermes/start", originalHandlers.StartHermes)
@@ -169,7 +378,10 @@ func main() {
 				relayer.POST("/rly/start", originalHandlers.StartGoRelayer)
 				relayer.POST("/rly/stop", originalHandlers.StopGoRelayer)
 				relayer.GET("/config", originalHandlers.GetRelayerConfig)
-				relayer.PUT("/config", originalHandlers.UpdateRelayerConfig)
+				relayerConfigHandlers.RegisterRoutes(relayer)
+				logStreamHandlers.RegisterRoutes(relayer)
+				balanceHandlers.RegisterRoutes(relayer)
+				clientHandlers.RegisterOperatorRoutes(relayer)
 				
 				// New Hermes-specific endpoints
 				relayer.GET("/hermes/version", originalHandlers.GetHermesVersion)
@@ -179,6 +391,7 @@ func main() {
 
 			// Metrics and monitoring
 			metrics := protected.Group("/metrics")
+			metrics.Use(middleware.RequireScope(apikeys.ScopeReadPackets))
 			{
 				metrics.GET("/summary", originalHandlers.GetMetricsSummary)
 				metrics.GET("/packets", originalHandlers.GetPacketMetrics)
@@ -191,6 +404,7 @@ func main() {
 			
 			// Monitoring endpoints
 			monitoring := protected.Group("/monitoring")
+			monitoring.Use(middleware.RequireScope(apikeys.ScopeReadPackets))
 			{
 				monitoring.GET("/data", originalHandlers.GetMonitoringData)
 				monitoring.GET("/metrics", originalHandlers.GetMonitoringMetrics)
@@ -198,8 +412,11 @@ func main() {
 		}
 	}
 
+	// Prometheus exposition of the API's own metrics
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Legacy WebSocket endpoint
-	router.GET("/ws", originalHandlers.WebSocketHandler)
+	router.GET("/ws", middleware.OptionalAuth(), originalHandlers.WebSocketHandler)
 
 	// Start server with graceful shutdown
 	port := os.Getenv("API_PORT")
@@ -341,10 +558,23 @@ func initializeRedis(logger *zap.Logger) *redis.Client {
 
 // runMigrations runs database migrations
 func runMigrations(db *gorm.DB) error {
+	// The audit log also needs its append-only triggers
+	if err := audit.AutoMigrate(db); err != nil {
+		return err
+	}
+
 	// Auto-migrate clearing operation tables
 	return db.AutoMigrate(
 		&clearing.ClearingOperation{},
 		&clearing.PaymentRecord{},
+		&clearing.RefundableOperation{},
+		&clearing.OperationPacket{},
+		&apikeys.APIKey{},
+		&relayerconfig.ConfigVersion{},
+		&packethistory.StuckPacket{},
+		&channels.Counterparty{},
+		&competition.RelayerActivity{},
+		&competition.RelayLatency{},
 		// Add other models as needed
 	)
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+-- Append-only audit log for privileged actions
+-- Entries are hash chained: hash = sha256(prev_hash || entry fields)
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+    id BIGSERIAL PRIMARY KEY,
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    target VARCHAR(255),
+    before TEXT,
+    after TEXT,
+    diff TEXT,
+    request_id VARCHAR(100),
+    outcome VARCHAR(20) NOT NULL,
+    error TEXT,
+    prev_hash VARCHAR(64),
+    hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_entries_timestamp ON audit_entries(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_actor ON audit_entries(actor_type, actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_action ON audit_entries(action);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_target ON audit_entries(target);
+CREATE INDEX IF NOT EXISTS idx_audit_entries_request_id ON audit_entries(request_id);
+
+-- Reject any modification of existing entries
+CREATE OR REPLACE FUNCTION audit_entries_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_entries_no_update ON audit_entries;
+CREATE TRIGGER audit_entries_no_update
+    BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only();
+
+DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries;
+CREATE TRIGGER audit_entries_no_truncate
+    BEFORE TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only();
@@ -0,0 +1,2 @@
+-- Drop relayer config history
+DROP TABLE IF EXISTS config_versions;
@@ -0,0 +1,15 @@
+-- Versioned history of relayer config files
+
+CREATE TABLE IF NOT EXISTS config_versions (
+    id SERIAL PRIMARY KEY,
+    relayer VARCHAR(20) NOT NULL,
+    version INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    message TEXT,
+    rolled_back_from INTEGER,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_config_versions_relayer_version ON config_versions(relayer, version);
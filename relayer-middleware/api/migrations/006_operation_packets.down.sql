@@ -0,0 +1,2 @@
+-- Drop clearing operation packets
+DROP TABLE IF EXISTS operation_packets;
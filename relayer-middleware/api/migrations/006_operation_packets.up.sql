@@ -0,0 +1,13 @@
+-- Packets targeted by each clearing operation, for packet timelines
+
+CREATE TABLE IF NOT EXISTS operation_packets (
+    id SERIAL PRIMARY KEY,
+    operation_id VARCHAR(255) NOT NULL,
+    chain_id VARCHAR(255) NOT NULL,
+    port_id VARCHAR(255) NOT NULL,
+    channel_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_operation_packets_operation_id ON operation_packets(operation_id);
+CREATE INDEX IF NOT EXISTS idx_operation_packets_packet ON operation_packets(chain_id, port_id, channel_id, sequence);
@@ -0,0 +1,2 @@
+-- Drop stuck packet history
+DROP TABLE IF EXISTS stuck_packets;
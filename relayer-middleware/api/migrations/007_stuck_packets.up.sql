@@ -0,0 +1,32 @@
+-- Stuck, expiring and expired packets reported by Chainpulse, kept after
+-- they resolve for historical queries
+
+CREATE TABLE IF NOT EXISTS stuck_packets (
+    id SERIAL PRIMARY KEY,
+    chain_id VARCHAR(255) NOT NULL,
+    src_channel VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    dst_channel VARCHAR(255),
+    sender VARCHAR(255),
+    receiver VARCHAR(255),
+    amount VARCHAR(255),
+    denom VARCHAR(255),
+    status VARCHAR(50) NOT NULL,
+    relay_attempts INTEGER NOT NULL DEFAULT 0,
+    last_attempt_by VARCHAR(255),
+    sent_at TIMESTAMP NOT NULL,
+    timeout_at TIMESTAMP,
+    first_seen_at TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stuck_packets_packet ON stuck_packets(chain_id, src_channel, sequence);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_channel ON stuck_packets(chain_id, src_channel);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_sender ON stuck_packets(sender);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_receiver ON stuck_packets(receiver);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_status ON stuck_packets(status);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_first_seen_at ON stuck_packets(first_seen_at);
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_resolved_at ON stuck_packets(resolved_at);
+-- Open packets, checked by every ingest
+CREATE INDEX IF NOT EXISTS idx_stuck_packets_open ON stuck_packets(last_seen_at) WHERE resolved_at IS NULL;
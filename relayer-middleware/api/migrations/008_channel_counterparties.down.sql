@@ -0,0 +1,2 @@
+-- Drop channel counterparties
+DROP TABLE IF EXISTS channel_counterparties;
@@ -0,0 +1,20 @@
+-- Where each channel leads, resolved from the channel's connection and
+-- client on chain and refreshed when the channel changes
+
+CREATE TABLE IF NOT EXISTS channel_counterparties (
+    id SERIAL PRIMARY KEY,
+    chain_id VARCHAR(255) NOT NULL,
+    port_id VARCHAR(255) NOT NULL,
+    channel_id VARCHAR(255) NOT NULL,
+    state VARCHAR(50),
+    connection_id VARCHAR(255),
+    client_id VARCHAR(255),
+    counterparty_chain_id VARCHAR(255),
+    counterparty_port_id VARCHAR(255),
+    counterparty_channel_id VARCHAR(255),
+    resolved_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_channel_counterparties_channel ON channel_counterparties(chain_id, port_id, channel_id);
+CREATE INDEX IF NOT EXISTS idx_channel_counterparties_expires_at ON channel_counterparties(expires_at);
@@ -0,0 +1,141 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes the API key management endpoints
+type Handlers struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandlers creates new API key management handlers
+func NewHandlers(service *Service, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		service: service,
+		logger:  logger.With(zap.String("component", "api_key_handlers")),
+	}
+}
+
+// RegisterRoutes registers the management routes. The caller is expected
+// to protect the group with operator authentication.
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/api-keys", h.CreateKey)
+	router.GET("/api-keys", h.ListKeys)
+	router.GET("/api-keys/:id", h.GetKey)
+	router.GET("/api-keys/:id/usage", h.GetUsage)
+	router.PUT("/api-keys/:id/quota", h.UpdateQuota)
+	router.DELETE("/api-keys/:id", h.RevokeKey)
+}
+
+// CreateKey handles POST /api-keys
+func (h *Handlers) CreateKey(c *gin.Context) {
+	var req CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	resp, err := h.service.Create(c.Request.Context(), req, c.GetString("username"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidScope) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListKeys handles GET /api-keys
+func (h *Handlers) ListKeys(c *gin.Context) {
+	keys, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list api keys", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":  keys,
+		"total": len(keys),
+	})
+}
+
+// GetKey handles GET /api-keys/:id
+func (h *Handlers) GetKey(c *gin.Context) {
+	key, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// GetUsage handles GET /api-keys/:id/usage
+func (h *Handlers) GetUsage(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 30"})
+		return
+	}
+
+	stats, err := h.service.GetUsage(c.Request.Context(), c.Param("id"), days)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// UpdateQuota handles PUT /api-keys/:id/quota
+func (h *Handlers) UpdateQuota(c *gin.Context) {
+	var req struct {
+		DailyQuota int64 `json:"dailyQuota" binding:"min=0"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	if err := h.service.UpdateQuota(c.Request.Context(), c.Param("id"), req.DailyQuota); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Quota updated"})
+}
+
+// RevokeKey handles DELETE /api-keys/:id
+func (h *Handlers) RevokeKey(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.logger.Info("API key revoked by operator",
+		zap.String("key_id", c.Param("id")),
+		zap.String("username", c.GetString("username")),
+	)
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func (h *Handlers) handleError(c *gin.Context, err error) {
+	if errors.Is(err, ErrKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	h.logger.Error("API key operation failed", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
@@ -0,0 +1,304 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrKeyNotFound   = errors.New("api key not found")
+	ErrKeyExpired    = errors.New("api key expired")
+	ErrKeyRevoked    = errors.New("api key revoked")
+	ErrInvalidKey    = errors.New("invalid api key format")
+	ErrQuotaExceeded = errors.New("api key daily quota exceeded")
+	ErrInvalidScope  = errors.New("invalid scope")
+)
+
+const (
+	// KeyPrefix identifies relayooor API keys in headers and logs
+	KeyPrefix = "rlyr"
+
+	// usageRetention is how long daily usage counters are kept in Redis
+	usageRetention = 30 * 24 * time.Hour
+)
+
+// Service manages API key issuance, validation and usage accounting
+type Service struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	secret      []byte
+	logger      *zap.Logger
+}
+
+// NewService creates a new API key service.
+// The secret is used as an HMAC key so that a leaked database alone
+// is not enough to brute-force stored key hashes.
+func NewService(db *gorm.DB, redisClient *redis.Client, secret string, logger *zap.Logger) *Service {
+	return &Service{
+		db:          db,
+		redisClient: redisClient,
+		secret:      []byte(secret),
+		logger:      logger.With(zap.String("component", "api_keys")),
+	}
+}
+
+// Create issues a new API key and returns the plaintext value once
+func (s *Service) Create(ctx context.Context, req CreateKeyRequest, createdBy string) (*CreateKeyResponse, error) {
+	for _, scope := range req.Scopes {
+		if !IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+
+	prefix, err := randomHex(6)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomSecret(32)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := fmt.Sprintf("%s_%s_%s", KeyPrefix, prefix, secret)
+
+	key := &APIKey{
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Prefix:        prefix,
+		KeyHash:       s.hashKey(plaintext),
+		Scopes:        req.Scopes,
+		WalletAddress: req.WalletAddress,
+		DailyQuota:    req.DailyQuota,
+		CreatedBy:     createdBy,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if req.ExpiresInDays > 0 {
+		expiresAt := key.CreatedAt.Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.WithContext(ctx).Create(key).Error; err != nil {
+		s.logger.Error("Failed to store api key", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("API key created",
+		zap.String("key_id", key.ID),
+		zap.String("prefix", key.Prefix),
+		zap.String("created_by", createdBy),
+	)
+
+	return &CreateKeyResponse{
+		Key:    plaintext,
+		APIKey: key,
+	}, nil
+}
+
+// Authenticate validates a plaintext key and records its usage.
+// It returns ErrQuotaExceeded once the daily quota has been used up.
+func (s *Service) Authenticate(ctx context.Context, plaintext string) (*APIKey, error) {
+	prefix, err := parsePrefix(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	var key APIKey
+	if err := s.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+
+	// Compare hashes in constant time
+	if !hmac.Equal([]byte(key.KeyHash), []byte(s.hashKey(plaintext))) {
+		return nil, ErrKeyNotFound
+	}
+
+	if key.IsRevoked() {
+		return nil, ErrKeyRevoked
+	}
+	if key.IsExpired() {
+		return nil, ErrKeyExpired
+	}
+
+	used, err := s.recordUsage(ctx, &key)
+	if err != nil {
+		// Don't lock integrators out when Redis is unavailable
+		s.logger.Warn("Failed to record api key usage",
+			zap.String("key_id", key.ID),
+			zap.Error(err),
+		)
+	} else if key.DailyQuota > 0 && used > key.DailyQuota {
+		return &key, ErrQuotaExceeded
+	}
+
+	return &key, nil
+}
+
+// List returns all keys, newest first
+func (s *Service) List(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Get returns a single key by ID
+func (s *Service) Get(ctx context.Context, id string) (*APIKey, error) {
+	var key APIKey
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Revoke permanently disables a key
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	now := time.Now().UTC()
+	result := s.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+
+	s.logger.Info("API key revoked", zap.String("key_id", id))
+	return nil
+}
+
+// UpdateQuota changes the daily quota of a key
+func (s *Service) UpdateQuota(ctx context.Context, id string, dailyQuota int64) error {
+	result := s.db.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ?", id).
+		Update("daily_quota", dailyQuota)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// GetUsage returns usage counters for the last number of days
+func (s *Service) GetUsage(ctx context.Context, id string, days int) (*UsageStats, error) {
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &UsageStats{
+		KeyID:      key.ID,
+		Total:      key.UsageCount,
+		DailyQuota: key.DailyQuota,
+		Remaining:  -1,
+		Daily:      make(map[string]int64, days),
+		LastUsedAt: key.LastUsedAt,
+	}
+
+	now := time.Now().UTC()
+	for i := 0; i < days; i++ {
+		day := now.AddDate(0, 0, -i).Format("2006-01-02")
+		count, err := s.redisClient.Get(ctx, usageKey(key.ID, day)).Int64()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		stats.Daily[day] = count
+	}
+
+	stats.Today = stats.Daily[now.Format("2006-01-02")]
+	if key.DailyQuota > 0 {
+		stats.Remaining = key.DailyQuota - stats.Today
+		if stats.Remaining < 0 {
+			stats.Remaining = 0
+		}
+	}
+
+	return stats, nil
+}
+
+// recordUsage increments the daily counter and updates the persisted totals
+func (s *Service) recordUsage(ctx context.Context, key *APIKey) (int64, error) {
+	now := time.Now().UTC()
+	counterKey := usageKey(key.ID, now.Format("2006-01-02"))
+
+	pipe := s.redisClient.TxPipeline()
+	incr := pipe.Incr(ctx, counterKey)
+	pipe.Expire(ctx, counterKey, usageRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	// Persist totals without blocking the request
+	go func(id string) {
+		err := s.db.Model(&APIKey{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"usage_count":  gorm.Expr("usage_count + 1"),
+				"last_used_at": now,
+			}).Error
+		if err != nil {
+			s.logger.Warn("Failed to persist api key usage", zap.String("key_id", id), zap.Error(err))
+		}
+	}(key.ID)
+
+	return incr.Val(), nil
+}
+
+func (s *Service) hashKey(plaintext string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(plaintext))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// parsePrefix extracts the lookup prefix from a key of the form rlyr_<prefix>_<secret>
+func parsePrefix(plaintext string) (string, error) {
+	parts := strings.SplitN(plaintext, "_", 3)
+	if len(parts) != 3 || parts[0] != KeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", ErrInvalidKey
+	}
+	return parts[1], nil
+}
+
+func usageKey(keyID, day string) string {
+	return fmt.Sprintf("apikey:usage:%s:%s", keyID, day)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// '_' is the key separator, so swap it out of the base64 alphabet
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(b), "_", "-"), nil
+}
@@ -0,0 +1,80 @@
+package apikeys
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&APIKey{}))
+
+	// Unreachable Redis: usage accounting fails open
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
+
+	return NewService(db, redisClient, "test-secret", zap.NewNop())
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	resp, err := svc.Create(ctx, CreateKeyRequest{
+		Name:   "integrator",
+		Scopes: []Scope{ScopeReadPackets},
+	}, "admin")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Key, KeyPrefix+"_"))
+	assert.NotContains(t, resp.APIKey.KeyHash, resp.Key)
+
+	key, err := svc.Authenticate(ctx, resp.Key)
+	require.NoError(t, err)
+	assert.Equal(t, resp.APIKey.ID, key.ID)
+	assert.True(t, key.HasScope(ScopeReadPackets))
+	assert.False(t, key.HasScope(ScopeAdminRefunds))
+
+	// Wrong secret with a valid prefix must not authenticate
+	_, err = svc.Authenticate(ctx, KeyPrefix+"_"+resp.APIKey.Prefix+"_wrong")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	_, err = svc.Authenticate(ctx, "not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestRevokedKeyIsRejected(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	resp, err := svc.Create(ctx, CreateKeyRequest{
+		Name:   "integrator",
+		Scopes: []Scope{ScopeClearingRequest},
+	}, "admin")
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Revoke(ctx, resp.APIKey.ID))
+
+	_, err = svc.Authenticate(ctx, resp.Key)
+	assert.ErrorIs(t, err, ErrKeyRevoked)
+
+	assert.ErrorIs(t, svc.Revoke(ctx, resp.APIKey.ID), ErrKeyNotFound)
+}
+
+func TestCreateRejectsUnknownScope(t *testing.T) {
+	svc := setupTestService(t)
+
+	_, err := svc.Create(context.Background(), CreateKeyRequest{
+		Name:   "integrator",
+		Scopes: []Scope{"write:everything"},
+	}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
@@ -0,0 +1,95 @@
+package apikeys
+
+import (
+	"time"
+)
+
+// Scope represents a permission granted to an API key
+type Scope string
+
+const (
+	ScopeReadPackets     Scope = "read:packets"
+	ScopeClearingRequest Scope = "clearing:request"
+	ScopeAdminRefunds    Scope = "admin:refunds"
+)
+
+// AllScopes lists every scope that can be granted to a key
+var AllScopes = []Scope{
+	ScopeReadPackets,
+	ScopeClearingRequest,
+	ScopeAdminRefunds,
+}
+
+// IsValidScope checks if a scope is known
+func IsValidScope(scope Scope) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey represents a hashed API key in the database
+type APIKey struct {
+	ID            string     `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix" gorm:"uniqueIndex"`
+	KeyHash       string     `json:"-"`
+	Scopes        []Scope    `json:"scopes" gorm:"serializer:json"`
+	WalletAddress string     `json:"walletAddress,omitempty" gorm:"index"`
+	DailyQuota    int64      `json:"dailyQuota"` // 0 means unlimited
+	UsageCount    int64      `json:"usageCount"`
+	CreatedBy     string     `json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt    *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty"`
+}
+
+// HasScope checks if the key was granted a scope
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired checks if the key has passed its expiry time
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// IsRevoked checks if the key has been revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// CreateKeyRequest represents a request to issue a new API key
+type CreateKeyRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Scopes        []Scope `json:"scopes" binding:"required,min=1"`
+	WalletAddress string  `json:"walletAddress,omitempty"`
+	DailyQuota    int64   `json:"dailyQuota" binding:"min=0"`
+	ExpiresInDays int     `json:"expiresInDays" binding:"min=0"`
+}
+
+// CreateKeyResponse is returned once when a key is issued.
+// The plaintext key is never stored and cannot be retrieved again.
+type CreateKeyResponse struct {
+	Key    string  `json:"key"`
+	APIKey *APIKey `json:"apiKey"`
+}
+
+// UsageStats represents usage counters for a key
+type UsageStats struct {
+	KeyID      string           `json:"keyId"`
+	Total      int64            `json:"total"`
+	Today      int64            `json:"today"`
+	DailyQuota int64            `json:"dailyQuota"`
+	Remaining  int64            `json:"remaining"` // -1 when unlimited
+	Daily      map[string]int64 `json:"daily"`
+	LastUsedAt *time.Time       `json:"lastUsedAt,omitempty"`
+}
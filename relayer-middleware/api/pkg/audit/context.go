@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type actorKey struct{}
+
+// WithActor attaches an actor to a context for services that record
+// audit entries outside of an HTTP handler
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or the system actor
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: ActorSystem, ID: "system"}
+}
+
+// ActorFromGin identifies the caller from values set by the auth middleware
+func ActorFromGin(c *gin.Context) Actor {
+	if keyID := c.GetString("api_key_id"); keyID != "" {
+		return Actor{Type: ActorAPIKey, ID: keyID}
+	}
+	if username := c.GetString("username"); username != "" {
+		return Actor{Type: ActorOperator, ID: username}
+	}
+	if wallet := c.GetString("wallet"); wallet != "" {
+		return Actor{Type: ActorWallet, ID: wallet}
+	}
+	return Actor{Type: ActorOperator, ID: "anonymous@" + c.ClientIP()}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID attaches the originating request ID to a context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// FieldChange describes how a single field changed
+type FieldChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// TextDiff lists lines removed from and added to a text value
+type TextDiff struct {
+	Removed []string `json:"removed,omitempty"`
+	Added   []string `json:"added,omitempty"`
+}
+
+// Diff summarises the change between before and after as JSON.
+// Strings (e.g. config files) get a line diff; other values are compared
+// field by field after JSON encoding. Returns "" when nothing changed.
+func Diff(before, after interface{}) string {
+	if before == nil && after == nil {
+		return ""
+	}
+
+	beforeText, beforeIsText := before.(string)
+	afterText, afterIsText := after.(string)
+	if beforeIsText || afterIsText {
+		if beforeText == afterText {
+			return ""
+		}
+		return marshal(lineDiff(beforeText, afterText))
+	}
+
+	beforeMap := toMap(before)
+	afterMap := toMap(after)
+
+	changes := make(map[string]FieldChange)
+	for key, value := range beforeMap {
+		if other, ok := afterMap[key]; !ok || !reflect.DeepEqual(value, other) {
+			changes[key] = FieldChange{Before: value, After: afterMap[key]}
+		}
+	}
+	for key, value := range afterMap {
+		if _, ok := beforeMap[key]; !ok {
+			changes[key] = FieldChange{After: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return ""
+	}
+	return marshal(changes)
+}
+
+// lineDiff returns lines present only in before and only in after, in order
+func lineDiff(before, after string) TextDiff {
+	beforeLines := strings.Split(before, "\n")
+	afterLines := strings.Split(after, "\n")
+
+	beforeCount := make(map[string]int)
+	for _, line := range beforeLines {
+		beforeCount[line]++
+	}
+	afterCount := make(map[string]int)
+	for _, line := range afterLines {
+		afterCount[line]++
+	}
+
+	var diff TextDiff
+	for _, line := range beforeLines {
+		if afterCount[line] > 0 {
+			afterCount[line]--
+			continue
+		}
+		diff.Removed = append(diff.Removed, line)
+	}
+	for _, line := range afterLines {
+		if beforeCount[line] > 0 {
+			beforeCount[line]--
+			continue
+		}
+		diff.Added = append(diff.Added, line)
+	}
+	return diff
+}
+
+// toMap converts a value into a generic map via JSON; scalars map to "value"
+func toMap(value interface{}) map[string]interface{} {
+	if value == nil {
+		return map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err == nil {
+		return m
+	}
+
+	var scalar interface{}
+	json.Unmarshal(data, &scalar)
+	return map[string]interface{}{"value": scalar}
+}
+
+func marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
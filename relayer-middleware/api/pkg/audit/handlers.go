@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes the audit log to operators
+type Handlers struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandlers creates new audit handlers
+func NewHandlers(service *Service, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		service: service,
+		logger:  logger.With(zap.String("component", "audit_handlers")),
+	}
+}
+
+// RegisterRoutes registers the audit routes. The caller is expected
+// to protect the group with operator authentication.
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/audit", h.ListEntries)
+	router.GET("/audit/verify", h.VerifyChain)
+}
+
+// ListEntries handles GET /admin/audit
+func (h *Handlers) ListEntries(c *gin.Context) {
+	var filter Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filter", "details": err.Error()})
+		return
+	}
+
+	entries, total, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list audit entries", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   total,
+	})
+}
+
+// VerifyChain handles GET /admin/audit/verify
+func (h *Handlers) VerifyChain(c *gin.Context) {
+	result, err := h.service.Verify(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to verify audit chain", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify audit log"})
+		return
+	}
+
+	if !result.Valid {
+		h.logger.Error("Audit chain integrity check failed",
+			zap.Uint64("broken_at", result.BrokenAt),
+			zap.String("reason", result.Reason),
+		)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// appendOnlyPostgres rejects any change to existing entries, as in
+// migrations/004_audit_log.up.sql
+var appendOnlyPostgres = []string{
+	`CREATE OR REPLACE FUNCTION audit_entries_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_entries is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_entries_no_update ON audit_entries`,
+	`CREATE TRIGGER audit_entries_no_update
+    BEFORE UPDATE OR DELETE ON audit_entries
+    FOR EACH ROW EXECUTE FUNCTION audit_entries_append_only()`,
+	`DROP TRIGGER IF EXISTS audit_entries_no_truncate ON audit_entries`,
+	`CREATE TRIGGER audit_entries_no_truncate
+    BEFORE TRUNCATE ON audit_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_entries_append_only()`,
+}
+
+// appendOnlySQLite is the same for SQLite, which has no TRUNCATE
+var appendOnlySQLite = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_update
+    BEFORE UPDATE ON audit_entries
+    BEGIN SELECT RAISE(ABORT, 'audit_entries is append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete
+    BEFORE DELETE ON audit_entries
+    BEGIN SELECT RAISE(ABORT, 'audit_entries is append-only'); END`,
+}
+
+// AutoMigrate creates the audit_entries table and the triggers that make
+// it append-only
+func AutoMigrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&Entry{}); err != nil {
+		return err
+	}
+
+	var statements []string
+	switch db.Dialector.Name() {
+	case "postgres":
+		statements = appendOnlyPostgres
+	case "sqlite":
+		statements = appendOnlySQLite
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("make audit_entries append-only: %w", err)
+		}
+	}
+	return nil
+}
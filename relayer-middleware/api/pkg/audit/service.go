@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var errStopVerify = errors.New("stop verify")
+
+// advisoryLockKey serialises appends across API instances on Postgres
+const advisoryLockKey = 7_210_001
+
+// Service appends entries to the audit log and queries it
+type Service struct {
+	db     *gorm.DB
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// NewService creates a new audit service
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger.With(zap.String("component", "audit")),
+	}
+}
+
+// Record appends an event to the log, chaining it to the previous entry
+func (s *Service) Record(ctx context.Context, event Event) (*Entry, error) {
+	entry := &Entry{
+		Timestamp: time.Now().UTC().Truncate(time.Microsecond),
+		ActorType: event.Actor.Type,
+		ActorID:   event.Actor.ID,
+		Action:    event.Action,
+		Target:    event.Target,
+		Before:    toText(event.Before),
+		After:     toText(event.After),
+		Diff:      Diff(event.Before, event.After),
+		RequestID: event.RequestID,
+		Outcome:   OutcomeSuccess,
+	}
+	if event.Err != nil {
+		entry.Outcome = OutcomeFailure
+		entry.Error = event.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error; err != nil {
+				return err
+			}
+		}
+
+		var prev Entry
+		if err := tx.Order("id DESC").Limit(1).Find(&prev).Error; err != nil {
+			return err
+		}
+
+		entry.PrevHash = prev.Hash
+		entry.Hash = computeHash(entry)
+		return tx.Create(entry).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// RecordRequest records an event performed during an HTTP request,
+// taking the actor and request ID from the gin context. Failures to
+// write the audit entry are logged rather than failing the request.
+func (s *Service) RecordRequest(c *gin.Context, action, target string, before, after interface{}, actionErr error) {
+	if s == nil {
+		return
+	}
+
+	_, err := s.Record(c.Request.Context(), Event{
+		Actor:     ActorFromGin(c),
+		Action:    action,
+		Target:    target,
+		Before:    before,
+		After:     after,
+		RequestID: c.GetString("request_id"),
+		Err:       actionErr,
+	})
+	if err != nil {
+		s.logger.Error("Failed to write audit entry",
+			zap.String("action", action),
+			zap.String("target", target),
+			zap.Error(err),
+		)
+	}
+}
+
+// List returns entries matching the filter, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]Entry, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Entry{})
+
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ActorType != "" {
+		query = query.Where("actor_type = ?", filter.ActorType)
+	}
+	if filter.Action != "" {
+		// Allow prefix filters such as "relayer." or "relayer.hermes.*"
+		if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+			query = query.Where("action LIKE ?", prefix+"%")
+		} else {
+			query = query.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("timestamp >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("timestamp <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize := filter.Page, filter.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 50
+	}
+
+	var entries []Entry
+	if err := query.Order("id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// Verify walks the whole chain and reports the first entry whose hash or
+// link does not match, which indicates tampering
+func (s *Service) Verify(ctx context.Context) (*VerifyResult, error) {
+	result := &VerifyResult{Valid: true}
+
+	var batch []Entry
+	prevHash := ""
+	err := s.db.WithContext(ctx).Order("id ASC").FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			entry := &batch[i]
+			result.Entries++
+
+			if entry.PrevHash != prevHash {
+				result.Valid = false
+				result.BrokenAt = entry.ID
+				result.Reason = "previous hash mismatch"
+				return errStopVerify
+			}
+			if computeHash(entry) != entry.Hash {
+				result.Valid = false
+				result.BrokenAt = entry.ID
+				result.Reason = "entry hash mismatch"
+				return errStopVerify
+			}
+			prevHash = entry.Hash
+		}
+		return nil
+	}).Error
+	if err != nil && err != errStopVerify {
+		return nil, err
+	}
+
+	result.HeadHash = prevHash
+	return result, nil
+}
+
+// computeHash hashes the entry contents together with the previous hash
+func computeHash(entry *Entry) string {
+	fields := []string{
+		entry.PrevHash,
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		entry.ActorType,
+		entry.ActorID,
+		entry.Action,
+		entry.Target,
+		entry.Before,
+		entry.After,
+		entry.Diff,
+		entry.RequestID,
+		entry.Outcome,
+		entry.Error,
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		// Length-prefix each field so boundaries can't be shifted
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte{':'})
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// toText renders a before/after value for storage
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestService(t *testing.T) (*Service, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Entry{}))
+	return NewService(db, zap.NewNop()), db
+}
+
+func TestRecordChainsEntries(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+	actor := Actor{Type: ActorOperator, ID: "admin"}
+
+	first, err := svc.Record(ctx, Event{Actor: actor, Action: ActionHermesStart, Target: "hermes", RequestID: "req-1"})
+	require.NoError(t, err)
+	assert.Empty(t, first.PrevHash)
+	assert.Equal(t, OutcomeSuccess, first.Outcome)
+
+	second, err := svc.Record(ctx, Event{Actor: actor, Action: ActionHermesStop, Target: "hermes", Err: errors.New("exit status 1")})
+	require.NoError(t, err)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Equal(t, OutcomeFailure, second.Outcome)
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(2), result.Entries)
+	assert.Equal(t, second.Hash, result.HeadHash)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	svc, db := setupTestService(t)
+	ctx := context.Background()
+	actor := Actor{Type: ActorOperator, ID: "admin"}
+
+	for _, target := range []string{"hermes", "go-relayer", "hermes"} {
+		_, err := svc.Record(ctx, Event{Actor: actor, Action: ActionConfigUpdate, Target: target})
+		require.NoError(t, err)
+	}
+
+	// Rewrite history behind the service's back
+	require.NoError(t, db.Exec("UPDATE audit_entries SET actor_id = ? WHERE id = ?", "someone-else", 2).Error)
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, uint64(2), result.BrokenAt)
+}
+
+func TestAutoMigrateAppendOnly(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, AutoMigrate(db))
+	// Running it again on every start is fine
+	require.NoError(t, AutoMigrate(db))
+
+	svc := NewService(db, zap.NewNop())
+	ctx := context.Background()
+	actor := Actor{Type: ActorOperator, ID: "admin"}
+	for _, target := range []string{"hermes", "go-relayer"} {
+		_, err := svc.Record(ctx, Event{Actor: actor, Action: ActionConfigUpdate, Target: target})
+		require.NoError(t, err)
+	}
+
+	err = db.Exec("UPDATE audit_entries SET actor_id = ? WHERE id = ?", "someone-else", 1).Error
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "append-only")
+	assert.Error(t, db.Exec("DELETE FROM audit_entries WHERE id = ?", 2).Error)
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(2), result.Entries)
+}
+
+func TestListFilters(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.Record(ctx, Event{Actor: Actor{Type: ActorOperator, ID: "admin"}, Action: ActionHermesStart, Target: "hermes"})
+	require.NoError(t, err)
+	_, err = svc.Record(ctx, Event{Actor: Actor{Type: ActorAPIKey, ID: "key-1"}, Action: ActionPacketsClear, Target: "osmosis-1/channel-0"})
+	require.NoError(t, err)
+
+	entries, total, err := svc.List(ctx, Filter{Action: "relayer.*"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, ActionHermesStart, entries[0].Action)
+
+	_, total, err = svc.List(ctx, Filter{ActorType: ActorAPIKey})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestDiff(t *testing.T) {
+	assert.Empty(t, Diff("a\nb", "a\nb"))
+	assert.JSONEq(t, `{"removed":["b = 1"],"added":["b = 2"]}`, Diff("a\nb = 1", "a\nb = 2"))
+	assert.JSONEq(t, `{"status":{"before":"stopped","after":"running"}}`,
+		Diff(map[string]string{"status": "stopped", "name": "hermes"}, map[string]string{"status": "running", "name": "hermes"}))
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"time"
+)
+
+// Actor types
+const (
+	ActorOperator = "operator"
+	ActorAPIKey   = "api_key"
+	ActorWallet   = "wallet"
+	ActorSystem   = "system"
+)
+
+// Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Actions
+const (
+	ActionHermesStart    = "relayer.hermes.start"
+	ActionHermesStop     = "relayer.hermes.stop"
+	ActionHermesRestart  = "relayer.hermes.restart"
+	ActionRlyStart       = "relayer.rly.start"
+	ActionRlyStop        = "relayer.rly.stop"
+	ActionRlyRestart     = "relayer.rly.restart"
+	ActionConfigUpdate   = "relayer.config.update"
+	ActionConfigRollback = "relayer.config.rollback"
+	ActionPacketsClear   = "ibc.packets.clear"
+	ActionClientUpdate   = "ibc.client.update"
+	ActionRefundProcess  = "clearing.refund.process"
+)
+
+// Actor identifies who performed an action
+type Actor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Entry is a single record in the append-only audit log.
+// Hash covers every other field plus PrevHash, chaining entries together.
+type Entry struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Timestamp time.Time `json:"timestamp" gorm:"index"`
+	ActorType string    `json:"actorType" gorm:"index"`
+	ActorID   string    `json:"actorId" gorm:"index"`
+	Action    string    `json:"action" gorm:"index"`
+	Target    string    `json:"target" gorm:"index"`
+	Before    string    `json:"before,omitempty" gorm:"type:text"`
+	After     string    `json:"after,omitempty" gorm:"type:text"`
+	Diff      string    `json:"diff,omitempty" gorm:"type:text"`
+	RequestID string    `json:"requestId,omitempty" gorm:"index"`
+	Outcome   string    `json:"outcome" gorm:"index"`
+	Error     string    `json:"error,omitempty"`
+	PrevHash  string    `json:"prevHash"`
+	Hash      string    `json:"hash" gorm:"uniqueIndex"`
+}
+
+// TableName keeps the table name stable for the SQL migration triggers
+func (Entry) TableName() string {
+	return "audit_entries"
+}
+
+// Event describes an action to record
+type Event struct {
+	Actor     Actor
+	Action    string
+	Target    string
+	Before    interface{}
+	After     interface{}
+	RequestID string
+	Err       error
+}
+
+// Filter narrows audit log queries
+type Filter struct {
+	ActorID   string    `form:"actor"`
+	ActorType string    `form:"actor_type"`
+	Action    string    `form:"action"`
+	Target    string    `form:"target"`
+	Outcome   string    `form:"outcome" binding:"omitempty,oneof=success failure"`
+	RequestID string    `form:"request_id"`
+	From      time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int       `form:"page" binding:"omitempty,min=1"`
+	PageSize  int       `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// VerifyResult reports the integrity of the hash chain
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Entries  int64  `json:"entries"`
+	BrokenAt uint64 `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	HeadHash string `json:"headHash,omitempty"`
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+	ErrFamilyRevoked        = errors.New("token family revoked")
+)
+
+// RefreshTokenRecord is the server-side state of an issued refresh token
+type RefreshTokenRecord struct {
+	ID        string    `json:"id"`
+	FamilyID  string    `json:"family_id"`
+	Username  string    `json:"username"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenStore persists refresh token families and revoked access tokens in Redis.
+//
+// Each login starts a family. Every refresh consumes the presented token and
+// issues a successor in the same family; presenting a consumed token again
+// means it leaked, so the whole family is revoked.
+type TokenStore struct {
+	redisClient *redis.Client
+	familyTTL   time.Duration
+	logger      *zap.Logger
+}
+
+// NewTokenStore creates a new token store. familyTTL should match the
+// refresh token lifetime so revocation markers outlive every family member.
+func NewTokenStore(redisClient *redis.Client, familyTTL time.Duration, logger *zap.Logger) *TokenStore {
+	return &TokenStore{
+		redisClient: redisClient,
+		familyTTL:   familyTTL,
+		logger:      logger.With(zap.String("component", "token_store")),
+	}
+}
+
+// SaveRefreshToken records a newly issued refresh token
+func (s *TokenStore) SaveRefreshToken(ctx context.Context, record RefreshTokenRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("refresh token already expired")
+	}
+
+	return s.redisClient.Set(ctx, refreshKey(record.ID), data, ttl).Err()
+}
+
+// ConsumeRefreshToken marks a refresh token as used and returns its record.
+// A second use of the same token revokes its family and returns ErrRefreshTokenReused.
+func (s *TokenStore) ConsumeRefreshToken(ctx context.Context, id string) (*RefreshTokenRecord, error) {
+	data, err := s.redisClient.Get(ctx, refreshKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record RefreshTokenRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	revoked, err := s.IsFamilyRevoked(ctx, record.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrFamilyRevoked
+	}
+
+	// SETNX makes consumption one-time even under concurrent refreshes
+	first, err := s.redisClient.SetNX(ctx, usedKey(id), time.Now().Unix(), time.Until(record.ExpiresAt)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		s.logger.Warn("Refresh token reuse detected, revoking family",
+			zap.String("family_id", record.FamilyID),
+			zap.String("username", record.Username),
+		)
+		if err := s.RevokeFamily(ctx, record.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	return &record, nil
+}
+
+// RevokeFamily revokes every refresh and access token issued in a family
+func (s *TokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.redisClient.Set(ctx, familyRevokedKey(familyID), time.Now().Unix(), s.familyTTL).Err()
+}
+
+// IsFamilyRevoked checks if a token family has been revoked
+func (s *TokenStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	if familyID == "" {
+		return false, nil
+	}
+	n, err := s.redisClient.Exists(ctx, familyRevokedKey(familyID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// DenyAccessToken adds an access token to the denylist until it expires
+func (s *TokenStore) DenyAccessToken(ctx context.Context, id string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if id == "" || ttl <= 0 {
+		return nil
+	}
+	return s.redisClient.Set(ctx, denylistKey(id), time.Now().Unix(), ttl).Err()
+}
+
+// IsRevoked reports whether an access token was denylisted or belongs to a
+// revoked family. It implements middleware.TokenDenylist.
+func (s *TokenStore) IsRevoked(ctx context.Context, tokenID, familyID string) (bool, error) {
+	var keys []string
+	if familyID != "" {
+		keys = append(keys, familyRevokedKey(familyID))
+	}
+	if tokenID != "" {
+		keys = append(keys, denylistKey(tokenID))
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+
+	n, err := s.redisClient.Exists(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func refreshKey(id string) string {
+	return fmt.Sprintf("auth:refresh:%s", id)
+}
+
+func usedKey(id string) string {
+	return fmt.Sprintf("auth:refresh:%s:used", id)
+}
+
+func familyRevokedKey(familyID string) string {
+	return fmt.Sprintf("auth:family:%s:revoked", familyID)
+}
+
+func denylistKey(id string) string {
+	return fmt.Sprintf("auth:denylist:%s", id)
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestStore(t *testing.T) (*TokenStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	return NewTokenStore(redisClient, 7*24*time.Hour, zap.NewNop()), mr
+}
+
+func saveToken(t *testing.T, store *TokenStore, id, familyID string) {
+	t.Helper()
+	require.NoError(t, store.SaveRefreshToken(context.Background(), RefreshTokenRecord{
+		ID:        id,
+		FamilyID:  familyID,
+		Username:  "admin",
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+}
+
+func TestRotateThenReuseRevokesFamily(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	saveToken(t, store, "refresh-1", "family-1")
+	record, err := store.ConsumeRefreshToken(ctx, "refresh-1")
+	require.NoError(t, err)
+	assert.Equal(t, "family-1", record.FamilyID)
+	assert.Equal(t, "admin", record.Username)
+
+	// Rotated into a successor, as a refresh does
+	saveToken(t, store, "refresh-2", "family-1")
+	saveToken(t, store, "other-1", "family-2")
+
+	// Replaying the consumed token means it leaked
+	_, err = store.ConsumeRefreshToken(ctx, "refresh-1")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	revoked, err := store.IsFamilyRevoked(ctx, "family-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// The successor and the family's access tokens are revoked with it
+	_, err = store.ConsumeRefreshToken(ctx, "refresh-2")
+	assert.ErrorIs(t, err, ErrFamilyRevoked)
+	revoked, err = store.IsRevoked(ctx, "access-1", "family-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Other sessions are unaffected
+	_, err = store.ConsumeRefreshToken(ctx, "other-1")
+	assert.NoError(t, err)
+	revoked, err = store.IsRevoked(ctx, "access-2", "family-2")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	_, err = store.ConsumeRefreshToken(ctx, "never-issued")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+}
+
+func TestConcurrentRefreshConsumesOnce(t *testing.T) {
+	store, _ := newTestStore(t)
+	saveToken(t, store, "refresh-1", "family-1")
+
+	const attempts = 20
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		errs      []error
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.ConsumeRefreshToken(context.Background(), "refresh-1")
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+			} else {
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, succeeded)
+	require.Len(t, errs, attempts-1)
+	for _, err := range errs {
+		// Losers either tripped reuse detection or saw the family it revoked
+		assert.True(t, err == ErrRefreshTokenReused || err == ErrFamilyRevoked, err)
+	}
+}
+
+func TestDenyAccessToken(t *testing.T) {
+	store, mr := newTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.DenyAccessToken(ctx, "access-1", time.Now().Add(time.Hour)))
+	revoked, err := store.IsRevoked(ctx, "access-1", "")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsRevoked(ctx, "access-2", "")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Nothing to store for a token that has already expired
+	require.NoError(t, store.DenyAccessToken(ctx, "access-3", time.Now().Add(-time.Minute)))
+	assert.False(t, mr.Exists(denylistKey("access-3")))
+
+	// The entry lasts as long as the token would have
+	mr.FastForward(time.Hour + time.Second)
+	revoked, err = store.IsRevoked(ctx, "access-1", "")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
@@ -0,0 +1,130 @@
+package balances
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+// defaultMaxGas is assumed when a config doesn't set one
+const defaultMaxGas = 400000
+
+// commandRunner runs a command and returns its output
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// discovered is an account found in a relayer config, err is set when its
+// address couldn't be resolved
+type discovered struct {
+	Account
+	err error
+}
+
+// hermesAccounts lists the accounts of a Hermes config. Addresses come from
+// the test keyring Hermes keeps at <keysDir>/<chain>/keyring-test/<key>.json.
+func hermesAccounts(content, keysDir string) ([]discovered, error) {
+	chains, err := relayerconfig.ListHermesChains(content)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]discovered, 0, len(chains))
+	for _, chain := range chains {
+		account := Account{
+			Relayer: relayerconfig.RelayerHermes,
+			ChainID: chain.ID,
+			KeyName: "relayer",
+			MaxGas:  defaultMaxGas,
+		}
+		if chain.KeyName != nil {
+			account.KeyName = *chain.KeyName
+		}
+		if chain.GasPrice != nil {
+			if chain.GasPrice.Denom != nil {
+				account.Denom = *chain.GasPrice.Denom
+			}
+			if chain.GasPrice.Price != nil {
+				account.GasPrice = *chain.GasPrice.Price
+			}
+		}
+		if chain.MaxGas != nil {
+			account.MaxGas = *chain.MaxGas
+		}
+
+		address, err := hermesKeyAddress(keysDir, chain.ID, account.KeyName)
+		account.Address = address
+		accounts = append(accounts, discovered{Account: account, err: err})
+	}
+	return accounts, nil
+}
+
+func hermesKeyAddress(keysDir, chainID, keyName string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(keysDir, chainID, "keyring-test", keyName+".json"))
+	if err != nil {
+		return "", fmt.Errorf("read hermes key: %w", err)
+	}
+
+	var key struct {
+		Account string `json:"account"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", fmt.Errorf("parse hermes key: %w", err)
+	}
+	if key.Account == "" {
+		return "", fmt.Errorf("hermes key %s on %s has no account", keyName, chainID)
+	}
+	return key.Account, nil
+}
+
+// rlyAccounts lists the accounts of a rly config, resolving addresses
+// with `rly keys show`
+func rlyAccounts(ctx context.Context, content string, run commandRunner) ([]discovered, error) {
+	chains, err := relayerconfig.ListRlyChains(content)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]discovered, 0, len(chains))
+	for _, chain := range chains {
+		account := Account{
+			Relayer: relayerconfig.RelayerRly,
+			ChainID: chain.ChainID,
+			KeyName: chain.Key,
+			MaxGas:  defaultMaxGas,
+		}
+		account.GasPrice, account.Denom = parseGasPrice(chain.GasPrices)
+
+		var resolveErr error
+		output, err := run(ctx, "rly", "keys", "show", chain.Name, chain.Key)
+		if err != nil {
+			resolveErr = fmt.Errorf("rly keys show %s: %w", chain.Name, err)
+		} else {
+			account.Address = strings.TrimSpace(string(output))
+		}
+		accounts = append(accounts, discovered{Account: account, err: resolveErr})
+	}
+	return accounts, nil
+}
+
+var gasPricePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]*)$`)
+
+// parseGasPrice splits a rly gas price like "0.025uatom"
+func parseGasPrice(value string) (float64, string) {
+	match := gasPricePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, ""
+	}
+	price, _ := strconv.ParseFloat(match[1], 64)
+	return price, match[2]
+}
@@ -0,0 +1,46 @@
+package balances
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes relayer account balances
+type Handlers struct {
+	monitor *Monitor
+	logger  *zap.Logger
+}
+
+// NewHandlers creates balance handlers
+func NewHandlers(monitor *Monitor, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		monitor: monitor,
+		logger:  logger.With(zap.String("component", "balance_handlers")),
+	}
+}
+
+// RegisterRoutes registers balance routes on the relayer group
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/balances", h.GetBalances)
+	router.POST("/balances/check", h.CheckBalances)
+}
+
+// GetBalances handles GET /relayer/balances
+func (h *Handlers) GetBalances(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": h.monitor.Balances(c.Query("relayer")),
+		"alerts":   h.monitor.Alerts(),
+	})
+}
+
+// CheckBalances handles POST /relayer/balances/check, checking every
+// account now instead of waiting for the next interval
+func (h *Handlers) CheckBalances(c *gin.Context) {
+	accounts := h.monitor.Check(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": accounts,
+		"alerts":   h.monitor.Alerts(),
+	})
+}
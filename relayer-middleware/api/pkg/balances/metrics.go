@@ -0,0 +1,87 @@
+package balances
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var statusValues = map[string]float64{
+	StatusOK:       0,
+	StatusWarning:  1,
+	StatusCritical: 2,
+	StatusUnknown:  -1,
+}
+
+type metrics struct {
+	balance      *prometheus.GaugeVec
+	spendPerHour *prometheus.GaugeVec
+	runwayHours  *prometheus.GaugeVec
+	status       *prometheus.GaugeVec
+	checkErrors  *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	labels := []string{"relayer", "chain_id", "address", "denom"}
+	return &metrics{
+		balance: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_relayer_account_balance",
+			Help: "Fee token balance of a relayer account in base units",
+		}, labels),
+		spendPerHour: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_relayer_account_spend_per_hour",
+			Help: "Recent fee spend of a relayer account in base units per hour",
+		}, labels),
+		runwayHours: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_relayer_account_runway_hours",
+			Help: "Estimated hours until a relayer account runs out of fees",
+		}, labels),
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_relayer_account_status",
+			Help: "Relayer account status: 0 ok, 1 warning, 2 critical, -1 unknown",
+		}, labels),
+		checkErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "relayooor_relayer_account_check_errors_total",
+			Help: "Failed relayer account balance checks",
+		}, []string{"relayer", "chain_id"}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.balance, m.spendPerHour, m.runwayHours, m.status, m.checkErrors}
+}
+
+func (m *metrics) observe(b AccountBalance) {
+	labels := prometheus.Labels{
+		"relayer":  b.Relayer,
+		"chain_id": b.ChainID,
+		"address":  b.Address,
+		"denom":    b.Denom,
+	}
+
+	m.status.With(labels).Set(statusValues[b.Status])
+	if b.Error != "" {
+		m.checkErrors.WithLabelValues(b.Relayer, b.ChainID).Inc()
+		return
+	}
+
+	m.balance.With(labels).Set(b.Balance)
+	m.spendPerHour.With(labels).Set(b.SpendPerHour)
+	if b.RunwayHours != nil {
+		m.runwayHours.With(labels).Set(*b.RunwayHours)
+	} else {
+		m.runwayHours.Delete(labels)
+	}
+}
+
+// forget removes the series of an account that's no longer configured
+func (m *metrics) forget(b AccountBalance) {
+	labels := prometheus.Labels{
+		"relayer":  b.Relayer,
+		"chain_id": b.ChainID,
+		"address":  b.Address,
+		"denom":    b.Denom,
+	}
+	m.balance.Delete(labels)
+	m.spendPerHour.Delete(labels)
+	m.runwayHours.Delete(labels)
+	m.status.Delete(labels)
+}
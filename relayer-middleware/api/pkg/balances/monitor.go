@@ -0,0 +1,353 @@
+package balances
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/relayerconfig"
+)
+
+// minSpendWindow is how much history is needed before a spend rate is trusted
+const minSpendWindow = 15 * time.Minute
+
+// sample is one observed balance
+type sample struct {
+	at      time.Time
+	balance float64
+}
+
+// Monitor checks the balances of the relayer accounts, estimates how long
+// they'll last at the recent spend rate and raises alerts before they run dry
+type Monitor struct {
+	configs    ConfigReader
+	querier    Querier
+	keysDir    string
+	run        commandRunner
+	interval   time.Duration
+	window     time.Duration
+	thresholds Thresholds
+	notifier   Notifier
+	metrics    *metrics
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	history  map[string][]sample
+	balances map[string]AccountBalance
+
+	logger *zap.Logger
+}
+
+// NewMonitor creates a balance monitor. Settings come from HERMES_KEYS_DIR,
+// BALANCE_CHECK_INTERVAL, BALANCE_SPEND_WINDOW, BALANCE_RUNWAY_WARNING,
+// BALANCE_RUNWAY_CRITICAL and BALANCE_MIN_TXS.
+func NewMonitor(configs ConfigReader, querier Querier, logger *zap.Logger) *Monitor {
+	minTxs, err := strconv.Atoi(os.Getenv("BALANCE_MIN_TXS"))
+	if err != nil || minTxs < 1 {
+		minTxs = 10
+	}
+
+	keysDir := os.Getenv("HERMES_KEYS_DIR")
+	if keysDir == "" {
+		keysDir = "/home/relayer/.hermes/keys"
+	}
+
+	return &Monitor{
+		configs:  configs,
+		querier:  querier,
+		keysDir:  keysDir,
+		run:      runCommand,
+		interval: database.EnvDuration("BALANCE_CHECK_INTERVAL", 5*time.Minute),
+		window:   database.EnvDuration("BALANCE_SPEND_WINDOW", 24*time.Hour),
+		thresholds: Thresholds{
+			RunwayWarning:  database.EnvDuration("BALANCE_RUNWAY_WARNING", 72*time.Hour),
+			RunwayCritical: database.EnvDuration("BALANCE_RUNWAY_CRITICAL", 24*time.Hour),
+			MinTxs:         minTxs,
+		},
+		metrics:  newMetrics(),
+		now:      time.Now,
+		history:  make(map[string][]sample),
+		balances: make(map[string]AccountBalance),
+		logger:   logger.With(zap.String("component", "balance_monitor")),
+	}
+}
+
+// UseNotifier sets where alerts are sent in addition to the log
+func (m *Monitor) UseNotifier(notifier Notifier) {
+	m.notifier = notifier
+}
+
+// Register adds the monitor's metrics to a Prometheus registry
+func (m *Monitor) Register(registerer prometheus.Registerer) error {
+	for _, collector := range m.metrics.collectors() {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start checks balances immediately and then every interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			m.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check discovers the relayer accounts and checks each balance
+func (m *Monitor) Check(ctx context.Context) []AccountBalance {
+	accounts := m.discover(ctx)
+
+	results := make([]AccountBalance, 0, len(accounts))
+	seen := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		result := m.check(ctx, account)
+		seen[account.key()] = true
+		results = append(results, result)
+	}
+
+	m.mu.Lock()
+	for key, previous := range m.balances {
+		if !seen[key] {
+			delete(m.balances, key)
+			delete(m.history, key)
+			m.metrics.forget(previous)
+		}
+	}
+	m.mu.Unlock()
+
+	sortBalances(results)
+	return results
+}
+
+// Balances returns the latest check of every account, or of one relayer's
+func (m *Monitor) Balances(relayer string) []AccountBalance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]AccountBalance, 0, len(m.balances))
+	for _, balance := range m.balances {
+		if relayer == "" || balance.Relayer == relayer {
+			results = append(results, balance)
+		}
+	}
+	sortBalances(results)
+	return results
+}
+
+// Alerts returns the accounts currently in warning or critical state
+func (m *Monitor) Alerts() []Alert {
+	var alerts []Alert
+	for _, balance := range m.Balances("") {
+		if balance.Status == StatusWarning || balance.Status == StatusCritical {
+			alerts = append(alerts, newAlert(balance, balance.Status))
+		}
+	}
+	return alerts
+}
+
+func (m *Monitor) discover(ctx context.Context) []discovered {
+	var accounts []discovered
+
+	if content, err := m.configs.Current(relayerconfig.RelayerHermes); err != nil {
+		m.logger.Warn("Failed to read Hermes config", zap.Error(err))
+	} else if content != "" {
+		found, err := hermesAccounts(content, m.keysDir)
+		if err != nil {
+			m.logger.Warn("Failed to parse Hermes config", zap.Error(err))
+		}
+		accounts = append(accounts, found...)
+	}
+
+	if content, err := m.configs.Current(relayerconfig.RelayerRly); err != nil {
+		m.logger.Warn("Failed to read rly config", zap.Error(err))
+	} else if content != "" {
+		found, err := rlyAccounts(ctx, content, m.run)
+		if err != nil {
+			m.logger.Warn("Failed to parse rly config", zap.Error(err))
+		}
+		accounts = append(accounts, found...)
+	}
+
+	return accounts
+}
+
+func (m *Monitor) check(ctx context.Context, account discovered) AccountBalance {
+	now := m.now()
+	result := AccountBalance{Account: account.Account, CheckedAt: now}
+
+	err := account.err
+	if err == nil && account.Denom == "" {
+		err = fmt.Errorf("no fee denom configured for %s", account.ChainID)
+	}
+	if err == nil {
+		result.Balance, err = m.querier.Balance(ctx, account.ChainID, account.Address, account.Denom)
+	}
+
+	m.mu.Lock()
+	previous, known := m.balances[account.key()]
+	if err != nil {
+		result.Status = StatusUnknown
+		result.Error = err.Error()
+	} else {
+		history := m.record(account.key(), now, result.Balance)
+		result.SpendPerHour = spendPerHour(history)
+		if result.SpendPerHour > 0 {
+			runway := result.Balance / result.SpendPerHour
+			result.RunwayHours = &runway
+		}
+		result.Status, result.Reason = m.evaluate(result)
+	}
+	m.balances[account.key()] = result
+	m.mu.Unlock()
+
+	m.metrics.observe(result)
+
+	previousStatus := StatusOK
+	if known {
+		previousStatus = previous.Status
+	}
+	m.transition(result, previousStatus)
+	return result
+}
+
+// record appends a sample and drops those older than the spend window
+func (m *Monitor) record(key string, at time.Time, balance float64) []sample {
+	history := append(m.history[key], sample{at: at, balance: balance})
+	cutoff := at.Add(-m.window)
+	for len(history) > 1 && history[0].at.Before(cutoff) {
+		history = history[1:]
+	}
+	m.history[key] = history
+	return history
+}
+
+// spendPerHour sums the balance decreases in the history. Increases are
+// top-ups and don't offset spend.
+func spendPerHour(history []sample) float64 {
+	if len(history) < 2 {
+		return 0
+	}
+	elapsed := history[len(history)-1].at.Sub(history[0].at)
+	if elapsed < minSpendWindow {
+		return 0
+	}
+
+	var spent float64
+	for i := 1; i < len(history); i++ {
+		if drop := history[i-1].balance - history[i].balance; drop > 0 {
+			spent += drop
+		}
+	}
+	return spent / elapsed.Hours()
+}
+
+// evaluate returns the status of a checked account and why
+func (m *Monitor) evaluate(b AccountBalance) (string, string) {
+	txFee := b.GasPrice * float64(b.MaxGas)
+
+	switch {
+	case b.Balance <= 0:
+		return StatusCritical, "balance is empty"
+	case txFee > 0 && b.Balance < txFee:
+		return StatusCritical, "balance can't pay for a max_gas transaction"
+	case b.RunwayHours != nil && *b.RunwayHours < m.thresholds.RunwayCritical.Hours():
+		return StatusCritical, fmt.Sprintf("runway of %.1fh is below %s", *b.RunwayHours, m.thresholds.RunwayCritical)
+	case b.RunwayHours != nil && *b.RunwayHours < m.thresholds.RunwayWarning.Hours():
+		return StatusWarning, fmt.Sprintf("runway of %.1fh is below %s", *b.RunwayHours, m.thresholds.RunwayWarning)
+	case txFee > 0 && b.Balance < txFee*float64(m.thresholds.MinTxs):
+		return StatusWarning, fmt.Sprintf("balance covers fewer than %d max_gas transactions", m.thresholds.MinTxs)
+	}
+	return StatusOK, ""
+}
+
+// transition logs and notifies when an account enters or leaves an alert state
+func (m *Monitor) transition(b AccountBalance, previous string) {
+	if b.Status == previous {
+		return
+	}
+	if b.Status == StatusUnknown {
+		m.logger.Warn("Relayer account balance check failed",
+			zap.String("relayer", b.Relayer),
+			zap.String("chain_id", b.ChainID),
+			zap.String("error", b.Error),
+		)
+		return
+	}
+
+	level := b.Status
+	if level == StatusOK {
+		// Recovering from unknown isn't worth an alert
+		if previous == StatusUnknown {
+			return
+		}
+		level = AlertResolved
+	}
+
+	alert := newAlert(b, level)
+	fields := []zap.Field{
+		zap.String("relayer", b.Relayer),
+		zap.String("chain_id", b.ChainID),
+		zap.String("address", b.Address),
+		zap.Float64("balance", b.Balance),
+		zap.String("denom", b.Denom),
+		zap.String("message", alert.Message),
+	}
+	switch level {
+	case StatusCritical:
+		m.logger.Error("OPERATOR ALERT: relayer account running out of funds", fields...)
+	case StatusWarning:
+		m.logger.Warn("Relayer account balance low", fields...)
+	default:
+		m.logger.Info("Relayer account balance recovered", fields...)
+	}
+
+	if m.notifier != nil {
+		m.notifier.Notify(alert)
+	}
+}
+
+func newAlert(b AccountBalance, level string) Alert {
+	message := b.Reason
+	if level == AlertResolved {
+		message = "balance recovered"
+	}
+	return Alert{
+		Relayer:     b.Relayer,
+		ChainID:     b.ChainID,
+		Address:     b.Address,
+		Denom:       b.Denom,
+		Level:       level,
+		Message:     message,
+		Balance:     b.Balance,
+		RunwayHours: b.RunwayHours,
+		Timestamp:   b.CheckedAt,
+	}
+}
+
+func sortBalances(balances []AccountBalance) {
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Relayer != balances[j].Relayer {
+			return balances[i].Relayer < balances[j].Relayer
+		}
+		return balances[i].ChainID < balances[j].ChainID
+	})
+}
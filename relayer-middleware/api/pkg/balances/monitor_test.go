@@ -0,0 +1,244 @@
+package balances
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+const testHermesConfig = `[global]
+log_level = 'info'
+
+[[chains]]
+id = 'cosmoshub-4'
+key_name = 'relayer'
+max_gas = 400000
+gas_price = { price = 0.025, denom = 'uatom' }
+
+[[chains]]
+id = 'osmosis-1'
+key_name = 'missing'
+gas_price = { price = 0.025, denom = 'uosmo' }
+`
+
+const testRlyConfig = `chains:
+  noble:
+    type: cosmos
+    value:
+      key: default
+      chain-id: noble-1
+      gas-prices: 0.1uusdc
+`
+
+type fakeConfigs map[string]string
+
+func (f fakeConfigs) Current(relayer string) (string, error) {
+	return f[relayer], nil
+}
+
+type fakeQuerier struct {
+	balances map[string]float64
+	err      error
+}
+
+func (f *fakeQuerier) Balance(ctx context.Context, chainID, address, denom string) (float64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.balances[chainID+"/"+address+"/"+denom], nil
+}
+
+func newTestMonitor(t *testing.T, querier Querier) (*Monitor, *time.Time) {
+	t.Helper()
+
+	keysDir := t.TempDir()
+	keyDir := filepath.Join(keysDir, "cosmoshub-4", "keyring-test")
+	require.NoError(t, os.MkdirAll(keyDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(keyDir, "relayer.json"),
+		[]byte(`{"account":"cosmos1relayer","address_type":"Cosmos"}`), 0600))
+
+	m := NewMonitor(fakeConfigs{
+		relayerconfig.RelayerHermes: testHermesConfig,
+		relayerconfig.RelayerRly:    testRlyConfig,
+	}, querier, zap.NewNop())
+	m.keysDir = keysDir
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if fmt.Sprint(args) == "[keys show noble default]" {
+			return []byte("noble1relayer\n"), nil
+		}
+		return nil, errors.New("unexpected command")
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestDiscoverAccounts(t *testing.T) {
+	m, _ := newTestMonitor(t, &fakeQuerier{})
+
+	accounts := m.discover(context.Background())
+	require.Len(t, accounts, 3)
+
+	assert.Equal(t, "cosmos1relayer", accounts[0].Address)
+	assert.Equal(t, "uatom", accounts[0].Denom)
+	assert.Equal(t, uint64(400000), accounts[0].MaxGas)
+	assert.NoError(t, accounts[0].err)
+
+	assert.Equal(t, "osmosis-1", accounts[1].ChainID)
+	assert.Error(t, accounts[1].err)
+
+	assert.Equal(t, relayerconfig.RelayerRly, accounts[2].Relayer)
+	assert.Equal(t, "noble1relayer", accounts[2].Address)
+	assert.Equal(t, "uusdc", accounts[2].Denom)
+	assert.Equal(t, 0.1, accounts[2].GasPrice)
+}
+
+func TestRunwayAndAlerts(t *testing.T) {
+	querier := &fakeQuerier{balances: map[string]float64{
+		"cosmoshub-4/cosmos1relayer/uatom": 1000000,
+		"noble-1/noble1relayer/uusdc":      100000000,
+	}}
+	m, now := newTestMonitor(t, querier)
+
+	var alerts []Alert
+	m.UseNotifier(NotifierFunc(func(alert Alert) { alerts = append(alerts, alert) }))
+
+	results := m.Check(context.Background())
+	require.Len(t, results, 3)
+	assert.Equal(t, StatusOK, results[0].Status)
+	assert.Nil(t, results[0].RunwayHours)
+	assert.Equal(t, StatusUnknown, results[1].Status)
+	assert.Empty(t, alerts)
+
+	// Spend 40k uatom/hour for an hour
+	*now = now.Add(30 * time.Minute)
+	querier.balances["cosmoshub-4/cosmos1relayer/uatom"] = 980000
+	m.Check(context.Background())
+	*now = now.Add(30 * time.Minute)
+	querier.balances["cosmoshub-4/cosmos1relayer/uatom"] = 960000
+	results = m.Check(context.Background())
+
+	hub := results[0]
+	assert.InDelta(t, 40000, hub.SpendPerHour, 0.001)
+	require.NotNil(t, hub.RunwayHours)
+	assert.InDelta(t, 24, *hub.RunwayHours, 0.001)
+	assert.Equal(t, StatusWarning, hub.Status)
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, StatusWarning, alerts[0].Level)
+	assert.Equal(t, "cosmoshub-4", alerts[0].ChainID)
+	assert.Len(t, m.Alerts(), 1)
+
+	// A balance that can't pay for a max_gas transaction is critical
+	*now = now.Add(time.Minute)
+	querier.balances["cosmoshub-4/cosmos1relayer/uatom"] = 5000
+	results = m.Check(context.Background())
+	assert.Equal(t, StatusCritical, results[0].Status)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, StatusCritical, alerts[1].Level)
+
+	// Topping up beyond the window resolves the alert
+	*now = now.Add(25 * time.Hour)
+	querier.balances["cosmoshub-4/cosmos1relayer/uatom"] = 50000000
+	results = m.Check(context.Background())
+	assert.Equal(t, StatusOK, results[0].Status)
+	require.Len(t, alerts, 3)
+	assert.Equal(t, AlertResolved, alerts[2].Level)
+	assert.Empty(t, m.Alerts())
+
+	assert.Len(t, m.Balances(relayerconfig.RelayerRly), 1)
+}
+
+func TestSpendIgnoresTopUps(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []sample{
+		{at: start, balance: 1000},
+		{at: start.Add(30 * time.Minute), balance: 900},
+		{at: start.Add(60 * time.Minute), balance: 5000},
+		{at: start.Add(120 * time.Minute), balance: 4900},
+	}
+	assert.InDelta(t, 100, spendPerHour(history), 0.001)
+
+	// Too little history to estimate a rate
+	assert.Zero(t, spendPerHour(history[:1]))
+	assert.Zero(t, spendPerHour([]sample{{at: start, balance: 10}, {at: start.Add(time.Minute), balance: 5}}))
+}
+
+func TestMinTxsWarning(t *testing.T) {
+	// 0.025 * 400000 = 10000 per transaction, 10 needed
+	querier := &fakeQuerier{balances: map[string]float64{"cosmoshub-4/cosmos1relayer/uatom": 50000}}
+	m, _ := newTestMonitor(t, querier)
+
+	results := m.Check(context.Background())
+	assert.Equal(t, StatusWarning, results[0].Status)
+	assert.Contains(t, results[0].Reason, "fewer than 10")
+}
+
+func TestMetrics(t *testing.T) {
+	querier := &fakeQuerier{balances: map[string]float64{"cosmoshub-4/cosmos1relayer/uatom": 1000000}}
+	m, _ := newTestMonitor(t, querier)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, m.Register(registry))
+	m.Check(context.Background())
+
+	balance := m.metrics.balance.WithLabelValues("hermes", "cosmoshub-4", "cosmos1relayer", "uatom")
+	assert.Equal(t, 1000000.0, testutil.ToFloat64(balance))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.checkErrors.WithLabelValues("hermes", "osmosis-1")))
+
+	// Accounts removed from the config stop being exported
+	m.configs = fakeConfigs{}
+	m.Check(context.Background())
+	assert.Equal(t, 0, testutil.CollectAndCount(m.metrics.balance))
+	assert.Empty(t, m.Balances(""))
+}
+
+func TestParseGasPrice(t *testing.T) {
+	price, denom := parseGasPrice("0.025uatom")
+	assert.Equal(t, 0.025, price)
+	assert.Equal(t, "uatom", denom)
+
+	price, denom = parseGasPrice("12500000000adydx")
+	assert.Equal(t, 12500000000.0, price)
+	assert.Equal(t, "adydx", denom)
+
+	_, denom = parseGasPrice("")
+	assert.Empty(t, denom)
+}
+
+func TestRESTQuerier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/cosmos/bank/v1beta1/balances/cosmos1relayer/by_denom", r.URL.Path)
+		assert.Equal(t, "ibc/ABC", r.URL.Query().Get("denom"))
+		fmt.Fprint(w, `{"balance":{"denom":"ibc/ABC","amount":"123456"}}`)
+	}))
+	defer server.Close()
+
+	registry := &config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RESTEndpoint: server.URL + "/"},
+	}}
+	querier := NewRESTQuerier(registry)
+
+	amount, err := querier.Balance(context.Background(), "cosmoshub-4", "cosmos1relayer", "ibc/ABC")
+	require.NoError(t, err)
+	assert.Equal(t, 123456.0, amount)
+
+	_, err = querier.Balance(context.Background(), "unknown-1", "addr", "uatom")
+	assert.Error(t, err)
+}
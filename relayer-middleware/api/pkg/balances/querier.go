@@ -0,0 +1,60 @@
+package balances
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// RESTQuerier reads balances from the bank module over each chain's REST API
+type RESTQuerier struct {
+	client *endpoints.Client
+}
+
+// NewRESTQuerier creates a querier using the REST endpoints of the registry
+func NewRESTQuerier(registry *config.ChainRegistry) *RESTQuerier {
+	return &RESTQuerier{
+		client: endpoints.NewClient(registry, 10*time.Second),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (q *RESTQuerier) UseEndpoints(pools *endpoints.Pools) {
+	q.client.UsePools(pools)
+}
+
+// Balance implements Querier
+func (q *RESTQuerier) Balance(ctx context.Context, chainID, address, denom string) (float64, error) {
+	path := fmt.Sprintf("/cosmos/bank/v1beta1/balances/%s/by_denom?denom=%s", url.PathEscape(address), url.QueryEscape(denom))
+	resp, err := q.client.Get(ctx, chainID, endpoints.REST, path)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("balance query for %s on %s returned %d", address, chainID, resp.StatusCode)
+	}
+
+	var body struct {
+		Balance struct {
+			Denom  string `json:"denom"`
+			Amount string `json:"amount"`
+		} `json:"balance"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("decode balance: %w", err)
+	}
+	if body.Balance.Amount == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(body.Balance.Amount, 64)
+}
@@ -0,0 +1,91 @@
+package balances
+
+import (
+	"context"
+	"time"
+)
+
+// Account statuses, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+	StatusUnknown  = "unknown"
+)
+
+// AlertResolved is the level of an alert sent when an account recovers
+const AlertResolved = "resolved"
+
+// Account is a relayer key on one chain
+type Account struct {
+	Relayer  string  `json:"relayer"`
+	ChainID  string  `json:"chain_id"`
+	KeyName  string  `json:"key_name"`
+	Address  string  `json:"address,omitempty"`
+	Denom    string  `json:"denom"`
+	GasPrice float64 `json:"gas_price"`
+	MaxGas   uint64  `json:"max_gas"`
+}
+
+func (a Account) key() string {
+	return a.Relayer + "/" + a.ChainID + "/" + a.KeyName
+}
+
+// AccountBalance is the latest check of an account. Amounts are in the
+// base denom of the fee token.
+type AccountBalance struct {
+	Account
+	Balance      float64   `json:"balance"`
+	SpendPerHour float64   `json:"spend_per_hour"`
+	RunwayHours  *float64  `json:"runway_hours,omitempty"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Alert is raised when an account's status changes
+type Alert struct {
+	Relayer     string    `json:"relayer"`
+	ChainID     string    `json:"chain_id"`
+	Address     string    `json:"address"`
+	Denom       string    `json:"denom"`
+	Level       string    `json:"level"`
+	Message     string    `json:"message"`
+	Balance     float64   `json:"balance"`
+	RunwayHours *float64  `json:"runway_hours,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Thresholds decide when an account needs attention
+type Thresholds struct {
+	// RunwayWarning and RunwayCritical apply to the estimated time until
+	// the balance is spent at the recent rate
+	RunwayWarning  time.Duration
+	RunwayCritical time.Duration
+	// MinTxs is how many max_gas transactions the balance should cover
+	MinTxs int
+}
+
+// Querier fetches an account balance in one denom
+type Querier interface {
+	Balance(ctx context.Context, chainID, address, denom string) (float64, error)
+}
+
+// ConfigReader returns the current config of a relayer
+type ConfigReader interface {
+	Current(relayer string) (string, error)
+}
+
+// Notifier receives balance alerts
+type Notifier interface {
+	Notify(alert Alert)
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(alert Alert)
+
+// Notify calls f(alert)
+func (f NotifierFunc) Notify(alert Alert) {
+	f(alert)
+}
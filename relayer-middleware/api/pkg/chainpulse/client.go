@@ -3,19 +3,69 @@ package chainpulse
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
+
+	"relayooor/api/pkg/circuitbreaker"
+	"relayooor/api/pkg/retry"
+)
+
+var (
+	// ErrNotFound is returned for packets Chainpulse has no record of
+	ErrNotFound = errors.New("not found in chainpulse")
+	// ErrUnavailable is returned without a request while the circuit
+	// breaker is open after repeated failures
+	ErrUnavailable = errors.New("chainpulse unavailable")
+)
+
+const (
+	// queryOperation names Chainpulse requests to the retrier, which
+	// retries them as idempotent reads
+	queryOperation = "chainpulse_query"
+
+	// allPageSize and maxPages bound the requests made by the All methods
+	allPageSize = 500
+	maxPages    = 50
 )
 
-// Client represents a Chainpulse API client
+// StatusError is returned for responses other than 200 OK. It unwraps to
+// ErrNotFound for 404s and to retry.ErrRPCUnavailable for responses worth
+// retrying.
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("chainpulse %s returned %d", e.Endpoint, e.StatusCode)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests, e.StatusCode >= 500:
+		return retry.ErrRPCUnavailable
+	default:
+		return nil
+	}
+}
+
+// Client is a typed client for the Chainpulse API. Requests are retried
+// with backoff on network errors and 5xx responses, and a circuit breaker
+// stops calling Chainpulse for a while once retries keep failing.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	retrier    *retry.Retrier
+	breaker    *circuitbreaker.CircuitBreaker
 	logger     *zap.Logger
 }
 
@@ -25,202 +75,286 @@ func NewClient(baseURL string, logger *zap.Logger) *Client {
 	if baseURL == "" {
 		baseURL = "http://localhost:3000"
 	}
+	logger = logger.With(zap.String("component", "chainpulse_client"))
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		logger: logger.With(zap.String("component", "chainpulse_client")),
-	}
-}
-
-// PacketByUser represents a packet associated with a user
-type PacketByUser struct {
-	Chain     string    `json:"chain"`
-	Channel   string    `json:"channel"`
-	Sequence  uint64    `json:"sequence"`
-	Sender    string    `json:"sender"`
-	Receiver  string    `json:"receiver"`
-	Amount    string    `json:"amount"`
-	Denom     string    `json:"denom"`
-	TxHash    string    `json:"tx_hash"`
-	Timestamp time.Time `json:"timestamp"`
-	Status    string    `json:"status"` // pending, completed, stuck
-}
-
-// StuckPacket represents a stuck IBC packet
-type StuckPacket struct {
-	SrcChain      string    `json:"src_chain"`
-	DstChain      string    `json:"dst_chain"`
-	SrcChannel    string    `json:"src_channel"`
-	DstChannel    string    `json:"dst_channel"`
-	Sequence      uint64    `json:"sequence"`
-	Sender        string    `json:"sender"`
-	Receiver      string    `json:"receiver"`
-	Amount        string    `json:"amount"`
-	Denom         string    `json:"denom"`
-	StuckSince    time.Time `json:"stuck_since"`
-	StuckDuration int       `json:"stuck_duration_minutes"`
-}
-
-// ChannelCongestion represents channel congestion status
-type ChannelCongestion struct {
-	Chain           string  `json:"chain"`
-	Channel         string  `json:"channel"`
-	CounterpartyChain string `json:"counterparty_chain"`
-	CounterpartyChannel string `json:"counterparty_channel"`
-	PendingPackets  int     `json:"pending_packets"`
-	AvgClearTime    float64 `json:"avg_clear_time_seconds"`
-	CongestionLevel string  `json:"congestion_level"` // low, medium, high
-}
-
-// PacketDetails represents detailed packet information
-type PacketDetails struct {
-	Chain         string                 `json:"chain"`
-	Channel       string                 `json:"channel"`
-	Sequence      uint64                 `json:"sequence"`
-	PacketData    map[string]interface{} `json:"packet_data"`
-	Status        string                 `json:"status"`
-	Acknowledgement string               `json:"acknowledgement,omitempty"`
-	TxHash        string                 `json:"tx_hash"`
-	Height        uint64                 `json:"height"`
-	Timestamp     time.Time              `json:"timestamp"`
-}
-
-// GetPacketsByUser retrieves packets for a specific user address
-func (c *Client) GetPacketsByUser(ctx context.Context, userAddress string) ([]PacketByUser, error) {
-	endpoint := fmt.Sprintf("/api/v1/packets/by-user?address=%s", url.QueryEscape(userAddress))
-	
-	var packets []PacketByUser
-	if err := c.get(ctx, endpoint, &packets); err != nil {
-		return nil, fmt.Errorf("failed to get packets by user: %w", err)
+		retrier: retry.NewRetrier(retry.Config{
+			MaxAttempts:     3,
+			InitialInterval: 500 * time.Millisecond,
+			MaxInterval:     5 * time.Second,
+			Multiplier:      2.0,
+			RandomFactor:    0.2,
+		}, logger),
+		breaker: circuitbreaker.New(
+			"chainpulse",
+			5,              // Open after 5 failed requests
+			30*time.Second, // Try again after 30 seconds
+		),
+		logger: logger,
 	}
-	
-	return packets, nil
 }
 
-// GetStuckPackets retrieves all currently stuck packets
-func (c *Client) GetStuckPackets(ctx context.Context, minStuckMinutes int) ([]StuckPacket, error) {
-	endpoint := fmt.Sprintf("/api/v1/packets/stuck?min_stuck_minutes=%d", minStuckMinutes)
-	
-	var packets []StuckPacket
-	if err := c.get(ctx, endpoint, &packets); err != nil {
+// GetStuckPackets returns a page of packets pending for at least q.MinAge
+func (c *Client) GetStuckPackets(ctx context.Context, q StuckPacketsQuery) (*PacketsResponse, error) {
+	params := url.Values{}
+	if q.MinAge > 0 {
+		params.Set("min_age_seconds", strconv.FormatInt(int64(q.MinAge/time.Second), 10))
+	}
+	q.Page.encode(params)
+
+	var result PacketsResponse
+	if err := c.get(ctx, "/api/v1/packets/stuck", params, &result); err != nil {
 		return nil, fmt.Errorf("failed to get stuck packets: %w", err)
 	}
-	
-	return packets, nil
+	return &result, nil
 }
 
-// GetPacketDetails retrieves details for a specific packet
+// AllStuckPackets pages through every packet pending for at least minAge
+func (c *Client) AllStuckPackets(ctx context.Context, minAge time.Duration) ([]Packet, error) {
+	return collect(func(page Page) ([]Packet, int, error) {
+		resp, err := c.GetStuckPackets(ctx, StuckPacketsQuery{MinAge: minAge, Page: page})
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Packets, resp.Total, nil
+	})
+}
+
+// GetPacketsByUser returns a page of the pending packets sent or received by
+// an address
+func (c *Client) GetPacketsByUser(ctx context.Context, q UserPacketsQuery) (*PacketsResponse, error) {
+	params := url.Values{}
+	params.Set("address", q.Address)
+	if q.Role != "" {
+		params.Set("role", q.Role)
+	}
+	q.Page.encode(params)
+
+	var result PacketsResponse
+	if err := c.get(ctx, "/api/v1/packets/by-user", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get packets by user: %w", err)
+	}
+	return &result, nil
+}
+
+// AllPacketsByUser pages through every pending packet of an address
+func (c *Client) AllPacketsByUser(ctx context.Context, address, role string) ([]Packet, error) {
+	return collect(func(page Page) ([]Packet, int, error) {
+		resp, err := c.GetPacketsByUser(ctx, UserPacketsQuery{Address: address, Role: role, Page: page})
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Packets, resp.Total, nil
+	})
+}
+
+// GetExpiringPackets returns a page of packets that time out within the
+// given duration, in whole minutes
+func (c *Client) GetExpiringPackets(ctx context.Context, within time.Duration, page Page) (*ExpiringPacketsResponse, error) {
+	params := url.Values{}
+	if within > 0 {
+		params.Set("minutes", strconv.FormatInt(int64(within/time.Minute), 10))
+	}
+	page.encode(params)
+
+	var result ExpiringPacketsResponse
+	if err := c.get(ctx, "/api/v1/packets/expiring", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get expiring packets: %w", err)
+	}
+	return &result, nil
+}
+
+// AllExpiringPackets pages through every packet that times out within the
+// given duration
+func (c *Client) AllExpiringPackets(ctx context.Context, within time.Duration) ([]ExpiringPacket, error) {
+	return collect(func(page Page) ([]ExpiringPacket, int, error) {
+		resp, err := c.GetExpiringPackets(ctx, within, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Packets, 0, nil
+	})
+}
+
+// GetExpiredPackets returns a page of packets past their timeout
+func (c *Client) GetExpiredPackets(ctx context.Context, page Page) (*ExpiredPacketsResponse, error) {
+	params := url.Values{}
+	page.encode(params)
+
+	var result ExpiredPacketsResponse
+	if err := c.get(ctx, "/api/v1/packets/expired", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get expired packets: %w", err)
+	}
+	return &result, nil
+}
+
+// AllExpiredPackets pages through every packet past its timeout
+func (c *Client) AllExpiredPackets(ctx context.Context) ([]ExpiredPacket, error) {
+	return collect(func(page Page) ([]ExpiredPacket, int, error) {
+		resp, err := c.GetExpiredPackets(ctx, page)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Packets, 0, nil
+	})
+}
+
+// GetDuplicatePackets returns a page of groups of packets sent with
+// identical data
+func (c *Client) GetDuplicatePackets(ctx context.Context, page Page) (*DuplicatesResponse, error) {
+	params := url.Values{}
+	page.encode(params)
+
+	var result DuplicatesResponse
+	if err := c.get(ctx, "/api/v1/packets/duplicates", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get duplicate packets: %w", err)
+	}
+	return &result, nil
+}
+
+// GetPacketDetails retrieves details for a specific packet. The error wraps
+// ErrNotFound when Chainpulse has no record of it.
 func (c *Client) GetPacketDetails(ctx context.Context, chain, channel string, sequence uint64) (*PacketDetails, error) {
-	endpoint := fmt.Sprintf("/api/v1/packets/%s/%s/%d", chain, channel, sequence)
-	
+	endpoint := fmt.Sprintf("/api/v1/packets/%s/%s/%d", url.PathEscape(chain), url.PathEscape(channel), sequence)
+
 	var details PacketDetails
-	if err := c.get(ctx, endpoint, &details); err != nil {
+	if err := c.get(ctx, endpoint, nil, &details); err != nil {
 		return nil, fmt.Errorf("failed to get packet details: %w", err)
 	}
-	
 	return &details, nil
 }
 
-// GetChannelCongestion retrieves congestion status for all channels
-func (c *Client) GetChannelCongestion(ctx context.Context) ([]ChannelCongestion, error) {
-	endpoint := "/api/v1/channels/congestion"
-	
-	var congestion []ChannelCongestion
-	if err := c.get(ctx, endpoint, &congestion); err != nil {
+// GetChannelCongestion retrieves the stuck packet backlog of every channel
+func (c *Client) GetChannelCongestion(ctx context.Context) (*ChannelCongestionResponse, error) {
+	var result ChannelCongestionResponse
+	if err := c.get(ctx, "/api/v1/channels/congestion", nil, &result); err != nil {
 		return nil, fmt.Errorf("failed to get channel congestion: %w", err)
 	}
-	
-	return congestion, nil
+	return &result, nil
 }
 
 // GetMetrics retrieves raw Prometheus metrics
 func (c *Client) GetMetrics(ctx context.Context) (string, error) {
-	endpoint := "/metrics"
-	
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
-	if err != nil {
-		return "", err
-	}
-	
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		c.logger.Error("Failed to fetch metrics", zap.Error(err))
-		return "", err
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-	
-	body, err := io.ReadAll(resp.Body)
+	var metrics string
+	err := c.do(ctx, "/metrics", nil, "text/plain", func(body io.Reader) error {
+		data, err := io.ReadAll(body)
+		metrics = string(data)
+		return err
+	})
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to get metrics: %w", err)
 	}
-	
-	return string(body), nil
+	return metrics, nil
 }
 
-// HealthCheck checks if Chainpulse is healthy
+// HealthCheck checks if Chainpulse is healthy. It makes a single request,
+// bypassing the retries and circuit breaker.
 func (c *Client) HealthCheck(ctx context.Context) error {
 	endpoint := "/health"
-	
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
-	if err != nil {
-		return err
-	}
-	
-	resp, err := c.httpClient.Do(req)
+	err := c.attempt(ctx, endpoint, nil, "application/json", func(io.Reader) error { return nil })
 	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+		return fmt.Errorf("health check failed: %w", err)
 	}
-	
 	return nil
 }
 
-// get performs a GET request to the specified endpoint
-func (c *Client) get(ctx context.Context, endpoint string, result interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+// get fetches a JSON endpoint into result
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, result interface{}) error {
+	return c.do(ctx, endpoint, params, "application/json", func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(result); err != nil {
+			c.logger.Error("Failed to decode response",
+				zap.String("endpoint", endpoint),
+				zap.Error(err),
+			)
+			return fmt.Errorf("decode %s: %w", endpoint, err)
+		}
+		return nil
+	})
+}
+
+// do performs a GET through the circuit breaker and retrier. Only failures
+// that say Chainpulse is unhealthy count towards opening the breaker: a 404
+// or a cancelled request is an answer, not an outage.
+func (c *Client) do(ctx context.Context, endpoint string, params url.Values, accept string, read func(io.Reader) error) error {
+	var result error
+	err := c.breaker.Execute(func() error {
+		result = c.retrier.Do(ctx, queryOperation, func() error {
+			return c.attempt(ctx, endpoint, params, accept, read)
+		})
+		switch {
+		case result == nil, ctx.Err() != nil:
+			return nil
+		case isAnswer(result) && !errors.Is(result, retry.ErrRPCUnavailable):
+			return nil
+		}
+		return result
+	})
+	if errors.Is(err, circuitbreaker.ErrCircuitOpen) {
+		return ErrUnavailable
+	}
+	return result
+}
+
+// isAnswer reports whether err is a response Chainpulse chose to give, as
+// opposed to a failure to respond
+func isAnswer(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr)
+}
+
+func (c *Client) attempt(ctx context.Context, endpoint string, params url.Values, accept string, read func(io.Reader) error) error {
+	target := c.baseURL + endpoint
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 	if err != nil {
 		return err
 	}
-	
-	req.Header.Set("Accept", "application/json")
-	
+	req.Header.Set("Accept", accept)
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		c.logger.Error("Failed to perform request",
+		c.logger.Warn("Failed to perform request",
 			zap.String("endpoint", endpoint),
 			zap.Error(err),
 		)
 		return err
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.Error("Unexpected status code",
-			zap.String("endpoint", endpoint),
-			zap.Int("status", resp.StatusCode),
-			zap.String("body", string(body)),
-		)
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if resp.StatusCode != http.StatusNotFound {
+			c.logger.Warn("Unexpected status code",
+				zap.String("endpoint", endpoint),
+				zap.Int("status", resp.StatusCode),
+				zap.String("body", string(body)),
+			)
+		}
+		return &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode}
 	}
-	
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		c.logger.Error("Failed to decode response",
-			zap.String("endpoint", endpoint),
-			zap.Error(err),
-		)
-		return err
+	return read(resp.Body)
+}
+
+// collect pages through a list endpoint until a short page, the reported
+// total or the page cap
+func collect[T any](fetch func(Page) ([]T, int, error)) ([]T, error) {
+	var all []T
+	page := Page{Limit: allPageSize}
+	for i := 0; i < maxPages; i++ {
+		items, total, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < page.Limit || (total > 0 && len(all) >= total) {
+			break
+		}
+		page.Offset += len(items)
 	}
-	
-	return nil
-}
\ No newline at end of file
+	return all, nil
+}
@@ -0,0 +1,191 @@
+package chainpulse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/circuitbreaker"
+	"relayooor/api/pkg/retry"
+)
+
+// newTestClient returns a client for the server with millisecond backoff
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := NewClient(server.URL, zap.NewNop())
+	client.retrier = retry.NewRetrier(retry.Config{
+		MaxAttempts:     3,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		Multiplier:      1,
+	}, zap.NewNop())
+	return client
+}
+
+func TestGetStuckPackets(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/packets/stuck", r.URL.Path)
+		assert.Equal(t, "1800", r.URL.Query().Get("min_age_seconds"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		assert.Equal(t, "20", r.URL.Query().Get("offset"))
+		fmt.Fprint(w, `{"packets":[{"chain_id":"osmosis-1","sequence":42,"src_channel":"channel-0","dst_channel":"channel-141","sender":"osmo1sender","receiver":"cosmos1receiver","amount":"1000","denom":"uosmo","age_seconds":3600,"relay_attempts":2,"last_attempt_by":"hermes","timeout_timestamp":1700000000000000000,"ibc_version":"v1"}],"total":21,"api_version":"1.0"}`)
+	})
+
+	resp, err := client.GetStuckPackets(context.Background(), StuckPacketsQuery{
+		MinAge: 30 * time.Minute,
+		Page:   Page{Limit: 10, Offset: 20},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Packets, 1)
+	assert.Equal(t, 21, resp.Total)
+
+	p := resp.Packets[0]
+	assert.Equal(t, "osmosis-1-channel-0-42", p.ID())
+	assert.Equal(t, "channel-141", p.DstChannel)
+	assert.Equal(t, int64(3600), p.AgeSeconds)
+	assert.Equal(t, 2, p.RelayAttempts)
+	require.NotNil(t, p.TimeoutTimestamp)
+	assert.Equal(t, int64(1700000000000000000), *p.TimeoutTimestamp)
+}
+
+func TestLegacyEndpoints(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/packets/expiring":
+			assert.Equal(t, "90", r.URL.Query().Get("minutes"))
+			fmt.Fprint(w, `{"packets":[{"chain_id":"osmosis-1","sequence":7,"src_channel":"channel-0","seconds_until_timeout":120,"timeout_type":"timestamp","timeout_value":"1700000000000000000"}],"api_version":"1.0"}`)
+		case "/api/v1/packets/expired":
+			fmt.Fprint(w, `{"packets":[{"chain_id":"osmosis-1","sequence":8,"src_channel":"channel-0","seconds_since_timeout":60,"timeout_type":"height"}],"api_version":"1.0"}`)
+		case "/api/v1/packets/duplicates":
+			fmt.Fprint(w, `{"duplicates":[{"data_hash":"abc","count":2,"packets":[{"chain_id":"osmosis-1","sequence":1,"src_channel":"channel-0","sender":"osmo1a","created_at":"2026-03-01T12:00:00Z"},{"chain_id":"osmosis-1","sequence":2,"src_channel":"channel-0","sender":"osmo1a","created_at":"2026-03-01T12:00:05Z"}]}],"api_version":"1.0"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	expiring, err := client.GetExpiringPackets(ctx, 90*time.Minute, Page{})
+	require.NoError(t, err)
+	require.Len(t, expiring.Packets, 1)
+	assert.Equal(t, uint64(7), expiring.Packets[0].Sequence)
+	assert.Equal(t, int64(120), expiring.Packets[0].SecondsUntilTimeout)
+	assert.Equal(t, "timestamp", expiring.Packets[0].TimeoutType)
+
+	expired, err := client.GetExpiredPackets(ctx, Page{})
+	require.NoError(t, err)
+	require.Len(t, expired.Packets, 1)
+	assert.Equal(t, int64(60), expired.Packets[0].SecondsSinceTimeout)
+	assert.Equal(t, "height", expired.Packets[0].TimeoutType)
+
+	duplicates, err := client.GetDuplicatePackets(ctx, Page{})
+	require.NoError(t, err)
+	require.Len(t, duplicates.Duplicates, 1)
+	assert.Equal(t, 2, duplicates.Duplicates[0].Count)
+	assert.Len(t, duplicates.Duplicates[0].Packets, 2)
+}
+
+func TestGetPacketDetails(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/packets/osmosis-1/channel-0/42", r.URL.Path)
+		fmt.Fprint(w, `{"chain_id":"osmosis-1","sequence":42,"src_channel":"channel-0","status":"pending","tx_hash":"SEND","height":100,"timestamp":"2026-03-01T12:00:00Z","attempts":[{"chain":"cosmoshub-4","msg_type_url":"/ibc.core.channel.v1.MsgRecvPacket","signer":"cosmos1relayer","effected":true,"tx_hash":"RECV","height":200}]}`)
+	})
+
+	details, err := client.GetPacketDetails(context.Background(), "osmosis-1", "channel-0", 42)
+	require.NoError(t, err)
+	assert.Equal(t, "osmosis-1", details.ChainID)
+	assert.Equal(t, "SEND", details.TxHash)
+	assert.Equal(t, uint64(100), details.Height)
+	require.Len(t, details.Attempts, 1)
+	assert.True(t, details.Attempts[0].Effected)
+}
+
+func TestRetriesUnavailable(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"channels":[{"src_channel":"channel-0","dst_channel":"channel-141","stuck_count":12,"oldest_stuck_age_seconds":600,"total_value":{"uosmo":"5000"}}],"api_version":"1.0"}`)
+	})
+
+	resp, err := client.GetChannelCongestion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.Len(t, resp.Channels, 1)
+	assert.Equal(t, 12, resp.Channels[0].StuckCount)
+	assert.Equal(t, "5000", resp.Channels[0].TotalValue["uosmo"])
+}
+
+func TestNotFoundIsNotRetried(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.NotFound(w, r)
+	})
+
+	for i := 0; i < 10; i++ {
+		_, err := client.GetPacketDetails(context.Background(), "osmosis-1", "channel-0", 42)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	// Every request reached Chainpulse once: not retried, breaker still closed
+	assert.Equal(t, int32(10), atomic.LoadInt32(&calls))
+}
+
+func TestBreakerOpens(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client.breaker = circuitbreaker.New("chainpulse_test", 2, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.GetExpiredPackets(ctx, Page{})
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+
+	_, err := client.GetExpiredPackets(ctx, Page{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestAllPacketsByUser(t *testing.T) {
+	const total = allPageSize + 3
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "osmo1sender", r.URL.Query().Get("address"))
+		assert.Equal(t, strconv.Itoa(allPageSize), r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		fmt.Fprint(w, `{"packets":[`)
+		for seq := offset; seq < total && seq < offset+allPageSize; seq++ {
+			if seq > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"chain_id":"osmosis-1","src_channel":"channel-0","sequence":%d}`, seq)
+		}
+		fmt.Fprintf(w, `],"total":%d}`, total)
+	})
+
+	packets, err := client.AllPacketsByUser(context.Background(), "osmo1sender", "")
+	require.NoError(t, err)
+	require.Len(t, packets, total)
+	assert.Equal(t, uint64(total-1), packets[total-1].Sequence)
+}
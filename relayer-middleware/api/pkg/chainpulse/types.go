@@ -0,0 +1,169 @@
+package chainpulse
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Page selects part of a list endpoint. Zero fields use Chainpulse's
+// defaults.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+func (p Page) encode(params url.Values) {
+	if p.Limit > 0 {
+		params.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Offset > 0 {
+		params.Set("offset", strconv.Itoa(p.Offset))
+	}
+}
+
+// StuckPacketsQuery filters GetStuckPackets
+type StuckPacketsQuery struct {
+	// MinAge is how long a packet must have been pending, in whole seconds
+	MinAge time.Duration
+	Page
+}
+
+// UserPacketsQuery filters GetPacketsByUser
+type UserPacketsQuery struct {
+	Address string
+	// Role is sender, receiver or empty for both
+	Role string
+	Page
+}
+
+// Packet is a pending packet as Chainpulse lists it
+type Packet struct {
+	ChainID       string `json:"chain_id"`
+	Sequence      uint64 `json:"sequence"`
+	SrcChannel    string `json:"src_channel"`
+	DstChannel    string `json:"dst_channel"`
+	Sender        string `json:"sender"`
+	Receiver      string `json:"receiver"`
+	Amount        string `json:"amount"`
+	Denom         string `json:"denom"`
+	AgeSeconds    int64  `json:"age_seconds"`
+	RelayAttempts int    `json:"relay_attempts"`
+	LastAttemptBy string `json:"last_attempt_by"`
+	// TimeoutTimestamp is in Unix nanoseconds, nil without a timestamp
+	// timeout
+	TimeoutTimestamp *int64 `json:"timeout_timestamp,omitempty"`
+	IBCVersion       string `json:"ibc_version"`
+}
+
+// ID is the packet's chain, channel and sequence, e.g.
+// osmosis-1-channel-0-42
+func (p Packet) ID() string {
+	return fmt.Sprintf("%s-%s-%d", p.ChainID, p.SrcChannel, p.Sequence)
+}
+
+// PacketsResponse is a page of packets
+type PacketsResponse struct {
+	Packets []Packet `json:"packets"`
+	// Total counts the matching packets across all pages
+	Total      int    `json:"total"`
+	APIVersion string `json:"api_version"`
+}
+
+// PacketDetails is everything Chainpulse knows about one packet
+type PacketDetails struct {
+	Packet
+	Status          string                 `json:"status,omitempty"`
+	PacketData      map[string]interface{} `json:"packet_data,omitempty"`
+	Acknowledgement string                 `json:"acknowledgement,omitempty"`
+	// TxHash, Height and Timestamp are those of the send
+	TxHash    string    `json:"tx_hash,omitempty"`
+	Height    uint64    `json:"height,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Attempts are the relay messages seen for the packet, including
+	// failed and redundant ones
+	Attempts []PacketAttempt `json:"attempts,omitempty"`
+}
+
+// PacketAttempt is a relay message for a packet seen in a block. Effected is
+// false for messages that didn't change state because another relayer's
+// message (by EffectedSigner) got there first, or because the tx failed.
+type PacketAttempt struct {
+	Chain          string    `json:"chain"`
+	MsgTypeURL     string    `json:"msg_type_url"`
+	Signer         string    `json:"signer"`
+	Effected       bool      `json:"effected"`
+	EffectedSigner string    `json:"effected_signer,omitempty"`
+	TxHash         string    `json:"tx_hash"`
+	Height         uint64    `json:"height"`
+	Memo           string    `json:"memo,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// ExpiringPacket is a pending packet close to its timeout
+type ExpiringPacket struct {
+	Packet
+	SecondsUntilTimeout int64 `json:"seconds_until_timeout"`
+	// TimeoutType is height or timestamp
+	TimeoutType  string `json:"timeout_type"`
+	TimeoutValue string `json:"timeout_value"`
+}
+
+// ExpiringPacketsResponse is a page of expiring packets
+type ExpiringPacketsResponse struct {
+	Packets    []ExpiringPacket `json:"packets"`
+	APIVersion string           `json:"api_version"`
+}
+
+// ExpiredPacket is a packet past its timeout that hasn't been timed out on
+// chain, so the sender hasn't been refunded
+type ExpiredPacket struct {
+	Packet
+	SecondsSinceTimeout int64  `json:"seconds_since_timeout"`
+	TimeoutType         string `json:"timeout_type"`
+}
+
+// ExpiredPacketsResponse is a page of expired packets
+type ExpiredPacketsResponse struct {
+	Packets    []ExpiredPacket `json:"packets"`
+	APIVersion string          `json:"api_version"`
+}
+
+// ChannelCongestion is the stuck packet backlog of a channel
+type ChannelCongestion struct {
+	SrcChannel            string `json:"src_channel"`
+	DstChannel            string `json:"dst_channel"`
+	StuckCount            int    `json:"stuck_count"`
+	OldestStuckAgeSeconds int64  `json:"oldest_stuck_age_seconds"`
+	// TotalValue is the stuck amount by denom
+	TotalValue map[string]string `json:"total_value"`
+}
+
+// ChannelCongestionResponse lists the congested channels
+type ChannelCongestionResponse struct {
+	Channels   []ChannelCongestion `json:"channels"`
+	APIVersion string              `json:"api_version"`
+}
+
+// DuplicatePacket is a group of packets sent with identical data
+type DuplicatePacket struct {
+	DataHash string           `json:"data_hash"`
+	Count    int              `json:"count"`
+	Packets  []DuplicateEntry `json:"packets"`
+}
+
+// DuplicateEntry is one packet of a duplicate group
+type DuplicateEntry struct {
+	ChainID    string `json:"chain_id"`
+	Sequence   uint64 `json:"sequence"`
+	SrcChannel string `json:"src_channel"`
+	Sender     string `json:"sender"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// DuplicatesResponse is a page of duplicate groups
+type DuplicatesResponse struct {
+	Duplicates []DuplicatePacket `json:"duplicates"`
+	APIVersion string            `json:"api_version"`
+}
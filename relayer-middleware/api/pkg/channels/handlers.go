@@ -0,0 +1,69 @@
+package channels
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes channel counterparty resolution
+type Handlers struct {
+	resolver *Resolver
+	logger   *zap.Logger
+}
+
+// NewHandlers creates channel counterparty handlers
+func NewHandlers(resolver *Resolver, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		resolver: resolver,
+		logger:   logger.With(zap.String("component", "channel_counterparty_handlers")),
+	}
+}
+
+// RegisterRoutes registers the counterparty routes
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/channels/counterparties", h.ListCounterparties)
+	router.GET("/channels/:chain_id/:channel_id/counterparty", h.GetCounterparty)
+}
+
+// ListCounterparties handles GET /channels/counterparties, the resolved
+// channels
+func (h *Handlers) ListCounterparties(c *gin.Context) {
+	counterparties, err := h.resolver.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list channel counterparties", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list channel counterparties"})
+		return
+	}
+	if counterparties == nil {
+		counterparties = []Counterparty{}
+	}
+	c.JSON(http.StatusOK, gin.H{"counterparties": counterparties})
+}
+
+// GetCounterparty handles GET /channels/:chain_id/:channel_id/counterparty.
+// ?port= defaults to transfer and ?refresh=true bypasses the cache.
+func (h *Handlers) GetCounterparty(c *gin.Context) {
+	chainID, channelID, portID := c.Param("chain_id"), c.Param("channel_id"), c.Query("port")
+
+	resolve := h.resolver.Resolve
+	if c.Query("refresh") == "true" {
+		resolve = h.resolver.ResolveNow
+	}
+	counterparty, err := resolve(c.Request.Context(), chainID, portID, channelID)
+	switch {
+	case errors.Is(err, ErrChannelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err != nil:
+		h.logger.Warn("Failed to resolve channel counterparty",
+			zap.String("chain_id", chainID),
+			zap.String("channel_id", channelID),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, counterparty)
+	}
+}
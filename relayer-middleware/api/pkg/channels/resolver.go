@@ -0,0 +1,53 @@
+package channels
+
+import (
+	"context"
+	"time"
+
+	chainchannels "github.com/relayooor/chains/channels"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/database"
+)
+
+// Resolver finds the chain at the other end of a channel from on-chain
+// state, keeping results in the channel_counterparties table. A periodic
+// refresh re-resolves channels whose state has changed.
+type Resolver struct {
+	*chainchannels.Resolver
+	interval time.Duration
+	logger   *zap.Logger
+}
+
+// NewResolver creates a counterparty resolver. Settings come from
+// CHANNEL_COUNTERPARTY_TTL and CHANNEL_COUNTERPARTY_REFRESH_INTERVAL.
+func NewResolver(db *gorm.DB, querier Querier, logger *zap.Logger) *Resolver {
+	resolver := chainchannels.NewResolver(querier, database.EnvDuration("CHANNEL_COUNTERPARTY_TTL", 24*time.Hour), logger)
+	resolver.UseStore(&dbStore{db: db})
+	return &Resolver{
+		Resolver: resolver,
+		interval: database.EnvDuration("CHANNEL_COUNTERPARTY_REFRESH_INTERVAL", 10*time.Minute),
+		logger:   logger.With(zap.String("component", "channel_counterparties")),
+	}
+}
+
+// Start refreshes the stored counterparties every interval until ctx is
+// done
+func (r *Resolver) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Warn("Failed to refresh channel counterparties", zap.Error(err))
+			}
+		}
+	}()
+}
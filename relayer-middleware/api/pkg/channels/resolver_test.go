@@ -0,0 +1,171 @@
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var testNow = time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+
+// fakeQuerier serves channels of osmosis-1 and counts channel queries
+type fakeQuerier struct {
+	channels map[string]*ChannelEnd
+	clients  map[string]string
+	err      error
+	queries  int
+}
+
+func (f *fakeQuerier) Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	end, ok := f.channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", channelID, ErrChannelNotFound)
+	}
+	copied := *end
+	return &copied, nil
+}
+
+func (f *fakeQuerier) ConnectionClient(ctx context.Context, chainID, connectionID string) (string, error) {
+	return "07-tendermint-" + connectionID, nil
+}
+
+func (f *fakeQuerier) ClientChainID(ctx context.Context, chainID, clientID string) (string, error) {
+	return f.clients[clientID], nil
+}
+
+func newTestResolver(t *testing.T) (*Resolver, *fakeQuerier, *gorm.DB, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&Counterparty{}))
+
+	querier := &fakeQuerier{
+		channels: map[string]*ChannelEnd{
+			"channel-0":   {State: "STATE_OPEN", ConnectionHops: []string{"connection-1"}, CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-141"},
+			"channel-750": {State: "STATE_OPEN", ConnectionHops: []string{"connection-2"}, CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-1"},
+		},
+		clients: map[string]string{
+			"07-tendermint-connection-1": "cosmoshub-4",
+			"07-tendermint-connection-2": "noble-1",
+			"07-tendermint-connection-3": "noble-1",
+		},
+	}
+	now := testNow
+	resolver := NewResolver(db, querier, zap.NewNop())
+	resolver.UseClock(func() time.Time { return now })
+	return resolver, querier, db, &now
+}
+
+func TestResolve(t *testing.T) {
+	resolver, querier, db, now := newTestResolver(t)
+	ctx := context.Background()
+
+	counterparty, err := resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", counterparty.CounterpartyChainID)
+	assert.Equal(t, "channel-141", counterparty.CounterpartyChannelID)
+	assert.Equal(t, "connection-1", counterparty.ConnectionID)
+	assert.Equal(t, DefaultPort, counterparty.PortID)
+	assert.True(t, counterparty.ExpiresAt.Equal(testNow.Add(24*time.Hour)))
+
+	// Served from the cache while fresh
+	_, err = resolver.Resolve(ctx, "osmosis-1", DefaultPort, "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, 1, querier.queries)
+
+	// and from the database after a restart
+	restarted := NewResolver(db, querier, zap.NewNop())
+	restarted.UseClock(func() time.Time { return *now })
+	assert.Equal(t, "cosmoshub-4", restarted.CounterpartyChain(ctx, "osmosis-1", "channel-0"))
+	assert.Equal(t, 1, querier.queries)
+
+	// A stale entry is better than nothing while the chain can't be reached
+	*now = testNow.Add(25 * time.Hour)
+	querier.err = errors.New("connection refused")
+	counterparty, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, "cosmoshub-4", counterparty.CounterpartyChainID)
+
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-750")
+	assert.Error(t, err)
+
+	querier.err = nil
+	_, err = resolver.Resolve(ctx, "osmosis-1", "", "channel-9999")
+	assert.ErrorIs(t, err, ErrChannelNotFound)
+	assert.Equal(t, "", resolver.CounterpartyChain(ctx, "osmosis-1", "channel-9999"))
+}
+
+func TestRefresh(t *testing.T) {
+	resolver, querier, db, now := newTestResolver(t)
+	ctx := context.Background()
+	for _, channel := range []string{"channel-0", "channel-750"} {
+		_, err := resolver.Resolve(ctx, "osmosis-1", "", channel)
+		require.NoError(t, err)
+	}
+
+	// Nothing changed
+	result, err := resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Checked)
+	assert.Equal(t, 0, result.Resolved)
+
+	// channel-750 closes and moves to a new connection
+	*now = testNow.Add(time.Hour)
+	querier.channels["channel-750"].State = "STATE_CLOSED"
+	querier.channels["channel-750"].ConnectionHops = []string{"connection-3"}
+	result, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Resolved)
+
+	counterparty, err := resolver.Resolve(ctx, "osmosis-1", "", "channel-750")
+	require.NoError(t, err)
+	assert.Equal(t, "STATE_CLOSED", counterparty.State)
+	assert.Equal(t, "connection-3", counterparty.ConnectionID)
+
+	var stored Counterparty
+	require.NoError(t, db.Where("channel_id = ?", "channel-750").First(&stored).Error)
+	assert.Equal(t, "STATE_CLOSED", stored.State)
+	assert.True(t, stored.ResolvedAt.Equal(*now))
+
+	// Expired entries are resolved again
+	*now = testNow.Add(48 * time.Hour)
+	result, err = resolver.Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Resolved)
+
+	var count int64
+	require.NoError(t, db.Model(&Counterparty{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolver, _, _, _ := newTestResolver(t)
+	router := gin.New()
+	NewHandlers(resolver, zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	get := func(target string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, get("/api/v1/channels/osmosis-1/channel-0/counterparty"))
+	assert.Equal(t, http.StatusOK, get("/api/v1/channels/osmosis-1/channel-0/counterparty?refresh=true"))
+	assert.Equal(t, http.StatusNotFound, get("/api/v1/channels/osmosis-1/channel-9999/counterparty"))
+	assert.Equal(t, http.StatusOK, get("/api/v1/channels/counterparties"))
+}
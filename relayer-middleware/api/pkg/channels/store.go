@@ -0,0 +1,50 @@
+package channels
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dbStore keeps counterparties in the channel_counterparties table
+type dbStore struct {
+	db *gorm.DB
+}
+
+// Get implements chainchannels.Store
+func (s *dbStore) Get(ctx context.Context, chainID, portID, channelID string) (*Counterparty, error) {
+	var stored Counterparty
+	err := s.db.WithContext(ctx).
+		Where("chain_id = ? AND port_id = ? AND channel_id = ?", chainID, portID, channelID).
+		Limit(1).Find(&stored).Error
+	if err != nil || stored.ID == 0 {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// List implements chainchannels.Store
+func (s *dbStore) List(ctx context.Context) ([]Counterparty, error) {
+	var counterparties []Counterparty
+	err := s.db.WithContext(ctx).Order("chain_id, port_id, channel_id").Find(&counterparties).Error
+	return counterparties, err
+}
+
+// Save implements chainchannels.Store
+func (s *dbStore) Save(ctx context.Context, counterparty *Counterparty) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain_id"}, {Name: "port_id"}, {Name: "channel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"state", "connection_id", "client_id", "counterparty_chain_id",
+			"counterparty_port_id", "counterparty_channel_id", "resolved_at", "expires_at",
+		}),
+	}).Create(counterparty).Error
+}
+
+// Delete implements chainchannels.Store
+func (s *dbStore) Delete(ctx context.Context, chainID, portID, channelID string) error {
+	return s.db.WithContext(ctx).
+		Where("chain_id = ? AND port_id = ? AND channel_id = ?", chainID, portID, channelID).
+		Delete(&Counterparty{}).Error
+}
@@ -0,0 +1,26 @@
+package channels
+
+import (
+	chainchannels "github.com/relayooor/chains/channels"
+)
+
+// DefaultPort is the port channels are resolved on when none is given
+const DefaultPort = chainchannels.DefaultPort
+
+var (
+	ErrChannelNotFound = chainchannels.ErrChannelNotFound
+	ErrNoConnection    = chainchannels.ErrNoConnection
+)
+
+// Counterparty is where a channel leads, stored in channel_counterparties
+type Counterparty = chainchannels.Counterparty
+
+// ChannelEnd is a channel as its own chain stores it
+type ChannelEnd = chainchannels.ChannelEnd
+
+// Querier reads the channel, connection and client state a counterparty is
+// resolved from
+type Querier = chainchannels.Querier
+
+// RefreshResult summarises one refresh of the stored counterparties
+type RefreshResult = chainchannels.RefreshResult
@@ -0,0 +1,158 @@
+package clearing
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"relayooor/api/pkg/apikeys"
+	"relayooor/api/pkg/audit"
+	"relayooor/api/pkg/middleware"
+	"relayooor/api/pkg/types"
+)
+
+// RefundsResponse represents a paginated list of refunds
+type RefundsResponse struct {
+	Refunds    []RefundableOperation    `json:"refunds"`
+	Pagination types.PaginationResponse `json:"pagination"`
+}
+
+// RetryRefundRequest represents a manual refund request
+type RetryRefundRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// UseAPIKeys enables API key authentication on clearing routes.
+// Must be called before RegisterRoutes.
+func (h *HandlersV2) UseAPIKeys(service *apikeys.Service) {
+	h.apiKeys = service
+}
+
+// UseAuditLog records refund processing in the audit log
+func (h *HandlersV2) UseAuditLog(auditLog *audit.Service) {
+	h.service.refundService.SetAuditLog(auditLog)
+}
+
+// UsePacketChecker rejects token requests for packets a clear can't help
+func (h *HandlersV2) UsePacketChecker(checker PacketChecker) {
+	h.service.packetChecker = checker
+}
+
+// UseTimeouts queues the clearing of packets about to time out ahead of
+// other operations
+func (h *HandlersV2) UseTimeouts(predictor TimeoutPredictor) {
+	h.service.timeouts = predictor
+}
+
+// UseRateLimiter enables per-route rate limiting on public clearing routes.
+// Must be called before RegisterRoutes.
+func (h *HandlersV2) UseRateLimiter(limiter *middleware.RateLimiter) {
+	h.limiter = limiter
+}
+
+// rateLimit returns the limiter for a policy, or a no-op when disabled
+func (h *HandlersV2) rateLimit(policy string) gin.HandlerFunc {
+	if h.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return h.limiter.Limit(policy)
+}
+
+// RegisterAdminRoutes registers operator-only refund routes. Operators
+// authenticate with a JWT or an API key holding the admin:refunds scope.
+func (h *HandlersV2) RegisterAdminRoutes(router *gin.RouterGroup) {
+	admin := router.Group("/admin")
+	admin.Use(middleware.APIKeyAuth(h.apiKeys, middleware.AuthRequired()))
+	admin.Use(middleware.RequireScope(apikeys.ScopeAdminRefunds))
+	{
+		admin.GET("/refunds", h.ListRefunds)
+		admin.POST("/refunds/:operation_id/retry", h.RetryRefund)
+	}
+}
+
+// ListRefunds handles GET /api/v1/admin/refunds
+func (h *HandlersV2) ListRefunds(c *gin.Context) {
+	pagination := types.NewPaginationRequest()
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Code:    "INVALID_PAGINATION",
+				Message: "Invalid pagination parameters",
+				Details: sanitizeError(err),
+			},
+		})
+		return
+	}
+
+	refunds, total, err := h.service.refundService.ListRefunds(
+		c.Request.Context(),
+		c.Query("status"),
+		pagination.Offset(),
+		pagination.PageSize,
+	)
+	if err != nil {
+		h.logger.Error("Failed to list refunds", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: ErrorDetail{
+				Code:    "DB_ERROR",
+				Message: "Failed to retrieve refunds",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, RefundsResponse{
+		Refunds:    refunds,
+		Pagination: types.CalculatePaginationResponse(pagination.Page, pagination.PageSize, total),
+	})
+}
+
+// RetryRefund handles POST /api/v1/admin/refunds/:operation_id/retry
+func (h *HandlersV2) RetryRefund(c *gin.Context) {
+	operationID := c.Param("operation_id")
+
+	var request RetryRefundRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request format",
+				Details: sanitizeError(err),
+			},
+		})
+		return
+	}
+
+	h.logger.Info("Manual refund requested",
+		zap.String("operation_id", operationID),
+		zap.String("username", c.GetString("username")),
+		zap.String("api_key_id", c.GetString("api_key_id")),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+
+	ctx := audit.WithActor(c.Request.Context(), audit.ActorFromGin(c))
+	ctx = audit.WithRequestID(ctx, c.GetString("request_id"))
+
+	if err := h.service.refundService.ProcessRefund(ctx, operationID, request.Reason); err != nil {
+		status := http.StatusInternalServerError
+		code := "REFUND_FAILED"
+		if errors.Is(err, ErrInsufficientRefundBalance) {
+			status = http.StatusServiceUnavailable
+			code = "INSUFFICIENT_REFUND_BALANCE"
+		}
+		c.JSON(status, ErrorResponse{
+			Error: ErrorDetail{
+				Code:    code,
+				Message: "Failed to process refund",
+				Details: sanitizeError(err),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"operation_id": operationID,
+		"status":       "completed",
+	})
+}
@@ -3,14 +3,13 @@ package clearing
 import (
 	"context"
 	"errors"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 )
 
 // Test error types
@@ -20,31 +19,12 @@ func TestErrorTypes(t *testing.T) {
 		err      error
 		expected string
 	}{
-		{
-			name:     "validation error",
-			err:      ErrInvalidRequest,
-			expected: "invalid request",
-		},
-		{
-			name:     "not found error",
-			err:      ErrTokenNotFound,
-			expected: "token not found",
-		},
-		{
-			name:     "duplicate payment error",
-			err:      ErrDuplicatePayment,
-			expected: "duplicate payment detected",
-		},
-		{
-			name:     "payment validation error",
-			err:      ErrPaymentValidation,
-			expected: "payment validation failed",
-		},
-		{
-			name:     "execution error",
-			err:      ErrExecutionFailed,
-			expected: "execution failed",
-		},
+		{name: "expired token", err: ErrTokenExpired, expected: "token expired"},
+		{name: "duplicate payment", err: ErrDuplicatePayment, expected: "duplicate payment detected"},
+		{name: "no payment", err: ErrNoPaymentFound, expected: "no payment found"},
+		{name: "not clearable", err: ErrPacketNotClearable, expected: "can't be cleared"},
+		{name: "underpayment", err: &ErrUnderpayment{Required: "100", Paid: "50", Denom: "uosmo"}, expected: "insufficient payment: required 100 uosmo, paid 50 uosmo"},
+		{name: "overpayment", err: &ErrOverpayment{Required: "100", Paid: "150", Denom: "uosmo"}, expected: "overpayment detected"},
 	}
 
 	for _, tt := range tests {
@@ -52,6 +32,11 @@ func TestErrorTypes(t *testing.T) {
 			assert.Contains(t, tt.err.Error(), tt.expected)
 		})
 	}
+
+	assert.True(t, IsOverpayment(&ErrOverpayment{}))
+	assert.False(t, IsOverpayment(&ErrUnderpayment{}))
+	assert.True(t, IsUnderpayment(&ErrUnderpayment{}))
+	assert.False(t, IsUnderpayment(ErrNoPaymentFound))
 }
 
 // Test database error handling
@@ -60,395 +45,159 @@ func TestDatabaseErrorHandling(t *testing.T) {
 	ctx := context.Background()
 
 	// Close database to simulate connection error
-	sqlDB, _ := db.DB()
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
 	sqlDB.Close()
 
-	// Try to request token with closed DB
-	req := &ClearingRequest{
-		WalletAddress: "osmo1test123",
-		ChainID:       "osmosis-1",
-		Type:          "packet",
-		Targets: ClearingTargets{
-			Packets: []PacketIdentifier{
-				{Chain: "osmosis-1", Channel: "channel-0", Sequence: 123},
-			},
-		},
-	}
+	// Tokens live in Redis, so they're still issued
+	_, err = service.GenerateToken(ctx, testRequest(123))
+	assert.NoError(t, err)
 
-	_, err := service.RequestToken(ctx, req)
+	// but a paid operation can't be looked up
+	status, err := service.GetStatus(ctx, "paid-token")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "database")
+	assert.NotEqual(t, "operation not found", err.Error())
+	assert.Nil(t, status)
 }
 
 // Test Redis error handling
 func TestRedisErrorHandling(t *testing.T) {
-	service, _, redisClient := setupTestService(t)
+	service, _, mr := setupTestService(t)
 	ctx := context.Background()
 
 	// Close Redis to simulate connection error
-	redisClient.Close()
-
-	// Create operation in DB first
-	op := &ClearingOperation{
-		Token:  "test-token",
-		Status: "pending",
-	}
+	mr.Close()
 
-	// Cache operations should fail gracefully
-	err := service.cache.Set(ctx, op)
+	_, err := service.GenerateToken(ctx, testRequest(123))
 	assert.Error(t, err)
 
-	// Get should return error
-	_, err = service.cache.Get(ctx, "test-token")
-	assert.Error(t, err)
+	// Duplicate payments are still caught through the database
+	duplicate, err := service.duplicateDetector.CheckDuplicate(ctx, "tx123")
+	require.NoError(t, err)
+	assert.False(t, duplicate)
+	duplicate, err = service.duplicateDetector.CheckDuplicate(ctx, "tx123")
+	require.NoError(t, err)
+	assert.True(t, duplicate)
 }
 
 // Test timeout handling
 func TestTimeoutHandling(t *testing.T) {
-	service, _, _ := setupTestService(t)
-
-	// Create context with very short timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
-	defer cancel()
+	service, _, mr := setupTestService(t)
 
-	// Let context expire
-	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
 
-	req := &ClearingRequest{
-		WalletAddress: "osmo1test123",
-		ChainID:       "osmosis-1",
-		Type:          "packet",
-	}
-
-	_, err := service.RequestToken(ctx, req)
-	assert.Error(t, err)
-	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	_, err := service.GenerateToken(ctx, testRequest(123))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Empty(t, mr.Keys())
 }
 
 // Test payment validation errors
 func TestPaymentValidationErrors(t *testing.T) {
-	service, db, _ := setupTestService(t)
+	validator := NewPaymentValidator("osmo1service")
 	ctx := context.Background()
+	send := []Message{{Type: "/cosmos.bank.v1beta1.MsgSend"}}
 
 	tests := []struct {
 		name          string
-		setupOp       func() *ClearingOperation
-		mockBehavior  func(*MockPaymentValidator)
+		token         *ClearingToken
+		tx            *Transaction
+		check         func(error) bool
 		expectedError string
 	}{
 		{
-			name: "invalid amount",
-			setupOp: func() *ClearingOperation {
-				return &ClearingOperation{
-					Token:         "test-token",
-					Status:        "pending_payment",
-					PaymentAmount: "invalid",
-					ExpiresAt:     time.Now().Add(5 * time.Minute),
-				}
-			},
-			mockBehavior: func(m *MockPaymentValidator) {
-				m.On("ValidatePayment", ctx, mock.Anything).
-					Return(errors.New("invalid payment amount"))
-			},
-			expectedError: "invalid payment amount",
+			name:          "no payment",
+			token:         &ClearingToken{TotalRequired: "1000000", AcceptedDenom: "uatom"},
+			tx:            &Transaction{Amount: "1000000"},
+			check:         func(err error) bool { return errors.Is(err, ErrNoPaymentFound) },
+			expectedError: "no payment found",
+		},
+		{
+			name:          "wrong denom",
+			token:         &ClearingToken{TotalRequired: "1000000", AcceptedDenom: "uosmo"},
+			tx:            &Transaction{Amount: "1000000", Messages: send},
+			check:         func(err error) bool { return err != nil },
+			expectedError: "invalid payment denomination",
 		},
 		{
-			name: "insufficient amount",
-			setupOp: func() *ClearingOperation {
-				return &ClearingOperation{
-					Token:         "test-token",
-					Status:        "pending_payment",
-					PaymentAmount: "1000000",
-					ExpiresAt:     time.Now().Add(5 * time.Minute),
-				}
-			},
-			mockBehavior: func(m *MockPaymentValidator) {
-				m.On("ValidatePayment", ctx, mock.Anything).
-					Return(errors.New("insufficient payment amount"))
-			},
-			expectedError: "insufficient payment amount",
+			name:          "insufficient amount",
+			token:         &ClearingToken{TotalRequired: "1000000", AcceptedDenom: "uatom"},
+			tx:            &Transaction{Amount: "900000", Messages: send},
+			check:         IsUnderpayment,
+			expectedError: "insufficient payment",
 		},
 		{
-			name: "wrong memo",
-			setupOp: func() *ClearingOperation {
-				return &ClearingOperation{
-					Token:         "test-token",
-					Status:        "pending_payment",
-					PaymentAmount: "1000000",
-					PaymentMemo:   "expected-memo",
-					ExpiresAt:     time.Now().Add(5 * time.Minute),
-				}
-			},
-			mockBehavior: func(m *MockPaymentValidator) {
-				m.On("ValidatePayment", ctx, mock.Anything).
-					Return(errors.New("payment memo mismatch"))
-			},
-			expectedError: "payment memo mismatch",
+			name:          "overpayment",
+			token:         &ClearingToken{TotalRequired: "1000000", AcceptedDenom: "uatom"},
+			tx:            &Transaction{Amount: "2000000", Messages: send},
+			check:         IsOverpayment,
+			expectedError: "overpayment detected",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Setup
-			op := tt.setupOp()
-			err := db.Create(op).Error
-			assert.NoError(t, err)
-
-			// Mock behavior
-			mockValidator := service.paymentValidator.(*MockPaymentValidator)
-			tt.mockBehavior(mockValidator)
-
-			// Mock duplicate detector
-			mockDetector := service.duplicateDetector.(*MockDuplicateDetector)
-			mockDetector.On("IsDuplicate", ctx, mock.Anything).Return(false, nil).Once()
-
-			// Test
-			err = service.VerifyPayment(ctx, op.Token, "tx123")
-			assert.Error(t, err)
+			err := validator.ValidatePayment(ctx, tt.token, tt.tx)
+			require.Error(t, err)
+			assert.True(t, tt.check(err), err.Error())
 			assert.Contains(t, err.Error(), tt.expectedError)
-
-			// Cleanup
-			db.Delete(op)
-			mockValidator.ExpectedCalls = nil
-			mockDetector.ExpectedCalls = nil
 		})
 	}
-}
-
-// Test execution errors with refund
-func TestExecutionErrorsWithRefund(t *testing.T) {
-	execService, mockRelayer := setupTestExecutionService(t)
-	ctx := context.Background()
-
-	op := &ClearingOperation{
-		ID:    "test-op",
-		Token: "test-token",
-		Targets: map[string]interface{}{
-			"packets": []map[string]interface{}{
-				{
-					"chain":    "osmosis-1",
-					"channel":  "channel-0",
-					"sequence": float64(123),
-				},
-			},
-		},
-	}
 
-	payment := &PaymentRecord{
-		TxHash:        "payment-tx-123",
-		Amount:        "1000000",
-		WalletAddress: "osmo1sender",
-	}
-
-	// Mock clearing failure after max retries
-	mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(123)).
-		Return(errors.New("permanent failure")).Times(3)
-
-	// Mock refund service
-	mockRefund := execService.refundService.(*MockRefundService)
-	mockRefund.On("ProcessRefund", ctx, payment, mock.MatchedBy(func(reason string) bool {
-		return strings.Contains(reason, "clearing failed")
-	})).Return(nil)
-
-	// Mock tracker
-	tracker := execService.tracker.(*MockOperationTracker)
-	tracker.On("StartOperation", mock.Anything).Return()
-
-	// Execute with payment for refund
-	result, err := execService.ExecutePacketClearingWithRefund(ctx, op, payment)
-
-	assert.Error(t, err)
-	assert.NotNil(t, result)
-	assert.Len(t, result.FailedPackets, 1)
-
-	// Verify refund was called
-	mockRefund.AssertCalled(t, "ProcessRefund", ctx, payment, mock.Anything)
+	// Gas estimates may be off by up to 1%
+	token := &ClearingToken{TotalRequired: "1000000", AcceptedDenom: "uatom"}
+	assert.NoError(t, validator.ValidatePayment(ctx, token, &Transaction{Amount: "995000", Messages: send}))
+	assert.NoError(t, validator.ValidatePayment(ctx, token, &Transaction{Amount: "1010000", Messages: send}))
 }
 
-// Test concurrent error scenarios
+// Test concurrent submissions of one payment
 func TestConcurrentErrorHandling(t *testing.T) {
-	service, db, _ := setupTestService(t)
+	service, _, _ := setupTestService(t)
 	ctx := context.Background()
 
-	// Create multiple operations that will conflict
-	const numOps = 5
-	token := "conflict-token"
-
-	errors := make(chan error, numOps)
-
-	for i := 0; i < numOps; i++ {
+	// Only one of the submissions is accepted, the rest are duplicates
+	const attempts = 10
+	var accepted int32
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
 		go func() {
-			op := &ClearingOperation{
-				Token:         token,
-				WalletAddress: "osmo1test123",
-				Status:        "pending",
-				ExpiresAt:     time.Now().Add(5 * time.Minute),
+			defer wg.Done()
+			duplicate, err := service.duplicateDetector.CheckDuplicate(ctx, "tx-concurrent")
+			assert.NoError(t, err)
+			if !duplicate {
+				atomic.AddInt32(&accepted, 1)
 			}
-			err := db.Create(op).Error
-			errors <- err
 		}()
 	}
-
-	// Collect results
-	successCount := 0
-	errorCount := 0
-
-	for i := 0; i < numOps; i++ {
-		err := <-errors
-		if err == nil {
-			successCount++
-		} else {
-			errorCount++
-			// Should be unique constraint violation
-			assert.Contains(t, err.Error(), "UNIQUE") // SQLite error
-		}
-	}
-
-	// Only one should succeed due to unique token constraint
-	assert.Equal(t, 1, successCount)
-	assert.Equal(t, numOps-1, errorCount)
+	wg.Wait()
+	assert.Equal(t, int32(1), accepted)
 }
 
-// Test panic recovery
+// Test a panicking clearing doesn't take the worker down with it
 func TestPanicRecovery(t *testing.T) {
-	logger, _ := zap.NewDevelopment()
-
-	// Create a service method that panics
-	panicService := &ServiceWithPanicRecovery{
-		logger: logger,
-	}
-
-	// Should recover from panic and return error
-	err := panicService.MethodThatMightPanic()
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "panic recovered")
-}
-
-// ServiceWithPanicRecovery demonstrates panic recovery
-type ServiceWithPanicRecovery struct {
-	logger *zap.Logger
-}
-
-func (s *ServiceWithPanicRecovery) MethodThatMightPanic() (err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.Error("Panic recovered", zap.Any("panic", r))
-			err = errors.New("panic recovered: operation failed")
-		}
-	}()
-
-	// Simulate panic
-	panic("unexpected error")
-}
-
-// Test error aggregation
-func TestErrorAggregation(t *testing.T) {
-	var errs ErrorList
-
-	errs.Add(errors.New("error 1"))
-	errs.Add(errors.New("error 2"))
-	errs.Add(nil) // Should be ignored
-	errs.Add(errors.New("error 3"))
-
-	assert.Len(t, errs, 3)
-	assert.Contains(t, errs.Error(), "error 1")
-	assert.Contains(t, errs.Error(), "error 2")
-	assert.Contains(t, errs.Error(), "error 3")
-}
+	service, _, _ := setupTestService(t)
+	tracker := &testTracker{added: make(chan string, 1), panics: true}
+	execution := NewExecutionServiceV2(service.db, service.redisClient, &fakeHermes{}, service.refundService, tracker, zap.NewNop())
 
-// ErrorList aggregates multiple errors
-type ErrorList []error
+	execution.workerPool <- struct{}{}
+	execution.activeTasks.Add(1)
+	assert.NotPanics(t, func() { execution.runClearing(context.Background(), "token-1") })
 
-func (e *ErrorList) Add(err error) {
-	if err != nil {
-		*e = append(*e, err)
-	}
+	// The worker slot is released
+	assert.Empty(t, execution.workerPool)
+	execution.activeTasks.Wait()
 }
 
-func (e ErrorList) Error() string {
-	if len(e) == 0 {
-		return ""
-	}
-	
-	messages := make([]string, len(e))
-	for i, err := range e {
-		messages[i] = err.Error()
-	}
-	
-	return "multiple errors: " + strings.Join(messages, "; ")
-}
-
-// Test graceful degradation
+// Test clearing carries on while the database is unavailable
 func TestGracefulDegradation(t *testing.T) {
-	service, _, _ := setupTestService(t)
-	ctx := context.Background()
-
-	// Simulate various component failures
-	tests := []struct {
-		name             string
-		failureScenario  func()
-		operation        func() error
-		shouldSucceed    bool
-		expectedBehavior string
-	}{
-		{
-			name: "cache failure - should continue",
-			failureScenario: func() {
-				// Cache is unavailable
-				service.cache = nil
-			},
-			operation: func() error {
-				req := &ClearingRequest{
-					WalletAddress: "osmo1test123",
-					ChainID:       "osmosis-1",
-					Type:          "packet",
-				}
-				_, err := service.RequestToken(ctx, req)
-				return err
-			},
-			shouldSucceed:    true,
-			expectedBehavior: "continues without cache",
-		},
-		{
-			name: "metrics failure - should continue",
-			failureScenario: func() {
-				// Metrics collector is nil
-				service.metrics = nil
-			},
-			operation: func() error {
-				service.recordMetric("test_metric", 1)
-				return nil
-			},
-			shouldSucceed:    true,
-			expectedBehavior: "continues without metrics",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Apply failure scenario
-			tt.failureScenario()
-
-			// Execute operation
-			err := tt.operation()
+	service, db, _ := setupTestService(t)
+	execution := NewExecutionServiceV2(db, service.redisClient, &fakeHermes{}, service.refundService, &simpleOperationTracker{}, zap.NewNop())
 
-			if tt.shouldSucceed {
-				assert.NoError(t, err, tt.expectedBehavior)
-			} else {
-				assert.Error(t, err)
-			}
-		})
-	}
-}
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.Close()
 
-// Helper method for metrics recording
-func (s *ServiceV2) recordMetric(name string, value float64) {
-	// Gracefully handle nil metrics
-	if s.metrics == nil {
-		return
-	}
-	
-	// Record metric
-	s.metrics.Record(name, value)
+	// Status updates are only logged when they fail
+	assert.NoError(t, execution.executeClearing(context.Background(), "token-1"))
 }
-
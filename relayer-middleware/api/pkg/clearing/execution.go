@@ -32,6 +32,9 @@ type ExecutionServiceV2 struct {
 	retrier        *retry.Retrier
 	refundService  *RefundService
 	tracker        OperationTracker
+	// pollTimeout bounds how long the queue is blocked on, and so how long
+	// shutting down waits for the processor to notice
+	pollTimeout time.Duration
 }
 
 // HermesClient interface for Hermes interactions
@@ -89,6 +92,7 @@ func NewExecutionServiceV2(
 		retrier:        retry.NewRetrier(retry.DefaultConfig(), logger),
 		refundService:  refundService,
 		tracker:        tracker,
+		pollTimeout:    5 * time.Second,
 	}
 }
 
@@ -106,7 +110,7 @@ func (es *ExecutionServiceV2) processQueue(ctx context.Context) {
 			return
 		default:
 			// Get next operation from queue
-			tokenID, err := es.redisClient.BLPop(ctx, 5*time.Second, "clearing:execution:queue").Result()
+			tokenID, err := es.redisClient.BLPop(ctx, es.pollTimeout, "clearing:execution:queue").Result()
 			if err != nil {
 				if err != redis.Nil {
 					es.logger.Error("Failed to get from queue", zap.Error(err))
@@ -122,32 +126,42 @@ func (es *ExecutionServiceV2) processQueue(ctx context.Context) {
 			es.workerPool <- struct{}{} // Acquire worker slot
 			es.activeTasks.Add(1)
 
-			go func(token string) {
-				defer func() {
-					<-es.workerPool // Release worker slot
-					es.activeTasks.Done()
-				}()
-
-				// Track operation
-				op := &ActiveOperation{
-					ID:        token,
-					StartTime: time.Now(),
-					Type:      "clearing",
-				}
-				es.tracker.Add(op)
-				defer es.tracker.Remove(op.ID)
-
-				if err := es.executeClearing(ctx, token); err != nil {
-					es.logger.Error("Failed to execute clearing",
-						zap.String("token", token),
-						zap.Error(err),
-					)
-				}
-			}(tokenID[1])
+			go es.runClearing(ctx, tokenID[1])
 		}
 	}
 }
 
+// runClearing executes a queued clearing in a worker slot. A panic fails
+// only this clearing, not the server.
+func (es *ExecutionServiceV2) runClearing(ctx context.Context, token string) {
+	defer func() {
+		if r := recover(); r != nil {
+			es.logger.Error("Clearing panicked",
+				zap.String("token", token),
+				zap.Any("panic", r),
+			)
+		}
+		<-es.workerPool // Release worker slot
+		es.activeTasks.Done()
+	}()
+
+	// Track operation
+	op := &ActiveOperation{
+		ID:        token,
+		StartTime: time.Now(),
+		Type:      "clearing",
+	}
+	es.tracker.Add(op)
+	defer es.tracker.Remove(op.ID)
+
+	if err := es.executeClearing(ctx, token); err != nil {
+		es.logger.Error("Failed to execute clearing",
+			zap.String("token", token),
+			zap.Error(err),
+		)
+	}
+}
+
 func (es *ExecutionServiceV2) executeClearing(ctx context.Context, tokenID string) error {
 	// Get operation details
 	operation, err := es.getOperation(ctx, tokenID)
@@ -317,16 +331,22 @@ func (es *ExecutionServiceV2) updateOperationStatus(operationID, status, message
 		}).Error
 }
 
+// completeOperation marks an operation completed with the transactions that
+// cleared it. clearing_operations has no success column: failed clearings
+// never get here, they go through handleClearingFailure.
 func (es *ExecutionServiceV2) completeOperation(operationID string, result *ClearingResult) error {
 	now := time.Now()
+	update := ClearingOperation{
+		Status:            "completed",
+		ExecutionTxHashes: result.TxHashes,
+		CompletedAt:       &now,
+	}
+	if len(result.TxHashes) > 0 {
+		update.ClearingTxHash = result.TxHashes[0]
+	}
 	return es.db.Model(&ClearingOperation{}).
 		Where("id = ?", operationID).
-		Updates(map[string]interface{}{
-			"status":          "completed",
-			"success":         result.Success,
-			"clearing_tx_hash": result.TxHashes[0], // Store first tx hash
-			"completed_at":    &now,
-		}).Error
+		Updates(update).Error
 }
 
 func (es *ExecutionServiceV2) broadcastStatus(tokenID string, status ClearingStatus) {
@@ -3,347 +3,257 @@ package clearing
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/sony/gobreaker"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+
+	"relayooor/api/pkg/circuitbreaker"
 )
 
-// Mock relayer client
-type MockRelayerClient struct {
-	mock.Mock
+// fakeHermes records clear requests and answers them with respond
+type fakeHermes struct {
+	mu       sync.Mutex
+	requests []ClearPacketsRequest
+	respond  func(req *ClearPacketsRequest) (*ClearPacketsResponse, error)
 }
 
-func (m *MockRelayerClient) ClearPacket(ctx context.Context, chain, channel string, sequence uint64) error {
-	args := m.Called(ctx, chain, channel, sequence)
-	return args.Error(0)
+func (f *fakeHermes) ClearPackets(ctx context.Context, req *ClearPacketsRequest) (*ClearPacketsResponse, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, *req)
+	f.mu.Unlock()
+	return f.respond(req)
 }
 
-// Mock operation tracker
-type MockOperationTracker struct {
-	mock.Mock
+func (f *fakeHermes) GetVersion(ctx context.Context) (*VersionResponse, error) {
+	return &VersionResponse{Version: "1.10.0"}, nil
 }
 
-func (m *MockOperationTracker) StartOperation(id string) {
-	m.Called(id)
+func (f *fakeHermes) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
 }
 
-func (m *MockOperationTracker) CompleteOperation(id string) {
-	m.Called(id)
+// testTracker reports each clearing it's given and holds it until release
+// is closed, or panics
+type testTracker struct {
+	added   chan string
+	release chan struct{}
+	panics  bool
 }
 
-func (m *MockOperationTracker) WaitForCompletion(timeout time.Duration) bool {
-	args := m.Called(timeout)
-	return args.Bool(0)
+func (t *testTracker) Add(op *ActiveOperation) {
+	t.added <- op.ID
+	if t.panics {
+		panic("tracker failed")
+	}
+	if t.release != nil {
+		<-t.release
+	}
 }
 
+func (t *testTracker) Remove(id string) {}
+
 // Test setup
-func setupTestExecutionService(t *testing.T) (*ExecutionServiceV2, *MockRelayerClient) {
-	logger, _ := zap.NewDevelopment()
-	
-	mockRelayer := &MockRelayerClient{}
-	mockTracker := &MockOperationTracker{}
-	
-	// Create circuit breaker with test settings
-	cbSettings := gobreaker.Settings{
-		Name:        "test-circuit-breaker",
-		MaxRequests: 2,
-		Interval:    10 * time.Second,
-		Timeout:     5 * time.Second,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
-			return counts.Requests >= 3 && failureRatio >= 0.6
-		},
-	}
-	
-	service := &ExecutionServiceV2{
-		circuitBreaker: gobreaker.NewCircuitBreaker(cbSettings),
-		retrier:        NewRetrier(3, 100*time.Millisecond, 2.0),
-		refundService:  &MockRefundService{},
-		tracker:        mockTracker,
-		relayerClient:  mockRelayer,
-		logger:         logger,
-	}
-	
-	return service, mockRelayer
+func setupTestExecutionService(t *testing.T, hermes *fakeHermes) *ExecutionServiceV2 {
+	t.Helper()
+	service, _, _ := setupTestService(t)
+	return NewExecutionServiceV2(
+		service.db,
+		service.redisClient,
+		hermes,
+		service.refundService,
+		&simpleOperationTracker{},
+		zap.NewNop(),
+	)
+}
+
+func channelPacket(channel string, sequence uint64) PacketIdentifier {
+	return PacketIdentifier{ChainID: "osmosis-1", ChannelID: channel, PortID: "transfer", Sequence: sequence}
 }
 
 // Test successful packet clearing
-func TestExecutePacketClearingSuccess(t *testing.T) {
-	service, mockRelayer := setupTestExecutionService(t)
-	ctx := context.Background()
-	
-	op := &ClearingOperation{
-		ID:    "test-op-1",
-		Token: "test-token",
-		Targets: map[string]interface{}{
-			"packets": []map[string]interface{}{
-				{
-					"chain":    "osmosis-1",
-					"channel":  "channel-0",
-					"sequence": float64(123),
-				},
-			},
-		},
-	}
-	
-	// Mock successful clearing
-	mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(123)).Return(nil)
-	
-	// Mock tracker
-	tracker := service.tracker.(*MockOperationTracker)
-	tracker.On("StartOperation", "clear-osmosis-1-channel-0-123").Return()
-	tracker.On("CompleteOperation", "clear-osmosis-1-channel-0-123").Return()
-	
-	// Execute
-	result, err := service.ExecutePacketClearing(ctx, op)
-	
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, 1, result.PacketsCleared)
-	assert.Len(t, result.FailedPackets, 0)
-	
-	mockRelayer.AssertExpectations(t)
-	tracker.AssertExpectations(t)
+func TestClearPacketsSuccess(t *testing.T) {
+	hermes := &fakeHermes{respond: func(req *ClearPacketsRequest) (*ClearPacketsResponse, error) {
+		return &ClearPacketsResponse{Success: true, TxHashes: []string{"tx-" + req.Channel}}, nil
+	}}
+	service := setupTestExecutionService(t, hermes)
+
+	result, err := service.clearPackets(context.Background(), []PacketIdentifier{
+		channelPacket("channel-0", 1),
+		channelPacket("channel-1", 7),
+		channelPacket("channel-0", 2),
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.ElementsMatch(t, []string{"tx-channel-0", "tx-channel-1"}, result.TxHashes)
+
+	// One request per channel
+	require.Equal(t, 2, hermes.calls())
+	sort.Slice(hermes.requests, func(i, j int) bool { return hermes.requests[i].Channel < hermes.requests[j].Channel })
+	assert.Equal(t, ClearPacketsRequest{Chain: "osmosis-1", Channel: "channel-0", Port: "transfer", Sequences: []uint64{1, 2}}, hermes.requests[0])
+	assert.Equal(t, []uint64{7}, hermes.requests[1].Sequences)
 }
 
-// Test packet clearing with retry
-func TestExecutePacketClearingWithRetry(t *testing.T) {
-	service, mockRelayer := setupTestExecutionService(t)
-	ctx := context.Background()
-	
-	op := &ClearingOperation{
-		ID:    "test-op-2",
-		Token: "test-token",
-		Targets: map[string]interface{}{
-			"packets": []map[string]interface{}{
-				{
-					"chain":    "osmosis-1",
-					"channel":  "channel-0",
-					"sequence": float64(123),
-				},
-			},
-		},
-	}
-	
-	// Mock first two attempts fail, third succeeds
-	mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(123)).
-		Return(errors.New("temporary error")).Twice()
-	mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(123)).
-		Return(nil).Once()
-	
-	// Mock tracker
-	tracker := service.tracker.(*MockOperationTracker)
-	tracker.On("StartOperation", mock.Anything).Return()
-	tracker.On("CompleteOperation", mock.Anything).Return()
-	
-	// Execute
-	result, err := service.ExecutePacketClearing(ctx, op)
-	
-	assert.NoError(t, err)
-	assert.NotNil(t, result)
-	assert.Equal(t, 1, result.PacketsCleared)
-	
-	// Verify retry happened
-	mockRelayer.AssertNumberOfCalls(t, "ClearPacket", 3)
+// Test packet clearing that Hermes rejects
+func TestClearPacketsFailure(t *testing.T) {
+	hermes := &fakeHermes{respond: func(req *ClearPacketsRequest) (*ClearPacketsResponse, error) {
+		return &ClearPacketsResponse{Success: false, Error: "packet commitment not found"}, nil
+	}}
+	service := setupTestExecutionService(t, hermes)
+
+	result, err := service.clearPackets(context.Background(), []PacketIdentifier{channelPacket("channel-0", 1)})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "packet commitment not found")
+	assert.False(t, result.Success)
+
+	// Clearing isn't idempotent, so a rejected clear isn't retried
+	assert.Equal(t, 1, hermes.calls())
 }
 
-// Test circuit breaker open
-func TestExecutePacketClearingCircuitBreakerOpen(t *testing.T) {
-	service, mockRelayer := setupTestExecutionService(t)
+// Test the circuit breaker in front of Hermes
+func TestCircuitBreakerClient(t *testing.T) {
+	hermes := &fakeHermes{respond: func(req *ClearPacketsRequest) (*ClearPacketsResponse, error) {
+		return nil, errors.New("connection refused")
+	}}
+	client := NewCircuitBreakerClient(hermes)
 	ctx := context.Background()
-	
-	// Cause circuit breaker to open by failing multiple times
+	req := &ClearPacketsRequest{Chain: "osmosis-1", Channel: "channel-0", Port: "transfer", Sequences: []uint64{1}}
+
 	for i := 0; i < 5; i++ {
-		op := &ClearingOperation{
-			ID:    "test-op-fail-" + string(rune(i)),
-			Token: "test-token",
-			Targets: map[string]interface{}{
-				"packets": []map[string]interface{}{
-					{
-						"chain":    "osmosis-1",
-						"channel":  "channel-0",
-						"sequence": float64(i),
-					},
-				},
-			},
-		}
-		
-		mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(i)).
-			Return(errors.New("service unavailable"))
-		
-		// Mock tracker
-		tracker := service.tracker.(*MockOperationTracker)
-		tracker.On("StartOperation", mock.Anything).Return()
-		
-		_, _ = service.ExecutePacketClearing(ctx, op)
-	}
-	
-	// Now circuit breaker should be open
-	op := &ClearingOperation{
-		ID:    "test-op-blocked",
-		Token: "test-token",
-		Targets: map[string]interface{}{
-			"packets": []map[string]interface{}{
-				{
-					"chain":    "osmosis-1",
-					"channel":  "channel-0",
-					"sequence": float64(999),
-				},
-			},
-		},
+		_, err := client.ClearPackets(ctx, req)
+		assert.EqualError(t, err, "connection refused")
 	}
-	
-	// This call should fail immediately due to circuit breaker
-	result, err := service.ExecutePacketClearing(ctx, op)
-	
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "circuit breaker is open")
-	assert.Nil(t, result)
-	
-	// Verify the last call wasn't made to the relayer
-	mockRelayer.AssertNotCalled(t, "ClearPacket", ctx, "osmosis-1", "channel-0", uint64(999))
+
+	// Once open, Hermes isn't called at all
+	_, err := client.ClearPackets(ctx, req)
+	assert.ErrorIs(t, err, ErrHermesUnavailable)
+	assert.Equal(t, 5, hermes.calls())
 }
 
-// Test concurrent execution
-func TestConcurrentPacketClearing(t *testing.T) {
-	service, mockRelayer := setupTestExecutionService(t)
-	ctx := context.Background()
-	
-	// Setup multiple operations
-	numOps := 10
-	operations := make([]*ClearingOperation, numOps)
-	
-	for i := 0; i < numOps; i++ {
-		operations[i] = &ClearingOperation{
-			ID:    "test-op-concurrent-" + string(rune(i)),
-			Token: "test-token-" + string(rune(i)),
-			Targets: map[string]interface{}{
-				"packets": []map[string]interface{}{
-					{
-						"chain":    "osmosis-1",
-						"channel":  "channel-0",
-						"sequence": float64(i),
-					},
-				},
-			},
-		}
-		
-		// Mock successful clearing
-		mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(i)).Return(nil)
+// Test which failures are refunded
+func TestDetermineRefundReason(t *testing.T) {
+	service := setupTestExecutionService(t, &fakeHermes{})
+
+	tests := []struct {
+		err        error
+		refundable bool
+	}{
+		{err: ErrChannelClosed, refundable: true},
+		{err: fmt.Errorf("clear channel-0: %w", ErrHermesUnavailable), refundable: true},
+		{err: ErrInsufficientGas, refundable: true},
+		{err: circuitbreaker.ErrCircuitOpen, refundable: true},
+		{err: errors.New("clearing failed: packet commitment not found"), refundable: false},
 	}
-	
-	// Mock tracker for all operations
-	tracker := service.tracker.(*MockOperationTracker)
-	tracker.On("StartOperation", mock.Anything).Return()
-	tracker.On("CompleteOperation", mock.Anything).Return()
-	
-	// Execute concurrently
-	results := make(chan *ExecutionResult, numOps)
-	errors := make(chan error, numOps)
-	
-	for _, op := range operations {
-		go func(operation *ClearingOperation) {
-			result, err := service.ExecutePacketClearing(ctx, operation)
-			if err != nil {
-				errors <- err
-			} else {
-				results <- result
-			}
-		}(op)
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			reason := service.determineRefundReason(tt.err)
+			assert.Equal(t, tt.refundable, reason != "", reason)
+		})
+	}
+}
+
+// Test that a failure without a refund reason leaves the operation alone
+func TestHandleClearingFailureNotRefundable(t *testing.T) {
+	service := setupTestExecutionService(t, &fakeHermes{})
+	require.NoError(t, service.db.Create(&ClearingOperation{ID: "op-1", Status: "processing"}).Error)
+
+	service.handleClearingFailure(context.Background(), "op-1", errors.New("packet commitment not found"))
+
+	var operation ClearingOperation
+	require.NoError(t, service.db.First(&operation, "id = ?", "op-1").Error)
+	assert.Empty(t, operation.RefundStatus)
+}
+
+// Test operation status updates
+func TestCompleteOperation(t *testing.T) {
+	service := setupTestExecutionService(t, &fakeHermes{})
+	require.NoError(t, service.db.Create(&ClearingOperation{ID: "op-1", Status: "queued"}).Error)
+
+	require.NoError(t, service.updateOperationStatus("op-1", "processing", ""))
+	var operation ClearingOperation
+	require.NoError(t, service.db.First(&operation, "id = ?", "op-1").Error)
+	assert.Equal(t, "processing", operation.Status)
+
+	require.NoError(t, service.completeOperation("op-1", &ClearingResult{Success: true, TxHashes: []string{"tx1", "tx2"}}))
+	require.NoError(t, service.db.First(&operation, "id = ?", "op-1").Error)
+	assert.Equal(t, "completed", operation.Status)
+	assert.Equal(t, "tx1", operation.ClearingTxHash)
+	assert.Equal(t, []string{"tx1", "tx2"}, operation.ExecutionTxHashes)
+	assert.NotNil(t, operation.CompletedAt)
+
+	// An operation cleared without a transaction still completes
+	require.NoError(t, service.db.Create(&ClearingOperation{ID: "op-2", Status: "processing"}).Error)
+	require.NoError(t, service.completeOperation("op-2", &ClearingResult{Success: true}))
+	var empty ClearingOperation
+	require.NoError(t, service.db.First(&empty, "id = ?", "op-2").Error)
+	assert.Equal(t, "completed", empty.Status)
+	assert.Empty(t, empty.ClearingTxHash)
+}
+
+// Test concurrent operation tracking
+func TestOperationTracker(t *testing.T) {
+	tracker := &simpleOperationTracker{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			tracker.Add(&ActiveOperation{ID: id, Type: "clearing"})
+		}(fmt.Sprintf("op-%d", i))
 	}
-	
-	// Collect results
-	successCount := 0
-	for i := 0; i < numOps; i++ {
-		select {
-		case err := <-errors:
-			t.Fatalf("Unexpected error: %v", err)
-		case result := <-results:
-			assert.Equal(t, 1, result.PacketsCleared)
-			successCount++
-		case <-time.After(5 * time.Second):
-			t.Fatal("Timeout waiting for results")
-		}
+	wg.Wait()
+	assert.Len(t, tracker.operations, 10)
+
+	for i := 0; i < 10; i++ {
+		tracker.Remove(fmt.Sprintf("op-%d", i))
 	}
-	
-	assert.Equal(t, numOps, successCount)
+	assert.Empty(t, tracker.operations)
 }
 
-// Test graceful shutdown
+// Test shutting down waits for the clearings in flight
 func TestGracefulShutdown(t *testing.T) {
-	service, mockRelayer := setupTestExecutionService(t)
-	ctx := context.Background()
-	
-	// Create a long-running operation
-	op := &ClearingOperation{
-		ID:    "test-op-shutdown",
-		Token: "test-token",
-		Targets: map[string]interface{}{
-			"packets": []map[string]interface{}{
-				{
-					"chain":    "osmosis-1",
-					"channel":  "channel-0",
-					"sequence": float64(123),
-				},
-			},
-		},
-	}
-	
-	// Mock slow clearing operation
-	mockRelayer.On("ClearPacket", ctx, "osmosis-1", "channel-0", uint64(123)).
-		Run(func(args mock.Arguments) {
-			time.Sleep(2 * time.Second)
-		}).
-		Return(nil)
-	
-	// Mock tracker
-	tracker := service.tracker.(*MockOperationTracker)
-	tracker.On("StartOperation", mock.Anything).Return()
-	tracker.On("CompleteOperation", mock.Anything).Return()
-	tracker.On("WaitForCompletion", 10*time.Second).Return(true)
-	
-	// Start operation in background
+	service, _, mr := setupTestService(t)
+	tracker := &testTracker{added: make(chan string, 1), release: make(chan struct{})}
+	execution := NewExecutionServiceV2(service.db, service.redisClient, &fakeHermes{}, service.refundService, tracker, zap.NewNop())
+	execution.pollTimeout = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan struct{})
 	go func() {
-		_, _ = service.ExecutePacketClearing(ctx, op)
+		execution.processQueue(ctx)
+		close(stopped)
 	}()
-	
-	// Give it time to start
-	time.Sleep(100 * time.Millisecond)
-	
-	// Initiate shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	
-	err := service.Shutdown(shutdownCtx)
-	
-	assert.NoError(t, err)
-	tracker.AssertCalled(t, "WaitForCompletion", 10*time.Second)
-}
 
-// Test retry configuration
-func TestRetryConfiguration(t *testing.T) {
-	retrier := NewRetrier(3, 100*time.Millisecond, 2.0)
-	
-	attempts := 0
-	start := time.Now()
-	
-	err := retrier.Do(func() error {
-		attempts++
-		if attempts < 3 {
-			return errors.New("temporary error")
-		}
-		return nil
-	})
-	
-	duration := time.Since(start)
-	
-	assert.NoError(t, err)
-	assert.Equal(t, 3, attempts)
-	// Should take at least 100ms + 200ms = 300ms (with backoff)
-	assert.Greater(t, duration, 250*time.Millisecond)
-}
\ No newline at end of file
+	_, err := mr.RPush("clearing:execution:queue", "token-1")
+	require.NoError(t, err)
+	select {
+	case token := <-tracker.added:
+		assert.Equal(t, "token-1", token)
+	case <-time.After(5 * time.Second):
+		t.Fatal("clearing didn't start")
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+		t.Fatal("stopped with a clearing in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(tracker.release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("didn't stop once the clearing finished")
+	}
+}
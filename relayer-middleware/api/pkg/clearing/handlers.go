@@ -13,9 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
+	"github.com/relayooor/chains/config"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
-	"relayooor/api/internal/config"
+	"relayooor/api/pkg/apikeys"
+	"relayooor/api/pkg/middleware"
 	"relayooor/api/pkg/types"
 )
 
@@ -24,6 +26,8 @@ type HandlersV2 struct {
 	service    *ServiceV2
 	logger     *zap.Logger
 	wsManager  *WebSocketManager
+	apiKeys    *apikeys.Service
+	limiter    *middleware.RateLimiter
 }
 
 // NewHandlersV2 creates new clearing handlers with improved error handling
@@ -615,6 +619,8 @@ func mapErrorToResponse(err error) (int, string) {
 		return http.StatusBadRequest, "INVALID_TOKEN"
 	case errors.Is(err, ErrDuplicatePayment):
 		return http.StatusConflict, "DUPLICATE_PAYMENT"
+	case errors.Is(err, ErrPacketNotClearable):
+		return http.StatusUnprocessableEntity, "PACKET_NOT_CLEARABLE"
 	default:
 		return http.StatusInternalServerError, "INTERNAL_ERROR"
 	}
@@ -628,6 +634,8 @@ func getUserFriendlyMessage(err error) string {
 		return "The provided token is invalid."
 	case errors.Is(err, ErrDuplicatePayment):
 		return "This payment has already been processed."
+	case errors.Is(err, ErrPacketNotClearable):
+		return "Clearing can't relay one of the packets. Check the packet diagnosis for why."
 	default:
 		return "An unexpected error occurred. Please try again."
 	}
@@ -668,13 +676,13 @@ func parseChainRPCs() map[string]string {
 	registry := config.DefaultChainRegistry()
 	
 	// Use centralized registry with environment override support
-	for chainID, chain := range registry.Chains {
+	for chainID, chain := range registry.AllChains() {
 		// Check for environment override first
 		envKey := fmt.Sprintf("RPC_%s", strings.ToUpper(strings.ReplaceAll(chainID, "-", "_")))
 		if envRPC := os.Getenv(envKey); envRPC != "" {
 			rpcs[chainID] = envRPC
-		} else if chain.RPCEndpoint != "" {
-			rpcs[chainID] = chain.RPCEndpoint
+		} else if rpc := chain.Endpoints().RPC; rpc != "" {
+			rpcs[chainID] = rpc
 		}
 	}
 	
@@ -692,19 +700,26 @@ func (h *HandlersV2) RegisterRoutes(router *gin.RouterGroup) {
 	// Public endpoints
 	public := router.Group("/")
 	{
-		public.POST("/clearing/request-token", h.RequestToken)
-		public.POST("/clearing/verify-payment", h.VerifyPayment)
+		public.POST("/clearing/request-token",
+			middleware.APIKeyAuth(h.apiKeys, nil),
+			middleware.RequireScope(apikeys.ScopeClearingRequest),
+			h.rateLimit("request-token"),
+			h.rateLimit("request-token-wallet"),
+			h.RequestToken,
+		)
+		public.POST("/clearing/verify-payment", h.rateLimit("verify-payment"), h.VerifyPayment)
 		public.GET("/clearing/status/:token", h.GetStatus)
-		public.POST("/auth/wallet-sign", h.WalletSignIn)
+		public.POST("/auth/wallet-sign", h.rateLimit("wallet-sign"), h.WalletSignIn)
 		public.GET("/statistics/platform", h.GetPlatformStatistics)
 		
 		// WebSocket endpoint
 		public.GET("/ws", h.wsManager.HandleWebSocket)
 	}
 	
-	// Protected endpoints (require session)
+	// Protected endpoints (require session or API key)
 	protected := router.Group("/")
-	protected.Use(h.authMiddleware())
+	protected.Use(middleware.APIKeyAuth(h.apiKeys, h.authMiddleware()))
+	protected.Use(middleware.RequireScope(apikeys.ScopeClearingRequest))
 	{
 		protected.GET("/users/statistics", h.GetUserStatistics)
 		protected.GET("/clearing/operations", h.GetOperations)
@@ -729,41 +744,18 @@ func (h *HandlersV2) authMiddleware() gin.HandlerFunc {
 		
 		sessionToken := strings.TrimPrefix(authHeader, "Bearer ")
 		sessionKey := fmt.Sprintf("clearing:session:%s", sessionToken)
-		
+
 		// Get session data
-		sessionData, err := h.service.redisClient.Get(c.Request.Context(), sessionKey).Result()
+		session, err := loadSession(c.Request.Context(), h.service.redisClient, sessionToken)
 		if err != nil {
+			code, message := "INVALID_SESSION", "Invalid or expired session"
+			if errors.Is(err, ErrSessionExpired) {
+				code, message = "SESSION_EXPIRED", "Session has expired"
+			}
 			c.JSON(http.StatusUnauthorized, ErrorResponse{
 				Error: ErrorDetail{
-					Code:    "INVALID_SESSION",
-					Message: "Invalid or expired session",
-				},
-			})
-			c.Abort()
-			return
-		}
-		
-		var session SessionData
-		if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
-			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Error: ErrorDetail{
-					Code:    "INVALID_SESSION",
-					Message: "Invalid session data",
-				},
-			})
-			c.Abort()
-			return
-		}
-		
-		// Check expiry
-		if time.Now().Unix() > session.ExpiresAt {
-			// Clean up expired session
-			h.service.redisClient.Del(c.Request.Context(), sessionKey)
-			
-			c.JSON(http.StatusUnauthorized, ErrorResponse{
-				Error: ErrorDetail{
-					Code:    "SESSION_EXPIRED",
-					Message: "Session has expired",
+					Code:    code,
+					Message: message,
 				},
 			})
 			c.Abort()
@@ -772,7 +764,7 @@ func (h *HandlersV2) authMiddleware() gin.HandlerFunc {
 		
 		// Store wallet and session info in context
 		c.Set("wallet", session.Wallet)
-		c.Set("session", session)
+		c.Set("session", *session)
 		c.Set("session_token", sessionToken)
 		
 		// Update session last activity
@@ -785,6 +777,33 @@ func (h *HandlersV2) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// loadSession resolves a wallet session token, removing it once expired
+func loadSession(ctx context.Context, redisClient *redis.Client, sessionToken string) (*SessionData, error) {
+	if sessionToken == "" {
+		return nil, ErrSessionNotFound
+	}
+
+	sessionKey := fmt.Sprintf("clearing:session:%s", sessionToken)
+	sessionData, err := redisClient.Get(ctx, sessionKey).Result()
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	var session SessionData
+	if err := json.Unmarshal([]byte(sessionData), &session); err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	// Check expiry
+	if time.Now().Unix() > session.ExpiresAt {
+		// Clean up expired session
+		redisClient.Del(ctx, sessionKey)
+		return nil, ErrSessionExpired
+	}
+
+	return &session, nil
+}
+
 // RequestIDMiddleware adds a unique request ID to each request
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,96 @@
+package clearing
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// OperationPacket records a packet targeted by a clearing operation, so the
+// operations and refunds touching a packet can be found later. Channel
+// clears don't name packets and have no rows.
+type OperationPacket struct {
+	ID          uint   `gorm:"primaryKey"`
+	OperationID string `gorm:"index"`
+	ChainID     string `gorm:"index:idx_operation_packets_packet"`
+	PortID      string `gorm:"index:idx_operation_packets_packet"`
+	ChannelID   string `gorm:"index:idx_operation_packets_packet"`
+	Sequence    uint64 `gorm:"index:idx_operation_packets_packet"`
+}
+
+// PacketOperation is a clearing operation that targeted a packet, with the
+// refunds made for it
+type PacketOperation struct {
+	Operation ClearingOperation
+	Refunds   []RefundableOperation
+}
+
+// PacketHistory looks up the clearing history of packets
+type PacketHistory struct {
+	db *gorm.DB
+}
+
+// NewPacketHistory creates a packet history reader
+func NewPacketHistory(db *gorm.DB) *PacketHistory {
+	return &PacketHistory{db: db}
+}
+
+// PacketOperations returns the operations that targeted a packet, oldest
+// first
+func (h *PacketHistory) PacketOperations(ctx context.Context, chainID, portID, channelID string, sequence uint64) ([]PacketOperation, error) {
+	var operations []ClearingOperation
+	err := h.db.WithContext(ctx).
+		Where("id IN (?)", h.db.Model(&OperationPacket{}).
+			Select("operation_id").
+			Where("chain_id = ? AND port_id = ? AND channel_id = ? AND sequence = ?", chainID, portID, channelID, sequence)).
+		Order("created_at").
+		Find(&operations).Error
+	if err != nil || len(operations) == 0 {
+		return nil, err
+	}
+
+	ids := make([]string, len(operations))
+	for i, operation := range operations {
+		ids[i] = operation.ID
+	}
+	var refunds []RefundableOperation
+	if err := h.db.WithContext(ctx).Where("operation_id IN ?", ids).Order("created_at").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+
+	byOperation := make(map[string][]RefundableOperation)
+	for _, refund := range refunds {
+		byOperation[refund.OperationID] = append(byOperation[refund.OperationID], refund)
+	}
+	result := make([]PacketOperation, len(operations))
+	for i, operation := range operations {
+		result[i] = PacketOperation{Operation: operation, Refunds: byOperation[operation.ID]}
+	}
+	return result, nil
+}
+
+// operationPackets builds the rows recording the packets of an operation.
+// Identifiers may use either the clearing or the execution field names.
+func operationPackets(operationID string, packets []PacketIdentifier) []OperationPacket {
+	rows := make([]OperationPacket, 0, len(packets))
+	for _, packet := range packets {
+		row := OperationPacket{
+			OperationID: operationID,
+			ChainID:     packet.ChainID,
+			PortID:      packet.PortID,
+			ChannelID:   packet.ChannelID,
+			Sequence:    packet.Sequence,
+		}
+		if row.ChainID == "" {
+			row.ChainID = packet.Chain
+		}
+		if row.ChannelID == "" {
+			row.ChannelID = packet.Channel
+		}
+		if row.PortID == "" {
+			row.PortID = "transfer"
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
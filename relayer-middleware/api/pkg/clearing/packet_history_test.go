@@ -0,0 +1,49 @@
+package clearing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestPacketHistory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&ClearingOperation{}, &RefundableOperation{}, &OperationPacket{}))
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"op-2", "op-1", "op-other"} {
+		require.NoError(t, db.Create(&ClearingOperation{ID: id, Status: "completed", CreatedAt: start.Add(-time.Duration(i) * time.Hour)}).Error)
+	}
+
+	// Identifiers use either field naming
+	packets := operationPackets("op-1", []PacketIdentifier{{Chain: "osmosis-1", Channel: "channel-0", Sequence: 42}})
+	packets = append(packets, operationPackets("op-2", []PacketIdentifier{
+		{ChainID: "osmosis-1", ChannelID: "channel-0", PortID: "transfer", Sequence: 42},
+		{ChainID: "osmosis-1", ChannelID: "channel-0", PortID: "transfer", Sequence: 43},
+	})...)
+	packets = append(packets, operationPackets("op-other", []PacketIdentifier{{Chain: "osmosis-1", Channel: "channel-1", Sequence: 42}})...)
+	require.NoError(t, db.Create(&packets).Error)
+
+	require.NoError(t, db.Create(&RefundableOperation{ID: "refund-1", OperationID: "op-2", RefundStatus: "completed", CreatedAt: start}).Error)
+	require.NoError(t, db.Create(&RefundableOperation{ID: "refund-2", OperationID: "op-other", RefundStatus: "completed", CreatedAt: start}).Error)
+
+	history := NewPacketHistory(db)
+	operations, err := history.PacketOperations(context.Background(), "osmosis-1", "transfer", "channel-0", 42)
+	require.NoError(t, err)
+	require.Len(t, operations, 2)
+	assert.Equal(t, "op-1", operations[0].Operation.ID)
+	assert.Empty(t, operations[0].Refunds)
+	assert.Equal(t, "op-2", operations[1].Operation.ID)
+	require.Len(t, operations[1].Refunds, 1)
+	assert.Equal(t, "refund-1", operations[1].Refunds[0].ID)
+
+	operations, err = history.PacketOperations(context.Background(), "osmosis-1", "transfer", "channel-0", 44)
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+}
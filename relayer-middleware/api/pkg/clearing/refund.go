@@ -9,12 +9,14 @@ import (
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"relayooor/api/pkg/audit"
 )
 
 type RefundService struct {
 	db            *gorm.DB
 	serviceWallet ServiceWallet
 	logger        *zap.Logger
+	auditLog      *audit.Service
 }
 
 type ServiceWallet struct {
@@ -50,7 +52,34 @@ func NewRefundService(db *gorm.DB, wallet ServiceWallet, logger *zap.Logger) *Re
 	}
 }
 
+// SetAuditLog enables audit logging of refund processing
+func (s *RefundService) SetAuditLog(auditLog *audit.Service) {
+	s.auditLog = auditLog
+}
+
+// ProcessRefund refunds an operation and records the outcome in the audit log.
+// The actor is taken from ctx (see audit.WithActor) and defaults to the system.
 func (s *RefundService) ProcessRefund(ctx context.Context, operationID string, reason string) error {
+	err := s.processRefund(ctx, operationID, reason)
+
+	if s.auditLog != nil {
+		_, auditErr := s.auditLog.Record(ctx, audit.Event{
+			Actor:     audit.ActorFromContext(ctx),
+			Action:    audit.ActionRefundProcess,
+			Target:    operationID,
+			After:     map[string]interface{}{"reason": reason},
+			RequestID: audit.RequestIDFromContext(ctx),
+			Err:       err,
+		})
+		if auditErr != nil {
+			s.logger.Error("Failed to write audit entry", zap.String("operation_id", operationID), zap.Error(auditErr))
+		}
+	}
+
+	return err
+}
+
+func (s *RefundService) processRefund(ctx context.Context, operationID string, reason string) error {
 	logger := s.logger.With(
 		zap.String("operation_id", operationID),
 		zap.String("reason", reason),
@@ -220,6 +249,26 @@ func (s *RefundService) alertOperators(subject string, data map[string]interface
 	)
 }
 
+// ListRefunds returns refund records, optionally filtered by status
+func (s *RefundService) ListRefunds(ctx context.Context, status string, offset, limit int) ([]RefundableOperation, int64, error) {
+	query := s.db.WithContext(ctx).Model(&RefundableOperation{})
+	if status != "" {
+		query = query.Where("refund_status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var refunds []RefundableOperation
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&refunds).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return refunds, total, nil
+}
+
 // Background worker to process pending refunds
 func (s *RefundService) ProcessPendingRefunds(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -18,10 +18,27 @@ import (
 )
 
 var (
-	ErrTokenExpired     = errors.New("token expired")
-	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrPacketNotClearable = errors.New("packet can't be cleared")
 )
 
+// PacketChecker vets packets before a clearing token is issued for them
+type PacketChecker interface {
+	// CheckClearable returns an error wrapping ErrPacketNotClearable when
+	// clearing wouldn't get one of the packets relayed
+	CheckClearable(ctx context.Context, packets []PacketIdentifier) error
+}
+
+// TimeoutPredictor tells which packets are about to time out, so their
+// clearing is queued ahead of others
+type TimeoutPredictor interface {
+	// Urgent reports whether a packet is predicted to time out soon
+	Urgent(chainID, channelID string, sequence uint64) bool
+}
+
 // ServiceV2 is the improved clearing service with error handling
 type ServiceV2 struct {
 	db                *gorm.DB
@@ -38,6 +55,8 @@ type ServiceV2 struct {
 	cache             *PacketCache
 	refundService     *RefundService
 	executionService  *ExecutionServiceV2
+	packetChecker     PacketChecker
+	timeouts          TimeoutPredictor
 }
 
 // Config holds service configuration
@@ -127,6 +146,14 @@ func (s *ServiceV2) GenerateToken(ctx context.Context, request ClearingRequest)
 		logger.Error("Request validation failed", zap.Error(err))
 		return nil, err
 	}
+
+	// Don't take payment for packets a clear can't help
+	if s.packetChecker != nil {
+		if err := s.packetChecker.CheckClearable(ctx, request.Targets.Packets); err != nil {
+			logger.Warn("Packets not clearable", zap.Error(err))
+			return nil, err
+		}
+	}
 	
 	// Calculate fees
 	totalPackets := len(request.Targets.Packets)
@@ -299,7 +326,16 @@ func (s *ServiceV2) VerifyPayment(ctx context.Context, tokenID string, txHash st
 		CreatedAt:        time.Now(),
 	}
 	
-	if err := s.db.Create(operation).Error; err != nil {
+	err = s.db.Transaction(func(dbTx *gorm.DB) error {
+		if err := dbTx.Create(operation).Error; err != nil {
+			return err
+		}
+		if packets := operationPackets(operationID, token.TargetIdentifiers.Packets); len(packets) > 0 {
+			return dbTx.Create(&packets).Error
+		}
+		return nil
+	})
+	if err != nil {
 		logger.Error("Failed to create operation", zap.Error(err))
 		return nil, err
 	}
@@ -320,7 +356,7 @@ func (s *ServiceV2) VerifyPayment(ctx context.Context, tokenID string, txHash st
 	}
 	
 	// Queue for execution
-	if err := s.redisClient.RPush(ctx, "clearing:execution:queue", tokenID).Err(); err != nil {
+	if err := s.queueExecution(ctx, tokenID, token.TargetIdentifiers.Packets, logger); err != nil {
 		logger.Error("Failed to queue for execution", zap.Error(err))
 		return nil, err
 	}
@@ -342,6 +378,37 @@ func (s *ServiceV2) VerifyPayment(ctx context.Context, tokenID string, txHash st
 
 // Helper methods
 
+// queueExecution queues a paid token's clearing, ahead of the queue when a
+// packet is about to time out
+func (s *ServiceV2) queueExecution(ctx context.Context, tokenID string, packets []PacketIdentifier, logger *zap.Logger) error {
+	push := s.redisClient.RPush
+	if s.urgent(packets) {
+		push = s.redisClient.LPush
+		logger.Info("Packets about to time out, queueing clearing first")
+	}
+	return push(ctx, "clearing:execution:queue", tokenID).Err()
+}
+
+// urgent reports whether any of the packets is predicted to time out soon
+func (s *ServiceV2) urgent(packets []PacketIdentifier) bool {
+	if s.timeouts == nil {
+		return false
+	}
+	for _, packet := range packets {
+		chainID, channelID := packet.Chain, packet.Channel
+		if chainID == "" {
+			chainID = packet.ChainID
+		}
+		if channelID == "" {
+			channelID = packet.ChannelID
+		}
+		if s.timeouts.Urgent(chainID, channelID, packet.Sequence) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *ServiceV2) validateRequest(request ClearingRequest) error {
 	if request.WalletAddress == "" {
 		return errors.New("wallet address required")
@@ -2,349 +2,327 @@ package clearing
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-// Mock dependencies
-type MockPaymentValidator struct {
-	mock.Mock
-}
+// Test setup
+func setupTestService(t *testing.T) (*ServiceV2, *gorm.DB, *miniredis.Miniredis) {
+	t.Helper()
 
-func (m *MockPaymentValidator) ValidatePayment(ctx context.Context, payment *PaymentRecord) error {
-	args := m.Called(ctx, payment)
-	return args.Error(0)
-}
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// Every connection to :memory: opens its own database
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&ClearingOperation{}, &OperationPacket{}, &RefundableOperation{}, &PaymentRecord{}))
 
-type MockDuplicateDetector struct {
-	mock.Mock
-}
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
 
-func (m *MockDuplicateDetector) IsDuplicate(ctx context.Context, txHash string) (bool, error) {
-	args := m.Called(ctx, txHash)
-	return args.Bool(0), args.Error(1)
-}
+	service := NewServiceV2(db, redisClient, Config{
+		SecretKey:      "test-secret",
+		ServiceAddress: "osmo1service",
+		HermesURL:      "http://localhost:5185",
+	}, zap.NewNop())
 
-func (m *MockDuplicateDetector) Add(ctx context.Context, txHash string) error {
-	args := m.Called(ctx, txHash)
-	return args.Error(0)
+	return service, db, mr
 }
 
-type MockRefundService struct {
-	mock.Mock
+func testRequest(sequences ...uint64) ClearingRequest {
+	packets := make([]PacketIdentifier, 0, len(sequences))
+	for _, sequence := range sequences {
+		packets = append(packets, PacketIdentifier{Chain: "osmosis-1", Channel: "channel-0", Sequence: sequence})
+	}
+	return ClearingRequest{
+		WalletAddress: "osmo1test123",
+		ChainID:       "osmosis-1",
+		Type:          "packet",
+		Targets:       ClearingTargets{Packets: packets},
+	}
 }
 
-func (m *MockRefundService) ProcessRefund(ctx context.Context, payment *PaymentRecord, reason string) error {
-	args := m.Called(ctx, payment, reason)
-	return args.Error(0)
-}
+// Test GenerateToken
+func TestGenerateToken(t *testing.T) {
+	service, _, mr := setupTestService(t)
+	ctx := context.Background()
 
-// Test setup
-func setupTestService(t *testing.T) (*ServiceV2, *gorm.DB, *redis.Client) {
-	// Setup in-memory database
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	resp, err := service.GenerateToken(ctx, testRequest(123))
 	require.NoError(t, err)
-	
-	// Run migrations
-	err = db.AutoMigrate(&ClearingOperation{}, &PaymentRecord{})
+	require.NotNil(t, resp.Token)
+
+	token := resp.Token
+	assert.NotEmpty(t, token.Token)
+	assert.Equal(t, "osmo1test123", token.WalletAddress)
+	assert.Equal(t, "uosmo", token.AcceptedDenom)
+	assert.Equal(t, service.signToken(token), token.Signature)
+	assert.Equal(t, "CLR-"+token.Token, resp.PaymentMemo)
+	assert.Equal(t, token.TotalRequired, resp.PaymentAmount)
+	assert.Equal(t, int(TokenTTL.Seconds()), resp.ExpiresIn)
+
+	// The token and its packets are kept until payment
+	assert.True(t, mr.Exists("token:"+token.Token))
+	stored, err := mr.Get("packets:" + token.Token)
 	require.NoError(t, err)
-	
-	// Setup Redis (using miniredis for testing)
-	redisClient := redis.NewClient(&redis.Options{
-		Addr: "localhost:6379",
-	})
-	
-	// Create logger
-	logger, _ := zap.NewDevelopment()
-	
-	// Create service with mocks
-	service := &ServiceV2{
-		db:                db,
-		redisClient:       redisClient,
-		duplicateDetector: &MockDuplicateDetector{},
-		paymentValidator:  &MockPaymentValidator{},
-		cache:             NewPacketCache(redisClient, logger),
-		refundService:     &MockRefundService{},
-		executionService:  nil, // Will be mocked separately
-	}
-	
-	return service, db, redisClient
+	var packets []PacketIdentifier
+	require.NoError(t, json.Unmarshal([]byte(stored), &packets))
+	assert.Equal(t, testRequest(123).Targets.Packets, packets)
+	assert.Equal(t, TokenTTL, mr.TTL("token:"+token.Token))
 }
 
-// Test RequestToken
-func TestRequestToken(t *testing.T) {
+// Test token fees
+func TestGenerateTokenFees(t *testing.T) {
 	service, _, _ := setupTestService(t)
 	ctx := context.Background()
-	
-	req := &ClearingRequest{
-		WalletAddress: "osmo1test123",
-		ChainID:       "osmosis-1",
-		Type:          "packet",
-		Targets: ClearingTargets{
-			Packets: []PacketIdentifier{
-				{Chain: "osmosis-1", Channel: "channel-0", Sequence: 123},
-			},
-		},
+
+	tests := []struct {
+		name      string
+		sequences []uint64
+	}{
+		{name: "single packet", sequences: []uint64{123}},
+		{name: "multiple packets", sequences: []uint64{123, 124, 125}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.GenerateToken(ctx, testRequest(tt.sequences...))
+			require.NoError(t, err)
+
+			packets := int64(len(tt.sequences))
+			serviceFee := int64(DefaultServiceFee) + DefaultPerPacketFee*packets
+			gasFee := (BaseGasAmount + PerPacketGas*packets) * service.getGasPrice("osmosis-1")
+			assert.Equal(t, fmt.Sprint(serviceFee), resp.Token.ServiceFee)
+			assert.Equal(t, fmt.Sprint(gasFee), resp.Token.EstimatedGasFee)
+			assert.Equal(t, fmt.Sprint(serviceFee+gasFee), resp.Token.TotalRequired)
+		})
 	}
-	
-	resp, err := service.RequestToken(ctx, req)
-	
-	assert.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.NotEmpty(t, resp.Token)
-	assert.NotEmpty(t, resp.PaymentAddress)
-	assert.NotEmpty(t, resp.PaymentMemo)
-	assert.Greater(t, resp.PaymentAmount, "0")
-	assert.Greater(t, resp.ExpiresIn, int64(0))
 }
 
-// Test RequestToken with invalid request
-func TestRequestTokenInvalidRequest(t *testing.T) {
-	service, _, _ := setupTestService(t)
+// Test GenerateToken with invalid requests
+func TestGenerateTokenInvalidRequest(t *testing.T) {
+	service, _, mr := setupTestService(t)
 	ctx := context.Background()
-	
-	// Missing wallet address
-	req := &ClearingRequest{
-		ChainID: "osmosis-1",
-		Type:    "packet",
+
+	tooMany := make([]uint64, 101)
+	for i := range tooMany {
+		tooMany[i] = uint64(i + 1)
 	}
-	
-	resp, err := service.RequestToken(ctx, req)
-	
-	assert.Error(t, err)
-	assert.Nil(t, resp)
+
+	noWallet := testRequest(123)
+	noWallet.WalletAddress = ""
+
+	tests := []struct {
+		name          string
+		req           ClearingRequest
+		expectedError string
+	}{
+		{name: "missing wallet", req: noWallet, expectedError: "wallet address required"},
+		{name: "no packets", req: testRequest(), expectedError: "no packets to clear"},
+		{name: "too many packets", req: testRequest(tooMany...), expectedError: "too many packets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := service.GenerateToken(ctx, tt.req)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+			assert.Nil(t, resp)
+		})
+	}
+	assert.Empty(t, mr.Keys())
+}
+
+// Test GenerateToken with packets a clear can't help
+func TestGenerateTokenNotClearable(t *testing.T) {
+	service, _, mr := setupTestService(t)
+	service.packetChecker = packetCheckerFunc(func(ctx context.Context, packets []PacketIdentifier) error {
+		return fmt.Errorf("packet %d already received: %w", packets[0].Sequence, ErrPacketNotClearable)
+	})
+
+	_, err := service.GenerateToken(context.Background(), testRequest(123))
+	assert.ErrorIs(t, err, ErrPacketNotClearable)
+	assert.Empty(t, mr.Keys())
+}
+
+type packetCheckerFunc func(ctx context.Context, packets []PacketIdentifier) error
+
+func (f packetCheckerFunc) CheckClearable(ctx context.Context, packets []PacketIdentifier) error {
+	return f(ctx, packets)
 }
 
 // Test VerifyPayment
 func TestVerifyPayment(t *testing.T) {
-	service, db, _ := setupTestService(t)
+	service, db, mr := setupTestService(t)
 	ctx := context.Background()
-	
-	// Create a clearing operation
-	op := &ClearingOperation{
-		Token:          "test-token",
-		WalletAddress:  "osmo1test123",
-		ChainID:        "osmosis-1",
-		Type:           "packet",
-		Status:         "pending_payment",
-		PaymentAmount:  "1000000",
-		PaymentAddress: "osmo1payment",
-		PaymentMemo:    "test-memo",
-		ExpiresAt:      time.Now().Add(5 * time.Minute),
-	}
-	err := db.Create(op).Error
+
+	resp, err := service.GenerateToken(ctx, testRequest(123))
 	require.NoError(t, err)
-	
-	// Mock duplicate detector
-	mockDetector := service.duplicateDetector.(*MockDuplicateDetector)
-	mockDetector.On("IsDuplicate", ctx, "tx123").Return(false, nil)
-	mockDetector.On("Add", ctx, "tx123").Return(nil)
-	
-	// Mock payment validator
-	mockValidator := service.paymentValidator.(*MockPaymentValidator)
-	mockValidator.On("ValidatePayment", ctx, mock.Anything).Return(nil)
-	
-	// Verify payment
-	err = service.VerifyPayment(ctx, "test-token", "tx123")
-	
-	assert.NoError(t, err)
-	
-	// Check operation status updated
-	var updatedOp ClearingOperation
-	err = db.First(&updatedOp, "token = ?", "test-token").Error
+
+	// The transaction holds no payment to the service, so nothing is queued
+	verification, err := service.VerifyPayment(ctx, resp.Token.Token, "tx123")
+	assert.ErrorIs(t, err, ErrNoPaymentFound)
+	assert.Nil(t, verification)
+
+	var count int64
+	require.NoError(t, db.Model(&ClearingOperation{}).Count(&count).Error)
+	assert.Zero(t, count)
+	assert.False(t, mr.Exists("clearing:execution:queue"))
+	assert.True(t, mr.Exists("token:"+resp.Token.Token))
+}
+
+// urgentPackets predicts the listed sequences are about to time out
+type urgentPackets map[uint64]bool
+
+func (u urgentPackets) Urgent(chainID, channelID string, sequence uint64) bool {
+	return u[sequence]
+}
+
+// Test paid clearings about to time out are queued first
+func TestQueueExecutionUrgent(t *testing.T) {
+	service, _, mr := setupTestService(t)
+	ctx := context.Background()
+	_, err := mr.RPush("clearing:execution:queue", "queued-token")
+	require.NoError(t, err)
+
+	service.timeouts = urgentPackets{7: true}
+	require.NoError(t, service.queueExecution(ctx, "normal-token", testRequest(1, 2).Targets.Packets, zap.NewNop()))
+	require.NoError(t, service.queueExecution(ctx, "urgent-token", testRequest(1, 7).Targets.Packets, zap.NewNop()))
+
+	queue, err := mr.List("clearing:execution:queue")
 	require.NoError(t, err)
-	assert.Equal(t, "payment_verified", updatedOp.Status)
-	
-	mockDetector.AssertExpectations(t)
-	mockValidator.AssertExpectations(t)
+	assert.Equal(t, []string{"urgent-token", "queued-token", "normal-token"}, queue)
 }
 
-// Test VerifyPayment with duplicate
+// Test VerifyPayment with a transaction hash seen before
 func TestVerifyPaymentDuplicate(t *testing.T) {
-	service, db, _ := setupTestService(t)
+	service, _, _ := setupTestService(t)
 	ctx := context.Background()
-	
-	// Create operation
-	op := &ClearingOperation{
-		Token:         "test-token",
-		Status:        "pending_payment",
-		PaymentAmount: "1000000",
-		ExpiresAt:     time.Now().Add(5 * time.Minute),
-	}
-	err := db.Create(op).Error
+
+	duplicate, err := service.duplicateDetector.CheckDuplicate(ctx, "tx123")
 	require.NoError(t, err)
-	
-	// Mock duplicate detector to return true
-	mockDetector := service.duplicateDetector.(*MockDuplicateDetector)
-	mockDetector.On("IsDuplicate", ctx, "tx123").Return(true, nil)
-	
-	// Verify payment
-	err = service.VerifyPayment(ctx, "test-token", "tx123")
-	
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "duplicate payment")
-	
-	mockDetector.AssertExpectations(t)
+	require.False(t, duplicate)
+	require.NoError(t, service.duplicateDetector.StorePaymentInfo(ctx, &PaymentInfo{
+		TxHash:      "tx123",
+		TokenID:     "test-token",
+		OperationID: "op-1",
+	}))
+
+	// The same token paying again is answered with its operation
+	resp, err := service.VerifyPayment(ctx, "test-token", "tx123")
+	require.NoError(t, err)
+	assert.True(t, resp.Success)
+	assert.Equal(t, "op-1", resp.OperationID)
+
+	// Another token can't reuse the transaction
+	resp, err = service.VerifyPayment(ctx, "other-token", "tx123")
+	assert.ErrorIs(t, err, ErrDuplicatePayment)
+	assert.Nil(t, resp)
+}
+
+// Test token expiration
+func TestTokenExpiration(t *testing.T) {
+	service, _, mr := setupTestService(t)
+	ctx := context.Background()
+
+	expired, err := json.Marshal(&ClearingToken{
+		Token:     "expired-token",
+		ChainID:   "osmosis-1",
+		ExpiresAt: time.Now().Add(-time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, mr.Set("token:expired-token", string(expired)))
+
+	_, err = service.VerifyPayment(ctx, "expired-token", "tx123")
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	// Tokens are dropped from Redis once their TTL passes
+	resp, err := service.GenerateToken(ctx, testRequest(123))
+	require.NoError(t, err)
+	mr.FastForward(TokenTTL + time.Second)
+	_, err = service.VerifyPayment(ctx, resp.Token.Token, "tx456")
+	assert.ErrorIs(t, err, ErrTokenExpired)
 }
 
 // Test GetStatus
 func TestGetStatus(t *testing.T) {
 	service, db, _ := setupTestService(t)
 	ctx := context.Background()
-	
-	// Create operation with execution details
-	op := &ClearingOperation{
-		Token:         "test-token",
-		WalletAddress: "osmo1test123",
-		Status:        "completed",
-		ExecutionDetails: map[string]interface{}{
-			"packetsCleared": 2,
-			"txHashes":       []string{"tx1", "tx2"},
-		},
-	}
-	err := db.Create(op).Error
+
+	resp, err := service.GenerateToken(ctx, testRequest(123))
+	require.NoError(t, err)
+
+	status, err := service.GetStatus(ctx, resp.Token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "pending_payment", status.Status)
+	assert.Equal(t, 0, status.Progress)
+
+	require.NoError(t, db.Create(&ClearingOperation{
+		ID:             "op-1",
+		TokenID:        "paid-token",
+		WalletAddress:  "osmo1test123",
+		Status:         "completed",
+		ClearingTxHash: "tx1",
+	}).Error)
+
+	status, err = service.GetStatus(ctx, "paid-token")
 	require.NoError(t, err)
-	
-	// Get status
-	status, err := service.GetStatus(ctx, "test-token")
-	
-	assert.NoError(t, err)
-	assert.NotNil(t, status)
-	assert.Equal(t, "test-token", status.Token)
 	assert.Equal(t, "completed", status.Status)
-	assert.NotNil(t, status.Execution)
+	assert.Equal(t, 100, status.Progress)
+	assert.Equal(t, []string{"tx1"}, status.TxHashes)
 }
 
 // Test GetStatus with non-existent token
 func TestGetStatusNotFound(t *testing.T) {
 	service, _, _ := setupTestService(t)
-	ctx := context.Background()
-	
-	status, err := service.GetStatus(ctx, "non-existent-token")
-	
+
+	status, err := service.GetStatus(context.Background(), "non-existent-token")
 	assert.Error(t, err)
 	assert.Nil(t, status)
 }
 
-// Test calculateServiceFee
-func TestCalculateServiceFee(t *testing.T) {
-	service, _, _ := setupTestService(t)
-	
-	tests := []struct {
-		name     string
-		req      *ClearingRequest
-		expected string
-	}{
-		{
-			name: "single packet",
-			req: &ClearingRequest{
-				Type: "packet",
-				Targets: ClearingTargets{
-					Packets: []PacketIdentifier{
-						{Chain: "osmosis-1", Channel: "channel-0", Sequence: 123},
-					},
-				},
-			},
-			expected: "110000", // BaseGasAmount + PerPacketGas
-		},
-		{
-			name: "multiple packets",
-			req: &ClearingRequest{
-				Type: "packet",
-				Targets: ClearingTargets{
-					Packets: []PacketIdentifier{
-						{Chain: "osmosis-1", Channel: "channel-0", Sequence: 123},
-						{Chain: "osmosis-1", Channel: "channel-0", Sequence: 124},
-						{Chain: "osmosis-1", Channel: "channel-0", Sequence: 125},
-					},
-				},
-			},
-			expected: "130000", // BaseGasAmount + (3 * PerPacketGas)
-		},
-	}
-	
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			fee := service.calculateServiceFee(tt.req)
-			assert.Equal(t, tt.expected, fee)
-		})
-	}
-}
-
 // Test concurrent token requests
 func TestConcurrentTokenRequests(t *testing.T) {
 	service, _, _ := setupTestService(t)
 	ctx := context.Background()
-	
-	// Run multiple concurrent requests
+
 	const numRequests = 10
-	errors := make(chan error, numRequests)
+	errs := make(chan error, numRequests)
 	tokens := make(chan string, numRequests)
-	
+
 	for i := 0; i < numRequests; i++ {
 		go func(idx int) {
-			req := &ClearingRequest{
-				WalletAddress: "osmo1test123",
-				ChainID:       "osmosis-1",
-				Type:          "packet",
-				Targets: ClearingTargets{
-					Packets: []PacketIdentifier{
-						{Chain: "osmosis-1", Channel: "channel-0", Sequence: uint64(idx)},
-					},
-				},
-			}
-			
-			resp, err := service.RequestToken(ctx, req)
+			resp, err := service.GenerateToken(ctx, testRequest(uint64(idx)))
 			if err != nil {
-				errors <- err
-			} else {
-				tokens <- resp.Token
+				errs <- err
+				return
 			}
+			tokens <- resp.Token.Token
 		}(i)
 	}
-	
-	// Collect results
+
 	uniqueTokens := make(map[string]bool)
 	for i := 0; i < numRequests; i++ {
 		select {
-		case err := <-errors:
+		case err := <-errs:
 			t.Fatalf("Unexpected error: %v", err)
 		case token := <-tokens:
 			assert.NotEmpty(t, token)
-			// Ensure all tokens are unique
 			assert.False(t, uniqueTokens[token], "Duplicate token generated")
 			uniqueTokens[token] = true
 		}
 	}
-	
+
 	assert.Len(t, uniqueTokens, numRequests)
 }
-
-// Test token expiration
-func TestTokenExpiration(t *testing.T) {
-	service, db, _ := setupTestService(t)
-	ctx := context.Background()
-	
-	// Create expired operation
-	op := &ClearingOperation{
-		Token:     "expired-token",
-		Status:    "pending_payment",
-		ExpiresAt: time.Now().Add(-1 * time.Hour), // Expired 1 hour ago
-	}
-	err := db.Create(op).Error
-	require.NoError(t, err)
-	
-	// Try to verify payment on expired token
-	err = service.VerifyPayment(ctx, "expired-token", "tx123")
-	
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "expired")
-}
\ No newline at end of file
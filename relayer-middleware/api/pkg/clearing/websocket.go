@@ -14,17 +14,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"relayooor/api/pkg/middleware"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// In production, implement proper origin checking
-		return true
-	},
-}
-
 // WebSocketManager manages WebSocket connections for real-time updates
 type WebSocketManager struct {
 	clients      map[string]map[*Client]bool
@@ -35,6 +27,7 @@ type WebSocketManager struct {
 	broadcast    chan BroadcastMessage
 	register     chan *Client
 	unregister   chan *Client
+	upgrader     websocket.Upgrader
 }
 
 // Client represents a WebSocket client
@@ -46,6 +39,7 @@ type Client struct {
 	manager      *WebSocketManager
 	id           string
 	walletAddr   string
+	sessionExp   int64
 	pingTicker   *time.Ticker
 	lastActivity time.Time
 }
@@ -64,10 +58,11 @@ type WebSocketMessage struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
-// SubscriptionMessage for topic subscriptions
+// SubscriptionMessage for topic subscriptions and authentication
 type SubscriptionMessage struct {
-	Action string   `json:"action"` // subscribe/unsubscribe
+	Action string   `json:"action"` // auth/subscribe/unsubscribe
 	Topics []string `json:"topics"`
+	Token  string   `json:"token,omitempty"` // session token for auth
 }
 
 const (
@@ -87,6 +82,13 @@ func NewWebSocketManager(redis *redis.Client, logger *zap.Logger) *WebSocketMana
 		broadcast:  make(chan BroadcastMessage, 256),
 		register:   make(chan *Client, 16),
 		unregister: make(chan *Client, 16),
+		// Built here rather than at init so the allowed origins are read
+		// after the environment has been loaded
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     middleware.WebSocketOriginChecker(),
+		},
 	}
 
 	// Start the manager
@@ -146,12 +148,13 @@ func (m *WebSocketManager) registerClient(client *Client) {
 		}
 		m.clients[topic][client] = true
 	}
+	wallet, topics := client.walletAddr, len(client.topics)
 	client.topicsMutex.RUnlock()
 
 	m.logger.Info("Client registered",
 		zap.String("client_id", client.id),
-		zap.String("wallet", maskWallet(client.walletAddr)),
-		zap.Int("topics", len(client.topics)),
+		zap.String("wallet", maskWallet(wallet)),
+		zap.Int("topics", topics),
 	)
 }
 
@@ -170,6 +173,7 @@ func (m *WebSocketManager) unregisterClient(client *Client) {
 			}
 		}
 	}
+	wallet := client.walletAddr
 	client.topicsMutex.RUnlock()
 
 	// Close channels
@@ -178,12 +182,20 @@ func (m *WebSocketManager) unregisterClient(client *Client) {
 
 	m.logger.Info("Client unregistered",
 		zap.String("client_id", client.id),
-		zap.String("wallet", maskWallet(client.walletAddr)),
+		zap.String("wallet", maskWallet(wallet)),
 	)
 }
 
-// broadcastToTopic sends a message to all clients subscribed to a topic
+// broadcastToTopic publishes a message through Redis so every instance,
+// including this one, delivers it. Falls back to local delivery if Redis fails.
 func (m *WebSocketManager) broadcastToTopic(topic string, message WebSocketMessage) {
+	if err := m.publishToRedis(topic, message); err != nil {
+		m.deliverToTopic(topic, message)
+	}
+}
+
+// deliverToTopic sends a message to local clients subscribed to a topic
+func (m *WebSocketManager) deliverToTopic(topic string, message WebSocketMessage) {
 	m.clientsMutex.RLock()
 	clients := m.clients[topic]
 	m.clientsMutex.RUnlock()
@@ -204,8 +216,14 @@ func (m *WebSocketManager) broadcastToTopic(topic string, message WebSocketMessa
 		zap.String("type", message.Type),
 	)
 
+	userTopic := strings.HasPrefix(topic, "user:")
+
 	// Send to all clients subscribed to this topic
 	for client := range clients {
+		// Stop delivering user updates once the wallet session expires
+		if userTopic && client.sessionExpired() {
+			continue
+		}
 		select {
 		case client.send <- data:
 			// Message sent successfully
@@ -217,15 +235,12 @@ func (m *WebSocketManager) broadcastToTopic(topic string, message WebSocketMessa
 			)
 		}
 	}
-
-	// Also publish to Redis for other instances
-	m.publishToRedis(topic, message)
 }
 
 // subscribeToPubSub subscribes to Redis pub/sub for distributed updates
 func (m *WebSocketManager) subscribeToPubSub() {
 	ctx := context.Background()
-	m.pubsub = m.redis.Subscribe(ctx, "clearing:updates:*")
+	m.pubsub = m.redis.PSubscribe(ctx, "clearing:updates:*")
 
 	ch := m.pubsub.Channel()
 	for msg := range ch {
@@ -238,25 +253,27 @@ func (m *WebSocketManager) subscribeToPubSub() {
 			continue
 		}
 
-		// Broadcast to local clients
-		m.broadcastToTopic(topic, wsMsg)
+		// Deliver to local clients
+		m.deliverToTopic(topic, wsMsg)
 	}
 }
 
-// publishToRedis publishes a message to Redis for other instances
-func (m *WebSocketManager) publishToRedis(topic string, message WebSocketMessage) {
+// publishToRedis publishes a message to Redis for all instances
+func (m *WebSocketManager) publishToRedis(topic string, message WebSocketMessage) error {
 	ctx := context.Background()
 	channel := fmt.Sprintf("clearing:updates:%s", topic)
 
 	data, err := json.Marshal(message)
 	if err != nil {
 		m.logger.Error("Failed to marshal for Redis", zap.Error(err))
-		return
+		return err
 	}
 
 	if err := m.redis.Publish(ctx, channel, data).Err(); err != nil {
 		m.logger.Error("Failed to publish to Redis", zap.Error(err))
+		return err
 	}
+	return nil
 }
 
 // cleanupInactiveClients removes clients that haven't sent a pong recently
@@ -287,13 +304,38 @@ func (m *WebSocketManager) cleanupInactiveClients() {
 	}
 }
 
-// HandleWebSocket handles WebSocket connections
+// HandleWebSocket handles WebSocket connections.
+// Clients may authenticate during the upgrade with an Authorization: Bearer
+// session token, or afterwards with an {"action":"auth","token":"..."} frame.
 func (m *WebSocketManager) HandleWebSocket(c *gin.Context) {
 	// Get wallet from session (optional)
 	wallet := c.GetString("wallet")
+	var sessionExp int64
+	if session, ok := c.Get("session"); ok {
+		if data, ok := session.(SessionData); ok {
+			sessionExp = data.ExpiresAt
+		}
+	}
+
+	// Authenticate during the upgrade when a session token is supplied
+	authHeader := c.GetHeader("Authorization")
+	if wallet == "" && strings.HasPrefix(authHeader, "Bearer ") {
+		session, err := loadSession(c.Request.Context(), m.redis, strings.TrimPrefix(authHeader, "Bearer "))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{
+				Error: ErrorDetail{
+					Code:    "INVALID_SESSION",
+					Message: "Invalid or expired session",
+				},
+			})
+			return
+		}
+		wallet = session.Wallet
+		sessionExp = session.ExpiresAt
+	}
 
 	// Upgrade connection
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := m.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		m.logger.Error("Failed to upgrade connection", zap.Error(err))
 		return
@@ -307,6 +349,7 @@ func (m *WebSocketManager) HandleWebSocket(c *gin.Context) {
 		manager:      m,
 		id:           uuid.New().String(),
 		walletAddr:   wallet,
+		sessionExp:   sessionExp,
 		pingTicker:   time.NewTicker(pingPeriod),
 		lastActivity: time.Now(),
 	}
@@ -329,8 +372,9 @@ func (m *WebSocketManager) HandleWebSocket(c *gin.Context) {
 		Type:      "connected",
 		Timestamp: time.Now(),
 		Data: map[string]interface{}{
-			"client_id": client.id,
-			"version":   "1.0",
+			"client_id":     client.id,
+			"version":       "1.0",
+			"authenticated": wallet != "",
 		},
 	}
 	if data, err := json.Marshal(welcome); err == nil {
@@ -367,9 +411,13 @@ func (c *Client) readPump() {
 
 		c.lastActivity = time.Now()
 
-		// Handle subscription messages
+		// Handle auth and subscription messages
 		var subMsg SubscriptionMessage
 		if err := json.Unmarshal(message, &subMsg); err == nil {
+			if subMsg.Action == "auth" {
+				c.handleAuth(subMsg.Token)
+				continue
+			}
 			c.handleSubscription(subMsg)
 		}
 	}
@@ -403,11 +451,62 @@ func (c *Client) writePump() {
 	}
 }
 
+// handleAuth authenticates the connection with a wallet session token and
+// subscribes it to the wallet's user topic, dropping the topic of any wallet
+// it was authenticated as before
+func (c *Client) handleAuth(token string) {
+	reply := WebSocketMessage{
+		Type:      "authenticated",
+		Timestamp: time.Now(),
+		Data:      map[string]interface{}{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := loadSession(ctx, c.manager.redis, token)
+	if err != nil {
+		reply.Type = "auth_error"
+		reply.Data["message"] = "Invalid or expired session"
+	} else {
+		c.topicsMutex.Lock()
+		previous := c.walletAddr
+		c.walletAddr = session.Wallet
+		c.sessionExp = session.ExpiresAt
+		if previous != "" && previous != session.Wallet {
+			delete(c.topics, fmt.Sprintf("user:%s", previous))
+		}
+		c.topics[fmt.Sprintf("user:%s", session.Wallet)] = true
+		c.topicsMutex.Unlock()
+
+		if previous != "" && previous != session.Wallet {
+			c.manager.removeFromTopic(c, fmt.Sprintf("user:%s", previous))
+		}
+		c.manager.register <- c
+		reply.Data["wallet"] = session.Wallet
+	}
+
+	if data, err := json.Marshal(reply); err == nil {
+		select {
+		case c.send <- data:
+		default:
+		}
+	}
+}
+
+// sessionExpired checks if the client's wallet session has lapsed
+func (c *Client) sessionExpired() bool {
+	c.topicsMutex.RLock()
+	defer c.topicsMutex.RUnlock()
+	return c.sessionExp > 0 && time.Now().Unix() > c.sessionExp
+}
+
 // handleSubscription handles topic subscription/unsubscription
 func (c *Client) handleSubscription(msg SubscriptionMessage) {
+	// The manager takes clientsMutex before topicsMutex, so topics are
+	// dropped from it only once topicsMutex is released
+	var removed []string
 	c.topicsMutex.Lock()
-	defer c.topicsMutex.Unlock()
-
 	switch msg.Action {
 	case "subscribe":
 		for _, topic := range msg.Topics {
@@ -424,12 +523,18 @@ func (c *Client) handleSubscription(msg SubscriptionMessage) {
 	case "unsubscribe":
 		for _, topic := range msg.Topics {
 			delete(c.topics, topic)
+			removed = append(removed, topic)
 			c.manager.logger.Debug("Client unsubscribed from topic",
 				zap.String("client_id", c.id),
 				zap.String("topic", topic),
 			)
 		}
 	}
+	c.topicsMutex.Unlock()
+
+	for _, topic := range removed {
+		c.manager.removeFromTopic(c, topic)
+	}
 
 	// Re-register to update topic subscriptions
 	c.manager.register <- c
@@ -437,10 +542,13 @@ func (c *Client) handleSubscription(msg SubscriptionMessage) {
 
 // canAccessTopic checks if client can access a topic
 func (c *Client) canAccessTopic(topic string) bool {
-	// User-specific topics require authentication
+	// User-specific topics require an authenticated, unexpired session
 	if strings.HasPrefix(topic, "user:") {
 		requiredWallet := strings.TrimPrefix(topic, "user:")
-		return c.walletAddr == requiredWallet
+		if c.sessionExp > 0 && time.Now().Unix() > c.sessionExp {
+			return false
+		}
+		return c.walletAddr != "" && c.walletAddr == requiredWallet
 	}
 
 	// Token-specific topics are public
@@ -461,6 +569,19 @@ func (c *Client) canAccessTopic(topic string) bool {
 	return false
 }
 
+// removeFromTopic drops a client from a single topic
+func (m *WebSocketManager) removeFromTopic(client *Client, topic string) {
+	m.clientsMutex.Lock()
+	defer m.clientsMutex.Unlock()
+
+	if clients, ok := m.clients[topic]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(m.clients, topic)
+		}
+	}
+}
+
 // Broadcast sends a message to all clients subscribed to a token
 func (m *WebSocketManager) Broadcast(token string, message WebSocketMessage) {
 	m.broadcast <- BroadcastMessage{
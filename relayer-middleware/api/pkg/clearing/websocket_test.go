@@ -0,0 +1,91 @@
+package clearing
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupWebSocketManager(t *testing.T) (*WebSocketManager, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
+	return NewWebSocketManager(redisClient, zap.NewNop()), mr
+}
+
+func storeSession(t *testing.T, mr *miniredis.Miniredis, token, wallet string) {
+	t.Helper()
+	data, err := json.Marshal(SessionData{Wallet: wallet, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	require.NoError(t, mr.Set("clearing:session:"+token, string(data)))
+}
+
+func subscribers(m *WebSocketManager, topic string) int {
+	m.clientsMutex.RLock()
+	defer m.clientsMutex.RUnlock()
+	return len(m.clients[topic])
+}
+
+func TestWebSocketOriginsReadAtConstruction(t *testing.T) {
+	t.Setenv("WS_ALLOWED_ORIGINS", "https://app.example")
+	manager, _ := setupWebSocketManager(t)
+
+	allowed := httptest.NewRequest("GET", "/ws", nil)
+	allowed.Header.Set("Origin", "https://app.example")
+	assert.True(t, manager.upgrader.CheckOrigin(allowed))
+
+	denied := httptest.NewRequest("GET", "/ws", nil)
+	denied.Header.Set("Origin", "http://localhost:8080")
+	assert.False(t, manager.upgrader.CheckOrigin(denied))
+}
+
+func TestWebSocketReauthDropsPreviousWallet(t *testing.T) {
+	manager, mr := setupWebSocketManager(t)
+	storeSession(t, mr, "session-a", "osmo1alice")
+	storeSession(t, mr, "session-b", "osmo1bob")
+
+	client := &Client{
+		send:       make(chan []byte, 16),
+		topics:     map[string]bool{"global": true},
+		manager:    manager,
+		id:         "client-1",
+		pingTicker: time.NewTicker(pingPeriod),
+	}
+	manager.register <- client
+
+	client.handleAuth("session-a")
+	assert.Eventually(t, func() bool { return subscribers(manager, "user:osmo1alice") == 1 }, time.Second, 10*time.Millisecond)
+
+	// Subscribing concurrently with re-authentication mustn't race or
+	// deadlock with the manager registering the client
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.handleSubscription(SubscriptionMessage{Action: "subscribe", Topics: []string{"channel:channel-0"}})
+		client.handleSubscription(SubscriptionMessage{Action: "unsubscribe", Topics: []string{"global"}})
+	}()
+	client.handleAuth("session-b")
+	<-done
+
+	assert.Eventually(t, func() bool {
+		return subscribers(manager, "user:osmo1bob") == 1 && subscribers(manager, "global") == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, subscribers(manager, "user:osmo1alice"))
+	assert.Equal(t, 1, subscribers(manager, "channel:channel-0"))
+
+	// A failed re-authentication keeps the current wallet
+	client.handleAuth("unknown")
+
+	client.topicsMutex.RLock()
+	defer client.topicsMutex.RUnlock()
+	assert.Equal(t, map[string]bool{"user:osmo1bob": true, "channel:channel-0": true}, client.topics)
+	assert.Equal(t, "osmo1bob", client.walletAddr)
+}
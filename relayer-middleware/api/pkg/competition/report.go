@@ -0,0 +1,275 @@
+package competition
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"relayooor/api/pkg/packethistory"
+)
+
+// ParseWindow parses a report window, a Go duration or a number of days
+// such as "7d"
+func ParseWindow(s string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if window, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid window %q", s)
+		}
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("window %q must be positive", s)
+	}
+	return window, nil
+}
+
+// channel identifies a channel by the chain relays land on
+type channel struct {
+	chainID    string
+	srcChannel string
+	dstChannel string
+}
+
+// relayer identifies a signer, whose address belongs to one chain
+type relayer struct {
+	chainID string
+	signer  string
+}
+
+// Report ranks the relayers on every channel over the window ending now,
+// estimates the gas their redundant relays wasted and lists the channels
+// with stuck packets that too few relayers serve
+func (t *Tracker) Report(ctx context.Context, window time.Duration, label string) (*Report, error) {
+	to := t.now().UTC()
+	from := to.Add(-window)
+
+	var activity []RelayerActivity
+	if err := t.db.WithContext(ctx).Where("bucket >= ?", from.Truncate(bucketSize)).Find(&activity).Error; err != nil {
+		return nil, fmt.Errorf("query relayer activity: %w", err)
+	}
+	var latencies []RelayLatency
+	if err := t.db.WithContext(ctx).Where("relayed_at >= ?", from).Find(&latencies).Error; err != nil {
+		return nil, fmt.Errorf("query relay latencies: %w", err)
+	}
+
+	bySigner := make(map[relayer][]float64)
+	byChannelSigner := make(map[channel]map[string][]float64)
+	for _, l := range latencies {
+		bySigner[relayer{l.DstChainID, l.Signer}] = append(bySigner[relayer{l.DstChainID, l.Signer}], l.Seconds)
+		key := channel{l.DstChainID, l.SrcChannel, l.DstChannel}
+		if byChannelSigner[key] == nil {
+			byChannelSigner[key] = make(map[string][]float64)
+		}
+		byChannelSigner[key][l.Signer] = append(byChannelSigner[key][l.Signer], l.Seconds)
+	}
+
+	relayers := make(map[relayer]*RelayerStats)
+	relayerChannels := make(map[relayer]map[channel]bool)
+	channels := make(map[channel]*ChannelStats)
+	entries := make(map[channel]map[string]*ChannelRelayer)
+	for _, a := range activity {
+		r := relayer{a.ChainID, a.Signer}
+		stats, ok := relayers[r]
+		if !ok {
+			stats = &RelayerStats{Signer: a.Signer, ChainID: a.ChainID}
+			relayers[r] = stats
+			relayerChannels[r] = make(map[channel]bool)
+		}
+		stats.Memo = a.Memo
+		stats.add(a)
+
+		c := channel{a.ChainID, a.SrcChannel, a.DstChannel}
+		relayerChannels[r][c] = true
+		channelStats, ok := channels[c]
+		if !ok {
+			channelStats = &ChannelStats{ChainID: a.ChainID, SrcChannel: a.SrcChannel, DstChannel: a.DstChannel}
+			channels[c] = channelStats
+			entries[c] = make(map[string]*ChannelRelayer)
+		}
+		channelStats.add(a)
+
+		entry, ok := entries[c][a.Signer]
+		if !ok {
+			entry = &ChannelRelayer{Signer: a.Signer}
+			entries[c][a.Signer] = entry
+		}
+		entry.Memo = a.Memo
+		entry.add(a)
+	}
+
+	report := &Report{
+		Window:      label,
+		From:        from,
+		To:          to,
+		GasPerRelay: t.gasPerRelay,
+		Relayers:    make([]RelayerStats, 0, len(relayers)),
+		Channels:    make([]ChannelStats, 0, len(channels)),
+		UnderServed: []UnderServedChannel{},
+	}
+
+	for r, stats := range relayers {
+		stats.Channels = len(relayerChannels[r])
+		stats.finish(bySigner[r])
+		stats.WastedGas = int64(stats.Uneffected) * t.gasPerRelay
+		if t.chains != nil && stats.WastedGas > 0 {
+			if chain, ok := t.chains.GetChainByID(r.chainID); ok && chain.GasPrice > 0 && chain.FeeDenom != "" {
+				stats.WastedFee = &Fee{Amount: float64(stats.WastedGas) * chain.GasPrice, Denom: chain.FeeDenom}
+			}
+		}
+		report.Relayers = append(report.Relayers, *stats)
+	}
+	sort.Slice(report.Relayers, func(i, j int) bool {
+		a, b := report.Relayers[i], report.Relayers[j]
+		if a.Effected != b.Effected {
+			return a.Effected > b.Effected
+		}
+		return a.Signer < b.Signer
+	})
+
+	for c, stats := range channels {
+		var all []float64
+		for signer, entry := range entries[c] {
+			samples := byChannelSigner[c][signer]
+			all = append(all, samples...)
+			entry.finish(samples)
+			if stats.Effected > 0 {
+				entry.Share = entry.Effected / stats.Effected * 100
+			}
+			if entry.Effected > 0 {
+				stats.Relayers++
+			}
+			stats.Leaderboard = append(stats.Leaderboard, *entry)
+		}
+		stats.finish(all)
+		sort.Slice(stats.Leaderboard, func(i, j int) bool {
+			a, b := stats.Leaderboard[i], stats.Leaderboard[j]
+			if a.Effected != b.Effected {
+				return a.Effected > b.Effected
+			}
+			if a.Uneffected != b.Uneffected {
+				return a.Uneffected < b.Uneffected
+			}
+			return a.Signer < b.Signer
+		})
+		for i := range stats.Leaderboard {
+			stats.Leaderboard[i].Rank = i + 1
+		}
+		report.Channels = append(report.Channels, *stats)
+	}
+	sort.Slice(report.Channels, func(i, j int) bool {
+		a, b := report.Channels[i], report.Channels[j]
+		if a.Effected+a.Uneffected != b.Effected+b.Uneffected {
+			return a.Effected+a.Uneffected > b.Effected+b.Uneffected
+		}
+		return a.ChainID+a.SrcChannel < b.ChainID+b.SrcChannel
+	})
+
+	underServed, err := t.underServed(ctx, channels)
+	if err != nil {
+		return nil, err
+	}
+	report.UnderServed = underServed
+	return report, nil
+}
+
+// underServed lists the channels with open stuck packets that fewer than
+// the minimum number of relayers effected packets on over the window
+func (t *Tracker) underServed(ctx context.Context, channels map[channel]*ChannelStats) ([]UnderServedChannel, error) {
+	type stuckChannel struct {
+		ChainID    string
+		SrcChannel string
+		DstChannel string
+		Stuck      int
+	}
+	var stuck []stuckChannel
+	err := t.db.WithContext(ctx).Model(&packethistory.StuckPacket{}).
+		Select("chain_id, src_channel, dst_channel, COUNT(*) AS stuck").
+		Where("resolved_at IS NULL AND status IN ?", []string{packethistory.StatusStuck, packethistory.StatusExpiring}).
+		Group("chain_id, src_channel, dst_channel").
+		Scan(&stuck).Error
+	if err != nil {
+		return nil, fmt.Errorf("query stuck packets: %w", err)
+	}
+
+	result := []UnderServedChannel{}
+	for _, s := range stuck {
+		var dstChain string
+		if t.counterparties != nil {
+			dstChain = t.counterparties.CounterpartyChain(ctx, s.ChainID, s.SrcChannel)
+		}
+
+		entry := UnderServedChannel{
+			ChainID:    dstChain,
+			SrcChainID: s.ChainID,
+			SrcChannel: s.SrcChannel,
+			DstChannel: s.DstChannel,
+			Stuck:      s.Stuck,
+		}
+		// Without the destination chain, match on the channel pair alone
+		for c, stats := range channels {
+			if c.srcChannel != s.SrcChannel || c.dstChannel != s.DstChannel || (dstChain != "" && c.chainID != dstChain) {
+				continue
+			}
+			entry.ChainID = c.chainID
+			entry.Relayers += stats.Relayers
+			entry.Effected += stats.Effected
+		}
+
+		if entry.Effected == 0 {
+			entry.Reasons = append(entry.Reasons, "no relayer effected a packet in the window")
+		} else if entry.Relayers < t.minRelayers {
+			entry.Reasons = append(entry.Reasons, fmt.Sprintf("%d relayer(s) effected packets in the window, fewer than %d", entry.Relayers, t.minRelayers))
+		}
+		if len(entry.Reasons) > 0 {
+			result = append(result, entry)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Stuck != result[j].Stuck {
+			return result[i].Stuck > result[j].Stuck
+		}
+		return result[i].SrcChainID+result[i].SrcChannel < result[j].SrcChainID+result[j].SrcChannel
+	})
+	return result, nil
+}
+
+// add counts a bucket of activity
+func (s *Stats) add(a RelayerActivity) {
+	s.Effected += a.Effected
+	s.Uneffected += a.Uneffected
+	s.Frontrun += a.Frontrun
+	s.Frontran += a.Frontran
+}
+
+// finish sets the ratios once every bucket has been counted
+func (s *Stats) finish(latencies []float64) {
+	if total := s.Effected + s.Uneffected; total > 0 {
+		s.RedundancyRatio = s.Uneffected / total
+	}
+	s.LatencySamples = len(latencies)
+	if len(latencies) > 0 {
+		m := median(latencies)
+		s.MedianLatencySeconds = &m
+	}
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
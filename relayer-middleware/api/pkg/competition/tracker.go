@@ -0,0 +1,369 @@
+package competition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/packethistory"
+)
+
+// bucketSize is the resolution relayer activity is stored at
+const bucketSize = 10 * time.Minute
+
+// Chainpulse's relay counters
+const (
+	metricEffected   = "ibc_effected_packets"
+	metricUneffected = "ibc_uneffected_packets"
+	metricFrontrun   = "ibc_frontrun_counter"
+)
+
+var activityColumns = []string{
+	"bucket", "chain_id", "src_channel", "dst_channel", "signer", "memo",
+	"effected", "uneffected", "frontrun", "frontran",
+}
+
+// activityConflict adds a scrape's increase to what the bucket already has
+const activityConflict = `(bucket, chain_id, src_channel, dst_channel, signer) DO UPDATE SET
+	memo = EXCLUDED.memo,
+	effected = relayer_activity.effected + EXCLUDED.effected,
+	uneffected = relayer_activity.uneffected + EXCLUDED.uneffected,
+	frontrun = relayer_activity.frontrun + EXCLUDED.frontrun,
+	frontran = relayer_activity.frontran + EXCLUDED.frontran`
+
+// CollectResult summarises one collection
+type CollectResult struct {
+	// Series counts the signer and channel pairs whose counters increased
+	Series int `json:"series"`
+	// Latencies counts the relays newly dated
+	Latencies int       `json:"latencies"`
+	At        time.Time `json:"at"`
+}
+
+// series identifies a signer relaying on a channel
+type series struct {
+	chainID    string
+	srcChannel string
+	dstChannel string
+	signer     string
+}
+
+// counts are a series' cumulative Chainpulse counters
+type counts struct {
+	memo       string
+	effected   float64
+	uneffected float64
+	frontrun   float64
+	frontran   float64
+}
+
+// Tracker stores how much each relayer relays on each channel, how much of
+// it was redundant and how quickly packets get relayed, and reports on the
+// competition between relayers
+type Tracker struct {
+	db             *gorm.DB
+	source         Source
+	chains         Chains
+	counterparties Counterparties
+	interval       time.Duration
+	retention      time.Duration
+	gasPerRelay    int64
+	minRelayers    int
+	latencyBatch   int
+
+	mu       sync.Mutex
+	previous map[series]counts
+	// latencyCursor is the resolved_at and id up to which packets have been
+	// dated. Packets resolved in the same collection share a resolved_at, so
+	// the id breaks the tie.
+	latencyCursor   time.Time
+	latencyCursorID uint
+
+	now    func() time.Time
+	logger *zap.Logger
+}
+
+// NewTracker creates a relayer competition tracker. Settings come from
+// COMPETITION_INTERVAL, COMPETITION_RETENTION, COMPETITION_GAS_PER_RELAY
+// (an estimate of what a redundant relay message costs),
+// COMPETITION_MIN_RELAYERS (below which a channel with stuck packets is
+// under-served) and COMPETITION_LATENCY_BATCH (packets dated per
+// collection).
+func NewTracker(db *gorm.DB, source Source, logger *zap.Logger) *Tracker {
+	return &Tracker{
+		db:           db,
+		source:       source,
+		interval:     database.EnvDuration("COMPETITION_INTERVAL", time.Minute),
+		retention:    database.EnvDuration("COMPETITION_RETENTION", 30*24*time.Hour),
+		gasPerRelay:  int64(database.EnvInt("COMPETITION_GAS_PER_RELAY", 120000)),
+		minRelayers:  database.EnvInt("COMPETITION_MIN_RELAYERS", 2),
+		latencyBatch: database.EnvInt("COMPETITION_LATENCY_BATCH", 50),
+		now:          time.Now,
+		logger:       logger.With(zap.String("component", "competition")),
+	}
+}
+
+// UseChains prices wasted gas in each chain's fee denom
+func (t *Tracker) UseChains(chains Chains) {
+	t.chains = chains
+}
+
+// UseCounterparties matches stuck packets to the chain they're relayed to
+func (t *Tracker) UseCounterparties(counterparties Counterparties) {
+	t.counterparties = counterparties
+}
+
+// Start collects immediately and then every interval until ctx is done
+func (t *Tracker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := t.Collect(ctx); err != nil {
+				t.logger.Warn("Failed to collect relayer activity", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Collect scrapes Chainpulse's relay counters and stores their increase
+// since the last scrape, dates recently resolved packets and prunes what's
+// older than the retention. The first scrape only sets the baseline, as
+// Chainpulse's counters cover however long it has been running.
+func (t *Tracker) Collect(ctx context.Context) (*CollectResult, error) {
+	text, err := t.source.GetMetrics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch chainpulse metrics: %w", err)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("parse chainpulse metrics: %w", err)
+	}
+	current := readCounts(families)
+
+	now := t.now().UTC()
+	result := &CollectResult{At: now}
+
+	t.mu.Lock()
+	previous := t.previous
+	t.mu.Unlock()
+
+	if previous != nil {
+		builder := database.NewBatchInsertBuilder("relayer_activity", activityColumns...)
+		bucket := now.Truncate(bucketSize)
+		for key, cur := range current {
+			prev := previous[key]
+			delta := counts{
+				effected:   increase(prev.effected, cur.effected),
+				uneffected: increase(prev.uneffected, cur.uneffected),
+				frontrun:   increase(prev.frontrun, cur.frontrun),
+				frontran:   increase(prev.frontran, cur.frontran),
+			}
+			if delta.effected+delta.uneffected+delta.frontrun+delta.frontran == 0 {
+				continue
+			}
+			builder.AddRow(
+				bucket, key.chainID, key.srcChannel, key.dstChannel, key.signer, cur.memo,
+				delta.effected, delta.uneffected, delta.frontrun, delta.frontran,
+			)
+			result.Series++
+		}
+		if query, args := builder.OnConflict(activityConflict).Build(); query != "" {
+			if err := t.db.WithContext(ctx).Exec(query, args...).Error; err != nil {
+				return nil, fmt.Errorf("store relayer activity: %w", err)
+			}
+		}
+	}
+
+	// The baseline only moves once the increase is stored, so a failed
+	// insert is counted by the next collection instead of lost
+	t.mu.Lock()
+	t.previous = current
+	t.mu.Unlock()
+
+	latencies, err := t.sampleLatencies(ctx, now)
+	if err != nil {
+		t.logger.Warn("Failed to date relayed packets", zap.Error(err))
+	}
+	result.Latencies = latencies
+
+	cutoff := now.Add(-t.retention)
+	if err := t.db.WithContext(ctx).Where("bucket < ?", cutoff).Delete(&RelayerActivity{}).Error; err != nil {
+		return nil, fmt.Errorf("prune relayer activity: %w", err)
+	}
+	if err := t.db.WithContext(ctx).Where("relayed_at < ?", cutoff).Delete(&RelayLatency{}).Error; err != nil {
+		return nil, fmt.Errorf("prune relay latencies: %w", err)
+	}
+
+	t.logger.Debug("Collected relayer activity",
+		zap.Int("series", result.Series),
+		zap.Int("latencies", result.Latencies),
+	)
+	return result, nil
+}
+
+// sampleLatencies dates the relay of up to a batch of packets the history
+// has seen resolved since the last collection. Only packets that were
+// reported stuck are dated, as Chainpulse can't list every relayed packet,
+// so the latencies are those of the packets relayers had to compete for.
+func (t *Tracker) sampleLatencies(ctx context.Context, now time.Time) (int, error) {
+	t.mu.Lock()
+	cursor, cursorID := t.latencyCursor, t.latencyCursorID
+	t.mu.Unlock()
+	if cursor.IsZero() {
+		cursor = now.Add(-t.interval)
+	}
+
+	var resolved []packethistory.StuckPacket
+	err := t.db.WithContext(ctx).
+		Where("status = ? AND (resolved_at > ? OR (resolved_at = ? AND id > ?))",
+			packethistory.StatusResolved, cursor, cursor, cursorID).
+		Order("resolved_at ASC, id ASC").
+		Limit(t.latencyBatch).
+		Find(&resolved).Error
+	if err != nil {
+		return 0, fmt.Errorf("query resolved packets: %w", err)
+	}
+
+	var latencies []RelayLatency
+	for _, p := range resolved {
+		cursor, cursorID = *p.ResolvedAt, p.ID
+		details, err := t.source.GetPacketDetails(ctx, p.ChainID, p.SrcChannel, p.Sequence)
+		if err != nil {
+			t.logger.Debug("Failed to fetch packet details",
+				zap.String("chain_id", p.ChainID),
+				zap.String("channel", p.SrcChannel),
+				zap.Uint64("sequence", p.Sequence),
+				zap.Error(err),
+			)
+			continue
+		}
+		if latency, ok := relayLatency(p, details); ok {
+			latencies = append(latencies, latency)
+		}
+	}
+
+	t.mu.Lock()
+	t.latencyCursor, t.latencyCursorID = cursor, cursorID
+	t.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return 0, nil
+	}
+	builder := database.NewBatchInsertBuilder("relay_latencies",
+		"chain_id", "src_channel", "sequence", "dst_chain_id", "dst_channel", "signer", "seconds", "relayed_at")
+	for _, l := range latencies {
+		builder.AddRow(l.ChainID, l.SrcChannel, l.Sequence, l.DstChainID, l.DstChannel, l.Signer, l.Seconds, l.RelayedAt)
+	}
+	query, args := builder.OnConflict("(chain_id, src_channel, sequence) DO NOTHING").Build()
+	if err := t.db.WithContext(ctx).Exec(query, args...).Error; err != nil {
+		return 0, fmt.Errorf("store relay latencies: %w", err)
+	}
+	return len(latencies), nil
+}
+
+// relayLatency is the time from a packet's send to the receive that
+// effected it
+func relayLatency(p packethistory.StuckPacket, details *chainpulse.PacketDetails) (RelayLatency, bool) {
+	sentAt := details.Timestamp
+	if sentAt.IsZero() {
+		sentAt = p.SentAt
+	}
+	for _, attempt := range details.Attempts {
+		if !attempt.Effected || !strings.HasSuffix(attempt.MsgTypeURL, "MsgRecvPacket") || attempt.Timestamp.Before(sentAt) {
+			continue
+		}
+		dstChannel := p.DstChannel
+		if dstChannel == "" {
+			dstChannel = details.DstChannel
+		}
+		return RelayLatency{
+			ChainID:    p.ChainID,
+			SrcChannel: p.SrcChannel,
+			Sequence:   p.Sequence,
+			DstChainID: attempt.Chain,
+			DstChannel: dstChannel,
+			Signer:     attempt.Signer,
+			Seconds:    attempt.Timestamp.Sub(sentAt).Seconds(),
+			RelayedAt:  attempt.Timestamp.UTC(),
+		}, true
+	}
+	return RelayLatency{}, false
+}
+
+// readCounts sums Chainpulse's relay counters by signer and channel, over
+// ports and memos
+func readCounts(families map[string]*dto.MetricFamily) map[series]counts {
+	result := make(map[series]counts)
+	add := func(family, signerLabel, memoLabel string, field func(*counts, float64)) {
+		for _, metric := range families[family].GetMetric() {
+			labels := labelMap(metric)
+			key := series{
+				chainID:    labels["chain_id"],
+				srcChannel: labels["src_channel"],
+				dstChannel: labels["dst_channel"],
+				signer:     labels[signerLabel],
+			}
+			if key.signer == "" {
+				continue
+			}
+			c := result[key]
+			field(&c, value(metric))
+			// A frontrun's memo is the victim's, its effected memo the winner's
+			if memo := labels[memoLabel]; memo != "" && (c.memo == "" || memoLabel == "memo") {
+				c.memo = memo
+			}
+			result[key] = c
+		}
+	}
+	add(metricEffected, "signer", "memo", func(c *counts, v float64) { c.effected += v })
+	add(metricUneffected, "signer", "memo", func(c *counts, v float64) { c.uneffected += v })
+	add(metricFrontrun, "signer", "memo", func(c *counts, v float64) { c.frontrun += v })
+	add(metricFrontrun, "frontrunned_by", "effected_memo", func(c *counts, v float64) { c.frontran += v })
+	return result
+}
+
+// increase is how much a counter grew, counting a reset as growth from zero
+func increase(previous, current float64) float64 {
+	if current < previous {
+		return current
+	}
+	return current - previous
+}
+
+func labelMap(metric *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+func value(metric *dto.Metric) float64 {
+	switch {
+	case metric.GetCounter() != nil:
+		return metric.GetCounter().GetValue()
+	case metric.GetGauge() != nil:
+		return metric.GetGauge().GetValue()
+	case metric.GetUntyped() != nil:
+		return metric.GetUntyped().GetValue()
+	}
+	return 0
+}
@@ -0,0 +1,321 @@
+package competition
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/packethistory"
+)
+
+var testNow = time.Date(2026, 3, 3, 12, 1, 0, 0, time.UTC)
+
+const (
+	fastRelayer = "osmo1fast"
+	slowRelayer = "osmo1slow"
+)
+
+// fakeSource serves Chainpulse counters for two relayers on channel-141
+type fakeSource struct {
+	effected   map[string]float64
+	uneffected map[string]float64
+	// frontrun counts slowRelayer being frontrun by fastRelayer
+	frontrun float64
+	details  map[uint64]*chainpulse.PacketDetails
+}
+
+func (f *fakeSource) GetMetrics(ctx context.Context) (string, error) {
+	labels := `chain_id="osmosis-1",src_channel="channel-141",src_port="transfer",dst_channel="channel-0",dst_port="transfer"`
+	var b strings.Builder
+	b.WriteString("# TYPE ibc_effected_packets counter\n")
+	for signer, v := range f.effected {
+		fmt.Fprintf(&b, "ibc_effected_packets{%s,signer=%q,memo=\"%s | hermes\"} %v\n", labels, signer, signer, v)
+	}
+	b.WriteString("# TYPE ibc_uneffected_packets counter\n")
+	for signer, v := range f.uneffected {
+		fmt.Fprintf(&b, "ibc_uneffected_packets{%s,signer=%q,memo=\"%s | hermes\"} %v\n", labels, signer, signer, v)
+	}
+	b.WriteString("# TYPE ibc_frontrun_counter counter\n")
+	fmt.Fprintf(&b, "ibc_frontrun_counter{%s,signer=%q,memo=\"slow\",frontrunned_by=%q,effected_memo=\"fast\"} %v\n",
+		labels, slowRelayer, fastRelayer, f.frontrun)
+	return b.String(), nil
+}
+
+func (f *fakeSource) GetPacketDetails(ctx context.Context, chain, channel string, sequence uint64) (*chainpulse.PacketDetails, error) {
+	details, ok := f.details[sequence]
+	if !ok {
+		return nil, fmt.Errorf("packet %d not found", sequence)
+	}
+	return details, nil
+}
+
+type fakeChains map[string]config.ChainConfig
+
+func (f fakeChains) GetChainByID(chainID string) (config.ChainConfig, bool) {
+	chain, ok := f[chainID]
+	return chain, ok
+}
+
+type fakeCounterparties map[string]string
+
+func (f fakeCounterparties) CounterpartyChain(ctx context.Context, chainID, channelID string) string {
+	return f[chainID+"/"+channelID]
+}
+
+func stuckPacket(channel string, sequence uint64, status string, resolvedAt *time.Time) packethistory.StuckPacket {
+	return packethistory.StuckPacket{
+		ChainID:     "cosmoshub-4",
+		SrcChannel:  channel,
+		Sequence:    sequence,
+		DstChannel:  "channel-0",
+		Status:      status,
+		SentAt:      testNow.Add(-10 * time.Minute),
+		FirstSeenAt: testNow.Add(-5 * time.Minute),
+		LastSeenAt:  testNow.Add(-time.Minute),
+		ResolvedAt:  resolvedAt,
+	}
+}
+
+func TestTracker(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&packethistory.StuckPacket{}, &RelayerActivity{}, &RelayLatency{}))
+
+	sentAt := testNow.Add(-10 * time.Minute)
+	resolvedAt := testNow.Add(-30 * time.Second)
+	packets := []packethistory.StuckPacket{
+		stuckPacket("channel-141", 1, packethistory.StatusResolved, &resolvedAt),
+		stuckPacket("channel-141", 2, packethistory.StatusStuck, nil),
+		stuckPacket("channel-750", 3, packethistory.StatusStuck, nil),
+		stuckPacket("channel-750", 4, packethistory.StatusExpiring, nil),
+	}
+	require.NoError(t, db.Create(&packets).Error)
+
+	source := &fakeSource{
+		effected:   map[string]float64{fastRelayer: 10, slowRelayer: 2},
+		uneffected: map[string]float64{slowRelayer: 5},
+		frontrun:   5,
+		details: map[uint64]*chainpulse.PacketDetails{
+			1: {
+				Packet:    chainpulse.Packet{ChainID: "cosmoshub-4", SrcChannel: "channel-141", DstChannel: "channel-0", Sequence: 1},
+				Timestamp: sentAt,
+				Attempts: []chainpulse.PacketAttempt{
+					{Chain: "osmosis-1", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: slowRelayer, Effected: false, Timestamp: sentAt.Add(42 * time.Second)},
+					{Chain: "osmosis-1", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: fastRelayer, Effected: true, Timestamp: sentAt.Add(40 * time.Second)},
+					{Chain: "cosmoshub-4", MsgTypeURL: "/ibc.core.channel.v1.MsgAcknowledgement", Signer: "cosmos1fast", Effected: true, Timestamp: sentAt.Add(60 * time.Second)},
+				},
+			},
+		},
+	}
+
+	now := testNow
+	tracker := NewTracker(db, source, zap.NewNop())
+	tracker.UseChains(fakeChains{"osmosis-1": {ChainID: "osmosis-1", FeeDenom: "uosmo", GasPrice: 0.0025}})
+	tracker.UseCounterparties(fakeCounterparties{"cosmoshub-4/channel-141": "osmosis-1"})
+	tracker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// The first scrape is the baseline
+	result, err := tracker.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Series)
+	assert.Equal(t, 1, result.Latencies)
+
+	source.effected = map[string]float64{fastRelayer: 14, slowRelayer: 3}
+	source.uneffected = map[string]float64{slowRelayer: 8}
+	source.frontrun = 8
+	now = now.Add(time.Minute)
+	result, err = tracker.Collect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Series)
+	assert.Equal(t, 0, result.Latencies)
+
+	// Chainpulse restarted, so its counters started over
+	source.effected[fastRelayer] = 2
+	now = now.Add(time.Minute)
+	_, err = tracker.Collect(ctx)
+	require.NoError(t, err)
+
+	report, err := tracker.Report(ctx, 24*time.Hour, "24h")
+	require.NoError(t, err)
+	assert.Equal(t, "24h", report.Window)
+	assert.Equal(t, int64(120000), report.GasPerRelay)
+
+	require.Len(t, report.Relayers, 2)
+	fast, slow := report.Relayers[0], report.Relayers[1]
+	assert.Equal(t, fastRelayer, fast.Signer)
+	assert.Equal(t, "osmosis-1", fast.ChainID)
+	assert.Equal(t, fastRelayer+" | hermes", fast.Memo)
+	assert.Equal(t, 6.0, fast.Effected)
+	assert.Equal(t, 3.0, fast.Frontran)
+	assert.Equal(t, 0.0, fast.RedundancyRatio)
+	assert.Equal(t, int64(0), fast.WastedGas)
+	assert.Nil(t, fast.WastedFee)
+	require.NotNil(t, fast.MedianLatencySeconds)
+	assert.Equal(t, 40.0, *fast.MedianLatencySeconds)
+	assert.Equal(t, 1, fast.LatencySamples)
+
+	assert.Equal(t, slowRelayer, slow.Signer)
+	assert.Equal(t, 1.0, slow.Effected)
+	assert.Equal(t, 3.0, slow.Uneffected)
+	assert.Equal(t, 3.0, slow.Frontrun)
+	assert.Equal(t, 0.75, slow.RedundancyRatio)
+	assert.Equal(t, int64(360000), slow.WastedGas)
+	require.NotNil(t, slow.WastedFee)
+	assert.InDelta(t, 900.0, slow.WastedFee.Amount, 0.001)
+	assert.Equal(t, "uosmo", slow.WastedFee.Denom)
+	assert.Nil(t, slow.MedianLatencySeconds)
+
+	require.Len(t, report.Channels, 1)
+	channel := report.Channels[0]
+	assert.Equal(t, "osmosis-1", channel.ChainID)
+	assert.Equal(t, "channel-141", channel.SrcChannel)
+	assert.Equal(t, "channel-0", channel.DstChannel)
+	assert.Equal(t, 2, channel.Relayers)
+	assert.Equal(t, 7.0, channel.Effected)
+	require.Len(t, channel.Leaderboard, 2)
+	assert.Equal(t, 1, channel.Leaderboard[0].Rank)
+	assert.Equal(t, fastRelayer, channel.Leaderboard[0].Signer)
+	assert.InDelta(t, 600.0/7, channel.Leaderboard[0].Share, 0.001)
+	assert.Equal(t, 2, channel.Leaderboard[1].Rank)
+
+	// channel-141 has two relayers, channel-750 none
+	require.Len(t, report.UnderServed, 1)
+	underServed := report.UnderServed[0]
+	assert.Equal(t, "cosmoshub-4", underServed.SrcChainID)
+	assert.Equal(t, "channel-750", underServed.SrcChannel)
+	assert.Equal(t, 2, underServed.Stuck)
+	assert.Equal(t, 0, underServed.Relayers)
+	assert.NotEmpty(t, underServed.Reasons)
+
+	// With a higher bar channel-141 is under-served too
+	tracker.minRelayers = 3
+	report, err = tracker.Report(ctx, 24*time.Hour, "24h")
+	require.NoError(t, err)
+	require.Len(t, report.UnderServed, 2)
+	assert.Equal(t, "channel-141", report.UnderServed[1].SrcChannel)
+	assert.Equal(t, "osmosis-1", report.UnderServed[1].ChainID)
+	assert.Equal(t, 2, report.UnderServed[1].Relayers)
+
+	// Activity older than the window is left out
+	now = now.Add(48 * time.Hour)
+	report, err = tracker.Report(ctx, time.Hour, "1h")
+	require.NoError(t, err)
+	assert.Empty(t, report.Relayers)
+	assert.Empty(t, report.Channels)
+
+	// and pruned past the retention
+	now = now.Add(31 * 24 * time.Hour)
+	_, err = tracker.Collect(ctx)
+	require.NoError(t, err)
+	var stored int64
+	require.NoError(t, db.Model(&RelayerActivity{}).Count(&stored).Error)
+	assert.Equal(t, int64(0), stored)
+	require.NoError(t, db.Model(&RelayLatency{}).Count(&stored).Error)
+	assert.Equal(t, int64(0), stored)
+}
+
+func TestTrackerKeepsBaselineOnFailedInsert(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&packethistory.StuckPacket{}, &RelayerActivity{}, &RelayLatency{}))
+
+	source := &fakeSource{effected: map[string]float64{fastRelayer: 10}}
+	now := testNow
+	tracker := NewTracker(db, source, zap.NewNop())
+	tracker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err = tracker.Collect(ctx)
+	require.NoError(t, err)
+
+	// The relays counted while the table can't be written to
+	require.NoError(t, db.Migrator().DropTable(&RelayerActivity{}))
+	source.effected[fastRelayer] = 14
+	now = now.Add(time.Minute)
+	_, err = tracker.Collect(ctx)
+	require.Error(t, err)
+
+	// are stored by the next collection
+	require.NoError(t, db.AutoMigrate(&RelayerActivity{}))
+	source.effected[fastRelayer] = 15
+	now = now.Add(time.Minute)
+	_, err = tracker.Collect(ctx)
+	require.NoError(t, err)
+
+	var effected float64
+	require.NoError(t, db.Model(&RelayerActivity{}).Select("SUM(effected)").Scan(&effected).Error)
+	assert.Equal(t, 5.0, effected)
+}
+
+func TestTrackerLatencyTies(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&packethistory.StuckPacket{}, &RelayerActivity{}, &RelayLatency{}))
+
+	// All three packets were resolved by the same collection
+	sentAt := testNow.Add(-10 * time.Minute)
+	resolvedAt := testNow.Add(-30 * time.Second)
+	source := &fakeSource{details: map[uint64]*chainpulse.PacketDetails{}}
+	for sequence := uint64(1); sequence <= 3; sequence++ {
+		require.NoError(t, db.Create(&[]packethistory.StuckPacket{
+			stuckPacket("channel-141", sequence, packethistory.StatusResolved, &resolvedAt),
+		}).Error)
+		source.details[sequence] = &chainpulse.PacketDetails{
+			Packet:    chainpulse.Packet{ChainID: "cosmoshub-4", SrcChannel: "channel-141", DstChannel: "channel-0", Sequence: sequence},
+			Timestamp: sentAt,
+			Attempts: []chainpulse.PacketAttempt{
+				{Chain: "osmosis-1", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: fastRelayer, Effected: true, Timestamp: sentAt.Add(40 * time.Second)},
+			},
+		}
+	}
+
+	tracker := NewTracker(db, source, zap.NewNop())
+	tracker.latencyBatch = 2
+	tracker.now = func() time.Time { return testNow }
+	ctx := context.Background()
+
+	dated, err := tracker.sampleLatencies(ctx, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, 2, dated)
+
+	// The next page picks up the packet tied with the last one dated
+	dated, err = tracker.sampleLatencies(ctx, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dated)
+
+	dated, err = tracker.sampleLatencies(ctx, testNow)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dated)
+
+	var stored int64
+	require.NoError(t, db.Model(&RelayLatency{}).Count(&stored).Error)
+	assert.Equal(t, int64(3), stored)
+}
+
+func TestParseWindow(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"1h":  time.Hour,
+		"24h": 24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		got, err := ParseWindow(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"", "d", "0d", "-1h", "week"} {
+		_, err := ParseWindow(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,154 @@
+package competition
+
+import (
+	"context"
+	"time"
+
+	"github.com/relayooor/chains/config"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+// RelayerActivity is what one signer relayed on a channel during one
+// bucket, the increase of Chainpulse's counters over it. ChainID is the
+// chain the relay messages were submitted to; SrcChannel is the channel on
+// the chain the packets were sent from and DstChannel the one on ChainID.
+type RelayerActivity struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	Bucket     time.Time `gorm:"uniqueIndex:idx_relayer_activity_series;index" json:"bucket"`
+	ChainID    string    `gorm:"uniqueIndex:idx_relayer_activity_series" json:"chain_id"`
+	SrcChannel string    `gorm:"uniqueIndex:idx_relayer_activity_series" json:"src_channel"`
+	DstChannel string    `gorm:"uniqueIndex:idx_relayer_activity_series" json:"dst_channel"`
+	Signer     string    `gorm:"uniqueIndex:idx_relayer_activity_series;index" json:"signer"`
+	Memo       string    `json:"memo"`
+	// Effected messages changed state; Uneffected ones were redundant
+	Effected   float64 `json:"effected"`
+	Uneffected float64 `json:"uneffected"`
+	// Frontrun counts the times another signer got there first, Frontran
+	// the times this signer got there before another
+	Frontrun float64 `json:"frontrun"`
+	Frontran float64 `json:"frontran"`
+}
+
+// TableName sets the table name
+func (RelayerActivity) TableName() string {
+	return "relayer_activity"
+}
+
+// RelayLatency is how long a packet took from being sent on ChainID to
+// being received on DstChainID, and who relayed it
+type RelayLatency struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	ChainID    string    `gorm:"uniqueIndex:idx_relay_latencies_packet" json:"chain_id"`
+	SrcChannel string    `gorm:"uniqueIndex:idx_relay_latencies_packet" json:"src_channel"`
+	Sequence   uint64    `gorm:"uniqueIndex:idx_relay_latencies_packet" json:"sequence"`
+	DstChainID string    `json:"dst_chain_id"`
+	DstChannel string    `json:"dst_channel"`
+	Signer     string    `gorm:"index" json:"signer"`
+	Seconds    float64   `json:"seconds"`
+	RelayedAt  time.Time `gorm:"index" json:"relayed_at"`
+}
+
+// TableName sets the table name
+func (RelayLatency) TableName() string {
+	return "relay_latencies"
+}
+
+// Report is relayer competition over a window
+type Report struct {
+	Window string    `json:"window"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	// GasPerRelay is the gas a redundant relay message is assumed to cost
+	GasPerRelay int64                `json:"gas_per_relay"`
+	Relayers    []RelayerStats       `json:"relayers"`
+	Channels    []ChannelStats       `json:"channels"`
+	UnderServed []UnderServedChannel `json:"under_served"`
+}
+
+// RelayerStats is one signer's relaying across channels
+type RelayerStats struct {
+	Signer   string `json:"signer"`
+	ChainID  string `json:"chain_id"`
+	Memo     string `json:"memo"`
+	Channels int    `json:"channels"`
+	Stats
+	// WastedGas is the estimated gas spent on redundant messages, and
+	// WastedFee what it cost at the chain's gas price
+	WastedGas int64 `json:"wasted_gas"`
+	WastedFee *Fee  `json:"wasted_fee,omitempty"`
+}
+
+// Stats are the relay counts of a signer or channel
+type Stats struct {
+	Effected   float64 `json:"effected"`
+	Uneffected float64 `json:"uneffected"`
+	Frontrun   float64 `json:"frontrun"`
+	Frontran   float64 `json:"frontran"`
+	// RedundancyRatio is the share of messages that were redundant
+	RedundancyRatio float64 `json:"redundancy_ratio"`
+	// MedianLatencySeconds is from send to receive over the sampled
+	// packets, nil without samples
+	MedianLatencySeconds *float64 `json:"median_latency_seconds,omitempty"`
+	LatencySamples       int      `json:"latency_samples"`
+}
+
+// Fee is an amount of a chain's fee denom
+type Fee struct {
+	Amount float64 `json:"amount"`
+	Denom  string  `json:"denom"`
+}
+
+// ChannelStats is the competition on one channel
+type ChannelStats struct {
+	ChainID    string `json:"chain_id"`
+	SrcChannel string `json:"src_channel"`
+	DstChannel string `json:"dst_channel"`
+	Stats
+	// Relayers counts the signers that effected a packet
+	Relayers    int              `json:"relayers"`
+	Leaderboard []ChannelRelayer `json:"leaderboard"`
+}
+
+// ChannelRelayer is a signer's place on a channel's leaderboard, ranked by
+// effected packets
+type ChannelRelayer struct {
+	Rank   int    `json:"rank"`
+	Signer string `json:"signer"`
+	Memo   string `json:"memo"`
+	Stats
+	// Share is the percentage of the channel's effected packets
+	Share float64 `json:"share"`
+}
+
+// UnderServedChannel is a channel with stuck packets and too few relayers
+type UnderServedChannel struct {
+	ChainID    string   `json:"chain_id"`
+	SrcChainID string   `json:"src_chain_id"`
+	SrcChannel string   `json:"src_channel"`
+	DstChannel string   `json:"dst_channel"`
+	Stuck      int      `json:"stuck"`
+	Relayers   int      `json:"relayers"`
+	Effected   float64  `json:"effected"`
+	Reasons    []string `json:"reasons"`
+}
+
+// Source is where relay activity comes from, satisfied by
+// *chainpulse.Client. Its metrics count effected, redundant and frontrun
+// relay messages per signer; packet details date the relays.
+type Source interface {
+	GetMetrics(ctx context.Context) (string, error)
+	GetPacketDetails(ctx context.Context, chain, channel string, sequence uint64) (*chainpulse.PacketDetails, error)
+}
+
+// Chains looks up a chain's fee denom and gas price, satisfied by
+// *config.ChainRegistry
+type Chains interface {
+	GetChainByID(chainID string) (config.ChainConfig, bool)
+}
+
+// Counterparties finds the chain a channel leads to, satisfied by
+// *channels.Resolver
+type Counterparties interface {
+	CounterpartyChain(ctx context.Context, chainID, channelID string) string
+}
@@ -155,4 +155,22 @@ func getEnvAsDurationOrDefault(key string, defaultValue time.Duration) time.Dura
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// EnvDuration reads a positive duration from the environment, falling back
+// to defaultValue when it's unset, malformed or not positive
+func EnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// EnvInt reads a positive integer from the environment, falling back to
+// defaultValue when it's unset, malformed or not positive
+func EnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,134 @@
+package diagnostics
+
+import (
+	"path"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+// coveringRelayers lists the configured relayers whose packet filters
+// include the packet's channel. counterpartyChainID and
+// counterpartyChannelID may be empty when unknown.
+func coveringRelayers(configs ConfigReader, p PacketRef, counterpartyChainID, counterpartyChannelID string) ([]string, error) {
+	relayers := []string{}
+
+	content, err := configs.Current(relayerconfig.RelayerHermes)
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		covered, err := hermesCovers(content, p, counterpartyChainID)
+		if err != nil {
+			return nil, err
+		}
+		if covered {
+			relayers = append(relayers, relayerconfig.RelayerHermes)
+		}
+	}
+
+	content, err = configs.Current(relayerconfig.RelayerRly)
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		covered, err := rlyCovers(content, p, counterpartyChainID, counterpartyChannelID)
+		if err != nil {
+			return nil, err
+		}
+		if covered {
+			relayers = append(relayers, relayerconfig.RelayerRly)
+		}
+	}
+
+	return relayers, nil
+}
+
+// hermesCovers checks that Hermes has both chains and that the source
+// chain's packet filter lets the channel through. Filter entries may use
+// glob wildcards.
+func hermesCovers(content string, p PacketRef, counterpartyChainID string) (bool, error) {
+	chains, err := relayerconfig.ListHermesChains(content)
+	if err != nil {
+		return false, err
+	}
+
+	var source *relayerconfig.ChainConfig
+	counterpartyKnown := counterpartyChainID == ""
+	for i := range chains {
+		switch chains[i].ID {
+		case p.ChainID:
+			source = &chains[i]
+		case counterpartyChainID:
+			counterpartyKnown = true
+		}
+	}
+	if source == nil || !counterpartyKnown {
+		return false, nil
+	}
+
+	filter := source.PacketFilter
+	if filter == nil || filter.Policy == nil || len(filter.List) == 0 {
+		return filter == nil || filter.Policy == nil || *filter.Policy != "allow", nil
+	}
+
+	matched := false
+	for _, pair := range filter.List {
+		if len(pair) == 2 && globMatch(pair[0], p.PortID) && globMatch(pair[1], p.ChannelID) {
+			matched = true
+			break
+		}
+	}
+	if *filter.Policy == "deny" {
+		return !matched, nil
+	}
+	return matched, nil
+}
+
+// rlyCovers checks for a path between the two chains whose channel filter
+// lets the channel through. rly filters name channels on the path's source
+// chain, so packets sent from the destination are matched by their
+// counterparty channel.
+func rlyCovers(content string, p PacketRef, counterpartyChainID, counterpartyChannelID string) (bool, error) {
+	paths, err := relayerconfig.ListRlyPaths(content)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rp := range paths {
+		var channel string
+		switch {
+		case rp.SrcChainID == p.ChainID && (counterpartyChainID == "" || rp.DstChainID == counterpartyChainID):
+			channel = p.ChannelID
+		case rp.DstChainID == p.ChainID && rp.SrcChainID == counterpartyChainID:
+			channel = counterpartyChannelID
+		default:
+			continue
+		}
+
+		listed := false
+		for _, c := range rp.Channels {
+			if c == channel {
+				listed = true
+				break
+			}
+		}
+		switch rp.Rule {
+		case "allowlist":
+			if listed {
+				return true, nil
+			}
+		case "denylist":
+			if !listed {
+				return true, nil
+			}
+		default:
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func globMatch(pattern, value string) bool {
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
@@ -0,0 +1,472 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/balances"
+	"relayooor/api/pkg/clearing"
+	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/ibcclients"
+)
+
+var ErrInvalidPacket = errors.New("packet needs a chain, channel and sequence")
+
+// maxConcurrent bounds the packets diagnosed at once by CheckClearable
+const maxConcurrent = 8
+
+// Diagnoser works out why a packet is stuck by checking the usual causes
+// in turn: the channel, the packet's state on both chains, the light
+// clients, its timeout, chain liveness, relayer coverage and funds, and
+// recent relay errors
+type Diagnoser struct {
+	chains        ChainQuerier
+	clients       ibcclients.Querier
+	configs       ConfigReader
+	balances      BalanceReader
+	logs          LogReader
+	haltThreshold time.Duration
+	logWindow     time.Duration
+	now           func() time.Time
+	logger        *zap.Logger
+}
+
+// NewDiagnoser creates a diagnoser. A chain whose latest block is older
+// than DIAGNOSTICS_HALT_THRESHOLD is considered halted; relayer errors are
+// looked for in the last DIAGNOSTICS_LOG_WINDOW of logs.
+func NewDiagnoser(chains ChainQuerier, clients ibcclients.Querier, configs ConfigReader, logger *zap.Logger) *Diagnoser {
+	return &Diagnoser{
+		chains:        chains,
+		clients:       clients,
+		configs:       configs,
+		haltThreshold: database.EnvDuration("DIAGNOSTICS_HALT_THRESHOLD", 5*time.Minute),
+		logWindow:     database.EnvDuration("DIAGNOSTICS_LOG_WINDOW", time.Hour),
+		now:           time.Now,
+		logger:        logger.With(zap.String("component", "packet_diagnostics")),
+	}
+}
+
+// UseBalances enables the relayer funds check
+func (d *Diagnoser) UseBalances(reader BalanceReader) {
+	d.balances = reader
+}
+
+// UseLogs enables the relay error check
+func (d *Diagnoser) UseLogs(reader LogReader) {
+	d.logs = reader
+}
+
+// diagnosis accumulates the checks and causes of one packet
+type diagnosis struct {
+	*Diagnosis
+}
+
+func (d *diagnosis) check(name, result, detail string) {
+	d.Checks = append(d.Checks, Check{Name: name, Result: result, Detail: detail})
+}
+
+func (d *diagnosis) checkErr(name string, err error) {
+	d.check(name, CheckError, err.Error())
+}
+
+func (d *diagnosis) cause(cause Cause) {
+	d.Causes = append(d.Causes, cause)
+}
+
+// Diagnose checks a packet identified by its source chain, port, channel
+// and sequence. Failing lookups are recorded as errored checks rather than
+// failing the diagnosis.
+func (d *Diagnoser) Diagnose(ctx context.Context, id clearing.PacketIdentifier) (*Diagnosis, error) {
+	ref, err := packetRef(id)
+	if err != nil {
+		return nil, err
+	}
+
+	diag := diagnosis{&Diagnosis{
+		Packet:      ref,
+		State:       StateUnknown,
+		Relayers:    []string{},
+		Causes:      []Cause{},
+		DiagnosedAt: d.now(),
+	}}
+
+	d.checkChannel(ctx, diag)
+	if d.checkPacketState(ctx, diag) {
+		d.checkClients(ctx, diag)
+		target := d.checkTimeout(ctx, diag)
+		d.checkHalted(ctx, diag, target)
+		d.checkCoverage(diag)
+		d.checkFunds(diag, target)
+		d.checkRelayErrors(diag)
+	}
+
+	d.conclude(diag)
+	return diag.Diagnosis, nil
+}
+
+// CheckClearable diagnoses packets concurrently and returns an error
+// wrapping clearing.ErrPacketNotClearable for the first one a clear can't
+// help, e.g. because it was already relayed or its client expired
+func (d *Diagnoser) CheckClearable(ctx context.Context, packets []clearing.PacketIdentifier) error {
+	errs := make([]error, len(packets))
+	sem := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	for i, packet := range packets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, packet clearing.PacketIdentifier) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			diag, err := d.Diagnose(ctx, packet)
+			switch {
+			case err != nil:
+				errs[i] = fmt.Errorf("%w: %v", clearing.ErrPacketNotClearable, err)
+			case !diag.Clearable:
+				errs[i] = fmt.Errorf("%w: %s/%s/%d: %s", clearing.ErrPacketNotClearable,
+					diag.Packet.ChainID, diag.Packet.ChannelID, diag.Packet.Sequence, diag.Cause.Summary)
+			}
+		}(i, packet)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Diagnoser) checkChannel(ctx context.Context, diag diagnosis) {
+	p := diag.Packet
+	channel, err := d.chains.Channel(ctx, p.ChainID, p.PortID, p.ChannelID)
+	if err != nil {
+		diag.checkErr("channel", err)
+		return
+	}
+	diag.CounterpartyPortID = channel.CounterpartyPortID
+	diag.CounterpartyChannelID = channel.CounterpartyChannelID
+
+	if channel.State != "STATE_OPEN" {
+		diag.check("channel", CheckFailed, channel.State)
+		diag.cause(Cause{
+			Code:    CauseChannelClosed,
+			Summary: fmt.Sprintf("Channel %s on %s is not open", p.ChannelID, p.ChainID),
+			Detail:  fmt.Sprintf("The channel is in %s, so no packets can be received on it.", channel.State),
+			Action:  "Relay a timeout-on-close to refund the sender. Contact the operator, a regular clear can't do this.",
+		})
+		return
+	}
+	diag.check("channel", CheckPassed, channel.State)
+}
+
+// checkPacketState finds where the packet is. It returns false when the
+// packet is no longer outstanding and the remaining checks don't apply.
+func (d *Diagnoser) checkPacketState(ctx context.Context, diag diagnosis) bool {
+	p := diag.Packet
+
+	// The source client tracks the counterparty, which names it
+	if client, err := d.clients.ChannelClient(ctx, p.ChainID, p.PortID, p.ChannelID); err == nil {
+		diag.CounterpartyChainID = client.CounterpartyChainID
+	}
+
+	pending, err := d.chains.PacketCommitment(ctx, p.ChainID, p.PortID, p.ChannelID, p.Sequence)
+	if err != nil {
+		diag.checkErr("packet_commitment", err)
+		return true
+	}
+	if !pending {
+		diag.State = StateCompleted
+		diag.check("packet_commitment", CheckFailed, "no commitment on the source chain")
+		diag.cause(Cause{
+			Code:    CauseAlreadyRelayed,
+			Summary: "The packet is no longer stuck",
+			Detail:  "The source chain has no commitment for it, so it was acknowledged or timed out.",
+			Action:  "Nothing to do. Check the receiver's balance, or the sender's if the packet timed out.",
+		})
+		return false
+	}
+	diag.check("packet_commitment", CheckPassed, "packet is outstanding")
+
+	diag.State = StatePending
+	if diag.CounterpartyChainID == "" || diag.CounterpartyChannelID == "" {
+		diag.check("packet_receipt", CheckSkipped, "counterparty unknown")
+		return true
+	}
+	received, err := d.chains.PacketReceipt(ctx, diag.CounterpartyChainID, diag.CounterpartyPortID, diag.CounterpartyChannelID, p.Sequence)
+	switch {
+	case err != nil:
+		diag.checkErr("packet_receipt", err)
+	case received:
+		diag.State = StateReceived
+		diag.check("packet_receipt", CheckPassed, "received, acknowledgement not yet relayed back")
+	default:
+		diag.check("packet_receipt", CheckPassed, "not yet received")
+	}
+	return true
+}
+
+// checkClients checks the clients on both ends: receiving needs the
+// destination's client of the source, acks and timeouts the reverse
+func (d *Diagnoser) checkClients(ctx context.Context, diag diagnosis) {
+	p := diag.Packet
+	d.checkClient(ctx, diag, "source_client", p.ChainID, p.PortID, p.ChannelID)
+	if diag.CounterpartyChainID != "" && diag.CounterpartyChannelID != "" {
+		d.checkClient(ctx, diag, "destination_client", diag.CounterpartyChainID, diag.CounterpartyPortID, diag.CounterpartyChannelID)
+	}
+}
+
+func (d *Diagnoser) checkClient(ctx context.Context, diag diagnosis, name, chainID, portID, channelID string) {
+	client, err := d.clients.ChannelClient(ctx, chainID, portID, channelID)
+	if err != nil {
+		diag.checkErr(name, err)
+		return
+	}
+
+	where := fmt.Sprintf("client %s on %s (tracking %s)", client.ClientID, chainID, client.CounterpartyChainID)
+	if client.Frozen {
+		diag.check(name, CheckFailed, where+" is frozen")
+		diag.cause(Cause{
+			Code:    CauseClientFrozen,
+			Summary: fmt.Sprintf("The light client on %s is frozen", chainID),
+			Detail:  fmt.Sprintf("The %s was frozen after misbehaviour was submitted.", where),
+			Action:  "The client must be recovered by governance before packets can move. Funds are safe in escrow.",
+		})
+		return
+	}
+
+	timestamp, err := d.clients.ConsensusTimestamp(ctx, chainID, client.ClientID, client.LatestHeight)
+	if err != nil {
+		diag.checkErr(name, err)
+		return
+	}
+	expiresAt := timestamp.Add(client.TrustingPeriod)
+	if !diag.DiagnosedAt.Before(expiresAt) {
+		diag.check(name, CheckFailed, fmt.Sprintf("%s expired at %s", where, expiresAt.Format(time.RFC3339)))
+		diag.cause(Cause{
+			Code:    CauseClientExpired,
+			Summary: fmt.Sprintf("The light client on %s has expired", chainID),
+			Detail:  fmt.Sprintf("The %s wasn't updated within its %s trusting period.", where, client.TrustingPeriod),
+			Action:  "The client must be substituted through a governance proposal before packets can move. Funds are safe in escrow.",
+		})
+		return
+	}
+	diag.check(name, CheckPassed, fmt.Sprintf("%s expires at %s", where, expiresAt.Format(time.RFC3339)))
+}
+
+// checkTimeout checks whether a pending packet can no longer be received
+// and returns the chain the next relay transaction goes to
+func (d *Diagnoser) checkTimeout(ctx context.Context, diag diagnosis) string {
+	p := diag.Packet
+	if diag.State != StatePending {
+		diag.check("timeout", CheckSkipped, "packet was received")
+		return p.ChainID
+	}
+	if diag.CounterpartyChainID == "" {
+		diag.check("timeout", CheckSkipped, "counterparty unknown")
+		return ""
+	}
+
+	timeout, err := d.chains.PacketTimeout(ctx, p.ChainID, p.PortID, p.ChannelID, p.Sequence)
+	if err != nil {
+		diag.checkErr("timeout", err)
+		return diag.CounterpartyChainID
+	}
+	block, err := d.chains.LatestBlock(ctx, diag.CounterpartyChainID)
+	if err != nil {
+		diag.checkErr("timeout", err)
+		return diag.CounterpartyChainID
+	}
+
+	var reason string
+	switch {
+	case timeout.Height > 0 && (block.RevisionNumber > timeout.RevisionNumber ||
+		block.RevisionNumber == timeout.RevisionNumber && block.Height >= timeout.Height):
+		reason = fmt.Sprintf("%s passed the timeout height %d-%d", diag.CounterpartyChainID, timeout.RevisionNumber, timeout.Height)
+	case !timeout.Timestamp.IsZero() && !block.Time.Before(timeout.Timestamp):
+		reason = fmt.Sprintf("%s passed the timeout timestamp %s", diag.CounterpartyChainID, timeout.Timestamp.Format(time.RFC3339))
+	}
+	if reason == "" {
+		diag.check("timeout", CheckPassed, "packet can still be received")
+		return diag.CounterpartyChainID
+	}
+
+	diag.check("timeout", CheckFailed, reason)
+	diag.cause(Cause{
+		Code:      CauseTimedOut,
+		Summary:   "The packet timed out before it was received",
+		Detail:    reason + ", so it can only be timed out on the source chain.",
+		Action:    "Relay the timeout to refund the sender. A clear does this.",
+		Clearable: true,
+	})
+	return p.ChainID
+}
+
+// checkHalted checks that the chain the next relay goes to is producing blocks
+func (d *Diagnoser) checkHalted(ctx context.Context, diag diagnosis, chainID string) {
+	if chainID == "" {
+		diag.check("chain_liveness", CheckSkipped, "counterparty unknown")
+		return
+	}
+	block, err := d.chains.LatestBlock(ctx, chainID)
+	if err != nil {
+		diag.checkErr("chain_liveness", err)
+		return
+	}
+
+	age := diag.DiagnosedAt.Sub(block.Time)
+	if age < d.haltThreshold {
+		diag.check("chain_liveness", CheckPassed, fmt.Sprintf("%s at height %d", chainID, block.Height))
+		return
+	}
+	diag.check("chain_liveness", CheckFailed, fmt.Sprintf("%s last produced a block %s ago", chainID, age.Round(time.Second)))
+	diag.cause(Cause{
+		Code:    CauseChainHalted,
+		Summary: fmt.Sprintf("%s appears to be halted", chainID),
+		Detail:  fmt.Sprintf("Its latest block %d is from %s.", block.Height, block.Time.Format(time.RFC3339)),
+		Action:  fmt.Sprintf("Wait for %s to resume; the packet will be relayed once it does.", chainID),
+	})
+}
+
+// checkCoverage checks that some configured relayer's filter covers the channel
+func (d *Diagnoser) checkCoverage(diag diagnosis) {
+	relayers, err := coveringRelayers(d.configs, diag.Packet, diag.CounterpartyChainID, diag.CounterpartyChannelID)
+	if err != nil {
+		diag.checkErr("packet_filter", err)
+		return
+	}
+	diag.Relayers = relayers
+	if len(relayers) > 0 {
+		diag.check("packet_filter", CheckPassed, "relayed by "+strings.Join(relayers, ", "))
+		return
+	}
+
+	diag.check("packet_filter", CheckFailed, "no relayer filter includes the channel")
+	diag.cause(Cause{
+		Code:      CauseNotRelayed,
+		Summary:   fmt.Sprintf("No configured relayer relays %s on %s", diag.Packet.ChannelID, diag.Packet.ChainID),
+		Detail:    "The channel isn't in any relayer's packet filter, so it's only relayed if someone else picks it up.",
+		Action:    "Request a clear to relay it now. Operators can add the channel to a packet filter.",
+		Clearable: true,
+	})
+}
+
+// checkFunds checks the relayer accounts on the chain the next relay goes to
+func (d *Diagnoser) checkFunds(diag diagnosis, chainID string) {
+	if d.balances == nil || chainID == "" {
+		diag.check("relayer_funds", CheckSkipped, "no balance data")
+		return
+	}
+
+	var empty []string
+	checked := 0
+	for _, account := range d.balances.Balances("") {
+		if account.ChainID != chainID || !relayedBy(diag.Relayers, account.Relayer) {
+			continue
+		}
+		checked++
+		if account.Status == balances.StatusCritical {
+			empty = append(empty, fmt.Sprintf("%s %s (%s)", account.Relayer, account.Address, account.Reason))
+		}
+	}
+	if checked == 0 {
+		diag.check("relayer_funds", CheckSkipped, "no relayer account on "+chainID)
+		return
+	}
+	if len(empty) == 0 {
+		diag.check("relayer_funds", CheckPassed, "relayer accounts on "+chainID+" are funded")
+		return
+	}
+
+	diag.check("relayer_funds", CheckFailed, strings.Join(empty, "; "))
+	diag.cause(Cause{
+		Code:    CauseOutOfFunds,
+		Summary: fmt.Sprintf("The relayer account on %s is out of funds", chainID),
+		Detail:  "Relay transactions can't pay fees: " + strings.Join(empty, "; "),
+		Action:  fmt.Sprintf("The operator needs to top up the relayer account on %s.", chainID),
+	})
+}
+
+// relayedBy reports whether relayer is one of relayers. With no covering
+// relayers every account is relevant.
+func relayedBy(relayers []string, relayer string) bool {
+	if len(relayers) == 0 {
+		return true
+	}
+	for _, r := range relayers {
+		if r == relayer {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRelayErrors looks for recent relayer errors on the packet's channels
+func (d *Diagnoser) checkRelayErrors(diag diagnosis) {
+	if d.logs == nil {
+		diag.check("relay_errors", CheckSkipped, "no relayer logs")
+		return
+	}
+
+	failure := recentFailure(d.logs, diag.Diagnosis, diag.DiagnosedAt.Add(-d.logWindow))
+	if failure == nil {
+		diag.check("relay_errors", CheckPassed, "no recent relay errors on the channel")
+		return
+	}
+	diag.check("relay_errors", CheckFailed, failure.Detail)
+	diag.cause(*failure)
+}
+
+// conclude orders the causes and derives the overall verdict
+func (d *Diagnoser) conclude(diag diagnosis) {
+	sort.SliceStable(diag.Causes, func(i, j int) bool {
+		return causeOrder[diag.Causes[i].Code] < causeOrder[diag.Causes[j].Code]
+	})
+
+	if len(diag.Causes) == 0 {
+		diag.Clearable = true
+		switch diag.State {
+		case StateReceived:
+			diag.SuggestedAction = "The packet was received but the acknowledgement hasn't been relayed back. A clear will relay it."
+		default:
+			diag.SuggestedAction = "No blocking problem found; the packet is waiting for a relayer. A clear will relay it now."
+		}
+		return
+	}
+
+	diag.Cause = &diag.Causes[0]
+	diag.SuggestedAction = diag.Cause.Action
+	diag.Clearable = true
+	for _, cause := range diag.Causes {
+		if !cause.Clearable {
+			diag.Clearable = false
+		}
+	}
+}
+
+// packetRef normalises the identifier, which may use either the clearing
+// or the execution field names
+func packetRef(id clearing.PacketIdentifier) (PacketRef, error) {
+	ref := PacketRef{ChainID: id.ChainID, PortID: id.PortID, ChannelID: id.ChannelID, Sequence: id.Sequence}
+	if ref.ChainID == "" {
+		ref.ChainID = id.Chain
+	}
+	if ref.ChannelID == "" {
+		ref.ChannelID = id.Channel
+	}
+	if ref.PortID == "" {
+		ref.PortID = "transfer"
+	}
+	if ref.ChainID == "" || ref.ChannelID == "" || ref.Sequence == 0 {
+		return ref, ErrInvalidPacket
+	}
+	return ref, nil
+}
@@ -0,0 +1,520 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/balances"
+	"relayooor/api/pkg/clearing"
+	"relayooor/api/pkg/ibcclients"
+	"relayooor/api/pkg/logstream"
+	"relayooor/api/pkg/relayerconfig"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+const testHermesConfig = `[[chains]]
+id = 'osmosis-1'
+[chains.packet_filter]
+policy = 'allow'
+list = [['transfer', 'channel-0'], ['icahost', '*']]
+
+[[chains]]
+id = 'cosmoshub-4'
+`
+
+const testRlyConfig = `paths:
+  hub-osmo:
+    src:
+      chain-id: cosmoshub-4
+    dst:
+      chain-id: osmosis-1
+    src-channel-filter:
+      rule: allowlist
+      channel-list: [channel-141]
+`
+
+// fakeChains is a healthy osmosis-1 channel-0 <-> cosmoshub-4 channel-141
+// with packet 42 outstanding and not yet received
+type fakeChains struct {
+	channelState string
+	commitment   bool
+	receipt      bool
+	timeout      Timeout
+	blocks       map[string]Block
+	err          error
+}
+
+func newFakeChains() *fakeChains {
+	return &fakeChains{
+		channelState: "STATE_OPEN",
+		commitment:   true,
+		timeout:      Timeout{Timestamp: testNow.Add(time.Hour)},
+		blocks: map[string]Block{
+			"osmosis-1":   {RevisionNumber: 1, Height: 1000, Time: testNow.Add(-5 * time.Second)},
+			"cosmoshub-4": {RevisionNumber: 4, Height: 2000, Time: testNow.Add(-6 * time.Second)},
+		},
+	}
+}
+
+func (f *fakeChains) Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &ChannelEnd{State: f.channelState, CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-141"}, nil
+}
+
+func (f *fakeChains) PacketCommitment(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error) {
+	return f.commitment, f.err
+}
+
+func (f *fakeChains) PacketReceipt(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error) {
+	return f.receipt, f.err
+}
+
+func (f *fakeChains) PacketTimeout(ctx context.Context, chainID, portID, channelID string, sequence uint64) (*Timeout, error) {
+	timeout := f.timeout
+	return &timeout, f.err
+}
+
+func (f *fakeChains) LatestBlock(ctx context.Context, chainID string) (*Block, error) {
+	block, ok := f.blocks[chainID]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain %s", chainID)
+	}
+	return &block, nil
+}
+
+// fakeClients serves a client per chain, updated an hour ago
+type fakeClients struct {
+	expired map[string]bool
+	frozen  map[string]bool
+}
+
+func (f *fakeClients) ChannelClient(ctx context.Context, chainID, portID, channelID string) (*ibcclients.ClientState, error) {
+	counterparty := map[string]string{"osmosis-1": "cosmoshub-4", "cosmoshub-4": "osmosis-1"}[chainID]
+	return &ibcclients.ClientState{
+		ClientID:            "07-tendermint-" + chainID,
+		CounterpartyChainID: counterparty,
+		TrustingPeriod:      14 * 24 * time.Hour,
+		Frozen:              f.frozen[chainID],
+	}, nil
+}
+
+func (f *fakeClients) ConsensusTimestamp(ctx context.Context, chainID, clientID string, height ibcclients.Height) (time.Time, error) {
+	if f.expired[chainID] {
+		return testNow.Add(-15 * 24 * time.Hour), nil
+	}
+	return testNow.Add(-time.Hour), nil
+}
+
+type fakeConfigs map[string]string
+
+func (f fakeConfigs) Current(relayer string) (string, error) {
+	return f[relayer], nil
+}
+
+type fakeBalances []balances.AccountBalance
+
+func (f fakeBalances) Balances(relayer string) []balances.AccountBalance {
+	return f
+}
+
+type fakeLogs []*logstream.Entry
+
+func (f fakeLogs) Recent(filter logstream.Filter, afterID uint64, limit int) []*logstream.Entry {
+	var entries []*logstream.Entry
+	for _, entry := range f {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func newTestDiagnoser(chains *fakeChains, clients *fakeClients, configs fakeConfigs) *Diagnoser {
+	d := NewDiagnoser(chains, clients, configs, zap.NewNop())
+	d.now = func() time.Time { return testNow }
+	return d
+}
+
+var testPacket = clearing.PacketIdentifier{Chain: "osmosis-1", Channel: "channel-0", Sequence: 42}
+
+func testConfigs() fakeConfigs {
+	return fakeConfigs{relayerconfig.RelayerHermes: testHermesConfig, relayerconfig.RelayerRly: testRlyConfig}
+}
+
+func causeCodes(d *Diagnosis) []string {
+	codes := []string{}
+	for _, cause := range d.Causes {
+		codes = append(codes, cause.Code)
+	}
+	return codes
+}
+
+func TestDiagnoseHealthyPacket(t *testing.T) {
+	d := newTestDiagnoser(newFakeChains(), &fakeClients{}, testConfigs())
+	d.UseLogs(fakeLogs{})
+
+	diag, err := d.Diagnose(context.Background(), testPacket)
+	require.NoError(t, err)
+
+	assert.Equal(t, PacketRef{ChainID: "osmosis-1", PortID: "transfer", ChannelID: "channel-0", Sequence: 42}, diag.Packet)
+	assert.Equal(t, "cosmoshub-4", diag.CounterpartyChainID)
+	assert.Equal(t, "channel-141", diag.CounterpartyChannelID)
+	assert.Equal(t, StatePending, diag.State)
+	assert.Equal(t, []string{"hermes", "rly"}, diag.Relayers)
+	assert.Empty(t, diag.Causes)
+	assert.Nil(t, diag.Cause)
+	assert.True(t, diag.Clearable)
+	assert.Contains(t, diag.SuggestedAction, "waiting for a relayer")
+
+	results := map[string]string{}
+	for _, check := range diag.Checks {
+		results[check.Name] = check.Result
+	}
+	assert.Equal(t, map[string]string{
+		"channel":            CheckPassed,
+		"packet_commitment":  CheckPassed,
+		"packet_receipt":     CheckPassed,
+		"source_client":      CheckPassed,
+		"destination_client": CheckPassed,
+		"timeout":            CheckPassed,
+		"chain_liveness":     CheckPassed,
+		"packet_filter":      CheckPassed,
+		"relayer_funds":      CheckSkipped,
+		"relay_errors":       CheckPassed,
+	}, results)
+}
+
+func TestDiagnoseCauses(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(*fakeChains, *fakeClients)
+		configs   fakeConfigs
+		causes    []string
+		clearable bool
+		state     string
+	}{
+		{
+			name:   "already relayed",
+			setup:  func(c *fakeChains, _ *fakeClients) { c.commitment = false },
+			causes: []string{CauseAlreadyRelayed},
+			state:  StateCompleted,
+		},
+		{
+			name:   "channel closed",
+			setup:  func(c *fakeChains, _ *fakeClients) { c.channelState = "STATE_CLOSED" },
+			causes: []string{CauseChannelClosed},
+			state:  StatePending,
+		},
+		{
+			name:   "destination client expired",
+			setup:  func(_ *fakeChains, cl *fakeClients) { cl.expired = map[string]bool{"cosmoshub-4": true} },
+			causes: []string{CauseClientExpired},
+			state:  StatePending,
+		},
+		{
+			name:   "source client frozen",
+			setup:  func(_ *fakeChains, cl *fakeClients) { cl.frozen = map[string]bool{"osmosis-1": true} },
+			causes: []string{CauseClientFrozen},
+			state:  StatePending,
+		},
+		{
+			name: "timed out by height",
+			setup: func(c *fakeChains, _ *fakeClients) {
+				c.timeout = Timeout{RevisionNumber: 4, Height: 1999}
+			},
+			causes:    []string{CauseTimedOut},
+			clearable: true,
+			state:     StatePending,
+		},
+		{
+			name: "timed out by timestamp",
+			setup: func(c *fakeChains, _ *fakeClients) {
+				c.timeout = Timeout{RevisionNumber: 4, Height: 5000, Timestamp: testNow.Add(-time.Minute)}
+			},
+			causes:    []string{CauseTimedOut},
+			clearable: true,
+			state:     StatePending,
+		},
+		{
+			name: "destination halted",
+			setup: func(c *fakeChains, _ *fakeClients) {
+				c.blocks["cosmoshub-4"] = Block{RevisionNumber: 4, Height: 2000, Time: testNow.Add(-time.Hour)}
+			},
+			causes: []string{CauseChainHalted},
+			state:  StatePending,
+		},
+		{
+			name: "received, source halted",
+			setup: func(c *fakeChains, _ *fakeClients) {
+				c.receipt = true
+				c.blocks["osmosis-1"] = Block{RevisionNumber: 1, Height: 1000, Time: testNow.Add(-time.Hour)}
+			},
+			causes: []string{CauseChainHalted},
+			state:  StateReceived,
+		},
+		{
+			name:      "not in any filter",
+			configs:   fakeConfigs{relayerconfig.RelayerHermes: "[[chains]]\nid = 'juno-1'\n"},
+			causes:    []string{CauseNotRelayed},
+			clearable: true,
+			state:     StatePending,
+		},
+		{
+			name: "closed and expired",
+			setup: func(c *fakeChains, cl *fakeClients) {
+				c.channelState = "STATE_CLOSED"
+				cl.expired = map[string]bool{"cosmoshub-4": true}
+				c.timeout = Timeout{RevisionNumber: 4, Height: 1}
+			},
+			causes: []string{CauseChannelClosed, CauseClientExpired, CauseTimedOut},
+			state:  StatePending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains, clients := newFakeChains(), &fakeClients{}
+			if tt.setup != nil {
+				tt.setup(chains, clients)
+			}
+			configs := tt.configs
+			if configs == nil {
+				configs = testConfigs()
+			}
+
+			diag, err := newTestDiagnoser(chains, clients, configs).Diagnose(context.Background(), testPacket)
+			require.NoError(t, err)
+			assert.Equal(t, tt.causes, causeCodes(diag))
+			assert.Equal(t, tt.clearable, diag.Clearable)
+			assert.Equal(t, tt.state, diag.State)
+			require.NotNil(t, diag.Cause)
+			assert.Equal(t, tt.causes[0], diag.Cause.Code)
+			assert.Equal(t, diag.Cause.Action, diag.SuggestedAction)
+		})
+	}
+}
+
+func TestDiagnoseRelayerFunds(t *testing.T) {
+	d := newTestDiagnoser(newFakeChains(), &fakeClients{}, testConfigs())
+	d.UseBalances(fakeBalances{
+		{Account: balances.Account{Relayer: "hermes", ChainID: "cosmoshub-4", Address: "cosmos1hermes"}, Status: balances.StatusCritical, Reason: "balance is empty"},
+		{Account: balances.Account{Relayer: "hermes", ChainID: "osmosis-1", Address: "osmo1hermes"}, Status: balances.StatusOK},
+	})
+
+	diag, err := d.Diagnose(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.Equal(t, []string{CauseOutOfFunds}, causeCodes(diag))
+	assert.False(t, diag.Clearable)
+	assert.Contains(t, diag.Cause.Detail, "cosmos1hermes")
+
+	// Once received, the ack goes to osmosis where the account is funded
+	chains := newFakeChains()
+	chains.receipt = true
+	d.chains = chains
+	diag, err = d.Diagnose(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.Empty(t, diag.Causes)
+}
+
+func TestDiagnoseRelayErrors(t *testing.T) {
+	d := newTestDiagnoser(newFakeChains(), &fakeClients{}, testConfigs())
+	d.UseLogs(fakeLogs{
+		{
+			Timestamp: testNow.Add(-2 * time.Hour), Relayer: "hermes", Level: logstream.LevelError,
+			Chains: []string{"cosmoshub-4"}, Channels: []string{"channel-141"},
+			Message: "too old to matter", Raw: "too old to matter",
+		},
+		{
+			Timestamp: testNow.Add(-10 * time.Minute), Relayer: "hermes", Level: logstream.LevelWarn,
+			Chains: []string{"cosmoshub-4"}, Channels: []string{"channel-141"},
+			Message: "failed to send: account sequence mismatch", Raw: "failed to send: account sequence mismatch",
+		},
+		{
+			Timestamp: testNow.Add(-20 * time.Minute), Relayer: "hermes", Level: logstream.LevelError,
+			Chains: []string{"osmosis-1"}, Channels: []string{"channel-0"},
+			Message: "recv packet failed: out of gas in location: WriteFlat",
+			Raw:     "ERROR packet{src_chain=osmosis-1 src_channel=channel-0 sequences=[41, 42]}: recv packet failed: out of gas in location: WriteFlat",
+		},
+		{
+			Timestamp: testNow.Add(-time.Minute), Relayer: "hermes", Level: logstream.LevelWarn,
+			Chains: []string{"osmosis-1"}, Channels: []string{"channel-0"},
+			Message: "packet messages are redundant", Raw: "packet messages are redundant",
+		},
+	})
+
+	diag, err := d.Diagnose(context.Background(), testPacket)
+	require.NoError(t, err)
+	require.Equal(t, []string{CauseRelayFailing}, causeCodes(diag))
+
+	// The error naming the sequence wins over the newer channel-wide one
+	assert.Equal(t, "Relay transactions are running out of gas", diag.Cause.Summary)
+	assert.Contains(t, diag.Cause.Detail, "for this packet")
+	assert.False(t, diag.Clearable)
+}
+
+func TestDiagnoseInvalidPacket(t *testing.T) {
+	d := newTestDiagnoser(newFakeChains(), &fakeClients{}, testConfigs())
+	_, err := d.Diagnose(context.Background(), clearing.PacketIdentifier{Chain: "osmosis-1", Sequence: 1})
+	assert.ErrorIs(t, err, ErrInvalidPacket)
+}
+
+func TestDiagnoseQueryErrors(t *testing.T) {
+	chains := newFakeChains()
+	chains.err = errors.New("node unreachable")
+	diag, err := newTestDiagnoser(chains, &fakeClients{}, testConfigs()).Diagnose(context.Background(), testPacket)
+	require.NoError(t, err)
+
+	// Nothing conclusive, so the packet is given the benefit of the doubt
+	assert.Empty(t, diag.Causes)
+	assert.True(t, diag.Clearable)
+	assert.Equal(t, Check{Name: "channel", Result: CheckError, Detail: "node unreachable"}, diag.Checks[0])
+}
+
+func TestCheckClearable(t *testing.T) {
+	chains := newFakeChains()
+	d := newTestDiagnoser(chains, &fakeClients{}, testConfigs())
+	packets := []clearing.PacketIdentifier{testPacket, {ChainID: "osmosis-1", ChannelID: "channel-0", Sequence: 43}}
+
+	assert.NoError(t, d.CheckClearable(context.Background(), packets))
+
+	chains.commitment = false
+	err := d.CheckClearable(context.Background(), packets)
+	assert.ErrorIs(t, err, clearing.ErrPacketNotClearable)
+	assert.Contains(t, err.Error(), "osmosis-1/channel-0/42")
+}
+
+func TestCoveringRelayers(t *testing.T) {
+	configs := testConfigs()
+	tests := []struct {
+		name                  string
+		packet                PacketRef
+		counterparty, channel string
+		want                  []string
+	}{
+		{"allow list", PacketRef{ChainID: "osmosis-1", PortID: "transfer", ChannelID: "channel-0"}, "cosmoshub-4", "channel-141", []string{"hermes", "rly"}},
+		{"wildcard", PacketRef{ChainID: "osmosis-1", PortID: "icahost", ChannelID: "channel-9"}, "cosmoshub-4", "channel-300", []string{"hermes"}},
+		{"filtered out", PacketRef{ChainID: "osmosis-1", PortID: "transfer", ChannelID: "channel-1"}, "cosmoshub-4", "channel-2", []string{}},
+		{"no filter, rly path source", PacketRef{ChainID: "cosmoshub-4", PortID: "transfer", ChannelID: "channel-141"}, "osmosis-1", "channel-0", []string{"hermes", "rly"}},
+		{"counterparty not in hermes", PacketRef{ChainID: "cosmoshub-4", PortID: "transfer", ChannelID: "channel-5"}, "juno-1", "channel-1", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relayers, err := coveringRelayers(configs, tt.packet, tt.counterparty, tt.channel)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, relayers)
+		})
+	}
+
+	denied := fakeConfigs{relayerconfig.RelayerHermes: `[[chains]]
+id = 'osmosis-1'
+[chains.packet_filter]
+policy = 'deny'
+list = [['transfer', 'channel-0']]
+`}
+	relayers, err := coveringRelayers(denied, PacketRef{ChainID: "osmosis-1", PortID: "transfer", ChannelID: "channel-0"}, "", "")
+	require.NoError(t, err)
+	assert.Empty(t, relayers)
+	relayers, err = coveringRelayers(denied, PacketRef{ChainID: "osmosis-1", PortID: "transfer", ChannelID: "channel-1"}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hermes"}, relayers)
+}
+
+func TestRESTQuerier(t *testing.T) {
+	legacy := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer":
+			w.Write([]byte(`{"channel":{"state":"STATE_OPEN","counterparty":{"port_id":"transfer","channel_id":"channel-141"}}}`))
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer/packet_commitments/42":
+			w.Write([]byte(`{"commitment":"q83vEjRWeJA="}`))
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer/packet_commitments/43":
+			http.NotFound(w, r)
+		case "/ibc/core/channel/v1/channels/channel-0/ports/transfer/packet_receipts/42":
+			w.Write([]byte(`{"received":true}`))
+		case "/cosmos/tx/v1beta1/txs":
+			// An SDK v0.47 node rejects the query parameter
+			if r.URL.Query().Get("query") != "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			legacy++
+			assert.Len(t, r.URL.Query()["events"], 3)
+			w.Write([]byte(`{"tx_responses":[{"events":[
+				{"type":"send_packet","attributes":[
+					{"key":"packet_sequence","value":"42"},
+					{"key":"packet_src_channel","value":"channel-0"},
+					{"key":"packet_timeout_height","value":"4-21000000"},
+					{"key":"packet_timeout_timestamp","value":"1772366400000000000"}]}]}]}`))
+		case "/cosmos/base/tendermint/v1beta1/blocks/latest":
+			w.Write([]byte(`{"block":{"header":{"height":"20999000","time":"2026-03-01T11:59:55Z"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	q := NewRESTQuerier(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RESTEndpoint: server.URL},
+	}})
+	ctx := context.Background()
+
+	channel, err := q.Channel(ctx, "cosmoshub-4", "transfer", "channel-0")
+	require.NoError(t, err)
+	assert.Equal(t, &ChannelEnd{State: "STATE_OPEN", CounterpartyPortID: "transfer", CounterpartyChannelID: "channel-141"}, channel)
+
+	pending, err := q.PacketCommitment(ctx, "cosmoshub-4", "transfer", "channel-0", 42)
+	require.NoError(t, err)
+	assert.True(t, pending)
+	pending, err = q.PacketCommitment(ctx, "cosmoshub-4", "transfer", "channel-0", 43)
+	require.NoError(t, err)
+	assert.False(t, pending)
+
+	received, err := q.PacketReceipt(ctx, "cosmoshub-4", "transfer", "channel-0", 42)
+	require.NoError(t, err)
+	assert.True(t, received)
+
+	timeout, err := q.PacketTimeout(ctx, "cosmoshub-4", "transfer", "channel-0", 42)
+	require.NoError(t, err)
+	assert.Equal(t, 1, legacy)
+	assert.Equal(t, &Timeout{RevisionNumber: 4, Height: 21000000, Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}, timeout)
+
+	block, err := q.LatestBlock(ctx, "cosmoshub-4")
+	require.NoError(t, err)
+	assert.Equal(t, &Block{RevisionNumber: 4, Height: 20999000, Time: time.Date(2026, 3, 1, 11, 59, 55, 0, time.UTC)}, block)
+}
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api/v1")
+	api.GET("/packets/stuck/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	api.GET("/packets/channel/stream", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	NewHandlers(newTestDiagnoser(newFakeChains(), &fakeClients{}, testConfigs()), nil, zap.NewNop()).RegisterRoutes(api)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/osmosis-1/channel-0/42/diagnosis", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"pending"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/osmosis-1/channel-0/abc/diagnosis", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/stuck/stream", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
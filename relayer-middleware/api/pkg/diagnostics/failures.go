@@ -0,0 +1,136 @@
+package diagnostics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"relayooor/api/pkg/logstream"
+)
+
+// failureKind maps a relayer error message to what it means for the packet
+type failureKind struct {
+	patterns []string
+	summary  string
+	action   string
+	// clearable is false when a clear through the same relayer would hit
+	// the same error
+	clearable bool
+}
+
+var failureKinds = []failureKind{
+	{
+		patterns: []string{"out of gas"},
+		summary:  "Relay transactions are running out of gas",
+		action:   "The operator needs to raise max_gas or gas_multiplier for the chain.",
+	},
+	{
+		patterns: []string{"insufficient fee"},
+		summary:  "Relay transactions pay too little in fees",
+		action:   "The operator needs to raise the gas price for the chain.",
+	},
+	{
+		patterns: []string{"insufficient funds"},
+		summary:  "The relayer account can't pay for relay transactions",
+		action:   "The operator needs to top up the relayer account.",
+	},
+	{
+		patterns:  []string{"account sequence mismatch", "incorrect account sequence"},
+		summary:   "Relay transactions fail with account sequence mismatches",
+		action:    "Usually transient. If it persists, make sure only one process uses the relayer key and restart the relayer.",
+		clearable: true,
+	},
+	{
+		patterns:  []string{"connection refused", "deadline exceeded", "context deadline", "503 service unavailable", "502 bad gateway", "timed out"},
+		summary:   "The relayer can't reach a chain's RPC node",
+		action:    "Check the RPC and gRPC endpoints configured for the chains. A clear retries through the current endpoints.",
+		clearable: true,
+	},
+}
+
+// genericFailure is used for relay errors that match no known kind
+var genericFailure = failureKind{
+	summary:   "Recent relay attempts on the channel are failing",
+	action:    "Request a clear to retry. Operators should check the relayer logs for the channel.",
+	clearable: true,
+}
+
+// recentFailure returns a relay_failing cause for the most relevant relayer
+// error on the packet's channels since since, preferring errors that mention
+// the sequence
+func recentFailure(logs LogReader, diag *Diagnosis, since time.Time) *Cause {
+	p := diag.Packet
+	sequence := regexp.MustCompile(fmt.Sprintf(`(?i)seq[a-z_]*\W{1,3}(?:%d\b|\[[^\]]*\b%d\b)`, p.Sequence, p.Sequence))
+
+	var channelError, sequenceError *logstream.Entry
+	check := func(chainID, channelID string) {
+		if chainID == "" || channelID == "" {
+			return
+		}
+		filter := logstream.Filter{Level: logstream.LevelWarn, Chain: chainID, Channel: channelID}
+		for _, entry := range logs.Recent(filter, 0, 500) {
+			if entry.Timestamp.Before(since) || !isRelayError(entry) {
+				continue
+			}
+			if sequence.MatchString(entry.Raw) {
+				sequenceError = newer(sequenceError, entry)
+			} else {
+				channelError = newer(channelError, entry)
+			}
+		}
+	}
+	check(p.ChainID, p.ChannelID)
+	check(diag.CounterpartyChainID, diag.CounterpartyChannelID)
+
+	entry, scope := sequenceError, "for this packet"
+	if entry == nil {
+		entry, scope = channelError, "on the channel"
+	}
+	if entry == nil {
+		return nil
+	}
+
+	kind := classifyFailure(entry.Message + " " + entry.Raw)
+	return &Cause{
+		Code:      CauseRelayFailing,
+		Summary:   kind.summary,
+		Detail:    fmt.Sprintf("%s reported %s at %s: %s", entry.Relayer, scope, entry.Timestamp.Format(time.RFC3339), entry.Message),
+		Action:    kind.action,
+		Clearable: kind.clearable,
+	}
+}
+
+// isRelayError reports whether a log entry is a relay failure. Warnings only
+// count when they match a known failure, since Hermes warns about a lot of
+// harmless things.
+func isRelayError(entry *logstream.Entry) bool {
+	text := strings.ToLower(entry.Message + " " + entry.Raw)
+	// Another relayer delivering first isn't a failure
+	if strings.Contains(text, "packet messages are redundant") || strings.Contains(text, "already received") {
+		return false
+	}
+	if entry.Level == logstream.LevelError {
+		return true
+	}
+	return classifyFailure(text).summary != genericFailure.summary
+}
+
+func classifyFailure(text string) failureKind {
+	text = strings.ToLower(text)
+	for _, kind := range failureKinds {
+		for _, pattern := range kind.patterns {
+			if strings.Contains(text, pattern) {
+				return kind
+			}
+		}
+	}
+	return genericFailure
+}
+
+func newer(current, entry *logstream.Entry) *logstream.Entry {
+	if current == nil || entry.Timestamp.After(current.Timestamp) {
+		return entry
+	}
+	return current
+}
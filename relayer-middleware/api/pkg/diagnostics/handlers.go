@@ -0,0 +1,119 @@
+package diagnostics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/clearing"
+)
+
+// maxBatch is the most packets one POST /packets/diagnose request may carry
+const maxBatch = 100
+
+// Handlers exposes packet diagnoses and timelines
+type Handlers struct {
+	diagnoser *Diagnoser
+	timelines *Timelines
+	logger    *zap.Logger
+}
+
+// NewHandlers creates diagnostics handlers
+func NewHandlers(diagnoser *Diagnoser, timelines *Timelines, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		diagnoser: diagnoser,
+		timelines: timelines,
+		logger:    logger.With(zap.String("component", "diagnostics_handlers")),
+	}
+}
+
+// RegisterRoutes registers the diagnosis and timeline routes
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/packets/:chain/:channel/:sequence/diagnosis", h.GetDiagnosis)
+	router.GET("/packets/:chain/:channel/:sequence/timeline", h.GetTimeline)
+	router.POST("/packets/diagnose", h.DiagnosePackets)
+}
+
+// GetDiagnosis handles GET /packets/:chain/:channel/:sequence/diagnosis.
+// The port defaults to transfer and can be set with ?port=.
+func (h *Handlers) GetDiagnosis(c *gin.Context) {
+	sequence, err := strconv.ParseUint(c.Param("sequence"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sequence"})
+		return
+	}
+
+	diagnosis, err := h.diagnoser.Diagnose(c.Request.Context(), pathPacket(c, sequence))
+	if h.writeError(c, err, "Failed to diagnose packet") {
+		return
+	}
+	c.JSON(http.StatusOK, diagnosis)
+}
+
+// GetTimeline handles GET /packets/:chain/:channel/:sequence/timeline. The
+// port defaults to transfer and can be set with ?port=.
+func (h *Handlers) GetTimeline(c *gin.Context) {
+	sequence, err := strconv.ParseUint(c.Param("sequence"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sequence"})
+		return
+	}
+
+	timeline, err := h.timelines.Timeline(c.Request.Context(), pathPacket(c, sequence))
+	if h.writeError(c, err, "Failed to build packet timeline") {
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// DiagnosePackets handles POST /packets/diagnose for up to 100 packets
+func (h *Handlers) DiagnosePackets(c *gin.Context) {
+	var req struct {
+		Packets []clearing.PacketIdentifier `json:"packets"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Packets) == 0 || len(req.Packets) > maxBatch {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "between 1 and 100 packets are required"})
+		return
+	}
+
+	diagnoses := make([]*Diagnosis, 0, len(req.Packets))
+	for _, packet := range req.Packets {
+		diagnosis, err := h.diagnoser.Diagnose(c.Request.Context(), packet)
+		if h.writeError(c, err, "Failed to diagnose packet") {
+			return
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+	c.JSON(http.StatusOK, gin.H{"diagnoses": diagnoses})
+}
+
+func pathPacket(c *gin.Context, sequence uint64) clearing.PacketIdentifier {
+	return clearing.PacketIdentifier{
+		Chain:    c.Param("chain"),
+		Channel:  c.Param("channel"),
+		PortID:   c.Query("port"),
+		Sequence: sequence,
+	}
+}
+
+func (h *Handlers) writeError(c *gin.Context, err error, message string) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrInvalidPacket):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrPacketNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error(message, zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+	return true
+}
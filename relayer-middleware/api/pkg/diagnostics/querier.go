@@ -0,0 +1,278 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// errNotFound is returned by RESTQuerier.get for 404 responses, which the
+// IBC queries use for missing commitments and receipts
+var errNotFound = errors.New("not found")
+
+// RESTQuerier reads channel and packet state over each chain's REST API
+type RESTQuerier struct {
+	client *endpoints.Client
+}
+
+// NewRESTQuerier creates a querier using the REST endpoints of the registry
+func NewRESTQuerier(registry *config.ChainRegistry) *RESTQuerier {
+	return &RESTQuerier{
+		client: endpoints.NewClient(registry, 10*time.Second),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (q *RESTQuerier) UseEndpoints(pools *endpoints.Pools) {
+	q.client.UsePools(pools)
+}
+
+// Channel implements ChainQuerier
+func (q *RESTQuerier) Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error) {
+	var body struct {
+		Channel struct {
+			State        string `json:"state"`
+			Counterparty struct {
+				PortID    string `json:"port_id"`
+				ChannelID string `json:"channel_id"`
+			} `json:"counterparty"`
+		} `json:"channel"`
+	}
+	if err := q.get(ctx, chainID, channelPath(portID, channelID), &body); err != nil {
+		return nil, err
+	}
+	return &ChannelEnd{
+		State:                 body.Channel.State,
+		CounterpartyPortID:    body.Channel.Counterparty.PortID,
+		CounterpartyChannelID: body.Channel.Counterparty.ChannelID,
+	}, nil
+}
+
+// PacketCommitment implements ChainQuerier
+func (q *RESTQuerier) PacketCommitment(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error) {
+	var body struct {
+		Commitment string `json:"commitment"`
+	}
+	err := q.get(ctx, chainID, fmt.Sprintf("%s/packet_commitments/%d", channelPath(portID, channelID), sequence), &body)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	return body.Commitment != "", err
+}
+
+// PacketReceipt implements ChainQuerier
+func (q *RESTQuerier) PacketReceipt(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error) {
+	var body struct {
+		Received bool `json:"received"`
+	}
+	err := q.get(ctx, chainID, fmt.Sprintf("%s/packet_receipts/%d", channelPath(portID, channelID), sequence), &body)
+	if errors.Is(err, errNotFound) {
+		return false, nil
+	}
+	return body.Received, err
+}
+
+// PacketTimeout implements ChainQuerier
+func (q *RESTQuerier) PacketTimeout(ctx context.Context, chainID, portID, channelID string, sequence uint64) (*Timeout, error) {
+	txs, err := q.PacketTxs(ctx, chainID, EventSendPacket, portID, channelID, sequence)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		if tx.Attributes != nil {
+			return parseTimeout(tx.Attributes["packet_timeout_height"], tx.Attributes["packet_timeout_timestamp"])
+		}
+	}
+	return nil, fmt.Errorf("send_packet event for %s/%s/%d not found on %s", portID, channelID, sequence, chainID)
+}
+
+// PacketTxs implements TxSearcher. SDK v0.50 chains take the tx search as a
+// query parameter, older ones as repeated events parameters.
+func (q *RESTQuerier) PacketTxs(ctx context.Context, chainID, event, portID, channelID string, sequence uint64) ([]PacketTx, error) {
+	side := "src"
+	if event == EventRecvPacket || event == EventWriteAck {
+		side = "dst"
+	}
+	conditions := []string{
+		fmt.Sprintf("%s.packet_%s_port='%s'", event, side, portID),
+		fmt.Sprintf("%s.packet_%s_channel='%s'", event, side, channelID),
+		fmt.Sprintf("%s.packet_sequence='%d'", event, sequence),
+	}
+
+	var body struct {
+		TxResponses []txResponse `json:"tx_responses"`
+	}
+	query := url.Values{"query": {strings.Join(conditions, " AND ")}, "order_by": {"ORDER_BY_ASC"}, "pagination.limit": {"20"}}
+	err := q.get(ctx, chainID, "/cosmos/tx/v1beta1/txs?"+query.Encode(), &body)
+	if err != nil && !errors.Is(err, errNotFound) {
+		legacy := url.Values{"events": conditions, "order_by": {"ORDER_BY_ASC"}, "pagination.limit": {"20"}}
+		err = q.get(ctx, chainID, "/cosmos/tx/v1beta1/txs?"+legacy.Encode(), &body)
+	}
+	if errors.Is(err, errNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	want := strconv.FormatUint(sequence, 10)
+	txs := make([]PacketTx, 0, len(body.TxResponses))
+	for _, resp := range body.TxResponses {
+		tx := resp.packetTx()
+		for _, e := range resp.Events {
+			attrs := e.attributes()
+			if e.Type == event && attrs["packet_sequence"] == want && attrs["packet_"+side+"_channel"] == channelID {
+				tx.Attributes = attrs
+				break
+			}
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// Tx implements TxSearcher
+func (q *RESTQuerier) Tx(ctx context.Context, chainID, hash string) (*PacketTx, error) {
+	var body struct {
+		TxResponse txResponse `json:"tx_response"`
+	}
+	if err := q.get(ctx, chainID, "/cosmos/tx/v1beta1/txs/"+url.PathEscape(hash), &body); err != nil {
+		return nil, err
+	}
+	tx := body.TxResponse.packetTx()
+	return &tx, nil
+}
+
+// txResponse is the part of a cosmos.tx.v1beta1 TxResponse the queries read
+type txResponse struct {
+	Height    string    `json:"height"`
+	TxHash    string    `json:"txhash"`
+	Code      uint32    `json:"code"`
+	RawLog    string    `json:"raw_log"`
+	Timestamp time.Time `json:"timestamp"`
+	Events    []txEvent `json:"events"`
+	Tx        struct {
+		Body struct {
+			Messages []struct {
+				Signer string `json:"signer"`
+			} `json:"messages"`
+		} `json:"body"`
+	} `json:"tx"`
+}
+
+func (r txResponse) packetTx() PacketTx {
+	tx := PacketTx{Hash: r.TxHash, Time: r.Timestamp, Code: r.Code}
+	tx.Height, _ = strconv.ParseUint(r.Height, 10, 64)
+	if r.Code != 0 {
+		tx.RawLog = r.RawLog
+	}
+	for _, msg := range r.Tx.Body.Messages {
+		if msg.Signer != "" {
+			tx.Signer = msg.Signer
+			break
+		}
+	}
+	return tx
+}
+
+type txEvent struct {
+	Type       string `json:"type"`
+	Attributes []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	} `json:"attributes"`
+}
+
+func (e txEvent) attributes() map[string]string {
+	attrs := make(map[string]string, len(e.Attributes))
+	for _, attr := range e.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+// LatestBlock implements ChainQuerier
+func (q *RESTQuerier) LatestBlock(ctx context.Context, chainID string) (*Block, error) {
+	var body struct {
+		Block struct {
+			Header struct {
+				Height string    `json:"height"`
+				Time   time.Time `json:"time"`
+			} `json:"header"`
+		} `json:"block"`
+	}
+	if err := q.get(ctx, chainID, "/cosmos/base/tendermint/v1beta1/blocks/latest", &body); err != nil {
+		return nil, err
+	}
+	height, err := strconv.ParseUint(body.Block.Header.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latest block height %q on %s", body.Block.Header.Height, chainID)
+	}
+	return &Block{RevisionNumber: revisionNumber(chainID), Height: height, Time: body.Block.Header.Time}, nil
+}
+
+func (q *RESTQuerier) get(ctx context.Context, chainID, path string, out interface{}) error {
+	resp, err := q.client.Get(ctx, chainID, endpoints.REST, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return errNotFound
+	default:
+		return fmt.Errorf("query %s on %s returned %d", strings.SplitN(path, "?", 2)[0], chainID, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func channelPath(portID, channelID string) string {
+	return fmt.Sprintf("/ibc/core/channel/v1/channels/%s/ports/%s", url.PathEscape(channelID), url.PathEscape(portID))
+}
+
+// parseTimeout parses the send_packet timeout attributes: a height like
+// "1-18234567" ("0-0" when unset) and a timestamp in Unix nanoseconds
+func parseTimeout(height, timestamp string) (*Timeout, error) {
+	timeout := &Timeout{}
+	if revision, value, ok := strings.Cut(height, "-"); ok {
+		timeout.RevisionNumber, _ = strconv.ParseUint(revision, 10, 64)
+		timeout.Height, _ = strconv.ParseUint(value, 10, 64)
+	}
+	if timestamp != "" && timestamp != "0" {
+		nanos, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packet timeout timestamp %q", timestamp)
+		}
+		timeout.Timestamp = time.Unix(0, nanos).UTC()
+	}
+	return timeout, nil
+}
+
+// revisionNumber parses the revision from a chain ID in the {name}-{N}
+// format, 0 otherwise
+func revisionNumber(chainID string) uint64 {
+	i := strings.LastIndex(chainID, "-")
+	if i < 0 {
+		return 0
+	}
+	n, err := strconv.ParseUint(chainID[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,419 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/clearing"
+	"relayooor/api/pkg/ibcclients"
+)
+
+var ErrPacketNotFound = errors.New("no events found for packet")
+
+// maxAttemptLookups bounds the transactions looked up per timeline to tell
+// failed relay attempts from redundant ones
+const maxAttemptLookups = 20
+
+// eventRank orders events sharing a timestamp, e.g. a recv and the
+// write_acknowledgement from the same transaction
+var eventRank = map[string]int{
+	EventSendPacket:        0,
+	EventClearingPaid:      1,
+	EventRecvPacket:        2,
+	EventWriteAck:          3,
+	EventAckPacket:         4,
+	EventTimeout:           4,
+	EventClearingCompleted: 5,
+	EventClearingFailed:    5,
+	EventRefund:            6,
+}
+
+// attemptEvents maps Chainpulse message types to the events they cause
+var attemptEvents = map[string]string{
+	"/ibc.core.channel.v1.MsgRecvPacket":      EventRecvPacket,
+	"/ibc.core.channel.v1.MsgAcknowledgement": EventAckPacket,
+	"/ibc.core.channel.v1.MsgTimeout":         EventTimeout,
+	"/ibc.core.channel.v1.MsgTimeoutOnClose":  EventTimeout,
+}
+
+// Timelines reconstructs the life of a packet from chain queries,
+// Chainpulse's record of relay attempts and the clearing history
+type Timelines struct {
+	chains     ChainQuerier
+	txs        TxSearcher
+	clients    ibcclients.Querier
+	chainpulse PacketDetailsReader
+	history    ClearingHistory
+	now        func() time.Time
+	logger     *zap.Logger
+}
+
+// NewTimelines creates a timeline builder
+func NewTimelines(chains ChainQuerier, txs TxSearcher, clients ibcclients.Querier, logger *zap.Logger) *Timelines {
+	return &Timelines{
+		chains:  chains,
+		txs:     txs,
+		clients: clients,
+		now:     time.Now,
+		logger:  logger.With(zap.String("component", "packet_timeline")),
+	}
+}
+
+// UseChainpulse adds Chainpulse's relay attempts, including failed and
+// redundant ones, to timelines
+func (t *Timelines) UseChainpulse(reader PacketDetailsReader) {
+	t.chainpulse = reader
+}
+
+// UseClearingHistory adds clearing operations and refunds to timelines
+func (t *Timelines) UseClearingHistory(history ClearingHistory) {
+	t.history = history
+}
+
+// timeline accumulates the events of one packet. Relay events are keyed by
+// type and tx hash so each source can add to what the others found.
+type timeline struct {
+	*Timeline
+	mu    sync.Mutex
+	relay map[string]int
+}
+
+func (tl *timeline) warn(format string, args ...interface{}) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.Warnings = append(tl.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (tl *timeline) add(events ...TimelineEvent) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	for _, event := range events {
+		if event.TxHash != "" && event.Source != SourceClearing {
+			tl.relay[relayKey(event.Type, event.TxHash)] = len(tl.Events)
+		}
+		tl.Events = append(tl.Events, event)
+	}
+}
+
+// find returns the index of a relay event already added, or -1
+func (tl *timeline) find(eventType, hash string) int {
+	if i, ok := tl.relay[relayKey(eventType, hash)]; ok {
+		return i
+	}
+	return -1
+}
+
+func relayKey(eventType, hash string) string {
+	return eventType + "/" + strings.ToUpper(hash)
+}
+
+// Timeline returns every event found for a packet identified by its source
+// chain, port, channel and sequence. Sources that can't be reached are
+// reported as warnings rather than failing the timeline.
+func (t *Timelines) Timeline(ctx context.Context, id clearing.PacketIdentifier) (*Timeline, error) {
+	ref, err := packetRef(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &timeline{
+		Timeline: &Timeline{
+			Packet:      ref,
+			State:       StateUnknown,
+			Events:      []TimelineEvent{},
+			GeneratedAt: t.now(),
+		},
+		relay: make(map[string]int),
+	}
+	t.resolveCounterparty(ctx, tl)
+
+	// Chainpulse and the clearing history don't depend on the chain
+	// events, so they're fetched while the chains are searched
+	var wg sync.WaitGroup
+	var details *chainpulse.PacketDetails
+	var operations []clearing.PacketOperation
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		details = t.fetchChainpulse(ctx, tl)
+	}()
+	go func() {
+		defer wg.Done()
+		operations = t.fetchClearing(ctx, tl)
+	}()
+	t.addChainEvents(ctx, tl)
+	wg.Wait()
+
+	t.addChainpulse(ctx, tl, details)
+	tl.add(clearingEvents(operations)...)
+
+	if len(tl.Events) == 0 && len(tl.Warnings) == 0 {
+		return nil, fmt.Errorf("%w: %s/%s/%d", ErrPacketNotFound, ref.ChainID, ref.ChannelID, ref.Sequence)
+	}
+	if len(tl.Warnings) > 0 {
+		t.logger.Warn("Packet timeline is incomplete",
+			zap.String("packet", fmt.Sprintf("%s/%s/%d", ref.ChainID, ref.ChannelID, ref.Sequence)),
+			zap.Strings("warnings", tl.Warnings))
+	}
+	tl.finish()
+	return tl.Timeline, nil
+}
+
+// resolveCounterparty finds the destination end of the packet's channel
+func (t *Timelines) resolveCounterparty(ctx context.Context, tl *timeline) {
+	p := tl.Packet
+	channel, err := t.chains.Channel(ctx, p.ChainID, p.PortID, p.ChannelID)
+	if err != nil {
+		tl.warn("channel query on %s: %v", p.ChainID, err)
+	} else {
+		tl.CounterpartyPortID = channel.CounterpartyPortID
+		tl.CounterpartyChannelID = channel.CounterpartyChannelID
+	}
+
+	client, err := t.clients.ChannelClient(ctx, p.ChainID, p.PortID, p.ChannelID)
+	if err != nil {
+		tl.warn("client query on %s: %v", p.ChainID, err)
+		return
+	}
+	tl.CounterpartyChainID = client.CounterpartyChainID
+}
+
+// addChainEvents searches both chains for the packet's events
+func (t *Timelines) addChainEvents(ctx context.Context, tl *timeline) {
+	p := tl.Packet
+	for _, event := range []string{EventSendPacket, EventAckPacket, EventTimeout} {
+		t.searchEvents(ctx, tl, p.ChainID, event, p.PortID, p.ChannelID)
+	}
+	if tl.CounterpartyChainID == "" || tl.CounterpartyChannelID == "" {
+		tl.warn("counterparty unknown, %s and %s not searched", EventRecvPacket, EventWriteAck)
+		return
+	}
+	for _, event := range []string{EventRecvPacket, EventWriteAck} {
+		t.searchEvents(ctx, tl, tl.CounterpartyChainID, event, tl.CounterpartyPortID, tl.CounterpartyChannelID)
+	}
+}
+
+func (t *Timelines) searchEvents(ctx context.Context, tl *timeline, chainID, event, portID, channelID string) {
+	txs, err := t.txs.PacketTxs(ctx, chainID, event, portID, channelID, tl.Packet.Sequence)
+	if err != nil {
+		tl.warn("%s search on %s: %v", event, chainID, err)
+		return
+	}
+	for _, tx := range txs {
+		e := TimelineEvent{
+			Type:      event,
+			ChainID:   chainID,
+			Height:    tx.Height,
+			Timestamp: tx.Time,
+			TxHash:    tx.Hash,
+			Source:    SourceChain,
+		}
+		if event != EventSendPacket && event != EventWriteAck {
+			e.Relayer = tx.Signer
+			e.Outcome = OutcomeSuccess
+		}
+		if tx.Code != 0 {
+			e.Outcome = OutcomeFailed
+			e.Error = tx.RawLog
+		}
+		tl.add(e)
+	}
+}
+
+func (t *Timelines) fetchChainpulse(ctx context.Context, tl *timeline) *chainpulse.PacketDetails {
+	if t.chainpulse == nil {
+		return nil
+	}
+	p := tl.Packet
+	details, err := t.chainpulse.GetPacketDetails(ctx, p.ChainID, p.ChannelID, p.Sequence)
+	if errors.Is(err, chainpulse.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		tl.warn("chainpulse: %v", err)
+		return nil
+	}
+	return details
+}
+
+// addChainpulse adds the send and the relay attempts the chain search
+// missed. Chainpulse marks the first message for a packet as effected even
+// when its tx failed, so attempts the chains didn't confirm are looked up.
+func (t *Timelines) addChainpulse(ctx context.Context, tl *timeline, details *chainpulse.PacketDetails) {
+	if details == nil {
+		return
+	}
+
+	if details.TxHash != "" && tl.find(EventSendPacket, details.TxHash) < 0 {
+		tl.add(TimelineEvent{
+			Type:      EventSendPacket,
+			ChainID:   tl.Packet.ChainID,
+			Height:    details.Height,
+			Timestamp: details.Timestamp,
+			TxHash:    details.TxHash,
+			Source:    SourceChainpulse,
+		})
+	}
+
+	lookups := 0
+	for _, attempt := range details.Attempts {
+		event, ok := attemptEvents[attempt.MsgTypeURL]
+		if !ok {
+			continue
+		}
+		if i := tl.find(event, attempt.TxHash); i >= 0 {
+			if tl.Events[i].Relayer == "" {
+				tl.Events[i].Relayer = attempt.Signer
+			}
+			continue
+		}
+
+		e := TimelineEvent{
+			Type:      event,
+			ChainID:   attempt.Chain,
+			Height:    attempt.Height,
+			Timestamp: attempt.Timestamp,
+			TxHash:    attempt.TxHash,
+			Relayer:   attempt.Signer,
+			Outcome:   OutcomeRedundant,
+			Source:    SourceChainpulse,
+		}
+		// Unless the chain search found the message that took effect
+		if attempt.Effected && !tl.relayed(event) {
+			e.Outcome = OutcomeSuccess
+		}
+		if lookups < maxAttemptLookups {
+			lookups++
+			tx, err := t.txs.Tx(ctx, attempt.Chain, attempt.TxHash)
+			switch {
+			case err != nil:
+				tl.warn("tx %s on %s: %v", attempt.TxHash, attempt.Chain, err)
+			case tx.Code != 0:
+				e.Outcome = OutcomeFailed
+				e.Error = tx.RawLog
+			}
+		}
+		tl.add(e)
+	}
+}
+
+// relayed reports whether a successful event of the type was already added
+func (tl *timeline) relayed(event string) bool {
+	for _, e := range tl.Events {
+		if e.Type == event && e.Outcome == OutcomeSuccess {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Timelines) fetchClearing(ctx context.Context, tl *timeline) []clearing.PacketOperation {
+	if t.history == nil {
+		return nil
+	}
+	p := tl.Packet
+	operations, err := t.history.PacketOperations(ctx, p.ChainID, p.PortID, p.ChannelID, p.Sequence)
+	if err != nil {
+		tl.warn("clearing history: %v", err)
+		return nil
+	}
+	return operations
+}
+
+// clearingEvents turns clearing operations and their refunds into events
+func clearingEvents(operations []clearing.PacketOperation) []TimelineEvent {
+	var events []TimelineEvent
+	for _, po := range operations {
+		op := po.Operation
+		events = append(events, TimelineEvent{
+			Type:        EventClearingPaid,
+			ChainID:     op.ChainID,
+			Timestamp:   op.CreatedAt,
+			TxHash:      op.PaymentTxHash,
+			OperationID: op.ID,
+			Status:      op.Status,
+			Source:      SourceClearing,
+		})
+
+		switch {
+		case op.CompletedAt != nil:
+			hash := op.ClearingTxHash
+			if hash == "" && len(op.ExecutionTxHashes) > 0 {
+				hash = op.ExecutionTxHashes[0]
+			}
+			events = append(events, TimelineEvent{
+				Type:        EventClearingCompleted,
+				Timestamp:   *op.CompletedAt,
+				TxHash:      hash,
+				OperationID: op.ID,
+				Status:      op.Status,
+				Source:      SourceClearing,
+			})
+		case op.Status == "failed":
+			events = append(events, TimelineEvent{
+				Type:        EventClearingFailed,
+				Timestamp:   op.UpdatedAt,
+				OperationID: op.ID,
+				Status:      op.Status,
+				Error:       op.ErrorMessage,
+				Source:      SourceClearing,
+			})
+		}
+
+		for _, refund := range po.Refunds {
+			at := refund.CreatedAt
+			if refund.ProcessedAt != nil {
+				at = *refund.ProcessedAt
+			}
+			events = append(events, TimelineEvent{
+				Type:        EventRefund,
+				ChainID:     refund.ChainID,
+				Timestamp:   at,
+				TxHash:      refund.RefundTxHash,
+				OperationID: op.ID,
+				Status:      refund.RefundStatus,
+				Error:       refund.ErrorMessage,
+				Source:      SourceClearing,
+			})
+		}
+	}
+	return events
+}
+
+// finish orders the events and works out the packet's state from the
+// relay messages that took effect
+func (tl *timeline) finish() {
+	sort.SliceStable(tl.Events, func(i, j int) bool {
+		a, b := tl.Events[i], tl.Events[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		return eventRank[a.Type] < eventRank[b.Type]
+	})
+
+	for _, e := range tl.Events {
+		if e.Outcome == OutcomeFailed || e.Outcome == OutcomeRedundant {
+			continue
+		}
+		switch e.Type {
+		case EventAckPacket, EventTimeout:
+			tl.State = StateCompleted
+		case EventRecvPacket, EventWriteAck:
+			if tl.State != StateCompleted {
+				tl.State = StateReceived
+			}
+		case EventSendPacket:
+			if tl.State == StateUnknown {
+				tl.State = StatePending
+			}
+		}
+	}
+}
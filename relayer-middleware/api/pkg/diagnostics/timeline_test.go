@@ -0,0 +1,250 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/clearing"
+)
+
+// fakeTxs serves packet events by chain and event, and tx lookups by hash
+type fakeTxs struct {
+	events map[string][]PacketTx
+	txs    map[string]PacketTx
+	err    map[string]error
+}
+
+func (f *fakeTxs) PacketTxs(ctx context.Context, chainID, event, portID, channelID string, sequence uint64) ([]PacketTx, error) {
+	key := chainID + "/" + event
+	return f.events[key], f.err[key]
+}
+
+func (f *fakeTxs) Tx(ctx context.Context, chainID, hash string) (*PacketTx, error) {
+	tx, ok := f.txs[hash]
+	if !ok {
+		return nil, errNotFound
+	}
+	return &tx, nil
+}
+
+type fakeChainpulse struct {
+	details *chainpulse.PacketDetails
+	err     error
+}
+
+func (f fakeChainpulse) GetPacketDetails(ctx context.Context, chain, channel string, sequence uint64) (*chainpulse.PacketDetails, error) {
+	return f.details, f.err
+}
+
+type fakeHistory []clearing.PacketOperation
+
+func (f fakeHistory) PacketOperations(ctx context.Context, chainID, portID, channelID string, sequence uint64) ([]clearing.PacketOperation, error) {
+	return f, nil
+}
+
+func at(minutes int) time.Time {
+	return testNow.Add(time.Duration(minutes) * time.Minute)
+}
+
+// newTestTxs is a packet sent at 0, received by relayer B at 30 after a
+// failed attempt by A, and acknowledged at 31
+func newTestTxs() *fakeTxs {
+	return &fakeTxs{
+		events: map[string][]PacketTx{
+			"osmosis-1/send_packet":             {{Hash: "SEND", Height: 100, Time: at(0), Attributes: map[string]string{}}},
+			"cosmoshub-4/recv_packet":           {{Hash: "RECV", Height: 200, Time: at(30), Signer: "cosmos1relayerb"}},
+			"cosmoshub-4/write_acknowledgement": {{Hash: "RECV", Height: 200, Time: at(30)}},
+			"osmosis-1/acknowledge_packet":      {{Hash: "ACK", Height: 130, Time: at(31), Signer: "osmo1relayerb"}},
+		},
+		txs: map[string]PacketTx{
+			"FAILED":    {Hash: "FAILED", Code: 11, RawLog: "out of gas in location: ReadFlat"},
+			"REDUNDANT": {Hash: "REDUNDANT"},
+		},
+		err: map[string]error{},
+	}
+}
+
+func newTestTimelines(txs *fakeTxs) *Timelines {
+	tl := NewTimelines(newFakeChains(), txs, &fakeClients{}, zap.NewNop())
+	tl.now = func() time.Time { return testNow }
+	return tl
+}
+
+func eventSummary(events []TimelineEvent) []string {
+	summary := []string{}
+	for _, e := range events {
+		s := e.Type + " " + e.TxHash
+		if e.Outcome != "" {
+			s += " " + e.Outcome
+		}
+		summary = append(summary, s)
+	}
+	return summary
+}
+
+func TestTimeline(t *testing.T) {
+	completed := at(20)
+	processed := at(45)
+	timelines := newTestTimelines(newTestTxs())
+	timelines.UseChainpulse(fakeChainpulse{details: &chainpulse.PacketDetails{
+		TxHash: "SEND", Height: 100, Timestamp: at(0),
+		Attempts: []chainpulse.PacketAttempt{
+			// Chainpulse counts the first message as effected even though its tx failed
+			{Chain: "cosmoshub-4", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: "cosmos1relayera", Effected: true, TxHash: "FAILED", Height: 150, Timestamp: at(10)},
+			{Chain: "cosmoshub-4", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: "cosmos1relayerb", TxHash: "recv", Height: 200, Timestamp: at(30)},
+			{Chain: "cosmoshub-4", MsgTypeURL: "/ibc.core.channel.v1.MsgRecvPacket", Signer: "cosmos1relayerc", EffectedSigner: "cosmos1relayerb", TxHash: "REDUNDANT", Height: 201, Timestamp: at(30).Add(6 * time.Second)},
+			{Chain: "osmosis-1", MsgTypeURL: "/ibc.core.channel.v1.MsgUpdateClient", TxHash: "UPDATE"},
+		},
+	}})
+	timelines.UseClearingHistory(fakeHistory{{
+		Operation: clearing.ClearingOperation{
+			ID: "op-1", ChainID: "osmosis-1", PaymentTxHash: "PAY", Status: "failed",
+			CreatedAt: at(15), UpdatedAt: completed, ErrorMessage: "hermes unavailable",
+		},
+		Refunds: []clearing.RefundableOperation{
+			{ChainID: "osmosis-1", RefundStatus: "completed", RefundTxHash: "REFUND", CreatedAt: at(21), ProcessedAt: &processed},
+		},
+	}})
+
+	timeline, err := timelines.Timeline(context.Background(), testPacket)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cosmoshub-4", timeline.CounterpartyChainID)
+	assert.Equal(t, "channel-141", timeline.CounterpartyChannelID)
+	assert.Equal(t, StateCompleted, timeline.State)
+	assert.Empty(t, timeline.Warnings)
+	assert.Equal(t, []string{
+		"send_packet SEND",
+		"recv_packet FAILED failed",
+		"clearing_paid PAY",
+		"clearing_failed ",
+		"recv_packet RECV success",
+		"write_acknowledgement RECV",
+		"recv_packet REDUNDANT redundant",
+		"acknowledge_packet ACK success",
+		"refund REFUND",
+	}, eventSummary(timeline.Events))
+
+	failed := timeline.Events[1]
+	assert.Equal(t, "cosmos1relayera", failed.Relayer)
+	assert.Equal(t, "out of gas in location: ReadFlat", failed.Error)
+	assert.Equal(t, SourceChainpulse, failed.Source)
+	assert.Equal(t, "cosmos1relayerb", timeline.Events[4].Relayer)
+	assert.Equal(t, SourceChain, timeline.Events[4].Source)
+	assert.Equal(t, "cosmos1relayerc", timeline.Events[6].Relayer)
+	assert.Equal(t, "hermes unavailable", timeline.Events[3].Error)
+	assert.Equal(t, processed, timeline.Events[8].Timestamp)
+	assert.Equal(t, "op-1", timeline.Events[8].OperationID)
+}
+
+func TestTimelineStates(t *testing.T) {
+	txs := newTestTxs()
+	delete(txs.events, "osmosis-1/acknowledge_packet")
+	timeline, err := newTestTimelines(txs).Timeline(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.Equal(t, StateReceived, timeline.State)
+
+	delete(txs.events, "cosmoshub-4/recv_packet")
+	delete(txs.events, "cosmoshub-4/write_acknowledgement")
+	timeline, err = newTestTimelines(txs).Timeline(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, timeline.State)
+
+	txs.events["osmosis-1/timeout_packet"] = []PacketTx{{Hash: "TIMEOUT", Time: at(90), Signer: "osmo1relayera"}}
+	timeline, err = newTestTimelines(txs).Timeline(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.Equal(t, StateCompleted, timeline.State)
+	assert.Equal(t, []string{"send_packet SEND", "timeout_packet TIMEOUT success"}, eventSummary(timeline.Events))
+}
+
+func TestTimelineWarnings(t *testing.T) {
+	txs := newTestTxs()
+	txs.err["cosmoshub-4/recv_packet"] = errors.New("connection refused")
+	timelines := newTestTimelines(txs)
+	timelines.UseChainpulse(fakeChainpulse{err: errors.New("unexpected status code: 502")})
+
+	timeline, err := timelines.Timeline(context.Background(), testPacket)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"recv_packet search on cosmoshub-4: connection refused",
+		"chainpulse: unexpected status code: 502",
+	}, timeline.Warnings)
+	assert.NotContains(t, eventSummary(timeline.Events), "recv_packet RECV success")
+}
+
+func TestTimelineNotFound(t *testing.T) {
+	_, err := newTestTimelines(&fakeTxs{}).Timeline(context.Background(), testPacket)
+	assert.ErrorIs(t, err, ErrPacketNotFound)
+
+	_, err = newTestTimelines(&fakeTxs{}).Timeline(context.Background(), clearing.PacketIdentifier{Chain: "osmosis-1"})
+	assert.ErrorIs(t, err, ErrInvalidPacket)
+}
+
+func TestTimelineRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandlers(nil, newTestTimelines(newTestTxs()), zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/osmosis-1/channel-0/42/timeline", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"type":"recv_packet"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/osmosis-1/channel-0/7/timeline?port=icahost", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	router = gin.New()
+	NewHandlers(nil, newTestTimelines(&fakeTxs{}), zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/osmosis-1/channel-0/42/timeline", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRESTQuerierPacketTxs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cosmos/tx/v1beta1/txs":
+			assert.Equal(t, "recv_packet.packet_dst_port='transfer' AND recv_packet.packet_dst_channel='channel-141' AND recv_packet.packet_sequence='42'", r.URL.Query().Get("query"))
+			w.Write([]byte(`{"tx_responses":[{"height":"200","txhash":"RECV","code":0,"timestamp":"2026-03-01T12:30:00Z",
+				"tx":{"body":{"messages":[{"@type":"/ibc.core.client.v1.MsgUpdateClient","signer":"cosmos1relayerb"},{"@type":"/ibc.core.channel.v1.MsgRecvPacket","signer":"cosmos1relayerb"}]}},
+				"events":[{"type":"recv_packet","attributes":[
+					{"key":"packet_sequence","value":"42"},
+					{"key":"packet_dst_channel","value":"channel-141"}]}]}]}`))
+		case "/cosmos/tx/v1beta1/txs/FAILED":
+			w.Write([]byte(`{"tx_response":{"height":"150","txhash":"FAILED","code":11,"raw_log":"out of gas","timestamp":"2026-03-01T12:10:00Z"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	q := NewRESTQuerier(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RESTEndpoint: server.URL},
+	}})
+
+	txs, err := q.PacketTxs(context.Background(), "cosmoshub-4", EventRecvPacket, "transfer", "channel-141", 42)
+	require.NoError(t, err)
+	require.Len(t, txs, 1)
+	assert.Equal(t, "RECV", txs[0].Hash)
+	assert.Equal(t, uint64(200), txs[0].Height)
+	assert.Equal(t, at(30), txs[0].Time)
+	assert.Equal(t, "cosmos1relayerb", txs[0].Signer)
+	assert.Equal(t, "42", txs[0].Attributes["packet_sequence"])
+
+	tx, err := q.Tx(context.Background(), "cosmoshub-4", "FAILED")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(11), tx.Code)
+	assert.Equal(t, "out of gas", tx.RawLog)
+}
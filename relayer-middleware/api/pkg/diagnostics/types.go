@@ -0,0 +1,248 @@
+package diagnostics
+
+import (
+	"context"
+	"time"
+
+	"relayooor/api/pkg/balances"
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/clearing"
+	"relayooor/api/pkg/logstream"
+)
+
+// Cause codes, in the order they're reported. Earlier causes explain later
+// ones, e.g. an expired client also makes relay attempts fail.
+const (
+	CauseAlreadyRelayed = "already_relayed"
+	CauseChannelClosed  = "channel_closed"
+	CauseClientFrozen   = "client_frozen"
+	CauseClientExpired  = "client_expired"
+	CauseTimedOut       = "packet_timed_out"
+	CauseChainHalted    = "chain_halted"
+	CauseNotRelayed     = "channel_not_relayed"
+	CauseOutOfFunds     = "relayer_out_of_funds"
+	CauseRelayFailing   = "relay_failing"
+)
+
+var causeOrder = map[string]int{
+	CauseAlreadyRelayed: 0,
+	CauseChannelClosed:  1,
+	CauseClientFrozen:   2,
+	CauseClientExpired:  3,
+	CauseTimedOut:       4,
+	CauseChainHalted:    5,
+	CauseNotRelayed:     6,
+	CauseOutOfFunds:     7,
+	CauseRelayFailing:   8,
+}
+
+// Packet states
+const (
+	// StatePending packets haven't been received on the counterparty
+	StatePending = "pending"
+	// StateReceived packets were received but the acknowledgement hasn't
+	// been relayed back
+	StateReceived = "received"
+	// StateCompleted packets were acknowledged or timed out
+	StateCompleted = "completed"
+	StateUnknown   = "unknown"
+)
+
+// Check results
+const (
+	CheckPassed  = "passed"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+	CheckError   = "error"
+)
+
+// IBC packet events, which also name the on-chain timeline entries
+const (
+	EventSendPacket = "send_packet"
+	EventRecvPacket = "recv_packet"
+	EventWriteAck   = "write_acknowledgement"
+	EventAckPacket  = "acknowledge_packet"
+	EventTimeout    = "timeout_packet"
+)
+
+// Timeline entries for clearing operations
+const (
+	EventClearingPaid      = "clearing_paid"
+	EventClearingCompleted = "clearing_completed"
+	EventClearingFailed    = "clearing_failed"
+	EventRefund            = "refund"
+)
+
+// Relay message outcomes
+const (
+	OutcomeSuccess = "success"
+	// OutcomeRedundant messages landed after another relayer's
+	OutcomeRedundant = "redundant"
+	OutcomeFailed    = "failed"
+)
+
+// Timeline entry sources
+const (
+	SourceChain      = "chain"
+	SourceChainpulse = "chainpulse"
+	SourceClearing   = "clearing"
+)
+
+// PacketRef identifies the packet being diagnosed by its source end
+type PacketRef struct {
+	ChainID   string `json:"chain_id"`
+	PortID    string `json:"port_id"`
+	ChannelID string `json:"channel_id"`
+	Sequence  uint64 `json:"sequence"`
+}
+
+// Cause is a reason a packet isn't being relayed
+type Cause struct {
+	Code    string `json:"code"`
+	Summary string `json:"summary"`
+	Detail  string `json:"detail,omitempty"`
+	// Action is what the user or operator should do about it
+	Action string `json:"action"`
+	// Clearable is true when paying for a clear would get the packet moving
+	Clearable bool `json:"clearable"`
+}
+
+// Check is the outcome of one diagnostic step
+type Check struct {
+	Name   string `json:"name"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Diagnosis explains why a packet is stuck
+type Diagnosis struct {
+	Packet                PacketRef `json:"packet"`
+	CounterpartyChainID   string    `json:"counterparty_chain_id,omitempty"`
+	CounterpartyPortID    string    `json:"counterparty_port_id,omitempty"`
+	CounterpartyChannelID string    `json:"counterparty_channel_id,omitempty"`
+	State                 string    `json:"state"`
+	// Relayers lists the configured relayers whose filters cover the channel
+	Relayers []string `json:"relayers"`
+	Causes   []Cause  `json:"causes"`
+	// Cause is the most fundamental of Causes, nil when none was found
+	Cause           *Cause    `json:"cause,omitempty"`
+	SuggestedAction string    `json:"suggested_action"`
+	Clearable       bool      `json:"clearable"`
+	Checks          []Check   `json:"checks"`
+	DiagnosedAt     time.Time `json:"diagnosed_at"`
+}
+
+// TimelineEvent is one step in the life of a packet
+type TimelineEvent struct {
+	Type      string    `json:"type"`
+	ChainID   string    `json:"chain_id,omitempty"`
+	Height    uint64    `json:"height,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	TxHash    string    `json:"tx_hash,omitempty"`
+	// Relayer is the signer of relay transactions
+	Relayer string `json:"relayer,omitempty"`
+	// Outcome is set for recv, acknowledge and timeout messages
+	Outcome     string `json:"outcome,omitempty"`
+	OperationID string `json:"operation_id,omitempty"`
+	// Status is the clearing operation or refund status
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Source string `json:"source"`
+}
+
+// Timeline is every known event of a packet, oldest first
+type Timeline struct {
+	Packet                PacketRef       `json:"packet"`
+	CounterpartyChainID   string          `json:"counterparty_chain_id,omitempty"`
+	CounterpartyPortID    string          `json:"counterparty_port_id,omitempty"`
+	CounterpartyChannelID string          `json:"counterparty_channel_id,omitempty"`
+	State                 string          `json:"state"`
+	Events                []TimelineEvent `json:"events"`
+	// Warnings name the lookups that failed, so a missing event isn't
+	// mistaken for one that didn't happen
+	Warnings    []string  `json:"warnings,omitempty"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ChannelEnd is one end of a channel as stored on its chain
+type ChannelEnd struct {
+	State                 string `json:"state"`
+	CounterpartyPortID    string `json:"counterparty_port_id"`
+	CounterpartyChannelID string `json:"counterparty_channel_id"`
+}
+
+// Timeout is the timeout a packet was sent with. Zero fields are unset.
+type Timeout struct {
+	RevisionNumber uint64    `json:"revision_number"`
+	Height         uint64    `json:"height"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Block is the latest block of a chain
+type Block struct {
+	// RevisionNumber is parsed from the chain ID, e.g. 4 for cosmoshub-4
+	RevisionNumber uint64    `json:"revision_number"`
+	Height         uint64    `json:"height"`
+	Time           time.Time `json:"time"`
+}
+
+// ChainQuerier reads channel and packet state from a chain
+type ChainQuerier interface {
+	Channel(ctx context.Context, chainID, portID, channelID string) (*ChannelEnd, error)
+	// PacketCommitment reports whether the source chain still holds the
+	// packet commitment, i.e. the packet wasn't acknowledged or timed out
+	PacketCommitment(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error)
+	// PacketReceipt reports whether the destination received the packet
+	PacketReceipt(ctx context.Context, chainID, portID, channelID string, sequence uint64) (bool, error)
+	// PacketTimeout finds the timeout of a packet from its send_packet event
+	PacketTimeout(ctx context.Context, chainID, portID, channelID string, sequence uint64) (*Timeout, error)
+	LatestBlock(ctx context.Context, chainID string) (*Block, error)
+}
+
+// PacketTx is a transaction that carried a packet message
+type PacketTx struct {
+	Hash   string
+	Height uint64
+	Time   time.Time
+	// Code is non-zero for failed transactions, with RawLog the error
+	Code   uint32
+	RawLog string
+	// Signer is the signer of the first message naming one
+	Signer string
+	// Attributes are those of the matching packet event
+	Attributes map[string]string
+}
+
+// TxSearcher finds the transactions behind packet events
+type TxSearcher interface {
+	// PacketTxs finds the transactions that emitted event for a packet.
+	// Source events match the packet's source port and channel, recv and
+	// write_acknowledgement its destination port and channel.
+	PacketTxs(ctx context.Context, chainID, event, portID, channelID string, sequence uint64) ([]PacketTx, error)
+	Tx(ctx context.Context, chainID, hash string) (*PacketTx, error)
+}
+
+// PacketDetailsReader returns what Chainpulse recorded about a packet
+type PacketDetailsReader interface {
+	GetPacketDetails(ctx context.Context, chain, channel string, sequence uint64) (*chainpulse.PacketDetails, error)
+}
+
+// ClearingHistory returns the clearing operations that targeted a packet
+type ClearingHistory interface {
+	PacketOperations(ctx context.Context, chainID, portID, channelID string, sequence uint64) ([]clearing.PacketOperation, error)
+}
+
+// ConfigReader returns the current config of a relayer
+type ConfigReader interface {
+	Current(relayer string) (string, error)
+}
+
+// BalanceReader returns the latest relayer account balance checks
+type BalanceReader interface {
+	Balances(relayer string) []balances.AccountBalance
+}
+
+// LogReader returns recent relayer log entries
+type LogReader interface {
+	Recent(filter logstream.Filter, afterID uint64, limit int) []*logstream.Entry
+}
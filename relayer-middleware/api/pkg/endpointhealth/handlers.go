@@ -0,0 +1,48 @@
+package endpointhealth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/endpoints"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes endpoint pool health
+type Handlers struct {
+	pools  *endpoints.Pools
+	logger *zap.Logger
+}
+
+// NewHandlers creates endpoint pool handlers
+func NewHandlers(pools *endpoints.Pools, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		pools:  pools,
+		logger: logger.With(zap.String("component", "endpoint_handlers")),
+	}
+}
+
+// RegisterRoutes registers GET /chains/endpoints
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/chains/endpoints", h.GetEndpoints)
+}
+
+// GetEndpoints handles GET /chains/endpoints, every chain's endpoints best
+// first with their last probe. ?chain_id= picks one chain and ?probe=true
+// probes before answering. endpoints names the REST endpoint queries
+// currently go to for each chain. Endpoints from nodes.toml are only shown
+// by their label.
+func (h *Handlers) GetEndpoints(c *gin.Context) {
+	chainID := c.Query("chain_id")
+
+	if c.Query("probe") == "true" {
+		h.pools.Probe(c.Request.Context())
+	}
+	status := h.pools.Status(chainID)
+
+	if chainID != "" && len(status.Chains) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no endpoints for " + chainID})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,79 @@
+package endpointhealth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// Metrics exports the health of endpoint pools as Prometheus series
+type Metrics struct {
+	up        *prometheus.GaugeVec
+	latency   *prometheus.GaugeVec
+	lag       *prometheus.GaugeVec
+	rank      *prometheus.GaugeVec
+	failovers *prometheus.CounterVec
+}
+
+// NewMetrics creates the endpoint health metrics
+func NewMetrics() *Metrics {
+	labels := []string{"chain_id", "source", "endpoint"}
+	return &Metrics{
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_chain_endpoint_up",
+			Help: "Whether a chain endpoint passed its last probe",
+		}, labels),
+		latency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_chain_endpoint_latency_seconds",
+			Help: "Latency of a chain endpoint's last /status probe",
+		}, labels),
+		lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_chain_endpoint_lag_blocks",
+			Help: "Blocks a chain endpoint is behind the chain's highest endpoint",
+		}, labels),
+		rank: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_chain_endpoint_rank",
+			Help: "Position of a chain endpoint in its pool, 0 is used first",
+		}, labels),
+		failovers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "relayooor_chain_endpoint_failovers_total",
+			Help: "Queries retried on the next endpoint after one failed",
+		}, []string{"chain_id", "api"}),
+	}
+}
+
+// Register registers the endpoint health metrics
+func (m *Metrics) Register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{m.up, m.latency, m.lag, m.rank, m.failovers} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Failover counts a query retried on the next endpoint
+func (m *Metrics) Failover(chainID string, api endpoints.API) {
+	m.failovers.WithLabelValues(chainID, string(api)).Inc()
+}
+
+// Reset drops the series of endpoints that are no longer listed
+func (m *Metrics) Reset() {
+	m.up.Reset()
+	m.latency.Reset()
+	m.lag.Reset()
+	m.rank.Reset()
+}
+
+// Observe records an endpoint's last probe and rank
+func (m *Metrics) Observe(chainID string, e endpoints.Endpoint) {
+	labels := prometheus.Labels{"chain_id": chainID, "source": e.Source, "endpoint": endpoints.Label(&e)}
+
+	up := 0.0
+	if e.Healthy {
+		up = 1
+	}
+	m.up.With(labels).Set(up)
+	m.latency.With(labels).Set(float64(e.LatencyMS) / 1000)
+	m.lag.With(labels).Set(float64(e.Lag))
+	m.rank.With(labels).Set(float64(e.Rank))
+}
@@ -1,13 +1,25 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+
+	"relayooor/api/pkg/auth"
+	"relayooor/api/pkg/middleware"
+)
+
+// Operator token lifetimes
+const (
+	AccessTokenTTL  = time.Hour
+	RefreshTokenTTL = 7 * 24 * time.Hour
 )
 
 type LoginRequest struct {
@@ -15,10 +27,7 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
-type Claims struct {
-	Username string `json:"username"`
-	jwt.RegisteredClaims
-}
+type Claims = middleware.Claims
 
 // For demo purposes - in production, use proper user storage
 var demoUsers = map[string]string{
@@ -45,8 +54,8 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, refreshToken, err := h.generateTokens(req.Username)
+	// Generate JWT token, starting a new refresh token family
+	token, refreshToken, err := h.generateTokens(c.Request.Context(), req.Username, uuid.New().String())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -55,10 +64,15 @@ func (h *Handler) Login(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"token":         token,
 		"refresh_token": refreshToken,
-		"expires_in":    3600, // 1 hour
+		"expires_in":    int(AccessTokenTTL.Seconds()),
 	})
 }
 
+// UseTokenStore enables refresh token rotation and revocation
+func (h *Handler) UseTokenStore(store *auth.TokenStore) {
+	h.tokenStore = store
+}
+
 func (h *Handler) RefreshToken(c *gin.Context) {
 	var req struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
@@ -70,23 +84,32 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	}
 
 	// Parse and validate refresh token
-	token, err := jwt.ParseWithClaims(req.RefreshToken, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
-	if err != nil || !token.Valid {
+	claims, err := parseToken(req.RefreshToken)
+	if err != nil || claims.TokenType != middleware.TokenTypeRefresh {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
 		return
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-		return
+	familyID := claims.FamilyID
+	if h.tokenStore != nil {
+		// Refresh tokens are single use; replaying one revokes the whole family
+		record, err := h.tokenStore.ConsumeRefreshToken(c.Request.Context(), claims.ID)
+		switch {
+		case errors.Is(err, auth.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token reuse detected, session revoked"})
+			return
+		case errors.Is(err, auth.ErrRefreshTokenNotFound), errors.Is(err, auth.ErrFamilyRevoked):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate refresh token"})
+			return
+		}
+		familyID = record.FamilyID
 	}
 
-	// Generate new tokens
-	newToken, newRefreshToken, err := h.generateTokens(claims.Username)
+	// Generate new tokens in the same family
+	newToken, newRefreshToken, err := h.generateTokens(c.Request.Context(), claims.Username, familyID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -95,45 +118,118 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"token":         newToken,
 		"refresh_token": newRefreshToken,
-		"expires_in":    3600,
+		"expires_in":    int(AccessTokenTTL.Seconds()),
 	})
 }
 
-func (h *Handler) generateTokens(username string) (string, string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "default-secret-change-me"
+// Logout revokes the current access token and its refresh token family
+func (h *Handler) Logout(c *gin.Context) {
+	value, exists := c.Get("claims")
+	claims, ok := value.(*Claims)
+	if !exists || !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if h.tokenStore == nil {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Token revocation is not enabled"})
+		return
 	}
 
+	ctx := c.Request.Context()
+	if claims.ExpiresAt != nil {
+		if err := h.tokenStore.DenyAccessToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token"})
+			return
+		}
+	}
+	if claims.FamilyID != "" {
+		if err := h.tokenStore.RevokeFamily(ctx, claims.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func (h *Handler) generateTokens(ctx context.Context, username, familyID string) (string, string, error) {
+	now := time.Now()
+
 	// Access token - expires in 1 hour
 	claims := &Claims{
 		Username: username,
+		FamilyID: familyID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	tokenString, err := token.SignedString(jwtSecret())
 	if err != nil {
 		return "", "", err
 	}
 
 	// Refresh token - expires in 7 days
 	refreshClaims := &Claims{
-		Username: username,
+		Username:  username,
+		FamilyID:  familyID,
+		TokenType: middleware.TokenTypeRefresh,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(jwtSecret))
+	refreshTokenString, err := refreshToken.SignedString(jwtSecret())
 	if err != nil {
 		return "", "", err
 	}
 
+	if h.tokenStore != nil {
+		err := h.tokenStore.SaveRefreshToken(ctx, auth.RefreshTokenRecord{
+			ID:        refreshClaims.ID,
+			FamilyID:  familyID,
+			Username:  username,
+			IssuedAt:  now,
+			ExpiresAt: refreshClaims.ExpiresAt.Time,
+		})
+		if err != nil {
+			return "", "", err
+		}
+	}
+
 	return tokenString, refreshTokenString, nil
-}
\ No newline at end of file
+}
+
+// parseToken validates a signed token and returns its claims
+func parseToken(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return jwtSecret(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+func jwtSecret() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "default-secret-change-me"
+	}
+	return []byte(secret)
+}
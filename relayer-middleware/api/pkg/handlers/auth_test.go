@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/auth"
+	"relayooor/api/pkg/middleware"
+)
+
+type tokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func newAuthRouter(t *testing.T) (*gin.Engine, *Handler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test-jwt-secret")
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	store := auth.NewTokenStore(redisClient, RefreshTokenTTL, zap.NewNop())
+	h := &Handler{}
+	h.UseTokenStore(store)
+	middleware.SetTokenDenylist(store)
+	t.Cleanup(func() { middleware.SetTokenDenylist(nil) })
+
+	router := gin.New()
+	router.POST("/auth/refresh", h.RefreshToken)
+	router.POST("/auth/logout", middleware.AuthRequired(), h.Logout)
+	router.GET("/relayer/status", middleware.AuthRequired(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"username": c.GetString("username")})
+	})
+	return router, h
+}
+
+func login(t *testing.T, h *Handler) tokenPair {
+	t.Helper()
+	token, refreshToken, err := h.generateTokens(context.Background(), "admin", uuid.New().String())
+	require.NoError(t, err)
+	return tokenPair{Token: token, RefreshToken: refreshToken}
+}
+
+func refresh(router *gin.Engine, refreshToken string) (int, tokenPair) {
+	body, _ := json.Marshal(gin.H{"refresh_token": refreshToken})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/auth/refresh", bytes.NewReader(body)))
+	var pair tokenPair
+	json.Unmarshal(w.Body.Bytes(), &pair)
+	return w.Code, pair
+}
+
+func withBearer(router *gin.Engine, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	router, h := newAuthRouter(t)
+	first := login(t, h)
+
+	code, second := refresh(router, first.RefreshToken)
+	require.Equal(t, http.StatusOK, code)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.Equal(t, http.StatusOK, withBearer(router, "GET", "/relayer/status", second.Token).Code)
+
+	// Access tokens aren't refresh tokens
+	code, _ = refresh(router, first.Token)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Replaying the rotated token revokes the session, including the tokens
+	// issued in its place
+	code, _ = refresh(router, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = refresh(router, second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, http.StatusUnauthorized, withBearer(router, "GET", "/relayer/status", second.Token).Code)
+	assert.Equal(t, http.StatusUnauthorized, withBearer(router, "GET", "/relayer/status", first.Token).Code)
+
+	// Other sessions keep working
+	other := login(t, h)
+	assert.Equal(t, http.StatusOK, withBearer(router, "GET", "/relayer/status", other.Token).Code)
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	router, h := newAuthRouter(t)
+	session := login(t, h)
+	other := login(t, h)
+
+	require.Equal(t, http.StatusOK, withBearer(router, "GET", "/relayer/status", session.Token).Code)
+	require.Equal(t, http.StatusOK, withBearer(router, "POST", "/auth/logout", session.Token).Code)
+
+	// The denylisted access token is rejected by AuthRequired
+	w := withBearer(router, "GET", "/relayer/status", session.Token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "revoked")
+	assert.Equal(t, http.StatusUnauthorized, withBearer(router, "POST", "/auth/logout", session.Token).Code)
+
+	// and the session can't be refreshed back to life
+	code, _ := refresh(router, session.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	assert.Equal(t, http.StatusOK, withBearer(router, "GET", "/relayer/status", other.Token).Code)
+	code, _ = refresh(router, other.RefreshToken)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestAuthRequiredFailsClosedWithoutDenylist(t *testing.T) {
+	router, h := newAuthRouter(t)
+	session := login(t, h)
+
+	// A denylist that can't be reached must not let tokens through
+	middleware.SetTokenDenylist(unreachableDenylist{})
+	assert.Equal(t, http.StatusServiceUnavailable, withBearer(router, "GET", "/relayer/status", session.Token).Code)
+}
+
+type unreachableDenylist struct{}
+
+func (unreachableDenylist) IsRevoked(ctx context.Context, tokenID, familyID string) (bool, error) {
+	return false, context.DeadlineExceeded
+}
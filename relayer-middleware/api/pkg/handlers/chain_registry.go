@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/config"
+)
+
+// ChainRegistryHandler serves the loaded chain registry
+type ChainRegistryHandler struct {
+	registry *config.ChainRegistry
+}
+
+// NewChainRegistryHandler creates a handler for a registry
+func NewChainRegistryHandler(registry *config.ChainRegistry) *ChainRegistryHandler {
+	return &ChainRegistryHandler{registry: registry}
+}
+
+// RegisterRoutes registers GET /chains/registry
+func (h *ChainRegistryHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/chains/registry", h.GetRegistry)
+}
+
+// GetRegistry handles GET /api/v1/chains/registry, returning the chains and
+// channels as loaded along with where they came from and any entries that
+// were skipped
+func (h *ChainRegistryHandler) GetRegistry(c *gin.Context) {
+	all := h.registry.AllChains()
+	chains := make([]config.ChainConfig, 0, len(all))
+	for _, chain := range all {
+		chain.RPCEndpoint = redactEndpoint(chain.RPCEndpoint)
+		chain.RESTEndpoint = redactEndpoint(chain.RESTEndpoint)
+		chain.WSEndpoint = redactEndpoint(chain.WSEndpoint)
+		chain.GRPCEndpoint = redactEndpoint(chain.GRPCEndpoint)
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ChainID < chains[j].ChainID })
+
+	c.JSON(http.StatusOK, gin.H{
+		"chains":   chains,
+		"channels": h.registry.AllChannels(),
+		"status":   h.registry.Status(),
+	})
+}
+
+// redactEndpoint drops credentials an overrides file may put in the userinfo
+// or query string. nodes.toml endpoints aren't part of the served chains at
+// all.
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint
+	}
+	u.User = nil
+	u.RawQuery = ""
+	return u.String()
+}
@@ -1,41 +1,67 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	
+
 	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/channels"
+	"relayooor/api/pkg/timeouts"
 )
 
 // ChainpulseHandler handles chainpulse integration endpoints
 type ChainpulseHandler struct {
-	client *chainpulse.Client
-	logger *zap.Logger
+	client         *chainpulse.Client
+	counterparties *channels.Resolver
+	timeouts       *timeouts.Predictor
+	logger         *zap.Logger
 }
 
 // NewChainpulseHandler creates a new chainpulse handler
-func NewChainpulseHandler(chainpulseURL string, logger *zap.Logger) *ChainpulseHandler {
+func NewChainpulseHandler(client *chainpulse.Client, logger *zap.Logger) *ChainpulseHandler {
 	return &ChainpulseHandler{
-		client: chainpulse.NewClient(chainpulseURL, logger),
+		client: client,
 		logger: logger,
 	}
 }
 
-// RegisterRoutes registers chainpulse routes
+// UseCounterparties resolves the destination chain of packets from their
+// channel
+func (h *ChainpulseHandler) UseCounterparties(resolver *channels.Resolver) {
+	h.counterparties = resolver
+}
+
+// UseTimeouts replaces Chainpulse's time to timeout of expiring packets
+// with one predicted from the destination chain
+func (h *ChainpulseHandler) UseTimeouts(predictor *timeouts.Predictor) {
+	h.timeouts = predictor
+}
+
+// RegisterRoutes registers chainpulse routes, and the expiring, expired and
+// duplicate packet routes at the paths the legacy API served them on
 func (h *ChainpulseHandler) RegisterRoutes(api *gin.RouterGroup) {
 	cp := api.Group("/chainpulse")
 	{
 		cp.GET("/packets/by-user", h.GetUserPackets)
 		cp.GET("/packets/stuck", h.GetStuckPackets)
+		cp.GET("/packets/expiring", h.GetExpiringPackets)
+		cp.GET("/packets/expired", h.GetExpiredPackets)
+		cp.GET("/packets/duplicates", h.GetDuplicatePackets)
 		cp.GET("/packets/:chain/:channel/:sequence", h.GetPacketDetails)
 		cp.GET("/channels/congestion", h.GetChannelCongestion)
 		cp.GET("/metrics", h.GetMetrics)
 		cp.GET("/health", h.HealthCheck)
 	}
+
+	api.GET("/packets/expiring", h.GetExpiringPackets)
+	api.GET("/packets/expired", h.GetExpiredPackets)
+	api.GET("/packets/duplicates", h.GetDuplicatePackets)
 }
 
 // GetUserPackets returns packets for a specific user
@@ -46,38 +72,39 @@ func (h *ChainpulseHandler) GetUserPackets(c *gin.Context) {
 		return
 	}
 
-	packets, err := h.client.GetPacketsByUser(c.Request.Context(), address)
+	resp, err := h.client.GetPacketsByUser(c.Request.Context(), chainpulse.UserPacketsQuery{
+		Address: address,
+		Role:    c.Query("role"),
+		Page:    queryPage(c),
+	})
 	if err != nil {
-		h.logger.Error("Failed to get user packets", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user packets"})
+		h.writeError(c, err, "Failed to fetch user packets")
 		return
 	}
 
 	// Transform to match expected API format
-	transfers := make([]gin.H, len(packets))
-	for i, p := range packets {
+	transfers := make([]gin.H, len(resp.Packets))
+	for i, p := range resp.Packets {
+		sentAt := time.Now().Add(-time.Duration(p.AgeSeconds) * time.Second)
+		stuckDuration := formatDuration(int(p.AgeSeconds / 60))
 		transfers[i] = gin.H{
-			"id":               p.TxHash + "-" + strconv.FormatUint(p.Sequence, 10),
-			"channelId":        p.Channel,
+			"id":               p.ID(),
+			"channelId":        p.SrcChannel,
 			"sequence":         p.Sequence,
-			"sourceChain":      p.Chain,
-			"destinationChain": getCounterpartyChain(p.Chain), // Helper function needed
+			"sourceChain":      p.ChainID,
+			"destinationChain": h.destinationChain(c.Request.Context(), p),
 			"amount":           p.Amount,
 			"denom":            p.Denom,
 			"sender":           p.Sender,
 			"receiver":         p.Receiver,
-			"status":           p.Status,
-			"timestamp":        p.Timestamp,
-			"txHash":           p.TxHash,
-		}
-		
-		// Add stuck duration if packet is stuck
-		if p.Status == "stuck" {
-			duration := time.Since(p.Timestamp).String()
-			transfers[i]["stuckDuration"] = &duration
+			"status":           "stuck", // Chainpulse only lists pending packets
+			"timestamp":        sentAt,
+			"stuckDuration":    &stuckDuration,
+			"relayAttempts":    p.RelayAttempts,
 		}
 	}
 
+	c.Header("X-Total-Count", strconv.Itoa(resp.Total))
 	c.JSON(http.StatusOK, transfers)
 }
 
@@ -90,34 +117,147 @@ func (h *ChainpulseHandler) GetStuckPackets(c *gin.Context) {
 		}
 	}
 
-	packets, err := h.client.GetStuckPackets(c.Request.Context(), minStuckMinutes)
+	resp, err := h.client.GetStuckPackets(c.Request.Context(), chainpulse.StuckPacketsQuery{
+		MinAge: time.Duration(minStuckMinutes) * time.Minute,
+		Page:   queryPage(c),
+	})
 	if err != nil {
-		h.logger.Error("Failed to get stuck packets", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stuck packets"})
+		h.writeError(c, err, "Failed to fetch stuck packets")
 		return
 	}
 
 	// Transform to match expected format
-	stuckPackets := make([]gin.H, len(packets))
-	for i, p := range packets {
+	stuckPackets := make([]gin.H, len(resp.Packets))
+	for i, p := range resp.Packets {
 		stuckPackets[i] = gin.H{
-			"id":               strconv.FormatUint(p.Sequence, 10),
+			"id":               p.ID(),
 			"channelId":        p.SrcChannel,
 			"sequence":         p.Sequence,
-			"sourceChain":      p.SrcChain,
-			"destinationChain": p.DstChain,
-			"stuckDuration":    formatDuration(p.StuckDuration),
+			"sourceChain":      p.ChainID,
+			"destinationChain": h.destinationChain(c.Request.Context(), p),
+			"stuckDuration":    formatDuration(int(p.AgeSeconds / 60)),
 			"amount":           p.Amount,
 			"denom":            p.Denom,
 			"sender":           p.Sender,
 			"receiver":         p.Receiver,
-			"timestamp":        p.StuckSince,
+			"relayAttempts":    p.RelayAttempts,
+			"lastAttemptBy":    p.LastAttemptBy,
+			"timestamp":        time.Now().Add(-time.Duration(p.AgeSeconds) * time.Second),
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"stuck_packets": stuckPackets,
-		"total":         len(stuckPackets),
+		"total":         resp.Total,
+	})
+}
+
+// GetExpiringPackets returns packets that time out within ?minutes=
+// (default 60). With a predictor, the time to timeout is predicted from the
+// destination chain and packets are sorted soonest first.
+func (h *ChainpulseHandler) GetExpiringPackets(c *gin.Context) {
+	minutes := 60
+	if m, err := strconv.Atoi(c.Query("minutes")); err == nil && m > 0 {
+		minutes = m
+	}
+
+	resp, err := h.client.GetExpiringPackets(c.Request.Context(), time.Duration(minutes)*time.Minute, queryPage(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch expiring packets")
+		return
+	}
+
+	if h.timeouts != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"packets":     h.predictedPackets(c.Request.Context(), resp.Packets),
+			"api_version": resp.APIVersion,
+		})
+		return
+	}
+
+	packets := make([]gin.H, len(resp.Packets))
+	for i, p := range resp.Packets {
+		packet := legacyPacket(p.Packet)
+		packet["seconds_until_timeout"] = p.SecondsUntilTimeout
+		packet["timeout_type"] = p.TimeoutType
+		packet["timeout_value"] = p.TimeoutValue
+		packets[i] = packet
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packets":     packets,
+		"api_version": resp.APIVersion,
+	})
+}
+
+// predictedPackets formats expiring packets with their predicted timeout,
+// soonest first
+func (h *ChainpulseHandler) predictedPackets(ctx context.Context, expiring []chainpulse.ExpiringPacket) []gin.H {
+	byID := make(map[string]chainpulse.ExpiringPacket, len(expiring))
+	for _, p := range expiring {
+		byID[p.ID()] = p
+	}
+
+	predictions := h.timeouts.Predict(ctx, expiring)
+	packets := make([]gin.H, len(predictions))
+	for i, prediction := range predictions {
+		expiringPacket := byID[prediction.ID()]
+		packet := legacyPacket(expiringPacket.Packet)
+		packet["seconds_until_timeout"] = int64(prediction.SecondsUntilTimeout)
+		packet["chainpulse_seconds_until_timeout"] = prediction.ChainpulseSeconds
+		packet["timeout_type"] = prediction.TimeoutType
+		packet["timeout_value"] = expiringPacket.TimeoutValue
+		packet["timeout_height"] = prediction.TimeoutHeight
+		packet["timeout_timestamp"] = prediction.TimeoutTimestamp
+		packet["timeout_at"] = prediction.TimeoutAt
+		packet["destination_chain"] = prediction.DestinationChainID
+		packet["destination_height"] = prediction.DestinationHeight
+		packet["blocks_remaining"] = prediction.BlocksRemaining
+		packet["block_time_seconds"] = prediction.BlockTimeSeconds
+		packet["urgency"] = prediction.Urgency
+		packet["basis"] = prediction.Basis
+		packets[i] = packet
+	}
+	return packets
+}
+
+// GetExpiredPackets returns packets past their timeout
+func (h *ChainpulseHandler) GetExpiredPackets(c *gin.Context) {
+	resp, err := h.client.GetExpiredPackets(c.Request.Context(), queryPage(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch expired packets")
+		return
+	}
+
+	packets := make([]gin.H, len(resp.Packets))
+	for i, p := range resp.Packets {
+		packet := legacyPacket(p.Packet)
+		packet["seconds_since_timeout"] = p.SecondsSinceTimeout
+		packet["timeout_type"] = p.TimeoutType
+		packets[i] = packet
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packets":     packets,
+		"api_version": resp.APIVersion,
+	})
+}
+
+// GetDuplicatePackets returns groups of packets sent with identical data
+func (h *ChainpulseHandler) GetDuplicatePackets(c *gin.Context) {
+	resp, err := h.client.GetDuplicatePackets(c.Request.Context(), queryPage(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch duplicate packets")
+		return
+	}
+
+	duplicates := resp.Duplicates
+	if duplicates == nil {
+		duplicates = []chainpulse.DuplicatePacket{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"duplicates":  duplicates,
+		"api_version": resp.APIVersion,
 	})
 }
 
@@ -132,15 +272,18 @@ func (h *ChainpulseHandler) GetPacketDetails(c *gin.Context) {
 	}
 
 	details, err := h.client.GetPacketDetails(c.Request.Context(), chain, channel, sequence)
+	if errors.Is(err, chainpulse.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Packet not found"})
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to get packet details", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch packet details"})
+		h.writeError(c, err, "Failed to fetch packet details")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"chain":           details.Chain,
-		"channel":         details.Channel,
+		"chain":           details.ChainID,
+		"channel":         details.SrcChannel,
 		"sequence":        details.Sequence,
 		"status":          details.Status,
 		"packet_data":     details.PacketData,
@@ -148,29 +291,29 @@ func (h *ChainpulseHandler) GetPacketDetails(c *gin.Context) {
 		"tx_hash":         details.TxHash,
 		"height":          details.Height,
 		"timestamp":       details.Timestamp,
+		"relay_attempts":  details.RelayAttempts,
+		"attempts":        details.Attempts,
 	})
 }
 
 // GetChannelCongestion returns channel congestion statistics
 func (h *ChainpulseHandler) GetChannelCongestion(c *gin.Context) {
-	congestion, err := h.client.GetChannelCongestion(c.Request.Context())
+	resp, err := h.client.GetChannelCongestion(c.Request.Context())
 	if err != nil {
-		h.logger.Error("Failed to get channel congestion", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch channel congestion"})
+		h.writeError(c, err, "Failed to fetch channel congestion")
 		return
 	}
 
 	// Transform to match expected format
-	channels := make([]gin.H, len(congestion))
-	for i, ch := range congestion {
+	channels := make([]gin.H, len(resp.Channels))
+	for i, ch := range resp.Channels {
 		channels[i] = gin.H{
-			"channelId":             ch.Channel,
-			"counterpartyChannelId": ch.CounterpartyChannel,
-			"sourceChain":           ch.Chain,
-			"destinationChain":      ch.CounterpartyChain,
-			"pendingPackets":        ch.PendingPackets,
-			"avgClearTime":          ch.AvgClearTime,
-			"congestionLevel":       ch.CongestionLevel,
+			"channelId":             ch.SrcChannel,
+			"counterpartyChannelId": ch.DstChannel,
+			"pendingPackets":        ch.StuckCount,
+			"oldestStuckAgeSeconds": ch.OldestStuckAgeSeconds,
+			"totalValue":            ch.TotalValue,
+			"congestionLevel":       congestionLevel(ch.StuckCount),
 		}
 	}
 
@@ -181,8 +324,7 @@ func (h *ChainpulseHandler) GetChannelCongestion(c *gin.Context) {
 func (h *ChainpulseHandler) GetMetrics(c *gin.Context) {
 	metrics, err := h.client.GetMetrics(c.Request.Context())
 	if err != nil {
-		h.logger.Error("Failed to get metrics", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch metrics"})
+		h.writeError(c, err, "Failed to fetch metrics")
 		return
 	}
 
@@ -205,17 +347,63 @@ func (h *ChainpulseHandler) HealthCheck(c *gin.Context) {
 	})
 }
 
+// writeError logs a failed Chainpulse call and answers 503 while the
+// circuit breaker is open, 500 otherwise
+func (h *ChainpulseHandler) writeError(c *gin.Context, err error, message string) {
+	if errors.Is(err, chainpulse.ErrUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": message, "reason": "chainpulse unavailable"})
+		return
+	}
+	h.logger.Error(message, zap.Error(err))
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
 // Helper functions
-func getCounterpartyChain(chain string) string {
-	// This would normally come from configuration
-	switch chain {
-	case "cosmoshub-4":
-		return "osmosis-1"
-	case "osmosis-1":
-		return "cosmoshub-4"
+
+// queryPage reads ?limit= and ?offset=
+func queryPage(c *gin.Context) chainpulse.Page {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	return chainpulse.Page{Limit: limit, Offset: offset}
+}
+
+// legacyPacket formats a packet as the legacy API did
+func legacyPacket(p chainpulse.Packet) gin.H {
+	return gin.H{
+		"id":          p.ID(),
+		"chain_id":    p.ChainID,
+		"sequence":    p.Sequence,
+		"src_channel": p.SrcChannel,
+		"dst_channel": p.DstChannel,
+		"sender":      p.Sender,
+		"receiver":    p.Receiver,
+		"amount":      p.Amount,
+		"denom":       p.Denom,
+		"age_seconds": p.AgeSeconds,
+	}
+}
+
+func congestionLevel(stuck int) string {
+	switch {
+	case stuck >= 50:
+		return "high"
+	case stuck >= 10:
+		return "medium"
 	default:
+		return "low"
+	}
+}
+
+// destinationChain is the chain a packet's source channel leads to, or
+// "unknown"
+func (h *ChainpulseHandler) destinationChain(ctx context.Context, p chainpulse.Packet) string {
+	if h.counterparties == nil {
 		return "unknown"
 	}
+	if chainID := h.counterparties.CounterpartyChain(ctx, p.ChainID, p.SrcChannel); chainID != "" {
+		return chainID
+	}
+	return "unknown"
 }
 
 func formatDuration(minutes int) string {
@@ -228,4 +416,4 @@ func formatDuration(minutes int) string {
 		return strconv.Itoa(hours) + "h"
 	}
 	return strconv.Itoa(hours) + "h" + strconv.Itoa(mins) + "m"
-}
\ No newline at end of file
+}
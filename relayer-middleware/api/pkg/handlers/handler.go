@@ -8,19 +8,40 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/audit"
+	"relayooor/api/pkg/auth"
+	"relayooor/api/pkg/balances"
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/competition"
+	"relayooor/api/pkg/logstream"
+	"relayooor/api/pkg/middleware"
+	"relayooor/api/pkg/supervisor"
+	"relayooor/api/pkg/telemetry"
 )
 
 type Handler struct {
 	hermesURL    string
 	redisClient  *redis.Client
 	wsUpgrader   websocket.Upgrader
-	wsClients    map[*websocket.Conn]bool
+	wsClients    map[*wsClient]bool
+	wsMu         sync.Mutex
 	broadcast    chan interface{}
+	tokenStore   *auth.TokenStore
+	auditLog     *audit.Service
+	processes    supervisor.ProcessManager
+	logStream    *logstream.Service
+	balances     *balances.Monitor
+	telemetry    *telemetry.Collector
+	competition  *competition.Tracker
+	chainpulse   *chainpulse.Client
 }
 
 func NewHandler() *Handler {
@@ -41,13 +62,11 @@ func NewHandler() *Handler {
 		hermesURL:   hermesURL,
 		redisClient: redisClient,
 		wsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				// Configure CORS for WebSocket
-				return true
-			},
+			CheckOrigin: middleware.WebSocketOriginChecker(),
 		},
-		wsClients: make(map[*websocket.Conn]bool),
+		wsClients: make(map[*wsClient]bool),
 		broadcast: make(chan interface{}),
+		processes: supervisor.NewSupervisordManager(supervisor.DefaultSupervisorURL, zap.NewNop()),
 	}
 
 	// Start WebSocket broadcaster
@@ -56,6 +75,46 @@ func NewHandler() *Handler {
 	return h
 }
 
+// UseProcessManager sets the process manager controlling the relayers
+func (h *Handler) UseProcessManager(processes supervisor.ProcessManager) {
+	h.processes = processes
+}
+
+// UseLogStream enables relayer log subscriptions on the /ws hub
+func (h *Handler) UseLogStream(logStream *logstream.Service) {
+	h.logStream = logStream
+}
+
+// UseBalanceMonitor includes relayer account balances in the relayer status
+func (h *Handler) UseBalanceMonitor(monitor *balances.Monitor) {
+	h.balances = monitor
+}
+
+// UseTelemetry sets the collector scraping the relayers' Prometheus telemetry
+func (h *Handler) UseTelemetry(collector *telemetry.Collector) {
+	h.telemetry = collector
+}
+
+// UseCompetition adds relayer competition to the relayer performance
+func (h *Handler) UseCompetition(tracker *competition.Tracker) {
+	h.competition = tracker
+}
+
+// UseChainpulse sets the Chainpulse client the metrics routes scrape
+func (h *Handler) UseChainpulse(client *chainpulse.Client) {
+	h.chainpulse = client
+}
+
+// Broadcast sends a message to every connected WebSocket client
+func (h *Handler) Broadcast(message interface{}) {
+	h.broadcast <- message
+}
+
+// UseAuditLog enables audit logging of privileged relayer operations
+func (h *Handler) UseAuditLog(auditLog *audit.Service) {
+	h.auditLog = auditLog
+}
+
 // Health check endpoint
 func (h *Handler) HealthCheck(c *gin.Context) {
 	// Check Redis connection
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"relayooor/api/pkg/audit"
 	"relayooor/api/pkg/clearing"
 )
 
@@ -68,6 +69,7 @@ func (h *Handler) ClearPacketsWithHermes(c *gin.Context) {
 
 	// Clear packets
 	response, err := hermesClient.ClearPackets(c.Request.Context(), &req)
+	h.auditLog.RecordRequest(c, audit.ActionPacketsClear, clearTarget(req.Chain, req.Channel), req, response, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to clear packets",
@@ -5,6 +5,8 @@ import (
 	"os/exec"
 
 	"github.com/gin-gonic/gin"
+
+	"relayooor/api/pkg/audit"
 )
 
 // GetChains returns all configured chains
@@ -144,6 +146,10 @@ func (h *Handler) ClearPackets(c *gin.Context) {
 		}
 
 		result, err := h.postHermesAPI("/clear_packets", params)
+		h.auditLog.RecordRequest(c, audit.ActionPacketsClear, clearTarget(req.ChainID, req.ChannelID), nil, gin.H{
+			"relayer": "hermes",
+			"result":  result,
+		}, err)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear packets"})
 			return
@@ -173,6 +179,10 @@ func (h *Handler) ClearPackets(c *gin.Context) {
 
 	cmd := exec.Command("rly", args...)
 	output, err := cmd.CombinedOutput()
+	h.auditLog.RecordRequest(c, audit.ActionPacketsClear, clearTarget(req.ChainID, req.ChannelID), nil, gin.H{
+		"relayer": "rly",
+		"output":  string(output),
+	}, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":  "Failed to clear packets with rly",
@@ -197,21 +207,14 @@ func (h *Handler) GetStuckPackets(c *gin.Context) {
 	})
 }
 
-// GetClients returns all IBC clients
-func (h *Handler) GetClients(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"clients": []interface{}{},
-		"message": "Client listing implementation pending",
-	})
+// clearTarget describes the scope of a manual clear for the audit log
+func clearTarget(chainID, channelID string) string {
+	switch {
+	case chainID == "":
+		return "all"
+	case channelID == "":
+		return chainID
+	default:
+		return chainID + "/" + channelID
+	}
 }
-
-// GetChainClients returns clients for a specific chain
-func (h *Handler) GetChainClients(c *gin.Context) {
-	chainID := c.Param("chain_id")
-	
-	c.JSON(http.StatusOK, gin.H{
-		"chain_id": chainID,
-		"clients":  []interface{}{},
-		"message":  "Chain client listing implementation pending",
-	})
-}
\ No newline at end of file
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/telemetry"
 )
 
 // GetMetricsSummary returns a summary of key metrics
@@ -70,10 +73,7 @@ func (h *Handler) GetChannelMetrics(c *gin.Context) {
 
 // Helper functions for getting metrics from relayers
 func (h *Handler) getHermesMetrics() gin.H {
-	metrics := gin.H{
-		"status": "unknown",
-		"stats":  nil,
-	}
+	metrics := h.relayerTelemetry("hermes")
 
 	// Get Hermes state
 	if state, err := h.callHermesAPI("/state"); err == nil {
@@ -85,41 +85,64 @@ func (h *Handler) getHermesMetrics() gin.H {
 }
 
 func (h *Handler) getRlyMetrics() gin.H {
-	// The Go relayer exposes Prometheus metrics on port 5184
+	return h.relayerTelemetry("rly")
+}
+
+// relayerTelemetry summarises the scraped telemetry of a relayer
+func (h *Handler) relayerTelemetry(relayer string) gin.H {
 	metrics := gin.H{
 		"status": "unknown",
 		"stats":  nil,
 	}
+	if h.telemetry == nil {
+		return metrics
+	}
 
-	// Try to fetch metrics from the metrics endpoint
-	resp, err := http.Get("http://localhost:5184/relayer/metrics")
-	if err == nil {
-		defer resp.Body.Close()
-		metrics["status"] = "active"
-		// TODO: Parse Prometheus metrics
+	snapshot, ok := h.telemetry.Snapshot(relayer)
+	if !ok {
+		return metrics
 	}
 
+	switch snapshot.Status {
+	case telemetry.StatusUp:
+		metrics["status"] = "active"
+	case telemetry.StatusDown:
+		metrics["status"] = "unreachable"
+	}
+	metrics["stats"] = gin.H{
+		"totals":  snapshot.Totals,
+		"windows": snapshot.Windows,
+		"wallets": snapshot.Wallets,
+	}
+	metrics["telemetry"] = snapshot
 	return metrics
 }
 
-// MetricsCollector runs in the background to collect and cache metrics
+// StartMetricsCollector scrapes relayer telemetry in the background, caches
+// the latest metrics and pushes them to WebSocket clients. The interval is
+// METRICS_COLLECT_INTERVAL, 15s by default.
 func (h *Handler) StartMetricsCollector() {
+	interval := database.EnvDuration("METRICS_COLLECT_INTERVAL", 15*time.Second)
+
 	go func() {
-		ticker := time.NewTicker(30 * time.Second)
+		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 
 		for {
-			select {
-			case <-ticker.C:
-				h.collectMetrics()
-			}
+			h.collectMetrics()
+			<-ticker.C
 		}
 	}()
 }
 
 func (h *Handler) collectMetrics() {
-	ctx := context.Background()
-	
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if h.telemetry != nil {
+		h.telemetry.Collect(ctx)
+	}
+
 	// Collect metrics from both relayers
 	metrics := gin.H{
 		"timestamp": time.Now().Unix(),
@@ -136,4 +159,4 @@ func (h *Handler) collectMetrics() {
 		"type":    "metrics_update",
 		"metrics": metrics,
 	}
-}
\ No newline at end of file
+}
@@ -5,6 +5,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/chainmetrics"
+
+	"relayooor/api/pkg/competition"
+	"relayooor/api/pkg/telemetry"
 )
 
 // GetMonitoringData returns structured monitoring data
@@ -312,59 +316,81 @@ func (h *Handler) GetMonitoringStuckPackets(c *gin.Context) {
 	c.JSON(http.StatusOK, stuckPackets)
 }
 
-// GetRelayerPerformance returns relayer performance analytics
+// GetRelayerPerformance returns the performance of our relayers over the
+// last hour, built from their scraped telemetry, and the competition between
+// every relayer Chainpulse sees over ?window= (24h by default, e.g. 1h or 7d)
 func (h *Handler) GetRelayerPerformance(c *gin.Context) {
-	performance := []gin.H{
-		{
-			"address": "cosmos1xyz...abc",
-			"packetCount": 125000,
-			"successRate": 94.5,
-			"avgRelayTime": 45,
-			"frontrunRate": 0.096,
-			"gasEfficiency": 92.3,
-			"uptime": 99.8,
-			"isNew": false,
-		},
-		{
-			"address": "osmo1abc...xyz",
-			"packetCount": 98000,
-			"successRate": 92.3,
-			"avgRelayTime": 52,
-			"frontrunRate": 0.082,
-			"gasEfficiency": 89.5,
-			"uptime": 99.5,
-			"isNew": false,
-		},
-		{
-			"address": "neutron1new...relayer",
-			"packetCount": 5000,
-			"successRate": 88.5,
-			"avgRelayTime": 68,
-			"frontrunRate": 0.12,
-			"gasEfficiency": 85.2,
-			"uptime": 98.2,
-			"isNew": true,
-		},
+	window, err := competition.ParseWindow(c.DefaultQuery("window", "24h"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	performance := []gin.H{}
+	if h.telemetry != nil {
+		for _, snapshot := range h.telemetry.Snapshots() {
+			if snapshot.Status == telemetry.StatusUnknown {
+				continue
+			}
+
+			entry := gin.H{
+				"relayer":     snapshot.Relayer,
+				"status":      snapshot.Status,
+				"address":     "",
+				"packetCount": snapshot.Totals.PacketsRelayed,
+				"uptime":      snapshot.Uptime,
+				"windows":     snapshot.Windows,
+				"channels":    snapshot.Channels,
+			}
+			if len(snapshot.Wallets) > 0 {
+				entry["address"] = snapshot.Wallets[0].Account
+			}
+			if n := len(snapshot.Windows); n > 0 {
+				hour := snapshot.Windows[n-1]
+				entry["successRate"] = hour.SuccessRate
+				entry["packetsPerMinute"] = hour.PacketsPerMinute
+				if hour.LatencyConfirmed != nil {
+					// Seconds, like the rest of the analytics
+					entry["avgRelayTime"] = hour.LatencyConfirmed.AvgMs / 1000
+				}
+			}
+			performance = append(performance, entry)
+		}
 	}
 
-	c.JSON(http.StatusOK, performance)
+	response := gin.H{"relayers": performance}
+	if h.competition != nil {
+		report, err := h.competition.Report(c.Request.Context(), window, c.DefaultQuery("window", "24h"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response["competition"] = report
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// GetChainpulseMetrics proxies to chainpulse metrics endpoint
+// GetChainpulseMetrics serves Chainpulse's Prometheus metrics as text, or
+// parsed into chains, channels and relayers with ?format=json
 func (h *Handler) GetChainpulseMetrics(c *gin.Context) {
-	// Proxy to chainpulse metrics
-	resp, err := http.Get("http://localhost:3001/metrics")
+	if h.chainpulse == nil {
+		c.String(http.StatusServiceUnavailable, "Chainpulse metrics unavailable")
+		return
+	}
+	text, err := h.chainpulse.GetMetrics(c.Request.Context())
 	if err != nil {
 		c.String(http.StatusServiceUnavailable, "Chainpulse metrics unavailable")
 		return
 	}
-	defer resp.Body.Close()
 
-	c.DataFromReader(
-		resp.StatusCode,
-		resp.ContentLength,
-		resp.Header.Get("Content-Type"),
-		resp.Body,
-		nil,
-	)
+	if c.Query("format") != "json" {
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(text))
+		return
+	}
+	metrics, err := chainmetrics.ParseMetricsString(text)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to parse Chainpulse metrics"})
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
 }
\ No newline at end of file
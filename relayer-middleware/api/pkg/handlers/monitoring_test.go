@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/chainmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+func TestGetChainpulseMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	snapshot, err := os.ReadFile("../../../../chainpulse-metrics-snapshot.txt")
+	require.NoError(t, err)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(snapshot)
+	}))
+	t.Cleanup(upstream.Close)
+
+	h := &Handler{}
+	router := gin.New()
+	router.GET("/metrics/chainpulse", h.GetChainpulseMetrics)
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get("/metrics/chainpulse").Code)
+
+	h.UseChainpulse(chainpulse.NewClient(upstream.URL, zap.NewNop()))
+
+	// The raw text is served as is for the dashboard's own parser
+	w := get("/metrics/chainpulse")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, string(snapshot), w.Body.String())
+
+	w = get("/metrics/chainpulse?format=json")
+	require.Equal(t, http.StatusOK, w.Code)
+	var metrics chainmetrics.Metrics
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &metrics))
+	want, err := chainmetrics.ParseMetricsString(string(snapshot))
+	require.NoError(t, err)
+	assert.Equal(t, want.MonitoredChains, metrics.MonitoredChains)
+	assert.Len(t, metrics.Chains, len(want.Chains))
+	assert.NotEmpty(t, metrics.Channels)
+}
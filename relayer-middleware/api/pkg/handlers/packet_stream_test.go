@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/packethistory"
+)
+
+func TestPacketStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&packethistory.StuckPacket{}))
+
+	seen := time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+	for seq := uint64(1); seq <= 3; seq++ {
+		require.NoError(t, db.Create(&packethistory.StuckPacket{
+			ChainID: "osmosis-1", SrcChannel: "channel-0", Sequence: seq, Sender: "osmo1alice",
+			Status: packethistory.StatusStuck, SentAt: seen, FirstSeenAt: seen, LastSeenAt: seen,
+		}).Error)
+	}
+
+	handler := NewPacketStreamHandler(db, zap.NewNop())
+	handler.now = func() time.Time { return seen.Add(time.Minute) }
+	router := gin.New()
+	router.GET("/packets/stuck/stream", handler.GetStuckPacketsStream)
+	router.GET("/packets/channel/stream", handler.GetChannelPacketsStream)
+
+	get := func(target, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	type page struct {
+		Packets    []packethistory.StuckPacket `json:"packets"`
+		NextCursor string                      `json:"next_cursor"`
+		HasMore    bool                        `json:"has_more"`
+	}
+
+	w := get("/packets/stuck/stream?wallet=osmo1alice&limit=2", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var first page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+	require.Len(t, first.Packets, 2)
+	assert.True(t, first.HasMore)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// Unchanged data is not sent again
+	assert.Equal(t, http.StatusNotModified, get("/packets/stuck/stream?wallet=osmo1alice&limit=2", etag).Code)
+
+	w = get("/packets/stuck/stream?wallet=osmo1alice&limit=2&cursor="+first.NextCursor, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var second page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+	require.Len(t, second.Packets, 1)
+	assert.Equal(t, uint64(3), second.Packets[0].Sequence)
+	assert.False(t, second.HasMore)
+
+	// A change to a packet on the page changes the ETag
+	require.NoError(t, db.Model(&packethistory.StuckPacket{}).Where("sequence = 1").Update("relay_attempts", 2).Error)
+	assert.Equal(t, http.StatusOK, get("/packets/stuck/stream?wallet=osmo1alice&limit=2", etag).Code)
+
+	assert.Equal(t, http.StatusBadRequest, get("/packets/stuck/stream?wallet=osmo1alice&cursor=bogus", "").Code)
+	assert.Equal(t, http.StatusOK, get("/packets/channel/stream?src_chain=osmosis-1&src_channel=channel-0", "").Code)
+}
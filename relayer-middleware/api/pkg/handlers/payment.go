@@ -227,7 +227,7 @@ type ChainSummary struct {
 
 func (h *PaymentHandler) getStuckPacketsSummary(ctx context.Context, wallet string) *PacketsSummary {
 	// Query stuck packets for the user from Chainpulse
-	packets, err := h.chainpulseClient.GetPacketsByUser(ctx, wallet)
+	packets, err := h.chainpulseClient.AllPacketsByUser(ctx, wallet, "")
 	if err != nil {
 		h.logger.Error("Failed to fetch user packets from Chainpulse", zap.Error(err), zap.String("wallet", wallet))
 		return &PacketsSummary{
@@ -255,16 +255,16 @@ func (h *PaymentHandler) getStuckPacketsSummary(ctx context.Context, wallet stri
 		// Since we don't have a Status field, we'll assume all returned packets are stuck
 		
 		// Get or create chain summary
-		chainSummary, exists := chainMap[packet.Chain]
+		chainSummary, exists := chainMap[packet.ChainID]
 		if !exists {
 			chainSummary = &ChainSummary{
-				ChainID:     packet.Chain,
-				ChainName:   packet.Chain, // Would need chain name mapping
+				ChainID:     packet.ChainID,
+				ChainName:   packet.ChainID, // Would need chain name mapping
 				PacketCount: 0,
 				TotalValue:  "0",
 				Denom:       packet.Denom,
 			}
-			chainMap[packet.Chain] = chainSummary
+			chainMap[packet.ChainID] = chainSummary
 		}
 
 		// Update counts
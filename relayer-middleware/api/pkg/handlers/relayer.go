@@ -1,133 +1,163 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"relayooor/api/pkg/audit"
+	"relayooor/api/pkg/supervisor"
 )
 
 // GetRelayerStatus returns the status of both relayers
 func (h *Handler) GetRelayerStatus(c *gin.Context) {
+	hermes := h.checkHermesStatus()
+	rly := h.checkRlyStatus()
+	if h.balances != nil {
+		hermes["accounts"] = h.balances.Balances("hermes")
+		rly["accounts"] = h.balances.Balances("rly")
+	}
+
 	status := gin.H{
-		"hermes": h.checkHermesStatus(),
-		"rly":    h.checkRlyStatus(),
+		"hermes": hermes,
+		"rly":    rly,
+	}
+	if h.balances != nil {
+		status["balance_alerts"] = h.balances.Alerts()
 	}
 
 	c.JSON(http.StatusOK, status)
 }
 
-// StartHermes starts the Hermes relayer
+// StartHermes starts the Hermes relayer and its REST API
 func (h *Handler) StartHermes(c *gin.Context) {
-	// Use supervisorctl to start Hermes
-	cmd := exec.Command("supervisorctl", "start", "hermes")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "Failed to start Hermes",
-			"output": string(output),
-		})
-		return
-	}
+	h.controlRelayer(c, "hermes", "start")
+}
 
-	// Also start Hermes REST API
-	cmdRest := exec.Command("supervisorctl", "start", "hermes-rest")
-	cmdRest.Run()
+// StopHermes stops the Hermes relayer and its REST API
+func (h *Handler) StopHermes(c *gin.Context) {
+	h.controlRelayer(c, "hermes", "stop")
+}
 
-	// Broadcast status update
-	h.broadcast <- gin.H{
-		"type":    "relayer_status",
-		"relayer": "hermes",
-		"status":  "started",
-	}
+// StartGoRelayer starts the Go relayer
+func (h *Handler) StartGoRelayer(c *gin.Context) {
+	h.controlRelayer(c, "rly", "start")
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Hermes started successfully",
-		"output":  string(output),
-	})
+// StopGoRelayer stops the Go relayer
+func (h *Handler) StopGoRelayer(c *gin.Context) {
+	h.controlRelayer(c, "rly", "stop")
 }
 
-// StopHermes stops the Hermes relayer
-func (h *Handler) StopHermes(c *gin.Context) {
-	// Stop both Hermes and its REST API
-	cmd := exec.Command("supervisorctl", "stop", "hermes", "hermes-rest")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "Failed to stop Hermes",
-			"output": string(output),
-		})
-		return
-	}
+var relayerActions = map[string]map[string]string{
+	"hermes": {"start": audit.ActionHermesStart, "stop": audit.ActionHermesStop},
+	"rly":    {"start": audit.ActionRlyStart, "stop": audit.ActionRlyStop},
+}
 
-	// Broadcast status update
-	h.broadcast <- gin.H{
-		"type":    "relayer_status",
-		"relayer": "hermes",
-		"status":  "stopped",
+var relayerDisplayNames = map[string]string{
+	"hermes": "Hermes",
+	"rly":    "Go relayer",
+}
+
+// controlRelayer starts or stops every process of a relayer. Any process
+// failing fails the request, including auxiliary ones like hermes-rest.
+func (h *Handler) controlRelayer(c *gin.Context, relayer, action string) {
+	processes := RelayerProcesses(relayer)
+	ctx := c.Request.Context()
+
+	var errs []error
+	results := gin.H{}
+	if action == "stop" {
+		// Stop auxiliary processes before the relayer itself
+		for i := len(processes) - 1; i >= 0; i-- {
+			err := h.processes.Stop(ctx, processes[i])
+			results[processes[i]] = processResult(err)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	} else {
+		for _, name := range processes {
+			err := h.processes.Start(ctx, name)
+			results[name] = processResult(err)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Hermes stopped successfully",
-		"output":  string(output),
-	})
-}
+	err := errors.Join(errs...)
+	h.auditLog.RecordRequest(c, relayerActions[relayer][action], processes[0], nil, gin.H{"processes": results}, err)
 
-// StartGoRelayer starts the Go relayer
-func (h *Handler) StartGoRelayer(c *gin.Context) {
-	cmd := exec.Command("supervisorctl", "start", "go-relayer")
-	output, err := cmd.CombinedOutput()
+	displayName := relayerDisplayNames[relayer]
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "Failed to start Go relayer",
-			"output": string(output),
+			"error":     fmt.Sprintf("Failed to %s %s", action, displayName),
+			"processes": results,
 		})
 		return
 	}
 
+	status := "started"
+	if action == "stop" {
+		status = "stopped"
+	}
+
 	// Broadcast status update
 	h.broadcast <- gin.H{
 		"type":    "relayer_status",
-		"relayer": "rly",
-		"status":  "started",
+		"relayer": relayer,
+		"status":  status,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Go relayer started successfully",
-		"output":  string(output),
+		"status":    "success",
+		"message":   fmt.Sprintf("%s %s successfully", displayName, status),
+		"processes": results,
 	})
 }
 
-// StopGoRelayer stops the Go relayer
-func (h *Handler) StopGoRelayer(c *gin.Context) {
-	cmd := exec.Command("supervisorctl", "stop", "go-relayer")
-	output, err := cmd.CombinedOutput()
+func processResult(err error) gin.H {
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":  "Failed to stop Go relayer",
-			"output": string(output),
-		})
-		return
+		return gin.H{"status": "error", "error": err.Error()}
 	}
+	return gin.H{"status": "ok"}
+}
 
-	// Broadcast status update
-	h.broadcast <- gin.H{
-		"type":    "relayer_status",
-		"relayer": "rly",
-		"status":  "stopped",
+// RelayerProcesses returns the managed processes of a relayer, the relayer
+// itself first. Names can be overridden with HERMES_PROCESSES and
+// RLY_PROCESSES as comma-separated lists.
+func RelayerProcesses(relayer string) []string {
+	var value string
+	switch relayer {
+	case "hermes":
+		value = os.Getenv("HERMES_PROCESSES")
+		if value == "" {
+			value = "hermes,hermes-rest"
+		}
+	case "rly":
+		value = os.Getenv("RLY_PROCESSES")
+		if value == "" {
+			value = "go-relayer"
+		}
+	default:
+		return nil
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "Go relayer stopped successfully",
-		"output":  string(output),
-	})
+	var processes []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			processes = append(processes, name)
+		}
+	}
+	return processes
 }
 
 // GetRelayerConfig returns the configuration for both relayers
@@ -161,49 +191,26 @@ func (h *Handler) GetRelayerConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, config)
 }
 
-// UpdateRelayerConfig updates the configuration for a relayer
-func (h *Handler) UpdateRelayerConfig(c *gin.Context) {
-	var req struct {
-		Relayer string `json:"relayer" binding:"required,oneof=hermes rly"`
-		Config  string `json:"config" binding:"required"`
+// RestartRelayer restarts a relayer's processes so it reloads its config
+func (h *Handler) RestartRelayer(ctx context.Context, relayer string) error {
+	processes := RelayerProcesses(relayer)
+	if len(processes) == 0 {
+		return fmt.Errorf("unknown relayer %q", relayer)
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	var configPath string
-	switch req.Relayer {
-	case "hermes":
-		configPath = os.Getenv("HERMES_CONFIG_PATH")
-		if configPath == "" {
-			configPath = "/home/relayer/.hermes/config.toml"
-		}
-	case "rly":
-		configPath = os.Getenv("RLY_CONFIG_PATH")
-		if configPath == "" {
-			configPath = "/home/relayer/.relayer/config/config.yaml"
+	for _, name := range processes {
+		if err := h.processes.Restart(ctx, name); err != nil {
+			return err
 		}
 	}
 
-	// Backup existing config
-	backupPath := fmt.Sprintf("%s.backup", configPath)
-	if data, err := os.ReadFile(configPath); err == nil {
-		os.WriteFile(backupPath, data, 0644)
-	}
-
-	// Write new config
-	if err := os.WriteFile(configPath, []byte(req.Config), 0644); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write config"})
-		return
+	h.broadcast <- gin.H{
+		"type":    "relayer_status",
+		"relayer": relayer,
+		"status":  "restarted",
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": fmt.Sprintf("%s configuration updated successfully", req.Relayer),
-		"backup":  backupPath,
-	})
+	return nil
 }
 
 // Helper functions
@@ -213,24 +220,24 @@ func (h *Handler) checkHermesStatus() gin.H {
 		"rest_api":    false,
 		"version":     "unknown",
 		"config_path": os.Getenv("HERMES_CONFIG_PATH"),
+		"manager":     h.processes.Name(),
 	}
 
-	// Check if process is running
-	cmd := exec.Command("supervisorctl", "status", "hermes")
-	output, err := cmd.Output()
-	if err == nil && strings.Contains(string(output), "RUNNING") {
-		status["running"] = true
+	processes := RelayerProcesses("hermes")
+	if process := h.processStatus(processes[0]); process != nil {
+		status["running"] = process.Running()
+		status["process"] = process
 	}
 
 	// Check REST API
-	cmd = exec.Command("supervisorctl", "status", "hermes-rest")
-	output, err = cmd.Output()
-	if err == nil && strings.Contains(string(output), "RUNNING") {
-		status["rest_api"] = true
-
-		// Get version from REST API
-		if version, err := h.callHermesAPI("/version"); err == nil {
-			status["version"] = version
+	if len(processes) > 1 {
+		if rest := h.processStatus(processes[1]); rest != nil && rest.Running() {
+			status["rest_api"] = true
+
+			// Get version from REST API
+			if version, err := h.callHermesAPI("/version"); err == nil {
+				status["version"] = version
+			}
 		}
 	}
 
@@ -242,20 +249,31 @@ func (h *Handler) checkRlyStatus() gin.H {
 		"running":     false,
 		"version":     "unknown",
 		"config_path": os.Getenv("RLY_CONFIG_PATH"),
+		"manager":     h.processes.Name(),
 	}
 
-	// Check if process is running
-	cmd := exec.Command("supervisorctl", "status", "go-relayer")
-	output, err := cmd.Output()
-	if err == nil && strings.Contains(string(output), "RUNNING") {
-		status["running"] = true
+	if process := h.processStatus(RelayerProcesses("rly")[0]); process != nil {
+		status["running"] = process.Running()
+		status["process"] = process
 	}
 
 	// Get version
-	cmd = exec.Command("rly", "version")
+	cmd := exec.Command("rly", "version")
 	if output, err := cmd.Output(); err == nil {
 		status["version"] = strings.TrimSpace(string(output))
 	}
 
 	return status
-}
\ No newline at end of file
+}
+
+// processStatus returns a process status, or nil if it can't be determined
+func (h *Handler) processStatus(name string) *supervisor.Status {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := h.processes.Status(ctx, name)
+	if err != nil {
+		return nil
+	}
+	return status
+}
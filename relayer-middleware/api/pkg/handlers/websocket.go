@@ -1,16 +1,52 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"relayooor/api/pkg/logstream"
 )
 
-// WebSocketHandler handles WebSocket connections for real-time updates
+// wsClient is a connection to the legacy /ws hub. Writes are serialized
+// because broadcasts, replies and log streaming share the connection.
+type wsClient struct {
+	conn *websocket.Conn
+	// operator is set when the upgrade carried a valid operator JWT, which
+	// relayer logs require
+	operator   bool
+	mu         sync.Mutex
+	cancelLogs func()
+}
+
+func (c *wsClient) writeJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsClient) writeMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// WebSocketHandler handles WebSocket connections for real-time updates.
+// Operators authenticate during the upgrade with an Authorization: Bearer
+// JWT, checked by middleware.OptionalAuth, to subscribe to relayer logs.
 func (h *Handler) WebSocketHandler(c *gin.Context) {
+	operator := c.GetBool("authenticated")
+	if c.GetHeader("Authorization") != "" && !operator {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
 	conn, err := h.wsUpgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
@@ -19,11 +55,19 @@ func (h *Handler) WebSocketHandler(c *gin.Context) {
 	defer conn.Close()
 
 	// Register client
-	h.wsClients[conn] = true
-	defer delete(h.wsClients, conn)
+	client := &wsClient{conn: conn, operator: operator}
+	h.wsMu.Lock()
+	h.wsClients[client] = true
+	h.wsMu.Unlock()
+	defer func() {
+		h.wsMu.Lock()
+		delete(h.wsClients, client)
+		h.wsMu.Unlock()
+		client.stopLogs()
+	}()
 
 	// Send initial connection message
-	conn.WriteJSON(gin.H{
+	client.writeJSON(gin.H{
 		"type":      "connected",
 		"timestamp": time.Now().Unix(),
 	})
@@ -48,7 +92,7 @@ func (h *Handler) WebSocketHandler(c *gin.Context) {
 
 			// Handle different message types
 			if messageType == websocket.TextMessage {
-				h.handleWebSocketMessage(conn, message)
+				h.handleWebSocketMessage(client, message)
 			}
 		}
 	}()
@@ -58,7 +102,7 @@ func (h *Handler) WebSocketHandler(c *gin.Context) {
 		case <-done:
 			return
 		case <-ticker.C:
-			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := client.writeMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
 		}
@@ -69,20 +113,28 @@ func (h *Handler) WebSocketHandler(c *gin.Context) {
 func (h *Handler) handleBroadcast() {
 	for {
 		message := <-h.broadcast
-		
-		// Send to all connected clients
+
+		h.wsMu.Lock()
+		clients := make([]*wsClient, 0, len(h.wsClients))
 		for client := range h.wsClients {
-			err := client.WriteJSON(message)
-			if err != nil {
-				client.Close()
+			clients = append(clients, client)
+		}
+		h.wsMu.Unlock()
+
+		// Send to all connected clients
+		for _, client := range clients {
+			if err := client.writeJSON(message); err != nil {
+				client.conn.Close()
+				h.wsMu.Lock()
 				delete(h.wsClients, client)
+				h.wsMu.Unlock()
 			}
 		}
 	}
 }
 
 // handleWebSocketMessage processes incoming WebSocket messages
-func (h *Handler) handleWebSocketMessage(conn *websocket.Conn, message []byte) {
+func (h *Handler) handleWebSocketMessage(client *wsClient, message []byte) {
 	// Parse message to determine action
 	var msg struct {
 		Type    string                 `json:"type"`
@@ -90,7 +142,7 @@ func (h *Handler) handleWebSocketMessage(conn *websocket.Conn, message []byte) {
 	}
 
 	if err := json.Unmarshal(message, &msg); err != nil {
-		conn.WriteJSON(gin.H{
+		client.writeJSON(gin.H{
 			"type":  "error",
 			"error": "Invalid message format",
 		})
@@ -100,18 +152,23 @@ func (h *Handler) handleWebSocketMessage(conn *websocket.Conn, message []byte) {
 	switch msg.Type {
 	case "subscribe":
 		// Handle subscription to specific events
-		h.handleSubscription(conn, msg.Payload)
+		h.handleSubscription(client, msg.Payload)
+	case "unsubscribe":
+		if topic, _ := msg.Payload["topic"].(string); topic == "logs" {
+			client.stopLogs()
+			client.writeJSON(gin.H{"type": "unsubscribed", "topic": "logs"})
+		}
 	case "ping":
 		// Respond with pong
-		conn.WriteJSON(gin.H{
+		client.writeJSON(gin.H{
 			"type":      "pong",
 			"timestamp": time.Now().Unix(),
 		})
 	case "get_status":
 		// Send current status
-		h.sendCurrentStatus(conn)
+		h.sendCurrentStatus(client)
 	default:
-		conn.WriteJSON(gin.H{
+		client.writeJSON(gin.H{
 			"type":  "error",
 			"error": "Unknown message type",
 		})
@@ -119,11 +176,16 @@ func (h *Handler) handleWebSocketMessage(conn *websocket.Conn, message []byte) {
 }
 
 // handleSubscription manages event subscriptions for WebSocket clients
-func (h *Handler) handleSubscription(conn *websocket.Conn, payload map[string]interface{}) {
+func (h *Handler) handleSubscription(client *wsClient, payload map[string]interface{}) {
+	if topic, _ := payload["topic"].(string); topic == "logs" {
+		h.subscribeLogs(client, payload)
+		return
+	}
+
 	// TODO: Implement subscription management
 	// For now, all clients receive all broadcasts
-	
-	conn.WriteJSON(gin.H{
+
+	client.writeJSON(gin.H{
 		"type":    "subscribed",
 		"events":  []string{"all"},
 		"message": "Subscribed to all events",
@@ -131,7 +193,7 @@ func (h *Handler) handleSubscription(conn *websocket.Conn, payload map[string]in
 }
 
 // sendCurrentStatus sends the current system status to a WebSocket client
-func (h *Handler) sendCurrentStatus(conn *websocket.Conn) {
+func (h *Handler) sendCurrentStatus(client *wsClient) {
 	status := gin.H{
 		"type":      "status_update",
 		"timestamp": time.Now().Unix(),
@@ -141,7 +203,94 @@ func (h *Handler) sendCurrentStatus(conn *websocket.Conn) {
 		},
 	}
 
-	conn.WriteJSON(status)
+	client.writeJSON(status)
+}
+
+// subscribeLogs streams relayer log entries matching the payload's relayer,
+// level, chain and channel filters, starting with recent buffered entries
+func (h *Handler) subscribeLogs(client *wsClient, payload map[string]interface{}) {
+	// The same operators as /relayer/logs, and never API keys
+	if !client.operator {
+		client.writeJSON(gin.H{
+			"type":  "error",
+			"error": "Relayer logs require operator authentication",
+		})
+		return
+	}
+	if h.logStream == nil {
+		client.writeJSON(gin.H{
+			"type":  "error",
+			"error": "Log streaming is not enabled",
+		})
+		return
+	}
+
+	filter := logstream.Filter{}
+	filter.Relayer, _ = payload["relayer"].(string)
+	filter.Level, _ = payload["level"].(string)
+	filter.Chain, _ = payload["chain"].(string)
+	filter.Channel, _ = payload["channel"].(string)
+
+	backlog := 100
+	if value, ok := payload["backlog"].(float64); ok && value >= 0 {
+		backlog = int(value)
+	}
+
+	// Replace any previous log subscription
+	client.stopLogs()
+	sub, cancel := h.logStream.Subscribe(filter)
+	ctx, stop := context.WithCancel(context.Background())
+	client.mu.Lock()
+	client.cancelLogs = func() {
+		stop()
+		cancel()
+	}
+	client.mu.Unlock()
+
+	client.writeJSON(gin.H{
+		"type":   "subscribed",
+		"events": []string{"logs"},
+		"filter": filter,
+	})
+
+	go func() {
+		var sent uint64
+		for _, entry := range h.logStream.Recent(filter, 0, backlog) {
+			if err := client.writeJSON(gin.H{"type": "log", "data": entry}); err != nil {
+				return
+			}
+			sent = entry.ID
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry, ok := <-sub.Entries():
+				if !ok {
+					return
+				}
+				if entry.ID <= sent {
+					continue
+				}
+				if err := client.writeJSON(gin.H{"type": "log", "data": entry}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// stopLogs cancels the client's log subscription, if any
+func (c *wsClient) stopLogs() {
+	c.mu.Lock()
+	cancel := c.cancelLogs
+	c.cancelLogs = nil
+	c.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
 }
 
 // BroadcastEvent sends an event to all connected WebSocket clients
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/logstream"
+	"relayooor/api/pkg/middleware"
+)
+
+func TestWebSocketLogsRequireOperator(t *testing.T) {
+	_, h := newAuthRouter(t)
+	h.wsClients = make(map[*wsClient]bool)
+	h.UseLogStream(logstream.NewService(zap.NewNop()))
+
+	router := gin.New()
+	router.GET("/ws", middleware.OptionalAuth(), h.WebSocketHandler)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	// subscribe dials the hub and asks for relayer logs, returning the reply
+	subscribe := func(header http.Header) string {
+		t.Helper()
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var reply map[string]interface{}
+		require.NoError(t, conn.ReadJSON(&reply))
+		assert.Equal(t, "connected", reply["type"])
+		require.NoError(t, conn.WriteJSON(gin.H{"type": "subscribe", "payload": gin.H{"topic": "logs"}}))
+		require.NoError(t, conn.ReadJSON(&reply))
+		return reply["type"].(string)
+	}
+
+	assert.Equal(t, "error", subscribe(nil))
+
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer not-a-token"}})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	tokens := login(t, h)
+	assert.Equal(t, "subscribed", subscribe(http.Header{"Authorization": {"Bearer " + tokens.Token}}))
+}
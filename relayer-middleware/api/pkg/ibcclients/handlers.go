@@ -0,0 +1,100 @@
+package ibcclients
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/audit"
+)
+
+// Handlers exposes light client expiry status
+type Handlers struct {
+	watcher  *Watcher
+	auditLog *audit.Service
+	logger   *zap.Logger
+}
+
+// NewHandlers creates client handlers
+func NewHandlers(watcher *Watcher, auditLog *audit.Service, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		watcher:  watcher,
+		auditLog: auditLog,
+		logger:   logger.With(zap.String("component", "client_handlers")),
+	}
+}
+
+// RegisterRoutes registers the read-only client routes on the ibc group
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/clients", h.GetClients)
+	router.GET("/clients/channels", h.GetChannelClients)
+	router.GET("/chains/:chain_id/clients", h.GetChainClients)
+}
+
+// RegisterOperatorRoutes registers the client update routes on the relayer
+// group
+func (h *Handlers) RegisterOperatorRoutes(router *gin.RouterGroup) {
+	router.POST("/clients/check", h.CheckClients)
+	router.POST("/clients/:chain_id/:client_id/update", h.UpdateClient)
+}
+
+// GetClients handles GET /ibc/clients
+func (h *Handlers) GetClients(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"clients": h.watcher.Clients(""),
+		"alerts":  h.watcher.Alerts(),
+	})
+}
+
+// GetChainClients handles GET /ibc/chains/:chain_id/clients
+func (h *Handlers) GetChainClients(c *gin.Context) {
+	chainID := c.Param("chain_id")
+	c.JSON(http.StatusOK, gin.H{
+		"chain_id": chainID,
+		"clients":  h.watcher.Clients(chainID),
+		"channels": h.watcher.Channels(chainID),
+	})
+}
+
+// GetChannelClients handles GET /ibc/clients/channels, the expiry status of
+// the client behind every watched channel
+func (h *Handlers) GetChannelClients(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"channels": h.watcher.Channels(c.Query("chain_id")),
+	})
+}
+
+// CheckClients handles POST /relayer/clients/check, checking every client
+// now instead of waiting for the next interval
+func (h *Handlers) CheckClients(c *gin.Context) {
+	clients := h.watcher.Check(c.Request.Context())
+	c.JSON(http.StatusOK, gin.H{
+		"clients": clients,
+		"alerts":  h.watcher.Alerts(),
+	})
+}
+
+// UpdateClient handles POST /relayer/clients/:chain_id/:client_id/update,
+// running `hermes update client` for a watched client
+func (h *Handlers) UpdateClient(c *gin.Context) {
+	chainID, clientID := c.Param("chain_id"), c.Param("client_id")
+
+	client, err := h.watcher.Refresh(c.Request.Context(), chainID, clientID)
+	switch {
+	case errors.Is(err, ErrUnknownClient):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrRefreshDisabled), errors.Is(err, ErrNotRefreshable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.auditLog.RecordRequest(c, audit.ActionClientUpdate, chainID+"/"+clientID, nil, client, err)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "client": client})
+		return
+	}
+	c.JSON(http.StatusOK, client)
+}
@@ -0,0 +1,67 @@
+package ibcclients
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var statusValues = map[string]float64{
+	StatusOK:       0,
+	StatusWarning:  1,
+	StatusCritical: 2,
+	StatusExpired:  3,
+	StatusFrozen:   4,
+	StatusUnknown:  -1,
+}
+
+type metrics struct {
+	remaining *prometheus.GaugeVec
+	status    *prometheus.GaugeVec
+	refreshes *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	labels := []string{"chain_id", "client_id", "counterparty_chain_id"}
+	return &metrics{
+		remaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_ibc_client_trusting_period_remaining_seconds",
+			Help: "Seconds until a light client's trusting period ends, negative once expired",
+		}, labels),
+		status: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_ibc_client_status",
+			Help: "Light client status: 0 ok, 1 warning, 2 critical, 3 expired, 4 frozen, -1 unknown",
+		}, labels),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "relayooor_ibc_client_refreshes_total",
+			Help: "Light client updates triggered by the watcher",
+		}, []string{"chain_id", "result"}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.remaining, m.status, m.refreshes}
+}
+
+func (m *metrics) observe(c ClientStatus) {
+	labels := clientLabels(c)
+	m.status.With(labels).Set(statusValues[c.Status])
+	if c.RemainingSeconds != nil {
+		m.remaining.With(labels).Set(*c.RemainingSeconds)
+	} else {
+		m.remaining.Delete(labels)
+	}
+}
+
+// forget removes the series of a client that's no longer watched
+func (m *metrics) forget(c ClientStatus) {
+	labels := clientLabels(c)
+	m.remaining.Delete(labels)
+	m.status.Delete(labels)
+}
+
+func clientLabels(c ClientStatus) prometheus.Labels {
+	return prometheus.Labels{
+		"chain_id":              c.ChainID,
+		"client_id":             c.ClientID,
+		"counterparty_chain_id": c.CounterpartyChainID,
+	}
+}
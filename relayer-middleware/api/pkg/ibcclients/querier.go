@@ -0,0 +1,124 @@
+package ibcclients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// RESTQuerier reads light clients from the IBC core module over each chain's
+// REST API
+type RESTQuerier struct {
+	client *endpoints.Client
+}
+
+// NewRESTQuerier creates a querier using the REST endpoints of the registry
+func NewRESTQuerier(registry *config.ChainRegistry) *RESTQuerier {
+	return &RESTQuerier{
+		client: endpoints.NewClient(registry, 10*time.Second),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's REST endpoint
+func (q *RESTQuerier) UseEndpoints(pools *endpoints.Pools) {
+	q.client.UsePools(pools)
+}
+
+// restHeight is a height as the REST API encodes it, with string numbers
+type restHeight struct {
+	RevisionNumber string `json:"revision_number"`
+	RevisionHeight string `json:"revision_height"`
+}
+
+func (h restHeight) parse() Height {
+	number, _ := strconv.ParseUint(h.RevisionNumber, 10, 64)
+	height, _ := strconv.ParseUint(h.RevisionHeight, 10, 64)
+	return Height{RevisionNumber: number, RevisionHeight: height}
+}
+
+// ChannelClient implements Querier
+func (q *RESTQuerier) ChannelClient(ctx context.Context, chainID, portID, channelID string) (*ClientState, error) {
+	var body struct {
+		IdentifiedClientState struct {
+			ClientID    string `json:"client_id"`
+			ClientState struct {
+				Type           string     `json:"@type"`
+				ChainID        string     `json:"chain_id"`
+				TrustingPeriod string     `json:"trusting_period"`
+				LatestHeight   restHeight `json:"latest_height"`
+				FrozenHeight   restHeight `json:"frozen_height"`
+			} `json:"client_state"`
+		} `json:"identified_client_state"`
+	}
+	path := fmt.Sprintf("/ibc/core/channel/v1/channels/%s/ports/%s/client_state",
+		url.PathEscape(channelID), url.PathEscape(portID))
+	if err := q.get(ctx, chainID, path, &body); err != nil {
+		return nil, err
+	}
+
+	identified := body.IdentifiedClientState
+	if identified.ClientID == "" {
+		return nil, fmt.Errorf("no client found for %s/%s on %s", portID, channelID, chainID)
+	}
+	if !strings.Contains(identified.ClientState.Type, "tendermint") {
+		return nil, fmt.Errorf("client %s on %s is %s, only Tendermint clients are supported",
+			identified.ClientID, chainID, identified.ClientState.Type)
+	}
+
+	trustingPeriod, err := time.ParseDuration(identified.ClientState.TrustingPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("client %s on %s has invalid trusting period %q", identified.ClientID, chainID, identified.ClientState.TrustingPeriod)
+	}
+
+	return &ClientState{
+		ClientID:            identified.ClientID,
+		CounterpartyChainID: identified.ClientState.ChainID,
+		TrustingPeriod:      trustingPeriod,
+		LatestHeight:        identified.ClientState.LatestHeight.parse(),
+		Frozen:              identified.ClientState.FrozenHeight.parse() != Height{},
+	}, nil
+}
+
+// ConsensusTimestamp implements Querier
+func (q *RESTQuerier) ConsensusTimestamp(ctx context.Context, chainID, clientID string, height Height) (time.Time, error) {
+	var body struct {
+		ConsensusState struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"consensus_state"`
+	}
+	path := fmt.Sprintf("/ibc/core/client/v1/consensus_states/%s/revision/%d/height/%d",
+		url.PathEscape(clientID), height.RevisionNumber, height.RevisionHeight)
+	if err := q.get(ctx, chainID, path, &body); err != nil {
+		return time.Time{}, err
+	}
+	if body.ConsensusState.Timestamp.IsZero() {
+		return time.Time{}, fmt.Errorf("no consensus state for %s at %d-%d on %s",
+			clientID, height.RevisionNumber, height.RevisionHeight, chainID)
+	}
+	return body.ConsensusState.Timestamp, nil
+}
+
+func (q *RESTQuerier) get(ctx context.Context, chainID, path string, out interface{}) error {
+	resp, err := q.client.Get(ctx, chainID, endpoints.REST, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query %s on %s returned %d", path, chainID, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package ibcclients
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// commandRunner runs a command and returns its combined output
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// HermesRefresher updates clients with `hermes update client` using the
+// relayer's own config and keys
+type HermesRefresher struct {
+	configPath string
+	run        commandRunner
+}
+
+// NewHermesRefresher creates a refresher for the Hermes config at configPath
+func NewHermesRefresher(configPath string) *HermesRefresher {
+	return &HermesRefresher{configPath: configPath, run: runCommand}
+}
+
+// UpdateClient implements Refresher
+func (r *HermesRefresher) UpdateClient(ctx context.Context, hostChainID, clientID string) error {
+	output, err := r.run(ctx, "hermes", "--config", r.configPath,
+		"update", "client", "--host-chain", hostChainID, "--client", clientID)
+	if err != nil {
+		return fmt.Errorf("hermes update client %s on %s: %w: %s",
+			clientID, hostChainID, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package ibcclients
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/relayooor/chains/config"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+// channelRef is a channel end whose client is watched
+type channelRef struct {
+	ChainID   string
+	PortID    string
+	ChannelID string
+}
+
+func (r channelRef) String() string {
+	return r.PortID + "/" + r.ChannelID
+}
+
+// watchedChannels lists the channels relayed on the configured chains.
+// Clients are found through these channels rather than by listing every
+// client on a chain, since hubs host thousands of long-dead clients.
+//
+// Hermes chains use their packet filter allow list when it names concrete
+// channels; otherwise, and for rly chains, the registry channels of the
+// chain are used. hermesChains reports which chains Hermes can update.
+func watchedChannels(hermesConfig, rlyConfig string, registry *config.ChainRegistry) (refs []channelRef, hermesChains map[string]bool, err error) {
+	hermesChains = make(map[string]bool)
+	seen := make(map[channelRef]bool)
+	add := func(ref channelRef) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	addRegistry := func(chainID string) {
+		for _, ch := range registry.AllChannels() {
+			if ch.SourceChain == chainID && ch.Status == "active" {
+				add(channelRef{ChainID: chainID, PortID: ch.SourcePort, ChannelID: ch.SourceChannel})
+			}
+		}
+	}
+
+	if hermesConfig != "" {
+		chains, err := relayerconfig.ListHermesChains(hermesConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, chain := range chains {
+			hermesChains[chain.ID] = true
+			allowed := allowedChannels(chain)
+			if len(allowed) == 0 {
+				addRegistry(chain.ID)
+				continue
+			}
+			for _, pair := range allowed {
+				add(channelRef{ChainID: chain.ID, PortID: pair[0], ChannelID: pair[1]})
+			}
+		}
+	}
+
+	if rlyConfig != "" {
+		chains, err := relayerconfig.ListRlyChains(rlyConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, chain := range chains {
+			if !hermesChains[chain.ChainID] {
+				addRegistry(chain.ChainID)
+			}
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].ChainID != refs[j].ChainID {
+			return refs[i].ChainID < refs[j].ChainID
+		}
+		return refs[i].String() < refs[j].String()
+	})
+	return refs, hermesChains, nil
+}
+
+// allowedChannels returns the concrete [port, channel] pairs of an allow
+// packet filter, or nil when the filter uses wildcards or isn't an allow list
+func allowedChannels(chain relayerconfig.ChainConfig) [][]string {
+	filter := chain.PacketFilter
+	if filter == nil || filter.Policy == nil || *filter.Policy != "allow" {
+		return nil
+	}
+	for _, pair := range filter.List {
+		if len(pair) != 2 || strings.Contains(pair[0]+pair[1], "*") {
+			return nil
+		}
+	}
+	return filter.List
+}
@@ -0,0 +1,139 @@
+package ibcclients
+
+import (
+	"context"
+	"time"
+)
+
+// Client statuses, from best to worst
+const (
+	StatusOK       = "ok"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+	StatusExpired  = "expired"
+	StatusFrozen   = "frozen"
+	StatusUnknown  = "unknown"
+)
+
+// AlertResolved is the level of an alert sent when a client is refreshed
+// back out of an alert state
+const AlertResolved = "resolved"
+
+// Height is an IBC height
+type Height struct {
+	RevisionNumber uint64 `json:"revision_number"`
+	RevisionHeight uint64 `json:"revision_height"`
+}
+
+// ClientState is the part of a Tendermint light client state the watcher
+// needs
+type ClientState struct {
+	ClientID string `json:"client_id"`
+	// CounterpartyChainID is the chain the client tracks
+	CounterpartyChainID string        `json:"counterparty_chain_id"`
+	TrustingPeriod      time.Duration `json:"trusting_period"`
+	LatestHeight        Height        `json:"latest_height"`
+	Frozen              bool          `json:"frozen"`
+}
+
+// Refresh is the outcome of the last client update the watcher triggered
+type Refresh struct {
+	At      time.Time `json:"at"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// ClientStatus is the latest check of a light client hosted on ChainID
+type ClientStatus struct {
+	ChainID             string     `json:"chain_id"`
+	ClientID            string     `json:"client_id"`
+	CounterpartyChainID string     `json:"counterparty_chain_id,omitempty"`
+	LatestHeight        *Height    `json:"latest_height,omitempty"`
+	TrustingPeriod      string     `json:"trusting_period,omitempty"`
+	LastUpdate          *time.Time `json:"last_update,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	// RemainingSeconds is the time left until the trusting period ends,
+	// negative once the client has expired
+	RemainingSeconds *float64  `json:"remaining_seconds,omitempty"`
+	Status           string    `json:"status"`
+	Reason           string    `json:"reason,omitempty"`
+	Error            string    `json:"error,omitempty"`
+	Channels         []string  `json:"channels"`
+	LastRefresh      *Refresh  `json:"last_refresh,omitempty"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// ChannelStatus is the expiry status of the client behind a channel
+type ChannelStatus struct {
+	ChainID             string     `json:"chain_id"`
+	PortID              string     `json:"port_id"`
+	ChannelID           string     `json:"channel_id"`
+	ClientID            string     `json:"client_id,omitempty"`
+	CounterpartyChainID string     `json:"counterparty_chain_id,omitempty"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	RemainingSeconds    *float64   `json:"remaining_seconds,omitempty"`
+	Status              string     `json:"status"`
+	Error               string     `json:"error,omitempty"`
+}
+
+// Alert is raised when a client's status changes
+type Alert struct {
+	ChainID             string     `json:"chain_id"`
+	ClientID            string     `json:"client_id"`
+	CounterpartyChainID string     `json:"counterparty_chain_id"`
+	Channels            []string   `json:"channels"`
+	Level               string     `json:"level"`
+	Message             string     `json:"message"`
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	Timestamp           time.Time  `json:"timestamp"`
+}
+
+// Thresholds decide when a client needs attention
+type Thresholds struct {
+	// Warning and Critical apply to the time left in the trusting period
+	Warning  time.Duration
+	Critical time.Duration
+	// RefreshBefore is how long before expiry an automatic update is
+	// triggered, when enabled
+	RefreshBefore time.Duration
+}
+
+// Querier reads light client state from the chain hosting the client
+type Querier interface {
+	// ChannelClient returns the client a channel's connection is built on
+	ChannelClient(ctx context.Context, chainID, portID, channelID string) (*ClientState, error)
+	// ConsensusTimestamp returns the header time of a client's consensus
+	// state at height
+	ConsensusTimestamp(ctx context.Context, chainID, clientID string, height Height) (time.Time, error)
+}
+
+// ConfigReader returns the current config of a relayer
+type ConfigReader interface {
+	Current(relayer string) (string, error)
+}
+
+// Refresher updates a light client with a recent header of its counterparty
+type Refresher interface {
+	UpdateClient(ctx context.Context, hostChainID, clientID string) error
+}
+
+// RefresherFunc adapts a function to the Refresher interface
+type RefresherFunc func(ctx context.Context, hostChainID, clientID string) error
+
+// UpdateClient calls f(ctx, hostChainID, clientID)
+func (f RefresherFunc) UpdateClient(ctx context.Context, hostChainID, clientID string) error {
+	return f(ctx, hostChainID, clientID)
+}
+
+// Notifier receives client expiry alerts
+type Notifier interface {
+	Notify(alert Alert)
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(alert Alert)
+
+// Notify calls f(alert)
+func (f NotifierFunc) Notify(alert Alert) {
+	f(alert)
+}
@@ -0,0 +1,545 @@
+package ibcclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/relayooor/chains/config"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/database"
+	"relayooor/api/pkg/relayerconfig"
+)
+
+var (
+	ErrUnknownClient   = errors.New("client is not watched")
+	ErrRefreshDisabled = errors.New("no client refresher configured")
+	ErrNotRefreshable  = errors.New("client is on a chain Hermes doesn't relay")
+)
+
+// refreshRetryDelay keeps a failing automatic update from being retried
+// on every check
+const refreshRetryDelay = 30 * time.Minute
+
+// watchedClient is a client found through one or more channels
+type watchedClient struct {
+	state    *ClientState
+	channels []channelRef
+}
+
+// Watcher tracks how long the light clients behind the relayed channels
+// have left in their trusting period, raises alerts as expiry approaches
+// and can update clients through Hermes before they expire
+type Watcher struct {
+	configs     ConfigReader
+	registry    *config.ChainRegistry
+	querier     Querier
+	refresher   Refresher
+	autoRefresh bool
+	interval    time.Duration
+	thresholds  Thresholds
+	notifier    Notifier
+	metrics     *metrics
+	now         func() time.Time
+
+	// checkMu serialises checks and refreshes
+	checkMu sync.Mutex
+
+	mu           sync.RWMutex
+	clients      map[string]ClientStatus
+	channels     map[string]ChannelStatus
+	hermesChains map[string]bool
+
+	logger *zap.Logger
+}
+
+// NewWatcher creates a client expiry watcher. Settings come from
+// CLIENT_CHECK_INTERVAL, CLIENT_EXPIRY_WARNING, CLIENT_EXPIRY_CRITICAL,
+// CLIENT_AUTO_REFRESH and CLIENT_REFRESH_BEFORE.
+func NewWatcher(configs ConfigReader, registry *config.ChainRegistry, querier Querier, logger *zap.Logger) *Watcher {
+	warning := database.EnvDuration("CLIENT_EXPIRY_WARNING", 72*time.Hour)
+	return &Watcher{
+		configs:     configs,
+		registry:    registry,
+		querier:     querier,
+		autoRefresh: os.Getenv("CLIENT_AUTO_REFRESH") == "true",
+		interval:    database.EnvDuration("CLIENT_CHECK_INTERVAL", 10*time.Minute),
+		thresholds: Thresholds{
+			Warning:       warning,
+			Critical:      database.EnvDuration("CLIENT_EXPIRY_CRITICAL", 24*time.Hour),
+			RefreshBefore: database.EnvDuration("CLIENT_REFRESH_BEFORE", warning),
+		},
+		metrics:      newMetrics(),
+		now:          time.Now,
+		clients:      make(map[string]ClientStatus),
+		channels:     make(map[string]ChannelStatus),
+		hermesChains: make(map[string]bool),
+		logger:       logger.With(zap.String("component", "client_watcher")),
+	}
+}
+
+// UseRefresher sets how clients are updated. Automatic updates also need
+// CLIENT_AUTO_REFRESH=true; manual refreshes only need a refresher.
+func (w *Watcher) UseRefresher(refresher Refresher) {
+	w.refresher = refresher
+}
+
+// UseNotifier sets where alerts are sent in addition to the log
+func (w *Watcher) UseNotifier(notifier Notifier) {
+	w.notifier = notifier
+}
+
+// Register adds the watcher's metrics to a Prometheus registry
+func (w *Watcher) Register(registerer prometheus.Registerer) error {
+	for _, collector := range w.metrics.collectors() {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start checks clients immediately and then every interval until ctx is done
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check resolves the client of every watched channel, computes how long
+// each has left and updates those about to expire when enabled
+func (w *Watcher) Check(ctx context.Context) []ClientStatus {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	refs, hermesChains := w.discover()
+
+	clients := make(map[string]*watchedClient)
+	channels := make(map[string]ChannelStatus, len(refs))
+	var order []string
+	for _, ref := range refs {
+		status := ChannelStatus{ChainID: ref.ChainID, PortID: ref.PortID, ChannelID: ref.ChannelID, Status: StatusUnknown}
+
+		state, err := w.querier.ChannelClient(ctx, ref.ChainID, ref.PortID, ref.ChannelID)
+		if err != nil {
+			status.Error = err.Error()
+			channels[channelKey(ref)] = status
+			w.logger.Debug("Failed to resolve channel client",
+				zap.String("chain_id", ref.ChainID),
+				zap.String("channel", ref.String()),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		key := clientKey(ref.ChainID, state.ClientID)
+		client, ok := clients[key]
+		if !ok {
+			client = &watchedClient{state: state}
+			clients[key] = client
+			order = append(order, key)
+		}
+		client.channels = append(client.channels, ref)
+		status.ClientID = state.ClientID
+		status.CounterpartyChainID = state.CounterpartyChainID
+		channels[channelKey(ref)] = status
+	}
+
+	w.mu.Lock()
+	w.hermesChains = hermesChains
+	w.mu.Unlock()
+
+	results := make([]ClientStatus, 0, len(order))
+	for _, key := range order {
+		client := clients[key]
+		result := w.checkClient(ctx, client)
+		if w.shouldRefresh(result, hermesChains) {
+			result = w.refresh(ctx, client, result)
+		}
+		results = append(results, result)
+	}
+
+	w.store(results, channels)
+	sortClients(results)
+	return results
+}
+
+// Refresh updates a watched client now and checks it again
+func (w *Watcher) Refresh(ctx context.Context, chainID, clientID string) (ClientStatus, error) {
+	if w.refresher == nil {
+		return ClientStatus{}, ErrRefreshDisabled
+	}
+
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	w.mu.RLock()
+	current, ok := w.clients[clientKey(chainID, clientID)]
+	refreshable := w.hermesChains[chainID]
+	w.mu.RUnlock()
+	if !ok {
+		return ClientStatus{}, fmt.Errorf("%w: %s on %s", ErrUnknownClient, clientID, chainID)
+	}
+	if !refreshable {
+		return ClientStatus{}, fmt.Errorf("%w: %s", ErrNotRefreshable, chainID)
+	}
+
+	client := &watchedClient{state: &ClientState{ClientID: clientID}}
+	for _, channel := range current.Channels {
+		if ref, ok := parseChannel(chainID, channel); ok {
+			client.channels = append(client.channels, ref)
+		}
+	}
+
+	result := w.refresh(ctx, client, current)
+	w.mu.Lock()
+	w.clients[clientKey(chainID, clientID)] = result
+	w.updateChannels(result)
+	w.mu.Unlock()
+
+	w.metrics.observe(result)
+	w.transition(result, current.Status)
+
+	if result.LastRefresh != nil && !result.LastRefresh.Success {
+		return result, errors.New(result.LastRefresh.Error)
+	}
+	return result, nil
+}
+
+// Clients returns the latest check of every client, or of one chain's
+func (w *Watcher) Clients(chainID string) []ClientStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	results := make([]ClientStatus, 0, len(w.clients))
+	for _, client := range w.clients {
+		if chainID == "" || client.ChainID == chainID {
+			results = append(results, client)
+		}
+	}
+	sortClients(results)
+	return results
+}
+
+// Channels returns the client status of every watched channel, or of one
+// chain's
+func (w *Watcher) Channels(chainID string) []ChannelStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	results := make([]ChannelStatus, 0, len(w.channels))
+	for _, channel := range w.channels {
+		if chainID == "" || channel.ChainID == chainID {
+			results = append(results, channel)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ChainID != results[j].ChainID {
+			return results[i].ChainID < results[j].ChainID
+		}
+		return results[i].ChannelID < results[j].ChannelID
+	})
+	return results
+}
+
+// Alerts returns the clients currently in an alert state
+func (w *Watcher) Alerts() []Alert {
+	var alerts []Alert
+	for _, client := range w.Clients("") {
+		if isAlert(client.Status) {
+			alerts = append(alerts, newAlert(client, client.Status))
+		}
+	}
+	return alerts
+}
+
+func (w *Watcher) discover() ([]channelRef, map[string]bool) {
+	hermesConfig, err := w.configs.Current(relayerconfig.RelayerHermes)
+	if err != nil {
+		w.logger.Warn("Failed to read Hermes config", zap.Error(err))
+	}
+	rlyConfig, err := w.configs.Current(relayerconfig.RelayerRly)
+	if err != nil {
+		w.logger.Warn("Failed to read rly config", zap.Error(err))
+	}
+
+	refs, hermesChains, err := watchedChannels(hermesConfig, rlyConfig, w.registry)
+	if err != nil {
+		w.logger.Warn("Failed to parse relayer config", zap.Error(err))
+	}
+	return refs, hermesChains
+}
+
+// checkClient reads the consensus state at the client's latest height and
+// evaluates the time left in its trusting period
+func (w *Watcher) checkClient(ctx context.Context, client *watchedClient) ClientStatus {
+	state := client.state
+	chainID := client.channels[0].ChainID
+	result := ClientStatus{
+		ChainID:             chainID,
+		ClientID:            state.ClientID,
+		CounterpartyChainID: state.CounterpartyChainID,
+		Channels:            make([]string, 0, len(client.channels)),
+		CheckedAt:           w.now(),
+	}
+	for _, ref := range client.channels {
+		result.Channels = append(result.Channels, ref.String())
+	}
+	if state.TrustingPeriod > 0 {
+		result.TrustingPeriod = state.TrustingPeriod.String()
+		height := state.LatestHeight
+		result.LatestHeight = &height
+	}
+
+	w.mu.RLock()
+	if previous, ok := w.clients[clientKey(chainID, state.ClientID)]; ok {
+		result.LastRefresh = previous.LastRefresh
+	}
+	w.mu.RUnlock()
+
+	if state.Frozen {
+		result.Status, result.Reason = StatusFrozen, "client is frozen after misbehaviour"
+		return result
+	}
+
+	timestamp, err := w.querier.ConsensusTimestamp(ctx, chainID, state.ClientID, state.LatestHeight)
+	if err != nil {
+		result.Status, result.Error = StatusUnknown, err.Error()
+		return result
+	}
+
+	expiresAt := timestamp.Add(state.TrustingPeriod)
+	remaining := expiresAt.Sub(result.CheckedAt).Seconds()
+	result.LastUpdate = &timestamp
+	result.ExpiresAt = &expiresAt
+	result.RemainingSeconds = &remaining
+	result.Status, result.Reason = w.evaluate(expiresAt.Sub(result.CheckedAt))
+	return result
+}
+
+// evaluate returns the status of a client with the given time left and why
+func (w *Watcher) evaluate(remaining time.Duration) (string, string) {
+	switch {
+	case remaining <= 0:
+		return StatusExpired, "trusting period has ended, the client needs a governance recovery"
+	case remaining < w.thresholds.Critical:
+		return StatusCritical, fmt.Sprintf("expires in %s, below %s", remaining.Round(time.Minute), w.thresholds.Critical)
+	case remaining < w.thresholds.Warning:
+		return StatusWarning, fmt.Sprintf("expires in %s, below %s", remaining.Round(time.Minute), w.thresholds.Warning)
+	}
+	return StatusOK, ""
+}
+
+// shouldRefresh reports whether an automatic update is due. Expired and
+// frozen clients can't be updated, and failed attempts wait before retrying.
+func (w *Watcher) shouldRefresh(client ClientStatus, hermesChains map[string]bool) bool {
+	if !w.autoRefresh || w.refresher == nil || !hermesChains[client.ChainID] {
+		return false
+	}
+	if client.RemainingSeconds == nil || *client.RemainingSeconds <= 0 {
+		return false
+	}
+	if time.Duration(*client.RemainingSeconds*float64(time.Second)) >= w.thresholds.RefreshBefore {
+		return false
+	}
+	last := client.LastRefresh
+	return last == nil || last.Success || client.CheckedAt.Sub(last.At) >= refreshRetryDelay
+}
+
+// refresh updates a client and checks it again through its first channel
+func (w *Watcher) refresh(ctx context.Context, client *watchedClient, current ClientStatus) ClientStatus {
+	chainID, clientID := current.ChainID, current.ClientID
+	w.logger.Info("Updating light client",
+		zap.String("chain_id", chainID),
+		zap.String("client_id", clientID),
+		zap.String("status", current.Status),
+	)
+
+	refresh := &Refresh{At: w.now(), Success: true}
+	if err := w.refresher.UpdateClient(ctx, chainID, clientID); err != nil {
+		refresh.Success, refresh.Error = false, err.Error()
+		w.metrics.refreshes.WithLabelValues(chainID, "failure").Inc()
+		w.logger.Error("Failed to update light client",
+			zap.String("chain_id", chainID),
+			zap.String("client_id", clientID),
+			zap.Error(err),
+		)
+		current.LastRefresh = refresh
+		return current
+	}
+	w.metrics.refreshes.WithLabelValues(chainID, "success").Inc()
+
+	result := current
+	if len(client.channels) > 0 {
+		ref := client.channels[0]
+		if state, err := w.querier.ChannelClient(ctx, ref.ChainID, ref.PortID, ref.ChannelID); err == nil && state.ClientID == clientID {
+			client.state = state
+			result = w.checkClient(ctx, client)
+		}
+	}
+	result.LastRefresh = refresh
+	return result
+}
+
+// store replaces the check results, forgetting clients that are no longer
+// watched, and raises alerts for status changes
+func (w *Watcher) store(results []ClientStatus, channels map[string]ChannelStatus) {
+	w.mu.Lock()
+	previous := w.clients
+	w.clients = make(map[string]ClientStatus, len(results))
+	w.channels = channels
+	for _, result := range results {
+		w.clients[clientKey(result.ChainID, result.ClientID)] = result
+		w.updateChannels(result)
+	}
+	w.mu.Unlock()
+
+	for key, old := range previous {
+		if _, ok := w.clients[key]; !ok {
+			w.metrics.forget(old)
+		}
+	}
+	for _, result := range results {
+		w.metrics.observe(result)
+
+		previousStatus := StatusOK
+		if old, ok := previous[clientKey(result.ChainID, result.ClientID)]; ok {
+			previousStatus = old.Status
+		}
+		w.transition(result, previousStatus)
+	}
+}
+
+// updateChannels copies a client's expiry onto the channels built on it.
+// The caller holds w.mu.
+func (w *Watcher) updateChannels(client ClientStatus) {
+	for _, channel := range client.Channels {
+		ref, ok := parseChannel(client.ChainID, channel)
+		if !ok {
+			continue
+		}
+		key := channelKey(ref)
+		status := w.channels[key]
+		status.ChainID, status.PortID, status.ChannelID = ref.ChainID, ref.PortID, ref.ChannelID
+		status.ClientID = client.ClientID
+		status.CounterpartyChainID = client.CounterpartyChainID
+		status.ExpiresAt = client.ExpiresAt
+		status.RemainingSeconds = client.RemainingSeconds
+		status.Status = client.Status
+		status.Error = client.Error
+		w.channels[key] = status
+	}
+}
+
+// transition logs and notifies when a client enters or leaves an alert state
+func (w *Watcher) transition(client ClientStatus, previous string) {
+	if client.Status == previous {
+		return
+	}
+	if client.Status == StatusUnknown {
+		w.logger.Warn("Light client check failed",
+			zap.String("chain_id", client.ChainID),
+			zap.String("client_id", client.ClientID),
+			zap.String("error", client.Error),
+		)
+		return
+	}
+
+	level := client.Status
+	if level == StatusOK {
+		// Recovering from unknown isn't worth an alert
+		if previous == StatusUnknown {
+			return
+		}
+		level = AlertResolved
+	}
+
+	alert := newAlert(client, level)
+	fields := []zap.Field{
+		zap.String("chain_id", client.ChainID),
+		zap.String("client_id", client.ClientID),
+		zap.String("counterparty_chain_id", client.CounterpartyChainID),
+		zap.Strings("channels", client.Channels),
+		zap.String("message", alert.Message),
+	}
+	switch level {
+	case StatusCritical, StatusExpired, StatusFrozen:
+		w.logger.Error("OPERATOR ALERT: light client expiring", fields...)
+	case StatusWarning:
+		w.logger.Warn("Light client approaching expiry", fields...)
+	default:
+		w.logger.Info("Light client refreshed", fields...)
+	}
+
+	if w.notifier != nil {
+		w.notifier.Notify(alert)
+	}
+}
+
+func newAlert(client ClientStatus, level string) Alert {
+	message := client.Reason
+	if level == AlertResolved {
+		message = "client updated, trusting period renewed"
+	}
+	return Alert{
+		ChainID:             client.ChainID,
+		ClientID:            client.ClientID,
+		CounterpartyChainID: client.CounterpartyChainID,
+		Channels:            client.Channels,
+		Level:               level,
+		Message:             message,
+		ExpiresAt:           client.ExpiresAt,
+		Timestamp:           client.CheckedAt,
+	}
+}
+
+func isAlert(status string) bool {
+	switch status {
+	case StatusWarning, StatusCritical, StatusExpired, StatusFrozen:
+		return true
+	}
+	return false
+}
+
+func clientKey(chainID, clientID string) string {
+	return chainID + "/" + clientID
+}
+
+func channelKey(ref channelRef) string {
+	return ref.ChainID + "/" + ref.String()
+}
+
+// parseChannel reverses channelRef.String
+func parseChannel(chainID, channel string) (channelRef, bool) {
+	i := strings.LastIndex(channel, "/")
+	if i < 0 {
+		return channelRef{}, false
+	}
+	return channelRef{ChainID: chainID, PortID: channel[:i], ChannelID: channel[i+1:]}, true
+}
+
+func sortClients(clients []ClientStatus) {
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].ChainID != clients[j].ChainID {
+			return clients[i].ChainID < clients[j].ChainID
+		}
+		return clients[i].ClientID < clients[j].ClientID
+	})
+}
@@ -0,0 +1,317 @@
+package ibcclients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/relayerconfig"
+)
+
+const testHermesConfig = `[[chains]]
+id = 'cosmoshub-4'
+[chains.packet_filter]
+policy = 'allow'
+list = [['transfer', 'channel-141'], ['transfer', 'channel-750']]
+
+[[chains]]
+id = 'osmosis-1'
+[chains.packet_filter]
+policy = 'allow'
+list = [['transfer', 'channel-*']]
+`
+
+const testRlyConfig = `chains:
+  noble:
+    type: cosmos
+    value:
+      key: default
+      chain-id: noble-1
+`
+
+var testRegistry = &config.ChainRegistry{
+	Channels: []config.ChannelConfig{
+		{SourceChain: "osmosis-1", SourceChannel: "channel-0", SourcePort: "transfer", DestChain: "cosmoshub-4", Status: "active"},
+		{SourceChain: "osmosis-1", SourceChannel: "channel-9", SourcePort: "transfer", DestChain: "juno-1", Status: "inactive"},
+		{SourceChain: "noble-1", SourceChannel: "channel-1", SourcePort: "transfer", DestChain: "osmosis-1", Status: "active"},
+	},
+}
+
+type fakeConfigs map[string]string
+
+func (f fakeConfigs) Current(relayer string) (string, error) {
+	return f[relayer], nil
+}
+
+// fakeQuerier serves clients by chain/channel and consensus timestamps by
+// chain/client
+type fakeQuerier struct {
+	channels   map[string]*ClientState
+	timestamps map[string]time.Time
+}
+
+func (f *fakeQuerier) ChannelClient(ctx context.Context, chainID, portID, channelID string) (*ClientState, error) {
+	state, ok := f.channels[chainID+"/"+channelID]
+	if !ok {
+		return nil, fmt.Errorf("channel %s not found", channelID)
+	}
+	copied := *state
+	return &copied, nil
+}
+
+func (f *fakeQuerier) ConsensusTimestamp(ctx context.Context, chainID, clientID string, height Height) (time.Time, error) {
+	timestamp, ok := f.timestamps[chainID+"/"+clientID]
+	if !ok {
+		return time.Time{}, errors.New("no consensus state")
+	}
+	return timestamp, nil
+}
+
+func newTestWatcher(t *testing.T) (*Watcher, *fakeQuerier, time.Time) {
+	t.Helper()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	querier := &fakeQuerier{
+		channels: map[string]*ClientState{
+			// Both hub channels share a client
+			"cosmoshub-4/channel-141": {ClientID: "07-tendermint-259", CounterpartyChainID: "osmosis-1", TrustingPeriod: week},
+			"cosmoshub-4/channel-750": {ClientID: "07-tendermint-259", CounterpartyChainID: "osmosis-1", TrustingPeriod: week},
+			"osmosis-1/channel-0":     {ClientID: "07-tendermint-0", CounterpartyChainID: "cosmoshub-4", TrustingPeriod: week},
+			"noble-1/channel-1":       {ClientID: "07-tendermint-3", CounterpartyChainID: "osmosis-1", TrustingPeriod: week, Frozen: true},
+		},
+		timestamps: map[string]time.Time{
+			// 12h left
+			"cosmoshub-4/07-tendermint-259": now.Add(-week + 12*time.Hour),
+			// 6 days left
+			"osmosis-1/07-tendermint-0": now.Add(-24 * time.Hour),
+		},
+	}
+
+	w := NewWatcher(fakeConfigs{
+		relayerconfig.RelayerHermes: testHermesConfig,
+		relayerconfig.RelayerRly:    testRlyConfig,
+	}, testRegistry, querier, zap.NewNop())
+	w.now = func() time.Time { return now }
+	return w, querier, now
+}
+
+func TestWatchedChannels(t *testing.T) {
+	refs, hermesChains, err := watchedChannels(testHermesConfig, testRlyConfig, testRegistry)
+	require.NoError(t, err)
+
+	var channels []string
+	for _, ref := range refs {
+		channels = append(channels, channelKey(ref))
+	}
+	// The wildcard filter on osmosis falls back to the active registry channels
+	assert.Equal(t, []string{
+		"cosmoshub-4/transfer/channel-141",
+		"cosmoshub-4/transfer/channel-750",
+		"noble-1/transfer/channel-1",
+		"osmosis-1/transfer/channel-0",
+	}, channels)
+	assert.Equal(t, map[string]bool{"cosmoshub-4": true, "osmosis-1": true}, hermesChains)
+}
+
+func TestCheckEvaluatesExpiry(t *testing.T) {
+	w, _, now := newTestWatcher(t)
+
+	var alerts []Alert
+	w.UseNotifier(NotifierFunc(func(alert Alert) { alerts = append(alerts, alert) }))
+
+	clients := w.Check(context.Background())
+	require.Len(t, clients, 3)
+
+	hub := clients[0]
+	assert.Equal(t, "07-tendermint-259", hub.ClientID)
+	assert.Equal(t, StatusCritical, hub.Status)
+	assert.Equal(t, []string{"transfer/channel-141", "transfer/channel-750"}, hub.Channels)
+	require.NotNil(t, hub.ExpiresAt)
+	assert.Equal(t, now.Add(12*time.Hour), *hub.ExpiresAt)
+	assert.Equal(t, 12*3600.0, *hub.RemainingSeconds)
+
+	assert.Equal(t, "noble-1", clients[1].ChainID)
+	assert.Equal(t, StatusFrozen, clients[1].Status)
+	assert.Equal(t, StatusOK, clients[2].Status)
+
+	channels := w.Channels("cosmoshub-4")
+	require.Len(t, channels, 2)
+	assert.Equal(t, "07-tendermint-259", channels[1].ClientID)
+	assert.Equal(t, StatusCritical, channels[1].Status)
+
+	require.Len(t, alerts, 2)
+	assert.Equal(t, StatusCritical, alerts[0].Level)
+	assert.Equal(t, StatusFrozen, alerts[1].Level)
+	assert.Len(t, w.Alerts(), 2)
+
+	// A second check with the same state doesn't repeat the alerts
+	w.Check(context.Background())
+	assert.Len(t, alerts, 2)
+}
+
+func TestAutoRefresh(t *testing.T) {
+	w, querier, now := newTestWatcher(t)
+	w.autoRefresh = true
+
+	var updates []string
+	w.UseRefresher(RefresherFunc(func(ctx context.Context, chainID, clientID string) error {
+		updates = append(updates, chainID+"/"+clientID)
+		querier.timestamps[chainID+"/"+clientID] = now
+		return nil
+	}))
+
+	var alerts []Alert
+	w.UseNotifier(NotifierFunc(func(alert Alert) { alerts = append(alerts, alert) }))
+
+	clients := w.Check(context.Background())
+
+	// Only the hub client is inside the refresh window; the frozen noble
+	// client isn't updatable and noble isn't a Hermes chain anyway
+	assert.Equal(t, []string{"cosmoshub-4/07-tendermint-259"}, updates)
+	assert.Equal(t, StatusOK, clients[0].Status)
+	require.NotNil(t, clients[0].LastRefresh)
+	assert.True(t, clients[0].LastRefresh.Success)
+	assert.Equal(t, 7*24*3600.0, *clients[0].RemainingSeconds)
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, StatusFrozen, alerts[0].Level)
+}
+
+func TestAutoRefreshFailureWaitsBeforeRetrying(t *testing.T) {
+	w, _, now := newTestWatcher(t)
+	w.autoRefresh = true
+
+	attempts := 0
+	w.UseRefresher(RefresherFunc(func(ctx context.Context, chainID, clientID string) error {
+		attempts++
+		return errors.New("no route to host")
+	}))
+
+	clients := w.Check(context.Background())
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, StatusCritical, clients[0].Status)
+	require.NotNil(t, clients[0].LastRefresh)
+	assert.False(t, clients[0].LastRefresh.Success)
+
+	w.Check(context.Background())
+	assert.Equal(t, 1, attempts)
+
+	w.now = func() time.Time { return now.Add(refreshRetryDelay) }
+	w.Check(context.Background())
+	assert.Equal(t, 2, attempts)
+}
+
+func TestManualRefresh(t *testing.T) {
+	w, querier, now := newTestWatcher(t)
+	ctx := context.Background()
+
+	_, err := w.Refresh(ctx, "cosmoshub-4", "07-tendermint-259")
+	assert.ErrorIs(t, err, ErrRefreshDisabled)
+
+	w.UseRefresher(RefresherFunc(func(ctx context.Context, chainID, clientID string) error {
+		querier.timestamps[chainID+"/"+clientID] = now
+		return nil
+	}))
+	w.Check(ctx)
+
+	_, err = w.Refresh(ctx, "cosmoshub-4", "07-tendermint-999")
+	assert.ErrorIs(t, err, ErrUnknownClient)
+	_, err = w.Refresh(ctx, "noble-1", "07-tendermint-3")
+	assert.ErrorIs(t, err, ErrNotRefreshable)
+
+	var alerts []Alert
+	w.UseNotifier(NotifierFunc(func(alert Alert) { alerts = append(alerts, alert) }))
+
+	client, err := w.Refresh(ctx, "cosmoshub-4", "07-tendermint-259")
+	require.NoError(t, err)
+	assert.Equal(t, StatusOK, client.Status)
+	assert.Equal(t, StatusOK, w.Channels("cosmoshub-4")[0].Status)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertResolved, alerts[0].Level)
+}
+
+func TestMetrics(t *testing.T) {
+	w, _, _ := newTestWatcher(t)
+	registry := prometheus.NewRegistry()
+	require.NoError(t, w.Register(registry))
+
+	w.Check(context.Background())
+
+	labels := []string{"cosmoshub-4", "07-tendermint-259", "osmosis-1"}
+	assert.Equal(t, 12*3600.0, testutil.ToFloat64(w.metrics.remaining.WithLabelValues(labels...)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(w.metrics.status.WithLabelValues(labels...)))
+	assert.Equal(t, 4.0, testutil.ToFloat64(w.metrics.status.WithLabelValues("noble-1", "07-tendermint-3", "osmosis-1")))
+
+	// Dropping Hermes forgets its clients
+	w.configs = fakeConfigs{relayerconfig.RelayerRly: testRlyConfig}
+	w.Check(context.Background())
+	assert.Equal(t, 1, testutil.CollectAndCount(w.metrics.status))
+}
+
+func TestRESTQuerier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ibc/core/channel/v1/channels/channel-141/ports/transfer/client_state":
+			w.Write([]byte(`{"identified_client_state":{"client_id":"07-tendermint-259","client_state":{
+				"@type":"/ibc.lightclients.tendermint.v1.ClientState","chain_id":"osmosis-1",
+				"trusting_period":"1209600s",
+				"frozen_height":{"revision_number":"0","revision_height":"0"},
+				"latest_height":{"revision_number":"1","revision_height":"18234567"}}}}`))
+		case "/ibc/core/client/v1/consensus_states/07-tendermint-259/revision/1/height/18234567":
+			w.Write([]byte(`{"consensus_state":{"@type":"/ibc.lightclients.tendermint.v1.ConsensusState","timestamp":"2026-02-20T08:30:00.123Z"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	querier := NewRESTQuerier(&config.ChainRegistry{Chains: map[string]config.ChainConfig{
+		"cosmoshub-4": {ChainID: "cosmoshub-4", RESTEndpoint: server.URL + "/"},
+	}})
+	ctx := context.Background()
+
+	state, err := querier.ChannelClient(ctx, "cosmoshub-4", "transfer", "channel-141")
+	require.NoError(t, err)
+	assert.Equal(t, &ClientState{
+		ClientID:            "07-tendermint-259",
+		CounterpartyChainID: "osmosis-1",
+		TrustingPeriod:      14 * 24 * time.Hour,
+		LatestHeight:        Height{RevisionNumber: 1, RevisionHeight: 18234567},
+	}, state)
+
+	timestamp, err := querier.ConsensusTimestamp(ctx, "cosmoshub-4", state.ClientID, state.LatestHeight)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 2, 20, 8, 30, 0, 123000000, time.UTC), timestamp)
+
+	_, err = querier.ChannelClient(ctx, "cosmoshub-4", "transfer", "channel-0")
+	assert.Error(t, err)
+	_, err = querier.ChannelClient(ctx, "juno-1", "transfer", "channel-0")
+	assert.Error(t, err)
+}
+
+func TestHermesRefresher(t *testing.T) {
+	refresher := NewHermesRefresher("/etc/hermes/config.toml")
+	var command []string
+	refresher.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		command = append([]string{name}, args...)
+		return []byte("ERROR client expired"), errors.New("exit status 1")
+	}
+
+	err := refresher.UpdateClient(context.Background(), "cosmoshub-4", "07-tendermint-259")
+	assert.Equal(t, []string{"hermes", "--config", "/etc/hermes/config.toml",
+		"update", "client", "--host-chain", "cosmoshub-4", "--client", "07-tendermint-259"}, command)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "client expired")
+}
@@ -43,7 +43,13 @@ func InitLogger(env string) error {
 }
 
 // Helper functions for structured logging
+
+// With returns a child of Logger, or of zap's global logger before
+// InitLogger has been called
 func With(fields ...zap.Field) *zap.Logger {
+	if Logger == nil {
+		return zap.L().With(fields...)
+	}
 	return Logger.With(fields...)
 }
 
@@ -0,0 +1,66 @@
+package logstream
+
+import "sync"
+
+// RingBuffer keeps the most recent entries so late joiners get context
+type RingBuffer struct {
+	mu      sync.RWMutex
+	entries []*Entry
+	next    int
+	full    bool
+}
+
+// NewRingBuffer creates a buffer holding up to size entries
+func NewRingBuffer(size int) *RingBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBuffer{entries: make([]*Entry, size)}
+}
+
+// Add stores an entry, evicting the oldest when full
+func (b *RingBuffer) Add(entry *Entry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Recent returns up to limit matching entries with an ID greater than
+// afterID, oldest first. limit <= 0 means no limit.
+func (b *RingBuffer) Recent(filter Filter, afterID uint64, limit int) []*Entry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	count := b.next
+	start := 0
+	if b.full {
+		count = len(b.entries)
+		start = b.next
+	}
+
+	// Walk newest to oldest so the limit keeps the latest entries
+	var matched []*Entry
+	for i := count - 1; i >= 0; i-- {
+		entry := b.entries[(start+i)%len(b.entries)]
+		if entry.ID <= afterID {
+			break
+		}
+		if !filter.Matches(entry) {
+			continue
+		}
+		matched = append(matched, entry)
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+	}
+
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched
+}
@@ -0,0 +1,150 @@
+package logstream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBacklog    = 100
+	maxLimit          = 1000
+	heartbeatInterval = 15 * time.Second
+)
+
+// Handlers exposes relayer logs over HTTP and Server-Sent Events
+type Handlers struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+// NewHandlers creates log stream handlers
+func NewHandlers(service *Service, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		service: service,
+		logger:  logger.With(zap.String("component", "log_stream_handlers")),
+	}
+}
+
+// RegisterRoutes registers log routes on the relayer group
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/logs", h.GetLogs)
+	router.GET("/logs/stream", h.StreamLogs)
+}
+
+// GetLogs handles GET /relayer/logs, returning buffered entries
+func (h *Handlers) GetLogs(c *gin.Context) {
+	var filter Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := queryInt(c, "limit", 200)
+	entries := h.service.Recent(filter, 0, limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// StreamLogs handles GET /relayer/logs/stream. It replays recent entries
+// (the last `backlog`, or everything after Last-Event-ID when reconnecting)
+// and then streams live entries as SSE "log" events.
+func (h *Handlers) StreamLogs(c *gin.Context) {
+	var filter Filter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Subscribe before reading the backlog so nothing falls in between
+	sub, cancel := h.service.Subscribe(filter)
+	defer cancel()
+
+	var backlog []*Entry
+	lastID, err := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if err == nil {
+		backlog = h.service.Recent(filter, lastID, 0)
+	} else {
+		backlog = h.service.Recent(filter, 0, queryInt(c, "backlog", defaultBacklog))
+	}
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Debug("Could not clear write deadline", zap.Error(err))
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	var sent uint64
+	for _, entry := range backlog {
+		if err := writeEvent(c, entry); err != nil {
+			return
+		}
+		sent = entry.ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	var reportedDrops uint64
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case entry, ok := <-sub.Entries():
+			if !ok {
+				return
+			}
+			// Already sent as part of the backlog
+			if entry.ID <= sent {
+				continue
+			}
+			if err := writeEvent(c, entry); err != nil {
+				return
+			}
+			if dropped := sub.Dropped(); dropped > reportedDrops {
+				fmt.Fprintf(c.Writer, "event: dropped\ndata: {\"count\":%d}\n\n", dropped-reportedDrops)
+				reportedDrops = dropped
+			}
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: log\ndata: %s\n\n", entry.ID, data)
+	return err
+}
+
+func queryInt(c *gin.Context, key string, defaultValue int) int {
+	value, err := strconv.Atoi(c.Query(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	if value > maxLimit {
+		return maxLimit
+	}
+	return value
+}
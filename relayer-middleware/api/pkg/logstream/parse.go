@@ -0,0 +1,211 @@
+package logstream
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	// key=value pairs from Hermes spans, e.g. packet{src_chain=osmosis-1 src_channel=channel-0}
+	spanFieldRegex = regexp.MustCompile(`\b([a-z_]+)=([\w./-]+)`)
+	channelRegex   = regexp.MustCompile(`\bchannel-\d+\b`)
+)
+
+// Field names that identify chains and channels in Hermes spans and rly fields
+var (
+	chainFields = map[string]bool{
+		"chain": true, "chain_id": true, "src_chain": true, "dst_chain": true,
+		"src_chain_id": true, "dst_chain_id": true, "host_chain": true,
+	}
+	channelFields = map[string]bool{
+		"channel": true, "channel_id": true, "src_channel": true, "dst_channel": true,
+		"src_channel_id": true, "dst_channel_id": true,
+	}
+)
+
+// ParseLine parses a log line from the given relayer. It understands Hermes
+// plain and JSON (log_json = true) output and rly console and JSON output.
+// Lines that don't match any format are kept with the raw text as message.
+func ParseLine(relayer, line string) *Entry {
+	line = strings.TrimRight(ansiRegex.ReplaceAllString(line, ""), "\r\n")
+	entry := &Entry{Relayer: relayer, Raw: line, Message: line}
+
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			parseJSON(entry, fields)
+			finish(entry)
+			return entry
+		}
+	}
+
+	parseText(entry, trimmed)
+	finish(entry)
+	return entry
+}
+
+// parseJSON handles Hermes tracing JSON and rly zap JSON
+func parseJSON(entry *Entry, fields map[string]interface{}) {
+	entry.Level = normalizeLevel(stringValue(fields["level"]))
+	entry.Timestamp = parseTimestamp(fields["timestamp"])
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = parseTimestamp(fields["ts"])
+	}
+
+	extra := make(map[string]interface{})
+
+	// Hermes: {"fields": {"message": ...}, "span": {...}, "spans": [...]}
+	if nested, ok := fields["fields"].(map[string]interface{}); ok {
+		entry.Message = stringValue(nested["message"])
+		for key, value := range nested {
+			if key != "message" {
+				extra[key] = value
+			}
+		}
+	}
+	if span, ok := fields["span"].(map[string]interface{}); ok {
+		collect(entry, span, extra)
+	}
+	if spans, ok := fields["spans"].([]interface{}); ok {
+		for _, s := range spans {
+			if span, ok := s.(map[string]interface{}); ok {
+				collect(entry, span, extra)
+			}
+		}
+	}
+
+	// rly: flat {"level", "ts", "msg", ...}
+	if msg := stringValue(fields["msg"]); msg != "" {
+		entry.Message = msg
+	}
+	for key, value := range fields {
+		switch key {
+		case "level", "timestamp", "ts", "msg", "fields", "span", "spans":
+			continue
+		}
+		extra[key] = value
+	}
+	collect(entry, extra, nil)
+
+	if len(extra) > 0 {
+		entry.Fields = extra
+	}
+}
+
+// parseText handles plain text lines:
+//
+//	Hermes: 2024-01-01T00:00:00.123Z  INFO ThreadId(01) packet{src_chain=osmosis-1}: message
+//	rly:    2024-01-01T00:00:00.123Z	info	message	{"chain_id": "osmosis-1"}
+func parseText(entry *Entry, line string) {
+	parts := strings.Fields(line)
+	if len(parts) >= 2 {
+		if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+			if level := normalizeLevel(parts[1]); level != "" {
+				entry.Timestamp = ts
+				entry.Level = level
+				rest := strings.TrimSpace(line[strings.Index(line, parts[1])+len(parts[1]):])
+				rest = strings.TrimSpace(strings.TrimPrefix(rest, threadID(rest)))
+				entry.Message = rest
+			}
+		}
+	}
+	if entry.Level == "" && len(parts) >= 1 {
+		// Hermes without timestamps: "INFO message"
+		if level := normalizeLevel(parts[0]); level != "" && strings.ToUpper(parts[0]) == parts[0] {
+			entry.Level = level
+			entry.Message = strings.TrimSpace(strings.TrimPrefix(line, parts[0]))
+		}
+	}
+
+	// rly console output ends with a JSON object of fields
+	if idx := strings.LastIndex(entry.Message, "\t{"); idx >= 0 {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(entry.Message[idx+1:]), &fields); err == nil {
+			entry.Message = strings.TrimSpace(entry.Message[:idx])
+			entry.Fields = fields
+			collect(entry, fields, nil)
+		}
+	}
+
+	for _, match := range spanFieldRegex.FindAllStringSubmatch(line, -1) {
+		addField(entry, match[1], match[2])
+	}
+	for _, channel := range channelRegex.FindAllString(line, -1) {
+		entry.Channels = appendUnique(entry.Channels, channel)
+	}
+}
+
+// threadID returns a leading Hermes "ThreadId(NN)" token, if any
+func threadID(s string) string {
+	if strings.HasPrefix(s, "ThreadId(") {
+		if end := strings.Index(s, ")"); end >= 0 {
+			return s[:end+1]
+		}
+	}
+	return ""
+}
+
+// collect records chains and channels from a field map, copying other
+// fields into extra when it is non-nil
+func collect(entry *Entry, fields map[string]interface{}, extra map[string]interface{}) {
+	for key, value := range fields {
+		if s, ok := value.(string); ok {
+			addField(entry, key, s)
+		}
+		if extra != nil && key != "name" {
+			extra[key] = value
+		}
+	}
+}
+
+func addField(entry *Entry, key, value string) {
+	if value == "" {
+		return
+	}
+	switch {
+	case chainFields[key]:
+		entry.Chains = appendUnique(entry.Chains, value)
+	case channelFields[key]:
+		entry.Channels = appendUnique(entry.Channels, value)
+	}
+}
+
+func finish(entry *Entry) {
+	sort.Strings(entry.Chains)
+	sort.Strings(entry.Channels)
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	if contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func parseTimestamp(v interface{}) time.Time {
+	switch ts := v.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return t
+		}
+	case float64:
+		// zap epoch seconds
+		sec := int64(ts)
+		return time.Unix(sec, int64((ts-float64(sec))*1e9)).UTC()
+	}
+	return time.Time{}
+}
@@ -0,0 +1,57 @@
+package logstream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHermesText(t *testing.T) {
+	entry := ParseLine("hermes", "\x1b[2m2024-05-01T12:00:00.123456Z\x1b[0m \x1b[33m WARN\x1b[0m ThreadId(42) packet_cmd{src_chain=osmosis-1 src_port=transfer src_channel=channel-0 dst_chain=cosmoshub-4}: failed to relay packet")
+
+	assert.Equal(t, LevelWarn, entry.Level)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC), entry.Timestamp)
+	assert.Equal(t, "packet_cmd{src_chain=osmosis-1 src_port=transfer src_channel=channel-0 dst_chain=cosmoshub-4}: failed to relay packet", entry.Message)
+	assert.Equal(t, []string{"cosmoshub-4", "osmosis-1"}, entry.Chains)
+	assert.Equal(t, []string{"channel-0"}, entry.Channels)
+}
+
+func TestParseHermesJSON(t *testing.T) {
+	entry := ParseLine("hermes", `{"timestamp":"2024-05-01T12:00:00.5Z","level":"ERROR","fields":{"message":"timeout","sequence":42},"target":"ibc_relayer::link","spans":[{"name":"worker.packet","src_chain":"noble-1","src_channel":"channel-1","dst_chain":"osmosis-1"}]}`)
+
+	assert.Equal(t, LevelError, entry.Level)
+	assert.Equal(t, "timeout", entry.Message)
+	assert.Equal(t, []string{"noble-1", "osmosis-1"}, entry.Chains)
+	assert.Equal(t, []string{"channel-1"}, entry.Channels)
+	assert.Equal(t, float64(42), entry.Fields["sequence"])
+	assert.Equal(t, "ibc_relayer::link", entry.Fields["target"])
+}
+
+func TestParseRly(t *testing.T) {
+	entry := ParseLine("rly", "2024-05-01T12:00:00.000000Z\tinfo\tSuccessful transaction\t{\"provider_type\": \"cosmos\", \"chain_id\": \"osmosis-1\", \"src_channel_id\": \"channel-0\"}")
+	assert.Equal(t, LevelInfo, entry.Level)
+	assert.Equal(t, "Successful transaction", entry.Message)
+	assert.Equal(t, []string{"osmosis-1"}, entry.Chains)
+	assert.Equal(t, []string{"channel-0"}, entry.Channels)
+
+	entry = ParseLine("rly", `{"level":"warn","ts":1714564800.5,"msg":"Packet timed out","src_chain_id":"cosmoshub-4","dst_channel_id":"channel-141"}`)
+	assert.Equal(t, LevelWarn, entry.Level)
+	assert.Equal(t, "Packet timed out", entry.Message)
+	assert.Equal(t, time.Unix(1714564800, 500000000).UTC(), entry.Timestamp)
+	assert.Equal(t, []string{"cosmoshub-4"}, entry.Chains)
+	assert.Equal(t, []string{"channel-141"}, entry.Channels)
+}
+
+func TestFilterMatches(t *testing.T) {
+	entry := &Entry{Relayer: "hermes", Level: LevelInfo, Chains: []string{"osmosis-1"}, Channels: []string{"channel-0"}}
+
+	assert.True(t, Filter{}.Matches(entry))
+	assert.True(t, Filter{Level: "INFO", Chain: "osmosis-1", Channel: "channel-0"}.Matches(entry))
+	assert.False(t, Filter{Level: "warn"}.Matches(entry))
+	assert.False(t, Filter{Relayer: "rly"}.Matches(entry))
+	assert.False(t, Filter{Channel: "channel-1"}.Matches(entry))
+
+	// Unparsed lines (e.g. stack traces) aren't hidden by level filters
+	assert.True(t, Filter{Level: "error"}.Matches(&Entry{Relayer: "hermes"}))
+}
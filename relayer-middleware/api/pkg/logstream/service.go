@@ -0,0 +1,195 @@
+package logstream
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer is how many entries a slow subscriber can fall behind
+// before entries are dropped for it
+const subscriberBuffer = 256
+
+// Source produces raw log lines for a relayer
+type Source interface {
+	// Name identifies the source, e.g. a file path
+	Name() string
+	// Run sends lines until ctx is done
+	Run(ctx context.Context, lines chan<- string) error
+}
+
+// Service collects relayer logs from sources, keeps recent entries in a
+// ring buffer and fans them out to subscribers
+type Service struct {
+	buffer *RingBuffer
+	lastID atomic.Uint64
+	logger *zap.Logger
+
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	sources     []registeredSource
+}
+
+type registeredSource struct {
+	relayer string
+	source  Source
+}
+
+// Subscription receives live entries matching its filter
+type Subscription struct {
+	filter  Filter
+	entries chan *Entry
+	dropped atomic.Uint64
+}
+
+// Entries returns the channel live entries are delivered on. It is closed
+// when the subscription is cancelled.
+func (s *Subscription) Entries() <-chan *Entry {
+	return s.entries
+}
+
+// Dropped returns how many entries were skipped because the subscriber
+// wasn't keeping up
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// NewService creates a log stream service. The ring buffer size comes from
+// LOG_STREAM_BUFFER_SIZE (default 1000).
+func NewService(logger *zap.Logger) *Service {
+	size := 1000
+	if value, err := strconv.Atoi(os.Getenv("LOG_STREAM_BUFFER_SIZE")); err == nil && value > 0 {
+		size = value
+	}
+
+	return &Service{
+		buffer:      NewRingBuffer(size),
+		logger:      logger.With(zap.String("component", "log_stream")),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// AddSource registers a log source for a relayer. Sources are read once
+// Start is called.
+func (s *Service) AddSource(relayer string, source Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = append(s.sources, registeredSource{relayer: relayer, source: source})
+}
+
+// AddFileSources registers file sources from HERMES_LOG_FILES and
+// RLY_LOG_FILES (comma-separated), defaulting to the supervisord log paths
+func (s *Service) AddFileSources() {
+	defaults := map[string]string{
+		"hermes": "/var/log/relayers/hermes.out.log,/var/log/relayers/hermes.err.log",
+		"rly":    "/var/log/relayers/rly.out.log,/var/log/relayers/rly.err.log",
+	}
+	for relayer, fallback := range defaults {
+		value := os.Getenv(strings.ToUpper(relayer) + "_LOG_FILES")
+		if value == "" {
+			value = fallback
+		}
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				s.AddSource(relayer, NewFileSource(path, s.logger))
+			}
+		}
+	}
+}
+
+// AddProcessSources registers the stdout and stderr of each process as
+// sources, read through a process manager that can tail logs
+func (s *Service) AddProcessSources(tailer LogTailer, relayer string, processes ...string) {
+	for _, process := range processes {
+		for _, stream := range []string{"stdout", "stderr"} {
+			s.AddSource(relayer, NewProcessSource(tailer, process, stream, s.logger))
+		}
+	}
+}
+
+// Start reads every registered source until ctx is done
+func (s *Service) Start(ctx context.Context) {
+	s.mu.RLock()
+	sources := append([]registeredSource(nil), s.sources...)
+	s.mu.RUnlock()
+
+	for _, rs := range sources {
+		go s.run(ctx, rs)
+	}
+}
+
+func (s *Service) run(ctx context.Context, rs registeredSource) {
+	lines := make(chan string, 64)
+	go func() {
+		defer close(lines)
+		if err := rs.source.Run(ctx, lines); err != nil && ctx.Err() == nil {
+			s.logger.Warn("Log source stopped",
+				zap.String("relayer", rs.relayer),
+				zap.String("source", rs.source.Name()),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	for line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry := ParseLine(rs.relayer, line)
+		entry.Source = rs.source.Name()
+		s.Publish(entry)
+	}
+}
+
+// Publish assigns an ID to an entry, buffers it and delivers it to subscribers
+func (s *Service) Publish(entry *Entry) {
+	entry.ID = s.lastID.Add(1)
+	s.buffer.Add(entry)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscribers {
+		if !sub.filter.Matches(entry) {
+			continue
+		}
+		select {
+		case sub.entries <- entry:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Recent returns buffered entries matching filter with an ID after afterID
+func (s *Service) Recent(filter Filter, afterID uint64, limit int) []*Entry {
+	return s.buffer.Recent(filter, afterID, limit)
+}
+
+// Subscribe registers a live subscriber. The returned function cancels
+// the subscription and must be called when the subscriber goes away.
+func (s *Service) Subscribe(filter Filter) (*Subscription, func()) {
+	sub := &Subscription{
+		filter:  filter,
+		entries: make(chan *Entry, subscriberBuffer),
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, sub)
+			s.mu.Unlock()
+			close(sub.entries)
+		})
+	}
+}
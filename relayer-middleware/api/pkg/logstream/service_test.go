@@ -0,0 +1,76 @@
+package logstream
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRingBufferKeepsLatest(t *testing.T) {
+	buffer := NewRingBuffer(3)
+	for i := uint64(1); i <= 5; i++ {
+		buffer.Add(&Entry{ID: i, Level: LevelInfo})
+	}
+
+	var ids []uint64
+	for _, entry := range buffer.Recent(Filter{}, 0, 0) {
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []uint64{3, 4, 5}, ids)
+
+	entries := buffer.Recent(Filter{}, 0, 2)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(4), entries[0].ID)
+
+	entries = buffer.Recent(Filter{}, 4, 0)
+	require.Len(t, entries, 1)
+	assert.Equal(t, uint64(5), entries[0].ID)
+}
+
+func TestFileSourceStreamsToSubscribers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hermes.log")
+	require.NoError(t, os.WriteFile(path, []byte("2024-05-01T12:00:00Z  INFO existing line\n"), 0644))
+
+	svc := NewService(zap.NewNop())
+	svc.AddSource("hermes", NewFileSource(path, zap.NewNop()))
+	sub, cancel := svc.Subscribe(Filter{Level: "warn"})
+	defer cancel()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	svc.Start(ctx)
+
+	require.Eventually(t, func() bool {
+		return len(svc.Recent(Filter{}, 0, 0)) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("2024-05-01T12:00:01Z  WARN chain{chain=osmosis-1}: slow\n2024-05-01T12:00:02Z  INFO ok\n")
+	require.NoError(t, err)
+	file.Close()
+
+	select {
+	case entry := <-sub.Entries():
+		assert.Equal(t, LevelWarn, entry.Level)
+		assert.Equal(t, []string{"osmosis-1"}, entry.Chains)
+		assert.Equal(t, path, entry.Source)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry streamed")
+	}
+
+	// Truncation starts reading from the top again
+	require.NoError(t, os.WriteFile(path, []byte("2024-05-01T12:00:03Z ERROR after truncate\n"), 0644))
+	select {
+	case entry := <-sub.Entries():
+		assert.Equal(t, "after truncate", entry.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no entry after truncation")
+	}
+}
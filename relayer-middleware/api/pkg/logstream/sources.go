@@ -0,0 +1,184 @@
+package logstream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/supervisor"
+)
+
+const (
+	pollInterval = 500 * time.Millisecond
+	// initialTail is how much of an existing file is read on start
+	initialTail = 64 * 1024
+)
+
+// FileSource follows a log file like tail -F, surviving rotation and truncation
+type FileSource struct {
+	path   string
+	logger *zap.Logger
+}
+
+// NewFileSource creates a source following path
+func NewFileSource(path string, logger *zap.Logger) *FileSource {
+	return &FileSource{path: path, logger: logger}
+}
+
+// Name implements Source
+func (f *FileSource) Name() string {
+	return f.path
+}
+
+// Run implements Source
+func (f *FileSource) Run(ctx context.Context, lines chan<- string) error {
+	var (
+		file    *os.File
+		reader  *bufio.Reader
+		info    os.FileInfo
+		offset  int64
+		partial string
+		first   = true
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if file == nil {
+			opened, err := os.Open(f.path)
+			if err == nil {
+				file = opened
+				info, _ = file.Stat()
+				offset = 0
+				// Only the first open starts near the end; reopened
+				// (rotated) files are read from the beginning
+				if first && info != nil && info.Size() > initialTail {
+					offset = info.Size() - initialTail
+				}
+				file.Seek(offset, io.SeekStart)
+				reader = bufio.NewReader(file)
+				partial = ""
+				if offset > 0 {
+					// Drop the partial first line
+					skipped, _ := reader.ReadString('\n')
+					offset += int64(len(skipped))
+				}
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			first = false
+		}
+
+		if file != nil {
+			for {
+				chunk, err := reader.ReadString('\n')
+				offset += int64(len(chunk))
+				if err != nil {
+					partial += chunk
+					break
+				}
+				line := partial + strings.TrimRight(chunk, "\n")
+				partial = ""
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+
+			// Reopen on rotation (new inode) or truncation
+			current, err := os.Stat(f.path)
+			if err != nil || !os.SameFile(info, current) || current.Size() < offset {
+				file.Close()
+				file = nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// LogTailer is implemented by process managers that can read process
+// output, such as supervisord
+type LogTailer interface {
+	TailLog(ctx context.Context, name, stream string, offset int64, length int) (data string, next int64, overflow bool, err error)
+}
+
+// ProcessSource follows a process's output through its process manager
+type ProcessSource struct {
+	tailer  LogTailer
+	process string
+	stream  string
+	logger  *zap.Logger
+}
+
+// NewProcessSource creates a source for a process's stdout or stderr
+func NewProcessSource(tailer LogTailer, process, stream string, logger *zap.Logger) *ProcessSource {
+	return &ProcessSource{tailer: tailer, process: process, stream: stream, logger: logger}
+}
+
+// Name implements Source
+func (p *ProcessSource) Name() string {
+	return p.process + ":" + p.stream
+}
+
+// Run implements Source
+func (p *ProcessSource) Run(ctx context.Context, lines chan<- string) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var (
+		offset  int64
+		partial string
+	)
+	for {
+		data, next, _, err := p.tailer.TailLog(ctx, p.process, p.stream, offset, initialTail)
+		if err != nil {
+			if errors.Is(err, supervisor.ErrUnknownProcess) {
+				return err
+			}
+			p.logger.Debug("Failed to tail process log", zap.String("source", p.Name()), zap.Error(err))
+		} else {
+			// The first read starts mid-file; drop the partial first line
+			if offset == 0 && next > int64(len(data)) {
+				if idx := strings.IndexByte(data, '\n'); idx >= 0 {
+					data = data[idx+1:]
+				}
+			}
+			offset = next
+
+			data = partial + data
+			parts := strings.Split(data, "\n")
+			partial = parts[len(parts)-1]
+			for _, line := range parts[:len(parts)-1] {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package logstream
+
+import (
+	"strings"
+	"time"
+)
+
+// Log levels, lowest first
+const (
+	LevelTrace = "trace"
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+var levelRank = map[string]int{
+	LevelTrace: 0,
+	LevelDebug: 1,
+	LevelInfo:  2,
+	LevelWarn:  3,
+	LevelError: 4,
+}
+
+// normalizeLevel maps level spellings used by Hermes and rly to our levels
+func normalizeLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace":
+		return LevelTrace
+	case "debug":
+		return LevelDebug
+	case "info":
+		return LevelInfo
+	case "warn", "warning":
+		return LevelWarn
+	case "error", "err", "fatal", "panic", "dpanic":
+		return LevelError
+	default:
+		return ""
+	}
+}
+
+// Entry is a single parsed relayer log line
+type Entry struct {
+	ID        uint64                 `json:"id"`
+	Timestamp time.Time              `json:"timestamp"`
+	Relayer   string                 `json:"relayer"`
+	Source    string                 `json:"source"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"message"`
+	Chains    []string               `json:"chains,omitempty"`
+	Channels  []string               `json:"channels,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+	Raw       string                 `json:"raw"`
+}
+
+// Filter selects log entries. Empty fields match everything.
+type Filter struct {
+	Relayer string `form:"relayer" json:"relayer"`
+	// Level is the minimum level
+	Level   string `form:"level" json:"level"`
+	Chain   string `form:"chain" json:"chain"`
+	Channel string `form:"channel" json:"channel"`
+}
+
+// Matches reports whether an entry passes the filter
+func (f Filter) Matches(entry *Entry) bool {
+	if f.Relayer != "" && entry.Relayer != f.Relayer {
+		return false
+	}
+	if f.Level != "" {
+		// Lines without a recognizable level are continuation output
+		// (stack traces, multi-line errors) and always pass
+		if rank, ok := levelRank[entry.Level]; ok && rank < levelRank[normalizeLevel(f.Level)] {
+			return false
+		}
+	}
+	if f.Chain != "" && !contains(entry.Chains, f.Chain) {
+		return false
+	}
+	if f.Channel != "" && !contains(entry.Channels, f.Channel) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
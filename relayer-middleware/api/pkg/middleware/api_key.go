@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"relayooor/api/pkg/apikeys"
+	apierrors "relayooor/api/pkg/errors"
+)
+
+// APIKeyHeader is the header integrators use to send their API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuth authenticates requests carrying an X-API-Key header.
+// Requests without the header are handed to fallback (e.g. AuthRequired or
+// the clearing session middleware); a nil fallback lets them through.
+func APIKeyAuth(service *apikeys.Service, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" || service == nil {
+			if fallback != nil {
+				fallback(c)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := service.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			switch {
+			case errors.Is(err, apikeys.ErrQuotaExceeded):
+				userErr := apierrors.NewUserError(apierrors.ErrRateLimitExceeded, http.StatusTooManyRequests, nil)
+				c.JSON(userErr.HTTPStatus, apierrors.FormatError(userErr))
+			case errors.Is(err, apikeys.ErrKeyExpired):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "API key expired"})
+			case errors.Is(err, apikeys.ErrKeyRevoked):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "API key revoked"})
+			case errors.Is(err, apikeys.ErrKeyNotFound), errors.Is(err, apikeys.ErrInvalidKey):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate API key"})
+			}
+			c.Abort()
+			return
+		}
+
+		// Store key info in context
+		c.Set("api_key", key)
+		c.Set("api_key_id", key.ID)
+		c.Set("authenticated", true)
+		if key.WalletAddress != "" {
+			c.Set("wallet", key.WalletAddress)
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScope rejects API key requests that were not granted scope.
+// Requests authenticated by other means (JWT, wallet session) are unaffected.
+func RequireScope(scope apikeys.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_key")
+		if !exists {
+			c.Next()
+			return
+		}
+
+		key, ok := value.(*apikeys.APIKey)
+		if !ok || !key.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "API key missing required scope",
+				"scope": scope,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyAPIKeys rejects API key requests on operator-only routes
+func DenyAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(APIKeyHeader) != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot access this endpoint"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/apikeys"
+)
+
+const testJWTSecret = "test-jwt-secret"
+
+func setupAPIKeys(t *testing.T) (*apikeys.Service, *miniredis.Miniredis) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&apikeys.APIKey{}))
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { redisClient.Close() })
+
+	return apikeys.NewService(db, redisClient, "test-secret", zap.NewNop()), mr
+}
+
+func createKey(t *testing.T, service *apikeys.Service, quota int64, scopes ...apikeys.Scope) string {
+	t.Helper()
+	resp, err := service.Create(context.Background(), apikeys.CreateKeyRequest{
+		Name:       "integrator",
+		Scopes:     scopes,
+		DailyQuota: quota,
+	}, "admin")
+	require.NoError(t, err)
+	return resp.Key
+}
+
+func signedToken(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+// newAPIKeyRouter mirrors how the server mounts API key auth: JWT fallback on
+// protected routes, scopes on IBC routes and no API keys on /relayer or /admin
+func newAPIKeyRouter(service *apikeys.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"api_key": c.GetString("api_key_id"), "username": c.GetString("username")})
+	}
+
+	admin := router.Group("/admin")
+	admin.Use(AuthRequired(), DenyAPIKeys())
+	admin.GET("/keys", ok)
+
+	protected := router.Group("/api")
+	protected.Use(APIKeyAuth(service, AuthRequired()))
+	ibc := protected.Group("/ibc")
+	ibc.Use(RequireScope(apikeys.ScopeReadPackets))
+	ibc.GET("/packets", ok)
+	ibc.POST("/packets/clear", RequireScope(apikeys.ScopeClearingRequest), ok)
+	relayer := protected.Group("/relayer")
+	relayer.Use(DenyAPIKeys())
+	relayer.GET("/status", ok)
+	return router
+}
+
+func serve(router *gin.Engine, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPIKeyAuthScopes(t *testing.T) {
+	service, _ := setupAPIKeys(t)
+	router := newAPIKeyRouter(service)
+	key := createKey(t, service, 0, apikeys.ScopeReadPackets)
+	withKey := map[string]string{APIKeyHeader: key}
+
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+
+	w := serve(router, "POST", "/api/ibc/packets/clear", withKey)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), string(apikeys.ScopeClearingRequest))
+
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "GET", "/api/ibc/packets", map[string]string{APIKeyHeader: "rlyr_000000_wrong"}).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "GET", "/api/ibc/packets", map[string]string{APIKeyHeader: "not-a-key"}).Code)
+}
+
+func TestDenyAPIKeys(t *testing.T) {
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	service, _ := setupAPIKeys(t)
+	router := newAPIKeyRouter(service)
+	key := createKey(t, service, 0, apikeys.AllScopes...)
+
+	assert.Equal(t, http.StatusForbidden, serve(router, "GET", "/api/relayer/status", map[string]string{APIKeyHeader: key}).Code)
+
+	// An operator's JWT doesn't make an API key acceptable on /admin
+	bearer := "Bearer " + signedToken(t, Claims{Username: "operator"})
+	assert.Equal(t, http.StatusForbidden, serve(router, "GET", "/admin/keys", map[string]string{
+		"Authorization": bearer,
+		APIKeyHeader:    key,
+	}).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/admin/keys", map[string]string{"Authorization": bearer}).Code)
+}
+
+func TestAPIKeyAuthFallsBackToJWT(t *testing.T) {
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	service, _ := setupAPIKeys(t)
+	router := newAPIKeyRouter(service)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "GET", "/api/relayer/status", nil).Code)
+
+	w := serve(router, "GET", "/api/relayer/status", map[string]string{
+		"Authorization": "Bearer " + signedToken(t, Claims{Username: "operator"}),
+	})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"username":"operator"`)
+
+	// Refresh tokens aren't access tokens
+	refresh := signedToken(t, Claims{Username: "operator", TokenType: TokenTypeRefresh})
+	assert.Equal(t, http.StatusUnauthorized, serve(router, "GET", "/api/relayer/status", map[string]string{"Authorization": "Bearer " + refresh}).Code)
+
+	// JWT-authenticated requests aren't held to API key scopes
+	assert.Equal(t, http.StatusOK, serve(router, "POST", "/api/ibc/packets/clear", map[string]string{
+		"Authorization": "Bearer " + signedToken(t, Claims{
+			Username:         "operator",
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		}),
+	}).Code)
+}
+
+func TestAPIKeyDailyQuota(t *testing.T) {
+	service, mr := setupAPIKeys(t)
+	router := newAPIKeyRouter(service)
+	key := createKey(t, service, 2, apikeys.ScopeReadPackets)
+	withKey := map[string]string{APIKeyHeader: key}
+
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+
+	// Tomorrow's counter starts over
+	mr.FlushAll()
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+
+	// Usage can't be counted without Redis, so the quota fails open
+	for i := 0; i < 2; i++ {
+		serve(router, "GET", "/api/ibc/packets", withKey)
+	}
+	mr.Close()
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/ibc/packets", withKey).Code)
+}
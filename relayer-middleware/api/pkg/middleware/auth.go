@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"strings"
@@ -10,10 +11,27 @@ import (
 )
 
 type Claims struct {
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	FamilyID  string `json:"fid,omitempty"`
+	TokenType string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// TokenTypeRefresh marks refresh tokens, which must not be used as access tokens
+const TokenTypeRefresh = "refresh"
+
+// TokenDenylist reports whether an access token has been revoked
+type TokenDenylist interface {
+	IsRevoked(ctx context.Context, tokenID, familyID string) (bool, error)
+}
+
+var tokenDenylist TokenDenylist
+
+// SetTokenDenylist enables revocation checks in AuthRequired and OptionalAuth
+func SetTokenDenylist(denylist TokenDenylist) {
+	tokenDenylist = denylist
+}
+
 // AuthRequired middleware validates JWT tokens
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -65,12 +83,27 @@ func AuthRequired() gin.HandlerFunc {
 
 		// Extract claims
 		claims, ok := token.Claims.(*Claims)
-		if !ok {
+		if !ok || claims.TokenType == TokenTypeRefresh {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
+		// Check if token has been revoked
+		if tokenDenylist != nil {
+			revoked, err := tokenDenylist.IsRevoked(c.Request.Context(), claims.ID, claims.FamilyID)
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+
 		// Store user info in context
 		c.Set("username", claims.Username)
 		c.Set("claims", claims)
@@ -110,7 +143,7 @@ func OptionalAuth() gin.HandlerFunc {
 		})
 
 		if err == nil && token.Valid {
-			if claims, ok := token.Claims.(*Claims); ok {
+			if claims, ok := token.Claims.(*Claims); ok && claims.TokenType != TokenTypeRefresh && !isRevoked(c.Request.Context(), claims) {
 				c.Set("username", claims.Username)
 				c.Set("claims", claims)
 				c.Set("authenticated", true)
@@ -119,4 +152,13 @@ func OptionalAuth() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// isRevoked checks the denylist, treating lookup failures as revoked
+func isRevoked(ctx context.Context, claims *Claims) bool {
+	if tokenDenylist == nil {
+		return false
+	}
+	revoked, err := tokenDenylist.IsRevoked(ctx, claims.ID, claims.FamilyID)
+	return err != nil || revoked
+}
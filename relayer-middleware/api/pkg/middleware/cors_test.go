@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocketOriginChecker(t *testing.T) {
+	t.Setenv("WS_ALLOWED_ORIGINS", "https://app.relayooor.com, http://localhost:5173")
+	check := WebSocketOriginChecker()
+
+	req := httptest.NewRequest("GET", "/ws", nil)
+	assert.True(t, check(req), "non-browser clients send no Origin")
+
+	req.Header.Set("Origin", "http://localhost:5173")
+	assert.True(t, check(req))
+
+	req.Header.Set("Origin", "https://evil.example")
+	assert.False(t, check(req))
+}
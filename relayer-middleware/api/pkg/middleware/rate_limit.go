@@ -0,0 +1,301 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	apierrors "relayooor/api/pkg/errors"
+)
+
+// RateLimitKey selects what a rate limit policy counts requests by
+type RateLimitKey string
+
+const (
+	RateLimitByIP     RateLimitKey = "ip"
+	RateLimitByWallet RateLimitKey = "wallet"
+	// RateLimitByWalletIP counts requests for a wallet from one client IP
+	RateLimitByWalletIP RateLimitKey = "wallet_ip"
+	RateLimitByAPIKey   RateLimitKey = "api_key"
+)
+
+// RateLimitPolicy defines how many requests are allowed in a sliding window
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	KeyBy  RateLimitKey
+}
+
+// RateLimitResult is the outcome of a single limiter check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+// DefaultRateLimitPolicies returns the built-in per-route policies. Wallet
+// policies are only ever secondary buckets: an unauthenticated caller picks
+// the wallet in its body, so a route keyed by wallet alone would hand a new
+// bucket to every address it makes up. They are also keyed by client IP, or
+// anyone could use up a wallet's bucket and lock its owner out.
+func DefaultRateLimitPolicies() map[string]RateLimitPolicy {
+	return map[string]RateLimitPolicy{
+		"request-token":        {Name: "request-token", Limit: 10, Window: time.Minute, KeyBy: RateLimitByIP},
+		"request-token-wallet": {Name: "request-token-wallet", Limit: 5, Window: time.Minute, KeyBy: RateLimitByWalletIP},
+		"wallet-sign":          {Name: "wallet-sign", Limit: 5, Window: time.Minute, KeyBy: RateLimitByIP},
+		"verify-payment":       {Name: "verify-payment", Limit: 20, Window: time.Minute, KeyBy: RateLimitByIP},
+		"api":                  {Name: "api", Limit: 300, Window: time.Minute, KeyBy: RateLimitByAPIKey},
+	}
+}
+
+// maxPeekBodySize bounds how much of a request body is read to find a wallet
+const maxPeekBodySize = 64 * 1024
+
+// slidingWindowScript atomically trims, counts and records a request.
+// Returns {allowed, count, oldest_score_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, member)
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local oldestScore = now
+if oldest[2] then
+	oldestScore = tonumber(oldest[2])
+end
+return {allowed, count, oldestScore}
+`)
+
+// RateLimiter enforces sliding-window rate limits stored in Redis
+type RateLimiter struct {
+	redisClient *redis.Client
+	policies    map[string]RateLimitPolicy
+	now         func() time.Time
+	logger      *zap.Logger
+}
+
+// NewRateLimiter creates a rate limiter using the default policies.
+// Each policy can be overridden with RATE_LIMIT_<NAME>=<limit>/<window>[/<key>],
+// e.g. RATE_LIMIT_REQUEST_TOKEN_WALLET=5/1m.
+func NewRateLimiter(redisClient *redis.Client, logger *zap.Logger) *RateLimiter {
+	policies := DefaultRateLimitPolicies()
+	for name, policy := range policies {
+		envKey := "RATE_LIMIT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		value := os.Getenv(envKey)
+		if value == "" {
+			continue
+		}
+		override, err := ParseRateLimitPolicy(name, value)
+		if err != nil {
+			logger.Warn("Ignoring invalid rate limit override",
+				zap.String("env", envKey),
+				zap.String("value", value),
+				zap.Error(err),
+			)
+			continue
+		}
+		if override.KeyBy == "" {
+			override.KeyBy = policy.KeyBy
+		}
+		policies[name] = override
+	}
+
+	return &RateLimiter{
+		redisClient: redisClient,
+		policies:    policies,
+		now:         time.Now,
+		logger:      logger.With(zap.String("component", "rate_limiter")),
+	}
+}
+
+// ParseRateLimitPolicy parses a policy of the form <limit>/<window>[/<key>]
+func ParseRateLimitPolicy(name, value string) (RateLimitPolicy, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return RateLimitPolicy{}, fmt.Errorf("expected <limit>/<window>[/<key>], got %q", value)
+	}
+
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid limit %q", parts[0])
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("invalid window %q", parts[1])
+	}
+
+	policy := RateLimitPolicy{Name: name, Limit: limit, Window: window}
+	if len(parts) == 3 {
+		switch key := RateLimitKey(parts[2]); key {
+		case RateLimitByIP, RateLimitByWallet, RateLimitByWalletIP, RateLimitByAPIKey:
+			policy.KeyBy = key
+		default:
+			return RateLimitPolicy{}, fmt.Errorf("invalid key %q", parts[2])
+		}
+	}
+
+	return policy, nil
+}
+
+// Policy returns a named policy
+func (rl *RateLimiter) Policy(name string) (RateLimitPolicy, bool) {
+	policy, ok := rl.policies[name]
+	return policy, ok
+}
+
+// Allow records a request for identifier and reports whether it is within policy
+func (rl *RateLimiter) Allow(ctx context.Context, policy RateLimitPolicy, identifier string) (RateLimitResult, error) {
+	now := rl.now().UnixMilli()
+	windowMs := policy.Window.Milliseconds()
+	key := fmt.Sprintf("ratelimit:%s:%s", policy.Name, identifier)
+
+	values, err := slidingWindowScript.Run(ctx, rl.redisClient,
+		[]string{key},
+		now, windowMs, policy.Limit, fmt.Sprintf("%d-%s", now, uuid.New().String()),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	count := int(values[1])
+	resetAfter := time.Duration(values[2]+windowMs-now) * time.Millisecond
+	if resetAfter < 0 {
+		resetAfter = 0
+	}
+
+	remaining := policy.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      policy.Limit,
+		Remaining:  remaining,
+		ResetAfter: resetAfter,
+	}, nil
+}
+
+// Limit returns middleware enforcing the named policy. Unknown policies and
+// Redis failures let requests through so the limiter never takes the API down.
+func (rl *RateLimiter) Limit(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy, ok := rl.policies[name]
+		if !ok {
+			rl.logger.Warn("Unknown rate limit policy", zap.String("policy", name))
+			c.Next()
+			return
+		}
+
+		result, err := rl.Allow(c.Request.Context(), policy, rateLimitIdentifier(c, policy.KeyBy))
+		if err != nil {
+			rl.logger.Warn("Rate limiter unavailable",
+				zap.String("policy", name),
+				zap.Error(err),
+			)
+			c.Next()
+			return
+		}
+
+		resetSeconds := int(math.Ceil(result.ResetAfter.Seconds()))
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(resetSeconds))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Window.Seconds())))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(resetSeconds))
+			userErr := apierrors.NewUserError(apierrors.ErrRateLimitExceeded, apierrors.GetHTTPStatus(apierrors.ErrRateLimitExceeded), map[string]interface{}{
+				"retry_after": resetSeconds,
+			})
+			c.AbortWithStatusJSON(userErr.HTTPStatus, apierrors.FormatError(userErr))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitIdentifier resolves the identity a request is counted against,
+// falling back to the client IP when the preferred identity is unavailable
+func rateLimitIdentifier(c *gin.Context, keyBy RateLimitKey) string {
+	switch keyBy {
+	case RateLimitByAPIKey:
+		if keyID := c.GetString("api_key_id"); keyID != "" {
+			return "key:" + keyID
+		}
+	case RateLimitByWallet:
+		if wallet := c.GetString("wallet"); wallet != "" {
+			return "wallet:" + wallet
+		}
+		if wallet := walletFromBody(c); wallet != "" {
+			return "wallet:" + wallet
+		}
+	case RateLimitByWalletIP:
+		wallet := c.GetString("wallet")
+		if wallet == "" {
+			wallet = walletFromBody(c)
+		}
+		if wallet != "" {
+			return "wallet:" + wallet + ":ip:" + c.ClientIP()
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// walletFromBody peeks at a JSON body for a wallet address and restores it
+// so the handler can still bind the request
+func walletFromBody(c *gin.Context) string {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPeekBodySize))
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	var payload struct {
+		WalletAddress      string `json:"walletAddress"`
+		WalletAddressSnake string `json:"wallet_address"`
+		Wallet             string `json:"wallet"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	for _, wallet := range []string{payload.WalletAddress, payload.WalletAddressSnake, payload.Wallet} {
+		if wallet != "" {
+			return wallet
+		}
+	}
+	return ""
+}
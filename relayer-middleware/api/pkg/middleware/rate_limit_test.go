@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	policy, err := ParseRateLimitPolicy("request-token", "5/30s/wallet")
+	require.NoError(t, err)
+	assert.Equal(t, 5, policy.Limit)
+	assert.Equal(t, 30*time.Second, policy.Window)
+	assert.Equal(t, RateLimitByWallet, policy.KeyBy)
+
+	policy, err = ParseRateLimitPolicy("api", "100/1m")
+	require.NoError(t, err)
+	assert.Equal(t, RateLimitKey(""), policy.KeyBy)
+
+	for _, invalid := range []string{"", "10", "0/1m", "10/abc", "10/1m/user", "10/1m/ip/extra"} {
+		_, err := ParseRateLimitPolicy("api", invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimitIdentifierFromBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := `{"walletAddress":"cosmos1abc","chainId":"cosmoshub-4"}`
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/clearing/request-token", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	assert.Equal(t, "wallet:cosmos1abc", rateLimitIdentifier(c, RateLimitByWallet))
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "wallet:cosmos1abc:ip:10.0.0.1", rateLimitIdentifier(c, RateLimitByWalletIP))
+
+	// Body must still be readable by the handler
+	remaining, err := io.ReadAll(c.Request.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(remaining))
+}
+
+func TestRateLimitIdentifierFallsBackToIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/metrics/summary", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+
+	assert.Equal(t, "ip:10.0.0.1", rateLimitIdentifier(c, RateLimitByAPIKey))
+
+	c.Set("api_key_id", "key-1")
+	assert.Equal(t, "key:key-1", rateLimitIdentifier(c, RateLimitByAPIKey))
+}
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *time.Time) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(redisClient, zap.NewNop())
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestRateLimiterSlidingWindow(t *testing.T) {
+	limiter, now := newTestRateLimiter(t)
+	ctx := context.Background()
+	policy := RateLimitPolicy{Name: "test", Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, policy, "ip:10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+		*now = now.Add(10 * time.Second)
+	}
+
+	result, err := limiter.Allow(ctx, policy, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	// The first request leaves the window 60s after it was made
+	assert.Equal(t, 30*time.Second, result.ResetAfter)
+
+	// Other identities have their own bucket
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Denied requests aren't recorded, so the window slides as the oldest
+	// request ages out
+	*now = now.Add(31 * time.Second)
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, policy, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+func TestRequestTokenLimitIgnoresRotatedWallets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter, _ := newTestRateLimiter(t)
+	limit := DefaultRateLimitPolicies()["request-token"].Limit
+	walletLimit := DefaultRateLimitPolicies()["request-token-wallet"].Limit
+
+	router := gin.New()
+	router.POST("/clearing/request-token", limiter.Limit("request-token"), limiter.Limit("request-token-wallet"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func(remoteAddr, wallet string) int {
+		req := httptest.NewRequest("POST", "/clearing/request-token", strings.NewReader(`{"walletAddress":"`+wallet+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// A new wallet on every request doesn't get the caller a new bucket
+	for i := 0; i < limit; i++ {
+		require.Equal(t, http.StatusOK, request("10.0.0.1:1234", fmt.Sprintf("cosmos1wallet%d", i)))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:1234", "cosmos1fresh"))
+
+	// One caller can only ask for so many tokens for the same wallet
+	for i := 0; i < walletLimit; i++ {
+		require.Equal(t, http.StatusOK, request("10.0.3.1:1234", "cosmos1target"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.3.1:1234", "cosmos1target"))
+
+	// but callers elsewhere hammering a wallet don't lock its owner out
+	for i := 0; i < limit; i++ {
+		require.Equal(t, http.StatusOK, request(fmt.Sprintf("10.0.1.%d:1234", i), "cosmos1victim"))
+	}
+	assert.Equal(t, http.StatusOK, request("10.0.2.1:1234", "cosmos1victim"))
+}
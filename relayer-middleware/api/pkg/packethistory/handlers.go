@@ -0,0 +1,145 @@
+package packethistory
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultMTTRWindow is the window of GetMeanTimeToRelay without ?from=
+const defaultMTTRWindow = 7 * 24 * time.Hour
+
+// Handlers exposes the stuck packet history
+type Handlers struct {
+	store  *Store
+	logger *zap.Logger
+}
+
+// NewHandlers creates stuck packet history handlers
+func NewHandlers(store *Store, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		store:  store,
+		logger: logger.With(zap.String("component", "packet_history_handlers")),
+	}
+}
+
+// RegisterRoutes registers the history routes
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/packets/history", h.GetHistory)
+	router.GET("/packets/history/mttr", h.GetMeanTimeToRelay)
+	router.GET("/users/:address/stuck-history", h.GetUserHistory)
+}
+
+// GetHistory handles GET /packets/history. ?from= and ?to= (RFC 3339 or
+// YYYY-MM-DD) select packets stuck at some point in between, e.g. what was
+// stuck on a channel on a given day.
+func (h *Handlers) GetHistory(c *gin.Context) {
+	filter, err := queryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Address = c.Query("address")
+	h.writePackets(c, filter)
+}
+
+// GetUserHistory handles GET /users/:address/stuck-history, the packets an
+// address sent or received that got stuck
+func (h *Handlers) GetUserHistory(c *gin.Context) {
+	filter, err := queryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Address = c.Param("address")
+	h.writePackets(c, filter)
+}
+
+// GetMeanTimeToRelay handles GET /packets/history/mttr, the mean time to
+// relay per channel of packets resolved in ?from= to ?to=, the last week
+// by default
+func (h *Handlers) GetMeanTimeToRelay(c *gin.Context) {
+	from, err := queryTime(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	end := time.Now().UTC()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultMTTRWindow)
+	if from != nil {
+		start = *from
+	}
+
+	channels, err := h.store.MeanTimeToRelay(c.Request.Context(), c.Query("chain_id"), c.Query("channel"), start, end)
+	if err != nil {
+		h.logger.Error("Failed to compute mean time to relay", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute mean time to relay"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"channels": channels,
+		"from":     start,
+		"to":       end,
+	})
+}
+
+func (h *Handlers) writePackets(c *gin.Context, filter Filter) {
+	packets, total, err := h.store.Packets(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to query stuck packet history", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query stuck packet history"})
+		return
+	}
+	if packets == nil {
+		packets = []StuckPacket{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"packets": packets,
+		"total":   total,
+	})
+}
+
+// queryFilter reads the filters shared by the history routes
+func queryFilter(c *gin.Context) (Filter, error) {
+	filter := Filter{
+		ChainID: c.Query("chain_id"),
+		Channel: c.Query("channel"),
+		Status:  c.Query("status"),
+	}
+	var err error
+	if filter.From, err = queryTime(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to"); err != nil {
+		return filter, err
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+	return filter, nil
+}
+
+// queryTime parses an RFC 3339 time or a UTC date
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be an RFC 3339 time or a YYYY-MM-DD date", name)
+}
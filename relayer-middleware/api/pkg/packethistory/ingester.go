@@ -0,0 +1,225 @@
+package packethistory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/database"
+)
+
+// batchSize keeps upserts well under Postgres' limit of 65535 parameters
+const batchSize = 500
+
+var upsertColumns = []string{
+	"chain_id", "src_channel", "sequence", "dst_channel", "sender", "receiver",
+	"amount", "denom", "status", "relay_attempts", "last_attempt_by",
+	"sent_at", "timeout_at", "first_seen_at", "last_seen_at", "resolved_at",
+}
+
+// upsertConflict keeps first_seen_at and sent_at from the first sighting.
+// A packet reported again after being resolved is reopened.
+const upsertConflict = `(chain_id, src_channel, sequence) DO UPDATE SET
+	dst_channel = EXCLUDED.dst_channel,
+	status = EXCLUDED.status,
+	relay_attempts = EXCLUDED.relay_attempts,
+	last_attempt_by = EXCLUDED.last_attempt_by,
+	timeout_at = COALESCE(EXCLUDED.timeout_at, stuck_packets.timeout_at),
+	last_seen_at = EXCLUDED.last_seen_at,
+	resolved_at = NULL`
+
+// IngestResult summarises one ingest
+type IngestResult struct {
+	Seen     int       `json:"seen"`
+	Resolved int64     `json:"resolved"`
+	At       time.Time `json:"at"`
+}
+
+// Ingester periodically stores what Chainpulse reports as stuck, expiring
+// and expired, and marks packets resolved once they're no longer reported
+type Ingester struct {
+	db             *gorm.DB
+	source         Source
+	interval       time.Duration
+	minAge         time.Duration
+	expiringWithin time.Duration
+	now            func() time.Time
+	logger         *zap.Logger
+}
+
+// NewIngester creates a stuck packet ingester. Settings come from
+// PACKET_HISTORY_INTERVAL, PACKET_HISTORY_MIN_AGE (Chainpulse's default
+// when unset) and PACKET_HISTORY_EXPIRING_WITHIN.
+func NewIngester(db *gorm.DB, source Source, logger *zap.Logger) *Ingester {
+	return &Ingester{
+		db:             db,
+		source:         source,
+		interval:       database.EnvDuration("PACKET_HISTORY_INTERVAL", time.Minute),
+		minAge:         database.EnvDuration("PACKET_HISTORY_MIN_AGE", 0),
+		expiringWithin: database.EnvDuration("PACKET_HISTORY_EXPIRING_WITHIN", time.Hour),
+		now:            time.Now,
+		logger:         logger.With(zap.String("component", "packet_history")),
+	}
+}
+
+// Start ingests immediately and then every interval until ctx is done
+func (i *Ingester) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(i.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := i.Ingest(ctx); err != nil && ctx.Err() == nil {
+				i.logger.Warn("Failed to ingest stuck packets", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Ingest stores a snapshot of Chainpulse's stuck, expiring and expired
+// packets. Nothing is written unless all three are fetched, so a failed
+// fetch can't make packets look resolved.
+func (i *Ingester) Ingest(ctx context.Context) (*IngestResult, error) {
+	stuck, err := i.source.AllStuckPackets(ctx, i.minAge)
+	if err != nil {
+		return nil, fmt.Errorf("fetch stuck packets: %w", err)
+	}
+	expiring, err := i.source.AllExpiringPackets(ctx, i.expiringWithin)
+	if err != nil {
+		return nil, fmt.Errorf("fetch expiring packets: %w", err)
+	}
+	expired, err := i.source.AllExpiredPackets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch expired packets: %w", err)
+	}
+
+	now := i.now().UTC()
+	snapshot := newSnapshot(now)
+	for _, p := range stuck {
+		snapshot.add(p, StatusStuck, nil)
+	}
+	for _, p := range expiring {
+		timeoutAt := now.Add(time.Duration(p.SecondsUntilTimeout) * time.Second)
+		snapshot.add(p.Packet, StatusExpiring, &timeoutAt)
+	}
+	for _, p := range expired {
+		timeoutAt := now.Add(-time.Duration(p.SecondsSinceTimeout) * time.Second)
+		snapshot.add(p.Packet, StatusExpired, &timeoutAt)
+	}
+
+	result := &IngestResult{Seen: len(snapshot.packets), At: now}
+	err = i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(snapshot.order); start += batchSize {
+			end := start + batchSize
+			if end > len(snapshot.order) {
+				end = len(snapshot.order)
+			}
+			query, args := snapshot.upsert(snapshot.order[start:end])
+			if err := tx.Exec(query, args...).Error; err != nil {
+				return fmt.Errorf("upsert stuck packets: %w", err)
+			}
+		}
+
+		resolved := tx.Model(&StuckPacket{}).
+			Where("resolved_at IS NULL AND last_seen_at < ?", now).
+			Updates(map[string]interface{}{
+				"resolved_at": now,
+				"status":      gorm.Expr("CASE WHEN status = ? THEN ? ELSE ? END", StatusExpired, StatusTimedOut, StatusResolved),
+			})
+		if resolved.Error != nil {
+			return fmt.Errorf("resolve stuck packets: %w", resolved.Error)
+		}
+		result.Resolved = resolved.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	i.logger.Debug("Ingested stuck packets",
+		zap.Int("seen", result.Seen),
+		zap.Int64("resolved", result.Resolved),
+	)
+	return result, nil
+}
+
+// snapshot is the packets reported by one ingest, keyed by packet ID
+type snapshot struct {
+	now     time.Time
+	packets map[string]*StuckPacket
+	order   []string
+}
+
+func newSnapshot(now time.Time) *snapshot {
+	return &snapshot{now: now, packets: make(map[string]*StuckPacket)}
+}
+
+// add records a reported packet. A packet listed by several endpoints
+// keeps the most urgent status.
+func (s *snapshot) add(p chainpulse.Packet, status string, timeoutAt *time.Time) {
+	if p.TimeoutTimestamp != nil && *p.TimeoutTimestamp > 0 {
+		at := time.Unix(0, *p.TimeoutTimestamp).UTC()
+		timeoutAt = &at
+	}
+
+	id := p.ID()
+	existing, ok := s.packets[id]
+	if !ok {
+		s.packets[id] = &StuckPacket{
+			ChainID:       p.ChainID,
+			SrcChannel:    p.SrcChannel,
+			Sequence:      p.Sequence,
+			DstChannel:    p.DstChannel,
+			Sender:        p.Sender,
+			Receiver:      p.Receiver,
+			Amount:        p.Amount,
+			Denom:         p.Denom,
+			Status:        status,
+			RelayAttempts: p.RelayAttempts,
+			LastAttemptBy: p.LastAttemptBy,
+			SentAt:        s.now.Add(-time.Duration(p.AgeSeconds) * time.Second),
+			TimeoutAt:     timeoutAt,
+			FirstSeenAt:   s.now,
+			LastSeenAt:    s.now,
+		}
+		s.order = append(s.order, id)
+		return
+	}
+
+	if statusRank[status] > statusRank[existing.Status] {
+		existing.Status = status
+	}
+	if existing.TimeoutAt == nil {
+		existing.TimeoutAt = timeoutAt
+	}
+	if p.RelayAttempts > existing.RelayAttempts {
+		existing.RelayAttempts = p.RelayAttempts
+		existing.LastAttemptBy = p.LastAttemptBy
+	}
+	// Expiring and expired listings may not carry every field
+	if existing.DstChannel == "" {
+		existing.DstChannel = p.DstChannel
+	}
+}
+
+func (s *snapshot) upsert(ids []string) (string, []interface{}) {
+	builder := database.NewBatchInsertBuilder("stuck_packets", upsertColumns...)
+	for _, id := range ids {
+		p := s.packets[id]
+		builder.AddRow(
+			p.ChainID, p.SrcChannel, p.Sequence, p.DstChannel, p.Sender, p.Receiver,
+			p.Amount, p.Denom, p.Status, p.RelayAttempts, p.LastAttemptBy,
+			p.SentAt, p.TimeoutAt, p.FirstSeenAt, p.LastSeenAt, nil,
+		)
+	}
+	return builder.OnConflict(upsertConflict).Build()
+}
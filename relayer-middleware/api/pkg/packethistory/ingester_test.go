@@ -0,0 +1,264 @@
+package packethistory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+var testNow = time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)
+
+type fakeSource struct {
+	stuck    []chainpulse.Packet
+	expiring []chainpulse.ExpiringPacket
+	expired  []chainpulse.ExpiredPacket
+	err      error
+}
+
+func (f *fakeSource) AllStuckPackets(ctx context.Context, minAge time.Duration) ([]chainpulse.Packet, error) {
+	return f.stuck, f.err
+}
+
+func (f *fakeSource) AllExpiringPackets(ctx context.Context, within time.Duration) ([]chainpulse.ExpiringPacket, error) {
+	return f.expiring, nil
+}
+
+func (f *fakeSource) AllExpiredPackets(ctx context.Context) ([]chainpulse.ExpiredPacket, error) {
+	return f.expired, nil
+}
+
+func newTestIngester(t *testing.T, source Source) (*Ingester, *gorm.DB, *time.Time) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&StuckPacket{}))
+
+	now := testNow
+	ingester := NewIngester(db, source, zap.NewNop())
+	ingester.now = func() time.Time { return now }
+	return ingester, db, &now
+}
+
+func testPacket(seq uint64, sender string, age int64) chainpulse.Packet {
+	return chainpulse.Packet{
+		ChainID:    "osmosis-1",
+		SrcChannel: "channel-141",
+		DstChannel: "channel-0",
+		Sequence:   seq,
+		Sender:     sender,
+		Receiver:   "cosmos1receiver",
+		Amount:     "1000",
+		Denom:      "uosmo",
+		AgeSeconds: age,
+	}
+}
+
+func findPacket(t *testing.T, db *gorm.DB, seq uint64) StuckPacket {
+	t.Helper()
+	var p StuckPacket
+	require.NoError(t, db.Where("sequence = ?", seq).First(&p).Error)
+	return p
+}
+
+func TestIngest(t *testing.T) {
+	source := &fakeSource{
+		stuck: []chainpulse.Packet{testPacket(1, "osmo1alice", 600), testPacket(2, "osmo1bob", 1200)},
+		expiring: []chainpulse.ExpiringPacket{
+			{Packet: chainpulse.Packet{ChainID: "osmosis-1", SrcChannel: "channel-141", Sequence: 2}, SecondsUntilTimeout: 300},
+		},
+		expired: []chainpulse.ExpiredPacket{
+			{Packet: testPacket(3, "osmo1alice", 7200), SecondsSinceTimeout: 60},
+		},
+	}
+	ingester, db, now := newTestIngester(t, source)
+
+	result, err := ingester.Ingest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Seen)
+	assert.Equal(t, int64(0), result.Resolved)
+
+	first := findPacket(t, db, 1)
+	assert.Equal(t, StatusStuck, first.Status)
+	assert.Equal(t, "osmo1alice", first.Sender)
+	assert.True(t, first.SentAt.Equal(testNow.Add(-10*time.Minute)))
+	assert.True(t, first.FirstSeenAt.Equal(testNow))
+	assert.Nil(t, first.ResolvedAt)
+
+	// Listed as stuck and expiring: the more urgent status wins and the
+	// stuck listing's fields are kept
+	second := findPacket(t, db, 2)
+	assert.Equal(t, StatusExpiring, second.Status)
+	assert.Equal(t, "osmo1bob", second.Sender)
+	require.NotNil(t, second.TimeoutAt)
+	assert.True(t, second.TimeoutAt.Equal(testNow.Add(5*time.Minute)))
+
+	// Packet 1 is relayed and packet 3 times out before the next ingest
+	*now = testNow.Add(time.Minute)
+	source.stuck = []chainpulse.Packet{testPacket(2, "osmo1bob", 1260)}
+	source.stuck[0].RelayAttempts = 3
+	source.expired = nil
+
+	result, err = ingester.Ingest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.Resolved)
+
+	first = findPacket(t, db, 1)
+	assert.Equal(t, StatusResolved, first.Status)
+	require.NotNil(t, first.ResolvedAt)
+	assert.True(t, first.ResolvedAt.Equal(*now))
+	assert.Equal(t, StatusTimedOut, findPacket(t, db, 3).Status)
+
+	// Upserts keep when the packet was first seen
+	second = findPacket(t, db, 2)
+	assert.True(t, second.FirstSeenAt.Equal(testNow))
+	assert.True(t, second.LastSeenAt.Equal(*now))
+	assert.Equal(t, 3, second.RelayAttempts)
+	require.NotNil(t, second.TimeoutAt, "timeout is kept once known")
+
+	var count int64
+	require.NoError(t, db.Model(&StuckPacket{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestIngestFailureResolvesNothing(t *testing.T) {
+	source := &fakeSource{stuck: []chainpulse.Packet{testPacket(1, "osmo1alice", 600)}}
+	ingester, db, now := newTestIngester(t, source)
+	_, err := ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	*now = testNow.Add(time.Minute)
+	source.err = errors.New("chainpulse down")
+	_, err = ingester.Ingest(context.Background())
+	require.Error(t, err)
+
+	packet := findPacket(t, db, 1)
+	assert.Equal(t, StatusStuck, packet.Status)
+	assert.Nil(t, packet.ResolvedAt)
+}
+
+func TestStore(t *testing.T) {
+	source := &fakeSource{stuck: []chainpulse.Packet{
+		testPacket(1, "osmo1alice", 600),
+		testPacket(2, "osmo1bob", 120),
+	}}
+	ingester, db, now := newTestIngester(t, source)
+	_, err := ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	// Packet 1 is relayed an hour later, packet 2 stays stuck
+	*now = testNow.Add(time.Hour)
+	source.stuck = source.stuck[1:]
+	_, err = ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	// Packet 4 gets stuck the next day
+	*now = testNow.Add(24 * time.Hour)
+	source.stuck = append(source.stuck, testPacket(4, "osmo1alice", 60))
+	_, err = ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	store := NewStore(db)
+	ctx := context.Background()
+
+	// What was stuck on the channel on the first day
+	from := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	packets, total, err := store.Packets(ctx, Filter{Channel: "channel-141", From: &from, To: &to})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, packets, 2)
+
+	packets, total, err = store.Packets(ctx, Filter{Address: "osmo1alice"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, uint64(4), packets[0].Sequence, "most recently first seen first")
+
+	_, total, err = store.Packets(ctx, Filter{Status: StatusResolved})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	mttr, err := store.MeanTimeToRelay(ctx, "", "", from, from.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, mttr, 1)
+	assert.Equal(t, 1, mttr[0].Resolved)
+	// Sent ten minutes before first seen, resolved an hour after
+	assert.InDelta(t, (70 * time.Minute).Seconds(), mttr[0].MeanSeconds, 0.001)
+}
+
+func TestHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	source := &fakeSource{stuck: []chainpulse.Packet{testPacket(1, "osmo1alice", 600)}}
+	ingester, db, _ := newTestIngester(t, source)
+	_, err := ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	router := gin.New()
+	NewHandlers(NewStore(db), zap.NewNop()).RegisterRoutes(router.Group("/api/v1"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/users/osmo1alice/stuck-history?from=2026-03-03", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Packets []StuckPacket `json:"packets"`
+		Total   int64         `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, int64(1), body.Total)
+	assert.Equal(t, "channel-141", body.Packets[0].SrcChannel)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/history?from=last-tuesday", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/packets/history/mttr", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestOpenPackets(t *testing.T) {
+	source := &fakeSource{stuck: []chainpulse.Packet{
+		testPacket(1, "osmo1alice", 600),
+		testPacket(2, "osmo1alice", 600),
+		testPacket(3, "osmo1alice", 600),
+	}}
+	ingester, db, now := newTestIngester(t, source)
+	_, err := ingester.Ingest(context.Background())
+	require.NoError(t, err)
+	store := NewStore(db)
+	ctx := context.Background()
+
+	snapshot := testNow
+	page, err := store.OpenPackets(ctx, OpenQuery{Address: "osmo1alice", Snapshot: snapshot, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+
+	// Packet 1 resolves and packet 4 appears before the next page is read
+	*now = testNow.Add(time.Minute)
+	source.stuck = append(source.stuck[1:], testPacket(4, "osmo1alice", 60))
+	_, err = ingester.Ingest(context.Background())
+	require.NoError(t, err)
+
+	last := page[1]
+	next, err := store.OpenPackets(ctx, OpenQuery{Address: "osmo1alice", Snapshot: snapshot, AfterSeenAt: last.FirstSeenAt, AfterID: last.ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, next, 1, "the snapshot leaves out packet 4")
+	assert.Equal(t, uint64(3), next[0].Sequence)
+
+	fresh, err := store.OpenPackets(ctx, OpenQuery{Address: "osmo1alice", Snapshot: *now, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, fresh, 3)
+	assert.Equal(t, uint64(2), fresh[0].Sequence, "packet 1 is resolved")
+}
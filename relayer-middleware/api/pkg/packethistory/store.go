@@ -0,0 +1,161 @@
+package packethistory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Store queries the stuck packet history
+type Store struct {
+	db *gorm.DB
+}
+
+// NewStore creates a stuck packet history reader
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Packets returns a page of the packets matching f, most recently first
+// seen first, and how many match in total
+func (s *Store) Packets(ctx context.Context, f Filter) ([]StuckPacket, int64, error) {
+	query := s.db.WithContext(ctx).Model(&StuckPacket{})
+	if f.ChainID != "" {
+		query = query.Where("chain_id = ?", f.ChainID)
+	}
+	if f.Channel != "" {
+		query = query.Where("src_channel = ?", f.Channel)
+	}
+	if f.Address != "" {
+		query = query.Where("sender = ? OR receiver = ?", f.Address, f.Address)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.To != nil {
+		query = query.Where("first_seen_at <= ?", f.To.UTC())
+	}
+	if f.From != nil {
+		query = query.Where("resolved_at IS NULL OR resolved_at >= ?", f.From.UTC())
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	var packets []StuckPacket
+	err := query.Order("first_seen_at DESC, id DESC").Limit(limit).Offset(f.Offset).Find(&packets).Error
+	return packets, total, err
+}
+
+// MeanTimeToRelay returns the mean time to relay per channel of the packets
+// resolved between from and to, optionally limited to a chain and channel.
+// Packets that timed out are counted but not averaged.
+func (s *Store) MeanTimeToRelay(ctx context.Context, chainID, channel string, from, to time.Time) ([]ChannelMTTR, error) {
+	query := s.db.WithContext(ctx).Model(&StuckPacket{}).
+		Select("chain_id, src_channel, status, sent_at, resolved_at").
+		Where("resolved_at >= ? AND resolved_at < ?", from.UTC(), to.UTC())
+	if chainID != "" {
+		query = query.Where("chain_id = ?", chainID)
+	}
+	if channel != "" {
+		query = query.Where("src_channel = ?", channel)
+	}
+
+	var packets []StuckPacket
+	if err := query.Find(&packets).Error; err != nil {
+		return nil, err
+	}
+
+	byChannel := make(map[string]*ChannelMTTR)
+	total := make(map[string]float64)
+	for _, p := range packets {
+		key := p.ChainID + "/" + p.SrcChannel
+		stats, ok := byChannel[key]
+		if !ok {
+			stats = &ChannelMTTR{ChainID: p.ChainID, SrcChannel: p.SrcChannel}
+			byChannel[key] = stats
+		}
+		if p.Status == StatusTimedOut || p.ResolvedAt == nil {
+			stats.TimedOut++
+			continue
+		}
+		seconds := p.ResolvedAt.Sub(p.SentAt).Seconds()
+		stats.Resolved++
+		total[key] += seconds
+		if seconds > stats.MaxSeconds {
+			stats.MaxSeconds = seconds
+		}
+	}
+
+	result := make([]ChannelMTTR, 0, len(byChannel))
+	for key, stats := range byChannel {
+		if stats.Resolved > 0 {
+			stats.MeanSeconds = total[key] / float64(stats.Resolved)
+		}
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ChainID != result[j].ChainID {
+			return result[i].ChainID < result[j].ChainID
+		}
+		return result[i].SrcChannel < result[j].SrcChannel
+	})
+	return result, nil
+}
+
+// OpenQuery selects the packets that were stuck at Snapshot, oldest first,
+// for keyset pagination. Packets first seen later are left out, and those
+// resolved later stay in, so pages don't shift as the history changes.
+type OpenQuery struct {
+	Address string
+	ChainID string
+	Channel string
+	// Snapshot is when the first page was read
+	Snapshot time.Time
+	// AfterSeenAt and AfterID are the keys of the last packet of the
+	// previous page. A zero AfterID starts from the first page.
+	AfterSeenAt time.Time
+	AfterID     uint
+	Limit       int
+}
+
+// OpenPackets returns a page of the packets stuck at q.Snapshot
+func (s *Store) OpenPackets(ctx context.Context, q OpenQuery) ([]StuckPacket, error) {
+	snapshot := q.Snapshot.UTC()
+	query := s.db.WithContext(ctx).
+		Where("first_seen_at <= ?", snapshot).
+		Where("resolved_at IS NULL OR resolved_at > ?", snapshot)
+	if q.Address != "" {
+		query = query.Where("sender = ? OR receiver = ?", q.Address, q.Address)
+	}
+	if q.ChainID != "" {
+		query = query.Where("chain_id = ?", q.ChainID)
+	}
+	if q.Channel != "" {
+		query = query.Where("src_channel = ?", q.Channel)
+	}
+	if q.AfterID > 0 {
+		after := q.AfterSeenAt.UTC()
+		query = query.Where("first_seen_at > ? OR (first_seen_at = ? AND id > ?)", after, after, q.AfterID)
+	}
+
+	var packets []StuckPacket
+	err := query.Order("first_seen_at, id").Limit(q.Limit).Find(&packets).Error
+	return packets, err
+}
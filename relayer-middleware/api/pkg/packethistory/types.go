@@ -0,0 +1,87 @@
+package packethistory
+
+import (
+	"context"
+	"time"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+// Packet statuses. A packet is stuck, expiring or expired while Chainpulse
+// lists it, and resolved or timed out once it stops doing so.
+const (
+	StatusStuck    = "stuck"
+	StatusExpiring = "expiring"
+	StatusExpired  = "expired"
+	StatusResolved = "resolved"
+	StatusTimedOut = "timed_out"
+)
+
+// statusRank orders the statuses of a packet listed by several endpoints
+var statusRank = map[string]int{
+	StatusStuck:    1,
+	StatusExpiring: 2,
+	StatusExpired:  3,
+}
+
+// StuckPacket is a packet Chainpulse has reported as stuck, expiring or
+// expired, from when it was first seen until it stopped being reported
+type StuckPacket struct {
+	ID            uint       `gorm:"primaryKey" json:"-"`
+	ChainID       string     `gorm:"uniqueIndex:idx_stuck_packets_packet;index:idx_stuck_packets_channel" json:"chain_id"`
+	SrcChannel    string     `gorm:"uniqueIndex:idx_stuck_packets_packet;index:idx_stuck_packets_channel" json:"src_channel"`
+	Sequence      uint64     `gorm:"uniqueIndex:idx_stuck_packets_packet" json:"sequence"`
+	DstChannel    string     `json:"dst_channel"`
+	Sender        string     `gorm:"index" json:"sender"`
+	Receiver      string     `gorm:"index" json:"receiver"`
+	Amount        string     `json:"amount"`
+	Denom         string     `json:"denom"`
+	Status        string     `gorm:"index" json:"status"`
+	RelayAttempts int        `json:"relay_attempts"`
+	LastAttemptBy string     `json:"last_attempt_by,omitempty"`
+	SentAt        time.Time  `json:"sent_at"`
+	TimeoutAt     *time.Time `json:"timeout_at,omitempty"`
+	FirstSeenAt   time.Time  `gorm:"index" json:"first_seen_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ResolvedAt    *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+}
+
+// TableName sets the table name
+func (StuckPacket) TableName() string {
+	return "stuck_packets"
+}
+
+// Filter selects stuck packets. Zero fields match everything.
+type Filter struct {
+	ChainID string
+	// Channel matches the source channel
+	Channel string
+	// Address matches the sender or receiver
+	Address string
+	Status  string
+	// From and To select packets that were stuck at some point in between
+	From *time.Time
+	To   *time.Time
+	// Limit defaults to 100
+	Limit  int
+	Offset int
+}
+
+// ChannelMTTR is the mean time to relay of the packets resolved on a
+// channel, measured from when they were sent
+type ChannelMTTR struct {
+	ChainID     string  `json:"chain_id"`
+	SrcChannel  string  `json:"src_channel"`
+	Resolved    int     `json:"resolved"`
+	TimedOut    int     `json:"timed_out"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	MaxSeconds  float64 `json:"max_seconds"`
+}
+
+// Source lists what Chainpulse currently reports, satisfied by
+// *chainpulse.Client
+type Source interface {
+	AllStuckPackets(ctx context.Context, minAge time.Duration) ([]chainpulse.Packet, error)
+	AllExpiringPackets(ctx context.Context, within time.Duration) ([]chainpulse.ExpiringPacket, error)
+	AllExpiredPackets(ctx context.Context) ([]chainpulse.ExpiredPacket, error)
+}
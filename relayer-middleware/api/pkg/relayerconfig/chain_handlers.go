@@ -0,0 +1,134 @@
+package relayerconfig
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/audit"
+)
+
+// registerChainRoutes registers structured Hermes [[chains]] management
+func (h *Handlers) registerChainRoutes(router *gin.RouterGroup) {
+	chains := router.Group("/config/hermes/chains")
+	{
+		chains.GET("", h.ListChains)
+		chains.GET("/:id", h.GetChain)
+		chains.POST("", h.AddChain)
+		chains.PATCH("/:id", h.UpdateChain)
+		chains.DELETE("/:id", h.RemoveChain)
+	}
+}
+
+// ListChains handles GET /relayer/config/hermes/chains
+func (h *Handlers) ListChains(c *gin.Context) {
+	chains, ok := h.currentChains(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"chains": chains})
+}
+
+// GetChain handles GET /relayer/config/hermes/chains/:id
+func (h *Handlers) GetChain(c *gin.Context) {
+	chains, ok := h.currentChains(c)
+	if !ok {
+		return
+	}
+	for _, chain := range chains {
+		if chain.ID == c.Param("id") {
+			c.JSON(http.StatusOK, chain)
+			return
+		}
+	}
+	h.writeError(c, ErrChainNotFound)
+}
+
+// AddChain handles POST /relayer/config/hermes/chains
+func (h *Handlers) AddChain(c *gin.Context) {
+	var chain ChainConfig
+	if err := c.ShouldBindJSON(&chain); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.editChains(c, fmt.Sprintf("Add chain %s", chain.ID), func(current string) (string, error) {
+		return AddHermesChain(current, chain)
+	})
+}
+
+// UpdateChain handles PATCH /relayer/config/hermes/chains/:id
+func (h *Handlers) UpdateChain(c *gin.Context) {
+	var chain ChainConfig
+	if err := c.ShouldBindJSON(&chain); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The id in the path identifies the entry; renaming isn't supported
+	chain.ID = c.Param("id")
+
+	h.editChains(c, fmt.Sprintf("Update chain %s", chain.ID), func(current string) (string, error) {
+		return UpdateHermesChain(current, chain)
+	})
+}
+
+// RemoveChain handles DELETE /relayer/config/hermes/chains/:id
+func (h *Handlers) RemoveChain(c *gin.Context) {
+	id := c.Param("id")
+	h.editChains(c, fmt.Sprintf("Remove chain %s", id), func(current string) (string, error) {
+		return RemoveHermesChain(current, id)
+	})
+}
+
+// editChains applies a structured edit to the Hermes config through the
+// versioned write path. With ?dry_run=true it returns a preview instead.
+func (h *Handlers) editChains(c *gin.Context, message string, edit func(current string) (string, error)) {
+	if c.Query("dry_run") == "true" {
+		current, err := h.service.Current(RelayerHermes)
+		if h.writeError(c, err) {
+			return
+		}
+		content, err := edit(current)
+		if h.writeError(c, err) {
+			return
+		}
+		preview, err := h.service.Preview(RelayerHermes, content)
+		if h.writeError(c, err) {
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+
+	var before, after string
+	version, err := h.service.Modify(c.Request.Context(), RelayerHermes, author(c), message, func(current string) (string, error) {
+		content, err := edit(current)
+		before, after = current, content
+		return content, err
+	})
+	if err == nil || !isClientError(err) {
+		h.auditLog.RecordRequest(c, audit.ActionConfigUpdate, RelayerHermes, before, after, err)
+	}
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.applied(c, RelayerHermes, version, c.Query("restart") == "true"))
+}
+
+func (h *Handlers) currentChains(c *gin.Context) ([]ChainConfig, bool) {
+	current, err := h.service.Current(RelayerHermes)
+	if h.writeError(c, err) {
+		return nil, false
+	}
+
+	chains, err := ListHermesChains(current)
+	if err != nil {
+		h.logger.Error("Failed to parse Hermes config", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse Hermes config"})
+		return nil, false
+	}
+	return chains, true
+}
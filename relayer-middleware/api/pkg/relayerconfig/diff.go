@@ -0,0 +1,162 @@
+package relayerconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff line operations
+const (
+	DiffContext = "context"
+	DiffAdd     = "add"
+	DiffRemove  = "remove"
+)
+
+// DiffLine is a single line of a line-based diff
+type DiffLine struct {
+	Op      string `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine,omitempty"`
+	NewLine int    `json:"newLine,omitempty"`
+}
+
+// maxDiffCells bounds the LCS table so huge files can't exhaust memory
+const maxDiffCells = 4_000_000
+
+// DiffLines computes a line diff between two texts using the longest
+// common subsequence, so moved or reordered lines show up correctly
+func DiffLines(before, after string) []DiffLine {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	if len(a)*len(b) > maxDiffCells {
+		// Too large for LCS, show a full replacement
+		diff := make([]DiffLine, 0, len(a)+len(b))
+		for i, line := range a {
+			diff = append(diff, DiffLine{Op: DiffRemove, Text: line, OldLine: i + 1})
+		}
+		for i, line := range b {
+			diff = append(diff, DiffLine{Op: DiffAdd, Text: line, NewLine: i + 1})
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, DiffLine{Op: DiffContext, Text: a[i], OldLine: i + 1, NewLine: j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffRemove, Text: a[i], OldLine: i + 1})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffAdd, Text: b[j], NewLine: j + 1})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, DiffLine{Op: DiffRemove, Text: a[i], OldLine: i + 1})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, DiffLine{Op: DiffAdd, Text: b[j], NewLine: j + 1})
+	}
+
+	return diff
+}
+
+// Unified renders a diff in unified format with the given context lines
+func Unified(diff []DiffLine, oldName, newName string, context int) string {
+	// Find which lines to show: every change plus surrounding context
+	show := make([]bool, len(diff))
+	changed := false
+	for idx, line := range diff {
+		if line.Op == DiffContext {
+			continue
+		}
+		changed = true
+		for k := idx - context; k <= idx+context; k++ {
+			if k >= 0 && k < len(diff) {
+				show[k] = true
+			}
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for idx := 0; idx < len(diff); {
+		if !show[idx] {
+			idx++
+			continue
+		}
+
+		// Collect a hunk of consecutive shown lines
+		end := idx
+		for end < len(diff) && show[end] {
+			end++
+		}
+
+		oldStart, newStart, oldCount, newCount := 0, 0, 0, 0
+		for _, line := range diff[idx:end] {
+			if line.OldLine > 0 && oldStart == 0 {
+				oldStart = line.OldLine
+			}
+			if line.NewLine > 0 && newStart == 0 {
+				newStart = line.NewLine
+			}
+			if line.Op != DiffAdd {
+				oldCount++
+			}
+			if line.Op != DiffRemove {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+		for _, line := range diff[idx:end] {
+			switch line.Op {
+			case DiffAdd:
+				sb.WriteString("+")
+			case DiffRemove:
+				sb.WriteString("-")
+			default:
+				sb.WriteString(" ")
+			}
+			sb.WriteString(line.Text)
+			sb.WriteString("\n")
+		}
+		idx = end
+	}
+
+	return sb.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,359 @@
+package relayerconfig
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/relayooor/chains/config"
+)
+
+var (
+	ErrNoChainsSelected = errors.New("at least one chain must be selected")
+	ErrNotInRegistry    = errors.New("chain not in registry")
+	ErrChainNotSelected = errors.New("channel belongs to a chain that isn't selected")
+	ErrGasPriceNeeded   = errors.New("no gas price known for chain")
+)
+
+// ChannelRef selects one end of a channel to relay on
+type ChannelRef struct {
+	ChainID   string `json:"chain_id"`
+	PortID    string `json:"port_id,omitempty"`
+	ChannelID string `json:"channel_id"`
+}
+
+// GasSettings overrides the generated gas settings of a chain
+type GasSettings struct {
+	DefaultGas    uint64  `json:"default_gas,omitempty"`
+	MaxGas        uint64  `json:"max_gas,omitempty"`
+	GasMultiplier float64 `json:"gas_multiplier,omitempty"`
+	GasPrice      float64 `json:"gas_price,omitempty"`
+	GasDenom      string  `json:"gas_denom,omitempty"`
+}
+
+// GenerateOptions selects what goes into a generated Hermes config
+type GenerateOptions struct {
+	// Chains are registry chain IDs, written in the given order
+	Chains []string `json:"chains"`
+	// Channels restrict the packet filters. When empty every active
+	// registry channel between two selected chains is relayed.
+	Channels []ChannelRef `json:"channels,omitempty"`
+	// KeyName is the Hermes key used on every chain unless KeyNames
+	// has an entry for it. Defaults to "relayer".
+	KeyName  string                 `json:"key_name,omitempty"`
+	KeyNames map[string]string      `json:"key_names,omitempty"`
+	Gas      map[string]GasSettings `json:"gas,omitempty"`
+	// MemoPrefix is added to the memo of every relayed transaction
+	MemoPrefix    string `json:"memo_prefix,omitempty"`
+	RESTPort      int    `json:"rest_port,omitempty"`
+	TelemetryPort int    `json:"telemetry_port,omitempty"`
+	// Nodes replaces the public registry endpoints with private ones
+	Nodes map[string]Node `json:"-"`
+}
+
+// GeneratedChain summarises one chain of a generated config
+type GeneratedChain struct {
+	ID        string     `json:"id"`
+	Endpoints string     `json:"endpoints"`
+	KeyName   string     `json:"key_name"`
+	Channels  [][]string `json:"channels"`
+}
+
+// GeneratedConfig is a rendered Hermes config and what went into it
+type GeneratedConfig struct {
+	Config string           `json:"config"`
+	Chains []GeneratedChain `json:"chains"`
+}
+
+// defaultGas is what Hermes is given unless a chain needs more
+var defaultGas = GasSettings{DefaultGas: 100000, MaxGas: 400000, GasMultiplier: 1.1}
+
+// chainGas raises the gas limits of chains whose relay transactions are
+// routinely more expensive, e.g. because of contract callbacks
+var chainGas = map[string]GasSettings{
+	"neutron-1":      {MaxGas: 2000000, GasMultiplier: 1.3},
+	"osmosis-1":      {MaxGas: 1000000, GasMultiplier: 1.2},
+	"dydx-mainnet-1": {MaxGas: 1000000},
+}
+
+const generatedHeader = `# Generated by relayooor from the chain registry
+# Chains: %s
+
+[global]
+log_level = 'info'
+
+[mode.clients]
+enabled = true
+refresh = true
+misbehaviour = false
+
+[mode.connections]
+enabled = false
+
+[mode.channels]
+enabled = false
+
+[mode.packets]
+enabled = true
+clear_interval = 100
+clear_on_start = true
+tx_confirmation = true
+
+[rest]
+enabled = true
+host = '0.0.0.0'
+port = %d
+
+[telemetry]
+enabled = true
+host = '0.0.0.0'
+port = %d
+`
+
+// GenerateHermes renders a complete Hermes config for the selected chains
+// and channels. The result is validated before it's returned.
+func GenerateHermes(registry *config.ChainRegistry, opts GenerateOptions) (*GeneratedConfig, error) {
+	chainIDs := dedupe(opts.Chains)
+	if len(chainIDs) == 0 {
+		return nil, ErrNoChainsSelected
+	}
+	for _, id := range chainIDs {
+		if _, ok := registry.GetChainByID(id); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotInRegistry, id)
+		}
+	}
+
+	filters, err := packetFilters(registry, chainIDs, opts.Channels)
+	if err != nil {
+		return nil, err
+	}
+
+	restPort, telemetryPort := opts.RESTPort, opts.TelemetryPort
+	if restPort == 0 {
+		restPort = 5185
+	}
+	if telemetryPort == 0 {
+		telemetryPort = 3001
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, generatedHeader, strings.Join(chainIDs, ", "), restPort, telemetryPort)
+
+	generated := &GeneratedConfig{Chains: make([]GeneratedChain, 0, len(chainIDs))}
+	for _, id := range chainIDs {
+		chain, _ := registry.GetChainByID(id)
+		entry, summary, err := generateChain(chain, filters[id], opts)
+		if err != nil {
+			return nil, err
+		}
+
+		block, err := AddHermesChain("", entry)
+		if err != nil {
+			return nil, err
+		}
+		comment := chain.ChainName
+		if summary.Endpoints == "private" {
+			comment += " (private endpoints from nodes.toml)"
+		}
+		fmt.Fprintf(&sb, "\n# %s\n%s", comment, block)
+		generated.Chains = append(generated.Chains, summary)
+	}
+
+	generated.Config = sb.String()
+	if result := ValidateHermes(generated.Config); !result.Valid {
+		return nil, &ValidationError{Issues: result.Issues}
+	}
+	return generated, nil
+}
+
+// packetFilters resolves the [port, channel] allow list of every chain
+func packetFilters(registry *config.ChainRegistry, chainIDs []string, refs []ChannelRef) (map[string][][]string, error) {
+	selected := make(map[string]bool, len(chainIDs))
+	for _, id := range chainIDs {
+		selected[id] = true
+	}
+
+	filters := make(map[string][][]string, len(chainIDs))
+	seen := make(map[string]bool)
+	add := func(chainID, port, channel string) {
+		key := chainID + "/" + port + "/" + channel
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		filters[chainID] = append(filters[chainID], []string{port, channel})
+	}
+
+	channels := registry.AllChannels()
+	if len(refs) == 0 {
+		for _, ch := range channels {
+			if ch.Status == "active" && selected[ch.SourceChain] && selected[ch.DestChain] {
+				add(ch.SourceChain, ch.SourcePort, ch.SourceChannel)
+			}
+		}
+		return filters, nil
+	}
+
+	for _, ref := range refs {
+		if !selected[ref.ChainID] {
+			return nil, fmt.Errorf("%w: %s %s", ErrChainNotSelected, ref.ChainID, ref.ChannelID)
+		}
+
+		known := false
+		for _, ch := range channels {
+			if ch.SourceChain != ref.ChainID || ch.SourceChannel != ref.ChannelID {
+				continue
+			}
+			if ref.PortID != "" && ch.SourcePort != ref.PortID {
+				continue
+			}
+			known = true
+			add(ch.SourceChain, ch.SourcePort, ch.SourceChannel)
+			// Relaying needs both ends, so include the counterparty when we can
+			if selected[ch.DestChain] {
+				add(ch.DestChain, ch.DestPort, ch.DestChannel)
+			}
+		}
+
+		if !known {
+			port := ref.PortID
+			if port == "" {
+				port = "transfer"
+			}
+			add(ref.ChainID, port, ref.ChannelID)
+		}
+	}
+
+	return filters, nil
+}
+
+// generateChain builds the [[chains]] entry for a registry chain
+func generateChain(chain config.ChainConfig, channels [][]string, opts GenerateOptions) (ChainConfig, GeneratedChain, error) {
+	summary := GeneratedChain{ID: chain.ChainID, Endpoints: "public", Channels: channels}
+	if summary.Channels == nil {
+		summary.Channels = [][]string{}
+	}
+
+	endpoints := chain.Endpoints()
+	rpc, grpc, ws := endpoints.RPC, endpoints.GRPC, endpoints.WS
+	var compatMode string
+	if node, ok := opts.Nodes[chain.ChainID]; ok && node.Usable() {
+		summary.Endpoints = "private"
+		rpc = node.RPC
+		if node.GRPC != "" {
+			grpc = node.GRPC
+		}
+		if node.WS != "" {
+			ws = node.WS
+		}
+		compatMode = node.CometVersion
+	}
+
+	gas := resolveGas(chain, opts.Gas[chain.ChainID])
+	if gas.GasDenom == "" {
+		return ChainConfig{}, summary, fmt.Errorf("%w: %s", ErrGasPriceNeeded, chain.ChainID)
+	}
+
+	summary.KeyName = opts.KeyNames[chain.ChainID]
+	if summary.KeyName == "" {
+		summary.KeyName = opts.KeyName
+	}
+	if summary.KeyName == "" {
+		summary.KeyName = "relayer"
+	}
+
+	eventSource := &EventSource{Mode: stringPtr("pull"), Interval: stringPtr("1s")}
+	if ws != "" {
+		eventSource = &EventSource{Mode: stringPtr("push"), URL: stringPtr(ws), BatchDelay: stringPtr("500ms")}
+	}
+
+	trustedNode := false
+	maxMsgNum := 30
+	maxTxSize := 1800000
+	entry := ChainConfig{
+		ID:            chain.ChainID,
+		Type:          stringPtr("CosmosSdk"),
+		RPCAddr:       stringPtr(rpc),
+		GRPCAddr:      stringPtr(grpcURL(grpc)),
+		EventSource:   eventSource,
+		RPCTimeout:    stringPtr("10s"),
+		TrustedNode:   &trustedNode,
+		AccountPrefix: stringPtr(chain.AddressPrefix),
+		KeyName:       stringPtr(summary.KeyName),
+		KeyStoreType:  stringPtr("Test"),
+		StorePrefix:   stringPtr("ibc"),
+		DefaultGas:    &gas.DefaultGas,
+		MaxGas:        &gas.MaxGas,
+		GasPrice:      &GasPrice{Price: &gas.GasPrice, Denom: &gas.GasDenom},
+		GasMultiplier: &gas.GasMultiplier,
+		MaxMsgNum:     &maxMsgNum,
+		MaxTxSize:     &maxTxSize,
+		ClockDrift:    stringPtr("5s"),
+		MaxBlockTime:  stringPtr("30s"),
+		PacketFilter:  &PacketFilter{Policy: stringPtr("allow"), List: summary.Channels},
+	}
+	if opts.MemoPrefix != "" {
+		entry.MemoPrefix = stringPtr(opts.MemoPrefix)
+	}
+	if compatMode != "" {
+		entry.CompatMode = stringPtr(compatMode)
+	}
+
+	return entry, summary, nil
+}
+
+// resolveGas layers the request overrides over the per-chain and global defaults
+func resolveGas(chain config.ChainConfig, override GasSettings) GasSettings {
+	gas := defaultGas
+	gas.GasPrice = chain.GasPrice
+	gas.GasDenom = chain.FeeDenom
+
+	for _, layer := range []GasSettings{chainGas[chain.ChainID], override} {
+		if layer.DefaultGas != 0 {
+			gas.DefaultGas = layer.DefaultGas
+		}
+		if layer.MaxGas != 0 {
+			gas.MaxGas = layer.MaxGas
+		}
+		if layer.GasMultiplier != 0 {
+			gas.GasMultiplier = layer.GasMultiplier
+		}
+		if layer.GasPrice != 0 {
+			gas.GasPrice = layer.GasPrice
+		}
+		if layer.GasDenom != "" {
+			gas.GasDenom = layer.GasDenom
+		}
+	}
+
+	if gas.MaxGas < gas.DefaultGas {
+		gas.MaxGas = gas.DefaultGas
+	}
+	return gas
+}
+
+// grpcURL adds the scheme Hermes requires to bare host:port gRPC endpoints,
+// assuming TLS on port 443
+func grpcURL(endpoint string) string {
+	if endpoint == "" || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	if _, port, err := net.SplitHostPort(endpoint); err == nil && port == "443" {
+		return "https://" + endpoint
+	}
+	return "http://" + endpoint
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
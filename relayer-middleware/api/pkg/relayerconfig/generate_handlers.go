@@ -0,0 +1,66 @@
+package relayerconfig
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GenerateRequest is the body for generating a Hermes config
+type GenerateRequest struct {
+	GenerateOptions
+	// PrivateEndpoints uses the endpoints in nodes.toml where available
+	PrivateEndpoints bool `json:"private_endpoints"`
+}
+
+// GenerateHermesConfig handles POST /relayer/config/hermes/generate. The
+// generated config is returned with a preview against the current one;
+// ?format=toml returns the file itself and ?apply=true writes it through
+// the versioned write path (honouring ?restart=true).
+func (h *Handlers) GenerateHermesConfig(c *gin.Context) {
+	var req GenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := req.GenerateOptions
+	if req.PrivateEndpoints {
+		nodes, err := LoadNodes(NodesPath())
+		if err != nil {
+			h.logger.Error("Failed to load nodes.toml", zap.String("path", NodesPath()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load private endpoints"})
+			return
+		}
+		opts.Nodes = nodes
+	}
+
+	generated, err := GenerateHermes(h.registry, opts)
+	if h.writeError(c, err) {
+		return
+	}
+
+	if c.Query("apply") == "true" {
+		h.editChains(c, "Generate from chain registry", func(string) (string, error) {
+			return generated.Config, nil
+		})
+		return
+	}
+
+	if c.Query("format") == "toml" {
+		c.Data(http.StatusOK, "application/toml; charset=utf-8", []byte(generated.Config))
+		return
+	}
+
+	preview, err := h.service.Preview(RelayerHermes, generated.Config)
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"config":  generated.Config,
+		"chains":  generated.Chains,
+		"preview": preview,
+	})
+}
@@ -0,0 +1,86 @@
+package relayerconfig
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/relayooor/chains/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files")
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, []byte(actual), 0644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+func TestGenerateHermesPublicEndpoints(t *testing.T) {
+	generated, err := GenerateHermes(config.DefaultChainRegistry(), GenerateOptions{
+		Chains:     []string{"cosmoshub-4", "osmosis-1", "neutron-1"},
+		MemoPrefix: "Relayed by Relayooor",
+	})
+	require.NoError(t, err)
+	assertGolden(t, "hermes_public.golden.toml", generated.Config)
+
+	require.Len(t, generated.Chains, 3)
+	assert.Equal(t, "public", generated.Chains[0].Endpoints)
+	assert.Equal(t, [][]string{{"transfer", "channel-141"}, {"transfer", "channel-569"}}, generated.Chains[0].Channels)
+
+	chains, err := ListHermesChains(generated.Config)
+	require.NoError(t, err)
+	require.Len(t, chains, 3)
+	assert.Equal(t, "https://grpc.cosmos.network:443", *chains[0].GRPCAddr)
+	assert.Equal(t, "http://grpc.osmosis.zone:9090", *chains[1].GRPCAddr)
+	assert.Equal(t, uint64(2000000), *chains[2].MaxGas)
+}
+
+func TestGenerateHermesPrivateEndpoints(t *testing.T) {
+	nodes, err := LoadNodes(filepath.Join("testdata", "nodes.toml"))
+	require.NoError(t, err)
+
+	generated, err := GenerateHermes(config.DefaultChainRegistry(), GenerateOptions{
+		Chains:   []string{"cosmoshub-4", "noble-1"},
+		Channels: []ChannelRef{{ChainID: "cosmoshub-4", ChannelID: "channel-536"}},
+		KeyNames: map[string]string{"noble-1": "noble-relayer"},
+		Gas:      map[string]GasSettings{"noble-1": {GasPrice: 0.2}},
+		Nodes:    nodes,
+	})
+	require.NoError(t, err)
+	assertGolden(t, "hermes_private.golden.toml", generated.Config)
+
+	// noble-1 is marked inactive in nodes.toml so its public endpoints are kept
+	assert.Equal(t, "private", generated.Chains[0].Endpoints)
+	assert.Equal(t, "public", generated.Chains[1].Endpoints)
+	assert.Equal(t, [][]string{{"transfer", "channel-4"}}, generated.Chains[1].Channels)
+	assert.Equal(t, "noble-relayer", generated.Chains[1].KeyName)
+}
+
+func TestGenerateHermesErrors(t *testing.T) {
+	registry := config.DefaultChainRegistry()
+
+	_, err := GenerateHermes(registry, GenerateOptions{})
+	assert.ErrorIs(t, err, ErrNoChainsSelected)
+
+	_, err = GenerateHermes(registry, GenerateOptions{Chains: []string{"unknown-1"}})
+	assert.ErrorIs(t, err, ErrNotInRegistry)
+
+	_, err = GenerateHermes(registry, GenerateOptions{
+		Chains:   []string{"cosmoshub-4"},
+		Channels: []ChannelRef{{ChainID: "osmosis-1", ChannelID: "channel-0"}},
+	})
+	assert.ErrorIs(t, err, ErrChainNotSelected)
+
+	registry.Chains["test-1"] = config.ChainConfig{ChainID: "test-1", RPCEndpoint: "http://localhost:26657"}
+	_, err = GenerateHermes(registry, GenerateOptions{Chains: []string{"test-1"}})
+	assert.ErrorIs(t, err, ErrGasPriceNeeded)
+}
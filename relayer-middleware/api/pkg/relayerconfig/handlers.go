@@ -0,0 +1,278 @@
+package relayerconfig
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/relayooor/chains/config"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/audit"
+)
+
+// Restarter restarts a relayer process so it picks up a new config
+type Restarter interface {
+	Restart(ctx context.Context, relayer string) error
+}
+
+// RestarterFunc adapts a function to the Restarter interface
+type RestarterFunc func(ctx context.Context, relayer string) error
+
+// Restart calls f(ctx, relayer)
+func (f RestarterFunc) Restart(ctx context.Context, relayer string) error {
+	return f(ctx, relayer)
+}
+
+// Handlers exposes validated, versioned config editing
+type Handlers struct {
+	service   *Service
+	restarter Restarter
+	auditLog  *audit.Service
+	registry  *config.ChainRegistry
+	logger    *zap.Logger
+}
+
+// NewHandlers creates config handlers. restarter and auditLog may be nil.
+func NewHandlers(service *Service, restarter Restarter, auditLog *audit.Service, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		service:   service,
+		restarter: restarter,
+		auditLog:  auditLog,
+		registry:  config.DefaultChainRegistry(),
+		logger:    logger.With(zap.String("component", "relayer_config_handlers")),
+	}
+}
+
+// UseChainRegistry sets the registry configs are generated from
+func (h *Handlers) UseChainRegistry(registry *config.ChainRegistry) {
+	h.registry = registry
+}
+
+// UpdateConfigRequest is the body for updating or previewing a config
+type UpdateConfigRequest struct {
+	Relayer string `json:"relayer" binding:"required,oneof=hermes rly"`
+	Config  string `json:"config" binding:"required"`
+	Message string `json:"message"`
+	Restart bool   `json:"restart"`
+}
+
+// RollbackRequest is the body for rolling back to a previous version
+type RollbackRequest struct {
+	Relayer string `json:"relayer" binding:"required,oneof=hermes rly"`
+	Version int    `json:"version" binding:"required,min=1"`
+	Restart bool   `json:"restart"`
+}
+
+// RegisterRoutes registers config routes on the relayer group
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.PUT("/config", h.UpdateConfig)
+	router.POST("/config/validate", h.ValidateConfig)
+	router.POST("/config/preview", h.PreviewConfig)
+	router.GET("/config/history/:relayer", h.GetHistory)
+	router.GET("/config/history/:relayer/:version", h.GetVersion)
+	router.POST("/config/rollback", h.Rollback)
+	router.POST("/config/hermes/generate", h.GenerateHermesConfig)
+	h.registerChainRoutes(router)
+}
+
+// ValidateConfig handles POST /relayer/config/validate
+func (h *Handlers) ValidateConfig(c *gin.Context) {
+	var req UpdateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := Validate(req.Relayer, req.Config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PreviewConfig handles POST /relayer/config/preview
+func (h *Handlers) PreviewConfig(c *gin.Context) {
+	var req UpdateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.service.Preview(req.Relayer, req.Config)
+	if err != nil {
+		h.logger.Error("Failed to preview config", zap.String("relayer", req.Relayer), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// UpdateConfig handles PUT /relayer/config
+func (h *Handlers) UpdateConfig(c *gin.Context) {
+	var req UpdateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous, _ := h.service.Current(req.Relayer)
+	version, err := h.service.Apply(c.Request.Context(), req.Relayer, req.Config, author(c), req.Message)
+	// Rejected edits never touch disk, so only attempted writes are audited
+	if err == nil || !isClientError(err) {
+		h.auditLog.RecordRequest(c, audit.ActionConfigUpdate, req.Relayer, previous, req.Config, err)
+	}
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.applied(c, req.Relayer, version, req.Restart))
+}
+
+// Rollback handles POST /relayer/config/rollback
+func (h *Handlers) Rollback(c *gin.Context) {
+	var req RollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	previous, _ := h.service.Current(req.Relayer)
+	version, err := h.service.Rollback(c.Request.Context(), req.Relayer, req.Version, author(c))
+	if err == nil || !isClientError(err) {
+		after := ""
+		if version != nil {
+			after = version.Content
+		}
+		h.auditLog.RecordRequest(c, audit.ActionConfigRollback, req.Relayer, previous, after, err)
+	}
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, h.applied(c, req.Relayer, version, req.Restart))
+}
+
+// GetHistory handles GET /relayer/config/history/:relayer
+func (h *Handlers) GetHistory(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	versions, err := h.service.History(c.Request.Context(), c.Param("relayer"), limit)
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"relayer":  c.Param("relayer"),
+		"versions": versions,
+	})
+}
+
+// GetVersion handles GET /relayer/config/history/:relayer/:version
+func (h *Handlers) GetVersion(c *gin.Context) {
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	version, err := h.service.GetVersion(c.Request.Context(), c.Param("relayer"), number)
+	if h.writeError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// applied builds the response for a written config, restarting the relayer if asked
+func (h *Handlers) applied(c *gin.Context, relayer string, version *ConfigVersion, restart bool) gin.H {
+	response := gin.H{
+		"status":  "success",
+		"relayer": relayer,
+		"version": version.Version,
+	}
+	if !restart {
+		return response
+	}
+
+	if h.restarter == nil {
+		response["restart"] = gin.H{"status": "unavailable"}
+		return response
+	}
+
+	action := audit.ActionHermesRestart
+	if relayer == RelayerRly {
+		action = audit.ActionRlyRestart
+	}
+
+	err := h.restarter.Restart(c.Request.Context(), relayer)
+	h.auditLog.RecordRequest(c, action, relayer, nil, gin.H{"version": version.Version}, err)
+	if err != nil {
+		h.logger.Error("Failed to restart relayer after config change",
+			zap.String("relayer", relayer),
+			zap.Int("version", version.Version),
+			zap.Error(err),
+		)
+		response["restart"] = gin.H{"status": "failed", "error": err.Error()}
+		return response
+	}
+
+	response["restart"] = gin.H{"status": "restarted"}
+	return response
+}
+
+// writeError writes the response for a service error and reports whether there was one
+func (h *Handlers) writeError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  "Config failed validation",
+			"issues": validationErr.Issues,
+		})
+	case errors.Is(err, ErrUnknownRelayer):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrChainIDNeeded), isGenerateError(err):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrVersionNotFound), errors.Is(err, ErrChainNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNoChanges), errors.Is(err, ErrChainExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Relayer config operation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update config"})
+	}
+	return true
+}
+
+func isClientError(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr) ||
+		errors.Is(err, ErrUnknownRelayer) ||
+		errors.Is(err, ErrVersionNotFound) ||
+		errors.Is(err, ErrNoChanges) ||
+		errors.Is(err, ErrChainNotFound) ||
+		errors.Is(err, ErrChainExists) ||
+		errors.Is(err, ErrChainIDNeeded) ||
+		isGenerateError(err)
+}
+
+func isGenerateError(err error) bool {
+	return errors.Is(err, ErrNoChainsSelected) ||
+		errors.Is(err, ErrNotInRegistry) ||
+		errors.Is(err, ErrChainNotSelected) ||
+		errors.Is(err, ErrGasPriceNeeded)
+}
+
+func author(c *gin.Context) string {
+	return audit.ActorFromGin(c).ID
+}
@@ -0,0 +1,412 @@
+package relayerconfig
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+var (
+	ErrChainNotFound = errors.New("chain not found")
+	ErrChainExists   = errors.New("chain already exists")
+	ErrChainIDNeeded = errors.New("chain id is required")
+)
+
+// ChainConfig is a Hermes [[chains]] entry. Fields mirror the Hermes keys;
+// nil fields are left untouched on update.
+type ChainConfig struct {
+	ID             string        `json:"id" toml:"id"`
+	Type           *string       `json:"type,omitempty" toml:"type"`
+	RPCAddr        *string       `json:"rpc_addr,omitempty" toml:"rpc_addr"`
+	GRPCAddr       *string       `json:"grpc_addr,omitempty" toml:"grpc_addr"`
+	EventSource    *EventSource  `json:"event_source,omitempty" toml:"event_source"`
+	RPCTimeout     *string       `json:"rpc_timeout,omitempty" toml:"rpc_timeout"`
+	TrustedNode    *bool         `json:"trusted_node,omitempty" toml:"trusted_node"`
+	AccountPrefix  *string       `json:"account_prefix,omitempty" toml:"account_prefix"`
+	KeyName        *string       `json:"key_name,omitempty" toml:"key_name"`
+	KeyStoreType   *string       `json:"key_store_type,omitempty" toml:"key_store_type"`
+	StorePrefix    *string       `json:"store_prefix,omitempty" toml:"store_prefix"`
+	DefaultGas     *uint64       `json:"default_gas,omitempty" toml:"default_gas"`
+	MaxGas         *uint64       `json:"max_gas,omitempty" toml:"max_gas"`
+	GasPrice       *GasPrice     `json:"gas_price,omitempty" toml:"gas_price"`
+	GasMultiplier  *float64      `json:"gas_multiplier,omitempty" toml:"gas_multiplier"`
+	MaxMsgNum      *int          `json:"max_msg_num,omitempty" toml:"max_msg_num"`
+	MaxTxSize      *int          `json:"max_tx_size,omitempty" toml:"max_tx_size"`
+	ClockDrift     *string       `json:"clock_drift,omitempty" toml:"clock_drift"`
+	MaxBlockTime   *string       `json:"max_block_time,omitempty" toml:"max_block_time"`
+	TrustingPeriod *string       `json:"trusting_period,omitempty" toml:"trusting_period"`
+	MemoPrefix     *string       `json:"memo_prefix,omitempty" toml:"memo_prefix"`
+	CompatMode     *string       `json:"compat_mode,omitempty" toml:"compat_mode"`
+	PacketFilter   *PacketFilter `json:"packet_filter,omitempty" toml:"packet_filter"`
+}
+
+// EventSource configures how Hermes receives chain events
+type EventSource struct {
+	Mode       *string `json:"mode,omitempty" toml:"mode"`
+	URL        *string `json:"url,omitempty" toml:"url"`
+	BatchDelay *string `json:"batch_delay,omitempty" toml:"batch_delay"`
+	Interval   *string `json:"interval,omitempty" toml:"interval"`
+}
+
+// GasPrice is the fee Hermes pays per unit of gas
+type GasPrice struct {
+	Price *float64 `json:"price,omitempty" toml:"price"`
+	Denom *string  `json:"denom,omitempty" toml:"denom"`
+}
+
+// PacketFilter restricts which [port, channel] pairs Hermes relays
+type PacketFilter struct {
+	Policy *string    `json:"policy,omitempty" toml:"policy"`
+	List   [][]string `json:"list,omitempty" toml:"list"`
+}
+
+// subtableKeys are nested objects written as [chains.<key>] sections when
+// they don't exist yet; other nested objects are written as inline tables
+var subtableKeys = map[string]bool{"packet_filter": true}
+
+// keyUpdate is a single key to set, table is empty for top-level chain keys
+type keyUpdate struct {
+	table string
+	key   string
+	value interface{}
+}
+
+// ListHermesChains decodes the [[chains]] entries of a Hermes config
+func ListHermesChains(content string) ([]ChainConfig, error) {
+	var cfg struct {
+		Chains []ChainConfig `toml:"chains"`
+	}
+	if err := toml.Unmarshal([]byte(content), &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Chains == nil {
+		cfg.Chains = []ChainConfig{}
+	}
+	return cfg.Chains, nil
+}
+
+// AddHermesChain appends a new [[chains]] entry after the existing ones
+func AddHermesChain(content string, chain ChainConfig) (string, error) {
+	if chain.ID == "" {
+		return "", ErrChainIDNeeded
+	}
+	if chain.Type == nil {
+		chain.Type = stringPtr("CosmosSdk")
+	}
+	if chain.StorePrefix == nil {
+		chain.StorePrefix = stringPtr("ibc")
+	}
+
+	doc := parseDoc(content)
+	blocks := doc.chainBlocks()
+	for _, block := range blocks {
+		if id, _ := doc.blockID(block); id == chain.ID {
+			return "", ErrChainExists
+		}
+	}
+
+	insertAt := len(doc.lines)
+	if len(blocks) > 0 {
+		insertAt = blocks[len(blocks)-1].contentEnd
+	} else {
+		insertAt = doc.contentEnd(0, len(doc.lines))
+	}
+
+	entry := []string{"[[chains]]", "id = " + renderString(chain.ID)}
+	if insertAt > 0 {
+		entry = append([]string{""}, entry...)
+	}
+	doc.splice(insertAt, insertAt, entry)
+
+	if err := doc.applyUpdates(chain.ID, chainUpdates(chain)); err != nil {
+		return "", err
+	}
+	return doc.String(), nil
+}
+
+// UpdateHermesChain sets the non-nil fields of chain on the entry with the
+// same id, keeping comments and keys that aren't part of the update
+func UpdateHermesChain(content string, chain ChainConfig) (string, error) {
+	if chain.ID == "" {
+		return "", ErrChainIDNeeded
+	}
+
+	doc := parseDoc(content)
+	if _, err := doc.findChain(chain.ID); err != nil {
+		return "", err
+	}
+	if err := doc.applyUpdates(chain.ID, chainUpdates(chain)); err != nil {
+		return "", err
+	}
+	return doc.String(), nil
+}
+
+// RemoveHermesChain deletes a [[chains]] entry and the comments directly above it
+func RemoveHermesChain(content, id string) (string, error) {
+	doc := parseDoc(content)
+	block, err := doc.findChain(id)
+	if err != nil {
+		return "", err
+	}
+
+	start := block.header
+	for start > 0 && doc.info[start-1].kind == lineComment {
+		start--
+	}
+	end := block.contentEnd
+	// Don't leave two blank lines where the entry was
+	if end < len(doc.lines) && doc.info[end].kind == lineBlank && (start == 0 || doc.info[start-1].kind == lineBlank) {
+		end++
+	}
+
+	doc.splice(start, end, nil)
+	return doc.String(), nil
+}
+
+// chainBlock locates one [[chains]] entry in a document
+type chainBlock struct {
+	header     int
+	contentEnd int // after the last key/value, before trailing comments
+}
+
+func (d *tomlDoc) chainBlocks() []chainBlock {
+	var blocks []chainBlock
+	for i, info := range d.info {
+		if info.kind != lineHeader || !info.array || info.name != "chains" {
+			continue
+		}
+		end := len(d.lines)
+		for j := i + 1; j < len(d.lines); j++ {
+			next := d.info[j]
+			if next.kind == lineHeader && !strings.HasPrefix(next.name, "chains.") {
+				end = j
+				break
+			}
+		}
+		blocks = append(blocks, chainBlock{
+			header:     i,
+			contentEnd: d.contentEnd(i, end),
+		})
+	}
+	return blocks
+}
+
+// blockID decodes a block on its own to read its chain id
+func (d *tomlDoc) blockID(block chainBlock) (string, error) {
+	chain, err := d.blockValues(block)
+	if err != nil {
+		return "", err
+	}
+	id, _ := chain["id"].(string)
+	return id, nil
+}
+
+func (d *tomlDoc) blockValues(block chainBlock) (map[string]interface{}, error) {
+	var parsed struct {
+		Chains []map[string]interface{} `toml:"chains"`
+	}
+	text := strings.Join(d.lines[block.header:block.contentEnd], "\n")
+	if err := toml.Unmarshal([]byte(text), &parsed); err != nil {
+		return nil, err
+	}
+	if len(parsed.Chains) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	return parsed.Chains[0], nil
+}
+
+func (d *tomlDoc) findChain(id string) (chainBlock, error) {
+	for _, block := range d.chainBlocks() {
+		if blockID, err := d.blockID(block); err == nil && blockID == id {
+			return block, nil
+		}
+	}
+	return chainBlock{}, ErrChainNotFound
+}
+
+// section returns the body [start, end) of the chain's top-level keys, or of
+// its [chains.<table>] subtable. ok is false if the subtable doesn't exist.
+func (d *tomlDoc) section(block chainBlock, table string) (start, end int, ok bool) {
+	if table == "" {
+		return block.header + 1, d.nextHeader(block.header+1, block.contentEnd), true
+	}
+	for i := block.header + 1; i < block.contentEnd; i++ {
+		if d.info[i].kind == lineHeader && d.info[i].name == "chains."+table {
+			return i + 1, d.nextHeader(i+1, block.contentEnd), true
+		}
+	}
+	return 0, 0, false
+}
+
+// nextHeader returns the first header in [from, limit), or limit
+func (d *tomlDoc) nextHeader(from, limit int) int {
+	for i := from; i < limit; i++ {
+		if d.info[i].kind == lineHeader {
+			return i
+		}
+	}
+	return limit
+}
+
+// applyUpdates sets each key on the chain, re-locating the block after every
+// edit since line numbers shift
+func (d *tomlDoc) applyUpdates(id string, updates []keyUpdate) error {
+	var deferred []string
+	byTable := make(map[string][]keyUpdate)
+	for i, update := range updates {
+		if update.table == "" {
+			block, err := d.findChain(id)
+			if err != nil {
+				return err
+			}
+			start, end, _ := d.section(block, "")
+			d.setKey(start, end, update.key, renderValue(update.value, false))
+			continue
+		}
+
+		byTable[update.table] = append(byTable[update.table], update)
+		if i+1 < len(updates) && updates[i+1].table == update.table {
+			continue
+		}
+		// Subtables go after top-level keys so new sections land at the
+		// end; inline tables keep their place in the field order
+		if subtableKeys[update.table] {
+			deferred = append(deferred, update.table)
+			continue
+		}
+		if err := d.applyTableUpdates(id, update.table, byTable[update.table]); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range deferred {
+		if err := d.applyTableUpdates(id, table, byTable[table]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *tomlDoc) applyTableUpdates(id, table string, updates []keyUpdate) error {
+	block, err := d.findChain(id)
+	if err != nil {
+		return err
+	}
+
+	// Existing [chains.<table>] section: set keys inside it
+	if _, _, ok := d.section(block, table); ok {
+		for _, update := range updates {
+			block, _ = d.findChain(id)
+			start, end, _ := d.section(block, table)
+			d.setKey(start, end, update.key, renderValue(update.value, false))
+		}
+		return nil
+	}
+
+	start, end, _ := d.section(block, "")
+
+	// Dotted keys (table.key = value): set each one
+	for i := start; i < end; i++ {
+		if d.info[i].kind == lineKeyValue && strings.HasPrefix(d.info[i].name, table+".") {
+			for _, update := range updates {
+				block, _ = d.findChain(id)
+				start, end, _ = d.section(block, "")
+				d.setKey(start, end, table+"."+update.key, renderValue(update.value, false))
+			}
+			return nil
+		}
+	}
+
+	values, err := d.blockValues(block)
+	if err != nil {
+		return err
+	}
+	existing, hasInline := values[table].(map[string]interface{})
+
+	// New subtable appended at the end of the entry
+	if !hasInline && subtableKeys[table] {
+		lines := []string{"", "[chains." + table + "]"}
+		for _, update := range updates {
+			lines = append(lines, update.key+" = "+renderValue(update.value, false))
+		}
+		d.splice(block.contentEnd, block.contentEnd, lines)
+		return nil
+	}
+
+	// Inline table: merge so keys we don't model are kept
+	merged := make(map[string]interface{}, len(existing)+len(updates))
+	for key, value := range existing {
+		merged[key] = value
+	}
+	for _, update := range updates {
+		merged[update.key] = update.value
+	}
+	d.setKey(start, end, table, renderInlineTable(merged, tableKeyOrder(table)))
+	return nil
+}
+
+// chainUpdates flattens the set fields of a ChainConfig into key updates,
+// in the order of the struct fields
+func chainUpdates(chain ChainConfig) []keyUpdate {
+	var updates []keyUpdate
+	rv := reflect.ValueOf(chain)
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		key := tomlKey(rt.Field(i))
+		field := rv.Field(i)
+		if key == "id" || field.IsZero() {
+			continue
+		}
+
+		if field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct {
+			nested := field.Elem()
+			for j := 0; j < nested.NumField(); j++ {
+				sub := nested.Field(j)
+				if sub.IsZero() {
+					continue
+				}
+				updates = append(updates, keyUpdate{table: key, key: tomlKey(nested.Type().Field(j)), value: deref(sub)})
+			}
+			continue
+		}
+
+		updates = append(updates, keyUpdate{key: key, value: deref(field)})
+	}
+
+	return updates
+}
+
+// tableKeyOrder returns the key order of a nested ChainConfig struct
+func tableKeyOrder(table string) []string {
+	rt := reflect.TypeOf(ChainConfig{})
+	for i := 0; i < rt.NumField(); i++ {
+		if tomlKey(rt.Field(i)) != table {
+			continue
+		}
+		nested := rt.Field(i).Type.Elem()
+		order := make([]string, nested.NumField())
+		for j := range order {
+			order[j] = tomlKey(nested.Field(j))
+		}
+		return order
+	}
+	return nil
+}
+
+func tomlKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("toml"), ",")[0]
+}
+
+func deref(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		return v.Elem().Interface()
+	}
+	return v.Interface()
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,150 @@
+package relayerconfig
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hermesChainsConfig = `[global]
+log_level = 'info'
+
+# Cosmos Hub
+[[chains]]
+id = 'cosmoshub-4'
+type = 'CosmosSdk'
+rpc_addr = 'https://rpc.cosmos.network:443' # primary
+grpc_addr = 'https://grpc.cosmos.network:443'
+event_source = { mode = 'push', url = 'wss://rpc.cosmos.network:443/websocket', batch_delay = '500ms' }
+account_prefix = 'cosmos'
+key_name = 'relayer'
+store_prefix = 'ibc'
+gas_price = { price = 0.025, denom = 'uatom' }
+ccv_consumer_chain = false
+
+[chains.packet_filter]
+policy = 'allow'
+list = [
+  ['transfer', 'channel-141'], # osmosis
+]
+
+# Osmosis
+[[chains]]
+id = 'osmosis-1'
+rpc_addr = 'https://rpc.osmosis.zone:443'
+grpc_addr = 'https://grpc.osmosis.zone:443'
+event_source = { mode = 'push', url = 'wss://rpc.osmosis.zone:443/websocket' }
+account_prefix = 'osmo'
+key_name = 'relayer'
+store_prefix = 'ibc'
+gas_price = { price = 0.0025, denom = 'uosmo' }
+
+# Disabled chains
+# [[chains]]
+# id = 'noble-1'
+`
+
+func TestListHermesChains(t *testing.T) {
+	chains, err := ListHermesChains(hermesChainsConfig)
+	require.NoError(t, err)
+	require.Len(t, chains, 2)
+	assert.Equal(t, "cosmoshub-4", chains[0].ID)
+	assert.Equal(t, [][]string{{"transfer", "channel-141"}}, chains[0].PacketFilter.List)
+	assert.Nil(t, chains[1].PacketFilter)
+}
+
+func TestUpdateHermesChainKeepsComments(t *testing.T) {
+	price := 0.03
+	updated, err := UpdateHermesChain(hermesChainsConfig, ChainConfig{
+		ID:         "cosmoshub-4",
+		RPCAddr:    stringPtr("https://rpc-2.cosmos.network:443"),
+		CompatMode: stringPtr("0.37"),
+		GasPrice:   &GasPrice{Price: &price},
+		PacketFilter: &PacketFilter{
+			List: [][]string{{"transfer", "channel-141"}, {"transfer", "channel-192"}},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, updated, "rpc_addr = 'https://rpc-2.cosmos.network:443' # primary\n")
+	assert.Contains(t, updated, "gas_price = { price = 0.03, denom = 'uatom' }\n")
+	assert.Contains(t, updated, "ccv_consumer_chain = false\ncompat_mode = '0.37'\n")
+	assert.Contains(t, updated, "list = [\n  ['transfer', 'channel-141'],\n  ['transfer', 'channel-192'],\n]\n\n# Osmosis")
+	assert.Contains(t, updated, "# Cosmos Hub\n[[chains]]")
+	assert.Contains(t, updated, "# Disabled chains\n# [[chains]]")
+	assert.True(t, ValidateHermes(updated).Valid, "%+v", ValidateHermes(updated).Issues)
+
+	// Only the targeted chain changes
+	chains, err := ListHermesChains(updated)
+	require.NoError(t, err)
+	assert.Equal(t, "https://rpc.osmosis.zone:443", *chains[1].RPCAddr)
+
+	_, err = UpdateHermesChain(hermesChainsConfig, ChainConfig{ID: "missing-1"})
+	assert.ErrorIs(t, err, ErrChainNotFound)
+}
+
+func TestUpdateHermesChainAddsPacketFilter(t *testing.T) {
+	updated, err := UpdateHermesChain(hermesChainsConfig, ChainConfig{
+		ID: "osmosis-1",
+		PacketFilter: &PacketFilter{
+			Policy: stringPtr("allow"),
+			List:   [][]string{{"transfer", "channel-0"}},
+		},
+		EventSource: &EventSource{BatchDelay: stringPtr("200ms")},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, updated, "event_source = { mode = 'push', url = 'wss://rpc.osmosis.zone:443/websocket', batch_delay = '200ms' }\n")
+	assert.Contains(t, updated, "gas_price = { price = 0.0025, denom = 'uosmo' }\n\n[chains.packet_filter]\npolicy = 'allow'\nlist = [\n  ['transfer', 'channel-0'],\n]\n\n# Disabled chains")
+
+	chains, err := ListHermesChains(updated)
+	require.NoError(t, err)
+	require.NotNil(t, chains[1].PacketFilter)
+	assert.Equal(t, "allow", *chains[1].PacketFilter.Policy)
+}
+
+func TestAddAndRemoveHermesChain(t *testing.T) {
+	price := 0.1
+	added, err := AddHermesChain(hermesChainsConfig, ChainConfig{
+		ID:            "noble-1",
+		RPCAddr:       stringPtr("https://rpc.noble.xyz:443"),
+		GRPCAddr:      stringPtr("https://grpc.noble.xyz:443"),
+		EventSource:   &EventSource{Mode: stringPtr("push"), URL: stringPtr("wss://rpc.noble.xyz:443/websocket")},
+		AccountPrefix: stringPtr("noble"),
+		KeyName:       stringPtr("relayer"),
+		GasPrice:      &GasPrice{Price: &price, Denom: stringPtr("uusdc")},
+	})
+	require.NoError(t, err)
+	assert.True(t, ValidateHermes(added).Valid, "%+v", ValidateHermes(added).Issues)
+	assert.Contains(t, added, "denom = 'uosmo' }\n\n[[chains]]\nid = 'noble-1'\ntype = 'CosmosSdk'\nrpc_addr")
+	assert.Contains(t, added, "gas_price = { price = 0.1, denom = 'uusdc' }\n\n# Disabled chains")
+
+	_, err = AddHermesChain(added, ChainConfig{ID: "noble-1"})
+	assert.ErrorIs(t, err, ErrChainExists)
+
+	// Removing the added chain restores the original document
+	removed, err := RemoveHermesChain(added, "noble-1")
+	require.NoError(t, err)
+	assert.Equal(t, hermesChainsConfig, removed)
+
+	removed, err = RemoveHermesChain(hermesChainsConfig, "cosmoshub-4")
+	require.NoError(t, err)
+	assert.NotContains(t, removed, "Cosmos Hub")
+	assert.NotContains(t, removed, "channel-141")
+	assert.Contains(t, removed, "log_level = 'info'\n\n# Osmosis\n[[chains]]")
+}
+
+func TestRenderStringEscapesControlCharacters(t *testing.T) {
+	for _, s := range []string{"relayer", "it's", "a\tb", "line\nbreak", "nul\x00", "bell\x01", "del\x7f", `back\slash "quoted"`} {
+		rendered := renderString(s)
+		var doc struct{ Value string }
+		require.NoError(t, toml.Unmarshal([]byte("value = "+rendered), &doc), rendered)
+		assert.Equal(t, s, doc.Value, rendered)
+	}
+
+	assert.Equal(t, "'relayer'", renderString("relayer"))
+	assert.Equal(t, `"bell\u0001"`, renderString("bell\x01"))
+	assert.Equal(t, `"del\u007F"`, renderString("del\x7f"))
+}
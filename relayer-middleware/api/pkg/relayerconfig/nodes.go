@@ -0,0 +1,16 @@
+package relayerconfig
+
+import "github.com/relayooor/chains/config"
+
+// Node is a private endpoint set for one chain from nodes.toml
+type Node = config.Node
+
+// NodesPath returns the nodes.toml location, overridable with NODES_CONFIG_PATH
+func NodesPath() string {
+	return envOrDefault("NODES_CONFIG_PATH", "config/nodes.toml")
+}
+
+// LoadNodes reads the private endpoints in a nodes.toml file, keyed by chain ID
+func LoadNodes(path string) (map[string]Node, error) {
+	return config.LoadNodes(path)
+}
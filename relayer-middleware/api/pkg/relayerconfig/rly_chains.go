@@ -0,0 +1,70 @@
+package relayerconfig
+
+import (
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RlyChain is a chain entry of a rly config.yaml
+type RlyChain struct {
+	Name          string `json:"name"`
+	ChainID       string `json:"chain_id"`
+	Key           string `json:"key"`
+	RPCAddr       string `json:"rpc_addr"`
+	AccountPrefix string `json:"account_prefix"`
+	GasPrices     string `json:"gas_prices"`
+}
+
+// ListRlyChains decodes the chains of a rly config, sorted by name
+func ListRlyChains(content string) ([]RlyChain, error) {
+	var cfg rlyConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return nil, err
+	}
+
+	chains := make([]RlyChain, 0, len(cfg.Chains))
+	for name, chain := range cfg.Chains {
+		chains = append(chains, RlyChain{
+			Name:          name,
+			ChainID:       chain.Value.ChainID,
+			Key:           chain.Value.Key,
+			RPCAddr:       chain.Value.RPCAddr,
+			AccountPrefix: chain.Value.AccountPrefix,
+			GasPrices:     chain.Value.GasPrices,
+		})
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].Name < chains[j].Name })
+	return chains, nil
+}
+
+// RlyPath is a path entry of a rly config.yaml. Rule is "allowlist",
+// "denylist" or empty, and applies to Channels on the source chain.
+type RlyPath struct {
+	Name       string   `json:"name"`
+	SrcChainID string   `json:"src_chain_id"`
+	DstChainID string   `json:"dst_chain_id"`
+	Rule       string   `json:"rule,omitempty"`
+	Channels   []string `json:"channels,omitempty"`
+}
+
+// ListRlyPaths decodes the paths of a rly config, sorted by name
+func ListRlyPaths(content string) ([]RlyPath, error) {
+	var cfg rlyConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		return nil, err
+	}
+
+	paths := make([]RlyPath, 0, len(cfg.Paths))
+	for name, path := range cfg.Paths {
+		paths = append(paths, RlyPath{
+			Name:       name,
+			SrcChainID: path.Src.ChainID,
+			DstChainID: path.Dst.ChainID,
+			Rule:       path.SrcChannelFilter.Rule,
+			Channels:   path.SrcChannelFilter.ChannelList,
+		})
+	}
+	sort.Slice(paths, func(i, j int) bool { return paths[i].Name < paths[j].Name })
+	return paths, nil
+}
@@ -0,0 +1,299 @@
+package relayerconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Service validates relayer config edits, writes them to disk and keeps
+// every applied revision in the database so any of them can be restored
+type Service struct {
+	db     *gorm.DB
+	paths  map[string]string
+	mu     sync.Mutex
+	logger *zap.Logger
+}
+
+// NewService creates a config service. Config paths come from
+// HERMES_CONFIG_PATH and RLY_CONFIG_PATH.
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return NewServiceWithPaths(db, map[string]string{
+		RelayerHermes: envOrDefault("HERMES_CONFIG_PATH", "/home/relayer/.hermes/config.toml"),
+		RelayerRly:    envOrDefault("RLY_CONFIG_PATH", "/home/relayer/.relayer/config/config.yaml"),
+	}, logger)
+}
+
+// NewServiceWithPaths creates a config service with explicit config paths
+func NewServiceWithPaths(db *gorm.DB, paths map[string]string, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		paths:  paths,
+		logger: logger.With(zap.String("component", "relayer_config")),
+	}
+}
+
+// Path returns the config file path for a relayer
+func (s *Service) Path(relayer string) (string, error) {
+	path, ok := s.paths[relayer]
+	if !ok {
+		return "", ErrUnknownRelayer
+	}
+	return path, nil
+}
+
+// Current returns the config currently on disk. A missing file is empty.
+func (s *Service) Current(relayer string) (string, error) {
+	path, err := s.Path(relayer)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Preview validates a config and diffs it against the one on disk
+func (s *Service) Preview(relayer, content string) (*Preview, error) {
+	validation, err := Validate(relayer, content)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.Current(relayer)
+	if err != nil {
+		return nil, err
+	}
+
+	path, _ := s.Path(relayer)
+	diff := DiffLines(current, content)
+
+	return &Preview{
+		Relayer:    relayer,
+		Validation: validation,
+		Changed:    current != content,
+		Diff:       diff,
+		Unified:    Unified(diff, path, path, 3),
+	}, nil
+}
+
+// Apply validates and writes a config, recording it as a new version.
+// Returns a *ValidationError if the config has errors.
+func (s *Service) Apply(ctx context.Context, relayer, content, author, message string) (*ConfigVersion, error) {
+	return s.apply(ctx, relayer, content, author, message, nil)
+}
+
+// Rollback re-applies a previous version as a new version
+func (s *Service) Rollback(ctx context.Context, relayer string, version int, author string) (*ConfigVersion, error) {
+	target, err := s.GetVersion(ctx, relayer, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apply(ctx, relayer, target.Content, author, fmt.Sprintf("Rollback to version %d", version), &version)
+}
+
+func (s *Service) apply(ctx context.Context, relayer, content, author, message string, rolledBackFrom *int) (*ConfigVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.Current(relayer)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyLocked(ctx, relayer, current, content, author, message, rolledBackFrom)
+}
+
+// Modify applies edit to the current config and records the result as a
+// new version. The config can't change between reading and writing.
+func (s *Service) Modify(ctx context.Context, relayer, author, message string, edit func(current string) (string, error)) (*ConfigVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, err := s.Current(relayer)
+	if err != nil {
+		return nil, err
+	}
+	content, err := edit(current)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyLocked(ctx, relayer, current, content, author, message, nil)
+}
+
+// applyLocked validates and writes content. s.mu must be held.
+func (s *Service) applyLocked(ctx context.Context, relayer, current, content, author, message string, rolledBackFrom *int) (*ConfigVersion, error) {
+	validation, err := Validate(relayer, content)
+	if err != nil {
+		return nil, err
+	}
+	if !validation.Valid {
+		return nil, &ValidationError{Issues: validation.Issues}
+	}
+	if current == content {
+		return nil, ErrNoChanges
+	}
+
+	latest, err := s.latestVersion(ctx, relayer)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first edit keeps whatever was on disk so it can be rolled back to
+	if latest == 0 && current != "" {
+		baseline := &ConfigVersion{
+			Relayer:  relayer,
+			Version:  1,
+			Content:  current,
+			Checksum: checksum(current),
+			Author:   "system",
+			Message:  "Imported from disk",
+		}
+		if err := s.db.WithContext(ctx).Create(baseline).Error; err != nil {
+			return nil, fmt.Errorf("failed to record baseline config: %w", err)
+		}
+		latest = 1
+	}
+
+	path, _ := s.Path(relayer)
+	if err := writeFileAtomic(path, []byte(content)); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	version := &ConfigVersion{
+		Relayer:        relayer,
+		Version:        latest + 1,
+		Content:        content,
+		Checksum:       checksum(content),
+		Author:         author,
+		Message:        message,
+		RolledBackFrom: rolledBackFrom,
+	}
+	if err := s.db.WithContext(ctx).Create(version).Error; err != nil {
+		// The file is already written; report it so the history can be repaired
+		s.logger.Error("Config written but version not recorded",
+			zap.String("relayer", relayer),
+			zap.Int("version", version.Version),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to record config version: %w", err)
+	}
+
+	s.logger.Info("Relayer config updated",
+		zap.String("relayer", relayer),
+		zap.Int("version", version.Version),
+		zap.String("author", author),
+	)
+
+	return version, nil
+}
+
+// History lists versions of a relayer config, newest first, without content
+func (s *Service) History(ctx context.Context, relayer string, limit int) ([]ConfigVersion, error) {
+	if _, err := s.Path(relayer); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	var versions []ConfigVersion
+	err := s.db.WithContext(ctx).
+		Select("id", "relayer", "version", "checksum", "author", "message", "rolled_back_from", "created_at").
+		Where("relayer = ?", relayer).
+		Order("version DESC").
+		Limit(limit).
+		Find(&versions).Error
+	return versions, err
+}
+
+// GetVersion returns a single version including its content
+func (s *Service) GetVersion(ctx context.Context, relayer string, version int) (*ConfigVersion, error) {
+	if _, err := s.Path(relayer); err != nil {
+		return nil, err
+	}
+
+	var v ConfigVersion
+	err := s.db.WithContext(ctx).
+		Where("relayer = ? AND version = ?", relayer, version).
+		First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (s *Service) latestVersion(ctx context.Context, relayer string) (int, error) {
+	var latest int
+	err := s.db.WithContext(ctx).
+		Model(&ConfigVersion{}).
+		Where("relayer = ?", relayer).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	return latest, err
+}
+
+// writeFileAtomic replaces a file via rename so the relayer never reads a
+// partially written config. The previous file is kept as <path>.backup.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if previous, err := os.ReadFile(path); err == nil {
+			if err := os.WriteFile(path+".backup", previous, mode); err != nil {
+				return err
+			}
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
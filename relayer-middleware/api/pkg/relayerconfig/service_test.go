@@ -0,0 +1,163 @@
+package relayerconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const validHermesConfig = `[global]
+log_level = 'info'
+
+[[chains]]
+id = 'osmosis-1'
+type = 'CosmosSdk'
+rpc_addr = 'https://rpc.osmosis.zone'
+grpc_addr = 'http://grpc.osmosis.zone:9090'
+event_source = { mode = 'push', url = 'wss://rpc.osmosis.zone/websocket', batch_delay = '500ms' }
+account_prefix = 'osmo'
+key_name = 'relayer'
+default_gas = 100000
+max_gas = 400000
+gas_price = { price = 0.0025, denom = 'uosmo' }
+clock_drift = '5s'
+trusting_period = '14days'
+`
+
+func setupTestService(t *testing.T) (*Service, string) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&ConfigVersion{}))
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(validHermesConfig), 0600))
+
+	return NewServiceWithPaths(db, map[string]string{RelayerHermes: path}, zap.NewNop()), path
+}
+
+func TestValidateHermes(t *testing.T) {
+	result := ValidateHermes(validHermesConfig)
+	assert.True(t, result.Valid, "%+v", result.Issues)
+
+	invalid := strings.NewReplacer(
+		"log_level = 'info'", "log_level = 'loud'",
+		"max_gas = 400000", "max_gas = 1000",
+		"wss://", "https://",
+	).Replace(validHermesConfig)
+	result = ValidateHermes(invalid)
+	assert.False(t, result.Valid)
+
+	paths := make(map[string]bool)
+	for _, issue := range result.Issues {
+		paths[issue.Path] = true
+	}
+	assert.True(t, paths["global.log_level"])
+	assert.True(t, paths["chains[osmosis-1].max_gas"])
+	assert.True(t, paths["chains[osmosis-1].event_source.url"])
+
+	result = ValidateHermes("[[chains]\nid = 'broken'")
+	require.False(t, result.Valid)
+	assert.Equal(t, 1, result.Issues[0].Line)
+}
+
+func TestValidateRly(t *testing.T) {
+	config := `global:
+  timeout: 10s
+chains:
+  osmosis:
+    type: cosmos
+    value:
+      key: default
+      chain-id: osmosis-1
+      rpc-addr: https://rpc.osmosis.zone:443
+      account-prefix: osmo
+      gas-adjustment: 1.2
+      gas-prices: 0.0025uosmo
+paths:
+  osmo-hub:
+    src:
+      chain-id: osmosis-1
+    dst:
+      chain-id: cosmoshub-4
+`
+	result := ValidateRly(config)
+	require.False(t, result.Valid)
+	require.Len(t, result.Issues, 1)
+	assert.Equal(t, "paths.osmo-hub.dst.chain-id", result.Issues[0].Path)
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, value := range []string{"500ms", "10s", "14days", "1h 30m", "2weeks"} {
+		_, err := ParseDuration(value)
+		assert.NoError(t, err, value)
+	}
+	_, err := ParseDuration("soon")
+	assert.Error(t, err)
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("a\nb\nc\n", "a\nc\nd\n")
+	var ops []string
+	for _, line := range diff {
+		ops = append(ops, line.Op+":"+line.Text)
+	}
+	assert.Equal(t, []string{"context:a", "remove:b", "context:c", "add:d"}, ops)
+
+	unified := Unified(diff, "old", "new", 1)
+	assert.Contains(t, unified, "@@ -1,3 +1,3 @@")
+	assert.Empty(t, Unified(DiffLines("a\n", "a\n"), "old", "new", 3))
+}
+
+func TestApplyAndRollback(t *testing.T) {
+	svc, path := setupTestService(t)
+	ctx := context.Background()
+
+	updated := strings.Replace(validHermesConfig, "'info'", "'debug'", 1)
+	preview, err := svc.Preview(RelayerHermes, updated)
+	require.NoError(t, err)
+	assert.True(t, preview.Changed)
+	assert.Contains(t, preview.Unified, "+log_level = 'debug'")
+
+	// The on-disk config is imported as version 1 on first edit
+	version, err := svc.Apply(ctx, RelayerHermes, updated, "alice", "verbose logs")
+	require.NoError(t, err)
+	assert.Equal(t, 2, version.Version)
+
+	onDisk, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, updated, string(onDisk))
+
+	_, err = svc.Apply(ctx, RelayerHermes, updated, "alice", "")
+	assert.ErrorIs(t, err, ErrNoChanges)
+
+	_, err = svc.Apply(ctx, RelayerHermes, "[global]\nlog_level = 'loud'\n", "alice", "")
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	rollback, err := svc.Rollback(ctx, RelayerHermes, 1, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, 3, rollback.Version)
+	require.NotNil(t, rollback.RolledBackFrom)
+	assert.Equal(t, 1, *rollback.RolledBackFrom)
+
+	onDisk, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, validHermesConfig, string(onDisk))
+
+	history, err := svc.History(ctx, RelayerHermes, 10)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "bob", history[0].Author)
+	assert.Empty(t, history[0].Content)
+
+	_, err = svc.Rollback(ctx, RelayerHermes, 42, "bob")
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+}
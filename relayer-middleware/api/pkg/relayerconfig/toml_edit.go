@@ -0,0 +1,382 @@
+package relayerconfig
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Hermes chain editor works on the config text line by line instead of
+// re-encoding it, so comments, formatting and keys we don't model survive.
+
+type lineKind int
+
+const (
+	lineBlank lineKind = iota
+	lineComment
+	lineHeader
+	lineKeyValue
+	lineContinuation
+)
+
+type docLine struct {
+	kind  lineKind
+	name  string // table name for headers, key for key/values
+	array bool   // [[header]]
+	end   int    // last line of a key/value
+	// Byte offsets of the value on the first line and of the trailing
+	// comment on the last line (-1 if none)
+	valueStart   int
+	commentStart int
+}
+
+type tomlDoc struct {
+	lines []string
+	info  []docLine
+}
+
+type scanState struct {
+	depth     int
+	multiline string
+}
+
+func parseDoc(content string) *tomlDoc {
+	doc := &tomlDoc{lines: strings.Split(strings.TrimSuffix(content, "\n"), "\n")}
+	if content == "" {
+		doc.lines = nil
+	}
+	doc.reindex()
+	return doc
+}
+
+func (d *tomlDoc) String() string {
+	if len(d.lines) == 0 {
+		return ""
+	}
+	return strings.Join(d.lines, "\n") + "\n"
+}
+
+// reindex classifies every line. It must be called after each edit.
+func (d *tomlDoc) reindex() {
+	d.info = make([]docLine, len(d.lines))
+	owner := -1
+	var st scanState
+
+	for i, line := range d.lines {
+		if owner >= 0 {
+			d.info[i] = docLine{kind: lineContinuation, commentStart: -1}
+			cs := scanValue(line, 0, &st)
+			if st.depth <= 0 && st.multiline == "" {
+				d.info[owner].end = i
+				d.info[owner].commentStart = cs
+				owner = -1
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			d.info[i] = docLine{kind: lineBlank, commentStart: -1}
+		case strings.HasPrefix(trimmed, "#"):
+			d.info[i] = docLine{kind: lineComment, commentStart: -1}
+		case strings.HasPrefix(trimmed, "["):
+			array := strings.HasPrefix(trimmed, "[[")
+			name := strings.TrimLeft(trimmed, "[")
+			if idx := strings.Index(name, "]"); idx >= 0 {
+				name = name[:idx]
+			}
+			d.info[i] = docLine{kind: lineHeader, name: normalizeKey(name), array: array, commentStart: -1}
+		default:
+			eq := keyEnd(line)
+			if eq < 0 {
+				// Not valid TOML; keep it as an opaque line
+				d.info[i] = docLine{kind: lineComment, commentStart: -1}
+				continue
+			}
+			valueStart := eq + 1
+			for valueStart < len(line) && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+				valueStart++
+			}
+			st = scanState{}
+			cs := scanValue(line, valueStart, &st)
+			d.info[i] = docLine{
+				kind:         lineKeyValue,
+				name:         normalizeKey(line[:eq]),
+				end:          i,
+				valueStart:   valueStart,
+				commentStart: cs,
+			}
+			if st.depth > 0 || st.multiline != "" {
+				owner = i
+			}
+		}
+	}
+}
+
+// keyEnd returns the index of the '=' separating key and value, skipping quoted keys
+func keyEnd(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"', '\'':
+			closing := strings.IndexByte(line[i+1:], line[i])
+			if closing < 0 {
+				return -1
+			}
+			i += closing + 1
+		case '=':
+			return i
+		case '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// scanValue tracks strings and bracket depth through a line and returns
+// the offset of a trailing comment, or -1
+func scanValue(line string, pos int, st *scanState) int {
+	for pos < len(line) {
+		if st.multiline != "" {
+			closing := strings.Index(line[pos:], st.multiline)
+			if closing < 0 {
+				return -1
+			}
+			pos += closing + len(st.multiline)
+			st.multiline = ""
+			continue
+		}
+
+		switch ch := line[pos]; ch {
+		case '#':
+			return pos
+		case '"', '\'':
+			delim := strings.Repeat(string(ch), 3)
+			if strings.HasPrefix(line[pos:], delim) {
+				st.multiline = delim
+				pos += 3
+				continue
+			}
+			pos++
+			for pos < len(line) && line[pos] != ch {
+				if ch == '"' && line[pos] == '\\' {
+					pos++
+				}
+				pos++
+			}
+		case '[', '{':
+			st.depth++
+		case ']', '}':
+			st.depth--
+		}
+		pos++
+	}
+	return -1
+}
+
+func normalizeKey(key string) string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// contentEnd returns the index after the last key/value or header in [start, end)
+func (d *tomlDoc) contentEnd(start, end int) int {
+	last := start
+	for i := start; i < end; i++ {
+		switch d.info[i].kind {
+		case lineKeyValue:
+			last = d.info[i].end + 1
+		case lineHeader:
+			last = i + 1
+		}
+	}
+	return last
+}
+
+// setKey sets key to an already rendered value within the section body
+// [start, end), replacing an existing value in place or appending a new line
+func (d *tomlDoc) setKey(start, end int, key, value string) {
+	lastKV := -1
+	for i := start; i < end; i++ {
+		info := d.info[i]
+		if info.kind != lineKeyValue {
+			continue
+		}
+		if info.name == key {
+			first := d.lines[i]
+			suffix := ""
+			if info.commentStart >= 0 {
+				last := d.lines[info.end]
+				ws := info.commentStart
+				for ws > 0 && (last[ws-1] == ' ' || last[ws-1] == '\t') {
+					ws--
+				}
+				suffix = last[ws:]
+			}
+			replacement := strings.Split(first[:info.valueStart]+value+suffix, "\n")
+			d.splice(i, info.end+1, replacement)
+			return
+		}
+		lastKV = info.end
+	}
+
+	indent := ""
+	insertAt := start
+	if lastKV >= 0 {
+		insertAt = lastKV + 1
+		first := d.lines[lastKV]
+		for k := lastKV; k >= start; k-- {
+			if d.info[k].kind == lineKeyValue {
+				first = d.lines[k]
+				break
+			}
+		}
+		indent = first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	}
+	d.splice(insertAt, insertAt, strings.Split(indent+key+" = "+value, "\n"))
+}
+
+// splice replaces lines [from, to) and reindexes the document
+func (d *tomlDoc) splice(from, to int, replacement []string) {
+	lines := make([]string, 0, len(d.lines)-(to-from)+len(replacement))
+	lines = append(lines, d.lines[:from]...)
+	lines = append(lines, replacement...)
+	lines = append(lines, d.lines[to:]...)
+	d.lines = lines
+	d.reindex()
+}
+
+// renderValue renders a Go value as TOML. Arrays of arrays are written one
+// element per line unless inline is set.
+func renderValue(v interface{}, inline bool) string {
+	switch value := v.(type) {
+	case string:
+		return renderString(value)
+	case bool:
+		return strconv.FormatBool(value)
+	case float32:
+		return renderFloat(float64(value))
+	case float64:
+		return renderFloat(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return value.String()
+	case map[string]interface{}:
+		return renderInlineTable(value, nil)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+		nested := false
+		for i := range items {
+			elem := rv.Index(i)
+			if elem.Kind() == reflect.Interface {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Slice {
+				nested = true
+			}
+			items[i] = renderValue(elem.Interface(), true)
+		}
+		if nested && !inline && len(items) > 0 {
+			return "[\n  " + strings.Join(items, ",\n  ") + ",\n]"
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+
+	return renderString(fmt.Sprint(v))
+}
+
+// renderInlineTable renders a table as { k = v, ... }, keys in order first
+func renderInlineTable(table map[string]interface{}, order []string) string {
+	keys := make([]string, 0, len(table))
+	seen := make(map[string]bool)
+	for _, key := range order {
+		if _, ok := table[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var rest []string
+	for key := range table {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + " = " + renderValue(table[key], true)
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+// renderString prefers literal strings, matching the Hermes config style.
+// Literal strings can't hold a quote or control characters, so those are
+// written as basic strings with the characters escaped.
+func renderString(s string) string {
+	if !strings.ContainsRune(s, '\'') && strings.IndexFunc(s, isControl) < 0 {
+		return "'" + s + "'"
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if isControl(r) {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+func renderFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package relayerconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported relayers
+const (
+	RelayerHermes = "hermes"
+	RelayerRly    = "rly"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+var (
+	ErrUnknownRelayer  = errors.New("unknown relayer")
+	ErrVersionNotFound = errors.New("config version not found")
+	ErrNoChanges       = errors.New("config is unchanged")
+)
+
+// ValidationIssue describes a problem found in a config file
+type ValidationIssue struct {
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// ValidationResult is the outcome of parsing and validating a config
+type ValidationResult struct {
+	Valid  bool              `json:"valid"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+func (r *ValidationResult) addError(path, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...), Severity: SeverityError})
+	r.Valid = false
+}
+
+func (r *ValidationResult) addWarning(path, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...), Severity: SeverityWarning})
+}
+
+// ValidationError is returned when a config fails validation
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	var messages []string
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, fmt.Sprintf("%s: %s", issue.Path, issue.Message))
+		}
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+// ConfigVersion is a stored revision of a relayer config file
+type ConfigVersion struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Relayer        string    `json:"relayer" gorm:"uniqueIndex:idx_config_versions_relayer_version"`
+	Version        int       `json:"version" gorm:"uniqueIndex:idx_config_versions_relayer_version"`
+	Content        string    `json:"content,omitempty" gorm:"type:text"`
+	Checksum       string    `json:"checksum"`
+	Author         string    `json:"author"`
+	Message        string    `json:"message"`
+	RolledBackFrom *int      `json:"rolledBackFrom,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Preview shows the effect of applying a config without writing it
+type Preview struct {
+	Relayer    string            `json:"relayer"`
+	Validation *ValidationResult `json:"validation"`
+	Changed    bool              `json:"changed"`
+	Diff       []DiffLine        `json:"diff"`
+	Unified    string            `json:"unified"`
+}
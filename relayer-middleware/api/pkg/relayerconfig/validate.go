@@ -0,0 +1,418 @@
+package relayerconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	hermesLogLevels   = map[string]bool{"trace": true, "debug": true, "info": true, "warn": true, "error": true}
+	hermesChainTypes  = map[string]bool{"": true, "CosmosSdk": true, "Namada": true}
+	hermesCompatModes = map[string]bool{"0.34": true, "0.37": true, "0.38": true}
+	hermesEventModes  = map[string]bool{"push": true, "pull": true}
+
+	rlyChainTypes     = map[string]bool{"cosmos": true, "penumbra": true}
+	rlyFilterRules    = map[string]bool{"": true, "allowlist": true, "denylist": true}
+	rlyGasPricesRegex = regexp.MustCompile(`^\d+(\.\d+)?[a-zA-Z][a-zA-Z0-9/:._-]*$`)
+
+	yamlLineRegex = regexp.MustCompile(`line (\d+)`)
+)
+
+// hermesConfig holds the parts of a Hermes config.toml that are validated.
+// Unknown keys are ignored so newer Hermes options don't fail validation.
+type hermesConfig struct {
+	Global struct {
+		LogLevel string `toml:"log_level"`
+	} `toml:"global"`
+	REST      hermesServer  `toml:"rest"`
+	Telemetry hermesServer  `toml:"telemetry"`
+	Chains    []hermesChain `toml:"chains"`
+}
+
+type hermesServer struct {
+	Enabled bool   `toml:"enabled"`
+	Host    string `toml:"host"`
+	Port    int    `toml:"port"`
+}
+
+type hermesChain struct {
+	ID            string `toml:"id"`
+	Type          string `toml:"type"`
+	RPCAddr       string `toml:"rpc_addr"`
+	GRPCAddr      string `toml:"grpc_addr"`
+	WebsocketAddr string `toml:"websocket_addr"`
+	EventSource   *struct {
+		Mode       string `toml:"mode"`
+		URL        string `toml:"url"`
+		BatchDelay string `toml:"batch_delay"`
+		Interval   string `toml:"interval"`
+	} `toml:"event_source"`
+	RPCTimeout    string   `toml:"rpc_timeout"`
+	AccountPrefix string   `toml:"account_prefix"`
+	KeyName       string   `toml:"key_name"`
+	StorePrefix   string   `toml:"store_prefix"`
+	DefaultGas    *uint64  `toml:"default_gas"`
+	MaxGas        *uint64  `toml:"max_gas"`
+	GasMultiplier *float64 `toml:"gas_multiplier"`
+	GasPrice      *struct {
+		Price float64 `toml:"price"`
+		Denom string  `toml:"denom"`
+	} `toml:"gas_price"`
+	MaxMsgNum      *int   `toml:"max_msg_num"`
+	MaxTxSize      *int   `toml:"max_tx_size"`
+	ClockDrift     string `toml:"clock_drift"`
+	MaxBlockTime   string `toml:"max_block_time"`
+	TrustingPeriod string `toml:"trusting_period"`
+	CompatMode     string `toml:"compat_mode"`
+	PacketFilter   *struct {
+		Policy string     `toml:"policy"`
+		List   [][]string `toml:"list"`
+	} `toml:"packet_filter"`
+}
+
+// rlyConfig holds the parts of a rly config.yaml that are validated
+type rlyConfig struct {
+	Global struct {
+		APIListenAddr  string `yaml:"api-listen-addr"`
+		Timeout        string `yaml:"timeout"`
+		LightCacheSize int    `yaml:"light-cache-size"`
+	} `yaml:"global"`
+	Chains map[string]struct {
+		Type  string `yaml:"type"`
+		Value struct {
+			Key           string  `yaml:"key"`
+			ChainID       string  `yaml:"chain-id"`
+			RPCAddr       string  `yaml:"rpc-addr"`
+			AccountPrefix string  `yaml:"account-prefix"`
+			GasAdjustment float64 `yaml:"gas-adjustment"`
+			GasPrices     string  `yaml:"gas-prices"`
+			Timeout       string  `yaml:"timeout"`
+		} `yaml:"value"`
+	} `yaml:"chains"`
+	Paths map[string]struct {
+		Src              rlyPathEnd `yaml:"src"`
+		Dst              rlyPathEnd `yaml:"dst"`
+		SrcChannelFilter struct {
+			Rule        string   `yaml:"rule"`
+			ChannelList []string `yaml:"channel-list"`
+		} `yaml:"src-channel-filter"`
+	} `yaml:"paths"`
+}
+
+type rlyPathEnd struct {
+	ChainID      string `yaml:"chain-id"`
+	ClientID     string `yaml:"client-id"`
+	ConnectionID string `yaml:"connection-id"`
+}
+
+// Validate parses and validates a config for the given relayer
+func Validate(relayer, content string) (*ValidationResult, error) {
+	switch relayer {
+	case RelayerHermes:
+		return ValidateHermes(content), nil
+	case RelayerRly:
+		return ValidateRly(content), nil
+	default:
+		return nil, ErrUnknownRelayer
+	}
+}
+
+// ValidateHermes parses a Hermes config.toml and checks it against the
+// subset of the Hermes schema the relayer needs to start
+func ValidateHermes(content string) *ValidationResult {
+	result := &ValidationResult{Valid: true, Issues: []ValidationIssue{}}
+
+	var cfg hermesConfig
+	if err := toml.Unmarshal([]byte(content), &cfg); err != nil {
+		issue := ValidationIssue{Path: "$", Message: err.Error(), Severity: SeverityError}
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			issue.Line, _ = decodeErr.Position()
+		}
+		result.Issues = append(result.Issues, issue)
+		result.Valid = false
+		return result
+	}
+
+	if cfg.Global.LogLevel != "" && !hermesLogLevels[cfg.Global.LogLevel] {
+		result.addError("global.log_level", "must be one of trace, debug, info, warn, error")
+	}
+	validateServer(result, "rest", cfg.REST)
+	validateServer(result, "telemetry", cfg.Telemetry)
+
+	if len(cfg.Chains) == 0 {
+		result.addError("chains", "at least one [[chains]] entry is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, chain := range cfg.Chains {
+		path := fmt.Sprintf("chains[%d]", i)
+		if chain.ID == "" {
+			result.addError(path+".id", "is required")
+		} else {
+			path = fmt.Sprintf("chains[%s]", chain.ID)
+			if seen[chain.ID] {
+				result.addError(path+".id", "duplicate chain id")
+			}
+			seen[chain.ID] = true
+		}
+
+		if !hermesChainTypes[chain.Type] {
+			result.addError(path+".type", "unsupported chain type %q", chain.Type)
+		}
+		requireURL(result, path+".rpc_addr", chain.RPCAddr, "http", "https")
+		requireURL(result, path+".grpc_addr", chain.GRPCAddr, "http", "https", "tcp")
+
+		switch {
+		case chain.EventSource != nil:
+			if !hermesEventModes[chain.EventSource.Mode] {
+				result.addError(path+".event_source.mode", "must be push or pull")
+			}
+			if chain.EventSource.Mode == "push" {
+				requireURL(result, path+".event_source.url", chain.EventSource.URL, "ws", "wss")
+			}
+			checkDuration(result, path+".event_source.batch_delay", chain.EventSource.BatchDelay)
+			checkDuration(result, path+".event_source.interval", chain.EventSource.Interval)
+		case chain.WebsocketAddr != "":
+			requireURL(result, path+".websocket_addr", chain.WebsocketAddr, "ws", "wss")
+			result.addWarning(path+".websocket_addr", "deprecated, use event_source instead")
+		default:
+			result.addError(path+".event_source", "is required")
+		}
+
+		if chain.AccountPrefix == "" {
+			result.addError(path+".account_prefix", "is required")
+		}
+		if chain.KeyName == "" {
+			result.addError(path+".key_name", "is required")
+		}
+
+		if chain.GasPrice == nil {
+			result.addError(path+".gas_price", "is required")
+		} else {
+			if chain.GasPrice.Price < 0 {
+				result.addError(path+".gas_price.price", "must not be negative")
+			}
+			if chain.GasPrice.Denom == "" {
+				result.addError(path+".gas_price.denom", "is required")
+			}
+		}
+		if chain.DefaultGas != nil && chain.MaxGas != nil && *chain.MaxGas < *chain.DefaultGas {
+			result.addError(path+".max_gas", "must be greater than or equal to default_gas")
+		}
+		if chain.GasMultiplier != nil && *chain.GasMultiplier < 1.0 {
+			result.addError(path+".gas_multiplier", "must be at least 1.0")
+		}
+		if chain.MaxMsgNum != nil && (*chain.MaxMsgNum < 1 || *chain.MaxMsgNum > 100) {
+			result.addError(path+".max_msg_num", "must be between 1 and 100")
+		}
+		if chain.MaxTxSize != nil && *chain.MaxTxSize <= 0 {
+			result.addError(path+".max_tx_size", "must be positive")
+		}
+
+		checkDuration(result, path+".rpc_timeout", chain.RPCTimeout)
+		checkDuration(result, path+".clock_drift", chain.ClockDrift)
+		checkDuration(result, path+".max_block_time", chain.MaxBlockTime)
+		checkDuration(result, path+".trusting_period", chain.TrustingPeriod)
+
+		if chain.CompatMode != "" && !hermesCompatModes[chain.CompatMode] {
+			result.addError(path+".compat_mode", "must be one of 0.34, 0.37, 0.38")
+		}
+
+		if filter := chain.PacketFilter; filter != nil {
+			if filter.Policy != "allow" && filter.Policy != "deny" {
+				result.addError(path+".packet_filter.policy", "must be allow or deny")
+			}
+			for j, entry := range filter.List {
+				if len(entry) != 2 || entry[0] == "" || entry[1] == "" {
+					result.addError(fmt.Sprintf("%s.packet_filter.list[%d]", path, j), "must be a [port, channel] pair")
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// ValidateRly parses a rly config.yaml and checks chains and paths
+func ValidateRly(content string) *ValidationResult {
+	result := &ValidationResult{Valid: true, Issues: []ValidationIssue{}}
+
+	var cfg rlyConfig
+	if err := yaml.Unmarshal([]byte(content), &cfg); err != nil {
+		issue := ValidationIssue{Path: "$", Message: err.Error(), Severity: SeverityError}
+		if match := yamlLineRegex.FindStringSubmatch(err.Error()); match != nil {
+			issue.Line, _ = strconv.Atoi(match[1])
+		}
+		result.Issues = append(result.Issues, issue)
+		result.Valid = false
+		return result
+	}
+
+	checkDuration(result, "global.timeout", cfg.Global.Timeout)
+	if cfg.Global.LightCacheSize < 0 {
+		result.addError("global.light-cache-size", "must not be negative")
+	}
+
+	if len(cfg.Chains) == 0 {
+		result.addError("chains", "at least one chain is required")
+	}
+
+	chainIDs := make(map[string]string)
+	for name, chain := range cfg.Chains {
+		path := "chains." + name
+		if !rlyChainTypes[chain.Type] {
+			result.addError(path+".type", "unsupported chain type %q", chain.Type)
+		}
+
+		value := chain.Value
+		if value.ChainID == "" {
+			result.addError(path+".value.chain-id", "is required")
+		} else if other, ok := chainIDs[value.ChainID]; ok {
+			result.addError(path+".value.chain-id", "duplicate chain id, also used by %s", other)
+		} else {
+			chainIDs[value.ChainID] = name
+		}
+		if value.Key == "" {
+			result.addError(path+".value.key", "is required")
+		}
+		requireURL(result, path+".value.rpc-addr", value.RPCAddr, "http", "https", "tcp")
+		if value.AccountPrefix == "" {
+			result.addError(path+".value.account-prefix", "is required")
+		}
+		if value.GasAdjustment != 0 && value.GasAdjustment < 1.0 {
+			result.addError(path+".value.gas-adjustment", "must be at least 1.0")
+		}
+		if value.GasPrices == "" {
+			result.addError(path+".value.gas-prices", "is required")
+		} else {
+			for _, price := range strings.Split(value.GasPrices, ",") {
+				if !rlyGasPricesRegex.MatchString(strings.TrimSpace(price)) {
+					result.addError(path+".value.gas-prices", "invalid gas price %q, expected e.g. 0.01uatom", price)
+				}
+			}
+		}
+		checkDuration(result, path+".value.timeout", value.Timeout)
+	}
+
+	for name, p := range cfg.Paths {
+		path := "paths." + name
+		for side, end := range map[string]rlyPathEnd{"src": p.Src, "dst": p.Dst} {
+			if end.ChainID == "" {
+				result.addError(path+"."+side+".chain-id", "is required")
+			} else if _, ok := chainIDs[end.ChainID]; !ok {
+				result.addError(path+"."+side+".chain-id", "references unknown chain %q", end.ChainID)
+			}
+		}
+		if p.Src.ChainID != "" && p.Src.ChainID == p.Dst.ChainID {
+			result.addError(path, "src and dst must be different chains")
+		}
+		if !rlyFilterRules[p.SrcChannelFilter.Rule] {
+			result.addError(path+".src-channel-filter.rule", "must be allowlist or denylist")
+		}
+		if p.SrcChannelFilter.Rule != "" && len(p.SrcChannelFilter.ChannelList) == 0 {
+			result.addWarning(path+".src-channel-filter.channel-list", "empty list with rule %q", p.SrcChannelFilter.Rule)
+		}
+	}
+
+	// Issues come from map iteration, keep output stable
+	sortIssues(result.Issues)
+
+	return result
+}
+
+func validateServer(result *ValidationResult, path string, server hermesServer) {
+	if server.Enabled && (server.Port < 1 || server.Port > 65535) {
+		result.addError(path+".port", "must be between 1 and 65535")
+	}
+}
+
+func requireURL(result *ValidationResult, path, value string, schemes ...string) {
+	if value == "" {
+		result.addError(path, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		result.addError(path, "invalid URL %q", value)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return
+		}
+	}
+	result.addError(path, "scheme must be one of %s", strings.Join(schemes, ", "))
+}
+
+func checkDuration(result *ValidationResult, path, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := ParseDuration(value); err != nil {
+		result.addError(path, "invalid duration %q", value)
+	}
+}
+
+// humanUnits maps the duration units accepted by Hermes (humantime) to Go durations
+var humanUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "us": time.Microsecond, "ms": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+var durationPartRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+// ParseDuration parses Go durations ("1m30s") and humantime durations
+// as used by Hermes ("14days", "1h 30m")
+func ParseDuration(value string) (time.Duration, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, nil
+	}
+
+	matches := durationPartRegex.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	consumed := 0
+	for _, m := range matches {
+		if strings.TrimSpace(value[consumed:m[0]]) != "" {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		unit, ok := humanUnits[value[m[4]:m[5]]]
+		if !ok {
+			return 0, fmt.Errorf("unknown unit in duration %q", value)
+		}
+		n, err := strconv.ParseFloat(value[m[2]:m[3]], 64)
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(n * float64(unit))
+		consumed = m[1]
+	}
+	if strings.TrimSpace(value[consumed:]) != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return total, nil
+}
+
+func sortIssues(issues []ValidationIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+}
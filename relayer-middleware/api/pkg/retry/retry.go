@@ -152,11 +152,12 @@ func (r *Retrier) calculateInterval(attempt int) time.Duration {
 
 func (r *Retrier) isIdempotent(operation string) bool {
 	idempotentOps := map[string]bool{
-		"get_packets":    true,
-		"check_status":   true,
-		"verify_payment": true,
-		"clear_packets":  false, // Not idempotent
-		"process_refund": false, // Not idempotent
+		"get_packets":      true,
+		"check_status":     true,
+		"verify_payment":   true,
+		"chainpulse_query": true,
+		"clear_packets":    false, // Not idempotent
+		"process_refund":   false, // Not idempotent
 	}
 
 	return idempotentOps[operation]
@@ -195,4 +196,4 @@ func isRetryable(err error) bool {
 
 func generateOperationID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
-}
\ No newline at end of file
+}
@@ -0,0 +1,208 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// childStopTimeout is how long a child gets to exit after SIGTERM
+const childStopTimeout = 10 * time.Second
+
+// ChildManager runs relayers as child processes of the API. It is meant for
+// development and single-container setups without a process supervisor.
+type ChildManager struct {
+	commands map[string][]string
+	logDir   string
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	children map[string]*child
+}
+
+type child struct {
+	cmd       *exec.Cmd
+	state     State
+	startedAt time.Time
+	starts    int
+	exitCode  *int
+	done      chan struct{}
+	logFile   io.Closer
+}
+
+// NewChildManager creates a child process manager. commands maps process
+// names to argv. If logDir is set, output goes to <logDir>/<name>.log.
+func NewChildManager(commands map[string][]string, logDir string, logger *zap.Logger) *ChildManager {
+	return &ChildManager{
+		commands: commands,
+		logDir:   logDir,
+		logger:   logger.With(zap.String("component", "child_processes")),
+		children: make(map[string]*child),
+	}
+}
+
+// Name implements ProcessManager
+func (m *ChildManager) Name() string {
+	return "child"
+}
+
+// Start implements ProcessManager
+func (m *ChildManager) Start(ctx context.Context, name string) error {
+	argv, ok := m.commands[name]
+	if !ok || len(argv) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	proc := m.children[name]
+	if proc != nil && proc.state == StateRunning {
+		return nil
+	}
+
+	// The child must outlive the request that started it
+	cmd := exec.Command(argv[0], argv[1:]...)
+	var logFile *os.File
+	if m.logDir != "" {
+		var err error
+		logFile, err = os.OpenFile(filepath.Join(m.logDir, name+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("open log for %s: %w", name, err)
+		}
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+	}
+
+	if err := cmd.Start(); err != nil {
+		if logFile != nil {
+			logFile.Close()
+		}
+		return fmt.Errorf("start %s: %w", name, err)
+	}
+
+	starts := 1
+	if proc != nil {
+		starts = proc.starts + 1
+	}
+	next := &child{
+		cmd:       cmd,
+		state:     StateRunning,
+		startedAt: time.Now().UTC(),
+		starts:    starts,
+		done:      make(chan struct{}),
+	}
+	if logFile != nil {
+		next.logFile = logFile
+	}
+	m.children[name] = next
+
+	go m.wait(name, next)
+
+	m.logger.Info("Started child process", zap.String("name", name), zap.Int("pid", cmd.Process.Pid))
+	return nil
+}
+
+// wait records the exit of a child process
+func (m *ChildManager) wait(name string, proc *child) {
+	err := proc.cmd.Wait()
+
+	m.mu.Lock()
+	code := proc.cmd.ProcessState.ExitCode()
+	proc.exitCode = &code
+	if proc.state == StateStopping {
+		proc.state = StateStopped
+	} else {
+		proc.state = StateExited
+		m.logger.Warn("Child process exited", zap.String("name", name), zap.Int("exit_code", code), zap.Error(err))
+	}
+	if proc.logFile != nil {
+		proc.logFile.Close()
+	}
+	m.mu.Unlock()
+
+	close(proc.done)
+}
+
+// Stop implements ProcessManager. The child gets SIGTERM, then SIGKILL
+// if it hasn't exited after childStopTimeout.
+func (m *ChildManager) Stop(ctx context.Context, name string) error {
+	if _, ok := m.commands[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+
+	m.mu.Lock()
+	proc := m.children[name]
+	if proc == nil || proc.state != StateRunning {
+		m.mu.Unlock()
+		return nil
+	}
+	proc.state = StateStopping
+	m.mu.Unlock()
+
+	if err := proc.cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("stop %s: %w", name, err)
+	}
+
+	timer := time.NewTimer(childStopTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-proc.done:
+		return nil
+	case <-timer.C:
+		m.logger.Warn("Child process did not exit, killing", zap.String("name", name))
+		proc.cmd.Process.Kill()
+		<-proc.done
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Restart implements ProcessManager
+func (m *ChildManager) Restart(ctx context.Context, name string) error {
+	if err := m.Stop(ctx, name); err != nil {
+		return err
+	}
+	return m.Start(ctx, name)
+}
+
+// Status implements ProcessManager
+func (m *ChildManager) Status(ctx context.Context, name string) (*Status, error) {
+	if _, ok := m.commands[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	proc := m.children[name]
+	if proc == nil {
+		return &Status{Name: name, State: StateStopped}, nil
+	}
+
+	startedAt := proc.startedAt
+	status := &Status{
+		Name:      name,
+		State:     proc.state,
+		StartedAt: &startedAt,
+		Restarts:  proc.starts - 1,
+		ExitCode:  proc.exitCode,
+	}
+	if proc.state == StateRunning {
+		status.PID = proc.cmd.Process.Pid
+	}
+	status.setUptime(time.Now())
+
+	return status, nil
+}
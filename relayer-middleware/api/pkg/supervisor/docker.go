@@ -0,0 +1,199 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultDockerHost is the local Docker Engine socket
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// dockerStopTimeout is how long Docker waits before killing a container
+const dockerStopTimeout = 30
+
+// DockerManager controls relayers running as containers through the
+// Docker Engine API. Process names are container names or IDs.
+type DockerManager struct {
+	baseURL string
+	client  *http.Client
+	logger  *zap.Logger
+}
+
+// NewDockerManager creates a manager for a Docker host, either
+// unix:///path/to/docker.sock or tcp://host:port
+func NewDockerManager(host string, logger *zap.Logger) (*DockerManager, error) {
+	client := &http.Client{Timeout: time.Duration(dockerStopTimeout+30) * time.Second}
+	var baseURL string
+
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socket := strings.TrimPrefix(host, "unix://")
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		baseURL = "http://docker"
+	case strings.HasPrefix(host, "tcp://"):
+		baseURL = "http://" + strings.TrimPrefix(host, "tcp://")
+	case strings.HasPrefix(host, "http://"), strings.HasPrefix(host, "https://"):
+		baseURL = strings.TrimSuffix(host, "/")
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q", host)
+	}
+
+	return &DockerManager{
+		baseURL: baseURL,
+		client:  client,
+		logger:  logger.With(zap.String("component", "docker")),
+	}, nil
+}
+
+// Name implements ProcessManager
+func (m *DockerManager) Name() string {
+	return "docker"
+}
+
+// Start implements ProcessManager
+func (m *DockerManager) Start(ctx context.Context, name string) error {
+	return m.post(ctx, name, "start", nil)
+}
+
+// Stop implements ProcessManager
+func (m *DockerManager) Stop(ctx context.Context, name string) error {
+	return m.post(ctx, name, "stop", url.Values{"t": {fmt.Sprint(dockerStopTimeout)}})
+}
+
+// Restart implements ProcessManager
+func (m *DockerManager) Restart(ctx context.Context, name string) error {
+	return m.post(ctx, name, "restart", url.Values{"t": {fmt.Sprint(dockerStopTimeout)}})
+}
+
+type dockerContainer struct {
+	RestartCount int `json:"RestartCount"`
+	State        struct {
+		Status     string `json:"Status"`
+		Running    bool   `json:"Running"`
+		Restarting bool   `json:"Restarting"`
+		Pid        int    `json:"Pid"`
+		ExitCode   int    `json:"ExitCode"`
+		Error      string `json:"Error"`
+		StartedAt  string `json:"StartedAt"`
+		FinishedAt string `json:"FinishedAt"`
+	} `json:"State"`
+}
+
+// Status implements ProcessManager
+func (m *DockerManager) Status(ctx context.Context, name string) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.containerURL(name, "json", nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker inspect %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if err := dockerError(name, "inspect", resp); err != nil {
+		return nil, err
+	}
+
+	var container dockerContainer
+	if err := json.NewDecoder(resp.Body).Decode(&container); err != nil {
+		return nil, fmt.Errorf("docker inspect %s: %w", name, err)
+	}
+
+	status := &Status{
+		Name:        name,
+		State:       dockerState(container.State.Status),
+		PID:         container.State.Pid,
+		Restarts:    container.RestartCount,
+		Description: container.State.Error,
+	}
+	if startedAt, err := time.Parse(time.RFC3339Nano, container.State.StartedAt); err == nil && !startedAt.IsZero() && startedAt.Year() > 1 {
+		status.StartedAt = &startedAt
+	}
+	if !container.State.Running {
+		if finishedAt, err := time.Parse(time.RFC3339Nano, container.State.FinishedAt); err == nil && finishedAt.Year() > 1 {
+			status.ExitCode = intPtr(container.State.ExitCode)
+		}
+	}
+	status.setUptime(time.Now())
+
+	return status, nil
+}
+
+func (m *DockerManager) post(ctx context.Context, name, action string, query url.Values) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.containerURL(name, action, query), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker %s %s: %w", action, name, err)
+	}
+	defer resp.Body.Close()
+
+	// 304 means the container is already started or stopped
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	return dockerError(name, action, resp)
+}
+
+func (m *DockerManager) containerURL(name, action string, query url.Values) string {
+	u := fmt.Sprintf("%s/containers/%s/%s", m.baseURL, url.PathEscape(name), action)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func dockerError(name, action string, resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, &body) != nil || body.Message == "" {
+		body.Message = strings.TrimSpace(string(data))
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+	return fmt.Errorf("docker %s %s: status %d: %s", action, name, resp.StatusCode, body.Message)
+}
+
+func dockerState(status string) State {
+	switch status {
+	case "running":
+		return StateRunning
+	case "created", "paused":
+		return StateStopped
+	case "restarting":
+		return StateBackoff
+	case "removing":
+		return StateStopping
+	case "exited":
+		return StateExited
+	case "dead":
+		return StateFailed
+	default:
+		return StateUnknown
+	}
+}
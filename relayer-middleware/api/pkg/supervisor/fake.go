@@ -0,0 +1,119 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Fake is an in-memory ProcessManager for tests
+type Fake struct {
+	mu        sync.Mutex
+	processes map[string]*Status
+	errors    map[string]error
+	calls     []string
+}
+
+// NewFake creates a fake manager with the given stopped processes
+func NewFake(names ...string) *Fake {
+	f := &Fake{
+		processes: make(map[string]*Status),
+		errors:    make(map[string]error),
+	}
+	for _, name := range names {
+		f.processes[name] = &Status{Name: name, State: StateStopped}
+	}
+	return f
+}
+
+// FailOn makes the given operation ("start", "stop", "restart" or
+// "status") on a process return err
+func (f *Fake) FailOn(op, name string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[op+":"+name] = err
+}
+
+// SetStatus replaces the status of a process
+func (f *Fake) SetStatus(status Status) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.processes[status.Name] = &status
+}
+
+// Calls returns the operations performed, as "op:name"
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// Name implements ProcessManager
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Start implements ProcessManager
+func (f *Fake) Start(ctx context.Context, name string) error {
+	return f.transition("start", name, func(s *Status) {
+		if s.State != StateRunning {
+			now := time.Now().UTC()
+			s.State = StateRunning
+			s.StartedAt = &now
+			s.ExitCode = nil
+		}
+	})
+}
+
+// Stop implements ProcessManager
+func (f *Fake) Stop(ctx context.Context, name string) error {
+	return f.transition("stop", name, func(s *Status) {
+		if s.State == StateRunning {
+			s.State = StateStopped
+			s.ExitCode = intPtr(0)
+		}
+	})
+}
+
+// Restart implements ProcessManager
+func (f *Fake) Restart(ctx context.Context, name string) error {
+	return f.transition("restart", name, func(s *Status) {
+		now := time.Now().UTC()
+		if s.StartedAt != nil {
+			s.Restarts++
+		}
+		s.State = StateRunning
+		s.StartedAt = &now
+		s.ExitCode = nil
+	})
+}
+
+// Status implements ProcessManager
+func (f *Fake) Status(ctx context.Context, name string) (*Status, error) {
+	var status Status
+	err := f.transition("status", name, func(s *Status) {
+		status = *s
+	})
+	if err != nil {
+		return nil, err
+	}
+	status.setUptime(time.Now())
+	return &status, nil
+}
+
+func (f *Fake) transition(op, name string, apply func(*Status)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, op+":"+name)
+	if err := f.errors[op+":"+name]; err != nil {
+		return err
+	}
+	status, ok := f.processes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+	apply(status)
+	return nil
+}
@@ -0,0 +1,108 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrUnknownProcess = errors.New("unknown process")
+
+// State is a process state normalized across process managers
+type State string
+
+const (
+	StateRunning  State = "running"
+	StateStarting State = "starting"
+	StateStopping State = "stopping"
+	StateStopped  State = "stopped"
+	StateExited   State = "exited"
+	StateBackoff  State = "backoff"
+	StateFailed   State = "failed"
+	StateUnknown  State = "unknown"
+)
+
+// Status describes a managed process
+type Status struct {
+	Name          string     `json:"name"`
+	State         State      `json:"state"`
+	PID           int        `json:"pid,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UptimeSeconds int64      `json:"uptime_seconds"`
+	Restarts      int        `json:"restarts"`
+	ExitCode      *int       `json:"exit_code,omitempty"`
+	Description   string     `json:"description,omitempty"`
+}
+
+// Running reports whether the process is up
+func (s *Status) Running() bool {
+	return s.State == StateRunning
+}
+
+// setUptime fills UptimeSeconds from StartedAt for running processes
+func (s *Status) setUptime(now time.Time) {
+	s.UptimeSeconds = 0
+	if s.Running() && s.StartedAt != nil && now.After(*s.StartedAt) {
+		s.UptimeSeconds = int64(now.Sub(*s.StartedAt).Seconds())
+	}
+}
+
+// ProcessManager controls relayer processes. Start and Stop are idempotent:
+// starting a running process or stopping a stopped one is not an error.
+type ProcessManager interface {
+	// Name identifies the backend, e.g. "supervisord"
+	Name() string
+	Start(ctx context.Context, name string) error
+	Stop(ctx context.Context, name string) error
+	Restart(ctx context.Context, name string) error
+	Status(ctx context.Context, name string) (*Status, error)
+}
+
+// NewFromEnv creates the process manager selected by PROCESS_MANAGER:
+// supervisord (default), systemd, docker or child.
+func NewFromEnv(logger *zap.Logger) (ProcessManager, error) {
+	switch kind := envOrDefault("PROCESS_MANAGER", "supervisord"); kind {
+	case "supervisord":
+		return NewSupervisordManager(envOrDefault("SUPERVISOR_URL", DefaultSupervisorURL), logger), nil
+	case "systemd":
+		return NewSystemdManager(logger), nil
+	case "docker":
+		return NewDockerManager(envOrDefault("DOCKER_HOST", DefaultDockerHost), logger)
+	case "child":
+		return NewChildManager(commandsFromEnv(), os.Getenv("PROCESS_LOG_DIR"), logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported process manager %q", kind)
+	}
+}
+
+// commandsFromEnv reads PROCESS_COMMAND_<NAME> variables, e.g.
+// PROCESS_COMMAND_HERMES="hermes --config /etc/hermes/config.toml start"
+// configures a process named "hermes". Underscores in NAME become dashes.
+func commandsFromEnv() map[string][]string {
+	commands := make(map[string][]string)
+	for _, entry := range os.Environ() {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, "PROCESS_COMMAND_") || value == "" {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(key, "PROCESS_COMMAND_"), "_", "-"))
+		commands[name] = strings.Fields(value)
+	}
+	return commands
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,160 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func xmlrpcResponse(value string) string {
+	return `<?xml version="1.0"?><methodResponse><params><param><value>` + value + `</value></param></params></methodResponse>`
+}
+
+func xmlrpcFault(code int, message string) string {
+	return `<?xml version="1.0"?><methodResponse><fault><value><struct>` +
+		`<member><name>faultCode</name><value><int>` + strconv.Itoa(code) + `</int></value></member>` +
+		`<member><name>faultString</name><value><string>` + message + `</string></value></member>` +
+		`</struct></value></fault></methodResponse>`
+}
+
+func TestSupervisordManager(t *testing.T) {
+	start := int64(1700000000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		call := string(body)
+		switch {
+		case strings.Contains(call, "supervisor.startProcess"):
+			io.WriteString(w, xmlrpcFault(60, "ALREADY_STARTED: hermes"))
+		case strings.Contains(call, "supervisor.stopProcess") && strings.Contains(call, "missing"):
+			io.WriteString(w, xmlrpcFault(10, "BAD_NAME: missing"))
+		case strings.Contains(call, "supervisor.getProcessInfo"):
+			io.WriteString(w, xmlrpcResponse(`<struct>`+
+				`<member><name>name</name><value><string>hermes</string></value></member>`+
+				`<member><name>statename</name><value><string>RUNNING</string></value></member>`+
+				`<member><name>pid</name><value><int>4242</int></value></member>`+
+				`<member><name>start</name><value><int>`+strconv.FormatInt(start, 10)+`</int></value></member>`+
+				`<member><name>now</name><value><int>1700000090</int></value></member>`+
+				`<member><name>stop</name><value><int>0</int></value></member>`+
+				`<member><name>exitstatus</name><value><int>0</int></value></member>`+
+				`<member><name>description</name><value>pid 4242, uptime 0:01:30</value></member>`+
+				`</struct>`))
+		}
+	}))
+	defer server.Close()
+
+	m := NewSupervisordManager(server.URL, zap.NewNop())
+	ctx := context.Background()
+
+	// Starting a running program is not an error
+	require.NoError(t, m.Start(ctx, "hermes"))
+	assert.ErrorIs(t, m.Stop(ctx, "missing"), ErrUnknownProcess)
+
+	status, err := m.Status(ctx, "hermes")
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, status.State)
+	assert.Equal(t, 4242, status.PID)
+	assert.Equal(t, int64(90), status.UptimeSeconds)
+	assert.Equal(t, "pid 4242, uptime 0:01:30", status.Description)
+	assert.Nil(t, status.ExitCode)
+
+	// A new start time means supervisord restarted the program
+	start += 60
+	status, err = m.Status(ctx, "hermes")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Restarts)
+}
+
+func TestDockerManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/containers/hermes/json":
+			io.WriteString(w, `{"RestartCount":3,"State":{"Status":"exited","Running":false,"Pid":0,"ExitCode":137,`+
+				`"StartedAt":"2024-01-01T00:00:00Z","FinishedAt":"2024-01-01T01:00:00Z"}}`)
+		case "/containers/hermes/start":
+			w.WriteHeader(http.StatusNotModified)
+		case "/containers/hermes/stop":
+			assert.Equal(t, "30", r.URL.Query().Get("t"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"No such container"}`)
+		}
+	}))
+	defer server.Close()
+
+	m, err := NewDockerManager(server.URL, zap.NewNop())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, m.Start(ctx, "hermes"))
+	require.NoError(t, m.Stop(ctx, "hermes"))
+	assert.ErrorIs(t, m.Restart(ctx, "rly"), ErrUnknownProcess)
+
+	status, err := m.Status(ctx, "hermes")
+	require.NoError(t, err)
+	assert.Equal(t, StateExited, status.State)
+	assert.Equal(t, 3, status.Restarts)
+	require.NotNil(t, status.ExitCode)
+	assert.Equal(t, 137, *status.ExitCode)
+	assert.Zero(t, status.UptimeSeconds)
+}
+
+func TestSystemdManagerStatus(t *testing.T) {
+	m := NewSystemdManager(zap.NewNop())
+	m.run = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		assert.Equal(t, "hermes.service", args[1])
+		return []byte("LoadState=loaded\nActiveState=failed\nSubState=failed\nMainPID=0\nNRestarts=5\n" +
+			"ExecMainStatus=1\nExecMainStartTimestamp=@1700000000\nExecMainExitTimestamp=@1700000100\nDescription=Hermes relayer\n"), nil
+	}
+
+	status, err := m.Status(context.Background(), "hermes")
+	require.NoError(t, err)
+	assert.Equal(t, StateFailed, status.State)
+	assert.Equal(t, 5, status.Restarts)
+	require.NotNil(t, status.ExitCode)
+	assert.Equal(t, 1, *status.ExitCode)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), *status.StartedAt)
+}
+
+func TestChildManager(t *testing.T) {
+	m := NewChildManager(map[string][]string{
+		"sleeper": {"sleep", "30"},
+		"crasher": {"sh", "-c", "exit 3"},
+	}, t.TempDir(), zap.NewNop())
+	ctx := context.Background()
+
+	require.NoError(t, m.Start(ctx, "sleeper"))
+	status, err := m.Status(ctx, "sleeper")
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, status.State)
+	assert.NotZero(t, status.PID)
+
+	require.NoError(t, m.Restart(ctx, "sleeper"))
+	status, err = m.Status(ctx, "sleeper")
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Restarts)
+
+	require.NoError(t, m.Stop(ctx, "sleeper"))
+	status, err = m.Status(ctx, "sleeper")
+	require.NoError(t, err)
+	assert.Equal(t, StateStopped, status.State)
+
+	require.NoError(t, m.Start(ctx, "crasher"))
+	require.Eventually(t, func() bool {
+		status, _ := m.Status(ctx, "crasher")
+		return status.State == StateExited
+	}, 5*time.Second, 10*time.Millisecond)
+	status, _ = m.Status(ctx, "crasher")
+	assert.Equal(t, 3, *status.ExitCode)
+
+	assert.ErrorIs(t, m.Start(ctx, "unknown"), ErrUnknownProcess)
+}
@@ -0,0 +1,221 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSupervisorURL matches the unix_http_server in supervisord.conf
+const DefaultSupervisorURL = "unix:///var/run/supervisor.sock"
+
+// supervisord fault codes, see supervisor/xmlrpc.py
+const (
+	faultBadName        = 10
+	faultNotRunning     = 70
+	faultAlreadyStarted = 60
+)
+
+// SupervisordManager controls programs through the supervisord XML-RPC API
+type SupervisordManager struct {
+	endpoint string
+	client   *http.Client
+	logger   *zap.Logger
+
+	// supervisord has no restart counter, so restarts are counted
+	// whenever a program's start time changes between observations
+	mu       sync.Mutex
+	observed map[string]observation
+}
+
+type observation struct {
+	start    int64
+	restarts int
+}
+
+// NewSupervisordManager creates a manager for a supervisord server URL,
+// either unix:///path/to/supervisor.sock or http://host:port
+func NewSupervisordManager(serverURL string, logger *zap.Logger) *SupervisordManager {
+	client := &http.Client{Timeout: 30 * time.Second}
+	endpoint := strings.TrimSuffix(serverURL, "/") + "/RPC2"
+
+	if socket, ok := strings.CutPrefix(serverURL, "unix://"); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		endpoint = "http://supervisord/RPC2"
+	}
+
+	return &SupervisordManager{
+		endpoint: endpoint,
+		client:   client,
+		logger:   logger.With(zap.String("component", "supervisord")),
+		observed: make(map[string]observation),
+	}
+}
+
+// Name implements ProcessManager
+func (m *SupervisordManager) Name() string {
+	return "supervisord"
+}
+
+// Start implements ProcessManager
+func (m *SupervisordManager) Start(ctx context.Context, name string) error {
+	_, err := xmlrpcCall(ctx, m.client, m.endpoint, "supervisor.startProcess", name, true)
+	return m.translate(name, err, faultAlreadyStarted)
+}
+
+// Stop implements ProcessManager
+func (m *SupervisordManager) Stop(ctx context.Context, name string) error {
+	_, err := xmlrpcCall(ctx, m.client, m.endpoint, "supervisor.stopProcess", name, true)
+	return m.translate(name, err, faultNotRunning)
+}
+
+// Restart implements ProcessManager
+func (m *SupervisordManager) Restart(ctx context.Context, name string) error {
+	if err := m.Stop(ctx, name); err != nil {
+		return err
+	}
+	return m.Start(ctx, name)
+}
+
+// Status implements ProcessManager
+func (m *SupervisordManager) Status(ctx context.Context, name string) (*Status, error) {
+	result, err := xmlrpcCall(ctx, m.client, m.endpoint, "supervisor.getProcessInfo", name)
+	if err := m.translate(name, err); err != nil {
+		return nil, err
+	}
+
+	info, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("supervisord: unexpected getProcessInfo result %T", result)
+	}
+
+	status := &Status{
+		Name:        name,
+		State:       supervisordState(stringField(info, "statename")),
+		PID:         int(intField(info, "pid")),
+		Description: stringField(info, "description"),
+	}
+
+	start := intField(info, "start")
+	if start > 0 {
+		startedAt := time.Unix(start, 0).UTC()
+		status.StartedAt = &startedAt
+	}
+	switch status.State {
+	case StateExited, StateBackoff, StateFailed:
+		if intField(info, "stop") > 0 {
+			status.ExitCode = intPtr(int(intField(info, "exitstatus")))
+		}
+	}
+
+	m.mu.Lock()
+	seen, ok := m.observed[name]
+	if ok && start > 0 && seen.start > 0 && start != seen.start {
+		seen.restarts++
+	}
+	if start > 0 {
+		seen.start = start
+	}
+	m.observed[name] = seen
+	status.Restarts = seen.restarts
+	m.mu.Unlock()
+
+	// Measure uptime against supervisord's clock
+	now := time.Now()
+	if serverNow := intField(info, "now"); serverNow > 0 {
+		now = time.Unix(serverNow, 0)
+	}
+	status.setUptime(now)
+
+	return status, nil
+}
+
+// translate maps supervisord faults to package errors. Faults listed in
+// ignore mean the process is already in the requested state.
+func (m *SupervisordManager) translate(name string, err error, ignore ...int) error {
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		if err != nil {
+			return fmt.Errorf("supervisord %s: %w", name, err)
+		}
+		return nil
+	}
+
+	for _, code := range ignore {
+		if fault.Code == code {
+			return nil
+		}
+	}
+	if fault.Code == faultBadName {
+		return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+	return fmt.Errorf("supervisord %s: %w", name, fault)
+}
+
+func supervisordState(name string) State {
+	switch name {
+	case "RUNNING":
+		return StateRunning
+	case "STARTING":
+		return StateStarting
+	case "STOPPING":
+		return StateStopping
+	case "STOPPED":
+		return StateStopped
+	case "EXITED":
+		return StateExited
+	case "BACKOFF":
+		return StateBackoff
+	case "FATAL":
+		return StateFailed
+	default:
+		return StateUnknown
+	}
+}
+
+func stringField(fields map[string]interface{}, key string) string {
+	value, _ := fields[key].(string)
+	return value
+}
+
+func intField(fields map[string]interface{}, key string) int64 {
+	value, _ := fields[key].(int64)
+	return value
+}
+
+// TailLog reads up to length bytes of a program's stdout or stderr log
+// starting at offset. When more than length bytes are available, supervisord
+// skips ahead and sets overflow. It returns the offset to read from next.
+func (m *SupervisordManager) TailLog(ctx context.Context, name, stream string, offset int64, length int) (string, int64, bool, error) {
+	method := "supervisor.tailProcessStdoutLog"
+	if stream == "stderr" {
+		method = "supervisor.tailProcessStderrLog"
+	}
+
+	result, err := xmlrpcCall(ctx, m.client, m.endpoint, method, name, int(offset), length)
+	if err := m.translate(name, err); err != nil {
+		return "", offset, false, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		return "", offset, false, fmt.Errorf("supervisord: unexpected %s result %T", method, result)
+	}
+	data, _ := values[0].(string)
+	next, _ := values[1].(int64)
+	overflow, _ := values[2].(bool)
+
+	return data, next, overflow, nil
+}
@@ -0,0 +1,149 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// commandRunner runs a command and returns its combined output
+type commandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
+
+// SystemdManager controls relayers running as systemd units via systemctl
+type SystemdManager struct {
+	run    commandRunner
+	logger *zap.Logger
+}
+
+// NewSystemdManager creates a systemd process manager. Process names map to
+// units, with ".service" appended when no unit suffix is given.
+func NewSystemdManager(logger *zap.Logger) *SystemdManager {
+	return &SystemdManager{
+		run:    runCommand,
+		logger: logger.With(zap.String("component", "systemd")),
+	}
+}
+
+// Name implements ProcessManager
+func (m *SystemdManager) Name() string {
+	return "systemd"
+}
+
+// Start implements ProcessManager
+func (m *SystemdManager) Start(ctx context.Context, name string) error {
+	return m.systemctl(ctx, "start", name)
+}
+
+// Stop implements ProcessManager
+func (m *SystemdManager) Stop(ctx context.Context, name string) error {
+	return m.systemctl(ctx, "stop", name)
+}
+
+// Restart implements ProcessManager
+func (m *SystemdManager) Restart(ctx context.Context, name string) error {
+	return m.systemctl(ctx, "restart", name)
+}
+
+// Status implements ProcessManager
+func (m *SystemdManager) Status(ctx context.Context, name string) (*Status, error) {
+	output, err := m.run(ctx, "systemctl", "show", unitName(name),
+		"--property=LoadState,ActiveState,SubState,MainPID,NRestarts,ExecMainStatus,ExecMainStartTimestamp,ExecMainExitTimestamp,Description",
+		"--timestamp=unix",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("systemctl show %s: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+
+	props := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			props[key] = value
+		}
+	}
+	if props["LoadState"] == "not-found" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+	}
+
+	status := &Status{
+		Name:        name,
+		State:       systemdState(props["ActiveState"], props["SubState"]),
+		Description: props["Description"],
+	}
+	status.PID, _ = strconv.Atoi(props["MainPID"])
+	status.Restarts, _ = strconv.Atoi(props["NRestarts"])
+
+	if startedAt, ok := parseSystemdTimestamp(props["ExecMainStartTimestamp"]); ok {
+		status.StartedAt = &startedAt
+	}
+	if _, exited := parseSystemdTimestamp(props["ExecMainExitTimestamp"]); exited && !status.Running() {
+		if code, err := strconv.Atoi(props["ExecMainStatus"]); err == nil {
+			status.ExitCode = intPtr(code)
+			if status.State == StateStopped && code != 0 {
+				status.State = StateExited
+			}
+		}
+	}
+	status.setUptime(time.Now())
+
+	return status, nil
+}
+
+func (m *SystemdManager) systemctl(ctx context.Context, action, name string) error {
+	output, err := m.run(ctx, "systemctl", action, unitName(name))
+	if err != nil {
+		if bytes.Contains(output, []byte("not found")) {
+			return fmt.Errorf("%w: %s", ErrUnknownProcess, name)
+		}
+		return fmt.Errorf("systemctl %s %s: %w: %s", action, name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func unitName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return name + ".service"
+}
+
+func systemdState(active, sub string) State {
+	switch active {
+	case "active", "reloading":
+		if sub == "running" || sub == "" {
+			return StateRunning
+		}
+		return StateExited
+	case "activating":
+		if sub == "auto-restart" {
+			return StateBackoff
+		}
+		return StateStarting
+	case "deactivating":
+		return StateStopping
+	case "inactive":
+		return StateStopped
+	case "failed":
+		return StateFailed
+	default:
+		return StateUnknown
+	}
+}
+
+// parseSystemdTimestamp parses "@<unix seconds>" as printed with --timestamp=unix
+func parseSystemdTimestamp(value string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimPrefix(value, "@"), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0).UTC(), true
+}
@@ -0,0 +1,175 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Minimal XML-RPC client, covering the value types supervisord uses
+
+// Fault is an XML-RPC fault returned by the server
+type Fault struct {
+	Code   int
+	String string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("xml-rpc fault %d: %s", f.Code, f.String)
+}
+
+type xmlValue struct {
+	String  *string    `xml:"string"`
+	Int     *string    `xml:"int"`
+	I4      *string    `xml:"i4"`
+	Boolean *string    `xml:"boolean"`
+	Double  *string    `xml:"double"`
+	Struct  *xmlStruct `xml:"struct"`
+	Array   *xmlArray  `xml:"array"`
+	Text    string     `xml:",chardata"`
+}
+
+type xmlStruct struct {
+	Members []struct {
+		Name  string   `xml:"name"`
+		Value xmlValue `xml:"value"`
+	} `xml:"member"`
+}
+
+type xmlArray struct {
+	Values []xmlValue `xml:"data>value"`
+}
+
+type methodResponse struct {
+	Params []struct {
+		Value xmlValue `xml:"value"`
+	} `xml:"params>param"`
+	Fault *struct {
+		Value xmlValue `xml:"value"`
+	} `xml:"fault"`
+}
+
+func (v xmlValue) decode() (interface{}, error) {
+	switch {
+	case v.String != nil:
+		return *v.String, nil
+	case v.Int != nil:
+		return strconv.ParseInt(strings.TrimSpace(*v.Int), 10, 64)
+	case v.I4 != nil:
+		return strconv.ParseInt(strings.TrimSpace(*v.I4), 10, 64)
+	case v.Boolean != nil:
+		return strings.TrimSpace(*v.Boolean) == "1", nil
+	case v.Double != nil:
+		return strconv.ParseFloat(strings.TrimSpace(*v.Double), 64)
+	case v.Struct != nil:
+		result := make(map[string]interface{}, len(v.Struct.Members))
+		for _, member := range v.Struct.Members {
+			value, err := member.Value.decode()
+			if err != nil {
+				return nil, err
+			}
+			result[member.Name] = value
+		}
+		return result, nil
+	case v.Array != nil:
+		result := make([]interface{}, len(v.Array.Values))
+		for i, item := range v.Array.Values {
+			value, err := item.decode()
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		// A value without a type element is a string
+		return v.Text, nil
+	}
+}
+
+func encodeParam(buf *bytes.Buffer, param interface{}) error {
+	buf.WriteString("<param><value>")
+	switch p := param.(type) {
+	case string:
+		buf.WriteString("<string>")
+		if err := xml.EscapeText(buf, []byte(p)); err != nil {
+			return err
+		}
+		buf.WriteString("</string>")
+	case bool:
+		if p {
+			buf.WriteString("<boolean>1</boolean>")
+		} else {
+			buf.WriteString("<boolean>0</boolean>")
+		}
+	case int:
+		fmt.Fprintf(buf, "<int>%d</int>", p)
+	default:
+		return fmt.Errorf("unsupported xml-rpc parameter type %T", param)
+	}
+	buf.WriteString("</value></param>")
+	return nil
+}
+
+// xmlrpcCall invokes method and returns its decoded result
+func xmlrpcCall(ctx context.Context, client *http.Client, endpoint, method string, params ...interface{}) (interface{}, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0"?><methodCall><methodName>`)
+	if err := xml.EscapeText(&buf, []byte(method)); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</methodName><params>")
+	for _, param := range params {
+		if err := encodeParam(&buf, param); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString("</params></methodCall>")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("xml-rpc %s: unexpected status %d: %s", method, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var response methodResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("xml-rpc %s: invalid response: %w", method, err)
+	}
+
+	if response.Fault != nil {
+		value, err := response.Fault.Value.decode()
+		if err != nil {
+			return nil, err
+		}
+		fault := &Fault{}
+		if fields, ok := value.(map[string]interface{}); ok {
+			if code, ok := fields["faultCode"].(int64); ok {
+				fault.Code = int(code)
+			}
+			fault.String, _ = fields["faultString"].(string)
+		}
+		return nil, fault
+	}
+
+	if len(response.Params) == 0 {
+		return nil, nil
+	}
+	return response.Params[0].Value.decode()
+}
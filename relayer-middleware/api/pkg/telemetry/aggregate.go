@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// increase is the growth of a counter between two readings. A drop means
+// the relayer restarted, so the whole current value is new.
+func increase(prev, cur float64) float64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	return cur
+}
+
+func increaseMap(into map[channelKey]float64, prev, cur map[channelKey]float64) {
+	for key, value := range cur {
+		into[key] += increase(prev[key], value)
+	}
+}
+
+// increaseHistogram returns the observations added between two readings
+func increaseHistogram(prev, cur histogram) histogram {
+	if cur.count < prev.count {
+		return cur
+	}
+	delta := histogram{
+		bounds: cur.bounds,
+		counts: make([]float64, len(cur.counts)),
+		sum:    cur.sum - prev.sum,
+		count:  cur.count - prev.count,
+	}
+	for i, bound := range cur.bounds {
+		j := sort.SearchFloat64s(prev.bounds, bound)
+		if j < len(prev.bounds) && prev.bounds[j] == bound {
+			delta.counts[i] = increase(prev.counts[j], cur.counts[i])
+		} else {
+			delta.counts[i] = cur.counts[i]
+		}
+	}
+	return delta
+}
+
+func mergeHistograms(into map[channelKey]histogram, prev, cur map[channelKey]histogram) {
+	for key, h := range cur {
+		delta := increaseHistogram(prev[key], h)
+		merged := into[key]
+		merged.sum += delta.sum
+		merged.count += delta.count
+		for i, bound := range delta.bounds {
+			merged.add(bound, delta.counts[i])
+		}
+		into[key] = merged
+	}
+}
+
+// windowDelta accumulates the increases over a run of readings
+type windowDelta struct {
+	elapsed          time.Duration
+	relayed          map[channelKey]float64
+	sent             map[channelKey]float64
+	timeouts         map[channelKey]float64
+	failures         float64
+	messages         float64
+	latencySubmitted map[channelKey]histogram
+	latencyConfirmed map[channelKey]histogram
+}
+
+// delta sums the increases between consecutive readings
+func delta(readings []reading) windowDelta {
+	d := windowDelta{
+		relayed:          map[channelKey]float64{},
+		sent:             map[channelKey]float64{},
+		timeouts:         map[channelKey]float64{},
+		latencySubmitted: map[channelKey]histogram{},
+		latencyConfirmed: map[channelKey]histogram{},
+	}
+	if len(readings) < 2 {
+		return d
+	}
+
+	d.elapsed = readings[len(readings)-1].at.Sub(readings[0].at)
+	for i := 1; i < len(readings); i++ {
+		prev, cur := readings[i-1], readings[i]
+		increaseMap(d.relayed, prev.relayed, cur.relayed)
+		increaseMap(d.sent, prev.sent, cur.sent)
+		increaseMap(d.timeouts, prev.timeouts, cur.timeouts)
+		d.failures += increase(prev.failures, cur.failures)
+		d.messages += increase(prev.messages, cur.messages)
+		mergeHistograms(d.latencySubmitted, prev.latencySubmitted, cur.latencySubmitted)
+		mergeHistograms(d.latencyConfirmed, prev.latencyConfirmed, cur.latencyConfirmed)
+	}
+	return d
+}
+
+func (d windowDelta) counts() Counts {
+	return Counts{
+		PacketsRelayed:    total(d.relayed),
+		SendPacketEvents:  total(d.sent),
+		Timeouts:          total(d.timeouts),
+		TxFailures:        d.failures,
+		MessagesSubmitted: d.messages,
+	}
+}
+
+func (d windowDelta) stats(window string) WindowStats {
+	stats := WindowStats{Window: window, Counts: d.counts()}
+	if minutes := d.elapsed.Minutes(); minutes > 0 {
+		stats.PacketsPerMinute = round(stats.PacketsRelayed / minutes)
+	}
+	if attempts := stats.PacketsRelayed + stats.TxFailures; attempts > 0 {
+		rate := round(stats.PacketsRelayed / attempts * 100)
+		stats.SuccessRate = &rate
+	}
+	stats.LatencySubmitted = latency(combine(d.latencySubmitted))
+	stats.LatencyConfirmed = latency(combine(d.latencyConfirmed))
+	return stats
+}
+
+// inWindow returns the readings covering the window ending at the latest
+// one, starting from the last reading at or before the window start
+func inWindow(readings []reading, window time.Duration) []reading {
+	if len(readings) == 0 {
+		return nil
+	}
+	start := readings[len(readings)-1].at.Add(-window)
+	first := 0
+	for i, r := range readings {
+		if r.at.After(start) {
+			break
+		}
+		first = i
+	}
+	return readings[first:]
+}
+
+func combine(histograms map[channelKey]histogram) histogram {
+	var combined histogram
+	for _, h := range histograms {
+		combined.sum += h.sum
+		combined.count += h.count
+		for i, bound := range h.bounds {
+			combined.add(bound, h.counts[i])
+		}
+	}
+	return combined
+}
+
+func latency(h histogram) *LatencyStats {
+	if h.count <= 0 {
+		return nil
+	}
+	return &LatencyStats{
+		Count: h.count,
+		AvgMs: round(h.sum / h.count),
+		P50Ms: round(h.quantile(0.5)),
+		P95Ms: round(h.quantile(0.95)),
+	}
+}
+
+// quantile estimates a quantile by linear interpolation within the bucket
+// it falls in, the same way PromQL's histogram_quantile does
+func (h histogram) quantile(q float64) float64 {
+	if len(h.counts) == 0 {
+		return 0
+	}
+	rank := q * h.counts[len(h.counts)-1]
+	for i, cumulative := range h.counts {
+		if cumulative < rank {
+			continue
+		}
+		lower, lowerCount := 0.0, 0.0
+		if i > 0 {
+			lower, lowerCount = h.bounds[i-1], h.counts[i-1]
+		}
+		if math.IsInf(h.bounds[i], 1) {
+			return lower
+		}
+		if cumulative == lowerCount {
+			return h.bounds[i]
+		}
+		return lower + (h.bounds[i]-lower)*(rank-lowerCount)/(cumulative-lowerCount)
+	}
+	return h.bounds[len(h.bounds)-1]
+}
+
+func total(values map[channelKey]float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
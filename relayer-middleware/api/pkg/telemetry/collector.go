@@ -0,0 +1,285 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+)
+
+// Windows are the rolling aggregate windows, shortest first
+var Windows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// retention is how long readings are kept, enough for the longest window
+const retention = time.Hour
+
+// scrapeResult records whether one scrape succeeded, for uptime
+type scrapeResult struct {
+	at time.Time
+	ok bool
+}
+
+type target struct {
+	Target
+	readings    []reading
+	scrapes     []scrapeResult
+	lastError   string
+	lastScrape  time.Time
+	lastSuccess time.Time
+}
+
+// Collector scrapes the Prometheus telemetry of the relayers and keeps
+// rolling aggregates of packet throughput, failures and latency
+type Collector struct {
+	targets []*target
+	client  *http.Client
+	now     func() time.Time
+	mu      sync.RWMutex
+	logger  *zap.Logger
+}
+
+// NewCollector creates a collector for the Hermes telemetry endpoint
+// (HERMES_TELEMETRY_URL) and the rly metrics endpoint (RLY_METRICS_URL)
+func NewCollector(logger *zap.Logger) *Collector {
+	hermesURL := os.Getenv("HERMES_TELEMETRY_URL")
+	if hermesURL == "" {
+		hermesURL = "http://localhost:3001/metrics"
+	}
+	rlyURL := os.Getenv("RLY_METRICS_URL")
+	if rlyURL == "" {
+		rlyURL = "http://localhost:5184/relayer/metrics"
+	}
+
+	return NewCollectorWithTargets([]Target{
+		{Relayer: "hermes", URL: hermesURL},
+		{Relayer: "rly", URL: rlyURL},
+	}, logger)
+}
+
+// NewCollectorWithTargets creates a collector for explicit endpoints
+func NewCollectorWithTargets(targets []Target, logger *zap.Logger) *Collector {
+	c := &Collector{
+		client: &http.Client{Timeout: 5 * time.Second},
+		now:    time.Now,
+		logger: logger.With(zap.String("component", "telemetry_collector")),
+	}
+	for _, t := range targets {
+		c.targets = append(c.targets, &target{Target: t})
+	}
+	return c
+}
+
+// Collect scrapes every target once
+func (c *Collector) Collect(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, t := range c.targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			c.collect(ctx, t)
+		}(t)
+	}
+	wg.Wait()
+}
+
+func (c *Collector) collect(ctx context.Context, t *target) {
+	s, ok := specs[t.Relayer]
+	if !ok {
+		return
+	}
+
+	at := c.now()
+	families, err := c.scrape(ctx, t.URL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t.lastScrape = at
+	t.scrapes = append(t.scrapes, scrapeResult{at: at, ok: err == nil})
+	for len(t.scrapes) > 0 && at.Sub(t.scrapes[0].at) > retention {
+		t.scrapes = t.scrapes[1:]
+	}
+
+	if err != nil {
+		// Only log when a target goes down, not on every failed scrape
+		if t.lastError == "" {
+			c.logger.Debug("Relayer telemetry unavailable", zap.String("relayer", t.Relayer), zap.Error(err))
+		}
+		t.lastError = err.Error()
+		return
+	}
+
+	t.lastError = ""
+	t.lastSuccess = at
+	t.readings = append(t.readings, newReading(s, at, families))
+	// Keep one reading older than the retention as the baseline of the longest window
+	for len(t.readings) > 1 && at.Sub(t.readings[1].at) > retention {
+		t.readings = t.readings[1:]
+	}
+}
+
+func (c *Collector) scrape(ctx context.Context, url string) (map[string]*dto.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(resp.Body)
+}
+
+// Snapshot returns the telemetry of one relayer
+func (c *Collector) Snapshot(relayer string) (Snapshot, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, t := range c.targets {
+		if t.Relayer == relayer {
+			return t.snapshot(), true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// Snapshots returns the telemetry of every relayer
+func (c *Collector) Snapshots() []Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	snapshots := make([]Snapshot, 0, len(c.targets))
+	for _, t := range c.targets {
+		snapshots = append(snapshots, t.snapshot())
+	}
+	return snapshots
+}
+
+func (t *target) snapshot() Snapshot {
+	snapshot := Snapshot{
+		Relayer:  t.Relayer,
+		URL:      t.URL,
+		Status:   StatusUnknown,
+		Error:    t.lastError,
+		Windows:  []WindowStats{},
+		Channels: []ChannelStats{},
+		Wallets:  []WalletBalance{},
+	}
+	if t.lastScrape.IsZero() {
+		return snapshot
+	}
+
+	lastScrape := t.lastScrape
+	snapshot.LastScrape = &lastScrape
+	snapshot.Status = StatusUp
+	if t.lastError != "" {
+		snapshot.Status = StatusDown
+	}
+	if !t.lastSuccess.IsZero() {
+		lastSuccess := t.lastSuccess
+		snapshot.LastSuccess = &lastSuccess
+	}
+
+	var ok int
+	for _, scrape := range t.scrapes {
+		if scrape.ok {
+			ok++
+		}
+	}
+	if len(t.scrapes) > 0 {
+		uptime := round(float64(ok) / float64(len(t.scrapes)) * 100)
+		snapshot.Uptime = &uptime
+	}
+
+	if len(t.readings) == 0 {
+		return snapshot
+	}
+
+	latest := t.readings[len(t.readings)-1]
+	snapshot.Totals = Counts{
+		PacketsRelayed:    total(latest.relayed),
+		SendPacketEvents:  total(latest.sent),
+		Timeouts:          total(latest.timeouts),
+		TxFailures:        latest.failures,
+		MessagesSubmitted: latest.messages,
+	}
+	if latest.wallets != nil {
+		snapshot.Wallets = latest.wallets
+	}
+
+	var longest windowDelta
+	for _, window := range Windows {
+		longest = delta(inWindow(t.readings, window.Duration))
+		snapshot.Windows = append(snapshot.Windows, longest.stats(window.Name))
+	}
+	snapshot.Channels = channelStats(longest, latest)
+	return snapshot
+}
+
+// channelStats breaks the longest window down by channel
+func channelStats(d windowDelta, latest reading) []ChannelStats {
+	keys := make(map[channelKey]bool)
+	for _, values := range []map[channelKey]float64{d.relayed, d.sent, d.timeouts, latest.backlog} {
+		for key := range values {
+			keys[key] = true
+		}
+	}
+	for key := range d.latencyConfirmed {
+		keys[key] = true
+	}
+
+	channels := make([]ChannelStats, 0, len(keys))
+	for key := range keys {
+		if key.channel == "" {
+			continue
+		}
+		stats := ChannelStats{
+			Chain:            key.chain,
+			Counterparty:     key.counterparty,
+			Channel:          key.channel,
+			Port:             key.port,
+			PacketsRelayed:   d.relayed[key],
+			SendPacketEvents: d.sent[key],
+			Timeouts:         d.timeouts[key],
+			LatencyConfirmed: latency(d.latencyConfirmed[key]),
+		}
+		if backlog, ok := latest.backlog[key]; ok {
+			stats.Backlog = &backlog
+		}
+		channels = append(channels, stats)
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Chain != channels[j].Chain {
+			return channels[i].Chain < channels[j].Chain
+		}
+		if channels[i].Channel != channels[j].Channel {
+			return channels[i].Channel < channels[j].Channel
+		}
+		return channels[i].Port < channels[j].Port
+	})
+	return channels
+}
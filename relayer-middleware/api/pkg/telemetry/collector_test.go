@@ -0,0 +1,162 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// hermesMetrics renders the Hermes fixture with the given counter values
+func hermesMetrics(t *testing.T, values map[string]string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/hermes.txt")
+	require.NoError(t, err)
+
+	content := string(data)
+	for name, value := range values {
+		content = strings.ReplaceAll(content, "{{"+name+"}}", value)
+	}
+	return content
+}
+
+func newTestCollector(t *testing.T, body *string) (*Collector, *time.Time) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *body == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(*body))
+	}))
+	t.Cleanup(server.Close)
+
+	c := NewCollectorWithTargets([]Target{{Relayer: "hermes", URL: server.URL}}, zap.NewNop())
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestCollectHermesAggregates(t *testing.T) {
+	body := hermesMetrics(t, map[string]string{
+		"RECV": "100", "ACK": "50", "SEND": "200", "ERRORS": "2",
+		"B1": "10", "B5": "20", "B10": "20", "SUM": "40000",
+	})
+	c, now := newTestCollector(t, &body)
+	ctx := context.Background()
+
+	c.Collect(ctx)
+	snapshot, ok := c.Snapshot("hermes")
+	require.True(t, ok)
+	assert.Equal(t, StatusUp, snapshot.Status)
+	assert.Equal(t, 151.0, snapshot.Totals.PacketsRelayed)
+	assert.Equal(t, 200.0, snapshot.Totals.SendPacketEvents)
+	assert.Equal(t, 1.0, snapshot.Totals.Timeouts)
+	assert.Equal(t, 2.0, snapshot.Totals.TxFailures)
+	require.Len(t, snapshot.Wallets, 2)
+	assert.Equal(t, "cosmos1relayer", snapshot.Wallets[0].Account)
+	assert.Equal(t, 2500000.0, snapshot.Wallets[0].Amount)
+
+	// Ten minutes later: 60 more packets, 1 more failure and 20 more
+	// confirmed transactions, all between 1s and 5s
+	*now = now.Add(10 * time.Minute)
+	body = hermesMetrics(t, map[string]string{
+		"RECV": "140", "ACK": "70", "SEND": "260", "ERRORS": "3",
+		"B1": "10", "B5": "40", "B10": "40", "SUM": "100000",
+	})
+	c.Collect(ctx)
+
+	snapshot, _ = c.Snapshot("hermes")
+	require.Len(t, snapshot.Windows, 3)
+	hour := snapshot.Windows[2]
+	assert.Equal(t, "1h", hour.Window)
+	assert.Equal(t, 60.0, hour.PacketsRelayed)
+	assert.Equal(t, 60.0, hour.SendPacketEvents)
+	assert.Equal(t, 1.0, hour.TxFailures)
+	assert.Equal(t, 6.0, hour.PacketsPerMinute)
+	require.NotNil(t, hour.SuccessRate)
+	assert.InDelta(t, 98.36, *hour.SuccessRate, 0.01)
+
+	require.NotNil(t, hour.LatencyConfirmed)
+	assert.Equal(t, 20.0, hour.LatencyConfirmed.Count)
+	assert.Equal(t, 3000.0, hour.LatencyConfirmed.AvgMs)
+	assert.Equal(t, 3000.0, hour.LatencyConfirmed.P50Ms)
+	assert.Equal(t, 4800.0, hour.LatencyConfirmed.P95Ms)
+
+	require.Len(t, snapshot.Channels, 2)
+	hub := snapshot.Channels[0]
+	assert.Equal(t, "cosmoshub-4", hub.Chain)
+	assert.Equal(t, "channel-141", hub.Channel)
+	assert.Equal(t, 20.0, hub.PacketsRelayed)
+	require.NotNil(t, hub.Backlog)
+	assert.Equal(t, 3.0, *hub.Backlog)
+	assert.Equal(t, "osmosis-1", snapshot.Channels[1].Chain)
+	assert.NotNil(t, snapshot.Channels[1].LatencyConfirmed)
+}
+
+func TestCollectHandlesRestartsAndOutages(t *testing.T) {
+	body := hermesMetrics(t, map[string]string{
+		"RECV": "100", "ACK": "0", "SEND": "100", "ERRORS": "0",
+		"B1": "0", "B5": "0", "B10": "0", "SUM": "0",
+	})
+	c, now := newTestCollector(t, &body)
+	ctx := context.Background()
+	c.Collect(ctx)
+
+	// Hermes is down for one scrape
+	*now = now.Add(time.Minute)
+	saved := body
+	body = ""
+	c.Collect(ctx)
+	snapshot, _ := c.Snapshot("hermes")
+	assert.Equal(t, StatusDown, snapshot.Status)
+	assert.NotEmpty(t, snapshot.Error)
+	require.NotNil(t, snapshot.Uptime)
+	assert.Equal(t, 50.0, *snapshot.Uptime)
+
+	// ...and comes back restarted, with its counters reset
+	*now = now.Add(time.Minute)
+	body = strings.Replace(saved, "} 100\n", "} 5\n", 1)
+	c.Collect(ctx)
+	snapshot, _ = c.Snapshot("hermes")
+	assert.Equal(t, StatusUp, snapshot.Status)
+	assert.Equal(t, 5.0, snapshot.Windows[0].PacketsRelayed)
+}
+
+func TestCollectRly(t *testing.T) {
+	data, err := os.ReadFile("testdata/rly.txt")
+	require.NoError(t, err)
+	body := string(data)
+
+	c, _ := newTestCollector(t, &body)
+	c.targets[0].Relayer = "rly"
+	c.Collect(context.Background())
+
+	snapshot, ok := c.Snapshot("rly")
+	require.True(t, ok)
+	assert.Equal(t, 38.0, snapshot.Totals.PacketsRelayed)
+	assert.Equal(t, 40.0, snapshot.Totals.SendPacketEvents)
+	assert.Equal(t, 2.0, snapshot.Totals.TxFailures)
+	require.Len(t, snapshot.Wallets, 1)
+	assert.Equal(t, "noble1relayer", snapshot.Wallets[0].Account)
+	assert.Equal(t, 1500000.0, snapshot.Wallets[0].Amount)
+}
+
+func TestSnapshotBeforeFirstScrape(t *testing.T) {
+	c := NewCollectorWithTargets([]Target{{Relayer: "hermes", URL: "http://localhost:0"}}, zap.NewNop())
+	snapshot, ok := c.Snapshot("hermes")
+	require.True(t, ok)
+	assert.Equal(t, StatusUnknown, snapshot.Status)
+	assert.Empty(t, snapshot.Windows)
+
+	_, ok = c.Snapshot("unknown")
+	assert.False(t, ok)
+}
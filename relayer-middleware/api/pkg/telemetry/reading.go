@@ -0,0 +1,221 @@
+package telemetry
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// channelKey identifies the channel a series belongs to. Series without
+// channel labels, like transaction failures, use the zero key.
+type channelKey struct {
+	chain        string
+	counterparty string
+	channel      string
+	port         string
+}
+
+// histogram is a cumulative latency histogram
+type histogram struct {
+	bounds []float64
+	counts []float64
+	sum    float64
+	count  float64
+}
+
+// reading is one scrape reduced to the series the aggregates are built from
+type reading struct {
+	at               time.Time
+	relayed          map[channelKey]float64
+	sent             map[channelKey]float64
+	timeouts         map[channelKey]float64
+	failures         float64
+	messages         float64
+	latencySubmitted map[channelKey]histogram
+	latencyConfirmed map[channelKey]histogram
+	backlog          map[channelKey]float64
+	wallets          []WalletBalance
+}
+
+// spec maps a relayer's metric names onto a reading. Counter names are
+// given without the _total suffix, which depends on the exporter version.
+type spec struct {
+	relayed          []string
+	sent             string
+	sentTypes        map[string]bool
+	timeouts         []string
+	failures         []string
+	messages         []string
+	latencySubmitted string
+	latencyConfirmed string
+	backlog          string
+	wallet           string
+	walletAccount    string
+}
+
+var specs = map[string]spec{
+	"hermes": {
+		relayed:          []string{"receive_packets_confirmed", "acknowledgment_packets_confirmed", "timeout_packets_confirmed"},
+		sent:             "send_packet_events",
+		timeouts:         []string{"timeout_packets_confirmed"},
+		failures:         []string{"broadcast_errors", "simulate_errors"},
+		messages:         []string{"total_messages_submitted", "messages_submitted"},
+		latencySubmitted: "tx_latency_submitted",
+		latencyConfirmed: "tx_latency_confirmed",
+		backlog:          "backlog_size",
+		wallet:           "wallet_balance",
+		walletAccount:    "account",
+	},
+	"rly": {
+		relayed:       []string{"cosmos_relayer_relayed_packets"},
+		sent:          "cosmos_relayer_observed_packets",
+		sentTypes:     map[string]bool{"send_packet": true, "SendPacket": true},
+		failures:      []string{"cosmos_relayer_tx_failure"},
+		wallet:        "cosmos_relayer_wallet_balance",
+		walletAccount: "address",
+	},
+}
+
+// newReading extracts the series of a spec from parsed metric families
+func newReading(s spec, at time.Time, families map[string]*dto.MetricFamily) reading {
+	r := reading{
+		at:               at,
+		relayed:          map[channelKey]float64{},
+		sent:             map[channelKey]float64{},
+		timeouts:         map[channelKey]float64{},
+		latencySubmitted: map[channelKey]histogram{},
+		latencyConfirmed: map[channelKey]histogram{},
+		backlog:          map[channelKey]float64{},
+	}
+
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for name, family := range families {
+		byName[strings.TrimSuffix(name, "_total")] = family
+	}
+
+	for _, name := range s.relayed {
+		addByChannel(r.relayed, byName[name], nil)
+	}
+	addByChannel(r.sent, byName[s.sent], s.sentTypes)
+	for _, name := range s.timeouts {
+		addByChannel(r.timeouts, byName[name], nil)
+	}
+	for _, name := range s.failures {
+		r.failures += sum(byName[name])
+	}
+	for _, name := range s.messages {
+		r.messages += sum(byName[name])
+	}
+	addHistograms(r.latencySubmitted, byName[s.latencySubmitted])
+	addHistograms(r.latencyConfirmed, byName[s.latencyConfirmed])
+	addByChannel(r.backlog, byName[s.backlog], nil)
+
+	if family := byName[s.wallet]; family != nil {
+		for _, metric := range family.GetMetric() {
+			labels := labelMap(metric)
+			r.wallets = append(r.wallets, WalletBalance{
+				Chain:   labels["chain"],
+				Account: labels[s.walletAccount],
+				Denom:   labels["denom"],
+				Amount:  value(metric),
+			})
+		}
+		sort.Slice(r.wallets, func(i, j int) bool {
+			if r.wallets[i].Chain != r.wallets[j].Chain {
+				return r.wallets[i].Chain < r.wallets[j].Chain
+			}
+			return r.wallets[i].Denom < r.wallets[j].Denom
+		})
+	}
+
+	return r
+}
+
+func addByChannel(into map[channelKey]float64, family *dto.MetricFamily, types map[string]bool) {
+	for _, metric := range family.GetMetric() {
+		labels := labelMap(metric)
+		if types != nil && !types[labels["type"]] {
+			continue
+		}
+		into[keyOf(labels)] += value(metric)
+	}
+}
+
+func addHistograms(into map[channelKey]histogram, family *dto.MetricFamily) {
+	for _, metric := range family.GetMetric() {
+		h := metric.GetHistogram()
+		if h == nil {
+			continue
+		}
+		key := keyOf(labelMap(metric))
+		current := into[key]
+		current.sum += h.GetSampleSum()
+		current.count += float64(h.GetSampleCount())
+		for _, bucket := range h.GetBucket() {
+			current.add(bucket.GetUpperBound(), float64(bucket.GetCumulativeCount()))
+		}
+		into[key] = current
+	}
+}
+
+// add merges a cumulative bucket count, keeping bounds sorted
+func (h *histogram) add(bound, count float64) {
+	i := sort.SearchFloat64s(h.bounds, bound)
+	if i < len(h.bounds) && h.bounds[i] == bound {
+		h.counts[i] += count
+		return
+	}
+	h.bounds = append(h.bounds, 0)
+	h.counts = append(h.counts, 0)
+	copy(h.bounds[i+1:], h.bounds[i:])
+	copy(h.counts[i+1:], h.counts[i:])
+	h.bounds[i], h.counts[i] = bound, count
+}
+
+func keyOf(labels map[string]string) channelKey {
+	return channelKey{
+		chain:        firstLabel(labels, "chain", "src_chain"),
+		counterparty: firstLabel(labels, "counterparty", "dst_chain", "path_name"),
+		channel:      firstLabel(labels, "channel", "src_channel"),
+		port:         firstLabel(labels, "port", "src_port"),
+	}
+}
+
+func firstLabel(labels map[string]string, names ...string) string {
+	for _, name := range names {
+		if value := labels[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func labelMap(metric *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+func value(metric *dto.Metric) float64 {
+	switch {
+	case metric.GetCounter() != nil:
+		return metric.GetCounter().GetValue()
+	case metric.GetGauge() != nil:
+		return metric.GetGauge().GetValue()
+	case metric.GetUntyped() != nil:
+		return metric.GetUntyped().GetValue()
+	}
+	return 0
+}
+
+func sum(family *dto.MetricFamily) float64 {
+	var total float64
+	for _, metric := range family.GetMetric() {
+		total += value(metric)
+	}
+	return total
+}
@@ -0,0 +1,81 @@
+package telemetry
+
+import "time"
+
+// Scrape statuses
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown"
+)
+
+// Counts are packet and transaction counters, either cumulative since the
+// relayer started or the increase over a window
+type Counts struct {
+	PacketsRelayed    float64 `json:"packets_relayed"`
+	SendPacketEvents  float64 `json:"send_packet_events"`
+	Timeouts          float64 `json:"timeouts"`
+	TxFailures        float64 `json:"tx_failures"`
+	MessagesSubmitted float64 `json:"messages_submitted"`
+}
+
+// LatencyStats summarises a latency histogram in milliseconds
+type LatencyStats struct {
+	Count float64 `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+}
+
+// WindowStats are the rolling aggregates over one window
+type WindowStats struct {
+	Window string `json:"window"`
+	Counts
+	PacketsPerMinute float64 `json:"packets_per_minute"`
+	// SuccessRate is the percentage of relay transactions that didn't fail
+	SuccessRate      *float64      `json:"success_rate,omitempty"`
+	LatencySubmitted *LatencyStats `json:"latency_submitted,omitempty"`
+	LatencyConfirmed *LatencyStats `json:"latency_confirmed,omitempty"`
+}
+
+// ChannelStats are the aggregates of one channel over the longest window
+type ChannelStats struct {
+	Chain            string        `json:"chain"`
+	Counterparty     string        `json:"counterparty,omitempty"`
+	Channel          string        `json:"channel"`
+	Port             string        `json:"port"`
+	PacketsRelayed   float64       `json:"packets_relayed"`
+	SendPacketEvents float64       `json:"send_packet_events"`
+	Timeouts         float64       `json:"timeouts"`
+	Backlog          *float64      `json:"backlog,omitempty"`
+	LatencyConfirmed *LatencyStats `json:"latency_confirmed,omitempty"`
+}
+
+// WalletBalance is a relayer wallet balance reported by the relayer
+type WalletBalance struct {
+	Chain   string  `json:"chain"`
+	Account string  `json:"account"`
+	Denom   string  `json:"denom"`
+	Amount  float64 `json:"amount"`
+}
+
+// Snapshot is everything known about one relayer's telemetry
+type Snapshot struct {
+	Relayer     string          `json:"relayer"`
+	URL         string          `json:"url"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	LastScrape  *time.Time      `json:"last_scrape,omitempty"`
+	LastSuccess *time.Time      `json:"last_success,omitempty"`
+	Uptime      *float64        `json:"uptime,omitempty"`
+	Totals      Counts          `json:"totals"`
+	Windows     []WindowStats   `json:"windows"`
+	Channels    []ChannelStats  `json:"channels"`
+	Wallets     []WalletBalance `json:"wallets"`
+}
+
+// Target is a relayer metrics endpoint
+type Target struct {
+	Relayer string
+	URL     string
+}
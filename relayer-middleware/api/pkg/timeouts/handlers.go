@@ -0,0 +1,55 @@
+package timeouts
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Handlers exposes timeout predictions
+type Handlers struct {
+	predictor *Predictor
+	logger    *zap.Logger
+}
+
+// NewHandlers creates timeout prediction handlers
+func NewHandlers(predictor *Predictor, logger *zap.Logger) *Handlers {
+	return &Handlers{
+		predictor: predictor,
+		logger:    logger.With(zap.String("component", "timeout_handlers")),
+	}
+}
+
+// RegisterRoutes registers GET /packets/timeouts
+func (h *Handlers) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/packets/timeouts", h.GetTimeouts)
+}
+
+// GetTimeouts handles GET /packets/timeouts, the predicted timeout of every
+// pending packet soonest first, with the destination chain heads they were
+// predicted from. ?chain_id= picks one source chain and ?urgency= one level.
+func (h *Handlers) GetTimeouts(c *gin.Context) {
+	urgency := c.Query("urgency")
+	if _, ok := urgencyRank[urgency]; urgency != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "urgency must be ok, warning, critical or expired"})
+		return
+	}
+
+	predictions := h.predictor.Predictions(c.Query("chain_id"))
+	if urgency != "" {
+		filtered := predictions[:0]
+		for _, prediction := range predictions {
+			if prediction.Urgency == urgency {
+				filtered = append(filtered, prediction)
+			}
+		}
+		predictions = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"predictions": predictions,
+		"alerts":      h.predictor.Alerts(),
+		"chains":      h.predictor.Heads(),
+	})
+}
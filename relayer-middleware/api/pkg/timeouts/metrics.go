@@ -0,0 +1,40 @@
+package timeouts
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	packets   *prometheus.GaugeVec
+	blockTime *prometheus.GaugeVec
+	alerts    *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		packets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_packets_expiring",
+			Help: "Pending packets by source chain and predicted timeout urgency",
+		}, []string{"chain_id", "urgency"}),
+		blockTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "relayooor_chain_block_time_seconds",
+			Help: "Average block time of a destination chain over its recent blocks",
+		}, []string{"chain_id"}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "relayooor_packet_timeout_alerts_total",
+			Help: "Alerts raised for packets becoming more urgent",
+		}, []string{"chain_id", "level"}),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.packets, m.blockTime, m.alerts}
+}
+
+// observe replaces the packet counts with those of a check
+func (m *metrics) observe(predictions []Prediction) {
+	m.packets.Reset()
+	for _, prediction := range predictions {
+		m.packets.WithLabelValues(prediction.ChainID, prediction.Urgency).Inc()
+	}
+}
@@ -0,0 +1,540 @@
+package timeouts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/chainpulse"
+	"relayooor/api/pkg/database"
+)
+
+// urgencyRank orders urgency levels, higher is more urgent
+var urgencyRank = map[string]int{
+	UrgencyOK:       0,
+	UrgencyWarning:  1,
+	UrgencyCritical: 2,
+	UrgencyExpired:  3,
+}
+
+// Predictor works out exactly when pending packets time out. A height
+// timeout is reached after the destination chain produces the blocks left
+// before the timeout height, at its recent average block time; a timestamp
+// timeout when the destination chain's clock reaches it. Chainpulse only
+// supplies the packets and a fallback estimate for chains that can't be
+// queried.
+type Predictor struct {
+	source         Source
+	querier        Querier
+	counterparties Counterparties
+	notifier       Notifier
+	interval       time.Duration
+	horizon        time.Duration
+	// blockWindow is how many blocks the block time is averaged over
+	blockWindow int64
+	thresholds  Thresholds
+	metrics     *metrics
+	now         func() time.Time
+
+	// checkMu serialises checks
+	checkMu sync.Mutex
+
+	mu          sync.RWMutex
+	heads       map[string]Head
+	predictions map[string]Prediction
+
+	logger *zap.Logger
+}
+
+// NewPredictor creates a timeout predictor. Settings come from
+// TIMEOUT_CHECK_INTERVAL (1m), TIMEOUT_HORIZON (6h, how far ahead packets
+// are listed), TIMEOUT_WARNING (1h), TIMEOUT_CRITICAL (15m) and
+// TIMEOUT_BLOCK_WINDOW (100 blocks).
+func NewPredictor(source Source, querier Querier, logger *zap.Logger) *Predictor {
+	p := &Predictor{
+		source:      source,
+		querier:     querier,
+		interval:    database.EnvDuration("TIMEOUT_CHECK_INTERVAL", time.Minute),
+		horizon:     database.EnvDuration("TIMEOUT_HORIZON", 6*time.Hour),
+		blockWindow: 100,
+		thresholds: Thresholds{
+			Warning:  database.EnvDuration("TIMEOUT_WARNING", time.Hour),
+			Critical: database.EnvDuration("TIMEOUT_CRITICAL", 15*time.Minute),
+		},
+		metrics:     newMetrics(),
+		now:         time.Now,
+		heads:       make(map[string]Head),
+		predictions: make(map[string]Prediction),
+		logger:      logger.With(zap.String("component", "timeout_predictor")),
+	}
+	if value, err := strconv.ParseInt(os.Getenv("TIMEOUT_BLOCK_WINDOW"), 10, 64); err == nil && value > 0 {
+		p.blockWindow = value
+	}
+	return p
+}
+
+// UseCounterparties sets how the destination chain of a packet is found
+// from its source channel. Without it every prediction falls back to
+// Chainpulse's estimate.
+func (p *Predictor) UseCounterparties(counterparties Counterparties) {
+	p.counterparties = counterparties
+}
+
+// UseNotifier sets where alerts are sent in addition to the log
+func (p *Predictor) UseNotifier(notifier Notifier) {
+	p.notifier = notifier
+}
+
+// Register adds the predictor's metrics to a Prometheus registry
+func (p *Predictor) Register(registerer prometheus.Registerer) error {
+	for _, collector := range p.metrics.collectors() {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start checks packets immediately and then every interval until ctx is
+// done
+func (p *Predictor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			p.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check predicts the timeout of every packet Chainpulse expects to time out
+// within the horizon and raises alerts for packets that became more urgent.
+// The previous predictions are kept when Chainpulse can't be reached.
+func (p *Predictor) Check(ctx context.Context) []Prediction {
+	p.checkMu.Lock()
+	defer p.checkMu.Unlock()
+
+	packets, err := p.source.AllExpiringPackets(ctx, p.horizon)
+	if err != nil {
+		p.logger.Warn("Failed to list expiring packets", zap.Error(err))
+		return p.Predictions("")
+	}
+
+	predictions := p.predict(ctx, packets, true)
+	p.store(predictions)
+	return predictions
+}
+
+// Predict predicts the timeout of packets, sorted soonest first, querying
+// the destination chains whose head is older than half the check interval
+func (p *Predictor) Predict(ctx context.Context, packets []chainpulse.ExpiringPacket) []Prediction {
+	return p.predict(ctx, packets, false)
+}
+
+// Predictions returns the predictions of the last check, or of one source
+// chain's packets, soonest first
+func (p *Predictor) Predictions(chainID string) []Prediction {
+	now := p.now()
+
+	p.mu.RLock()
+	results := make([]Prediction, 0, len(p.predictions))
+	for _, prediction := range p.predictions {
+		if chainID == "" || prediction.ChainID == chainID {
+			results = append(results, p.age(prediction, now))
+		}
+	}
+	p.mu.RUnlock()
+
+	sortPredictions(results)
+	return results
+}
+
+// Lookup returns the last prediction of a packet
+func (p *Predictor) Lookup(chainID, channelID string, sequence uint64) (Prediction, bool) {
+	p.mu.RLock()
+	prediction, ok := p.predictions[packetKey(chainID, channelID, sequence)]
+	p.mu.RUnlock()
+	if !ok {
+		return Prediction{}, false
+	}
+	return p.age(prediction, p.now()), true
+}
+
+// Urgent reports whether a packet is predicted to time out within the
+// warning threshold but hasn't yet, so clearing it first can still save a
+// timeout refund
+func (p *Predictor) Urgent(chainID, channelID string, sequence uint64) bool {
+	prediction, ok := p.Lookup(chainID, channelID, sequence)
+	return ok && (prediction.Urgency == UrgencyWarning || prediction.Urgency == UrgencyCritical)
+}
+
+// Heads returns the latest block and block time of every destination chain
+func (p *Predictor) Heads() []Head {
+	p.mu.RLock()
+	heads := make([]Head, 0, len(p.heads))
+	for _, head := range p.heads {
+		heads = append(heads, head)
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(heads, func(i, j int) bool { return heads[i].ChainID < heads[j].ChainID })
+	return heads
+}
+
+// Alerts returns an alert for every packet currently warning, critical or
+// expired
+func (p *Predictor) Alerts() []Alert {
+	var alerts []Alert
+	for _, prediction := range p.Predictions("") {
+		if prediction.Urgency != UrgencyOK {
+			alerts = append(alerts, newAlert(prediction))
+		}
+	}
+	return alerts
+}
+
+// predict predicts packets against their destination chain's head. force
+// queries every destination chain again, as a check does.
+func (p *Predictor) predict(ctx context.Context, packets []chainpulse.ExpiringPacket, force bool) []Prediction {
+	now := p.now()
+	destinations := make(map[string]string)
+	heads := make(map[string]*Head)
+	for _, packet := range packets {
+		key := packetKey(packet.ChainID, packet.SrcChannel, packet.Sequence)
+		if _, ok := destinations[key]; ok {
+			continue
+		}
+		destination := ""
+		if p.counterparties != nil {
+			destination = p.counterparties.CounterpartyChain(ctx, packet.ChainID, packet.SrcChannel)
+		}
+		destinations[key] = destination
+		if destination != "" {
+			if _, ok := heads[destination]; !ok {
+				heads[destination] = p.head(ctx, destination, force)
+			}
+		}
+	}
+
+	predictions := make([]Prediction, 0, len(packets))
+	seen := make(map[string]bool, len(packets))
+	for _, packet := range packets {
+		key := packetKey(packet.ChainID, packet.SrcChannel, packet.Sequence)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		destination := destinations[key]
+		predictions = append(predictions, p.predictPacket(packet, destination, heads[destination], now))
+	}
+	sortPredictions(predictions)
+	return predictions
+}
+
+// head returns a chain's latest block and average block time, from the
+// cache unless it's stale or force is set. It returns nil when the chain
+// can't be queried.
+func (p *Predictor) head(ctx context.Context, chainID string, force bool) *Head {
+	now := p.now()
+	p.mu.RLock()
+	cached, ok := p.heads[chainID]
+	p.mu.RUnlock()
+	if ok && !force && cached.Error == "" && now.Sub(cached.CheckedAt) < p.interval/2 {
+		return &cached
+	}
+
+	head := Head{ChainID: chainID, CheckedAt: now}
+	height, at, err := p.querier.Head(ctx, chainID)
+	if err != nil {
+		head.Error = err.Error()
+		// The last block time is still the best estimate of the chain's
+		p.mu.Lock()
+		if ok {
+			head.BlockTimeSeconds = cached.BlockTimeSeconds
+		}
+		p.heads[chainID] = head
+		p.mu.Unlock()
+		p.logger.Debug("Failed to query chain head", zap.String("chain_id", chainID), zap.Error(err))
+		return nil
+	}
+	head.Height, head.Time = height, at
+
+	window := p.blockWindow
+	if window >= height {
+		window = height - 1
+	}
+	if ok {
+		head.BlockTimeSeconds = cached.BlockTimeSeconds
+	}
+	if window > 0 {
+		if earlier, err := p.querier.BlockTime(ctx, chainID, height-window); err == nil && at.After(earlier) {
+			head.BlockTimeSeconds = at.Sub(earlier).Seconds() / float64(window)
+		} else if err != nil {
+			p.logger.Debug("Failed to query block time",
+				zap.String("chain_id", chainID),
+				zap.Int64("height", height-window),
+				zap.Error(err),
+			)
+		}
+	}
+
+	p.mu.Lock()
+	p.heads[chainID] = head
+	p.mu.Unlock()
+	p.metrics.blockTime.WithLabelValues(chainID).Set(head.BlockTimeSeconds)
+	return &head
+}
+
+// predictPacket predicts one packet. A packet with both timeouts times out
+// at whichever is reached first.
+func (p *Predictor) predictPacket(packet chainpulse.ExpiringPacket, destination string, head *Head, now time.Time) Prediction {
+	prediction := Prediction{
+		ChainID:            packet.ChainID,
+		SrcChannel:         packet.SrcChannel,
+		DstChannel:         packet.DstChannel,
+		Sequence:           packet.Sequence,
+		DestinationChainID: destination,
+		Sender:             packet.Sender,
+		Receiver:           packet.Receiver,
+		Amount:             packet.Amount,
+		Denom:              packet.Denom,
+		TimeoutHeight:      timeoutHeight(packet, destination),
+		TimeoutTimestamp:   timeoutTimestamp(packet),
+		ChainpulseSeconds:  packet.SecondsUntilTimeout,
+		PredictedAt:        now,
+	}
+
+	type candidate struct {
+		kind string
+		at   time.Time
+	}
+	var candidates []candidate
+
+	if head != nil {
+		prediction.DestinationHeight = head.Height
+		prediction.BlockTimeSeconds = head.BlockTimeSeconds
+		if h := prediction.TimeoutHeight; h != nil {
+			revision := revisionNumber(destination)
+			switch {
+			case h.RevisionNumber < revision:
+				// The chain has upgraded past the timeout's revision
+				candidates = append(candidates, candidate{TimeoutHeight, head.Time})
+			case h.RevisionNumber == revision:
+				// The packet can still be received in the blocks before the
+				// timeout height; it has timed out once the last of them is
+				// committed
+				remaining := int64(h.RevisionHeight) - head.Height - 1
+				prediction.BlocksRemaining = &remaining
+				if remaining <= 0 {
+					candidates = append(candidates, candidate{TimeoutHeight, head.Time})
+				} else if head.BlockTimeSeconds > 0 {
+					at := head.Time.Add(time.Duration(float64(remaining) * head.BlockTimeSeconds * float64(time.Second)))
+					candidates = append(candidates, candidate{TimeoutHeight, at})
+				}
+			}
+		}
+	}
+	if prediction.TimeoutTimestamp != nil && (head != nil || prediction.TimeoutHeight == nil) {
+		// A timestamp timeout doesn't need the chain's height, only its
+		// clock, which BFT time keeps close to ours
+		candidates = append(candidates, candidate{TimeoutTimestamp, *prediction.TimeoutTimestamp})
+	}
+
+	if len(candidates) == 0 {
+		at := now.Add(time.Duration(packet.SecondsUntilTimeout) * time.Second)
+		prediction.Basis = BasisChainpulse
+		prediction.TimeoutType = packet.TimeoutType
+		prediction.TimeoutAt = &at
+		return p.age(prediction, now)
+	}
+
+	first := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.at.Before(first.at) {
+			first = c
+		}
+	}
+	prediction.Basis = BasisChain
+	prediction.TimeoutType = first.kind
+	prediction.TimeoutAt = &first.at
+	return p.age(prediction, now)
+}
+
+// age recomputes the time left and urgency of a prediction at now
+func (p *Predictor) age(prediction Prediction, now time.Time) Prediction {
+	if prediction.TimeoutAt == nil {
+		return prediction
+	}
+	remaining := prediction.TimeoutAt.Sub(now)
+	prediction.SecondsUntilTimeout = math.Round(remaining.Seconds()*10) / 10
+	prediction.Urgency = p.evaluate(remaining)
+	return prediction
+}
+
+// evaluate returns the urgency of a packet with the given time left
+func (p *Predictor) evaluate(remaining time.Duration) string {
+	switch {
+	case remaining <= 0:
+		return UrgencyExpired
+	case remaining < p.thresholds.Critical:
+		return UrgencyCritical
+	case remaining < p.thresholds.Warning:
+		return UrgencyWarning
+	}
+	return UrgencyOK
+}
+
+// store replaces the predictions, raises alerts for packets that became
+// more urgent and updates the metrics
+func (p *Predictor) store(predictions []Prediction) {
+	p.mu.Lock()
+	previous := p.predictions
+	p.predictions = make(map[string]Prediction, len(predictions))
+	for _, prediction := range predictions {
+		p.predictions[prediction.ID()] = prediction
+	}
+	p.mu.Unlock()
+
+	p.metrics.observe(predictions)
+	for _, prediction := range predictions {
+		before := UrgencyOK
+		if old, ok := previous[prediction.ID()]; ok {
+			before = old.Urgency
+		}
+		if urgencyRank[prediction.Urgency] > urgencyRank[before] {
+			p.alert(prediction)
+		}
+	}
+}
+
+// alert logs and notifies that a packet became more urgent
+func (p *Predictor) alert(prediction Prediction) {
+	alert := newAlert(prediction)
+	p.metrics.alerts.WithLabelValues(prediction.ChainID, alert.Level).Inc()
+
+	fields := []zap.Field{
+		zap.String("chain_id", prediction.ChainID),
+		zap.String("channel", prediction.SrcChannel),
+		zap.Uint64("sequence", prediction.Sequence),
+		zap.String("destination_chain_id", prediction.DestinationChainID),
+		zap.String("sender", prediction.Sender),
+		zap.String("message", alert.Message),
+	}
+	switch alert.Level {
+	case UrgencyCritical:
+		p.logger.Error("OPERATOR ALERT: packet about to time out", fields...)
+	case UrgencyExpired:
+		p.logger.Warn("Packet timed out before it was relayed", fields...)
+	default:
+		p.logger.Warn("Packet approaching timeout", fields...)
+	}
+
+	if p.notifier != nil {
+		p.notifier.Notify(alert)
+	}
+}
+
+func newAlert(prediction Prediction) Alert {
+	message := fmt.Sprintf("times out in %s by %s", time.Duration(prediction.SecondsUntilTimeout*float64(time.Second)).Round(time.Second), prediction.TimeoutType)
+	if prediction.Urgency == UrgencyExpired {
+		message = "timed out by " + prediction.TimeoutType + ", the sender needs a timeout refund"
+	}
+	return Alert{
+		ChainID:             prediction.ChainID,
+		SrcChannel:          prediction.SrcChannel,
+		Sequence:            prediction.Sequence,
+		DestinationChainID:  prediction.DestinationChainID,
+		Sender:              prediction.Sender,
+		Level:               prediction.Urgency,
+		Message:             message,
+		TimeoutAt:           prediction.TimeoutAt,
+		SecondsUntilTimeout: prediction.SecondsUntilTimeout,
+		Timestamp:           prediction.PredictedAt,
+	}
+}
+
+// timeoutHeight reads a height timeout, given as revision-height or as a
+// height on the destination chain's current revision
+func timeoutHeight(packet chainpulse.ExpiringPacket, destination string) *Height {
+	if packet.TimeoutType != TimeoutHeight {
+		return nil
+	}
+	value := strings.TrimSpace(packet.TimeoutValue)
+	revision := revisionNumber(destination)
+	if i := strings.Index(value, "-"); i >= 0 {
+		number, err := strconv.ParseUint(value[:i], 10, 64)
+		if err != nil {
+			return nil
+		}
+		revision, value = number, value[i+1:]
+	}
+	height, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || height == 0 {
+		return nil
+	}
+	return &Height{RevisionNumber: revision, RevisionHeight: height}
+}
+
+// timeoutTimestamp reads a timestamp timeout from the packet, or from the
+// timeout value of a timestamp timeout, in Unix nanoseconds or RFC 3339
+func timeoutTimestamp(packet chainpulse.ExpiringPacket) *time.Time {
+	if packet.TimeoutTimestamp != nil && *packet.TimeoutTimestamp > 0 {
+		at := time.Unix(0, *packet.TimeoutTimestamp).UTC()
+		return &at
+	}
+	if packet.TimeoutType != TimeoutTimestamp {
+		return nil
+	}
+	value := strings.TrimSpace(packet.TimeoutValue)
+	if nanos, err := strconv.ParseInt(value, 10, 64); err == nil && nanos > 0 {
+		at := time.Unix(0, nanos).UTC()
+		return &at
+	}
+	if at, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return &at
+	}
+	return nil
+}
+
+// revisionNumber is the revision of a chain ID in the {name}-{revision}
+// format, 0 otherwise
+func revisionNumber(chainID string) uint64 {
+	i := strings.LastIndex(chainID, "-")
+	if i < 0 || i == len(chainID)-1 || chainID[i+1] == '0' {
+		return 0
+	}
+	revision, err := strconv.ParseUint(chainID[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+func packetKey(chainID, channelID string, sequence uint64) string {
+	return fmt.Sprintf("%s-%s-%d", chainID, channelID, sequence)
+}
+
+// sortPredictions orders predictions soonest timeout first
+func sortPredictions(predictions []Prediction) {
+	sort.SliceStable(predictions, func(i, j int) bool {
+		if predictions[i].SecondsUntilTimeout != predictions[j].SecondsUntilTimeout {
+			return predictions[i].SecondsUntilTimeout < predictions[j].SecondsUntilTimeout
+		}
+		return predictions[i].ID() < predictions[j].ID()
+	})
+}
@@ -0,0 +1,159 @@
+package timeouts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+type fakeSource []chainpulse.ExpiringPacket
+
+func (f fakeSource) AllExpiringPackets(ctx context.Context, within time.Duration) ([]chainpulse.ExpiringPacket, error) {
+	return f, nil
+}
+
+// fakeChain produces a block every blockTime up to height at time head
+type fakeChain struct {
+	height    int64
+	head      time.Time
+	blockTime time.Duration
+}
+
+type fakeQuerier map[string]fakeChain
+
+func (f fakeQuerier) Head(ctx context.Context, chainID string) (int64, time.Time, error) {
+	chain, ok := f[chainID]
+	if !ok {
+		return 0, time.Time{}, errors.New("unreachable")
+	}
+	return chain.height, chain.head, nil
+}
+
+func (f fakeQuerier) BlockTime(ctx context.Context, chainID string, height int64) (time.Time, error) {
+	chain := f[chainID]
+	return chain.head.Add(-time.Duration(chain.height-height) * chain.blockTime), nil
+}
+
+type fakeCounterparties map[string]string
+
+func (f fakeCounterparties) CounterpartyChain(ctx context.Context, chainID, channelID string) string {
+	return f[chainID+"/"+channelID]
+}
+
+func expiring(channel string, sequence uint64, timeoutType, value string, chainpulseSeconds int64) chainpulse.ExpiringPacket {
+	return chainpulse.ExpiringPacket{
+		Packet:              chainpulse.Packet{ChainID: "cosmoshub-4", SrcChannel: channel, Sequence: sequence, Sender: "cosmos1sender"},
+		SecondsUntilTimeout: chainpulseSeconds,
+		TimeoutType:         timeoutType,
+		TimeoutValue:        value,
+	}
+}
+
+func TestPredictor(t *testing.T) {
+	head := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	now := head.Add(10 * time.Second)
+
+	inTwoHours := now.Add(2 * time.Hour).UnixNano()
+	inHalfHour := now.Add(30 * time.Minute).UnixNano()
+	bothTimeouts := expiring("channel-141", 3, TimeoutHeight, "1-100000", 600)
+	bothTimeouts.TimeoutTimestamp = &inHalfHour
+	timestampOnly := expiring("channel-141", 2, TimeoutTimestamp, "", 1800)
+	timestampOnly.TimeoutTimestamp = &inTwoHours
+
+	source := fakeSource{
+		// 10 blocks left at 6s a block, from a head 10s old
+		expiring("channel-141", 1, TimeoutHeight, "1-1011", 3000),
+		timestampOnly,
+		bothTimeouts,
+		// Set before osmosis-1 upgraded to revision 1
+		expiring("channel-141", 4, TimeoutHeight, "0-5000", 3000),
+		// No revision, so osmosis-1's current one
+		expiring("channel-141", 5, TimeoutHeight, "1201", 3000),
+		// The destination can't be resolved
+		expiring("channel-999", 6, TimeoutHeight, "1-1011", 4000),
+	}
+	querier := fakeQuerier{"osmosis-1": {height: 1000, head: head, blockTime: 6 * time.Second}}
+
+	var alerts []Alert
+	predictor := NewPredictor(source, querier, zap.NewNop())
+	predictor.UseCounterparties(fakeCounterparties{"cosmoshub-4/channel-141": "osmosis-1"})
+	predictor.UseNotifier(NotifierFunc(func(alert Alert) { alerts = append(alerts, alert) }))
+	predictor.now = func() time.Time { return now }
+
+	predictions := predictor.Check(context.Background())
+	require.Len(t, predictions, 6)
+
+	bySequence := make(map[uint64]Prediction)
+	for _, p := range predictions {
+		bySequence[p.Sequence] = p
+	}
+
+	p := bySequence[1]
+	assert.Equal(t, BasisChain, p.Basis)
+	assert.Equal(t, TimeoutHeight, p.TimeoutType)
+	assert.InDelta(t, 6.0, p.BlockTimeSeconds, 0.001)
+	require.NotNil(t, p.BlocksRemaining)
+	assert.Equal(t, int64(10), *p.BlocksRemaining)
+	assert.InDelta(t, 50.0, p.SecondsUntilTimeout, 0.1)
+	assert.Equal(t, UrgencyCritical, p.Urgency)
+	assert.Equal(t, int64(3000), p.ChainpulseSeconds)
+
+	p = bySequence[2]
+	assert.Equal(t, TimeoutTimestamp, p.TimeoutType)
+	assert.InDelta(t, 7200.0, p.SecondsUntilTimeout, 0.1)
+	assert.Equal(t, UrgencyOK, p.Urgency)
+
+	// The timestamp is reached long before the height
+	p = bySequence[3]
+	assert.Equal(t, TimeoutTimestamp, p.TimeoutType)
+	assert.Equal(t, UrgencyWarning, p.Urgency)
+
+	assert.Equal(t, UrgencyExpired, bySequence[4].Urgency)
+
+	p = bySequence[5]
+	require.NotNil(t, p.TimeoutHeight)
+	assert.Equal(t, Height{RevisionNumber: 1, RevisionHeight: 1201}, *p.TimeoutHeight)
+	assert.InDelta(t, 200*6-10.0, p.SecondsUntilTimeout, 0.1)
+	assert.Equal(t, UrgencyWarning, p.Urgency)
+
+	p = bySequence[6]
+	assert.Equal(t, BasisChainpulse, p.Basis)
+	assert.InDelta(t, 4000.0, p.SecondsUntilTimeout, 0.1)
+	assert.Equal(t, UrgencyOK, p.Urgency)
+
+	// Soonest first
+	for i := 1; i < len(predictions); i++ {
+		assert.LessOrEqual(t, predictions[i-1].SecondsUntilTimeout, predictions[i].SecondsUntilTimeout)
+	}
+	assert.Equal(t, uint64(4), predictions[0].Sequence)
+
+	// Alerts are raised once per packet becoming more urgent
+	assert.Len(t, alerts, 4)
+	predictor.Check(context.Background())
+	assert.Len(t, alerts, 4)
+	now = now.Add(time.Minute)
+	predictor.Check(context.Background())
+	require.Len(t, alerts, 5)
+	assert.Equal(t, uint64(1), alerts[4].Sequence)
+	assert.Equal(t, UrgencyExpired, alerts[4].Level)
+
+	assert.True(t, predictor.Urgent("cosmoshub-4", "channel-141", 3))
+	assert.False(t, predictor.Urgent("cosmoshub-4", "channel-141", 1))
+	assert.False(t, predictor.Urgent("cosmoshub-4", "channel-141", 2))
+	assert.False(t, predictor.Urgent("cosmoshub-4", "channel-141", 42))
+}
+
+func TestRevisionNumber(t *testing.T) {
+	assert.Equal(t, uint64(4), revisionNumber("cosmoshub-4"))
+	assert.Equal(t, uint64(1), revisionNumber("dydx-mainnet-1"))
+	assert.Equal(t, uint64(2), revisionNumber("evmos_9001-2"))
+	assert.Equal(t, uint64(0), revisionNumber("celestia"))
+	assert.Equal(t, uint64(0), revisionNumber("chain-01"))
+}
@@ -0,0 +1,91 @@
+package timeouts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/relayooor/chains/config"
+	"github.com/relayooor/chains/endpoints"
+)
+
+// RPCQuerier reads block heights and times over each chain's CometBFT RPC
+type RPCQuerier struct {
+	client *endpoints.Client
+}
+
+// NewRPCQuerier creates a querier using the RPC endpoints of the registry
+func NewRPCQuerier(registry *config.ChainRegistry) *RPCQuerier {
+	return &RPCQuerier{
+		client: endpoints.NewClient(registry, 10*time.Second),
+	}
+}
+
+// UseEndpoints sends queries to the best endpoint of each chain's pool,
+// failing over between them, instead of the registry's RPC endpoint
+func (q *RPCQuerier) UseEndpoints(pools *endpoints.Pools) {
+	q.client.UsePools(pools)
+}
+
+// Head implements Querier
+func (q *RPCQuerier) Head(ctx context.Context, chainID string) (int64, time.Time, error) {
+	var body struct {
+		Result struct {
+			SyncInfo struct {
+				LatestBlockHeight string    `json:"latest_block_height"`
+				LatestBlockTime   time.Time `json:"latest_block_time"`
+			} `json:"sync_info"`
+		} `json:"result"`
+	}
+	if err := q.get(ctx, chainID, "/status", &body); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	sync := body.Result.SyncInfo
+	height, err := strconv.ParseInt(sync.LatestBlockHeight, 10, 64)
+	if err != nil || height <= 0 || sync.LatestBlockTime.IsZero() {
+		return 0, time.Time{}, fmt.Errorf("no latest block in /status of %s", chainID)
+	}
+	return height, sync.LatestBlockTime, nil
+}
+
+// BlockTime implements Querier. /blockchain returns only block metadata, so
+// it's much lighter than /block.
+func (q *RPCQuerier) BlockTime(ctx context.Context, chainID string, height int64) (time.Time, error) {
+	var body struct {
+		Result struct {
+			BlockMetas []struct {
+				Header struct {
+					Time time.Time `json:"time"`
+				} `json:"header"`
+			} `json:"block_metas"`
+		} `json:"result"`
+	}
+	path := fmt.Sprintf("/blockchain?minHeight=%d&maxHeight=%d", height, height)
+	if err := q.get(ctx, chainID, path, &body); err != nil {
+		return time.Time{}, err
+	}
+	if len(body.Result.BlockMetas) == 0 || body.Result.BlockMetas[0].Header.Time.IsZero() {
+		return time.Time{}, fmt.Errorf("no block %d on %s", height, chainID)
+	}
+	return body.Result.BlockMetas[0].Header.Time, nil
+}
+
+func (q *RPCQuerier) get(ctx context.Context, chainID, path string, out interface{}) error {
+	resp, err := q.client.Get(ctx, chainID, endpoints.RPC, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query %s on %s returned %d", path, chainID, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
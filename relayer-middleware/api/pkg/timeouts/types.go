@@ -0,0 +1,138 @@
+package timeouts
+
+import (
+	"context"
+	"time"
+
+	"relayooor/api/pkg/chainpulse"
+)
+
+// Urgency levels of a pending packet, from least to most urgent
+const (
+	UrgencyOK       = "ok"
+	UrgencyWarning  = "warning"
+	UrgencyCritical = "critical"
+	UrgencyExpired  = "expired"
+)
+
+// Timeout types
+const (
+	TimeoutHeight    = "height"
+	TimeoutTimestamp = "timestamp"
+)
+
+// Where a prediction's time to timeout came from
+const (
+	// BasisChain is computed from the destination chain's latest block
+	BasisChain = "chain"
+	// BasisChainpulse is Chainpulse's estimate, used when the destination
+	// chain couldn't be queried
+	BasisChainpulse = "chainpulse"
+)
+
+// Height is an IBC height
+type Height struct {
+	RevisionNumber uint64 `json:"revision_number"`
+	RevisionHeight uint64 `json:"revision_height"`
+}
+
+// Head is the latest block of a chain and its recent average block time
+type Head struct {
+	ChainID string    `json:"chain_id"`
+	Height  int64     `json:"height"`
+	Time    time.Time `json:"time"`
+	// BlockTimeSeconds is averaged over the last blocks, 0 until known
+	BlockTimeSeconds float64   `json:"block_time_seconds"`
+	CheckedAt        time.Time `json:"checked_at"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// Prediction is when a pending packet times out on its destination chain
+type Prediction struct {
+	ChainID            string `json:"chain_id"`
+	SrcChannel         string `json:"src_channel"`
+	DstChannel         string `json:"dst_channel"`
+	Sequence           uint64 `json:"sequence"`
+	DestinationChainID string `json:"destination_chain_id,omitempty"`
+	Sender             string `json:"sender"`
+	Receiver           string `json:"receiver"`
+	Amount             string `json:"amount"`
+	Denom              string `json:"denom"`
+
+	TimeoutHeight    *Height    `json:"timeout_height,omitempty"`
+	TimeoutTimestamp *time.Time `json:"timeout_timestamp,omitempty"`
+	// TimeoutType is the timeout that's reached first
+	TimeoutType string `json:"timeout_type"`
+
+	DestinationHeight int64   `json:"destination_height,omitempty"`
+	BlockTimeSeconds  float64 `json:"block_time_seconds,omitempty"`
+	// BlocksRemaining is how many blocks the destination chain produces
+	// before the timeout height, for height timeouts
+	BlocksRemaining *int64 `json:"blocks_remaining,omitempty"`
+
+	TimeoutAt           *time.Time `json:"timeout_at,omitempty"`
+	SecondsUntilTimeout float64    `json:"seconds_until_timeout"`
+	// ChainpulseSeconds is Chainpulse's own estimate, for comparison
+	ChainpulseSeconds int64     `json:"chainpulse_seconds_until_timeout"`
+	Basis             string    `json:"basis"`
+	Urgency           string    `json:"urgency"`
+	PredictedAt       time.Time `json:"predicted_at"`
+}
+
+// ID is the packet's chain, channel and sequence, as chainpulse.Packet.ID
+func (p Prediction) ID() string {
+	return packetKey(p.ChainID, p.SrcChannel, p.Sequence)
+}
+
+// Alert is raised when a packet becomes more urgent
+type Alert struct {
+	ChainID             string     `json:"chain_id"`
+	SrcChannel          string     `json:"src_channel"`
+	Sequence            uint64     `json:"sequence"`
+	DestinationChainID  string     `json:"destination_chain_id,omitempty"`
+	Sender              string     `json:"sender"`
+	Level               string     `json:"level"`
+	Message             string     `json:"message"`
+	TimeoutAt           *time.Time `json:"timeout_at,omitempty"`
+	SecondsUntilTimeout float64    `json:"seconds_until_timeout"`
+	Timestamp           time.Time  `json:"timestamp"`
+}
+
+// Thresholds decide how urgent a packet is from its time to timeout
+type Thresholds struct {
+	Warning  time.Duration
+	Critical time.Duration
+}
+
+// Source lists the packets Chainpulse expects to time out, satisfied by
+// *chainpulse.Client
+type Source interface {
+	AllExpiringPackets(ctx context.Context, within time.Duration) ([]chainpulse.ExpiringPacket, error)
+}
+
+// Querier reads block heights and times from a chain
+type Querier interface {
+	// Head returns the chain's latest block height and time
+	Head(ctx context.Context, chainID string) (int64, time.Time, error)
+	// BlockTime returns the time of the block at height
+	BlockTime(ctx context.Context, chainID string, height int64) (time.Time, error)
+}
+
+// Counterparties finds the chain a channel leads to, satisfied by
+// *channels.Resolver
+type Counterparties interface {
+	CounterpartyChain(ctx context.Context, chainID, channelID string) string
+}
+
+// Notifier receives timeout alerts
+type Notifier interface {
+	Notify(alert Alert)
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(alert Alert)
+
+// Notify calls f(alert)
+func (f NotifierFunc) Notify(alert Alert) {
+	f(alert)
+}
@@ -30,7 +30,7 @@ type PaginationResponse struct {
 // CursorRequest represents cursor-based pagination parameters
 type CursorRequest struct {
 	Cursor string `json:"cursor" form:"cursor"`
-	Limit  int    `json:"limit" form:"limit" binding:"min=1,max=100"`
+	Limit  int    `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
 }
 
 // CursorResponse contains cursor pagination metadata
@@ -64,6 +64,7 @@ run_tests() {
 # 1. API Backend Tests
 echo -e "${YELLOW}Phase 1: API Backend Tests${NC}"
 echo "==============================="
+run_tests "chains" "Shared Chain Registry"
 run_tests "api" "Simple API Backend"
 
 # 2. Relayer Middleware Tests
@@ -58,9 +58,11 @@ export const analyticsService = {
     return response.data
   },
 
-  // Get relayer performance analytics
-  async getRelayerPerformance(): Promise<any> {
-    const response = await api.get('/metrics/relayer-performance')
+  // Get relayer performance and competition analytics
+  async getRelayerPerformance(window?: string): Promise<any> {
+    const response = await api.get('/metrics/relayer-performance', {
+      params: window ? { window } : undefined
+    })
     return response.data
   },
 